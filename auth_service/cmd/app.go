@@ -0,0 +1,423 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"auth_service/internal/auth"
+	twoFactorAuth "auth_service/internal/auth/2fa"
+	"auth_service/internal/auth/apikeys"
+	"auth_service/internal/auth/appcache"
+	"auth_service/internal/auth/challenge"
+	"auth_service/internal/auth/geo"
+	"auth_service/internal/auth/identity"
+	"auth_service/internal/auth/lockout"
+	"auth_service/internal/auth/oauth"
+	"auth_service/internal/auth/oauth/providers"
+	"auth_service/internal/auth/oidc"
+	orgsService "auth_service/internal/auth/orgs"
+	"auth_service/internal/auth/rbac"
+	"auth_service/internal/auth/signingkeys"
+	"auth_service/internal/auth/totp"
+	"auth_service/internal/auth/trusteddevice"
+	"auth_service/internal/auth/verifycode"
+	"auth_service/internal/config"
+	httpRateLimit "auth_service/internal/http_server/middleware/rate_limiter"
+	realIP "auth_service/internal/http_server/middleware/real_ip"
+	"auth_service/internal/ipfilter"
+	"auth_service/internal/lib/background"
+	"auth_service/internal/lib/cookie"
+	"auth_service/internal/lib/geoip"
+	"auth_service/internal/lib/mailer"
+	"auth_service/internal/lib/passhash"
+	"auth_service/internal/lib/passwordpolicy"
+	customValidator "auth_service/internal/lib/validation/custom_validator"
+	"auth_service/internal/maintenance"
+	"auth_service/internal/metrics"
+	"auth_service/internal/rabbitmq"
+	rateLimit "auth_service/internal/ratelimit"
+	"auth_service/internal/retention"
+	"auth_service/internal/scheduler"
+	"auth_service/internal/storage"
+	"auth_service/internal/storage/backend"
+	"auth_service/internal/storage/redis"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// app — собранные компоненты сервиса. main запускает их и останавливает,
+// интеграционные тесты поднимают тот же роутер поверх контейнеров.
+type app struct {
+	router *chi.Mux
+
+	store     storage.Repository
+	redis     *redis.RedisRepo
+	msgBroker messagePublisher
+
+	jobs       *scheduler.Scheduler
+	auth       *auth.Auth
+	rateLimits *httpRateLimit.RateLimit
+	background *background.Tasks
+
+	// signingKeyGrace — до какого access TTL его можно поднять без рестарта
+	signingKeyGrace time.Duration
+
+	geoReader *geoip.Reader
+}
+
+// newApp подключается к хранилищам и брокеру и собирает сервисы и роутер.
+// ctx ограничивает только подключения при старте. Фоновые задачи
+// регистрируются в app.jobs, но не запускаются.
+func newApp(ctx context.Context, cfg *config.Config, log *slog.Logger) (*app, error) {
+	googleProvider := providers.NewGoogleProvider(
+		cfg.OAuth.GoogleClientID,
+		cfg.OAuth.GoogleClientSecret,
+		cfg.OAuth.GoogleRedirectURL,
+	)
+
+	githubProvider := providers.NewGitHubProvider(
+		cfg.OAuth.GitHubClientID,
+		cfg.OAuth.GitHubClientSecret,
+		cfg.OAuth.GitHubRedirectURL,
+	)
+
+	oauthProviders := map[string]oauth.OAuthProvider{
+		"google": googleProvider,
+		"github": githubProvider,
+	}
+
+	metrics := metrics.New()
+
+	store, err := backend.Open(ctx, cfg, log, metrics)
+	if err != nil {
+		return nil, fmt.Errorf("connect %s: %w", cfg.Storage.Driver, err)
+	}
+
+	logStorageConnected(log, cfg)
+
+	redis, err := redis.New(ctx, cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.Db)
+	if err != nil {
+		return nil, fmt.Errorf("connect redis: %w", err)
+	}
+
+	log.Info("redis connected successfully",
+		slog.String("host", cfg.Redis.Addr),
+		slog.Int("database", cfg.Redis.Db),
+	)
+
+	var msgBroker messagePublisher
+	if cfg.Mail.Sandbox {
+		msgBroker = mailer.NewSandboxPublisher(log)
+
+		log.Warn("mail sandbox enabled: emails are logged instead of published")
+	} else {
+		rabbitMQClient, err := rabbitmq.New(cfg.RabbitMQ, log, metrics)
+		if err != nil {
+			return nil, fmt.Errorf("connect rabbitmq: %w", err)
+		}
+		msgBroker = rabbitMQClient
+
+		log.Info("rabbitmq connected successfully")
+	}
+
+	limiter, err := rateLimit.New(ctx, redis)
+	if err != nil {
+		return nil, fmt.Errorf("init rate limiter: %w", err)
+	}
+
+	rlMiddlewares := httpRateLimit.New(limiter, log, rateLimitOverrides(cfg.RateLimits))
+
+	twoFactorAuthService := twoFactorAuth.New(
+		store,
+		store,
+		redis,
+		twoFANotifiers(log, cfg, msgBroker),
+		log,
+		cfg,
+	)
+
+	totpService, err := totp.New(
+		log,
+		store,
+		redis,
+		cfg.TwoFactorAuth.TOTPEncryptionKey,
+		cfg.TwoFactorAuth.TOTPIssuer,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("init totp: %w", err)
+	}
+	if cfg.TwoFactorAuth.TOTPEncryptionKey == "" {
+		log.Warn("totp disabled: TOTP_ENCRYPTION_KEY is not set")
+	}
+
+	maintenanceMode := maintenance.New(
+		log,
+		redis,
+		cfg.Maintenance.Enabled,
+		cfg.Maintenance.Reason,
+		cfg.Maintenance.CacheTTL,
+	)
+	if cfg.Maintenance.Enabled {
+		log.Warn("maintenance mode enabled by config", slog.String("reason", cfg.Maintenance.Reason))
+	}
+
+	loginLockout := lockout.New(
+		log,
+		redis,
+		msgBroker,
+		cfg.HTTPServer.PublicBaseURL,
+		cfg.Lockout,
+	)
+
+	loginChallenge := challenge.New(log, redis, cfg.Challenge)
+
+	ipRuleFilter := ipfilter.New(log, store, redis, cfg.IPFilter)
+
+	var geoReader *geoip.Reader
+	var geoLocator geo.Locator
+	if cfg.Geo.DatabasePath != "" {
+		geoReader, err = geoip.Open(cfg.Geo.DatabasePath)
+		if err != nil {
+			return nil, fmt.Errorf("open geoip database: %w", err)
+		}
+
+		geoLocator = geoReader
+	} else {
+		log.Warn("impossible travel check disabled: geo.database_path is not set")
+	}
+
+	geoGuard := geo.New(log, geoLocator, store, cfg.Geo)
+
+	deviceTrust := trusteddevice.New(store, cfg.TwoFactorAuth.TrustedDeviceTTL)
+
+	passwords := passwordpolicy.New(log, cfg.PasswordPolicy)
+	passwordHasher := passhash.New(cfg.PasswordHashing)
+
+	// выведенный ключ должен принимать токены до конца их TTL; grace
+	// задаётся при старте, поэтому access TTL на лету выше него не поднять
+	signingKeyGrace := max(cfg.SigningKeys.GracePeriod, cfg.Tokens.AccessTokenTTL+cfg.Tokens.Leeway)
+	signingKeyManager := signingkeys.New(
+		log,
+		store,
+		cfg.SigningKeys.RotationInterval,
+		signingKeyGrace,
+		cfg.Tokens.Leeway,
+	)
+
+	apps := appcache.New(store, cfg.Apps)
+
+	authService := auth.New(log, auth.Options{
+		UserSaver:      store,
+		UserProvider:   store,
+		AppProvider:    apps,
+		TwoFA:          twoFactorAuthService,
+		TOTP:           totpService,
+		UoW:            store,
+		AccessTokens:   redis,
+		Lockout:        loginLockout,
+		SigningKeys:    signingKeyManager,
+		EmailChanges:   redis,
+		Mail:           msgBroker,
+		Geo:            geoGuard,
+		TrustedDevices: deviceTrust,
+		Passwords:      passwords,
+		Hasher:         passwordHasher,
+		Metrics:        metrics,
+
+		TokenTTLs: auth.TokenTTLs{
+			Access:      cfg.Tokens.AccessTokenTTL,
+			Refresh:     cfg.Tokens.RefreshTokenTTL,
+			Reset:       cfg.Tokens.ResetTokenTTL,
+			EmailChange: cfg.Tokens.EmailChangeTokenTTL,
+		},
+		RefreshMaxLifetime: cfg.Tokens.RefreshMaxLifetime,
+		RefreshSliding:     cfg.Tokens.RefreshSliding,
+		MaxSessions:        cfg.Sessions.MaxPerApp,
+		RejectOverLimit:    cfg.Sessions.OnLimit == config.SessionLimitReject,
+		DeletionGrace:      cfg.Retention.DeletedAccounts,
+		Leeway:             cfg.Tokens.Leeway,
+
+		EnforceMembership:  cfg.Apps.EnforceMembership,
+		NewDeviceChallenge: cfg.TwoFactorAuth.NewDeviceChallenge,
+	})
+
+	identityService := identity.New(log, store, store)
+	rbacService := rbac.New(log, store, store)
+	apiKeys := apikeys.New(log, store, apps)
+
+	verifyCodes := verifycode.New(
+		redis,
+		store,
+		cfg.Tokens.VerificationTokenSecret,
+		cfg.Tokens.VerificationTokenTTL,
+		cfg.Tokens.VerificationCodeMaxAttempts,
+	)
+	organizations := orgsService.New(log, store, store, passwords, passwordHasher, cfg.Tokens.OrgInvitationTTL)
+
+	oauthService := oauth.New(
+		authService,
+		log,
+		identityService,
+		redis,
+		oauthProviders,
+		cfg.OAuth.StateTTL,
+	)
+
+	oidcProvider := oidc.New(
+		authService,
+		log,
+		redis,
+		cfg.OIDC.Issuer,
+		cfg.OIDC.CodeTTL,
+		cfg.OIDC.IDTokenTTL,
+	)
+
+	// * фоновые задачи — только на реплике-лидере
+	jobs := scheduler.New(log, metrics, scheduler.NewElector(
+		log,
+		store,
+		cfg.Scheduler.LeaderLockKey,
+		cfg.Scheduler.ElectionInterval,
+	))
+
+	jobs.Add(scheduler.Job{
+		Name:     "magic_link_cleanup",
+		Interval: cfg.Scheduler.MagicLinkCleanupInterval,
+		Timeout:  cfg.StorageCleanupTimeout(),
+		Run: func(ctx context.Context) error {
+			deleted, err := twoFactorAuthService.CleanupExpired(ctx)
+			metrics.RetentionPurgedRowsTotal.WithLabelValues("magic_links").Add(float64(deleted))
+			return err
+		},
+	})
+
+	jobs.Add(scheduler.Job{
+		Name:     "signing_key_rotation",
+		Interval: cfg.SigningKeys.CheckInterval,
+		Timeout:  cfg.StorageCleanupTimeout(),
+		Run:      signingKeyManager.RotateDue,
+	})
+
+	purger := retention.New(log, metrics, cfg.Retention.BatchSize)
+	purger.Add(retention.Policy{
+		Table:  "audit_events",
+		Period: cfg.Retention.AuditEvents,
+		Purge:  store.PurgeAuditEvents,
+	})
+	purger.Add(retention.Policy{
+		Table:  "magic_links",
+		Period: cfg.Retention.UsedMagicLinks,
+		Purge:  store.PurgeUsedMagicLinks,
+	})
+	purger.Add(retention.Policy{
+		Table:  "refresh_tokens",
+		Period: cfg.Retention.ExpiredRefreshTokens,
+		Purge:  store.PurgeExpiredRefreshTokens,
+	})
+	purger.Add(retention.Policy{
+		Table:  "trusted_devices",
+		Period: cfg.Retention.ExpiredTrustedDevices,
+		Purge:  store.PurgeExpiredTrustedDevices,
+	})
+	purger.Add(retention.Policy{
+		Table:  "ip_rules",
+		Period: cfg.Retention.ExpiredIPRules,
+		Purge:  store.PurgeExpiredIPRules,
+	})
+	// безвозвратное удаление аккаунтов по истечении grace period (право на удаление данных)
+	purger.Add(retention.Policy{
+		Table:  "users",
+		Period: cfg.Retention.DeletedAccounts,
+		Purge:  store.PurgeDeletedAccounts,
+	})
+
+	jobs.Add(scheduler.Job{
+		Name:     "retention",
+		Interval: cfg.Retention.Interval,
+		Timeout:  cfg.Retention.JobTimeout,
+		Run:      purger.Run,
+	})
+
+	requestValidator := customValidator.New()
+
+	refreshCookies := cookie.New(cfg.RefreshCookie, redis)
+
+	// * работа обработчиков после ответа — дожидается её shutdown
+	backgroundTasks := background.New()
+
+	trustedProxies, err := realIP.ParseTrusted(cfg.HTTPServer.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http_server.trusted_proxies: %w", err)
+	}
+
+	router := setupRouter(
+		log,
+		cfg,
+		requestValidator,
+		metrics,
+		rlMiddlewares,
+		authService,
+		oauthService,
+		identityService,
+		deviceTrust,
+		oidcProvider,
+		rbacService,
+		apiKeys,
+		verifyCodes,
+		organizations,
+		store,
+		store,
+		signingKeyManager,
+		redis,
+		maintenanceMode,
+		msgBroker,
+		backgroundTasks,
+		refreshCookies,
+		loginChallenge,
+		ipRuleFilter,
+		allowedRedirectHostSet(cfg.OAuth.AllowedRedirectHosts),
+		trustedProxies,
+	)
+
+	return &app{
+		router:          router,
+		store:           store,
+		redis:           redis,
+		msgBroker:       msgBroker,
+		jobs:            jobs,
+		auth:            authService,
+		rateLimits:      rlMiddlewares,
+		background:      backgroundTasks,
+		signingKeyGrace: signingKeyGrace,
+		geoReader:       geoReader,
+	}, nil
+}
+
+// logStorageConnected пишет в лог, к какому хранилищу подключился сервис.
+func logStorageConnected(log *slog.Logger, cfg *config.Config) {
+	switch cfg.Storage.Driver {
+	case config.StorageDriverMySQL:
+		log.Info("mysql connected successfully",
+			slog.String("host", cfg.MySQL.Host),
+			slog.Int("port", cfg.MySQL.Port),
+			slog.String("database", cfg.MySQL.DBName),
+		)
+	case config.StorageDriverSQLite:
+		log.Info("sqlite opened successfully", slog.String("path", cfg.SQLite.Path))
+	default:
+		log.Info("postgresql connected successfully",
+			slog.String("host", cfg.Postgres.Host),
+			slog.Int("port", cfg.Postgres.Port),
+			slog.String("database", cfg.Postgres.DBName),
+		)
+	}
+}
+
+// closeGeo закрывает базу geoip, если она открыта.
+func (a *app) closeGeo() {
+	if a.geoReader != nil {
+		_ = a.geoReader.Close()
+	}
+}
@@ -0,0 +1,452 @@
+// authctl — административная утилита для операций, которые неудобно
+// делать через HTTP: заведение приложений, ротация их секретов, работа
+// с аккаунтами и миграции. Ходит напрямую в хранилище и Redis из того же
+// конфига, что и сервис; действия над пользователями пишутся в аудит
+// с actor "cli:<$USER>".
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"os/user"
+	"text/tabwriter"
+	"time"
+
+	"auth_service/internal/auth"
+	"auth_service/internal/auth/trusteddevice"
+	"auth_service/internal/config"
+	"auth_service/internal/metrics"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+	"auth_service/internal/storage/backend"
+	"auth_service/internal/storage/redis"
+)
+
+const usage = `usage: authctl [--config path] <command> [flags]
+
+commands:
+  migrate                                  apply pending database migrations
+  apps create --name NAME [--public]       register an app and print its secret
+  apps rotate-secret --id ID               replace the app secret
+  users list [--after ID] [--limit N]      list users ordered by id
+  users disable --id ID [--reason TEXT]    disable the account and revoke sessions
+  users enable --id ID [--reason TEXT]     reactivate a disabled or suspended account
+  users verify --id ID                     mark the email as verified
+  sessions revoke --user ID                revoke all sessions of the user
+`
+
+var errUsage = errors.New("invalid usage")
+
+// command — подкоманда; args — её флаги без имени команды.
+type command func(ctx context.Context, env *env, args []string) error
+
+var commands = map[string]command{
+	"migrate":            migrate,
+	"apps create":        createApp,
+	"apps rotate-secret": rotateAppSecret,
+	"users list":         listUsers,
+	"users disable":      disableUser,
+	"users enable":       enableUser,
+	"users verify":       verifyUser,
+	"sessions revoke":    revokeSessions,
+}
+
+func main() {
+	configPath := flag.String("config", "./config/config.yaml", "path to the service config")
+	timeout := flag.Duration("timeout", 30*time.Second, "timeout for the whole command")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	cmd, args, ok := lookup(flag.Args())
+	if !ok {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.MustLoad(*configPath)
+
+	// stdout — для результата команды, логи уходят в stderr
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	e := &env{cfg: cfg, log: log, metrics: metrics.New()}
+	defer e.close()
+
+	if err := cmd(ctx, e, args); err != nil {
+		fmt.Fprintln(os.Stderr, "authctl:", err)
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+// lookup ищет команду из одного или двух слов.
+func lookup(args []string) (command, []string, bool) {
+	if len(args) >= 2 {
+		if cmd, ok := commands[args[0]+" "+args[1]]; ok {
+			return cmd, args[2:], true
+		}
+	}
+	if len(args) >= 1 {
+		if cmd, ok := commands[args[0]]; ok {
+			return cmd, args[1:], true
+		}
+	}
+	return nil, nil, false
+}
+
+// env лениво подключает хранилища: migrate не нужен Redis, а list — Auth.
+type env struct {
+	cfg     *config.Config
+	log     *slog.Logger
+	metrics *metrics.Metrics
+
+	db    storage.Repository
+	redis *redis.RedisRepo
+}
+
+// store подключается к хранилищу из storage.driver.
+func (e *env) store(ctx context.Context) (storage.Repository, error) {
+	if e.db != nil {
+		return e.db, nil
+	}
+
+	db, err := backend.Open(ctx, e.cfg, e.log, e.metrics)
+	if err != nil {
+		return nil, fmt.Errorf("connect %s: %w", e.cfg.Storage.Driver, err)
+	}
+	e.db = db
+
+	return db, nil
+}
+
+// auth собирает Auth только с зависимостями административных операций:
+// остальные (почта, 2FA, лимиты) этим методам не нужны.
+func (e *env) auth(ctx context.Context) (*auth.Auth, error) {
+	db, err := e.store(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rdb, err := e.redisRepo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.New(e.log, auth.Options{
+		UserSaver:      db,
+		UserProvider:   db,
+		AppProvider:    db,
+		UoW:            db,
+		AccessTokens:   rdb,
+		EmailChanges:   rdb,
+		TrustedDevices: trusteddevice.New(db, e.cfg.TwoFactorAuth.TrustedDeviceTTL),
+		Metrics:        e.metrics,
+
+		TokenTTLs: auth.TokenTTLs{
+			Access:      e.cfg.Tokens.AccessTokenTTL,
+			Refresh:     e.cfg.Tokens.RefreshTokenTTL,
+			Reset:       e.cfg.Tokens.ResetTokenTTL,
+			EmailChange: e.cfg.Tokens.EmailChangeTokenTTL,
+		},
+		RefreshMaxLifetime: e.cfg.Tokens.RefreshMaxLifetime,
+		RefreshSliding:     e.cfg.Tokens.RefreshSliding,
+		MaxSessions:        e.cfg.Sessions.MaxPerApp,
+		RejectOverLimit:    e.cfg.Sessions.OnLimit == config.SessionLimitReject,
+		DeletionGrace:      e.cfg.Retention.DeletedAccounts,
+		Leeway:             e.cfg.Tokens.Leeway,
+
+		EnforceMembership:  e.cfg.Apps.EnforceMembership,
+		NewDeviceChallenge: e.cfg.TwoFactorAuth.NewDeviceChallenge,
+	}), nil
+}
+
+func (e *env) redisRepo(ctx context.Context) (*redis.RedisRepo, error) {
+	if e.redis != nil {
+		return e.redis, nil
+	}
+
+	rdb, err := redis.New(ctx, e.cfg.Redis.Addr, e.cfg.Redis.Password, e.cfg.Redis.Db)
+	if err != nil {
+		return nil, fmt.Errorf("connect redis: %w", err)
+	}
+	e.redis = rdb
+
+	return rdb, nil
+}
+
+func (e *env) close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if e.redis != nil {
+		_ = e.redis.Close(ctx)
+	}
+	if e.db != nil {
+		_ = e.db.Close(ctx)
+	}
+}
+
+func migrate(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet("migrate")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	// backend.Open применяет миграции при включённом migrate и затем
+	// проверяет схему — отдельный вызов Migrate не нужен
+	e.cfg.EnableMigrate()
+	if _, err := e.store(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("migrations applied")
+	return nil
+}
+
+func createApp(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet("apps create")
+	name := fs.String("name", "", "app name")
+	public := fs.Bool("public", false, "public OAuth client (SPA, mobile): /oauth2/token accepts it without client_secret")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return fmt.Errorf("%w: --name is required", errUsage)
+	}
+
+	db, err := e.store(ctx)
+	if err != nil {
+		return err
+	}
+
+	secret, err := newAppSecret()
+	if err != nil {
+		return err
+	}
+
+	id, err := db.CreateApp(ctx, *name, secret, *public)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppAlreadyExists) {
+			return fmt.Errorf("app %q already exists", *name)
+		}
+		return err
+	}
+
+	fmt.Printf("app_id: %d\nsecret: %s\n", id, secret)
+	return nil
+}
+
+func rotateAppSecret(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet("apps rotate-secret")
+	id := fs.Int("id", 0, "app id")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *id <= 0 || *id > math.MaxInt32 {
+		return fmt.Errorf("%w: --id must be a valid app id", errUsage)
+	}
+
+	db, err := e.store(ctx)
+	if err != nil {
+		return err
+	}
+
+	secret, err := newAppSecret()
+	if err != nil {
+		return err
+	}
+
+	if err := db.SetAppSecret(ctx, int32(*id), secret); err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return fmt.Errorf("app %d not found", *id)
+		}
+		return err
+	}
+
+	// кеш apps живёт в памяти реплик: сбросить его отсюда нельзя
+	if e.cfg.Apps.CacheTTL > 0 {
+		fmt.Fprintf(os.Stderr, "note: running replicas sign with the old secret for up to %s\n", e.cfg.Apps.CacheTTL)
+	}
+
+	fmt.Printf("app_id: %d\nsecret: %s\n", *id, secret)
+	return nil
+}
+
+func listUsers(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet("users list")
+	after := fs.Int64("after", 0, "list users with id greater than this")
+	limit := fs.Int("limit", 50, "max users to list (1-1000)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *limit < 1 || *limit > 1000 {
+		return fmt.Errorf("%w: --limit must be between 1 and 1000", errUsage)
+	}
+
+	db, err := e.store(ctx)
+	if err != nil {
+		return err
+	}
+
+	users, err := db.ListUsers(ctx, *after, *limit)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tUSERNAME\tVERIFIED\tSTATUS\tREASON")
+	for _, u := range users {
+		reason := ""
+		if u.StatusReason != nil {
+			reason = *u.StatusReason
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%t\t%s\t%s\n", u.ID, u.Email, u.Username, u.IsVerified, u.Status, reason)
+	}
+
+	return w.Flush()
+}
+
+func disableUser(ctx context.Context, e *env, args []string) error {
+	return changeStatus(ctx, e, "users disable", models.AccountStatusDisabled, args)
+}
+
+func enableUser(ctx context.Context, e *env, args []string) error {
+	return changeStatus(ctx, e, "users enable", models.AccountStatusActive, args)
+}
+
+func changeStatus(ctx context.Context, e *env, name string, status models.AccountStatus, args []string) error {
+	fs := newFlagSet(name)
+	id := fs.Int64("id", 0, "user id")
+	reason := fs.String("reason", "", "reason recorded in the audit log")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *id <= 0 {
+		return fmt.Errorf("%w: --id is required", errUsage)
+	}
+
+	a, err := e.auth(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := a.ChangeStatus(ctx, *id, status, *reason, auditEvent(*reason)); err != nil {
+		return userError(*id, err)
+	}
+
+	fmt.Printf("user %d is now %s\n", *id, status)
+	return nil
+}
+
+func verifyUser(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet("users verify")
+	id := fs.Int64("id", 0, "user id")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *id <= 0 {
+		return fmt.Errorf("%w: --id is required", errUsage)
+	}
+
+	a, err := e.auth(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := a.VerifyEmailManually(ctx, *id, auditEvent("")); err != nil {
+		return userError(*id, err)
+	}
+
+	fmt.Printf("user %d email verified\n", *id)
+	return nil
+}
+
+func revokeSessions(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet("sessions revoke")
+	id := fs.Int64("user", 0, "user id")
+	reason := fs.String("reason", "", "reason recorded in the audit log")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *id <= 0 {
+		return fmt.Errorf("%w: --user is required", errUsage)
+	}
+
+	a, err := e.auth(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := a.ForceLogout(ctx, *id, auditEvent(*reason))
+	if err != nil {
+		return userError(*id, err)
+	}
+
+	fmt.Printf("user %d: %d refresh tokens deleted, %d access tokens revoked\n",
+		*id, result.RefreshTokensDeleted, result.AccessTokensRevoked)
+	return nil
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %s", errUsage, err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected arguments %v", errUsage, fs.Args())
+	}
+	return nil
+}
+
+// auditEvent — событие от имени оператора CLI. IP и User-Agent пустые:
+// запроса нет, а actor и так указывает на CLI.
+func auditEvent(reason string) models.AuditEvent {
+	actor := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		actor = u.Username
+	}
+
+	event := models.AuditEvent{Actor: "cli:" + actor}
+	if reason != "" {
+		event.Metadata = map[string]any{"reason": reason}
+	}
+
+	return event
+}
+
+func userError(id int64, err error) error {
+	switch {
+	case errors.Is(err, auth.ErrUserNotFound):
+		return fmt.Errorf("user %d not found", id)
+	case errors.Is(err, auth.ErrInvalidStatusTransition):
+		return fmt.Errorf("user %d: status cannot be changed from its current value", id)
+	case errors.Is(err, auth.ErrEmailAlreadyVerified):
+		return fmt.Errorf("user %d: email already verified", id)
+	}
+	return err
+}
+
+// newAppSecret — 256 бит случайных данных: секрет подписывает HS256-токены.
+func newAppSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate app secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,295 @@
+//go:build integration
+
+// Сквозной тест на настоящих Postgres, Redis и RabbitMQ в контейнерах:
+// роутер собирается тем же newApp, что и в main, поэтому ловятся ошибки
+// SQL, миграций и публикации писем, которых не видят unit-тесты на
+// memory-хранилищах. Нужен Docker:
+//
+//	go test -tags integration ./cmd/
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"auth_service/internal/config"
+
+	"github.com/XdMishaXd/auth_service/contract"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/testcontainers/testcontainers-go"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+	tcrabbitmq "github.com/testcontainers/testcontainers-go/modules/rabbitmq"
+	tcredis "github.com/testcontainers/testcontainers-go/modules/redis"
+)
+
+const (
+	testDB       = "auth_service"
+	testPassword = "integration-secret"
+)
+
+// startDeps поднимает контейнеры и выставляет env, из которых
+// config.Load собирает конфиг. Возвращает URL RabbitMQ для чтения писем.
+func startDeps(t *testing.T) string {
+	t.Helper()
+
+	ctx := t.Context()
+
+	// образ из docker/postgres: миграции требуют pg_cron
+	pg, err := tcpostgres.Run(ctx, "",
+		testcontainers.WithDockerfile(testcontainers.FromDockerfile{Context: "../../docker/postgres"}),
+		tcpostgres.WithDatabase(testDB),
+		tcpostgres.WithUsername("auth"),
+		tcpostgres.WithPassword(testPassword),
+		testcontainers.WithCmdArgs("-c", "shared_preload_libraries=pg_cron", "-c", "cron.database_name="+testDB),
+		tcpostgres.BasicWaitStrategies(),
+	)
+	testcontainers.CleanupContainer(t, pg)
+	if err != nil {
+		t.Fatalf("start postgres: %v", err)
+	}
+
+	rdb, err := tcredis.Run(ctx, "redis:8", testcontainers.WithCmdArgs("--requirepass", testPassword))
+	testcontainers.CleanupContainer(t, rdb)
+	if err != nil {
+		t.Fatalf("start redis: %v", err)
+	}
+
+	mq, err := tcrabbitmq.Run(ctx, "rabbitmq:3-management")
+	testcontainers.CleanupContainer(t, mq)
+	if err != nil {
+		t.Fatalf("start rabbitmq: %v", err)
+	}
+
+	pgAddr := endpoint(t, pg, "5432/tcp")
+	pgHost, pgPort, _ := net.SplitHostPort(pgAddr)
+
+	amqpURL, err := mq.AmqpURL(ctx)
+	if err != nil {
+		t.Fatalf("rabbitmq url: %v", err)
+	}
+
+	for name, value := range map[string]string{
+		"POSTGRES_HOST":             pgHost,
+		"POSTGRES_PORT":             pgPort,
+		"POSTGRES_USER":             "auth",
+		"POSTGRES_PASSWORD":         testPassword,
+		"POSTGRES_DB":               testDB,
+		"POSTGRES_MIGRATE":          "true",
+		"REDIS_ADDR":                endpoint(t, rdb, "6379/tcp"),
+		"REDIS_PASSWORD":            testPassword,
+		"RABBITMQ_URL":              amqpURL,
+		"VERIFICATION_TOKEN_SECRET": testPassword,
+		"TWO_FACTOR_TOKEN_SECRET":   testPassword,
+		"GOOGLE_CLIENT_ID":          "test",
+		"GOOGLE_CLIENT_SECRET":      "test",
+		"GOOGLE_REDIRECT_URL":       "http://localhost/oauth/google/callback",
+		"GITHUB_CLIENT_ID":          "test",
+		"GITHUB_CLIENT_SECRET":      "test",
+		"GITHUB_REDIRECT_URL":       "http://localhost/oauth/github/callback",
+	} {
+		t.Setenv(name, value)
+	}
+
+	return amqpURL
+}
+
+func endpoint(t *testing.T, c testcontainers.Container, port string) string {
+	t.Helper()
+
+	addr, err := c.PortEndpoint(t.Context(), port, "")
+	if err != nil {
+		t.Fatalf("endpoint %s: %v", port, err)
+	}
+
+	return addr
+}
+
+// startApp собирает сервис как main и закрывает его по окончании теста.
+func startApp(t *testing.T) (*app, *config.Config) {
+	t.Helper()
+
+	cfg, err := config.Load("../config/config.yaml")
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	initCtx, cancel := context.WithTimeout(t.Context(), time.Minute)
+	defer cancel()
+
+	a, err := newApp(initCtx, cfg, log)
+	if err != nil {
+		t.Fatalf("init app: %v", err)
+	}
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		_ = a.background.Wait(ctx)
+		_ = a.msgBroker.Close(ctx)
+		_ = a.store.Close(ctx)
+		_ = a.redis.Close(ctx)
+		a.closeGeo()
+	})
+
+	return a, cfg
+}
+
+func call(t *testing.T, h http.Handler, method, target string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal %s body: %v", target, err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, target, reqBody)
+	req.Header.Set("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var v T
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+	}
+
+	return v
+}
+
+// nextEmail ждёт письмо purpose в очереди, которую читает email_sender.
+func nextEmail(t *testing.T, amqpURL, queue, purpose string) contract.EmailMessage {
+	t.Helper()
+
+	conn, err := amqp.Dial(amqpURL)
+	if err != nil {
+		t.Fatalf("dial rabbitmq: %v", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		t.Fatalf("open channel: %v", err)
+	}
+	defer ch.Close()
+
+	deadline := time.Now().Add(15 * time.Second)
+	for time.Now().Before(deadline) {
+		delivery, ok, err := ch.Get(queue, true)
+		if err != nil {
+			t.Fatalf("get from %s: %v", queue, err)
+		}
+		if !ok {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		msg, err := contract.DecodeEmail(delivery.Body)
+		if err != nil {
+			t.Fatalf("decode email: %v", err)
+		}
+		if msg.Purpose == purpose {
+			return msg
+		}
+	}
+
+	t.Fatalf("no %s email in %s", purpose, queue)
+
+	return contract.EmailMessage{}
+}
+
+type tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func TestRegisterVerifyLoginRefreshLogout(t *testing.T) {
+	amqpURL := startDeps(t)
+	a, cfg := startApp(t)
+
+	appID, err := a.store.CreateApp(t.Context(), "web", "integration-app-secret", false)
+	if err != nil {
+		t.Fatalf("create app: %v", err)
+	}
+
+	const (
+		email = "alice@example.com"
+		pass  = "correct horse battery staple"
+	)
+	credentials := map[string]any{"email": email, "password": pass, "app_id": appID}
+
+	rec := call(t, a.router, http.MethodPost, "/auth/register", map[string]any{
+		"email": email, "username": "alice", "password": pass, "app_id": appID,
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("register: %d %s", rec.Code, rec.Body)
+	}
+
+	if rec := call(t, a.router, http.MethodPost, "/auth/login", credentials); rec.Code != http.StatusForbidden {
+		t.Fatalf("login before verify: %d %s", rec.Code, rec.Body)
+	}
+
+	// * ссылка из письма, опубликованного в RabbitMQ
+	msg := nextEmail(t, amqpURL, cfg.RabbitMQ.QueueName, "email_verification")
+	if msg.Email != email {
+		t.Fatalf("verification email to %q, want %q", msg.Email, email)
+	}
+
+	link, err := url.Parse(msg.Link)
+	if err != nil {
+		t.Fatalf("parse verification link: %v", err)
+	}
+
+	if rec := call(t, a.router, http.MethodGet, link.RequestURI(), nil); rec.Code != http.StatusOK {
+		t.Fatalf("verify: %d %s", rec.Code, rec.Body)
+	}
+
+	rec = call(t, a.router, http.MethodPost, "/auth/login", credentials)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login: %d %s", rec.Code, rec.Body)
+	}
+	login := decode[tokens](t, rec)
+	if login.AccessToken == "" || login.RefreshToken == "" {
+		t.Fatalf("login tokens missing: %s", rec.Body)
+	}
+
+	rec = call(t, a.router, http.MethodPost, "/auth/refresh", map[string]string{"refresh_token": login.RefreshToken})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh: %d %s", rec.Code, rec.Body)
+	}
+	refreshed := decode[tokens](t, rec)
+	if refreshed.RefreshToken == "" || refreshed.RefreshToken == login.RefreshToken {
+		t.Fatalf("refresh token not rotated: %s", rec.Body)
+	}
+
+	rec = call(t, a.router, http.MethodPost, "/auth/logout", map[string]string{"refresh_token": refreshed.RefreshToken})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("logout: %d %s", rec.Code, rec.Body)
+	}
+
+	rec = call(t, a.router, http.MethodPost, "/auth/refresh", map[string]string{"refresh_token": refreshed.RefreshToken})
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh after logout: %d %s", rec.Code, rec.Body)
+	}
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
 	"syscall"
@@ -12,58 +15,124 @@ import (
 
 	"auth_service/internal/auth"
 	twoFactorAuth "auth_service/internal/auth/2fa"
+	"auth_service/internal/auth/apikeys"
+	"auth_service/internal/auth/challenge"
 	"auth_service/internal/auth/oauth"
-	"auth_service/internal/auth/oauth/providers"
+	"auth_service/internal/auth/oidc"
+	orgsService "auth_service/internal/auth/orgs"
+	"auth_service/internal/auth/rbac"
+	"auth_service/internal/auth/verifycode"
 	"auth_service/internal/config"
 	"auth_service/internal/http_server/handlers/2fa/disable"
 	"auth_service/internal/http_server/handlers/2fa/enable"
 	requestAction "auth_service/internal/http_server/handlers/2fa/request_action_confirmation"
 	resendMagicLink "auth_service/internal/http_server/handlers/2fa/resend_magic_link"
+	totpHandler "auth_service/internal/http_server/handlers/2fa/totp"
 	verifyMagicLink "auth_service/internal/http_server/handlers/2fa/verify_magic_link"
+	changeEmail "auth_service/internal/http_server/handlers/account/change_email"
 	deleteAccount "auth_service/internal/http_server/handlers/account/delete"
 	requestRestoreConfirmation "auth_service/internal/http_server/handlers/account/request_restore_confirmation"
 	"auth_service/internal/http_server/handlers/account/restore"
+	"auth_service/internal/http_server/handlers/account/sessions"
+	adminAPIKeys "auth_service/internal/http_server/handlers/admin/api_keys"
+	changeStatus "auth_service/internal/http_server/handlers/admin/change_status"
+	forceLogout "auth_service/internal/http_server/handlers/admin/force_logout"
+	ipRules "auth_service/internal/http_server/handlers/admin/ip_rules"
+	maintenanceHandler "auth_service/internal/http_server/handlers/admin/maintenance"
+	requirePasswordReset "auth_service/internal/http_server/handlers/admin/require_password_reset"
+	adminRoles "auth_service/internal/http_server/handlers/admin/roles"
+	rotateSigningKey "auth_service/internal/http_server/handlers/admin/rotate_signing_key"
+	userRoles "auth_service/internal/http_server/handlers/admin/user_roles"
+	adminVerifyEmail "auth_service/internal/http_server/handlers/admin/verify_email"
+	apiKeySelf "auth_service/internal/http_server/handlers/api_keys/self"
+	graphqlHandler "auth_service/internal/http_server/handlers/graphql"
 	docsHandler "auth_service/internal/http_server/handlers/infrastructure/docs"
 	"auth_service/internal/http_server/handlers/infrastructure/health"
+	jwksHandler "auth_service/internal/http_server/handlers/infrastructure/jwks"
 	metricsHandler "auth_service/internal/http_server/handlers/infrastructure/metrics"
 	scalarHandler "auth_service/internal/http_server/handlers/infrastructure/scalar"
+	swaggerUIHandler "auth_service/internal/http_server/handlers/infrastructure/swagger_ui"
 	"auth_service/internal/http_server/handlers/login"
 	"auth_service/internal/http_server/handlers/logout"
+	"auth_service/internal/http_server/handlers/me/activity"
+	"auth_service/internal/http_server/handlers/me/identities"
+	trustedDevices "auth_service/internal/http_server/handlers/me/trusted_devices"
+	twoFactor "auth_service/internal/http_server/handlers/me/two_factor"
 	"auth_service/internal/http_server/handlers/oauth/accounts"
 	"auth_service/internal/http_server/handlers/oauth/callback"
 	"auth_service/internal/http_server/handlers/oauth/link"
 	ologin "auth_service/internal/http_server/handlers/oauth/login"
 	"auth_service/internal/http_server/handlers/oauth/unlink"
+	oidcHandler "auth_service/internal/http_server/handlers/oidc"
+	"auth_service/internal/http_server/handlers/orgs"
+	orgInvitations "auth_service/internal/http_server/handlers/orgs/invitations"
+	orgMembers "auth_service/internal/http_server/handlers/orgs/members"
 	"auth_service/internal/http_server/handlers/password/forgot"
 	"auth_service/internal/http_server/handlers/password/reset"
 	"auth_service/internal/http_server/handlers/refresh"
 	register "auth_service/internal/http_server/handlers/register"
 	resendVerification "auth_service/internal/http_server/handlers/resend_verification_email"
+	tokenExchange "auth_service/internal/http_server/handlers/token/exchange"
+	"auth_service/internal/http_server/handlers/token/introspect"
+	"auth_service/internal/http_server/handlers/unlock"
 	"auth_service/internal/http_server/handlers/verify"
+	verifyCode "auth_service/internal/http_server/handlers/verify_code"
+	adminAuth "auth_service/internal/http_server/middleware/admin_auth"
+	apiKeyAuth "auth_service/internal/http_server/middleware/api_key_auth"
+	cacheControl "auth_service/internal/http_server/middleware/cache_control"
+	challengeGuard "auth_service/internal/http_server/middleware/challenge_guard"
 	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	clientInfo "auth_service/internal/http_server/middleware/client_info"
+	csrfGuard "auth_service/internal/http_server/middleware/csrf_guard"
+	ipFilter "auth_service/internal/http_server/middleware/ip_filter"
+	maintenanceGuard "auth_service/internal/http_server/middleware/maintenance_guard"
 	metricsCollector "auth_service/internal/http_server/middleware/metrics_collector"
 	httpRateLimit "auth_service/internal/http_server/middleware/rate_limiter"
+	realIP "auth_service/internal/http_server/middleware/real_ip"
+	requestLogger "auth_service/internal/http_server/middleware/request_logger"
 	swaggerAuth "auth_service/internal/http_server/middleware/swagger-auth"
+	traceContext "auth_service/internal/http_server/middleware/trace_context"
+	"auth_service/internal/ipfilter"
+	"auth_service/internal/lib/background"
+	"auth_service/internal/lib/cookie"
 	"auth_service/internal/lib/jwt"
-	customValidator "auth_service/internal/lib/validation/custom_validator"
+	"auth_service/internal/lib/mailer"
+	"auth_service/internal/lib/notifier"
+	"auth_service/internal/maintenance"
 	"auth_service/internal/metrics"
-	"auth_service/internal/rabbitmq"
+	"auth_service/internal/models"
 	rateLimit "auth_service/internal/ratelimit"
-	"auth_service/internal/storage/postgres"
-	"auth_service/internal/storage/redis"
+	"auth_service/internal/storage"
 
+	"github.com/XdMishaXd/auth_service/secrets"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 	"golang.org/x/sync/errgroup"
 )
 
+// Спек в docs/ генерируется из аннотаций хендлеров; после их изменения —
+// go generate ./cmd/... Хендлеры лежат в internal/ и разбираются как
+// зависимости main (parseDependencyLevel 3 — и модели, и операции).
+//go:generate go run github.com/swaggo/swag/cmd/swag@v1.16.6 init --dir ./ --generalInfo main.go --output ../docs --parseInternal --parseDependencyLevel 3
+
 // @title           Auth Service API
 // @version         1.0
 // @description     Сервис авторизации
 // @host            localhost:8082
 // @BasePath        /
 
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 Access токен: "Bearer <token>"
+
+// @securityDefinitions.apikey  ApiKeyAuth
+// @in                          header
+// @name                        X-API-Key
+
 const (
 	envLocal = "local"
 	envDev   = "dev"
@@ -71,123 +140,79 @@ const (
 )
 
 func main() {
-	cfg := config.MustLoad("./config/config.yaml")
+	migrate := flag.Bool("migrate", false, "apply pending database migrations at startup")
+	flag.Parse()
 
-	googleProvider := providers.NewGoogleProvider(
-		cfg.OAuth.GoogleClientID,
-		cfg.OAuth.GoogleClientSecret,
-		cfg.OAuth.GoogleRedirectURL,
-	)
-
-	githubProvider := providers.NewGitHubProvider(
-		cfg.OAuth.GitHubClientID,
-		cfg.OAuth.GitHubClientSecret,
-		cfg.OAuth.GitHubRedirectURL,
-	)
-
-	oauthProviders := map[string]oauth.OAuthProvider{
-		"google": googleProvider,
-		"github": githubProvider,
+	cfg := config.MustLoad("./config/config.yaml")
+	if *migrate {
+		cfg.EnableMigrate()
 	}
 
-	log := setupLogger(cfg.Env)
+	log, logLevel := setupLogger(cfg.Env, cfg.LogLevel)
 
 	log.Info("starting auth service", slog.String("env", cfg.Env))
 
-	// * Context для инициализации компонентов
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	postgresql, err := postgres.New(ctx, cfg, log)
-	if err != nil {
-		log.Error("failed to connect postgres", slog.String("err", err.Error()))
-		os.Exit(1)
-	}
+	// * Context для инициализации компонентов — только на время подключений;
+	// дальше компоненты живут до явного Close при остановке
+	initCtx, initCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer initCancel()
 
-	log.Info("postgresql connected successfully",
-		slog.String("host", cfg.Postgres.Host),
-		slog.Int("port", cfg.Postgres.Port),
-		slog.String("database", cfg.Postgres.DBName),
-	)
+	// * W3C trace context: traceparent из HTTP протягивается в заголовки AMQP
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
 
-	redis, err := redis.New(ctx, cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.Db)
+	a, err := newApp(initCtx, cfg, log)
 	if err != nil {
-		log.Error("failed to connect redis", slog.String("err", err.Error()))
+		log.Error("failed to init app", slog.String("err", err.Error()))
 		os.Exit(1)
 	}
+	defer a.closeGeo()
 
-	log.Info("redis connected successfully",
-		slog.String("host", cfg.Redis.Addr),
-		slog.Int("database", cfg.Redis.Db),
-	)
+	initCancel()
 
-	rabbitMQClient, err := rabbitmq.New(cfg.RabbitMQ.URL, cfg.RabbitMQ.QueueName)
+	// * ротация секретов во внешнем хранилище — на каждой реплике
+	secretsProvider, err := secrets.New(cfg.Secrets)
 	if err != nil {
-		log.Error("failed to connect rabbitmq", slog.String("err", err.Error()))
+		log.Error("failed to init secrets provider", slog.String("err", err.Error()))
 		os.Exit(1)
 	}
 
-	log.Info("rabbitmq connected successfully")
-
-	limiter, err := rateLimit.New(ctx, redis)
-	if err != nil {
-		log.Error("failed to init rate limiter", slog.String("err", err.Error()))
-		os.Exit(1)
+	secretsCtx, secretsCancel := context.WithCancel(context.Background())
+	defer secretsCancel()
+
+	if secretsProvider != nil {
+		go secrets.Watch(
+			secretsCtx,
+			log,
+			secretsProvider,
+			cfg.Secrets.RefreshInterval,
+			cfg.Secrets.Timeout,
+			func(changed map[string]string) { applyRotatedSecrets(log, cfg.Storage.Driver, a.store, changed) },
+		)
 	}
 
-	rlMiddlewares := httpRateLimit.New(limiter, log)
+	schedulerCtx, schedulerCancel := context.WithCancel(context.Background())
+	defer schedulerCancel()
 
-	twoFactorAuthService := twoFactorAuth.New(
-		postgresql,
-		redis,
-		rabbitMQClient,
-		log,
-		cfg,
-	)
-
-	authService := auth.New(
-		log,
-		postgresql,
-		postgresql,
-		postgresql,
-		twoFactorAuthService,
-		cfg.Tokens.AccessTokenTTL,
-		cfg.Tokens.RefreshTokenTTL,
-		cfg.Tokens.ResetTokenTTL,
-	)
-
-	oauthService := oauth.New(
-		authService,
-		log,
-		postgresql,
-		redis,
-		oauthProviders,
-		cfg.OAuth.StateTTL,
-	)
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		a.jobs.Run(schedulerCtx)
+	}()
 
-	requestValidator := customValidator.New()
-
-	metrics := metrics.New()
-
-	router := setupRouter(
-		log,
-		cfg,
-		requestValidator,
-		metrics,
-		rlMiddlewares,
-		authService,
-		oauthService,
-		postgresql,
-		rabbitMQClient,
-		allowedRedirectHostSet(cfg.OAuth.AllowedRedirectHosts),
-	)
+	// * отменяется, только если запросы не успели завершиться к shutdown_timeout
+	requestsCtx, abortRequests := context.WithCancel(context.Background())
+	defer abortRequests()
 
 	srv := &http.Server{
 		Addr:         cfg.HTTPServer.Address,
-		Handler:      router,
+		Handler:      a.router,
 		ReadTimeout:  cfg.HTTPServer.Timeout,
 		WriteTimeout: cfg.HTTPServer.Timeout,
 		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
+		BaseContext:  func(net.Listener) context.Context { return requestsCtx },
 	}
 
 	serverErrors := make(chan error, 1)
@@ -196,6 +221,32 @@ func main() {
 		serverErrors <- srv.ListenAndServe()
 	}()
 
+	// * SIGHUP перечитывает конфиг: уровень логов, лимиты и сроки токенов
+	// применяются на лету, остальное — только рестартом
+	reloadCtx, reloadCancel := context.WithCancel(context.Background())
+	defer reloadCancel()
+
+	go watchReload(reloadCtx, log, cfg, func(r config.Reloadable) config.Reloadable {
+		if r.AccessTokenTTL+cfg.Tokens.Leeway > a.signingKeyGrace {
+			log.Warn("config change requires restart, ignored",
+				slog.String("section", "tokens.access_token_ttl"),
+				slog.Duration("max_without_restart", a.signingKeyGrace-cfg.Tokens.Leeway),
+			)
+			r.AccessTokenTTL = cfg.Tokens.AccessTokenTTL
+		}
+
+		logLevel.Set(levelFor(cfg.Env, r.LogLevel))
+		a.rateLimits.SetOverrides(rateLimitOverrides(r.RateLimits))
+		a.auth.SetTokenTTLs(auth.TokenTTLs{
+			Access:      r.AccessTokenTTL,
+			Refresh:     r.RefreshTokenTTL,
+			Reset:       r.ResetTokenTTL,
+			EmailChange: r.EmailChangeTokenTTL,
+		})
+
+		return r
+	})
+
 	// * graceful shutdown
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
@@ -209,40 +260,57 @@ func main() {
 		log.Info("shutdown signal received", slog.String("signal", sig.String()))
 
 		// * Graceful shutdown context
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
 		defer shutdownCancel()
 
+		// * 1. перестаём принимать соединения и ждём запросы в работе
 		log.Info("shutting down http server")
+		reloadCancel()
 
 		if err := srv.Shutdown(shutdownCtx); err != nil {
 			log.Error("failed to shutdown server gracefully", slog.String("error", err.Error()))
 
+			// оставшиеся обработчики получают отмену ctx и не ходят в
+			// хранилища, которые закрываются ниже
+			abortRequests()
 			if closeErr := srv.Close(); closeErr != nil {
 				log.Error("failed to force close server", slog.String("error", closeErr.Error()))
 			}
 		}
 
+		// * 2. фоновая работа обработчиков ещё публикует письма и пишет в БД
+		if err := a.background.Wait(shutdownCtx); err != nil {
+			log.Error("background tasks did not finish in time", slog.String("error", err.Error()))
+		}
+
+		// задачи и лидерский lock держат соединения пула — гасим их до закрытия хранилища
+		schedulerCancel()
+		<-schedulerDone
+		secretsCancel()
+
+		// * 3. новых писем больше не будет — отправляем отложенные в буфере
+		flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer flushCancel()
+
+		if err := a.msgBroker.Close(flushCtx); err != nil {
+			log.Error("failed to close rabbitmq gracefully", slog.String("err", err.Error()))
+		}
+
+		// * 4. хранилища закрываются последними
 		closeCtx, closeCancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer closeCancel()
 
 		var eg errgroup.Group
 
 		eg.Go(func() error {
-			if err := postgresql.Close(closeCtx); err != nil {
-				return fmt.Errorf("postgres close: %w", err)
-			}
-			return nil
-		})
-
-		eg.Go(func() error {
-			if err := rabbitMQClient.Close(closeCtx); err != nil {
-				return fmt.Errorf("rabbitmq close: %w", err)
+			if err := a.store.Close(closeCtx); err != nil {
+				return fmt.Errorf("%s close: %w", cfg.Storage.Driver, err)
 			}
 			return nil
 		})
 
 		eg.Go(func() error {
-			if err := redis.Close(closeCtx); err != nil {
+			if err := a.redis.Close(closeCtx); err != nil {
 				return fmt.Errorf("redis close: %w", err)
 			}
 
@@ -257,6 +325,31 @@ func main() {
 	}
 }
 
+// applyRotatedSecrets применяет ротацию без рестарта, где это возможно.
+// Секреты подписи токенов и ключи шифрования меняются только рестартом:
+// подмена на лету сделала бы недействительными уже выданные токены.
+// Пароль СУБД применяется, только если это пароль выбранного storage.driver.
+func applyRotatedSecrets(log *slog.Logger, driver string, store storage.Repository, changed map[string]string) {
+	for name, value := range changed {
+		switch {
+		case name == "POSTGRES_PASSWORD" && driver == config.StorageDriverPostgres:
+			store.SetPassword(value)
+			log.Info("postgres password rotated")
+		case name == "MYSQL_PASSWORD" && driver == config.StorageDriverMySQL:
+			store.SetPassword(value)
+			log.Info("mysql password rotated")
+		default:
+			log.Warn("secret changed, restart required to apply", slog.String("name", name))
+		}
+	}
+}
+
+// messagePublisher — RabbitMQ-клиент или sandbox-публикатор (mail.sandbox).
+type messagePublisher interface {
+	mailer.Publisher
+	Close(ctx context.Context) error
+}
+
 func setupRouter(
 	log *slog.Logger,
 	cfg *config.Config,
@@ -265,75 +358,128 @@ func setupRouter(
 	rateLimiter *httpRateLimit.RateLimit,
 	authService *auth.Auth,
 	oauthService *oauth.OAuthService,
-	appProvider jwt.AppSecretProvider,
-	msgBroker *rabbitmq.RabbitMQClient,
+	identityService identities.IdentityManager,
+	trustedDeviceManager trustedDevices.TrustedDeviceManager,
+	oidcProvider *oidc.Provider,
+	rbacService *rbac.Service,
+	apiKeys *apikeys.Service,
+	verifyCodes *verifycode.Service,
+	organizations *orgsService.Service,
+	appProvider jwt.KeyProvider,
+	signingKeys jwksHandler.KeySet,
+	keyRotator rotateSigningKey.KeyRotator,
+	accessTokens claimsParser.AccessTokenStore,
+	maintenanceMode *maintenance.Mode,
+	msgBroker mailer.Publisher,
+	backgroundTasks *background.Tasks,
+	refreshCookies *cookie.Jar,
+	loginChallenge *challenge.Challenge,
+	ipRuleFilter *ipfilter.Filter,
 	allowedRedirectHosts map[string]bool,
+	trustedProxies []netip.Prefix,
 ) *chi.Mux {
 	r := chi.NewRouter()
 
-	r.Get("/health", health.New())
+	r.Get("/health", health.New(maintenanceMode))
 	r.Get("/metrics", metricsHandler.New(m))
+	r.With(cacheControl.New(cfg.HTTPServer.CacheMaxAge)).Get("/.well-known/jwks.json",
+		jwksHandler.New(log, signingKeys, cfg.HTTPServer.HandlersTimeout),
+	)
+	if cfg.OIDC.Enabled {
+		r.With(cacheControl.New(cfg.HTTPServer.CacheMaxAge)).Get("/.well-known/openid-configuration",
+			oidcHandler.NewDiscovery(oidcProvider.Issuer()),
+		)
+	}
 
 	r.Group(func(r chi.Router) {
 		r.Use(metricsCollector.New(m))
+		r.Use(traceContext.New())
 		r.Use(middleware.RequestID)
-		r.Use(middleware.RealIP)
-		r.Use(middleware.Logger)
+		r.Use(realIP.New(trustedProxies))
+		r.Use(clientInfo.New())
+		r.Use(ipFilter.New(log, ipRuleFilter))
+		r.Use(requestLogger.New(log))
 		r.Use(middleware.Recoverer)
 
+		if cfg.HTTPServer.CompressionLevel > 0 {
+			r.Use(middleware.Compress(cfg.HTTPServer.CompressionLevel))
+		}
+
 		if cfg.Swagger.Enabled {
 			r.Group(func(r chi.Router) {
 				r.Use(swaggerAuth.New(cfg.Swagger.Username, cfg.Swagger.Password))
-				r.Get("/swagger/doc.json", docsHandler.New())
-				r.Get("/docs", scalarHandler.New("/swagger/doc.json"))
+				r.With(cacheControl.New(cfg.HTTPServer.CacheMaxAge)).Get("/swagger/doc.json", docsHandler.New())
+				r.Get("/docs", swaggerUIHandler.New("/swagger/doc.json"))
+				r.Get("/docs/reference", scalarHandler.New("/swagger/doc.json"))
 			})
 		}
 
+		// админка и GET-эндпоинты в режиме обслуживания продолжают работать
+		guard := maintenanceGuard.New(maintenanceMode, cfg.Maintenance.RetryAfter)
+
 		r.Route("/auth", func(r chi.Router) {
+			r.Use(guard.Writes())
+
 			r.With(rateLimiter.Register()).Post("/register",
 				register.New(
 					log,
 					validate,
 					authService,
 					msgBroker,
+					verifyCodes,
 					cfg.Tokens.VerificationTokenTTL,
 					cfg.Tokens.VerificationTokenSecret,
-					cfg.HTTPServer.Address,
+					cfg.HTTPServer.PublicBaseURL,
 					cfg.HTTPServer.HandlersTimeout,
 				),
 			)
-			r.With(rateLimiter.Login()).Post("/login",
+			r.With(rateLimiter.Login(), challengeGuard.New(log, loginChallenge)).Post("/login",
 				login.New(
 					log,
 					validate,
 					authService,
+					refreshCookies,
 					cfg.HTTPServer.HandlersTimeout,
 					cfg.TwoFactorAuth.PendingSessionTTL,
 				),
 			)
-			r.With(rateLimiter.Refresh()).Post("/refresh",
-				refresh.New(log, validate, authService, cfg.HTTPServer.HandlersTimeout),
+			r.With(rateLimiter.Refresh(), csrfGuard.New(log, refreshCookies)).Post("/refresh",
+				refresh.New(log, validate, authService, refreshCookies, cfg.HTTPServer.HandlersTimeout),
 			)
-			r.With(rateLimiter.Logout()).Post("/logout",
-				logout.New(log, validate, authService, cfg.HTTPServer.HandlersTimeout),
+			r.With(rateLimiter.Logout(), csrfGuard.New(log, refreshCookies)).Post("/logout",
+				logout.New(log, validate, authService, refreshCookies, cfg.HTTPServer.HandlersTimeout),
 			)
-			r.With(rateLimiter.Verify()).Get("/verify",
+			r.With(
+				claimsParser.RequireAuth(appProvider, cfg.Tokens.Leeway, accessTokens),
+				rateLimiter.LogoutAll(),
+			).Post("/logout/all",
+				logout.NewAll(log, authService, cfg.HTTPServer.HandlersTimeout),
+			)
+			r.With(guard.All(), rateLimiter.Verify()).Get("/verify",
 				verify.New(
 					log,
 					authService,
 					cfg.Tokens.VerificationTokenSecret,
+					cfg.Tokens.Leeway,
 					cfg.HTTPServer.HandlersTimeout,
 				),
 			)
+			r.With(rateLimiter.VerifyCode()).Post("/verify/code",
+				verifyCode.New(log, validate, verifyCodes, cfg.HTTPServer.HandlersTimeout),
+			)
+			r.With(rateLimiter.Unlock()).Get("/unlock",
+				unlock.New(log, authService, cfg.HTTPServer.HandlersTimeout),
+			)
 			r.With(rateLimiter.ResendVerificationEmail()).Post("/verify/resend",
 				resendVerification.New(
 					log,
 					validate,
 					authService,
 					msgBroker,
+					verifyCodes,
 					cfg.Tokens.VerificationTokenTTL,
 					cfg.Tokens.VerificationTokenSecret,
-					cfg.HTTPServer.Address,
+					cfg.HTTPServer.PublicBaseURL,
 					cfg.HTTPServer.HandlersTimeout,
 				),
 			)
@@ -344,7 +490,8 @@ func setupRouter(
 					validate,
 					msgBroker,
 					authService,
-					cfg.HTTPServer.Address,
+					backgroundTasks,
+					cfg.HTTPServer.PublicBaseURL,
 					cfg.HTTPServer.HandlersTimeout,
 				),
 			)
@@ -360,14 +507,14 @@ func setupRouter(
 
 			r.Route("/oauth", func(r chi.Router) {
 				// Публичные эндпоинты — юзер ещё не аутентифицирован.
-				r.With(rateLimiter.OAuthLogin()).Get("/{provider}/login",
+				r.With(guard.All(), rateLimiter.OAuthLogin()).Get("/{provider}/login",
 					ologin.New(
 						log,
 						oauthService,
 						allowedRedirectHosts,
 					),
 				)
-				r.With(rateLimiter.OAuthCallback()).Get("/{provider}/callback",
+				r.With(guard.All(), rateLimiter.OAuthCallback()).Get("/{provider}/callback",
 					callback.New(log,
 						oauthService,
 						allowedRedirectHosts,
@@ -378,7 +525,7 @@ func setupRouter(
 				// Authenticated — RequireAuth обязателен ДО rate limiter'ов,
 				// использующих byUserID (им нужен claims в контексте).
 				r.Group(func(r chi.Router) {
-					r.Use(claimsParser.RequireAuth(appProvider))
+					r.Use(claimsParser.RequireAuth(appProvider, cfg.Tokens.Leeway, accessTokens))
 
 					r.Get("/accounts",
 						accounts.New(log, oauthService),
@@ -403,6 +550,7 @@ func setupRouter(
 						log,
 						validate,
 						authService,
+						refreshCookies,
 						cfg.HTTPServer.HandlersTimeout,
 					),
 				)
@@ -410,14 +558,14 @@ func setupRouter(
 					resendMagicLink.New(
 						log,
 						validate,
-						authService,
+						authService.TwoFA,
 						cfg.HTTPServer.HandlersTimeout,
 					),
 				)
 
 				// Authenticated — требуют access-токен.
 				r.Group(func(r chi.Router) {
-					r.Use(claimsParser.RequireAuth(appProvider))
+					r.Use(claimsParser.RequireAuth(appProvider, cfg.Tokens.Leeway, accessTokens))
 
 					r.With(rateLimiter.MagicLinkEnable()).Post("/enable",
 						enable.New(log, authService, cfg.HTTPServer.HandlersTimeout),
@@ -428,16 +576,40 @@ func setupRouter(
 					r.With(rateLimiter.Disable2FARequestConfirmation()).Post("/disable/request-confirmation",
 						requestAction.NewDisable2FA(
 							log,
-							authService,
+							authService.TwoFA,
 							cfg.HTTPServer.HandlersTimeout,
 							cfg.TwoFactorAuth.PendingSessionTTL,
 						),
 					)
 				})
 			})
+
+			r.Route("/2fa/totp", func(r chi.Router) {
+				r.With(rateLimiter.TOTPVerify()).Post("/verify",
+					totpHandler.NewVerify(log, validate, authService, refreshCookies, cfg.HTTPServer.HandlersTimeout),
+				)
+
+				// Authenticated — требуют access-токен.
+				r.Group(func(r chi.Router) {
+					r.Use(claimsParser.RequireAuth(appProvider, cfg.Tokens.Leeway, accessTokens))
+					r.Use(rateLimiter.TOTPManage())
+
+					r.Post("/enroll",
+						totpHandler.NewEnroll(log, authService, cfg.HTTPServer.HandlersTimeout),
+					)
+					r.Post("/confirm",
+						totpHandler.NewConfirm(log, validate, authService, cfg.HTTPServer.HandlersTimeout),
+					)
+					r.Post("/disable",
+						totpHandler.NewDisable(log, validate, authService, cfg.HTTPServer.HandlersTimeout),
+					)
+				})
+			})
 		})
 
 		r.Route("/account", func(r chi.Router) {
+			r.Use(guard.Writes())
+
 			// Публичные эндпоинты — юзер soft-deleted, не может пройти
 			// RequireAuth (Login блокирует его до восстановления).
 			r.With(rateLimiter.AccountRestoreRequestConfirmation()).Post("/restore/request-confirmation",
@@ -452,52 +624,334 @@ func setupRouter(
 			r.With(rateLimiter.AccountRestore()).Post("/restore",
 				restore.New(log, validate, authService, cfg.HTTPServer.HandlersTimeout),
 			)
+			// ссылка из письма на новый адрес — открывается без access-токена
+			r.With(guard.All(), rateLimiter.EmailChangeConfirm()).Get("/email/confirm",
+				changeEmail.NewConfirm(
+					log,
+					authService,
+					msgBroker,
+					verifyCodes,
+					cfg.Tokens.VerificationTokenTTL,
+					cfg.Tokens.VerificationTokenSecret,
+					cfg.HTTPServer.PublicBaseURL,
+					cfg.HTTPServer.HandlersTimeout,
+				),
+			)
 
 			// Authenticated — требуют access-токен.
 			r.Group(func(r chi.Router) {
-				r.Use(claimsParser.RequireAuth(appProvider))
+				r.Use(claimsParser.RequireAuth(appProvider, cfg.Tokens.Leeway, accessTokens))
 
 				r.With(rateLimiter.AccountDeleteRequestConfirmation()).Post("/delete/request-confirmation",
 					requestAction.NewDeleteAccount(
 						log,
-						authService,
+						authService.TwoFA,
 						cfg.HTTPServer.HandlersTimeout,
 						cfg.TwoFactorAuth.PendingSessionTTL,
 					),
 				)
 				r.With(rateLimiter.AccountDelete()).Delete("/",
-					deleteAccount.New(log, validate, authService, cfg.HTTPServer.HandlersTimeout),
+					deleteAccount.New(log, validate, authService, msgBroker, cfg.HTTPServer.HandlersTimeout),
+				)
+				r.Get("/sessions",
+					sessions.New(log, authService, cfg.HTTPServer.HandlersTimeout),
+				)
+				r.With(rateLimiter.EmailChange()).Post("/email",
+					changeEmail.NewRequest(
+						log,
+						validate,
+						authService,
+						msgBroker,
+						cfg.HTTPServer.PublicBaseURL,
+						cfg.HTTPServer.HandlersTimeout,
+					),
 				)
 			})
 		})
+
+		r.With(rateLimiter.Introspect()).Post("/token/introspect",
+			introspect.New(log, appProvider, cfg.Tokens.Leeway, accessTokens),
+		)
+		r.With(guard.Writes(), rateLimiter.TokenExchange()).Post("/token/exchange",
+			tokenExchange.New(log, authService, appProvider, cfg.Tokens.Leeway, accessTokens, cfg.HTTPServer.HandlersTimeout),
+		)
+		r.With(rateLimiter.APIKeys(), apiKeyAuth.New(log, apiKeys)).Get("/api-keys/self", apiKeySelf.New())
+
+		r.Route("/me", func(r chi.Router) {
+			r.Use(claimsParser.RequireAuth(appProvider, cfg.Tokens.Leeway, accessTokens))
+
+			r.With(guard.Writes(), rateLimiter.AccountDelete()).Delete("/",
+				deleteAccount.New(log, validate, authService, msgBroker, cfg.HTTPServer.HandlersTimeout),
+			)
+			r.Get("/activity",
+				activity.New(log, authService, cfg.HTTPServer.HandlersTimeout),
+			)
+			r.Get("/2fa",
+				twoFactor.NewGet(log, authService, cfg.HTTPServer.HandlersTimeout),
+			)
+			r.With(guard.Writes(), rateLimiter.MeTwoFA()).Put("/2fa",
+				twoFactor.NewUpdate(log, validate, authService, cfg.HTTPServer.HandlersTimeout),
+			)
+			r.Get("/trusted-devices",
+				trustedDevices.NewList(log, trustedDeviceManager, cfg.HTTPServer.HandlersTimeout),
+			)
+			r.With(guard.Writes()).Delete("/trusted-devices",
+				trustedDevices.NewRevokeAll(log, trustedDeviceManager, cfg.HTTPServer.HandlersTimeout),
+			)
+			r.With(guard.Writes()).Delete("/trusted-devices/{id}",
+				trustedDevices.NewRevoke(log, trustedDeviceManager, cfg.HTTPServer.HandlersTimeout),
+			)
+			r.Get("/identities",
+				identities.NewList(log, identityService, cfg.HTTPServer.HandlersTimeout),
+			)
+			r.With(rateLimiter.OAuthUnlink()).Delete("/identities/{provider}",
+				identities.NewUnlink(log, identityService, cfg.HTTPServer.HandlersTimeout),
+			)
+		})
+
+		r.Route("/orgs", func(r chi.Router) {
+			r.Use(guard.Writes())
+
+			// Public — по токену из письма-приглашения, без входа.
+			r.With(rateLimiter.OrgInviteRespond()).Post("/invitations/decline",
+				orgInvitations.NewDecline(log, validate, organizations, cfg.HTTPServer.HandlersTimeout),
+			)
+			r.With(rateLimiter.OrgInviteRespond()).Post("/invitations/signup",
+				orgInvitations.NewSignUp(log, validate, organizations, cfg.HTTPServer.HandlersTimeout),
+			)
+
+			// Authenticated — требуют access-токен.
+			r.Group(func(r chi.Router) {
+				r.Use(claimsParser.RequireAuth(appProvider, cfg.Tokens.Leeway, accessTokens))
+
+				r.Get("/",
+					orgs.NewList(log, organizations, cfg.HTTPServer.HandlersTimeout),
+				)
+				r.Post("/",
+					orgs.NewCreate(log, validate, organizations, cfg.HTTPServer.HandlersTimeout),
+				)
+				r.With(rateLimiter.OrgInviteAccept()).Post("/invitations/accept",
+					orgInvitations.NewAccept(log, validate, organizations, cfg.HTTPServer.HandlersTimeout),
+				)
+
+				r.Route("/{id}", func(r chi.Router) {
+					r.With(rateLimiter.OrgInvite()).Post("/invitations",
+						orgInvitations.NewInvite(
+							log,
+							validate,
+							organizations,
+							msgBroker,
+							cfg.HTTPServer.PublicBaseURL,
+							cfg.HTTPServer.HandlersTimeout,
+						),
+					)
+					r.Get("/members",
+						orgMembers.NewList(log, organizations, cfg.HTTPServer.HandlersTimeout),
+					)
+					r.Put("/members/{userID}",
+						orgMembers.NewUpdate(log, validate, organizations, cfg.HTTPServer.HandlersTimeout),
+					)
+					r.Delete("/members/{userID}",
+						orgMembers.NewRemove(log, organizations, cfg.HTTPServer.HandlersTimeout),
+					)
+				})
+			})
+		})
+
+		if cfg.OIDC.Enabled {
+			r.Route("/oauth2", func(r chi.Router) {
+				r.Use(guard.Writes())
+
+				r.With(guard.All(), rateLimiter.OIDCAuthorize()).Get("/authorize",
+					oidcHandler.NewAuthorize(log, oidcProvider, cfg.OIDC.LoginURL, cfg.HTTPServer.HandlersTimeout),
+				)
+				r.With(rateLimiter.OIDCToken()).Post("/token",
+					oidcHandler.NewToken(log, oidcProvider, cfg.HTTPServer.HandlersTimeout),
+				)
+
+				// Authenticated — требуют access-токен.
+				r.Group(func(r chi.Router) {
+					r.Use(claimsParser.RequireAuth(appProvider, cfg.Tokens.Leeway, accessTokens))
+
+					r.With(rateLimiter.OIDCAuthorize()).Post("/authorize",
+						oidcHandler.NewApprove(log, oidcProvider, cfg.HTTPServer.HandlersTimeout),
+					)
+					r.Get("/userinfo",
+						oidcHandler.NewUserInfo(log, oidcProvider, cfg.HTTPServer.HandlersTimeout),
+					)
+				})
+			})
+		}
+
+		if cfg.GraphQL.Enabled {
+			// запросы и мутации приходят одним POST — блокируется весь /graphql
+			r.With(
+				guard.All(),
+				claimsParser.OptionalAuth(appProvider, cfg.Tokens.Leeway, accessTokens),
+				rateLimiter.GraphQL(),
+			).Post("/graphql",
+				graphqlHandler.New(
+					log,
+					validate,
+					authService,
+					rateLimiter,
+					cfg.TwoFactorAuth.PendingSessionTTL,
+					cfg.HTTPServer.HandlersTimeout,
+				),
+			)
+		}
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(adminAuth.New(cfg.Admin.Username, cfg.Admin.Password))
+
+			r.Post("/users/{id}/logout",
+				forceLogout.New(log, validate, authService, cfg.Admin.HandlersTimeout),
+			)
+			r.Post("/users/{id}/require-password-reset",
+				requirePasswordReset.New(log, validate, authService, cfg.Admin.HandlersTimeout),
+			)
+			r.Post("/users/{id}/verify-email",
+				adminVerifyEmail.New(log, validate, authService, cfg.Admin.HandlersTimeout),
+			)
+			r.Post("/users/{id}/status",
+				changeStatus.New(log, validate, authService, cfg.Admin.HandlersTimeout),
+			)
+			r.Get("/users/{id}/roles",
+				userRoles.NewList(log, rbacService, cfg.Admin.HandlersTimeout),
+			)
+			r.Post("/users/{id}/roles",
+				userRoles.NewAssign(log, validate, rbacService, cfg.Admin.HandlersTimeout),
+			)
+			r.Delete("/users/{id}/roles/{role}",
+				userRoles.NewRevoke(log, validate, rbacService, cfg.Admin.HandlersTimeout),
+			)
+
+			r.Get("/apps/{id}/roles",
+				adminRoles.NewList(log, rbacService, cfg.Admin.HandlersTimeout),
+			)
+			r.Post("/apps/{id}/roles",
+				adminRoles.NewCreate(log, validate, rbacService, cfg.Admin.HandlersTimeout),
+			)
+			r.Post("/apps/{id}/signing-keys/rotate",
+				rotateSigningKey.New(log, keyRotator, cfg.Admin.HandlersTimeout),
+			)
+			r.Get("/apps/{id}/api-keys",
+				adminAPIKeys.NewList(log, apiKeys, cfg.Admin.HandlersTimeout),
+			)
+			r.Post("/apps/{id}/api-keys",
+				adminAPIKeys.NewCreate(log, validate, apiKeys, cfg.Admin.HandlersTimeout),
+			)
+			r.Delete("/apps/{id}/api-keys/{key_id}",
+				adminAPIKeys.NewRevoke(log, apiKeys, cfg.Admin.HandlersTimeout),
+			)
+
+			r.Get("/ip-rules", ipRules.NewList(log, ipRuleFilter, cfg.Admin.HandlersTimeout))
+			r.Post("/ip-rules", ipRules.NewCreate(log, validate, ipRuleFilter, cfg.Admin.HandlersTimeout))
+			r.Delete("/ip-rules/{id}", ipRules.NewDelete(log, ipRuleFilter, cfg.Admin.HandlersTimeout))
+
+			r.Get("/maintenance", maintenanceHandler.NewGet(maintenanceMode))
+			r.Put("/maintenance",
+				maintenanceHandler.NewSchedule(log, validate, maintenanceMode, cfg.Admin.HandlersTimeout),
+			)
+			r.Delete("/maintenance",
+				maintenanceHandler.NewClear(log, maintenanceMode, cfg.Admin.HandlersTimeout),
+			)
+		})
 	})
 
 	return r
 }
 
-func setupLogger(env string) *slog.Logger {
+// setupLogger возвращает и LevelVar: уровень меняется при перечитывании
+// конфига без пересоздания логгера.
+func setupLogger(env, level string) (*slog.Logger, *slog.LevelVar) {
 	var log *slog.Logger
 
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(levelFor(env, level))
+	opts := &slog.HandlerOptions{Level: logLevel}
+
 	switch env {
 	case envLocal:
-		log = slog.New(
-			slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
-		)
-	case envDev:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
-		)
-	case envProd:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
-		)
+		log = slog.New(slog.NewTextHandler(os.Stdout, opts))
+	case envDev, envProd:
+		log = slog.New(slog.NewJSONHandler(os.Stdout, opts))
 	default:
-		log = slog.New(
-			slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		log = slog.New(slog.NewTextHandler(os.Stdout, opts))
+	}
+
+	return log, logLevel
+}
+
+// levelFor — явный log_level или уровень по умолчанию для env.
+func levelFor(env, level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "info":
+		return slog.LevelInfo
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+
+	if env == envProd {
+		return slog.LevelInfo
+	}
+
+	return slog.LevelDebug
+}
+
+// watchReload перечитывает конфиг по SIGHUP. Конфиг с ошибкой не
+// применяется целиком; изменения в секциях, требующих переподключения,
+// логируются и игнорируются, остальное передаётся в apply; apply
+// возвращает то, что применено на самом деле.
+func watchReload(
+	ctx context.Context,
+	log *slog.Logger,
+	running *config.Config,
+	apply func(config.Reloadable) config.Reloadable,
+) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		}
+
+		log.Info("reloading config")
+
+		loaded, err := config.Load("./config/config.yaml")
+		if err != nil {
+			log.Error("failed to reload config, keeping current", slog.String("err", err.Error()))
+			continue
+		}
+
+		for _, section := range config.RestartRequired(running, loaded) {
+			log.Warn("config change requires restart, ignored", slog.String("section", section))
+		}
+
+		running.SetReloadable(apply(loaded.Reloadable()))
+
+		log.Info("config reloaded",
+			slog.String("log_level", levelFor(running.Env, running.LogLevel).String()),
+			slog.Int("rate_limit_overrides", len(running.RateLimits.Overrides)),
 		)
 	}
+}
+
+func rateLimitOverrides(cfg config.RateLimits) map[string]rateLimit.Policy {
+	overrides := make(map[string]rateLimit.Policy, len(cfg.Overrides))
+	for key, p := range cfg.Overrides {
+		overrides[key] = rateLimit.Policy{Burst: p.Burst, Rate: p.Rate, Period: p.Period}
+	}
 
-	return log
+	return overrides
 }
 
 func allowedRedirectHostSet(allowedHosts []string) map[string]bool {
@@ -507,3 +961,23 @@ func allowedRedirectHostSet(allowedHosts []string) map[string]bool {
 	}
 	return set
 }
+
+// twoFANotifiers — каналы доставки magic link. Email есть всегда, SMS и
+// Telegram — если заданы их учётные данные.
+func twoFANotifiers(log *slog.Logger, cfg *config.Config, pub mailer.Publisher) map[models.TwoFAChannel]twoFactorAuth.Notifier {
+	notifiers := map[models.TwoFAChannel]twoFactorAuth.Notifier{
+		models.TwoFAChannelEmail: notifier.NewEmail(pub),
+	}
+
+	if sms := cfg.TwoFactorAuth.SMS; sms.AccountSID != "" {
+		notifiers[models.TwoFAChannelSMS] = notifier.NewTwilio(sms.AccountSID, sms.AuthToken, sms.From, sms.Timeout)
+		log.Info("2fa sms channel enabled")
+	}
+
+	if tg := cfg.TwoFactorAuth.Telegram; tg.BotToken != "" {
+		notifiers[models.TwoFAChannelTelegram] = notifier.NewTelegram(tg.BotToken, tg.Timeout)
+		log.Info("2fa telegram channel enabled")
+	}
+
+	return notifiers
+}
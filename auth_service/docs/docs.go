@@ -15,6 +15,63 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Публичные ключи, которыми подписаны access-токены приложений\nс RS256/ES256. Resource server выбирает ключ по kid из заголовка\nтокена и проверяет подпись без секрета приложения. Токены\nприложений с HS256 этими ключами не проверяются.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "JSON Web Key Set",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/jwks.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/.well-known/openid-configuration": {
+            "get": {
+                "description": "Метаданные провайдера: адреса эндпоинтов, JWKS и поддерживаемые\nпараметры. Стандартные OIDC-библиотеки настраиваются по issuer.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oidc"
+                ],
+                "summary": "OpenID Connect discovery",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/oidcHandler.Discovery"
+                        }
+                    }
+                }
+            }
+        },
         "/account": {
             "delete": {
                 "security": [
@@ -22,7 +79,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Помечает аккаунт как удалённый (soft delete, grace period 7\nдней). Требует подтверждения: паролем (если он установлен)\nлибо magic-link кодом, полученным через\n/account/delete/request-confirmation (для oauth-only\nпользователей без пароля). Все refresh-токены и активные\nсессии немедленно отзываются. Идемпотентно — повторный вызов\nна уже удалённый аккаунт не является ошибкой.",
+                "description": "Помечает аккаунт как удалённый (soft delete, status =\npending_deletion). Требует подтверждения: паролем (если он установлен)\nлибо magic-link кодом, полученным через\n/account/delete/request-confirmation (для oauth-only\nпользователей без пароля). Все refresh- и access-токены\nнемедленно отзываются, на email уходит письмо об удалении.\nАккаунт можно восстановить в течение retention.deleted_accounts\n(по умолчанию 7 дней), затем он удаляется безвозвратно.\nИдемпотентно — повторный вызов на уже удалённый аккаунт не\nявляется ошибкой. Доступен также как DELETE /me.",
                 "consumes": [
                     "application/json"
                 ],
@@ -40,7 +97,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_http_server_handlers_account_delete.Request"
+                            "$ref": "#/definitions/deleteAccount.Request"
                         }
                     }
                 ],
@@ -53,6 +110,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -67,6 +127,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -81,6 +144,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -95,6 +161,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -142,6 +211,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -156,6 +228,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -168,9 +243,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/account/restore": {
+        "/account/email": {
             "post": {
-                "description": "Отменяет soft-delete, если grace period (7 дней) ещё не\nистёк. Требует подтверждения: паролем (если он установлен)\nлибо magic-link кодом, полученным через\n/account/restore/request-confirmation (для oauth-only\nпользователей без пароля). Неаутентифицированный эндпоинт.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "## Описание\nОтправляет ссылку подтверждения на новый адрес и уведомление о запросе на\nтекущий. Email меняется только после перехода по ссылке из письма.\n\n### Особенности:\n- Требует текущий пароль, если он у аккаунта задан\n- Действует последний запрос: ссылки из предыдущих писем перестают работать\n- Ссылка живёт tokens.email_change_token_ttl",
                 "consumes": [
                     "application/json"
                 ],
@@ -180,27 +260,33 @@ const docTemplate = `{
                 "tags": [
                     "account"
                 ],
-                "summary": "Восстановить удалённый аккаунт",
+                "summary": "Запросить смену email",
                 "parameters": [
                     {
-                        "description": "Email + (пароль ИЛИ session_id+code)",
+                        "description": "Новый email и текущий пароль",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_http_server_handlers_account_restore.Request"
+                            "$ref": "#/definitions/changeEmail.Request"
                         }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Аккаунт восстановлен"
+                    "202": {
+                        "description": "Ссылка подтверждения отправлена на новый адрес",
+                        "schema": {
+                            "$ref": "#/definitions/changeEmail.RequestResponse"
+                        }
                     },
                     "400": {
-                        "description": "Невалидный запрос",
+                        "description": "Невалидный запрос или новый email совпадает с текущим",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -211,10 +297,47 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Неверный пароль или код подтверждения, аккаунт не найден, не был удалён или grace period истёк",
+                        "description": "Access token отсутствует/невалиден или неверный пароль",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Email уже занят другим аккаунтом",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "410": {
+                        "description": "Аккаунт удалён",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -229,6 +352,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -243,6 +369,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -255,37 +384,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/account/restore/request-confirmation": {
-            "post": {
-                "description": "Отправляет magic-link код на email указанного (soft-deleted)\nаккаунта для подтверждения восстановления. Неаутентифицированный\nэндпоинт — юзер не может залогиниться, пока аккаунт удалён.\nВозвращает session_id для последующего запроса в /account/restore.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/account/email/confirm": {
+            "get": {
+                "description": "## Описание\nПереход по ссылке из письма, отправленного на новый адрес. Email меняется\nатомарно; новый адрес требует верификации — на него сразу уходит письмо со\nссылкой /auth/verify, до подтверждения вход закрыт.\n\nТокен одноразовый. Если email изменился после запроса, токен отклоняется.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "account"
                 ],
-                "summary": "Запросить подтверждение восстановления аккаунта через magic link",
+                "summary": "Подтвердить смену email",
                 "parameters": [
                     {
-                        "description": "Email и app_id",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/internal_http_server_handlers_account_request_restore_confirmation.Request"
-                        }
+                        "type": "string",
+                        "description": "Токен подтверждения из письма",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Код отправлен на email",
+                        "description": "Email изменён",
+                        "schema": {
+                            "$ref": "#/definitions/changeEmail.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Токен отсутствует в URL",
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "session_id": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
                                     "type": "string"
                                 },
                                 "status": {
@@ -294,11 +427,14 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "Невалидный запрос",
+                    "401": {
+                        "description": "Токен невалиден, истёк или уже использован",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -308,11 +444,14 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "429": {
-                        "description": "Превышен лимит запросов",
+                    "409": {
+                        "description": "Email успели занять другим аккаунтом",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -327,6 +466,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -339,28 +481,43 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/2fa/disable/request-confirmation": {
+        "/account/restore": {
             "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
+                "description": "Отменяет soft-delete, если grace period (retention.deleted_accounts) ещё не\nистёк. Требует подтверждения: паролем (если он установлен)\nлибо magic-link кодом, полученным через\n/account/restore/request-confirmation (для oauth-only\nпользователей без пароля). Неаутентифицированный эндпоинт.",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "Отправляет magic-link код на email текущего пользователя для\nподтверждения отключения 2FA. Требуется только для\noauth-only пользователей без пароля — у них нет иного\nспособа подтвердить чувствительное действие. Возвращает\nsession_id, который затем передаётся вместе с кодом из письма\nв /auth/2fa/disable.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "2fa"
+                    "account"
+                ],
+                "summary": "Восстановить удалённый аккаунт",
+                "parameters": [
+                    {
+                        "description": "Email + (пароль ИЛИ session_id+code)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/restore.Request"
+                        }
+                    }
                 ],
-                "summary": "Запросить подтверждение отключения 2FA через magic link",
                 "responses": {
-                    "200": {
-                        "description": "Код отправлен на email",
+                    "204": {
+                        "description": "Аккаунт восстановлен"
+                    },
+                    "400": {
+                        "description": "Невалидный запрос",
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "session_id": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
                                     "type": "string"
                                 },
                                 "status": {
@@ -370,10 +527,30 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Access token отсутствует, невалиден или истёк",
+                        "description": "Неверный пароль или код подтверждения, аккаунт не найден, не был удалён или grace period истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -388,6 +565,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -400,14 +580,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/2fa/magic-link/disable": {
+        "/account/restore/request-confirmation": {
             "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Отключает magic-link 2FA. Подтверждение зависит от того, есть\nли у пользователя пароль: если да — передаётся password; если\nнет (oauth-only аккаунт) — передаются session_id и token,\nполученные через /auth/2fa/magic-link/request-action-confirmation.",
+                "description": "Отправляет magic-link код на email указанного (soft-deleted)\nаккаунта для подтверждения восстановления. Неаутентифицированный\nэндпоинт — юзер не может залогиниться, пока аккаунт удалён.\nВозвращает session_id для последующего запроса в /account/restore.",
                 "consumes": [
                     "application/json"
                 ],
@@ -415,47 +590,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "2fa"
+                    "account"
                 ],
-                "summary": "Отключить magic-link 2FA",
+                "summary": "Запросить подтверждение восстановления аккаунта через magic link",
                 "parameters": [
                     {
-                        "description": "Подтверждение отключения (один из наборов полей)",
+                        "description": "Email и app_id",
                         "name": "request",
                         "in": "body",
+                        "required": true,
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "password": {
-                                    "type": "string"
-                                },
-                                "session_id": {
-                                    "type": "string"
-                                },
-                                "token": {
-                                    "type": "string"
-                                }
-                            }
+                            "$ref": "#/definitions/requestRestoreConfirmation.Request"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "2FA отключена",
+                        "description": "Код отправлен на email",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "session_id": {
+                                    "type": "string"
+                                },
                                 "status": {
                                     "type": "string"
                                 }
                             }
                         }
                     },
-                    "401": {
-                        "description": "Access token отсутствует, невалиден или истёк, либо неверное подтверждение (пароль/magic-link код)",
+                    "400": {
+                        "description": "Невалидный запрос",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -465,11 +636,14 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "409": {
-                        "description": "2FA не включена",
+                    "429": {
+                        "description": "Превышен лимит запросов",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -484,6 +658,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -496,31 +673,26 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/2fa/magic-link/enable": {
-            "post": {
+        "/account/sessions": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Включает magic-link 2FA для текущего пользователя. Требует,\nчтобы у пользователя уже был рабочий фактор для будущего\nотключения (пароль или хотя бы один привязанный oauth-аккаунт) —\nиначе включение необратимо заблокирует доступ к аккаунту.",
+                "description": "Возвращает активные refresh-сессии текущего пользователя.\nДля сессий, открытых с device_id, показывается имя устройства;\nIP и User-Agent — логина, открывшего сессию.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "2fa"
+                    "account"
                 ],
-                "summary": "Включить magic-link 2FA",
+                "summary": "Список активных сессий",
                 "responses": {
                     "200": {
-                        "description": "2FA включена",
+                        "description": "Список сессий",
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "status": {
-                                    "type": "string"
-                                }
-                            }
+                            "$ref": "#/definitions/sessions.Response"
                         }
                     },
                     "401": {
@@ -528,20 +700,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "error": {
+                                "code": {
                                     "type": "string"
                                 },
-                                "status": {
-                                    "type": "string"
-                                }
-                            }
-                        }
-                    },
-                    "409": {
-                        "description": "2FA уже включена, либо нет ни одного доступного фактора для будущего disable",
-                        "schema": {
-                            "type": "object",
-                            "properties": {
                                 "error": {
                                     "type": "string"
                                 },
@@ -556,6 +717,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -568,52 +732,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/2fa/magic-link/resend": {
-            "post": {
-                "description": "Инвалидирует предыдущую активную ссылку и высылает новую в\nрамках той же pending-сессии, начатой на /auth/login. Не\nподтверждает и не раскрывает факт доставки письма — ответ\nодинаковый независимо от того, дошло письмо или нет.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/apps/{id}/api-keys": {
+            "get": {
+                "description": "Возвращает ключи приложения, включая отозванные и истёкшие, новые первыми.\nСами ключи не возвращаются.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "2fa"
+                    "admin"
                 ],
-                "summary": "Повторно отправить magic-link",
+                "summary": "API-ключи приложения",
                 "parameters": [
                     {
-                        "description": "Идентификатор pending-сессии",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "session_id": {
-                                    "type": "string"
-                                }
-                            }
-                        }
+                        "type": "integer",
+                        "description": "ID приложения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Новая ссылка отправлена (либо попытка предпринята)",
+                        "description": "Ключи приложения",
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "status": {
-                                    "type": "string"
-                                }
-                            }
+                            "$ref": "#/definitions/apiKeys.ListResponse"
                         }
                     },
                     "400": {
-                        "description": "Невалидное тело запроса",
+                        "description": "Некорректный ID приложения",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -624,24 +776,13 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Pending-сессия не найдена или истекла — нужно начать логин заново",
+                        "description": "Неверные credentials администратора",
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "error": {
+                                "code": {
                                     "type": "string"
                                 },
-                                "status": {
-                                    "type": "string"
-                                }
-                            }
-                        }
-                    },
-                    "429": {
-                        "description": "Слишком частые запросы на повторную отправку",
-                        "schema": {
-                            "type": "object",
-                            "properties": {
                                 "error": {
                                     "type": "string"
                                 },
@@ -656,6 +797,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -666,11 +810,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/auth/2fa/magic-link/verify": {
+            },
             "post": {
-                "description": "Завершает второй фактор аутентификации: проверяет токен из\nписьма в связке с session_id, полученным на этапе /auth/login,\nи при успехе выдаёт access/refresh токены. Токен одноразовый —\nповторное использование того же токена или session_id отклоняется.",
+                "description": "## Описание\nВыпускает долгоживущий ключ для машинного клиента (batch-задачи), который не может\nпройти интерактивный логин. Ключ передаётся в заголовке ` + "`" + `X-API-Key` + "`" + `.\n\n### Особенности:\n- Ключ возвращается один раз — сохраняется только его хеш\n- scopes — подмножество allowed_scopes приложения\n- Без expires_at ключ бессрочный; отозвать его можно в любой момент",
                 "consumes": [
                     "application/json"
                 ],
@@ -678,38 +820,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "2fa"
+                    "admin"
                 ],
-                "summary": "Подтверждение magic-link 2FA",
+                "summary": "Выпуск API-ключа приложения",
                 "parameters": [
                     {
-                        "description": "Данные для подтверждения",
+                        "type": "integer",
+                        "description": "ID приложения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Ключ",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "session_id": {
-                                    "type": "string"
-                                },
-                                "token": {
-                                    "type": "string"
-                                }
-                            }
+                            "$ref": "#/definitions/apiKeys.Request"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "2FA подтверждена, выданы токены",
+                    "201": {
+                        "description": "Ключ выпущен",
+                        "schema": {
+                            "$ref": "#/definitions/apiKeys.CreateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID приложения, тело запроса или scope",
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "access_token": {
+                                "code": {
                                     "type": "string"
                                 },
-                                "refresh_token": {
+                                "error": {
                                     "type": "string"
                                 },
                                 "status": {
@@ -718,11 +865,14 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "Невалидное тело запроса или ошибка валидации",
+                    "401": {
+                        "description": "Неверные credentials администратора",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -732,11 +882,14 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "Токен невалиден, истёк, уже использован, либо сессия истекла",
+                    "404": {
+                        "description": "Приложение не найдено",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -751,6 +904,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -763,64 +919,52 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/login": {
-            "post": {
-                "description": "## Описание\nВыполняет аутентификацию пользователя по email и паролю. Если\nу пользователя включена magic-link 2FA, вместо токенов\nвозвращается session_id для подтверждения через\n/auth/2fa/magic-link/verify; access/refresh в этом случае не выдаются.\n\n### Процесс аутентификации:\n1. Валидация входных данных (email формат, наличие пароля)\n2. Проверка существования пользователя в базе данных\n3. Верификация пароля (bcrypt hash comparison)\n4. Проверка статуса email (должен быть подтвержден)\n5. Валидация app_id (приложение должно существовать)\n6. Проверка статуса 2FA:\n- если выключена — генерация JWT токенов (access и refresh)\n- если включена — создание pending-сессии, отправка magic link на email, возврат session_id без токенов\n\n### Токены:\n- **Access Token**: JWT токен для доступа к защищенным ресурсам (TTL: 15 минут)\n- **Refresh Token**: JWT токен для обновления access токена (TTL: 30 дней)\n- **Session ID** (при включённой 2FA): используется для подтверждения через /auth/2fa/magic-link/verify, не является токеном доступа\n\n### Коды ошибок:\n- ` + "`" + `400` + "`" + ` - Некорректные данные (невалидный email, отсутствие полей, невалидный app_id)\n- ` + "`" + `401` + "`" + ` - Неверные credentials (пароль не совпадает; используется и для несуществующего email — не различается намеренно, во избежание user enumeration)\n- ` + "`" + `403` + "`" + ` - Email не подтвержден\n- ` + "`" + `500` + "`" + ` - Внутренняя ошибка сервера",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/apps/{id}/api-keys/{key_id}": {
+            "delete": {
+                "description": "Отзывает ключ приложения. Запросы с ним отклоняются сразу.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Аутентификация пользователя",
+                "summary": "Отзыв API-ключа",
                 "parameters": [
                     {
-                        "description": "Данные для входа",
-                        "name": "credentials",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "app_id": {
-                                    "type": "integer"
-                                },
-                                "email": {
-                                    "type": "string"
-                                },
-                                "password": {
-                                    "type": "string"
-                                }
-                            }
-                        }
+                        "type": "integer",
+                        "description": "ID приложения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID ключа",
+                        "name": "key_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Пароль верен, требуется подтверждение magic-link 2FA",
+                        "description": "Ключ отозван",
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "session_id": {
-                                    "type": "string"
-                                },
                                 "status": {
                                     "type": "string"
-                                },
-                                "two_factor_pending": {
-                                    "type": "boolean"
                                 }
                             }
                         }
                     },
                     "400": {
-                        "description": "Ошибка валидации или невалидный app_id",
+                        "description": "Некорректный ID приложения или ключа",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -831,10 +975,13 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Неверные credentials",
+                        "description": "Неверные credentials администратора",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -844,11 +991,14 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "403": {
-                        "description": "Email не подтвержден",
+                    "404": {
+                        "description": "Ключ не найден или уже отозван",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -859,10 +1009,13 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Внутренняя ошибка",
+                        "description": "Внутренняя ошибка сервера",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -872,56 +1025,43 @@ const docTemplate = `{
                             }
                         }
                     }
-                },
-                "x-order": 1
+                }
             }
         },
-        "/auth/logout": {
-            "post": {
-                "description": "## Описание\nЗавершает активную сессию пользователя, инвалидируя refresh токен.\n\n### Процесс выхода:\n1. Валидация refresh токена из тела запроса\n2. Проверка существования токена в базе данных\n3. Добавление токена в blacklist (Redis)\n4. Удаление токена из таблицы активных сессий\n5. Инвалидация связанного access токена\n\n### Особенности:\n- После logout refresh токен больше нельзя использовать для получения новых access токенов\n- Access токен технически остается валидным до истечения TTL (~15 минут)\n- Для немедленной инвалидации access токена используется blacklist в Redis\n- Поддержка \"logout from all devices\" (опционально)\n\n### Безопасность:\n- Токен в blacklist хранится только до истечения его TTL\n- При попытке использовать инвалидированный токен возвращается 401\n- Логирование всех операций logout для аудита",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/apps/{id}/roles": {
+            "get": {
+                "description": "Возвращает все роли приложения с их правами, по имени.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Выход из системы",
+                "summary": "Роли приложения",
                 "parameters": [
                     {
-                        "description": "Refresh токен для инвалидации",
-                        "name": "token",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "refresh_token": {
-                                    "type": "string"
-                                }
-                            }
-                        }
+                        "type": "integer",
+                        "description": "ID приложения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешный выход из системы",
+                        "description": "Роли приложения",
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "status": {
-                                    "type": "string"
-                                }
-                            }
+                            "$ref": "#/definitions/roles.ListResponse"
                         }
                     },
                     "400": {
-                        "description": "Ошибка валидации: токен не передан или некорректный JSON",
+                        "description": "Некорректный ID приложения",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -932,10 +1072,13 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Невалидный или истекший refresh токен",
+                        "description": "Неверные credentials администратора",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -950,6 +1093,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -959,37 +1105,53 @@ const docTemplate = `{
                             }
                         }
                     }
-                },
-                "x-order": 4
-            }
-        },
-        "/auth/oauth/accounts": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
+                }
+            },
+            "post": {
+                "description": "## Описание\nЗаводит роль с набором прав. Роли пользователя и объединение их прав попадают\nв claims roles/permissions access-токенов этого приложения.\n\n### Особенности:\n- Имя роли уникально в пределах приложения\n- Требует basic auth администратора",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "Возвращает все OAuth-провайдеры, привязанные к аккаунту\nтекущего аутентифицированного пользователя.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "oauth"
+                    "admin"
+                ],
+                "summary": "Создание роли приложения",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID приложения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Роль",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/roles.Request"
+                        }
+                    }
                 ],
-                "summary": "Список привязанных OAuth-аккаунтов",
                 "responses": {
-                    "200": {
-                        "description": "Список привязанных аккаунтов",
+                    "201": {
+                        "description": "Роль создана",
                         "schema": {
-                            "$ref": "#/definitions/internal_http_server_handlers_oauth_accounts.Response"
+                            "$ref": "#/definitions/roles.Response"
                         }
                     },
-                    "401": {
-                        "description": "Access token отсутствует, невалиден или истёк",
+                    "400": {
+                        "description": "Некорректный ID приложения или тело запроса",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -999,11 +1161,14 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
+                    "401": {
+                        "description": "Неверные credentials администратора",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1012,54 +1177,15 @@ const docTemplate = `{
                                 }
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/auth/oauth/{provider}": {
-            "delete": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Удаляет связь между указанным OAuth-провайдером и аккаунтом\nтекущего аутентифицированного пользователя.\nЕсли этот способ входа является последним доступным методом\nаутентификации, операция будет отклонена, чтобы предотвратить\nпотерю доступа к аккаунту.",
-                "tags": [
-                    "oauth"
-                ],
-                "summary": "Отвязка OAuth-провайдера от аккаунта",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Название OAuth-провайдера (например: google, github)",
-                        "name": "provider",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "204": {
-                        "description": "OAuth-провайдер успешно отвязан"
                     },
-                    "401": {
-                        "description": "Access-токен отсутствует, недействителен или истёк",
+                    "404": {
+                        "description": "Приложение не найдено",
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "error": {
+                                "code": {
                                     "type": "string"
                                 },
-                                "status": {
-                                    "type": "string"
-                                }
-                            }
-                        }
-                    },
-                    "403": {
-                        "description": "Нельзя отвязать последний доступный способ аутентификации",
-                        "schema": {
-                            "type": "object",
-                            "properties": {
                                 "error": {
                                     "type": "string"
                                 },
@@ -1069,11 +1195,14 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "У пользователя отсутствует привязка к указанному OAuth-провайдеру",
+                    "409": {
+                        "description": "Роль с таким именем уже есть",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1088,6 +1217,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1100,57 +1232,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/oauth/{provider}/callback": {
-            "get": {
-                "description": "Обрабатывает перенаправление от OAuth-провайдера после того, как пользователь предоставил или отклонил доступ.\nПроверяет параметр state на соответствие ранее сохранённому значению (авторизация или привязка аккаунта),\nобменивает код авторизации на токены провайдера и в зависимости от типа операции\nлибо выдает новую пару access и refresh токенов,\nлибо привязывает OAuth-провайдера к существующему аккаунту.",
+        "/admin/apps/{id}/signing-keys/rotate": {
+            "post": {
+                "description": "## Описание\nВыпускает новый ключ подписи access-токенов приложения с его текущим\nалгоритмом (apps.signing_alg) и выводит прежний.\n\n### Особенности:\n- Новые токены подписываются новым ключом сразу на всех репликах\n- Выведенный ключ принимается при проверке до конца grace-периода\n(не меньше TTL access-токена), для RS256/ES256 остаётся в JWKS\n- HS256-приложение первой ротацией переводится с секрета приложения\nна управляемые ключи; токены на старом секрете продолжают проверяться\n- Плановая ротация выполняется и без вызова — раз в signing_keys.rotation_interval",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "oauth"
+                    "admin"
                 ],
-                "summary": "Callback OAuth-провайдера",
+                "summary": "Ротация ключа подписи приложения",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Название OAuth-провайдера (например: google, github)",
-                        "name": "provider",
+                        "type": "integer",
+                        "description": "ID приложения",
+                        "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Код авторизации, полученный от OAuth-провайдера",
-                        "name": "code",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Токен состояния (state), должен совпадать со значением, выданным при начале авторизации или привязки аккаунта",
-                        "name": "state",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Код ошибки, возвращаемый OAuth-провайдером, если пользователь отказал в доступе",
-                        "name": "error",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешная авторизация или привязка аккаунта",
+                        "description": "Ключ ротирован",
                         "schema": {
-                            "$ref": "#/definitions/internal_http_server_handlers_oauth_callback.Response"
+                            "$ref": "#/definitions/rotateSigningKey.Response"
                         }
                     },
                     "400": {
-                        "description": "Пользователь отказал в доступе, отсутствуют параметры code/state, указан некорректный app_id либо state недействителен или истёк",
+                        "description": "Некорректный ID приложения",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1160,11 +1275,14 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "403": {
-                        "description": "Email, полученный от OAuth-провайдера, не подтверждён",
+                    "401": {
+                        "description": "Неверные credentials администратора",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1175,10 +1293,13 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Указанный OAuth-провайдер не поддерживается",
+                        "description": "Приложение не найдено",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1189,10 +1310,13 @@ const docTemplate = `{
                         }
                     },
                     "409": {
-                        "description": "Конфликт данных: аккаунт с таким email уже существует либо OAuth-провайдер уже привязан к другому аккаунту",
+                        "description": "Ключ одновременно ротирован другим запросом",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1207,6 +1331,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1219,77 +1346,31 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/oauth/{provider}/link": {
+        "/admin/ip-rules": {
             "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Запускает OAuth2-процесс привязки стороннего OAuth-провайдера к аккаунту\nтекущего аутентифицированного пользователя.\nТребует действительный access-токен. Идентификатор пользователя\nизвлекается из его данных, а не из параметров запроса,\nпоэтому привязка всегда выполняется для текущей сессии.",
+                "description": "Возвращает действующие правила, включая временные блокировки детектора\nзлоупотреблений (created_by = system:abuse). Самые узкие сети первыми.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "oauth"
-                ],
-                "summary": "Привязка OAuth-провайдера к существующему аккаунту",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Название OAuth-провайдера (например: google, github)",
-                        "name": "provider",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "URL, на который будет выполнено перенаправление после завершения авторизации. Должен входить в список разрешённых адресов.",
-                        "name": "redirect_uri",
-                        "in": "query",
-                        "required": true
-                    }
+                    "admin"
                 ],
+                "summary": "Правила доступа по IP",
                 "responses": {
                     "200": {
-                        "description": "Ссылка для перехода к OAuth-провайдеру",
-                        "schema": {
-                            "$ref": "#/definitions/internal_http_server_handlers_oauth_link.Response"
-                        }
-                    },
-                    "400": {
-                        "description": "Некорректный redirect_uri или app_id",
+                        "description": "Действующие правила",
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "error": {
-                                    "type": "string"
-                                },
-                                "status": {
-                                    "type": "string"
-                                }
-                            }
+                            "$ref": "#/definitions/ipRules.ListResponse"
                         }
                     },
                     "401": {
-                        "description": "Access-токен отсутствует, недействителен или истёк",
+                        "description": "Неверные credentials администратора",
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "error": {
+                                "code": {
                                     "type": "string"
                                 },
-                                "status": {
-                                    "type": "string"
-                                }
-                            }
-                        }
-                    },
-                    "404": {
-                        "description": "OAuth-провайдер не поддерживается",
-                        "schema": {
-                            "type": "object",
-                            "properties": {
                                 "error": {
                                     "type": "string"
                                 },
@@ -1304,6 +1385,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1314,50 +1398,45 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/auth/oauth/{provider}/login": {
-            "get": {
-                "description": "Запускает процесс OAuth2-авторизации для указанного OAuth-провайдера.\nПроверяет корректность app_id и redirect_uri, а также соответствие\nredirect_uri списку разрешённых адресов.\nПосле успешной проверки формирует параметр state и перенаправляет\nпользователя на страницу авторизации OAuth-провайдера.",
+            },
+            "post": {
+                "description": "## Описание\nДобавляет правило для сети: deny блокирует запросы с неё (403), allow освобождает\nеё от блокировок, в том числе автоматических.\n\n### Особенности:\n- При пересечении сетей действует правило с самой узкой\n- На одну сеть — одно правило; чтобы поменять действие, удалите старое\n- Без expires_at правило бессрочное\n- Реплики применяют изменение в течение ip_filter.local_cache_ttl",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "oauth"
+                    "admin"
                 ],
-                "summary": "Начало OAuth-авторизации",
+                "summary": "Правило доступа по IP",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Название OAuth-провайдера (например: google, github)",
-                        "name": "provider",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Идентификатор клиентского приложения",
-                        "name": "app_id",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "URL для перенаправления после завершения авторизации. Должен входить в список разрешённых адресов.",
-                        "name": "redirect_uri",
-                        "in": "query",
-                        "required": true
+                        "description": "Правило",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ipRules.Request"
+                        }
                     }
                 ],
                 "responses": {
-                    "302": {
-                        "description": "Перенаправление на страницу авторизации OAuth-провайдера"
+                    "201": {
+                        "description": "Правило добавлено",
+                        "schema": {
+                            "$ref": "#/definitions/ipRules.CreateResponse"
+                        }
                     },
                     "400": {
-                        "description": "Некорректный app_id или redirect_uri не прошёл проверку",
+                        "description": "Некорректное тело запроса, CIDR или expires_at",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1367,11 +1446,31 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "OAuth-провайдер не поддерживается",
+                    "401": {
+                        "description": "Неверные credentials администратора",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Для этой сети правило уже есть",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1382,10 +1481,13 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Не удалось сформировать URL для авторизации",
+                        "description": "Внутренняя ошибка сервера",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1398,38 +1500,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/password/forgot": {
-            "post": {
-                "description": "Запускает процесс сброса пароля для указанного адреса электронной почты.\nНезависимо от того, существует ли аккаунт с указанным email,\nвсегда возвращает ответ с кодом 200, чтобы исключить возможность\nопределения существования аккаунтов.\nЕсли аккаунт существует, на указанный email будет отправлено\nписьмо со ссылкой для сброса пароля. Ошибки отправки письма\nфиксируются на стороне сервера и не влияют на ответ API.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/ip-rules/{id}": {
+            "delete": {
+                "description": "Удаляет правило, в том числе временную блокировку детектора злоупотреблений.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Запрос на сброс пароля",
+                "summary": "Удаление правила доступа по IP",
                 "parameters": [
                     {
-                        "description": "Адрес электронной почты пользователя",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "email": {
-                                    "type": "string"
-                                }
-                            }
-                        }
+                        "type": "integer",
+                        "description": "ID правила",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Запрос успешно принят",
+                        "description": "Правило удалено",
                         "schema": {
                             "type": "object",
                             "properties": {
@@ -1440,10 +1532,13 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Некорректное тело запроса или ошибка валидации",
+                        "description": "Некорректный ID правила",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1453,11 +1548,14 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "429": {
-                        "description": "Превышен допустимый лимит запросов",
+                    "401": {
+                        "description": "Неверные credentials администратора",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1467,11 +1565,14 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
+                    "404": {
+                        "description": "Правило не найдено",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1480,73 +1581,52 @@ const docTemplate = `{
                                 }
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/auth/password/reset": {
-            "post": {
-                "description": "Сбрасывает пароль пользователя с использованием токена,\nполученного по электронной почте.\nТокен должен быть передан в теле запроса в формате\n\"selector.verifier\", где selector — UUID, а verifier —\nстрока в формате URL-safe Base64.\nТокен можно использовать только один раз. После успешного\nсброса пароля он становится недействительным.\nНовый пароль должен содержать не менее 8 символов и\nотличаться от текущего пароля.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "auth"
-                ],
-                "summary": "Сброс пароля",
-                "parameters": [
-                    {
-                        "description": "Токен для сброса пароля и новый пароль",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "password": {
+                                "code": {
                                     "type": "string"
                                 },
-                                "token": {
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
                                     "type": "string"
                                 }
                             }
                         }
                     }
+                }
+            }
+        },
+        "/admin/maintenance": {
+            "get": {
+                "description": "Возвращает, активен ли режим обслуживания, откуда он включён (config или redis)\nи окно из Redis, в том числе запланированное и ещё не начавшееся.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
                 ],
+                "summary": "Состояние режима обслуживания",
                 "responses": {
                     "200": {
-                        "description": "Пароль успешно сброшен",
+                        "description": "Состояние режима обслуживания",
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "status": {
-                                    "type": "string"
-                                }
-                            }
+                            "$ref": "#/definitions/maintenanceHandler.Response"
                         }
                     },
-                    "400": {
-                        "description": "Некорректный формат токена, токен недействителен, истёк или уже был использован, пароль не соответствует требованиям либо совпадает с текущим",
+                    "401": {
+                        "description": "Неверные credentials администратора",
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "error": {
+                                "code": {
                                     "type": "string"
                                 },
-                                "status": {
-                                    "type": "string"
-                                }
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
-                        "schema": {
-                            "type": "object",
-                            "properties": {
                                 "error": {
                                     "type": "string"
                                 },
@@ -1557,11 +1637,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/auth/refresh": {
-            "post": {
-                "description": "## Описание\nОбменивает действующий refresh токен на новую пару токенов (access и refresh).\n\n### Процесс обновления:\n1. Валидация refresh токена (подпись JWT, срок действия)\n2. Извлечение user_id и app_id из payload токена\n3. Проверка что токен не находится в blacklist (Redis)\n4. Проверка статуса пользователя (не заблокирован, email подтвержден)\n5. Генерация новой пары токенов:\n- Новый access токен (TTL: 15 минут)\n- Новый refresh токен (TTL: 30 дней)\n6. Инвалидация старого refresh токена (token rotation)\n7. Сохранение нового refresh токена в БД\n\n### Token Rotation (безопасность):\n- **Каждый refresh токен одноразовый** — после использования старый токен инвалидируется\n- Это защищает от атак с украденными токенами\n- При попытке использовать старый токен — все сессии пользователя инвалидируются\n\n### Время жизни токенов:\n- **Access Token**: 15 минут (короткий для безопасности)\n- **Refresh Token**: 30 дней (удобство для пользователя)\n- Если пользователь неактивен 30 дней — требуется повторный login\n\n### Когда использовать:\n- Access токен истек (получили 401 на защищенном endpoint)\n- Превентивное обновление перед истечением access токена\n- После длительного простоя приложения\n\n### Ошибки:\n- ` + "`" + `400` + "`" + `: Невалидный JSON или отсутствует refresh_token\n- ` + "`" + `401` + "`" + `: Токен истек, невалиден или уже использован\n- ` + "`" + `403` + "`" + `: Пользователь заблокирован\n- ` + "`" + `500` + "`" + `: Ошибка БД или генерации токенов",
+            },
+            "put": {
+                "description": "## Описание\nВключает режим обслуживания на всех репликах сразу или планирует окно на будущее.\n\n### Поведение в режиме обслуживания:\n- GET-эндпоинты продолжают работать\n- Выдача токенов и остальные изменяющие запросы получают 503 с Retry-After\n- /health отвечает 200 с maintenance.active = true\n- /admin/* не блокируется\n\nНовое окно заменяет ранее заданное. Окно с ends_at выключается само.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1569,19 +1647,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Обновление access токена",
+                "summary": "Включить режим обслуживания",
                 "parameters": [
                     {
-                        "description": "Текущий refresh токен",
-                        "name": "token",
+                        "description": "Окно обслуживания",
+                        "name": "body",
                         "in": "body",
-                        "required": true,
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "refresh_token": {
+                                "ends_at": {
+                                    "type": "string"
+                                },
+                                "reason": {
+                                    "type": "string"
+                                },
+                                "starts_at": {
                                     "type": "string"
                                 }
                             }
@@ -1590,27 +1673,19 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Новая пара токенов",
+                        "description": "Окно сохранено",
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "access_token": {
-                                    "type": "string"
-                                },
-                                "refresh_token": {
-                                    "type": "string"
-                                },
-                                "status": {
-                                    "type": "string"
-                                }
-                            }
+                            "$ref": "#/definitions/maintenanceHandler.Response"
                         }
                     },
                     "400": {
-                        "description": "Ошибка валидации",
+                        "description": "Некорректное тело или окно заканчивается раньше начала",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1621,10 +1696,13 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Невалидный или истекший токен",
+                        "description": "Неверные credentials администратора",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1635,10 +1713,13 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Внутренняя ошибка",
+                        "description": "Внутренняя ошибка сервера",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1648,79 +1729,32 @@ const docTemplate = `{
                             }
                         }
                     }
-                },
-                "x-order": 3
-            }
-        },
-        "/auth/register": {
-            "post": {
-                "description": "## Описание\nСоздает новую учетную запись пользователя в системе и отправляет письмо с подтверждением email.\n\n### Процесс регистрации:\n1. Валидация входных данных (email формат, наличие username и пароля)\n2. Проверка уникальности email и username в базе данных\n3. Хеширование пароля с использованием bcrypt (cost factor 12)\n4. Создание записи пользователя в БД со статусом ` + "`" + `email_verified = false` + "`" + `\n5. Генерация JWT токена верификации (валиден 24 часа)\n6. Отправка email с ссылкой подтверждения через RabbitMQ\n\n### Требования к данным:\n- **Email**: Валидный email формат (example@domain.com), должен быть уникальным\n- **Username**: Минимум 3 символа, только буквы, цифры и подчеркивание, должен быть уникальным\n- **Password**: Минимум 8 символов, рекомендуется использовать заглавные буквы, цифры и спецсимволы\n\n### Email верификация:\n- Письмо отправляется асинхронно через RabbitMQ (не блокирует ответ)\n- Токен верификации действует 24 часа\n- До подтверждения email пользователь не может войти в систему\n- Неподтвержденные аккаунты автоматически удаляются через 7 дней\n\n### Формат ссылки верификации:\n` + "`" + `http://domain.com/auth/verify?token=eyJhbGc...` + "`" + `",
-                "consumes": [
-                    "application/json"
-                ],
+                }
+            },
+            "delete": {
+                "description": "Удаляет окно обслуживания из Redis. Режим, включённый через конфиг\n(MAINTENANCE_ENABLED), снимается только рестартом.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
-                ],
-                "summary": "Регистрация нового пользователя",
-                "parameters": [
-                    {
-                        "description": "Данные нового пользователя",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "email": {
-                                    "type": "string"
-                                },
-                                "password": {
-                                    "type": "string"
-                                },
-                                "username": {
-                                    "type": "string"
-                                }
-                            }
-                        }
-                    }
+                    "admin"
                 ],
+                "summary": "Выключить режим обслуживания",
                 "responses": {
-                    "201": {
-                        "description": "Пользователь успешно создан, письмо отправлено",
+                    "200": {
+                        "description": "Окно удалено",
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "status": {
-                                    "type": "string"
-                                },
-                                "user_id": {
-                                    "type": "integer"
-                                }
-                            }
+                            "$ref": "#/definitions/maintenanceHandler.Response"
                         }
                     },
-                    "400": {
-                        "description": "Ошибка валидации: некорректный email, слишком короткий пароль или отсутствуют обязательные поля",
+                    "401": {
+                        "description": "Неверные credentials администратора",
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "error": {
+                                "code": {
                                     "type": "string"
                                 },
-                                "status": {
-                                    "type": "string"
-                                }
-                            }
-                        }
-                    },
-                    "409": {
-                        "description": "Пользователь с таким email или username уже существует",
-                        "schema": {
-                            "type": "object",
-                            "properties": {
                                 "error": {
                                     "type": "string"
                                 },
@@ -1731,10 +1765,13 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Внутренняя ошибка: проблемы с БД, RabbitMQ или email сервисом",
+                        "description": "Внутренняя ошибка сервера",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1744,13 +1781,12 @@ const docTemplate = `{
                             }
                         }
                     }
-                },
-                "x-order": 2
+                }
             }
         },
-        "/auth/verify": {
-            "get": {
-                "description": "## Описание\nПодтверждает email адрес пользователя по токену из письма, активируя учетную запись.\n\n### Процесс верификации:\n1. Извлечение токена из query параметра ` + "`" + `token` + "`" + `\n2. Валидация JWT токена (подпись, срок действия)\n3. Извлечение user_id из payload токена\n4. Проверка что пользователь существует и email еще не подтвержден\n5. Обновление статуса ` + "`" + `email_verified = true` + "`" + ` в базе данных\n6. Инвалидация токена (предотвращение повторного использования)\n\n### Особенности токена:\n- **Срок действия**: 24 часа с момента регистрации\n- **Формат**: JWT (JSON Web Token) подписанный HMAC-SHA256\n- **Одноразовый**: После успешной верификации токен инвалидируется\n- **Payload**: Содержит ` + "`" + `user_id` + "`" + ` и ` + "`" + `exp` + "`" + ` (время истечения)\n\n### После подтверждения:\n- Пользователь может войти в систему через ` + "`" + `/auth/login` + "`" + `\n- Email помечен как подтвержденный навсегда\n- Опционально: отправка welcome email\n\n### Ошибки:\n- ` + "`" + `400` + "`" + `: Токен отсутствует в URL\n- ` + "`" + `401` + "`" + `: Токен невалидный, истек или уже использован\n- ` + "`" + `500` + "`" + `: Ошибка базы данных",
+        "/admin/users/{id}/logout": {
+            "post": {
+                "description": "## Описание\nЗавершает все сессии пользователя во всех приложениях. Используется поддержкой,\nкогда захват аккаунта подтверждён.\n\n### Что происходит:\n1. Удаляются все refresh токены пользователя\n2. Все выданные и ещё не истёкшие access токены попадают в denylist по jti\n3. В журнал аудита пишется событие force_logout с логином администратора и причиной\n\n### Особенности:\n- Требует basic auth администратора (ADMIN_USERNAME / ADMIN_PASSWORD)\n- Если credentials администратора не заданы, эндпоинт возвращает 404\n- Операция идемпотентна: повторный вызов вернёт нулевые счётчики",
                 "consumes": [
                     "application/json"
                 ],
@@ -1758,24 +1794,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Подтверждение email адреса",
+                "summary": "Принудительный выход пользователя",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "JWT токен верификации из email",
-                        "name": "token",
-                        "in": "query",
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Причина для журнала аудита",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "reason": {
+                                    "type": "string"
+                                }
+                            }
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Email успешно подтвержден, можно входить в систему",
+                        "description": "Сессии завершены",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "access_tokens_revoked": {
+                                    "type": "integer"
+                                },
+                                "refresh_tokens_deleted": {
+                                    "type": "integer"
+                                },
                                 "status": {
                                     "type": "string"
                                 }
@@ -1783,10 +1838,13 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Токен отсутствует в URL",
+                        "description": "Некорректный ID пользователя или тело запроса",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1797,10 +1855,30 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Токен невалидный, истек или уже использован",
+                        "description": "Неверные credentials администратора",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1815,6 +1893,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1824,13 +1905,12 @@ const docTemplate = `{
                             }
                         }
                     }
-                },
-                "x-order": 5
+                }
             }
         },
-        "/auth/verify/resend": {
+        "/admin/users/{id}/require-password-reset": {
             "post": {
-                "description": "## Описание\nПовторно отправляет письмо с подтверждением email адреса пользователю.\n\n### Процесс отправки:\n1. Валидация email формата\n2. Проверка существования пользователя с указанным email\n3. Проверка статуса верификации email\n4. Если email не подтвержден - генерация нового токена верификации\n5. Отправка письма через RabbitMQ\n6. Возврат успешного ответа (независимо от статуса верификации)\n\n### Когда использовать:\n- Пользователь не получил первое письмо\n- Токен верификации истек (24 часа)\n- Письмо попало в спам\n- Пользователь случайно удалил письмо\n\n### Безопасность (важно!):\n- Endpoint всегда возвращает 200 OK, даже если email уже подтвержден\n- Это предотвращает enumeration атаки (определение существующих email)\n- Не раскрывает информацию о существовании пользователя\n- Rate limiting должен быть настроен (максимум 3 запроса в час на email)\n\n### Особенности:\n- Если email уже подтвержден - письмо не отправляется (но ответ 200 OK)\n- Новый токен инвалидирует предыдущий\n- Токен действителен 24 часа\n- Отправка асинхронная через RabbitMQ (не блокирует ответ)\n",
+                "description": "## Описание\nПомечает аккаунт как требующий смены пароля. Пока пользователь не сбросит пароль\nчерез /auth/password/forgot и /auth/password/reset, вход любым способом и refresh возвращают 403.\n\n### Особенности:\n- Активные сессии завершаются: refresh-токены удаляются, выданные access-токены отзываются\n- Флаг снимается автоматически при успешном сбросе пароля\n- В журнал аудита пишется событие require_password_reset\n- Требует basic auth администратора (ADMIN_USERNAME / ADMIN_PASSWORD)",
                 "consumes": [
                     "application/json"
                 ],
@@ -1838,19 +1918,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Повторная отправка письма верификации",
+                "summary": "Потребовать смену пароля",
                 "parameters": [
                     {
-                        "description": "Email пользователя",
-                        "name": "email",
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Причина для журнала аудита",
+                        "name": "body",
                         "in": "body",
-                        "required": true,
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "email": {
+                                "reason": {
                                     "type": "string"
                                 }
                             }
@@ -1859,7 +1945,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Письмо отправлено (или email уже подтвержден)",
+                        "description": "Флаг выставлен",
                         "schema": {
                             "type": "object",
                             "properties": {
@@ -1870,10 +1956,30 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Ошибка валидации: некорректный email формат",
+                        "description": "Некорректный ID пользователя или тело запроса",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Неверные credentials администратора",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1884,10 +1990,13 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Пользователь не найден",
+                        "description": "Пользователь не найден или удалён",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1902,6 +2011,9 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
                                 "error": {
                                     "type": "string"
                                 },
@@ -1911,173 +2023,7943 @@ const docTemplate = `{
                             }
                         }
                     }
-                },
-                "x-order": 6
+                }
             }
         },
-        "/health": {
+        "/admin/users/{id}/roles": {
             "get": {
-                "description": "Проверяет, запущен ли сервис и готов ли он обрабатывать запросы.",
+                "description": "Возвращает роли пользователя в приложении — те же, что попадут в claims\nroles/permissions его следующего access-токена.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "System"
+                    "admin"
                 ],
-                "summary": "Проверка работоспособности",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                "summary": "Роли пользователя в приложении",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID приложения",
+                        "name": "app_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Роли пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/userRoles.ListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID пользователя или приложения",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Неверные credentials администратора",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "## Описание\nНазначает пользователю роль приложения и пишет событие role_assigned в журнал аудита.\n\n### Особенности:\n- Роль попадает в access-токены, выпущенные после назначения (не позже следующего refresh)\n- Повторное назначение той же роли — no-op",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Назначение роли пользователю",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Приложение, роль и причина",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userRoles.AssignRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Роль назначена",
+                        "schema": {
+                            "$ref": "#/definitions/userRoles.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID пользователя или тело запроса",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Неверные credentials администратора",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь или роль не найдены",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/roles/{role}": {
+            "delete": {
+                "description": "## Описание\nСнимает с пользователя роль приложения и пишет событие role_revoked в журнал аудита.\nУже выданные access-токены сохраняют роль до истечения; чтобы отозвать её сразу,\nиспользуйте /admin/users/{id}/logout.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Снятие роли с пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя роли",
+                        "name": "role",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID приложения",
+                        "name": "app_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Причина для журнала аудита",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "reason": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Роль снята",
+                        "schema": {
+                            "$ref": "#/definitions/userRoles.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Неверные credentials администратора",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Роль не найдена или не назначена пользователю",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "$ref": "#/definitions/internal_http_server_handlers_infrastructure_health.Response"
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/status": {
+            "post": {
+                "description": "## Описание\nПереводит аккаунт между состояниями: active, disabled, suspended, banned.\n\n### Допустимые переходы:\n- active → disabled, suspended, banned\n- disabled → active, banned\n- suspended → active, disabled, banned\n- banned → active\n\n### Особенности:\n- При переводе в disabled/suspended/banned все сессии завершаются (как /admin/users/{id}/logout)\n- pending_deletion выставляется только самим пользователем при удалении аккаунта\n- disabled — обратимое отключение аккаунта, suspended — временная блокировка за нарушение\n- В журнал аудита пишется событие status_change с исходным и новым статусом\n- Требует basic auth администратора (ADMIN_USERNAME / ADMIN_PASSWORD)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Изменить статус аккаунта",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новый статус и причина",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "reason": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Статус изменён",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID пользователя или тело запроса",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Неверные credentials администратора",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Переход из текущего статуса недопустим",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/verify-email": {
+            "post": {
+                "description": "## Описание\nОтмечает email пользователя подтверждённым без письма. Для случаев, когда отправка\nписем сломалась, а владение адресом подтверждено поддержкой.\n\n### Особенности:\n- В журнал аудита пишется событие manual_email_verify с адресом и причиной\n- Для уже подтверждённого email возвращается 409, событие не пишется\n- Требует basic auth администратора (ADMIN_USERNAME / ADMIN_PASSWORD)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Подтвердить email вручную",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Причина для журнала аудита",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "reason": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Email подтверждён",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID пользователя или тело запроса",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Неверные credentials администратора",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден или удалён",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Email уже подтверждён",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api-keys/self": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "## Описание\nПроверяет ключ из заголовка ` + "`" + `X-API-Key` + "`" + ` и возвращает приложение и scope'ы, к которым\nон привязан. Сервисы, принимающие ключи от batch-задач, вызывают этот эндпоинт\nвместо интроспекции access-токена.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Данные API-ключа",
+                "responses": {
+                    "200": {
+                        "description": "Ключ действителен",
+                        "schema": {
+                            "$ref": "#/definitions/self.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Ключ отсутствует, неизвестен, истёк или отозван",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/2fa/disable/request-confirmation": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отправляет magic-link код на email текущего пользователя для\nподтверждения отключения 2FA. Требуется только для\noauth-only пользователей без пароля — у них нет иного\nспособа подтвердить чувствительное действие. Возвращает\nsession_id, который затем передаётся вместе с кодом из письма\nв /auth/2fa/disable.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "2fa"
+                ],
+                "summary": "Запросить подтверждение отключения 2FA через magic link",
+                "responses": {
+                    "200": {
+                        "description": "Код отправлен на email",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "session_id": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/2fa/magic-link/disable": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отключает magic-link 2FA. Подтверждение зависит от того, есть\nли у пользователя пароль: если да — передаётся password; если\nнет (oauth-only аккаунт) — передаются session_id и token,\nполученные через /auth/2fa/magic-link/request-action-confirmation.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "2fa"
+                ],
+                "summary": "Отключить magic-link 2FA",
+                "parameters": [
+                    {
+                        "description": "Подтверждение отключения (один из наборов полей)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "password": {
+                                    "type": "string"
+                                },
+                                "session_id": {
+                                    "type": "string"
+                                },
+                                "token": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "2FA отключена",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк, либо неверное подтверждение (пароль/magic-link код)",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "2FA не включена или включена через TOTP (отключается через /auth/2fa/totp/disable)",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/2fa/magic-link/enable": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Включает magic-link 2FA для текущего пользователя. Требует,\nчтобы у пользователя уже был рабочий фактор для будущего\nотключения (пароль или хотя бы один привязанный oauth-аккаунт) —\nиначе включение необратимо заблокирует доступ к аккаунту.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "2fa"
+                ],
+                "summary": "Включить magic-link 2FA",
+                "responses": {
+                    "200": {
+                        "description": "2FA включена",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "2FA уже включена, либо нет ни одного доступного фактора для будущего disable",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/2fa/magic-link/resend": {
+            "post": {
+                "description": "Инвалидирует предыдущую активную ссылку и высылает новую в\nрамках той же pending-сессии, начатой на /auth/login. Не\nподтверждает и не раскрывает факт доставки письма — ответ\nодинаковый независимо от того, дошло письмо или нет.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "2fa"
+                ],
+                "summary": "Повторно отправить magic-link",
+                "parameters": [
+                    {
+                        "description": "Идентификатор pending-сессии",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "session_id": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Новая ссылка отправлена (либо попытка предпринята)",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидное тело запроса",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Pending-сессия не найдена или истекла — нужно начать логин заново",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком частые запросы на повторную отправку",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/2fa/magic-link/verify": {
+            "post": {
+                "description": "Завершает второй фактор аутентификации: проверяет токен из\nписьма в связке с session_id, полученным на этапе /auth/login,\nи при успехе выдаёт access/refresh токены. Токен одноразовый —\nповторное использование того же токена или session_id отклоняется.\ndevice_id и device_name, как и в /auth/login, привязывают сессию к устройству.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "2fa"
+                ],
+                "summary": "Подтверждение magic-link 2FA",
+                "parameters": [
+                    {
+                        "description": "Данные для подтверждения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "device_id": {
+                                    "type": "string"
+                                },
+                                "device_name": {
+                                    "type": "string"
+                                },
+                                "remember_device": {
+                                    "type": "boolean"
+                                },
+                                "session_id": {
+                                    "type": "string"
+                                },
+                                "token": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "2FA подтверждена, выданы токены",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "access_token": {
+                                    "type": "string"
+                                },
+                                "csrf_token": {
+                                    "type": "string"
+                                },
+                                "device_trust_token": {
+                                    "type": "string"
+                                },
+                                "refresh_token": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидное тело запроса или ошибка валидации",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Токен невалиден, истёк, уже использован, либо сессия истекла",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Аккаунт заблокирован администратором или требуется смена пароля",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Достигнут лимит активных сессий",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/2fa/totp/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Проверяет первый код из приложения-аутентификатора и включает\nTOTP 2FA. Со следующего логина вторым фактором будет код из приложения.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "2fa"
+                ],
+                "summary": "Подтвердить подключение TOTP 2FA",
+                "parameters": [
+                    {
+                        "description": "Код из приложения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "TOTP 2FA включена",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидное тело запроса или ошибка валидации",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Access token невалиден, либо неверный код",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Подключение не начато (/auth/2fa/totp/enroll)",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "2FA уже включена",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "501": {
+                        "description": "TOTP не настроен на сервере",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/2fa/totp/disable": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отключает TOTP 2FA и удаляет секрет. Подтверждается\nдействующим кодом из приложения-аутентификатора.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "2fa"
+                ],
+                "summary": "Отключить TOTP 2FA",
+                "parameters": [
+                    {
+                        "description": "Код из приложения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "TOTP 2FA отключена",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидное тело запроса или ошибка валидации",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Access token невалиден, либо неверный код",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "TOTP 2FA не включена",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "501": {
+                        "description": "TOTP не настроен на сервере",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/2fa/totp/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Генерирует секрет для приложения-аутентификатора и возвращает\nего вместе с otpauth:// URI для QR-кода. 2FA включается только\nпосле подтверждения первым кодом через /auth/2fa/totp/confirm.\nПовторный вызов до подтверждения выдаёт новый секрет взамен старого.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "2fa"
+                ],
+                "summary": "Начать подключение TOTP 2FA",
+                "responses": {
+                    "200": {
+                        "description": "Секрет выдан",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "provisioning_uri": {
+                                    "type": "string"
+                                },
+                                "secret": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "2FA уже включена",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "501": {
+                        "description": "TOTP не настроен на сервере",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/2fa/totp/verify": {
+            "post": {
+                "description": "Завершает второй фактор аутентификации: проверяет код из\nприложения-аутентификатора в связке с session_id, полученным на\nэтапе /auth/login (two_factor_method=totp), и при успехе выдаёт\naccess/refresh токены. Один и тот же код нельзя использовать дважды.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "2fa"
+                ],
+                "summary": "Подтверждение TOTP 2FA при логине",
+                "parameters": [
+                    {
+                        "description": "Данные для подтверждения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "device_id": {
+                                    "type": "string"
+                                },
+                                "device_name": {
+                                    "type": "string"
+                                },
+                                "remember_device": {
+                                    "type": "boolean"
+                                },
+                                "session_id": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "2FA подтверждена, выданы токены",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "access_token": {
+                                    "type": "string"
+                                },
+                                "csrf_token": {
+                                    "type": "string"
+                                },
+                                "device_trust_token": {
+                                    "type": "string"
+                                },
+                                "refresh_token": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидное тело запроса или ошибка валидации",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Код неверен или уже использован, либо сессия истекла",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Аккаунт заблокирован администратором или требуется смена пароля",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Достигнут лимит активных сессий",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "501": {
+                        "description": "TOTP не настроен на сервере",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "## Описание\nВыполняет аутентификацию пользователя по email и паролю. Если\nу пользователя включена 2FA, вместо токенов возвращается\nsession_id и two_factor_method: magic_link — подтверждение через\n/auth/2fa/magic-link/verify, totp — через /auth/2fa/totp/verify;\naccess/refresh в этом случае не выдаются.\n\n### Процесс аутентификации:\n1. Валидация входных данных (email формат, наличие пароля)\n2. Проверка существования пользователя в базе данных\n3. Верификация пароля (Argon2id или bcrypt; bcrypt-хеш после успешного входа перехешируется в Argon2id)\n4. Проверка статуса email (должен быть подтвержден)\n5. Валидация app_id (приложение должно существовать)\n6. Проверка статуса 2FA:\n- если выключена — генерация JWT токенов (access и refresh)\n- если включена — создание pending-сессии (для magic-link — с отправкой письма), возврат session_id и two_factor_method без токенов\n\n### Токены:\n- **Access Token**: JWT токен для доступа к защищенным ресурсам (TTL: 15 минут)\n- **Refresh Token**: JWT токен для обновления access токена (TTL: 30 дней)\n- **Session ID** (при включённой 2FA): используется для подтверждения второго фактора, не является токеном доступа\n\n### Устройства:\n- Необязательные device_id и device_name привязывают сессию к устройству\n- Новый логин с тем же device_id завершает прежнюю сессию на этом устройстве\n- device_name показывается в списке сессий; по умолчанию равен device_id\n- Устройство узнаётся по device_id, без него — по User-Agent; вход с нового устройства приходит письмом\n- При two_factor_auth.new_device_challenge вход с нового устройства требует magic link даже без 2FA\n\n### Доверенные устройства:\n- Токен доверенного устройства из /auth/2fa/*/verify (remember_device) передаётся в заголовке X-Device-Trust или приходит cookie device_trust\n- Пока токен действует, 2FA и проверка нового устройства не требуются; невозможное перемещение проверяется всё равно\n\n### Невозможное перемещение:\n- При заданной geo.database_path место входа по IP сравнивается с местом прошлого входа\n- Если добраться оттуда можно только быстрее geo.max_speed_kmh, срабатывает geo.action: notify — письмо, challenge — magic link, block — 403\n\n### Refresh-токен в cookie:\n- Приложениям с refresh_token_delivery = cookie refresh-токен ставится HttpOnly cookie, в теле его нет\n- В теле и в cookie csrf_token приходит CSRF-токен: его нужно передавать в заголовке X-CSRF-Token на /auth/refresh и /auth/logout\n\n### Proof-of-work:\n- При challenge.enabled после серии неудачных входов с IP или на email вход требует решённой задачи\n- Ответ 428 несёт задачу в X-Challenge-Nonce и X-Challenge-Difficulty: нужно подобрать строку, при которой SHA-256(nonce + строка) начинается с difficulty нулевых бит\n- Запрос повторяется с X-Challenge-Nonce и решением в X-Challenge-Solution; задача одноразовая\n\n### Scope'ы:\n- Необязательный scope — список через пробел, каждый должен входить в allowed_scopes приложения\n- Выданные scope'ы попадают в claim scope access-токена и сохраняются за сессией для refresh\n\n### Коды ошибок:\n- ` + "`" + `400` + "`" + ` - Некорректные данные (невалидный email, отсутствие полей, невалидный app_id или scope)\n- ` + "`" + `401` + "`" + ` - Неверные credentials (пароль не совпадает; используется и для несуществующего email — не различается намеренно, во избежание user enumeration). Для несуществующего email пароль сверяется с фиктивным хешем, поэтому время ответа тоже одинаковое\n- ` + "`" + `403` + "`" + ` - Email не подтвержден или вход заблокирован как подозрительный (невозможное перемещение)\n- ` + "`" + `410` + "`" + ` - Аккаунт удалён и ждёт окончательного удаления (только при верном пароле, иначе 401)\n- ` + "`" + `428` + "`" + ` - Нужно решить proof-of-work (см. выше)\n- ` + "`" + `429` + "`" + ` - Вход временно заблокирован после серии неверных паролей (Retry-After — сколько ждать; ссылка для разблокировки отправляется на email)\n- ` + "`" + `500` + "`" + ` - Внутренняя ошибка сервера\n- ` + "`" + `503` + "`" + ` - Все воркеры хеширования паролей заняты дольше таймаута запроса (Retry-After)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Аутентификация пользователя",
+                "parameters": [
+                    {
+                        "description": "Данные для входа",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "app_id": {
+                                    "type": "integer"
+                                },
+                                "device_id": {
+                                    "type": "string"
+                                },
+                                "device_name": {
+                                    "type": "string"
+                                },
+                                "email": {
+                                    "type": "string"
+                                },
+                                "password": {
+                                    "type": "string"
+                                },
+                                "scope": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пароль верен, требуется подтверждение 2FA",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "session_id": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                },
+                                "two_factor_method": {
+                                    "type": "string"
+                                },
+                                "two_factor_pending": {
+                                    "type": "boolean"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации, невалидный app_id или scope",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Неверные credentials",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Email не подтвержден, требуется смена пароля, аккаунт заблокирован или вход подозрителен",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Достигнут лимит активных сессий (sessions.on_limit = reject)",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "410": {
+                        "description": "Аккаунт удалён, его можно восстановить",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "428": {
+                        "description": "Требуется proof-of-work: задача в X-Challenge-Nonce и X-Challenge-Difficulty",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Вход временно заблокирован после серии неверных паролей",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Все воркеры хеширования паролей заняты дольше таймаута запроса",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                },
+                "x-order": 1
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "description": "## Описание\nЗавершает активную сессию пользователя, инвалидируя refresh токен.\n\n### Процесс выхода:\n1. Валидация refresh токена из тела запроса\n2. Проверка существования токена в базе данных\n3. Добавление токена в blacklist (Redis)\n4. Удаление токена из таблицы активных сессий\n5. Инвалидация связанного access токена\n\n### Особенности:\n- После logout refresh токен больше нельзя использовать для получения новых access токенов\n- Access токен технически остается валидным до истечения TTL (~15 минут)\n- Для немедленной инвалидации access токена используется blacklist в Redis\n- Выход со всех устройств — /auth/logout/all\n- Без refresh_token в теле токен берётся из HttpOnly cookie (нужен заголовок X-CSRF-Token); cookie удаляются\n\n### Безопасность:\n- Токен в blacklist хранится только до истечения его TTL\n- При попытке использовать инвалидированный токен возвращается 401\n- Логирование всех операций logout для аудита",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Выход из системы",
+                "parameters": [
+                    {
+                        "description": "Refresh токен для инвалидации",
+                        "name": "token",
+                        "in": "body",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "refresh_token": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSRF-токен, если refresh-токен в cookie",
+                        "name": "X-CSRF-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный выход из системы",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации: токен не передан или некорректный JSON",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Невалидный или истекший refresh токен",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "CSRF-токен не совпал",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                },
+                "x-order": 4
+            }
+        },
+        "/auth/logout/all": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "## Описание\nЗавершает все сессии текущего пользователя: удаляет все refresh-токены и\nотзывает все выданные access-токены, включая тот, с которым пришёл запрос.\n\nИспользуется, если пользователь подозревает, что аккаунт скомпрометирован.\nСобытие попадает в ленту активности (/me/activity).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Выход со всех устройств",
+                "responses": {
+                    "200": {
+                        "description": "Все сессии завершены",
+                        "schema": {
+                            "$ref": "#/definitions/logout.AllResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oauth/accounts": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает все OAuth-провайдеры, привязанные к аккаунту\nтекущего аутентифицированного пользователя.\nУстарел: используйте GET /me/identities.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oauth"
+                ],
+                "summary": "Список привязанных OAuth-аккаунтов",
+                "deprecated": true,
+                "responses": {
+                    "200": {
+                        "description": "Список привязанных аккаунтов",
+                        "schema": {
+                            "$ref": "#/definitions/accounts.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oauth/{provider}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет связь между указанным OAuth-провайдером и аккаунтом\nтекущего аутентифицированного пользователя.\nЕсли этот способ входа является последним доступным методом\nаутентификации, операция будет отклонена, чтобы предотвратить\nпотерю доступа к аккаунту.\nУстарел: используйте DELETE /me/identities/{provider}.",
+                "tags": [
+                    "oauth"
+                ],
+                "summary": "Отвязка OAuth-провайдера от аккаунта",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Название OAuth-провайдера (например: google, github)",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "OAuth-провайдер успешно отвязан"
+                    },
+                    "401": {
+                        "description": "Access-токен отсутствует, недействителен или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Нельзя отвязать последний доступный способ аутентификации",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "У пользователя отсутствует привязка к указанному OAuth-провайдеру",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oauth/{provider}/callback": {
+            "get": {
+                "description": "Обрабатывает перенаправление от OAuth-провайдера после того, как пользователь предоставил или отклонил доступ.\nПроверяет параметр state на соответствие ранее сохранённому значению (авторизация или привязка аккаунта),\nобменивает код авторизации на токены провайдера и в зависимости от типа операции\nлибо выдает новую пару access и refresh токенов,\nлибо привязывает OAuth-провайдера к существующему аккаунту.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oauth"
+                ],
+                "summary": "Callback OAuth-провайдера",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Название OAuth-провайдера (например: google, github)",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Код авторизации, полученный от OAuth-провайдера",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Токен состояния (state), должен совпадать со значением, выданным при начале авторизации или привязки аккаунта",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Код ошибки, возвращаемый OAuth-провайдером, если пользователь отказал в доступе",
+                        "name": "error",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешная авторизация или привязка аккаунта",
+                        "schema": {
+                            "$ref": "#/definitions/callback.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Пользователь отказал в доступе, отсутствуют параметры code/state, указан некорректный app_id либо state недействителен или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Email, полученный от OAuth-провайдера, не подтверждён, аккаунт заблокирован или требуется смена пароля",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Указанный OAuth-провайдер не поддерживается",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Конфликт данных: аккаунт с таким email уже существует либо OAuth-провайдер уже привязан к другому аккаунту, либо достигнут лимит активных сессий",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oauth/{provider}/link": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Запускает OAuth2-процесс привязки стороннего OAuth-провайдера к аккаунту\nтекущего аутентифицированного пользователя.\nТребует действительный access-токен. Идентификатор пользователя\nизвлекается из его данных, а не из параметров запроса,\nпоэтому привязка всегда выполняется для текущей сессии.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oauth"
+                ],
+                "summary": "Привязка OAuth-провайдера к существующему аккаунту",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Название OAuth-провайдера (например: google, github)",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "URL, на который будет выполнено перенаправление после завершения авторизации. Должен входить в список разрешённых адресов.",
+                        "name": "redirect_uri",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ссылка для перехода к OAuth-провайдеру",
+                        "schema": {
+                            "$ref": "#/definitions/link.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный redirect_uri или app_id",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Access-токен отсутствует, недействителен или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "OAuth-провайдер не поддерживается",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oauth/{provider}/login": {
+            "get": {
+                "description": "Запускает процесс OAuth2-авторизации для указанного OAuth-провайдера.\nПроверяет корректность app_id и redirect_uri, а также соответствие\nredirect_uri списку разрешённых адресов.\nПосле успешной проверки формирует параметр state и перенаправляет\nпользователя на страницу авторизации OAuth-провайдера.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oauth"
+                ],
+                "summary": "Начало OAuth-авторизации",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Название OAuth-провайдера (например: google, github)",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор клиентского приложения",
+                        "name": "app_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "URL для перенаправления после завершения авторизации. Должен входить в список разрешённых адресов.",
+                        "name": "redirect_uri",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Перенаправление на страницу авторизации OAuth-провайдера"
+                    },
+                    "400": {
+                        "description": "Некорректный app_id или redirect_uri не прошёл проверку",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "OAuth-провайдер не поддерживается",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Не удалось сформировать URL для авторизации",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/forgot": {
+            "post": {
+                "description": "Запускает процесс сброса пароля для указанного адреса электронной почты.\nНезависимо от того, существует ли аккаунт с указанным email,\nвсегда возвращает ответ с кодом 200, чтобы исключить возможность\nопределения существования аккаунтов.\nЕсли аккаунт существует, на указанный email будет отправлено\nписьмо со ссылкой для сброса пароля.\nПоиск аккаунта, выпуск токена и отправка письма идут в фоне уже\nпосле ответа, поэтому ни статус, ни тело, ни время ответа не\nзависят от того, есть ли аккаунт. Ошибки фоновой части\nфиксируются на стороне сервера и не влияют на ответ API.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Запрос на сброс пароля",
+                "parameters": [
+                    {
+                        "description": "Адрес электронной почты пользователя",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "email": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Запрос успешно принят",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректное тело запроса или ошибка валидации",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен допустимый лимит запросов",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/reset": {
+            "post": {
+                "description": "Сбрасывает пароль пользователя с использованием токена,\nполученного по электронной почте.\nТокен должен быть передан в теле запроса в формате\n\"selector.verifier\", где selector — UUID, а verifier —\nстрока в формате URL-safe Base64.\nТокен можно использовать только один раз. После успешного\nсброса пароля он становится недействительным.\nНовый пароль должен соответствовать парольной политике\n(password_policy: длина, классы символов, оценка стойкости,\nопционально — отсутствие в базе утечек HaveIBeenPwned) и\nотличаться от текущего пароля. Нарушенные правила\nвозвращаются списком violations с code=ACCOUNT_WEAK_PASSWORD.\nПосле сброса завершаются все сессии пользователя: refresh-токены\nудаляются, уже выданные access-токены отзываются.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Сброс пароля",
+                "parameters": [
+                    {
+                        "description": "Токен для сброса пароля и новый пароль",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "password": {
+                                    "type": "string"
+                                },
+                                "token": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пароль успешно сброшен",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный формат токена, токен недействителен, истёк или уже был использован, пароль не соответствует требованиям либо совпадает с текущим",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "## Описание\nОбменивает действующий refresh токен на новую пару токенов (access и refresh).\n\n### Процесс обновления:\n1. Валидация refresh токена (подпись JWT, срок действия)\n2. Извлечение user_id и app_id из payload токена\n3. Проверка что токен не находится в blacklist (Redis)\n4. Проверка статуса пользователя (не заблокирован, email подтвержден)\n5. Генерация новой пары токенов:\n- Новый access токен (TTL: 15 минут)\n- Новый refresh токен (TTL: 30 дней)\n6. Инвалидация старого refresh токена (token rotation)\n7. Сохранение нового refresh токена в БД\n\n### Token Rotation (безопасность):\n- **Каждый refresh токен одноразовый** — после использования старый токен инвалидируется\n- Это защищает от атак с украденными токенами\n- При попытке использовать старый токен — все сессии пользователя инвалидируются\n\n### Время жизни токенов:\n- **Access Token**: 15 минут (короткий для безопасности)\n- **Refresh Token**: 30 дней (удобство для пользователя)\n- Если пользователь неактивен 30 дней — требуется повторный login\n\n### Когда использовать:\n- Access токен истек (получили 401 на защищенном endpoint)\n- Превентивное обновление перед истечением access токена\n- После длительного простоя приложения\n\n### Scope'ы:\n- Без scope новый access-токен получает scope'ы, выданные при логине\n- scope может только сузить их; refresh-токен сохраняет исходный набор\n\n### Организации:\n- org_id переключает сессию на организацию: claim org_id/org_role появляются в access-токене\n- Выбор запоминается за сессией и действует на следующих refresh без org_id\n- Если пользователя исключили из организации, следующий refresh выдаёт токен без org_id\n\n### Refresh-токен в cookie:\n- Без refresh_token в теле токен берётся из HttpOnly cookie; тело можно не передавать вовсе\n- Такой запрос требует заголовок X-CSRF-Token со значением CSRF-токена из логина\n- Новый refresh-токен ставится в cookie, в теле — новый csrf_token\n\n### Ошибки:\n- ` + "`" + `400` + "`" + `: Невалидный JSON или scope шире выданного при логине\n- ` + "`" + `401` + "`" + `: Токен отсутствует, истек, невалиден или уже использован\n- ` + "`" + `403` + "`" + `: Пользователь заблокирован, не состоит в организации org_id или CSRF-токен не совпал\n- ` + "`" + `500` + "`" + `: Ошибка БД или генерации токенов",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Обновление access токена",
+                "parameters": [
+                    {
+                        "description": "Текущий refresh токен",
+                        "name": "token",
+                        "in": "body",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "org_id": {
+                                    "type": "integer"
+                                },
+                                "refresh_token": {
+                                    "type": "string"
+                                },
+                                "scope": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSRF-токен, если refresh-токен в cookie",
+                        "name": "X-CSRF-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Новая пара токенов",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "access_token": {
+                                    "type": "string"
+                                },
+                                "csrf_token": {
+                                    "type": "string"
+                                },
+                                "refresh_token": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Невалидный или истекший токен",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Аккаунт заблокирован администратором, требуется смена пароля, пользователь не состоит в организации или CSRF-токен не совпал",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "410": {
+                        "description": "Аккаунт удалён",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                },
+                "x-order": 3
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "description": "## Описание\nСоздает новую учетную запись пользователя в системе и отправляет письмо с подтверждением email.\n\n### Процесс регистрации:\n1. Валидация входных данных (email формат, наличие username и пароля)\n2. Проверка уникальности username в базе данных\n3. Хеширование пароля алгоритмом из password_hashing (по умолчанию Argon2id)\n4. Создание записи пользователя в БД со статусом ` + "`" + `email_verified = false` + "`" + `\n5. Генерация JWT токена верификации (валиден 24 часа)\n6. Отправка email с ссылкой подтверждения через RabbitMQ\n\n### Требования к данным:\n- **Email**: Валидный email формат (example@domain.com)\n- **Username**: Минимум 3 символа, только буквы, цифры и подчеркивание, должен быть уникальным\n- **Password**: по парольной политике (password_policy): длина (по умолчанию от 8 символов),\nобязательные классы символов, оценка стойкости по шкале zxcvbn (без словарных слов,\nemail и username), опционально — отсутствие в базе утечек HaveIBeenPwned.\nНарушенные правила возвращаются разом в violations с code=ACCOUNT_WEAK_PASSWORD\n\n### Уже зарегистрированный email:\n- Ответ тот же, что и при успешной регистрации (201 без user_id) — по нему нельзя узнать, есть ли аккаунт\n- Владельцу адреса уходит письмо «у вас уже есть аккаунт» со ссылкой на сброс пароля\n- Username публичен, поэтому занятый username по-прежнему возвращает 409\n\n### Приложение:\n- **app_id**: приложение, в котором регистрируется пользователь; он становится его участником\n- При включённой проверке членства войти можно только в приложения, где пользователь зарегистрирован\n\n### Email верификация:\n- Письмо отправляется асинхронно через RabbitMQ (не блокирует ответ)\n- Токен верификации действует 24 часа\n- До подтверждения email пользователь не может войти в систему\n- Неподтвержденные аккаунты автоматически удаляются через 7 дней\n\n### Формат ссылки верификации:\n` + "`" + `http://domain.com/auth/verify?token=eyJhbGc...` + "`" + `",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Регистрация нового пользователя",
+                "parameters": [
+                    {
+                        "description": "Данные нового пользователя",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "app_id": {
+                                    "type": "integer"
+                                },
+                                "email": {
+                                    "type": "string"
+                                },
+                                "password": {
+                                    "type": "string"
+                                },
+                                "username": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Запрос принят, письмо отправлено на указанный email",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации: некорректный email, пароль не соответствует политике, неизвестный app_id или отсутствуют обязательные поля",
+                        "schema": {
+                            "$ref": "#/definitions/response.PasswordPolicyResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Username уже занят",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка: проблемы с БД, RabbitMQ или email сервисом",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Все воркеры хеширования паролей заняты дольше таймаута запроса",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                },
+                "x-order": 2
+            }
+        },
+        "/auth/unlock": {
+            "get": {
+                "description": "После серии неверных паролей вход по паролю блокируется на\nвремя, растущее с каждой блокировкой подряд, а на email уходит\nписьмо со ссылкой сюда. Переход по ссылке снимает блокировку и\nсбрасывает счётчики. Токен одноразовый.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Разблокировка входа по ссылке из письма",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен разблокировки из письма",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Вход разблокирован",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Токен отсутствует в URL",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Токен невалиден, истёк или уже использован",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/verify": {
+            "get": {
+                "description": "## Описание\nПодтверждает email адрес пользователя по токену из письма, активируя учетную запись.\n\n### Процесс верификации:\n1. Извлечение токена из query параметра ` + "`" + `token` + "`" + `\n2. Валидация JWT токена (подпись, срок действия)\n3. Извлечение user_id из payload токена\n4. Проверка что пользователь существует и email еще не подтвержден\n5. Обновление статуса ` + "`" + `email_verified = true` + "`" + ` в базе данных\n6. Инвалидация токена (предотвращение повторного использования)\n\n### Особенности токена:\n- **Срок действия**: 24 часа с момента регистрации\n- **Формат**: JWT (JSON Web Token) подписанный HMAC-SHA256\n- **Одноразовый**: После успешной верификации токен инвалидируется\n- **Payload**: Содержит ` + "`" + `user_id` + "`" + ` и ` + "`" + `exp` + "`" + ` (время истечения)\n\n### После подтверждения:\n- Пользователь может войти в систему через ` + "`" + `/auth/login` + "`" + `\n- Email помечен как подтвержденный навсегда\n- Опционально: отправка welcome email\n\n### Ошибки:\n- ` + "`" + `400` + "`" + `: Токен отсутствует в URL\n- ` + "`" + `401` + "`" + `: Токен невалидный, истек или уже использован\n- ` + "`" + `500` + "`" + `: Ошибка базы данных",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Подтверждение email адреса",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JWT токен верификации из email",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Email успешно подтвержден, можно входить в систему",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Токен отсутствует в URL",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Токен невалидный, истек или уже использован",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                },
+                "x-order": 5
+            }
+        },
+        "/auth/verify/code": {
+            "post": {
+                "description": "## Описание\nПодтверждает email 6-значным кодом из письма верификации — альтернатива ссылке для\nмобильных приложений, которым неудобно перехватывать deep link. Код приходит в том же\nписьме, что и ссылка, и действует столько же (` + "`" + `tokens.verification_token_ttl` + "`" + `).\n\n### Особенности:\n- Код одноразовый; новое письмо (` + "`" + `/auth/verify/resend` + "`" + `) отменяет прежний код\n- После ` + "`" + `tokens.verification_code_max_attempts` + "`" + ` неверных вводов код сгорает — нужно запросить новое письмо\n- Неизвестный email и неверный код дают одинаковый ответ ` + "`" + `401` + "`" + `",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Подтверждение email кодом",
+                "parameters": [
+                    {
+                        "description": "Email и код из письма",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/verifyCode.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Email подтверждён",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Код неверный, истёк или сгорел по числу попыток",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много попыток",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/verify/resend": {
+            "post": {
+                "description": "## Описание\nПовторно отправляет письмо с подтверждением email адреса пользователю.\n\n### Процесс отправки:\n1. Валидация email формата\n2. Проверка существования пользователя с указанным email\n3. Проверка статуса верификации email\n4. Если email не подтвержден - генерация нового токена верификации\n5. Отправка письма через RabbitMQ\n6. Возврат успешного ответа (независимо от статуса верификации)\n\n### Когда использовать:\n- Пользователь не получил первое письмо\n- Токен верификации истек (24 часа)\n- Письмо попало в спам\n- Пользователь случайно удалил письмо\n\n### Безопасность (важно!):\n- Endpoint всегда возвращает 200 OK, даже если email уже подтвержден\n- Это предотвращает enumeration атаки (определение существующих email)\n- Не раскрывает информацию о существовании пользователя\n- Rate limiting должен быть настроен (максимум 3 запроса в час на email)\n\n### Особенности:\n- Если email уже подтвержден - письмо не отправляется (но ответ 200 OK)\n- Новый токен инвалидирует предыдущий\n- Токен действителен 24 часа\n- Отправка асинхронная через RabbitMQ (не блокирует ответ)\n",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Повторная отправка письма верификации",
+                "parameters": [
+                    {
+                        "description": "Email пользователя",
+                        "name": "email",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "email": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Письмо отправлено (или email уже подтвержден)",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации: некорректный email формат",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                },
+                "x-order": 6
+            }
+        },
+        "/graphql": {
+            "post": {
+                "description": "## Описание\nGraphQL-фасад над тем же сервисным слоем, что и REST. Для фронтендов,\nкоторые работают только с GraphQL.\n\n### Операции:\n- query: me, sessions — требуют Bearer access токен\n- mutation: login, refresh, logout — без токена\n- mutation: updateProfile — требует Bearer access токен\n\n### Особенности:\n- Ошибки возвращаются в errors[] с кодом в extensions.code\n- login ограничен по email тем же счётчиком, что и /auth/login (код RATE_LIMITED)\n- Эндпоинт включается флагом graphql.enabled",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "graphql"
+                ],
+                "summary": "GraphQL API",
+                "parameters": [
+                    {
+                        "description": "GraphQL запрос",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "operationName": {
+                                    "type": "string"
+                                },
+                                "query": {
+                                    "type": "string"
+                                },
+                                "variables": {
+                                    "type": "object"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Результат выполнения",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "data": {
+                                    "type": "object"
+                                },
+                                "errors": {
+                                    "type": "array",
+                                    "items": {
+                                        "type": "object"
+                                    }
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректное тело запроса",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Передан невалидный access токен",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Проверяет, запущен ли сервис и готов ли он обрабатывать запросы.\nВ режиме обслуживания ответ остаётся 200, а maintenance.active = true:\nреплика жива, просто не выдаёт токены.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Проверка работоспособности",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/me": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Помечает аккаунт как удалённый (soft delete, status =\npending_deletion). Требует подтверждения: паролем (если он установлен)\nлибо magic-link кодом, полученным через\n/account/delete/request-confirmation (для oauth-only\nпользователей без пароля). Все refresh- и access-токены\nнемедленно отзываются, на email уходит письмо об удалении.\nАккаунт можно восстановить в течение retention.deleted_accounts\n(по умолчанию 7 дней), затем он удаляется безвозвратно.\nИдемпотентно — повторный вызов на уже удалённый аккаунт не\nявляется ошибкой. Доступен также как DELETE /me.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "account"
+                ],
+                "summary": "Удалить аккаунт",
+                "parameters": [
+                    {
+                        "description": "Пароль ИЛИ session_id+code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/deleteAccount.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Аккаунт удалён"
+                    },
+                    "400": {
+                        "description": "Невалидный запрос",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует/невалиден, либо неверный пароль/код подтверждения",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/2fa": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает, включена ли 2FA и каким способом, куда приходят\nmagic link (email, sms, telegram) и ещё не использованные\nссылки с адресом и клиентом, откуда их запросили.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Настройки 2FA",
+                "responses": {
+                    "200": {
+                        "description": "Настройки 2FA",
+                        "schema": {
+                            "$ref": "#/definitions/twoFactor.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "## Описание\nВключает или выключает magic-link 2FA и выбирает канал\nдоставки ссылок. Не переданные поля не меняются.\n\n### Правила:\n- канал меняется только при выключенной 2FA; если в одном запросе\nвыключить 2FA и сменить канал, сначала выполняется выключение\n- для sms и telegram в канал сначала уходит проверочное сообщение\n- выключение требует password, а у аккаунта без пароля — session_id\nи token из /auth/2fa/magic-link/disable/request-confirmation\n- TOTP 2FA выключается через /auth/2fa/totp/disable",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Изменить настройки 2FA",
+                "parameters": [
+                    {
+                        "description": "Изменения настроек",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/twoFactor.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Настройки после изменения",
+                        "schema": {
+                            "$ref": "#/definitions/twoFactor.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидное тело запроса, неверный номер или chat_id",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Access token невалиден, либо неверное подтверждение выключения",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Смена канала при включённой 2FA, TOTP 2FA, либо нет фактора для будущего выключения",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Проверочное сообщение не доставлено",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "501": {
+                        "description": "Канал не настроен на сервере",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/activity": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "## Описание\nВозвращает события безопасности текущего пользователя от новых к старым:\nсмена пароля, вход с нового устройства, включение и отключение 2FA,\nзавершение сессий, удаление и восстановление аккаунта, действия поддержки.\n\n### Пагинация:\n- limit — размер страницы, по умолчанию 20, максимум 100\n- cursor — next_cursor из предыдущего ответа\n\nДля действий поддержки IP и User-Agent не возвращаются — это данные администратора.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Лента активности аккаунта",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница событий",
+                        "schema": {
+                            "$ref": "#/definitions/activity.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный limit или cursor",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/identities": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает все внешние учётки (OAuth-провайдеры, SAML), привязанные\nк аккаунту текущего пользователя, в порядке привязки.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Список привязанных внешних учёток",
+                "responses": {
+                    "200": {
+                        "description": "Список привязанных учёток",
+                        "schema": {
+                            "$ref": "#/definitions/identities.ListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/identities/{provider}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет привязку провайдера к аккаунту текущего пользователя.\nПоследний способ входа (нет пароля и других учёток) отвязать нельзя,\nчтобы пользователь не потерял доступ к аккаунту.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Отвязка внешней учётки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Название провайдера (например: google, github)",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Учётка отвязана"
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Нельзя отвязать последний способ входа",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Провайдер не привязан",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/trusted-devices": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает устройства, на которых пользователь при подтверждении 2FA\nвыбрал remember_device и вход с которых пока не требует второго фактора.\nИстёкшие устройства в список не попадают.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Список доверенных устройств",
+                "responses": {
+                    "200": {
+                        "description": "Список доверенных устройств",
+                        "schema": {
+                            "$ref": "#/definitions/trustedDevices.ListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Все устройства пользователя снова будут спрашивать второй фактор.\nСмена пароля и завершение всех сессий отзывают доверие так же.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Отзыв доверия ко всем устройствам",
+                "responses": {
+                    "200": {
+                        "description": "Число отозванных устройств",
+                        "schema": {
+                            "$ref": "#/definitions/trustedDevices.RevokeAllResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/trusted-devices/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Следующий вход с этого устройства снова потребует второй фактор.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Отзыв доверия к устройству",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID доверенного устройства",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Доверие отозвано"
+                    },
+                    "400": {
+                        "description": "Некорректный id",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Устройство не найдено",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "description": "Отдаёт метрики сервиса в формате Prometheus exposition format.\nНе предназначен для вызова из браузера/фронтенда — эндпоинт\nдля scrape'а Prometheus-сервером.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Prometheus метрики",
+                "responses": {
+                    "200": {
+                        "description": "Метрики в текстовом формате Prometheus",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth2/authorize": {
+            "get": {
+                "description": "## Описание\nНачало authorization code flow с PKCE. Приложения — OAuth-клиенты:\nclient_id = ID приложения, redirect_uri должен быть в apps.redirect_uris.\n\n### Поведение:\n- Параметры валидны — редирект 302 на страницу логина (oidc.login_url) с теми же\nquery-параметрами. Страница логинит пользователя через /auth/login и вызывает\nPOST /oauth2/authorize с access-токеном\n- Неизвестный client_id или незарегистрированный redirect_uri — 400, без редиректа\n- Остальные ошибки — редирект на redirect_uri с error и state (RFC 6749 §4.1.2.1)\n\n### Требования:\n- response_type=code, scope содержит openid\n- code_challenge обязателен, code_challenge_method=S256",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oidc"
+                ],
+                "summary": "OIDC authorization endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "code",
+                        "name": "response_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID приложения",
+                        "name": "client_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Зарегистрированный redirect_uri",
+                        "name": "redirect_uri",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "openid email profile",
+                        "name": "scope",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Непрозрачное значение клиента",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Попадёт в ID-токен",
+                        "name": "nonce",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "PKCE challenge",
+                        "name": "code_challenge",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "S256",
+                        "name": "code_challenge_method",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Редирект на страницу логина или на redirect_uri с ошибкой"
+                    },
+                    "400": {
+                        "description": "Неизвестный клиент или redirect_uri",
+                        "schema": {
+                            "$ref": "#/definitions/oidcHandler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/oidcHandler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "## Описание\nВызывается страницей логина после входа пользователя: выдаёт одноразовый код\nдля клиента и возвращает redirect_uri с code и state. Параметры — те же, что\nпришли в GET /oauth2/authorize.\n\n### Особенности:\n- Требует access-токен пользователя\n- Код живёт oidc.code_ttl и обменивается один раз\n- Ошибки после проверки клиента возвращаются в redirect_to с error и state",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oidc"
+                ],
+                "summary": "Выдача кода авторизации OIDC",
+                "parameters": [
+                    {
+                        "description": "Параметры authorization request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/oidc.AuthorizeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Код выдан или ошибка для клиента в redirect_to",
+                        "schema": {
+                            "$ref": "#/definitions/oidcHandler.ApproveResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидное тело, неизвестный клиент или redirect_uri",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Access-токен невалиден",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth2/token": {
+            "post": {
+                "description": "## Описание\nОбменивает код авторизации на access, refresh и ID-токены или ротирует\nrefresh-токен клиента.\n\n### grant_type=authorization_code\n- code, redirect_uri (тот же, что в /authorize), client_id, code_verifier\n- Код одноразовый: повторный обмен — invalid_grant\n\n### grant_type=refresh_token\n- refresh_token, client_id; токен другого приложения — invalid_grant\n\n### Аутентификация клиента:\n- Публичные клиенты (apps.public_client) — без секрета, защищены PKCE\n- Конфиденциальные — обязательно client_secret в форме или HTTP Basic ` + "`" + `client_id:client_secret` + "`" + `",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oidc"
+                ],
+                "summary": "OIDC token endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization_code или refresh_token",
+                        "name": "grant_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID приложения (если не передан в Basic)",
+                        "name": "client_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Секрет приложения",
+                        "name": "client_secret",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Код авторизации",
+                        "name": "code",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "redirect_uri из запроса авторизации",
+                        "name": "redirect_uri",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "PKCE verifier",
+                        "name": "code_verifier",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Refresh-токен",
+                        "name": "refresh_token",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Токены выданы",
+                        "schema": {
+                            "$ref": "#/definitions/oidcHandler.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_request, invalid_grant, unsupported_grant_type",
+                        "schema": {
+                            "$ref": "#/definitions/oidcHandler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "invalid_client",
+                        "schema": {
+                            "$ref": "#/definitions/oidcHandler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/oidcHandler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth2/userinfo": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает claims пользователя по access-токену, выданному через\n/oauth2/token или обычный /auth/login.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oidc"
+                ],
+                "summary": "OIDC userinfo endpoint",
+                "responses": {
+                    "200": {
+                        "description": "Claims пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/oidcHandler.UserInfoResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Access-токен невалиден или аккаунт удалён",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/oidcHandler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orgs": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает организации приложения access-токена, в которых состоит текущий\nпользователь, вместе с его ролью в каждой.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orgs"
+                ],
+                "summary": "Организации пользователя",
+                "responses": {
+                    "200": {
+                        "description": "Список организаций",
+                        "schema": {
+                            "$ref": "#/definitions/orgs.ListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "## Описание\nСоздаёт организацию в приложении access-токена. Создатель становится её\nвладельцем (owner).\n\nОрганизация выбирается в сессии через /auth/refresh с org_id — после этого\naccess-токены содержат claims org_id и org_role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orgs"
+                ],
+                "summary": "Создание организации",
+                "parameters": [
+                    {
+                        "description": "Название организации",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/orgs.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Организация создана",
+                        "schema": {
+                            "$ref": "#/definitions/orgs.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный запрос",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orgs/invitations/accept": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "## Описание\nДобавляет текущего пользователя в организацию по токену из письма-приглашения.\n\n### Особенности:\n- Email аккаунта должен совпадать с адресом, на который ушло приглашение\n- Токен одноразовый\n- Организация попадёт в токены после refresh с org_id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orgs"
+                ],
+                "summary": "Принятие приглашения в организацию",
+                "parameters": [
+                    {
+                        "description": "Токен из письма",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/orgInvitations.AcceptRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь добавлен в организацию",
+                        "schema": {
+                            "$ref": "#/definitions/orgInvitations.AcceptResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный запрос",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Приглашение не найдено, истекло или адресовано другому email",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Пользователь уже состоит в организации",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orgs/invitations/decline": {
+            "post": {
+                "description": "## Описание\nОтклоняет приглашение по токену из письма. Вход не требуется.\nПосле отклонения токен не принимается ни для принятия, ни для регистрации.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orgs"
+                ],
+                "summary": "Отклонение приглашения в организацию",
+                "parameters": [
+                    {
+                        "description": "Токен из письма",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/orgInvitations.DeclineRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Приглашение отклонено",
+                        "schema": {
+                            "$ref": "#/definitions/orgInvitations.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный запрос",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Приглашение не найдено, истекло или уже использовано",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orgs/invitations/signup": {
+            "post": {
+                "description": "## Описание\nСоздаёт аккаунт приглашённому, у которого его ещё нет, и добавляет его в\nорганизацию. Ссылка на этот шаг приходит в письме-приглашении.\n\n### Особенности:\n- Email берётся из приглашения и сразу считается подтверждённым\n- Аккаунт становится участником приложения организации\n- Если аккаунт с этим email уже есть, нужно войти и принять приглашение\n- Токены не выдаются: после регистрации пользователь входит через /auth/login",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orgs"
+                ],
+                "summary": "Регистрация по приглашению в организацию",
+                "parameters": [
+                    {
+                        "description": "Токен из письма, имя пользователя и пароль",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/orgInvitations.SignUpRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Аккаунт создан, пользователь добавлен в организацию",
+                        "schema": {
+                            "$ref": "#/definitions/orgInvitations.SignUpResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный запрос или пароль не соответствует политике (code=ACCOUNT_WEAK_PASSWORD, violations — нарушенные правила)",
+                        "schema": {
+                            "$ref": "#/definitions/response.PasswordPolicyResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Приглашение не найдено, истекло или уже использовано",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Аккаунт с этим email уже существует",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orgs/{id}/invitations": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "## Описание\nОтправляет на email письмо со ссылкой-приглашением в организацию.\n\n### Особенности:\n- Приглашать могут owner и admin; роль приглашения — admin или member\n- admin может пригласить только на роль, которой управляет\n- Ссылка живёт tokens.org_invitation_ttl\n- Если аккаунта с этим email нет, письмо ведёт на регистрацию по приглашению",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orgs"
+                ],
+                "summary": "Приглашение в организацию",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Email и роль приглашаемого",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/orgInvitations.InviteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Приглашение отправлено",
+                        "schema": {
+                            "$ref": "#/definitions/orgInvitations.InviteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный запрос",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав в организации",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Организация не найдена или пользователь в ней не состоит",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Приглашаемый уже состоит в организации",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orgs/{id}/members": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает участников организации с их ролями. Доступно любому участнику.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orgs"
+                ],
+                "summary": "Участники организации",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список участников",
+                        "schema": {
+                            "$ref": "#/definitions/orgMembers.ListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID организации",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Организация не найдена или пользователь в ней не состоит",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orgs/{id}/members/{userID}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "## Описание\nМеняет роль участника организации.\n\n### Особенности:\n- owner управляет всеми ролями, admin — только admin и member\n- Последнего владельца понизить нельзя\n- Claim org_role в выданных токенах обновится при следующем refresh",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orgs"
+                ],
+                "summary": "Смена роли участника",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID участника",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новая роль",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/orgMembers.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Роль изменена",
+                        "schema": {
+                            "$ref": "#/definitions/orgMembers.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID или роль",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав в организации",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Организация или участник не найдены",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Нельзя понизить последнего владельца",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "## Описание\nИсключает участника из организации. Пользователь может выйти из организации\nсам, передав свой ID.\n\n### Особенности:\n- owner исключает кого угодно, admin — только admin и member\n- Последнего владельца исключить нельзя\n- Сессии с этой организацией при следующем refresh продолжатся без org_id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orgs"
+                ],
+                "summary": "Исключение участника",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID участника",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Участник исключён"
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Access token отсутствует, невалиден или истёк",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав в организации",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Организация или участник не найдены",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Нельзя исключить последнего владельца",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/token/exchange": {
+            "post": {
+                "description": "## Описание\nСервис, получивший access-токен пользователя, обменивает его на токен для другого\nсервиса (audience) — делегирование между микросервисами. Новый токен несёт claim\n` + "`" + `act` + "`" + ` с client_id обменявшего сервиса.\n\n### Ограничения:\n- Клиент аутентифицируется HTTP Basic ` + "`" + `app_id:secret` + "`" + `\n- subject_token должен быть выдан самому клиенту\n- audience должен быть в apps.token_exchange_audiences клиента\n- scope не шире scope'ов subject_token и allowed_scopes audience; без scope — их пересечение\n- Выдаётся только access-токен, refresh не выдаётся",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Обмен токена (RFC 8693)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "urn:ietf:params:oauth:grant-type:token-exchange",
+                        "name": "grant_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access-токен пользователя",
+                        "name": "subject_token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "urn:ietf:params:oauth:token-type:access_token",
+                        "name": "subject_token_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID приложения, для которого нужен токен",
+                        "name": "audience",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Scope'ы нового токена через пробел",
+                        "name": "scope",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "urn:ietf:params:oauth:token-type:access_token",
+                        "name": "requested_token_type",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Токен выдан",
+                        "schema": {
+                            "$ref": "#/definitions/exchange.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_request, invalid_scope, invalid_target, unsupported_grant_type",
+                        "schema": {
+                            "$ref": "#/definitions/exchange.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "invalid_client",
+                        "schema": {
+                            "$ref": "#/definitions/exchange.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/exchange.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Хранилище отзыва недоступно",
+                        "schema": {
+                            "$ref": "#/definitions/exchange.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/token/introspect": {
+            "post": {
+                "description": "## Описание\nПроверяет access-токен за resource-сервер: подпись и срок JWT, claims opaque-токена\nи отзыв по jti. Сервисам не нужно самим разбирать JWT и ходить в denylist.\n\n### Аутентификация вызывающего:\n- HTTP Basic: ` + "`" + `app_id:secret` + "`" + ` приложения\n- Токены других приложений возвращаются как ` + "`" + `{\"active\": false}` + "`" + `\n\n### Формат запроса:\n- ` + "`" + `application/x-www-form-urlencoded` + "`" + ` с полем token (как в RFC 7662) или JSON\n- Недействительный, истёкший или отозванный токен — 200 с ` + "`" + `{\"active\": false}` + "`" + `",
+                "consumes": [
+                    "application/x-www-form-urlencoded",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Интроспекция access-токена (RFC 7662)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Результат интроспекции",
+                        "schema": {
+                            "$ref": "#/definitions/introspect.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Не передан token",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Неверные credentials приложения",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Хранилище отзыва недоступно",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "code": {
+                                    "type": "string"
+                                },
+                                "error": {
+                                    "type": "string"
+                                },
+                                "status": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "accounts.Account": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-07-24T12:00:00Z"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "example@domain.com"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "google"
+                }
+            }
+        },
+        "accounts.Response": {
+            "type": "object",
+            "properties": {
+                "accounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/accounts.Account"
+                    }
+                },
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "activity.Event": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "new_device"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-07-24T12:00:00Z"
+                },
+                "device_name": {
+                    "type": "string",
+                    "example": "iPhone 15"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 8812
+                },
+                "initiated_by": {
+                    "description": "InitiatedBy — user, admin или system.",
+                    "type": "string",
+                    "example": "user"
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "user_agent": {
+                    "type": "string",
+                    "example": "Mozilla/5.0"
+                }
+            }
+        },
+        "activity.Response": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/activity.Event"
+                    }
+                },
+                "next_cursor": {
+                    "description": "NextCursor — передать в cursor за следующей страницей. Пусто — страниц больше нет.",
+                    "type": "string",
+                    "example": "8790"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "apiKeys.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-10-16T12:00:00Z"
+                },
+                "created_by": {
+                    "type": "string",
+                    "example": "admin:root"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2027-01-01T00:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5f0c3a4e-8d1b-4f7a-9c2e-1b3d5e7f9a0b"
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2026-10-16T13:00:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "nightly-export"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "reports:read"
+                    ]
+                }
+            }
+        },
+        "apiKeys.CreateResponse": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/apiKeys.APIKey"
+                },
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "key": {
+                    "description": "Key — сам ключ; показывается только в этом ответе",
+                    "type": "string",
+                    "example": "ak_5f0c3a4e-8d1b-4f7a-9c2e-1b3d5e7f9a0b.Xq3v..."
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "apiKeys.ListResponse": {
+            "type": "object",
+            "properties": {
+                "api_keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apiKeys.APIKey"
+                    }
+                },
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "apiKeys.Request": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_at": {
+                    "description": "ExpiresAt — без значения ключ бессрочный",
+                    "type": "string",
+                    "example": "2027-01-01T00:00:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "example": "nightly-export"
+                },
+                "scopes": {
+                    "description": "Scopes — подмножество allowed_scopes приложения",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "reports:read"
+                    ]
+                }
+            }
+        },
+        "callback.Response": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string",
+                    "example": "fkajeDJ1p3FJ..."
+                },
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "refresh_token": {
+                    "type": "string",
+                    "example": "abcDEF123..."
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "changeEmail.Request": {
+            "type": "object",
+            "required": [
+                "new_email"
+            ],
+            "properties": {
+                "new_email": {
+                    "type": "string",
+                    "example": "new@domain.com"
+                },
+                "password": {
+                    "description": "Password — текущий пароль; не нужен, если пароль у аккаунта не задан (вход только через OAuth).",
+                    "type": "string",
+                    "example": "SecurePass123!"
+                }
+            }
+        },
+        "changeEmail.RequestResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2026-07-24T12:30:00Z"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "changeEmail.Response": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "deleteAccount.Request": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "SecurePass123!"
+                },
+                "session_id": {
+                    "type": "string",
+                    "example": "abcDEF123..."
+                },
+                "token": {
+                    "type": "string",
+                    "example": "fkajeDJ1p3FJ..."
+                }
+            }
+        },
+        "exchange.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "invalid_target"
+                },
+                "error_description": {
+                    "type": "string",
+                    "example": "token exchange to this audience is not allowed"
+                }
+            }
+        },
+        "exchange.Response": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJSUzI1NiIsImtpZCI6..."
+                },
+                "expires_in": {
+                    "type": "integer",
+                    "example": 900
+                },
+                "issued_token_type": {
+                    "type": "string",
+                    "example": "urn:ietf:params:oauth:token-type:access_token"
+                },
+                "scope": {
+                    "type": "string",
+                    "example": "orders:read"
+                },
+                "token_type": {
+                    "type": "string",
+                    "example": "Bearer"
+                }
+            }
+        },
+        "health.Maintenance": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "миграция БД"
+                },
+                "until": {
+                    "type": "string",
+                    "example": "2026-07-24T12:30:00Z"
+                }
+            }
+        },
+        "health.Response": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "maintenance": {
+                    "$ref": "#/definitions/health.Maintenance"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "identities.Identity": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-07-24T12:00:00Z"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "example@domain.com"
+                },
+                "kind": {
+                    "description": "Kind — oauth или saml.",
+                    "type": "string",
+                    "example": "oauth"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "google"
+                }
+            }
+        },
+        "identities.ListResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "identities": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/identities.Identity"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "introspect.Actor": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string",
+                    "example": "2"
+                }
+            }
+        },
+        "introspect.Response": {
+            "type": "object",
+            "properties": {
+                "act": {
+                    "description": "Act — сервис, получивший токен обменом (/token/exchange).",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/introspect.Actor"
+                        }
+                    ]
+                },
+                "active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "app_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "client_id": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "exp": {
+                    "type": "integer",
+                    "example": 1784894400
+                },
+                "jti": {
+                    "type": "string",
+                    "example": "3b241101-e2bb-4255-8caf-4136c566a962"
+                },
+                "org_id": {
+                    "description": "OrgID и OrgRole — организация сессии и роль пользователя в ней.",
+                    "type": "integer",
+                    "example": 42
+                },
+                "org_role": {
+                    "type": "string",
+                    "example": "admin"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "posts:write"
+                    ]
+                },
+                "roles": {
+                    "description": "Roles и Permissions — роли пользователя в приложении токена и их права.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "editor"
+                    ]
+                },
+                "scope": {
+                    "description": "Scope — scope'ы токена через пробел.",
+                    "type": "string",
+                    "example": "profile orders:read"
+                },
+                "sub": {
+                    "type": "string",
+                    "example": "234"
+                },
+                "token_type": {
+                    "type": "string",
+                    "example": "access_token"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "newUser2008"
+                }
+            }
+        },
+        "ipRules.CreateResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "ip_rule": {
+                    "$ref": "#/definitions/ipRules.IPRule"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "ipRules.IPRule": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "deny"
+                },
+                "cidr": {
+                    "type": "string",
+                    "example": "203.0.113.0/24"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-10-16T12:00:00Z"
+                },
+                "created_by": {
+                    "type": "string",
+                    "example": "admin:root"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2026-11-01T00:00:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "ticket #4821: credential stuffing"
+                }
+            }
+        },
+        "ipRules.ListResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "ip_rules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ipRules.IPRule"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "ipRules.Request": {
+            "type": "object",
+            "required": [
+                "action",
+                "cidr"
+            ],
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "allow",
+                        "deny"
+                    ],
+                    "example": "deny"
+                },
+                "cidr": {
+                    "description": "CIDR — сеть или одиночный адрес (тогда /32 или /128)",
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "203.0.113.0/24"
+                },
+                "expires_at": {
+                    "description": "ExpiresAt — без значения правило бессрочное",
+                    "type": "string",
+                    "example": "2026-11-01T00:00:00Z"
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "ticket #4821: credential stuffing"
+                }
+            }
+        },
+        "jwks.Response": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/jwt.JWK"
+                    }
+                }
+            }
+        },
+        "jwt.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "description": "EC",
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "description": "RSA",
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                },
+                "y": {
+                    "type": "string"
+                }
+            }
+        },
+        "link.Response": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "redirect_url": {
+                    "type": "string",
+                    "example": "https://www.google.com/"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "logout.AllResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "sessions_terminated": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "maintenanceHandler.Response": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "миграция БД"
+                },
+                "source": {
+                    "type": "string",
+                    "example": "redis"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                },
+                "window": {
+                    "$ref": "#/definitions/maintenanceHandler.Window"
+                }
+            }
+        },
+        "maintenanceHandler.Window": {
+            "type": "object",
+            "properties": {
+                "ends_at": {
+                    "type": "string",
+                    "example": "2026-07-24T12:30:00Z"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "миграция БД"
+                },
+                "starts_at": {
+                    "type": "string",
+                    "example": "2026-07-24T12:00:00Z"
+                }
+            }
+        },
+        "oidc.AuthorizeRequest": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "code_challenge": {
+                    "type": "string",
+                    "example": "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+                },
+                "code_challenge_method": {
+                    "type": "string",
+                    "example": "S256"
+                },
+                "nonce": {
+                    "type": "string",
+                    "example": "n-0S6_WzA2Mj"
+                },
+                "redirect_uri": {
+                    "type": "string",
+                    "example": "https://app.example.com/callback"
+                },
+                "response_type": {
+                    "type": "string",
+                    "example": "code"
+                },
+                "scope": {
+                    "type": "string",
+                    "example": "openid email profile"
+                },
+                "state": {
+                    "type": "string",
+                    "example": "af0ifjsldkj"
+                }
+            }
+        },
+        "oidcHandler.ApproveResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "redirect_to": {
+                    "description": "RedirectTo — redirect_uri клиента с code и state (или error), куда\nстраница логина отправляет браузер.",
+                    "type": "string",
+                    "example": "https://app.example.com/callback?code=SplxlOBeZQQYbYS6WxSbIA\u0026state=af0ifjsldkj"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "oidcHandler.Discovery": {
+            "type": "object",
+            "properties": {
+                "authorization_endpoint": {
+                    "type": "string",
+                    "example": "https://auth.example.com/oauth2/authorize"
+                },
+                "claims_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "code_challenge_methods_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "grant_types_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id_token_signing_alg_values_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "introspection_endpoint": {
+                    "type": "string",
+                    "example": "https://auth.example.com/token/introspect"
+                },
+                "issuer": {
+                    "type": "string",
+                    "example": "https://auth.example.com"
+                },
+                "jwks_uri": {
+                    "type": "string",
+                    "example": "https://auth.example.com/.well-known/jwks.json"
+                },
+                "response_types_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scopes_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "subject_types_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token_endpoint": {
+                    "type": "string",
+                    "example": "https://auth.example.com/oauth2/token"
+                },
+                "token_endpoint_auth_methods_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "userinfo_endpoint": {
+                    "type": "string",
+                    "example": "https://auth.example.com/oauth2/userinfo"
+                }
+            }
+        },
+        "oidcHandler.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "invalid_grant"
+                },
+                "error_description": {
+                    "type": "string",
+                    "example": "invalid grant"
+                }
+            }
+        },
+        "oidcHandler.TokenResponse": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJSUzI1NiIsImtpZCI6..."
+                },
+                "expires_in": {
+                    "type": "integer",
+                    "example": 900
+                },
+                "id_token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJSUzI1NiIsImtpZCI6..."
+                },
+                "refresh_token": {
+                    "type": "string",
+                    "example": "3b241101-e2bb-4255-8caf-4136c566a962.dgsadfg..."
+                },
+                "scope": {
+                    "type": "string",
+                    "example": "openid email profile"
+                },
+                "token_type": {
+                    "type": "string",
+                    "example": "Bearer"
+                }
+            }
+        },
+        "oidcHandler.UserInfoResponse": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "email_verified": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "preferred_username": {
+                    "type": "string",
+                    "example": "newUser2008"
+                },
+                "sub": {
+                    "type": "string",
+                    "example": "234"
+                }
+            }
+        },
+        "orgInvitations.AcceptRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "orgInvitations.AcceptResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "org_id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "role": {
+                    "type": "string",
+                    "example": "member"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "orgInvitations.DeclineRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "orgInvitations.InviteRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "role"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "example@domain.com"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "admin",
+                        "member"
+                    ],
+                    "example": "member"
+                }
+            }
+        },
+        "orgInvitations.InviteResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2026-07-31T12:00:00Z"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "orgInvitations.Response": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "orgInvitations.SignUpRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "token",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "SecurePass123!"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "newUser2008"
+                }
+            }
+        },
+        "orgInvitations.SignUpResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "org_id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "role": {
+                    "type": "string",
+                    "example": "member"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "orgMembers.ListResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/orgMembers.Member"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "orgMembers.Member": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-07-24T12:00:00Z"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "example@domain.com"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "member"
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "orgMembers.Request": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "owner",
+                        "admin",
+                        "member"
+                    ],
+                    "example": "admin"
+                }
+            }
+        },
+        "orgMembers.Response": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "orgs.ListResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "organizations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/orgs.Organization"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "orgs.Organization": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Acme Inc."
+                },
+                "role": {
+                    "description": "Role — роль текущего пользователя в организации.",
+                    "type": "string",
+                    "example": "owner"
+                }
+            }
+        },
+        "orgs.Request": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "example": "Acme Inc."
+                }
+            }
+        },
+        "orgs.Response": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "organization": {
+                    "$ref": "#/definitions/orgs.Organization"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "passwordpolicy.Violation": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "password must be at least 8 characters long"
+                },
+                "rule": {
+                    "type": "string",
+                    "example": "min_length"
+                }
+            }
+        },
+        "requestRestoreConfirmation.Request": {
+            "type": "object",
+            "required": [
+                "app_id",
+                "email"
+            ],
+            "properties": {
+                "app_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "email": {
+                    "type": "string",
+                    "example": "example@domain.com"
+                }
+            }
+        },
+        "response.Code": {
+            "type": "string",
+            "enum": [
+                "REQUEST_MALFORMED",
+                "REQUEST_VALIDATION_FAILED",
+                "REQUEST_INVALID_PARAMETER",
+                "REQUEST_RATE_LIMITED",
+                "REQUEST_CHALLENGE_REQUIRED",
+                "REQUEST_IP_BLOCKED",
+                "INTERNAL_ERROR",
+                "SERVICE_UNAVAILABLE",
+                "SERVICE_MAINTENANCE",
+                "SERVICE_NOT_IMPLEMENTED",
+                "AUTH_INVALID_CREDENTIALS",
+                "AUTH_INVALID_ACCESS_TOKEN",
+                "AUTH_INVALID_TOKEN",
+                "AUTH_SESSION_EXPIRED",
+                "AUTH_EMAIL_NOT_VERIFIED",
+                "AUTH_PASSWORD_RESET_REQUIRED",
+                "AUTH_ACCOUNT_LOCKED",
+                "AUTH_SUSPICIOUS_LOGIN",
+                "AUTH_INVALID_APP",
+                "AUTH_INVALID_SCOPE",
+                "AUTH_NOT_APP_MEMBER",
+                "AUTH_INVALID_CLIENT",
+                "AUTH_INVALID_CONFIRMATION",
+                "AUTH_CSRF_MISMATCH",
+                "AUTH_INVALID_API_KEY",
+                "AUTH_INVALID_VERIFICATION_CODE",
+                "AUTH_SESSION_LIMIT_REACHED",
+                "TWO_FACTOR_ALREADY_ENABLED",
+                "TWO_FACTOR_NOT_ENABLED",
+                "TWO_FACTOR_METHOD_MISMATCH",
+                "TWO_FACTOR_NO_FACTOR_AVAILABLE",
+                "TWO_FACTOR_INVALID_CODE",
+                "TWO_FACTOR_TOTP_NOT_ENROLLED",
+                "TWO_FACTOR_CHANNEL_UNAVAILABLE",
+                "TWO_FACTOR_CHANNEL_UNDELIVERABLE",
+                "TWO_FACTOR_TRUSTED_DEVICE_NOT_FOUND",
+                "ACCOUNT_NOT_FOUND",
+                "ACCOUNT_ALREADY_EXISTS",
+                "ACCOUNT_DELETED",
+                "ACCOUNT_DISABLED",
+                "ACCOUNT_SUSPENDED",
+                "ACCOUNT_BANNED",
+                "ACCOUNT_INVALID_STATUS_TRANSITION",
+                "ACCOUNT_EMAIL_TAKEN",
+                "ACCOUNT_USERNAME_TAKEN",
+                "ACCOUNT_SAME_EMAIL",
+                "ACCOUNT_EMAIL_ALREADY_VERIFIED",
+                "ACCOUNT_SAME_PASSWORD",
+                "ACCOUNT_WEAK_PASSWORD",
+                "IDENTITY_UNKNOWN_PROVIDER",
+                "IDENTITY_NOT_FOUND",
+                "IDENTITY_CONFLICT",
+                "IDENTITY_LAST_AUTH_METHOD",
+                "IDENTITY_INVALID_STATE",
+                "IDENTITY_EMAIL_NOT_VERIFIED",
+                "IDENTITY_ACCESS_DENIED",
+                "IDENTITY_INVALID_REDIRECT_URI",
+                "ORG_NOT_FOUND",
+                "ORG_MEMBER_NOT_FOUND",
+                "ORG_NOT_MEMBER",
+                "ORG_FORBIDDEN",
+                "ORG_ALREADY_MEMBER",
+                "ORG_LAST_OWNER",
+                "ORG_INVALID_INVITATION",
+                "ORG_INVITEE_ACCOUNT_EXISTS",
+                "ROLE_INVALID",
+                "ROLE_NOT_FOUND",
+                "ROLE_ALREADY_EXISTS",
+                "APP_NOT_FOUND",
+                "SIGNING_KEY_CONFLICT",
+                "API_KEY_NOT_FOUND",
+                "IP_RULE_NOT_FOUND",
+                "IP_RULE_ALREADY_EXISTS"
+            ],
+            "x-enum-varnames": [
+                "CodeMalformedRequest",
+                "CodeValidationFailed",
+                "CodeInvalidParameter",
+                "CodeRateLimited",
+                "CodeChallengeRequired",
+                "CodeIPBlocked",
+                "CodeInternal",
+                "CodeServiceUnavailable",
+                "CodeMaintenance",
+                "CodeNotImplemented",
+                "CodeInvalidCredentials",
+                "CodeInvalidAccessToken",
+                "CodeInvalidToken",
+                "CodeSessionExpired",
+                "CodeEmailNotVerified",
+                "CodePasswordResetRequired",
+                "CodeAccountLocked",
+                "CodeSuspiciousLogin",
+                "CodeInvalidApp",
+                "CodeInvalidScope",
+                "CodeNotAppMember",
+                "CodeInvalidClient",
+                "CodeInvalidConfirmation",
+                "CodeCSRFMismatch",
+                "CodeInvalidAPIKey",
+                "CodeInvalidVerificationCode",
+                "CodeSessionLimit",
+                "CodeTwoFAAlreadyEnabled",
+                "CodeTwoFANotEnabled",
+                "CodeTwoFAMethodMismatch",
+                "CodeTwoFANoFactor",
+                "CodeInvalidCode",
+                "CodeTOTPNotEnrolled",
+                "CodeTwoFAChannelUnavailable",
+                "CodeTwoFAChannelUndeliverable",
+                "CodeTrustedDeviceNotFound",
+                "CodeUserNotFound",
+                "CodeUserAlreadyExists",
+                "CodeAccountDeleted",
+                "CodeAccountDisabled",
+                "CodeAccountSuspended",
+                "CodeAccountBanned",
+                "CodeInvalidStatusChange",
+                "CodeEmailTaken",
+                "CodeUsernameTaken",
+                "CodeSameEmail",
+                "CodeEmailAlreadyVerified",
+                "CodeSamePassword",
+                "CodeWeakPassword",
+                "CodeUnknownProvider",
+                "CodeIdentityNotFound",
+                "CodeIdentityConflict",
+                "CodeLastAuthMethod",
+                "CodeInvalidOAuthState",
+                "CodeProviderEmailUnverified",
+                "CodeAccessDenied",
+                "CodeInvalidRedirectURI",
+                "CodeOrgNotFound",
+                "CodeOrgMemberNotFound",
+                "CodeNotOrgMember",
+                "CodeOrgForbidden",
+                "CodeOrgAlreadyMember",
+                "CodeOrgLastOwner",
+                "CodeInvalidInvitation",
+                "CodeInviteeAccountExists",
+                "CodeInvalidRole",
+                "CodeRoleNotFound",
+                "CodeRoleAlreadyExists",
+                "CodeAppNotFound",
+                "CodeSigningKeyConflict",
+                "CodeAPIKeyNotFound",
+                "CodeIPRuleNotFound",
+                "CodeIPRuleExists"
+            ]
+        },
+        "response.PasswordPolicyResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                },
+                "violations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/passwordpolicy.Violation"
+                    }
+                }
+            }
+        },
+        "restore.Request": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "example@domain.com"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "SecurePass123!"
+                },
+                "session_id": {
+                    "type": "string",
+                    "example": "fkajeDJ1p3FJ..."
+                },
+                "token": {
+                    "type": "string",
+                    "example": "abcDEF123..."
+                }
+            }
+        },
+        "roles.ListResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/roles.Role"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "roles.Request": {
+            "type": "object",
+            "required": [
+                "name",
+                "permissions"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "Редактор контента"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "editor"
+                },
+                "permissions": {
+                    "description": "Permissions — права роли; формат строк определяет приложение.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "posts:write"
+                    ]
+                }
+            }
+        },
+        "roles.Response": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
                         }
-                    }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "role": {
+                    "$ref": "#/definitions/roles.Role"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
                 }
             }
         },
-        "/metrics": {
-            "get": {
-                "description": "Отдаёт метрики сервиса в формате Prometheus exposition format.\nНе предназначен для вызова из браузера/фронтенда — эндпоинт\nдля scrape'а Prometheus-сервером.",
-                "produces": [
-                    "text/plain"
-                ],
-                "tags": [
-                    "System"
-                ],
-                "summary": "Prometheus метрики",
-                "responses": {
-                    "200": {
-                        "description": "Метрики в текстовом формате Prometheus",
-                        "schema": {
-                            "type": "string"
+        "roles.Role": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-07-24T12:00:00Z"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Редактор контента"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "name": {
+                    "type": "string",
+                    "example": "editor"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "posts:write"
+                    ]
+                }
+            }
+        },
+        "rotateSigningKey.Response": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string",
+                    "example": "RS256"
+                },
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
                         }
-                    }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "kid": {
+                    "type": "string",
+                    "example": "mX3f1y0sVb2cQk9a"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
                 }
             }
-        }
-    },
-    "definitions": {
-        "internal_http_server_handlers_account_delete.Request": {
+        },
+        "self.Response": {
             "type": "object",
             "properties": {
-                "password": {
+                "app_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
                     "type": "string",
-                    "example": "SecurePass123!"
+                    "example": "error"
                 },
-                "session_id": {
+                "expires_at": {
                     "type": "string",
-                    "example": "abcDEF123..."
+                    "example": "2027-01-01T00:00:00Z"
                 },
-                "token": {
+                "key_id": {
                     "type": "string",
-                    "example": "fkajeDJ1p3FJ..."
+                    "example": "5f0c3a4e-8d1b-4f7a-9c2e-1b3d5e7f9a0b"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "nightly-export"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "reports:read"
+                    ]
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
                 }
             }
         },
-        "internal_http_server_handlers_account_request_restore_confirmation.Request": {
+        "sessions.Response": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/sessions.Session"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "sessions.Session": {
             "type": "object",
-            "required": [
-                "app_id",
-                "email"
-            ],
             "properties": {
                 "app_id": {
                     "type": "integer",
                     "example": 1
                 },
-                "email": {
+                "created_at": {
                     "type": "string",
-                    "example": "example@domain.com"
+                    "example": "2026-07-24T12:00:00Z"
+                },
+                "device_id": {
+                    "type": "string",
+                    "example": "3f2b7c1e-ios"
+                },
+                "device_name": {
+                    "type": "string",
+                    "example": "iPhone 15"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2026-08-24T12:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "user_agent": {
+                    "type": "string",
+                    "example": "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X)"
                 }
             }
         },
-        "internal_http_server_handlers_account_restore.Request": {
+        "trustedDevices.Device": {
             "type": "object",
-            "required": [
-                "email"
-            ],
             "properties": {
-                "email": {
+                "created_at": {
                     "type": "string",
-                    "example": "example@domain.com"
+                    "example": "2026-07-24T12:00:00Z"
                 },
-                "password": {
+                "device_id": {
                     "type": "string",
-                    "example": "SecurePass123!"
+                    "example": "b7c1e0a2-5f3d-4c1e-9a8b-2d6f0e4c7a91"
                 },
-                "session_id": {
+                "device_name": {
                     "type": "string",
-                    "example": "fkajeDJ1p3FJ..."
+                    "example": "iPhone 15"
                 },
-                "token": {
+                "expires_at": {
                     "type": "string",
-                    "example": "abcDEF123..."
+                    "example": "2026-08-23T12:00:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2026-07-30T08:15:00Z"
+                },
+                "user_agent": {
+                    "type": "string",
+                    "example": "Mozilla/5.0"
+                }
+            }
+        },
+        "trustedDevices.ListResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "devices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/trustedDevices.Device"
+                    }
+                },
+                "error": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
                 }
             }
         },
-        "internal_http_server_handlers_infrastructure_health.Response": {
+        "trustedDevices.RevokeAllResponse": {
             "type": "object",
             "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
                 "error": {
                     "type": "string",
                     "example": "error"
                 },
+                "revoked": {
+                    "type": "integer",
+                    "example": 3
+                },
                 "status": {
                     "type": "string",
                     "example": "ok"
                 }
             }
         },
-        "internal_http_server_handlers_oauth_accounts.Account": {
+        "twoFactor.MagicLink": {
             "type": "object",
             "properties": {
+                "app_id": {
+                    "type": "integer",
+                    "example": 1
+                },
                 "created_at": {
                     "type": "string",
                     "example": "2026-07-24T12:00:00Z"
                 },
-                "email": {
+                "expires_at": {
                     "type": "string",
-                    "example": "example@domain.com"
+                    "example": "2026-07-24T12:10:00Z"
                 },
-                "provider": {
+                "id": {
+                    "type": "integer",
+                    "example": 412
+                },
+                "ip": {
                     "type": "string",
-                    "example": "google"
+                    "example": "203.0.113.7"
+                },
+                "user_agent": {
+                    "type": "string",
+                    "example": "Mozilla/5.0"
                 }
             }
         },
-        "internal_http_server_handlers_oauth_accounts.Response": {
+        "twoFactor.Request": {
             "type": "object",
             "properties": {
-                "accounts": {
+                "channel": {
+                    "description": "Channel — куда доставлять magic link; destination — номер в E.164\nдля sms, chat_id для telegram.",
+                    "type": "string",
+                    "enum": [
+                        "email",
+                        "sms",
+                        "telegram"
+                    ],
+                    "example": "sms"
+                },
+                "destination": {
+                    "type": "string",
+                    "example": "+79991234567"
+                },
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "password": {
+                    "description": "Подтверждение выключения 2FA — как в /auth/2fa/magic-link/disable.",
+                    "type": "string",
+                    "example": "SecurePass123!"
+                },
+                "session_id": {
+                    "type": "string",
+                    "example": "abcDEF123..."
+                },
+                "token": {
+                    "type": "string",
+                    "example": "fkajeDJ1p3FJ..."
+                }
+            }
+        },
+        "twoFactor.Response": {
+            "type": "object",
+            "properties": {
+                "active_magic_links": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/internal_http_server_handlers_oauth_accounts.Account"
+                        "$ref": "#/definitions/twoFactor.MagicLink"
                     }
                 },
+                "channel": {
+                    "type": "string",
+                    "example": "sms"
+                },
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
+                "destination": {
+                    "type": "string",
+                    "example": "+79991234567"
+                },
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                },
                 "error": {
                     "type": "string",
                     "example": "error"
                 },
+                "method": {
+                    "description": "Method — magic_link или totp, пусто при выключенной 2FA.",
+                    "type": "string",
+                    "example": "magic_link"
+                },
                 "status": {
                     "type": "string",
                     "example": "ok"
                 }
             }
         },
-        "internal_http_server_handlers_oauth_callback.Response": {
+        "userRoles.AssignRequest": {
             "type": "object",
+            "required": [
+                "app_id",
+                "role"
+            ],
             "properties": {
-                "access_token": {
+                "app_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "reason": {
+                    "description": "Reason — причина для журнала аудита (номер обращения и т.п.)",
                     "type": "string",
-                    "example": "fkajeDJ1p3FJ..."
+                    "maxLength": 500,
+                    "example": "ticket #4821: подтверждён захват аккаунта"
+                },
+                "role": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "editor"
+                }
+            }
+        },
+        "userRoles.ListResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
                 },
                 "error": {
                     "type": "string",
                     "example": "error"
                 },
-                "refresh_token": {
-                    "type": "string",
-                    "example": "abcDEF123..."
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/roles.Role"
+                    }
                 },
                 "status": {
                     "type": "string",
@@ -2085,22 +9967,56 @@ const docTemplate = `{
                 }
             }
         },
-        "internal_http_server_handlers_oauth_link.Response": {
+        "userRoles.Response": {
             "type": "object",
             "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "AUTH_INVALID_CREDENTIALS"
+                },
                 "error": {
                     "type": "string",
                     "example": "error"
                 },
-                "redirect_url": {
-                    "type": "string",
-                    "example": "https://www.google.com/"
-                },
                 "status": {
                     "type": "string",
                     "example": "ok"
                 }
             }
+        },
+        "verifyCode.Request": {
+            "type": "object",
+            "required": [
+                "code",
+                "email"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "042917"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "example@domain.com"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "Access токен: \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
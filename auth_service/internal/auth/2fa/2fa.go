@@ -8,9 +8,12 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"regexp"
 	"time"
 
 	"auth_service/internal/config"
+	"auth_service/internal/lib/clientinfo"
+	"auth_service/internal/lib/notifier"
 	"auth_service/internal/models"
 	"auth_service/internal/storage"
 )
@@ -18,19 +21,33 @@ import (
 var (
 	ErrMagicLinkVerificationFailed = errors.New("lagic link verification failed")
 	ErrActionMismatch              = errors.New("action mismatch")
+
+	ErrChannelUnavailable = errors.New("2fa channel is not available")
+	ErrInvalidDestination = errors.New("invalid 2fa channel destination")
+	ErrChannelDelivery    = errors.New("failed to deliver test message to 2fa channel")
 )
 
-type Publisher interface {
-	SendMessage(ctx context.Context, msg models.Message) error
+// Notifier доставляет сообщение второго фактора по одному каналу: to —
+// адрес в этом канале (email, номер телефона, chat_id).
+type Notifier interface {
+	Notify(ctx context.Context, to string, n notifier.Notification) error
 }
 
+var (
+	phoneRe  = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+	chatIDRe = regexp.MustCompile(`^-?[0-9]{1,20}$`)
+)
+
 type PostgresRepo interface {
 	UserByID(ctx context.Context, id int64) (*models.User, error)
 
 	SaveMagicLink(ctx context.Context, link *models.MagicLink) error
 	ConsumeMagicLink(ctx context.Context, tokenHash []byte) (*models.MagicLink, error)
-	InvalidateMagicLinksByUserID(ctx context.Context, userID int64) (int64, error)
 	CleanupExpiredMagicLinks(ctx context.Context) (int, error)
+	ActiveMagicLinksByUserID(ctx context.Context, userID int64) ([]models.MagicLink, error)
+
+	TwoFADelivery(ctx context.Context, userID int64) (*models.TwoFADelivery, error)
+	SetTwoFADelivery(ctx context.Context, userID int64, delivery models.TwoFADelivery) error
 }
 
 type RedisRepo interface {
@@ -41,27 +58,32 @@ type RedisRepo interface {
 
 type TwoFactorAuthentificator struct {
 	pg          PostgresRepo
+	uow         storage.UoW
 	redis       RedisRepo
-	publisher   Publisher
+	notifiers   map[models.TwoFAChannel]Notifier // email обязателен
 	log         *slog.Logger
 	tokenTTL    time.Duration
 	redirectURL string
+	baseURL     string // база ссылки в письме
 }
 
 func New(
 	pg PostgresRepo,
+	uow storage.UoW,
 	redis RedisRepo,
-	publisher Publisher,
+	notifiers map[models.TwoFAChannel]Notifier,
 	log *slog.Logger,
 	cfg *config.Config,
 ) *TwoFactorAuthentificator {
 	return &TwoFactorAuthentificator{
 		pg:          pg,
+		uow:         uow,
 		redis:       redis,
-		publisher:   publisher,
+		notifiers:   notifiers,
 		log:         log,
 		tokenTTL:    cfg.TwoFactorAuth.TokenTTL,
 		redirectURL: cfg.TwoFactorAuth.RedirectURL,
+		baseURL:     cfg.HTTPServer.PublicBaseURL,
 	}
 }
 
@@ -69,44 +91,197 @@ func New(
 func (s *TwoFactorAuthentificator) SendMagicLink(ctx context.Context, req *models.SendMagicLinkRequest, sessionID string) error {
 	const op = "twoFactorAuth.Service.SendMagicLink"
 
+	magicLink, rawToken, err := s.newMagicLink(ctx, req, sessionID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.pg.SaveMagicLink(ctx, magicLink); err != nil {
+		return fmt.Errorf("%s: save: %w", op, err)
+	}
+
+	if err := s.publishMagicLink(ctx, req, sessionID, rawToken); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// * newMagicLink генерирует selector/verifier и собирает запись для БД.
+// В БД уходит только хеш verifier'а, rawToken — только в письмо.
+func (s *TwoFactorAuthentificator) newMagicLink(
+	ctx context.Context,
+	req *models.SendMagicLinkRequest,
+	sessionID string,
+) (*models.MagicLink, string, error) {
 	selector, verifier, err := generateSelectorVerifier()
 	if err != nil {
-		return fmt.Errorf("%s: generate token: %w", op, err)
+		return nil, "", fmt.Errorf("generate token: %w", err)
 	}
 
-	verifierHash := hashVerifier(verifier)
-	expiresAt := time.Now().Add(s.tokenTTL)
+	// откуда запрошен код — для разбора подозрительных входов
+	info := clientinfo.FromContext(ctx)
 
 	magicLink := &models.MagicLink{
 		UserID:    req.UserID,
 		AppID:     req.AppID,
-		TokenHash: verifierHash,
+		TokenHash: hashVerifier(verifier),
 		SessionID: sessionID,
-		ExpiresAt: expiresAt,
+		IPAddress: info.IP,
+		UserAgent: info.UserAgent,
+		ExpiresAt: time.Now().Add(s.tokenTTL),
 	}
 
-	if err := s.pg.SaveMagicLink(ctx, magicLink); err != nil {
-		return fmt.Errorf("%s: save: %w", op, err)
-	}
+	return magicLink, selector + "." + verifier, nil
+}
 
-	rawToken := selector + "." + verifier
-	magicLinkURL := fmt.Sprintf("/auth/2fa/magic-link/verify#token=%s", rawToken)
+func (s *TwoFactorAuthentificator) publishMagicLink(
+	ctx context.Context,
+	req *models.SendMagicLinkRequest,
+	sessionID, rawToken string,
+) error {
+	magicLinkURL := fmt.Sprintf("%s/auth/2fa/magic-link/verify#token=%s", s.baseURL, rawToken)
 
-	msg := models.Message{
-		Email:   req.Email,
+	n := notifier.Notification{
+		Purpose: notifier.PurposeMagicLink,
 		Link:    magicLinkURL,
-		Purpose: "2fa",
 	}
 
-	if err := s.publisher.SendMessage(ctx, msg); err != nil {
-		return fmt.Errorf("%s: enqueue message: %w", op, err)
+	channel := s.deliveryFor(ctx, req.UserID)
+
+	if channel.Channel != models.TwoFAChannelEmail {
+		err := s.notifiers[channel.Channel].Notify(ctx, channel.Destination, n)
+		if err == nil {
+			s.logIssued(req, sessionID, channel.Channel)
+			return nil
+		}
+
+		// email есть у всех, поэтому сбой SMS или Telegram не оставляет
+		// пользователя без ссылки
+		s.log.Warn("failed to send magic link, falling back to email",
+			slog.Int64("user_id", req.UserID),
+			slog.String("channel", string(channel.Channel)),
+			slog.Any("err", err),
+		)
+	}
+
+	if err := s.notifiers[models.TwoFAChannelEmail].Notify(ctx, req.Email, n); err != nil {
+		return fmt.Errorf("enqueue message: %w", err)
 	}
 
+	s.logIssued(req, sessionID, models.TwoFAChannelEmail)
+
+	return nil
+}
+
+func (s *TwoFactorAuthentificator) logIssued(req *models.SendMagicLinkRequest, sessionID string, channel models.TwoFAChannel) {
 	s.log.Info("magic link issued",
 		slog.Int64("user_id", req.UserID),
 		slog.Int("app_id", int(req.AppID)),
 		slog.String("session_id", sessionID),
+		slog.String("channel", string(channel)),
 	)
+}
+
+// * deliveryFor — канал, выбранный пользователем. Если канал не выбран,
+// выключен в конфиге или не читается из БД — email.
+func (s *TwoFactorAuthentificator) deliveryFor(ctx context.Context, userID int64) models.TwoFADelivery {
+	const op = "twoFactorAuth.Service.deliveryFor"
+
+	email := models.TwoFADelivery{Channel: models.TwoFAChannelEmail}
+
+	delivery, err := s.pg.TwoFADelivery(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrTwoFADeliveryNotFound) {
+			s.log.Warn("failed to get 2fa channel", slog.String("op", op), slog.Any("err", err))
+		}
+
+		return email
+	}
+
+	if _, ok := s.notifiers[delivery.Channel]; !ok {
+		s.log.Warn("2fa channel is disabled, falling back to email",
+			slog.String("op", op),
+			slog.Int64("user_id", userID),
+			slog.String("channel", string(delivery.Channel)),
+		)
+
+		return email
+	}
+
+	return *delivery
+}
+
+// * Delivery возвращает канал доставки magic link пользователя.
+func (s *TwoFactorAuthentificator) Delivery(ctx context.Context, userID int64) (*models.TwoFADelivery, error) {
+	const op = "twoFactorAuth.Service.Delivery"
+
+	delivery, err := s.pg.TwoFADelivery(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrTwoFADeliveryNotFound) {
+			return &models.TwoFADelivery{Channel: models.TwoFAChannelEmail}, nil
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return delivery, nil
+}
+
+// * ActiveMagicLinks возвращает ещё не использованные и не истёкшие ссылки
+// пользователя — чтобы он видел, откуда их запрашивали.
+func (s *TwoFactorAuthentificator) ActiveMagicLinks(ctx context.Context, userID int64) ([]models.MagicLink, error) {
+	const op = "twoFactorAuth.Service.ActiveMagicLinks"
+
+	links, err := s.pg.ActiveMagicLinksByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
+
+// * SetChannel выбирает канал доставки magic link. Перед сохранением в канал
+// уходит проверочное сообщение: опечатка в номере или chat_id, которому бот
+// не может писать, обнаружится сразу, а не при следующем входе.
+func (s *TwoFactorAuthentificator) SetChannel(ctx context.Context, userID int64, delivery models.TwoFADelivery) error {
+	const op = "twoFactorAuth.Service.SetChannel"
+
+	n, ok := s.notifiers[delivery.Channel]
+	if !ok {
+		return ErrChannelUnavailable
+	}
+
+	switch delivery.Channel {
+	case models.TwoFAChannelEmail:
+		delivery.Destination = ""
+	case models.TwoFAChannelSMS:
+		if !phoneRe.MatchString(delivery.Destination) {
+			return ErrInvalidDestination
+		}
+	case models.TwoFAChannelTelegram:
+		if !chatIDRe.MatchString(delivery.Destination) {
+			return ErrInvalidDestination
+		}
+	}
+
+	if delivery.Channel != models.TwoFAChannelEmail {
+		err := n.Notify(ctx, delivery.Destination, notifier.Notification{Purpose: notifier.PurposeChannelTest})
+		if err != nil {
+			s.log.Warn("failed to deliver 2fa channel test message",
+				slog.String("op", op),
+				slog.Int64("user_id", userID),
+				slog.String("channel", string(delivery.Channel)),
+				slog.Any("err", err),
+			)
+
+			return ErrChannelDelivery
+		}
+	}
+
+	if err := s.pg.SetTwoFADelivery(ctx, userID, delivery); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
 	return nil
 }
@@ -115,23 +290,23 @@ func (s *TwoFactorAuthentificator) SendMagicLink(ctx context.Context, req *model
 func (s *TwoFactorAuthentificator) VerifyLogin(
 	ctx context.Context,
 	sessionID, rawToken string,
-) (userID int64, appID int32, err error) {
+) (*models.PendingSession, error) {
 	const op = "twoFactorAuth.Service.VerifyLogin"
 
-	link, err := s.verifyToken(ctx, sessionID, rawToken, models.ActionLogin2FA)
+	pending, err := s.verifyToken(ctx, sessionID, rawToken, models.ActionLogin2FA)
 	if err != nil {
 		if errors.Is(err, ErrMagicLinkVerificationFailed) || errors.Is(err, storage.ErrMagicLinkNotFound) {
-			return 0, 0, err
+			return nil, err
 		}
 
-		return 0, 0, fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	if err := s.redis.DeletePendingSession(ctx, sessionID); err != nil {
 		s.log.Warn("failed to delete pending session", slog.String("op", op), slog.Any("err", err))
 	}
 
-	return link.UserID, link.AppID, nil
+	return pending, nil
 }
 
 // * RequestChallenge инициирует 2FA-челлендж после успешной проверки пароля на этапе логина.
@@ -139,11 +314,12 @@ func (s *TwoFactorAuthentificator) RequestChallenge(
 	ctx context.Context,
 	user *models.User,
 	appID int32,
+	scopes []string,
 	pendingSessionTTL time.Duration,
 ) (string, error) {
 	const op = "twoFactorAuth.Service.RequestChallenge"
 
-	sessionID, err := s.issueMagicLink(ctx, user, appID, models.ActionLogin2FA, pendingSessionTTL)
+	sessionID, err := s.issueMagicLink(ctx, user, appID, models.ActionLogin2FA, scopes, pendingSessionTTL)
 	if err != nil {
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
@@ -162,12 +338,12 @@ func (s *TwoFactorAuthentificator) VerifyForAction(
 ) error {
 	const op = "twoFactorAuth.Service.VerifyForAction"
 
-	link, err := s.verifyToken(ctx, sessionID, rawToken, expectedAction)
+	pending, err := s.verifyToken(ctx, sessionID, rawToken, expectedAction)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if link.UserID != expectedUserID {
+	if pending.UserID != expectedUserID {
 		return fmt.Errorf("%s: user mismatch", op)
 	}
 
@@ -224,7 +400,7 @@ func (s *TwoFactorAuthentificator) RequestActionConfirmation(
 		return "", fmt.Errorf("%s: get user: %w", op, err)
 	}
 
-	sessionID, err := s.issueMagicLink(ctx, user, appID, action, pendingSessionTTL)
+	sessionID, err := s.issueMagicLink(ctx, user, appID, action, nil, pendingSessionTTL)
 	if err != nil {
 		s.log.Error("failed to issue action confirmation",
 			slog.String("op", op),
@@ -246,8 +422,9 @@ func (s *TwoFactorAuthentificator) Resend(ctx context.Context, sessionID string)
 		return fmt.Errorf("%s: pending session: %w", op, err)
 	}
 
-	if _, err := s.pg.InvalidateMagicLinksByUserID(ctx, pending.UserID); err != nil {
-		return fmt.Errorf("%s: invalidate previous: %w", op, err)
+	// TOTP-сессию подтверждают кодом из приложения, письмо ей не положено
+	if pending.Action == models.ActionLoginTOTP {
+		return fmt.Errorf("%s: %w", op, storage.ErrPendingSessionNotFound)
 	}
 
 	user, err := s.pg.UserByID(ctx, pending.UserID)
@@ -261,7 +438,29 @@ func (s *TwoFactorAuthentificator) Resend(ctx context.Context, sessionID string)
 		Email:  user.Email,
 	}
 
-	if err := s.SendMagicLink(ctx, req, sessionID); err != nil {
+	magicLink, rawToken, err := s.newMagicLink(ctx, req, sessionID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Инвалидация старой ссылки и сохранение новой — атомарно: при сбое
+	// между ними юзер не должен остаться вовсе без рабочей ссылки.
+	err = s.uow.Do(ctx, func(ctx context.Context, tx storage.Tx) error {
+		if _, err := tx.MagicLinks().InvalidateMagicLinksByUserID(ctx, pending.UserID); err != nil {
+			return fmt.Errorf("invalidate previous: %w", err)
+		}
+
+		if err := tx.MagicLinks().SaveMagicLink(ctx, magicLink); err != nil {
+			return fmt.Errorf("save: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.publishMagicLink(ctx, req, sessionID, rawToken); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -274,6 +473,7 @@ func (s *TwoFactorAuthentificator) issueMagicLink(
 	user *models.User,
 	appID int32,
 	action models.Action,
+	scopes []string,
 	pendingSessionTTL time.Duration,
 ) (sessionID string, err error) {
 	sessionID, err = generateSessionID()
@@ -285,6 +485,7 @@ func (s *TwoFactorAuthentificator) issueMagicLink(
 		UserID: user.ID,
 		AppID:  appID,
 		Action: action,
+		Scopes: scopes,
 	}
 
 	if err := s.redis.SetPendingSession(ctx, sessionID, session, pendingSessionTTL); err != nil {
@@ -304,12 +505,13 @@ func (s *TwoFactorAuthentificator) issueMagicLink(
 	return sessionID, nil
 }
 
-// * verifyToken — общее ядро проверки magic-link токена.
+// * verifyToken — общее ядро проверки magic-link токена. Возвращает
+// pending-сессию, которой принадлежит погашенный токен.
 func (s *TwoFactorAuthentificator) verifyToken(
 	ctx context.Context,
 	sessionID, rawToken string,
 	expectedAction models.Action,
-) (*models.MagicLink, error) {
+) (*models.PendingSession, error) {
 	const op = "twoFactorAuth.Service.verifyToken"
 
 	pending, err := s.redis.GetPendingSession(ctx, sessionID)
@@ -349,7 +551,7 @@ func (s *TwoFactorAuthentificator) verifyToken(
 		return nil, fmt.Errorf("%s: pending session mismatch: %w", op, ErrMagicLinkVerificationFailed)
 	}
 
-	return link, nil
+	return pending, nil
 }
 
 func splitToken(raw string) (selector, verifier string, ok bool) {
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"auth_service/internal/lib/clientinfo"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+const actorUser = "user"
+
+// Activity — лента событий безопасности пользователя, от новых к старым.
+// beforeID — курсор из предыдущей страницы, 0 — первая страница.
+func (a *Auth) Activity(ctx context.Context, userID, beforeID int64, limit int) ([]models.AuditEvent, error) {
+	const op = "Auth.Activity"
+
+	events, err := a.UsrProvider.AuditEventsByUserID(ctx, userID, models.ActivityActions, beforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return events, nil
+}
+
+// userEvent собирает событие, инициированное самим пользователем. IP и
+// User-Agent берутся из контекста HTTP-запроса.
+func userEvent(ctx context.Context, userID int64, action models.AuditAction, metadata map[string]any) *models.AuditEvent {
+	info := clientinfo.FromContext(ctx)
+
+	return &models.AuditEvent{
+		UserID:    userID,
+		Actor:     actorUser,
+		Action:    action,
+		IP:        info.IP,
+		UserAgent: info.UserAgent,
+		Metadata:  metadata,
+	}
+}
+
+// recordUserEvent пишет событие пользователя вне транзакции действия.
+// Ошибка записи только логируется: действие уже выполнено, и отвечать на
+// него ошибкой из-за журнала нельзя.
+func (a *Auth) recordUserEvent(ctx context.Context, userID int64, action models.AuditAction, metadata map[string]any) {
+	event := userEvent(ctx, userID, action, metadata)
+
+	err := a.UoW.Do(ctx, func(ctx context.Context, tx storage.Tx) error {
+		return tx.Audit().SaveAuditEvent(ctx, event)
+	})
+	if err != nil {
+		a.Log.Error("failed to save audit event",
+			slog.Int64("user_id", userID),
+			slog.String("action", string(action)),
+			sl.Err(err),
+		)
+	}
+}
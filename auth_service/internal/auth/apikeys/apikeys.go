@@ -0,0 +1,185 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/lib/tokens"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNotFound     = errors.New("api key not found")
+	ErrAppNotFound  = errors.New("app not found")
+	ErrInvalidScope = errors.New("scope is not allowed for this app")
+	ErrInvalidKey   = errors.New("invalid, expired or revoked api key")
+)
+
+type Repo interface {
+	SaveAPIKey(ctx context.Context, key *models.APIKey) error
+	APIKeysByAppID(ctx context.Context, appID int32) ([]models.APIKey, error)
+	APIKeyByID(ctx context.Context, id uuid.UUID) (*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, appID int32, id uuid.UUID) error
+	TouchAPIKey(ctx context.Context, id uuid.UUID) error
+}
+
+type AppProvider interface {
+	App(ctx context.Context, appID int32) (*models.App, error)
+}
+
+// Service выпускает и проверяет API-ключи машинных клиентов — batch-задач,
+// которые не могут пройти интерактивный логин. Ключ действует от имени
+// приложения с фиксированным набором scope'ов.
+type Service struct {
+	log  *slog.Logger
+	repo Repo
+	apps AppProvider
+}
+
+func New(log *slog.Logger, repo Repo, apps AppProvider) *Service {
+	return &Service{
+		log:  log,
+		repo: repo,
+		apps: apps,
+	}
+}
+
+// Create выпускает ключ приложения. Scope'ы ограничены allowed_scopes
+// приложения; expiresAt == nil — ключ бессрочный. Ключ возвращается один раз:
+// хранится только хеш его секретной части.
+func (s *Service) Create(
+	ctx context.Context,
+	appID int32,
+	name string,
+	scopes []string,
+	expiresAt *time.Time,
+	createdBy string,
+) (*models.APIKey, string, error) {
+	const op = "apikeys.Create"
+
+	app, err := s.apps.App(ctx, appID)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return nil, "", ErrAppNotFound
+		}
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(app.AllowedScopes, scope) {
+			return nil, "", ErrInvalidScope
+		}
+	}
+
+	id, rawKey, hash, err := tokens.NewAPIKey()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	key := &models.APIKey{
+		ID:        uuid.MustParse(id),
+		AppID:     appID,
+		Name:      name,
+		KeyHash:   hash,
+		Scopes:    scopes,
+		CreatedBy: createdBy,
+		ExpiresAt: expiresAt,
+	}
+
+	if err := s.repo.SaveAPIKey(ctx, key); err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return nil, "", ErrAppNotFound
+		}
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("api key created",
+		slog.String("op", op),
+		slog.Int("app_id", int(appID)),
+		slog.String("key_id", key.ID.String()),
+		slog.String("created_by", createdBy),
+	)
+
+	return key, rawKey, nil
+}
+
+// List — ключи приложения, включая отозванные.
+func (s *Service) List(ctx context.Context, appID int32) ([]models.APIKey, error) {
+	const op = "apikeys.List"
+
+	keys, err := s.repo.APIKeysByAppID(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// Revoke отзывает ключ приложения. Отзыв мгновенный: ключ проверяется по
+// БД на каждом запросе.
+func (s *Service) Revoke(ctx context.Context, appID int32, id uuid.UUID) error {
+	const op = "apikeys.Revoke"
+
+	if err := s.repo.RevokeAPIKey(ctx, appID, id); err != nil {
+		if errors.Is(err, storage.ErrAPIKeyNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("api key revoked",
+		slog.String("op", op),
+		slog.Int("app_id", int(appID)),
+		slog.String("key_id", id.String()),
+	)
+
+	return nil
+}
+
+// Authenticate проверяет ключ из заголовка запроса. Неизвестный, истёкший,
+// отозванный ключ и неверный секрет неразличимы для вызывающего —
+// ErrInvalidKey.
+func (s *Service) Authenticate(ctx context.Context, rawKey string) (*models.APIKey, error) {
+	const op = "apikeys.Authenticate"
+
+	id, secret, ok := tokens.ParseAPIKey(rawKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	key, err := s.repo.APIKeyByID(ctx, uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrAPIKeyNotFound) {
+			return nil, ErrInvalidKey
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !tokens.VerifyOpaqueToken(secret, key.KeyHash) {
+		return nil, ErrInvalidKey
+	}
+
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt)) {
+		return nil, ErrInvalidKey
+	}
+
+	// last_used_at — подсказка для ротации ключей, запрос из-за неё не падает
+	if err := s.repo.TouchAPIKey(ctx, key.ID); err != nil {
+		s.log.Warn("failed to touch api key", slog.String("key_id", key.ID.String()), sl.Err(err))
+	}
+
+	return key, nil
+}
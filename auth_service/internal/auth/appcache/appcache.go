@@ -0,0 +1,97 @@
+package appcache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"auth_service/internal/config"
+	"auth_service/internal/models"
+)
+
+// Repo — источник истины, Postgres.
+type Repo interface {
+	App(ctx context.Context, appID int32) (*models.App, error)
+	IsAppMember(ctx context.Context, userID int64, appID int32) (bool, error)
+}
+
+// Apps отдаёт приложения из памяти реплики, затем из Postgres: строка apps
+// читается на каждом логине, refresh и проверке API-ключа, а меняется
+// редко. В Redis строка не кладётся — в ней секрет подписи HS256.
+// Несуществующие приложения не кешируются — только что заведённое видно
+// сразу.
+type Apps struct {
+	repo Repo
+	cfg  config.Apps
+
+	mu    sync.Mutex
+	local map[int32]entry
+}
+
+type entry struct {
+	app       models.App
+	expiresAt time.Time
+}
+
+func New(repo Repo, cfg config.Apps) *Apps {
+	return &Apps{
+		repo:  repo,
+		cfg:   cfg,
+		local: make(map[int32]entry),
+	}
+}
+
+// * App реализует storage.AppProvider. Ошибки Postgres возвращаются как есть,
+// storage.ErrAppNotFound в том числе.
+func (a *Apps) App(ctx context.Context, appID int32) (*models.App, error) {
+	if a.cfg.CacheTTL <= 0 {
+		return a.repo.App(ctx, appID)
+	}
+
+	if app, ok := a.fromLocal(appID); ok {
+		return app, nil
+	}
+
+	app, err := a.repo.App(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	a.storeLocal(app)
+
+	return app, nil
+}
+
+func (a *Apps) IsAppMember(ctx context.Context, userID int64, appID int32) (bool, error) {
+	return a.repo.IsAppMember(ctx, userID, appID)
+}
+
+// * Invalidate сбрасывает кеш приложения в этой реплике. Остальные увидят
+// изменение по истечении CacheTTL.
+func (a *Apps) Invalidate(appID int32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.local, appID)
+}
+
+// fromLocal отдаёт копию: вызывающие не должны менять общую запись.
+func (a *Apps) fromLocal(appID int32) (*models.App, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	e, ok := a.local[appID]
+	if !ok || time.Now().After(e.expiresAt) {
+		return nil, false
+	}
+
+	app := e.app
+	return &app, true
+}
+
+func (a *Apps) storeLocal(app *models.App) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.local[app.ID] = entry{app: *app, expiresAt: time.Now().Add(a.cfg.CacheTTL)}
+}
@@ -6,18 +6,21 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"auth_service/internal/lib/jwt"
+	"auth_service/internal/lib/mailer"
+	"auth_service/internal/lib/passhash"
 	"auth_service/internal/lib/tokens"
 	"auth_service/internal/lib/verification"
+	"auth_service/internal/metrics"
 	"auth_service/internal/models"
 	"auth_service/internal/storage"
 
 	sl "auth_service/internal/lib/logger"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 
 	_ "auth_service/docs"
 )
@@ -25,6 +28,9 @@ import (
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrInvalidAppID       = errors.New("invalid app id")
+	ErrInvalidScope       = errors.New("invalid scope")
+	ErrNotOrgMember       = errors.New("user is not a member of this organization")
+	ErrNotAppMember       = errors.New("user is not a member of this app")
 
 	ErrEmailNotVerified = errors.New("email not verified")
 
@@ -36,24 +42,80 @@ var (
 	ErrNoAuthFactorAvailable = errors.New("no password or linked oauth account to enable 2fa")
 	ErrTwoFAAlreadyEnabled   = errors.New("2fa already enabled")
 	ErrTwoFANotEnabled       = errors.New("2fa is not enabled")
+	ErrTOTPEnabled           = errors.New("2fa is enabled via totp")
 
 	ErrDisableConfirmation = errors.New("invalid confirmation")
 	ErrDeleteConfirmation  = errors.New("invalid confirmation")
 	ErrRestoreConfirmation = errors.New("invalid confirmation")
 
 	ErrAccountDeleted = errors.New("account deleted")
+
+	ErrUserNotFound  = errors.New("user not found")
+	ErrUsernameTaken = errors.New("username already taken")
+
+	ErrAccountDisabled         = errors.New("account disabled")
+	ErrAccountSuspended        = errors.New("account suspended")
+	ErrAccountBanned           = errors.New("account banned")
+	ErrInvalidStatusTransition = errors.New("invalid account status transition")
+
+	ErrPasswordResetRequired = errors.New("password reset required")
+	ErrEmailAlreadyVerified  = errors.New("email already verified")
+
+	ErrSameEmail               = errors.New("new email is the same as the current one")
+	ErrEmailTaken              = errors.New("email already taken")
+	ErrInvalidEmailChangeToken = errors.New("invalid or expired email change token")
+
+	ErrSuspiciousLogin = errors.New("login blocked: impossible travel since last login")
+	ErrSessionLimit    = errors.New("active session limit reached")
+
+	ErrInvalidClient       = errors.New("invalid client credentials")
+	ErrInvalidSubjectToken = errors.New("subject token was not issued to this client")
+	ErrExchangeNotAllowed  = errors.New("token exchange to this audience is not allowed")
 )
 
 type Auth struct {
-	Log         *slog.Logger
-	UsrSaver    UserSaver
-	UsrProvider UserProvider
-	AppProvider AppProvider
-	TwoFA       TwoFAService
-
-	tokenTTL   time.Duration
-	refreshTTL time.Duration
-	resetTTL   time.Duration
+	Log          *slog.Logger
+	UsrSaver     storage.UserSaver
+	UsrProvider  storage.UserProvider
+	AppProvider  storage.AppProvider
+	TwoFA        TwoFAService
+	TOTP         TOTPService
+	UoW          storage.UoW
+	AccessTokens AccessTokenRegistry
+	Lockout      LoginLockout
+	SigningKeys  SigningKeys
+	EmailChanges EmailChangeStore
+	// Mail — уведомления безопасности (новое устройство, смена пароля и email).
+	Mail mailer.Publisher
+	Geo  GeoGuard
+	// TrustedDevices — устройства, на которых пользователь попросил не
+	// спрашивать второй фактор.
+	TrustedDevices TrustedDeviceRegistry
+	// Passwords — политика для новых паролей.
+	Passwords PasswordPolicy
+	Hasher    PasswordHasher
+
+	metrics *metrics.Metrics
+
+	// tokenTTLs подменяется целиком при перечитывании конфига (SetTokenTTLs)
+	tokenTTLs atomic.Pointer[TokenTTLs]
+	// refreshMaxLifetime — предел сессии от логина; refreshSliding — ротация
+	// продлевает refresh-токен в его пределах
+	refreshMaxLifetime time.Duration
+	refreshSliding     bool
+	// maxSessions — лимит активных сессий пользователя в приложении (0 — без
+	// лимита); rejectOverLimit — отклонять вход вместо вытеснения старых
+	maxSessions     int
+	rejectOverLimit bool
+	deletionGrace   time.Duration // сколько удалённый аккаунт можно восстановить
+	leeway          time.Duration // допуск на расхождение часов при проверке токенов
+
+	// enforceMembership — вход только в приложения, в которых пользователь
+	// зарегистрирован. Выключено — пул пользователей общий для всех приложений.
+	enforceMembership bool
+	// newDeviceChallenge — вход с неизвестного устройства подтверждается
+	// magic link, даже если 2FA у пользователя не включена.
+	newDeviceChallenge bool
 }
 
 type LoginResult struct {
@@ -61,47 +123,53 @@ type LoginResult struct {
 	RefreshToken     string
 	TwoFactorPending bool
 	SessionID        string
+	// TwoFactorMethod — каким способом подтверждать pending-сессию:
+	// magic_link (код придёт письмом) или totp (код из приложения)
+	TwoFactorMethod string
+	// RefreshCookie — приложение получает refresh-токен в HttpOnly cookie,
+	// а не в теле ответа.
+	RefreshCookie bool
+	// RefreshExpiresAt — срок refresh-токена, по нему же живёт cookie.
+	RefreshExpiresAt time.Time
+	// TrustToken — токен доверенного устройства, если после 2FA клиент
+	// попросил запомнить устройство; действует до TrustExpiresAt.
+	TrustToken     string
+	TrustExpiresAt time.Time
 }
 
-type UserSaver interface {
-	SaveUser(ctx context.Context, email string, username string, passHash []byte) (uid int64, err error)
-	DeleteAccount(ctx context.Context, userID int64) error
-	RestoreAccount(ctx context.Context, userID int64) error
-
-	SaveRefreshToken(ctx context.Context, id string, userID int64, appID int32, tokenHash []byte, expiresAt time.Time) error
-	UpdateRefreshToken(ctx context.Context, id uuid.UUID, newTokenHash []byte, oldTokenHash []byte, expiresAt time.Time) error
-	DeleteRefreshToken(ctx context.Context, id uuid.UUID) error
-
-	SaveResetToken(ctx context.Context, tokenID uuid.UUID, userID int64, tokenHash []byte, expiresAt time.Time) error
-	DeleteAllResetTokens(ctx context.Context, uid int64) error
+// AccessTokenRegistry помнит jti выданных access-токенов, чтобы их можно
+// было отозвать до истечения exp (force-logout), и хранит claims
+// opaque-токенов.
+type AccessTokenRegistry interface {
+	TrackAccessToken(ctx context.Context, userID int64, jti string, expiresAt time.Time) error
+	SaveOpaqueAccessToken(ctx context.Context, tokenHash []byte, claims jwt.Claims) error
+	RevokeUserAccessTokens(ctx context.Context, userID int64, leeway time.Duration) (int, error)
 }
 
-type UserProvider interface {
-	UserByEmail(ctx context.Context, email string) (*models.User, error)
-	UserByID(ctx context.Context, id int64) (*models.User, error)
-	UserIDByEmail(ctx context.Context, email string) (int64, error)
-
-	RefreshTokenByID(ctx context.Context, id uuid.UUID) (*models.RefreshToken, error)
-
-	ResetTokenByID(ctx context.Context, tokenID uuid.UUID) (*models.ResetToken, error)
-	ResetPassword(ctx context.Context, userID int64, tokenID uuid.UUID, newPasswordHash []byte) error
-
-	SetEmailVerified(ctx context.Context, uid int64) error
-	CheckIfUserVerified(ctx context.Context, email string) (int64, bool, error)
-
-	TwoFAStatus(ctx context.Context, userID int64) (*models.TwoFAStatus, error)
-	EnableMagicLink2FA(ctx context.Context, userID int64) error
-	DisableMagicLink2FA(ctx context.Context, userID int64) error
+// LoginLockout блокирует вход по паролю на email после серии неудачных
+// попыток с IP клиента.
+type LoginLockout interface {
+	Check(ctx context.Context, email string) error
+	RegisterFailure(ctx context.Context, email string, user *models.User) error
+	Reset(ctx context.Context, email string)
+	ResetAll(ctx context.Context, email string)
+	Unlock(ctx context.Context, rawToken string) error
+}
 
-	HasOAuthAccounts(ctx context.Context, userID int64) (bool, error)
+// EmailChangeStore хранит запросы смены email до подтверждения с нового адреса.
+type EmailChangeStore interface {
+	SaveEmailChange(ctx context.Context, tokenHash []byte, change models.EmailChange, ttl time.Duration) error
+	ConsumeEmailChange(ctx context.Context, tokenHash []byte) (*models.EmailChange, error)
 }
 
-type AppProvider interface {
-	App(ctx context.Context, appID int32) (*models.App, error)
+// SigningKeys выдаёт ключ подписи access-токенов приложения. nil — подпись
+// HS256 на секрете приложения.
+type SigningKeys interface {
+	ActiveKey(ctx context.Context, app *models.App) (*models.SigningKey, error)
 }
 
 type TwoFAService interface {
-	RequestChallenge(ctx context.Context, user *models.User, appID int32, pendingSessionTTL time.Duration) (sessionID string, err error)
+	RequestChallenge(ctx context.Context, user *models.User, appID int32, scopes []string, pendingSessionTTL time.Duration) (sessionID string, err error)
 	RequestActionConfirmation(
 		ctx context.Context,
 		userID int64,
@@ -112,92 +180,375 @@ type TwoFAService interface {
 
 	Resend(ctx context.Context, sessionID string) error
 
-	VerifyLogin(ctx context.Context, sessionID, rawToken string) (userID int64, appID int32, err error)
+	VerifyLogin(ctx context.Context, sessionID, rawToken string) (*models.PendingSession, error)
 	VerifyForAction(ctx context.Context, sessionID, rawToken string, expectedUserID int64, action models.Action) error
+
+	Delivery(ctx context.Context, userID int64) (*models.TwoFADelivery, error)
+	SetChannel(ctx context.Context, userID int64, delivery models.TwoFADelivery) error
+	ActiveMagicLinks(ctx context.Context, userID int64) ([]models.MagicLink, error)
+}
+
+// PasswordPolicy возвращает *passwordpolicy.Error, если пароль нарушает
+// политику. userInputs — данные пользователя, которых не должно быть в пароле.
+type PasswordPolicy interface {
+	Check(ctx context.Context, password string, userInputs ...string) error
+}
+
+// PasswordHasher хеширует пароли. Verify возвращает ошибку, если пароль не
+// подходит; needsRehash — пароль верный, но хеш записан устаревшим
+// алгоритмом или параметрами и его пора пересчитать.
+// VerifyDummy тратит на проверку столько же времени, сколько Verify, —
+// для входа несуществующего пользователя.
+type PasswordHasher interface {
+	Hash(ctx context.Context, password string) ([]byte, error)
+	Verify(ctx context.Context, hash []byte, password string) (needsRehash bool, err error)
+	VerifyDummy(ctx context.Context, password string)
+}
+
+type TrustedDeviceRegistry interface {
+	Enabled() bool
+	Trust(ctx context.Context, userID int64, device *models.Device) (token string, expiresAt time.Time, err error)
+	Trusted(ctx context.Context, userID int64, token string) (bool, error)
+	RevokeAll(ctx context.Context, userID int64) (int64, error)
+}
+
+// Options — зависимости и настройки Auth. Незаданные зависимости остаются
+// nil: утилитам вроде authctl нужна только часть методов. Нулевые
+// настройки выключают соответствующую функцию (лимит сессий, sliding
+// refresh, проверку членства и т.д.).
+type Options struct {
+	UserSaver      storage.UserSaver
+	UserProvider   storage.UserProvider
+	AppProvider    storage.AppProvider
+	TwoFA          TwoFAService
+	TOTP           TOTPService
+	UoW            storage.UoW
+	AccessTokens   AccessTokenRegistry
+	Lockout        LoginLockout
+	SigningKeys    SigningKeys
+	EmailChanges   EmailChangeStore
+	Mail           mailer.Publisher
+	Geo            GeoGuard
+	TrustedDevices TrustedDeviceRegistry
+	Passwords      PasswordPolicy
+	Hasher         PasswordHasher
+	Metrics        *metrics.Metrics
+
+	TokenTTLs          TokenTTLs
+	RefreshMaxLifetime time.Duration
+	RefreshSliding     bool
+	MaxSessions        int
+	RejectOverLimit    bool
+	DeletionGrace      time.Duration
+	Leeway             time.Duration
+
+	EnforceMembership  bool
+	NewDeviceChallenge bool
+}
+
+func New(log *slog.Logger, opts Options) *Auth {
+	a := &Auth{
+		UsrSaver:     opts.UserSaver,
+		UsrProvider:  opts.UserProvider,
+		AppProvider:  opts.AppProvider,
+		TwoFA:        opts.TwoFA,
+		TOTP:         opts.TOTP,
+		UoW:          opts.UoW,
+		AccessTokens: opts.AccessTokens,
+		Lockout:      opts.Lockout,
+		SigningKeys:  opts.SigningKeys,
+		EmailChanges: opts.EmailChanges,
+		Mail:         opts.Mail,
+		Geo:          opts.Geo,
+		Log:          log,
+
+		TrustedDevices: opts.TrustedDevices,
+		Passwords:      opts.Passwords,
+		Hasher:         opts.Hasher,
+
+		metrics: opts.Metrics,
+
+		refreshMaxLifetime: opts.RefreshMaxLifetime,
+		refreshSliding:     opts.RefreshSliding,
+		maxSessions:        opts.MaxSessions,
+		rejectOverLimit:    opts.RejectOverLimit,
+		deletionGrace:      opts.DeletionGrace,
+		leeway:             opts.Leeway,
+
+		enforceMembership:  opts.EnforceMembership,
+		newDeviceChallenge: opts.NewDeviceChallenge,
+	}
+	a.SetTokenTTLs(opts.TokenTTLs)
+
+	return a
+}
+
+// TokenTTLs — сроки жизни выдаваемых токенов.
+type TokenTTLs struct {
+	Access      time.Duration
+	Refresh     time.Duration
+	Reset       time.Duration
+	EmailChange time.Duration
 }
 
-func New(
-	log *slog.Logger,
-	userSaver UserSaver,
-	userProvider UserProvider,
-	appProvider AppProvider,
-	twoFAService TwoFAService,
-	jwtTTL, refreshTTL, resetTTL time.Duration,
-) *Auth {
-	return &Auth{
-		UsrSaver:    userSaver,
-		UsrProvider: userProvider,
-		AppProvider: appProvider,
-		TwoFA:       twoFAService,
-		Log:         log,
-		tokenTTL:    jwtTTL,
-		refreshTTL:  refreshTTL,
-		resetTTL:    resetTTL,
+// SetTokenTTLs применяется к токенам, выданным после вызова; выданные
+// раньше живут со своим сроком.
+func (a *Auth) SetTokenTTLs(ttls TokenTTLs) {
+	a.tokenTTLs.Store(&ttls)
+}
+
+// AccessTokenTTL — срок жизни access-токенов приложения для expires_in.
+func (a *Auth) AccessTokenTTL(app *models.App) time.Duration {
+	return a.accessTTL(app)
+}
+
+// accessTTL и refreshTTL — сроки из настроек приложения, если они заданы,
+// иначе глобальные из конфига.
+func (a *Auth) accessTTL(app *models.App) time.Duration {
+	if app.AccessTokenTTL > 0 {
+		return app.AccessTokenTTL
+	}
+
+	return a.ttls().Access
+}
+
+func (a *Auth) refreshTTL(app *models.App) time.Duration {
+	if app.RefreshTokenTTL > 0 {
+		return app.RefreshTokenTTL
 	}
+
+	return a.ttls().Refresh
 }
 
-// * Login проверяет учетные данные и возвращает JWT и refresh token
+// refreshExpiry — сроки нового refresh-токена: expiresAt — через TTL
+// приложения, absoluteExpiresAt — предел сессии. Без sliding предел совпадает
+// со сроком, и ротация его не продлевает.
+func (a *Auth) refreshExpiry(app *models.App, now time.Time) (expiresAt, absoluteExpiresAt time.Time) {
+	absoluteExpiresAt = now.Add(a.refreshMaxLifetime)
+	expiresAt = now.Add(a.refreshTTL(app))
+	if expiresAt.After(absoluteExpiresAt) {
+		expiresAt = absoluteExpiresAt
+	}
+
+	if !a.refreshSliding {
+		absoluteExpiresAt = expiresAt
+	}
+
+	return expiresAt, absoluteExpiresAt
+}
+
+func (a *Auth) ttls() TokenTTLs {
+	return *a.tokenTTLs.Load()
+}
+
+// * Login проверяет учетные данные и возвращает JWT и refresh token.
+// scopes — запрошенные клиентом, каждый должен быть разрешён приложением.
+// trustToken — токен доверенного устройства, если клиент его сохранил.
 func (a *Auth) Login(
 	ctx context.Context,
 	email, password string,
 	appID int32,
+	scopes []string,
+	device *models.Device,
+	trustToken string,
 	pendingSessionTTL time.Duration,
-) (*LoginResult, error) {
+) (res *LoginResult, err error) {
 	const op = "Auth.Login"
 
 	log := a.Log.With(slog.String("op", op))
 
+	defer func() {
+		if err == nil && res.TwoFactorPending {
+			a.observe(operationLogin, "two_factor_pending")
+			return
+		}
+
+		a.observe(operationLogin, result(err))
+	}()
+
+	// до поиска пользователя и пароля: пока вход заблокирован, перебор не
+	// продвигается, а ответ не зависит от того, есть ли аккаунт
+	if err := a.Lockout.Check(ctx, email); err != nil {
+		return nil, err
+	}
+
 	user, err := a.UsrProvider.UserByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			log.Warn("user not found")
-			return nil, storage.ErrUserNotFound
+
+			// несуществующий email отвечает так же и так же долго, как
+			// неверный пароль, и так же ведёт к блокировке: иначе по ответу
+			// перебираются аккаунты
+			a.Hasher.VerifyDummy(ctx, password)
+
+			if err := a.Lockout.RegisterFailure(ctx, email, nil); err != nil {
+				return nil, err
+			}
+
+			return nil, ErrInvalidCredentials
 		}
 
 		log.Error("failed to get user", sl.Err(err))
 		return nil, err
 	}
 
+	needsRehash, err := a.Hasher.Verify(ctx, user.PassHash, password)
+	if err != nil {
+		// перегрузка — не неверный пароль: попытку в блокировку не засчитываем
+		if errors.Is(err, passhash.ErrBusy) {
+			log.Warn("password verification timed out waiting for a worker")
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if errors.Is(err, passhash.ErrUnknownPepper) {
+			// перец убрали из конфига раньше, чем мигрировали его хеши
+			log.Error("password hash pepper is missing", sl.Err(err))
+		} else {
+			log.Info("invalid credentials", sl.Err(err))
+		}
+
+		if err := a.Lockout.RegisterFailure(ctx, email, user); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrInvalidCredentials
+	}
+
+	a.Lockout.Reset(ctx, email)
+
+	// после пароля: иначе по ответу видно, что аккаунт с этим email был
 	if user.DeletedAt != nil {
 		return nil, ErrAccountDeleted
 	}
 
-	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
-		log.Info("invalid credentials", sl.Err(err))
-		return nil, ErrInvalidCredentials
+	if needsRehash {
+		a.rehashPassword(ctx, user, password)
 	}
 
 	if !user.IsVerified {
 		return nil, ErrEmailNotVerified
 	}
 
+	// после пароля: иначе по ответу можно узнать, что аккаунт
+	// заблокирован или помечен администратором
+	if err := checkTokenIssuance(user); err != nil {
+		return nil, err
+	}
+
 	app, err := a.AppProvider.App(ctx, appID)
 	if err != nil {
 		return nil, ErrInvalidAppID
 	}
 
+	// до 2FA: иначе письмо с magic link уйдёт пользователю чужого приложения
+	if err := a.CheckAppMembership(ctx, user.ID, app.ID); err != nil {
+		return nil, err
+	}
+
+	scopes, err = allowedScopes(app, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	// до 2FA: заблокированному входу письмо с magic link не отправляется
+	travelChallenge, err := a.checkTravel(ctx, user, app)
+	if err != nil {
+		return nil, err
+	}
+
 	status, err := a.UsrProvider.TwoFAStatus(ctx, user.ID)
 	if err != nil {
 		log.Error("failed to get 2fa status", sl.Err(err))
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	if status.IsEnabled {
-		sessionID, err := a.TwoFA.RequestChallenge(ctx, user, app.ID, pendingSessionTTL)
+	// доверенное устройство снимает 2FA и проверку нового устройства, но не
+	// «невозможное перемещение»: токен мог уехать вместе с украденным ноутбуком
+	var trusted bool
+	if (status.IsEnabled || a.newDeviceChallenge) && !travelChallenge {
+		trusted, err = a.TrustedDevices.Trusted(ctx, user.ID, trustToken)
+		if err != nil {
+			log.Warn("failed to check trusted device, requiring 2fa", sl.Err(err))
+			trusted = false
+		}
+	}
+
+	challenge := (status.IsEnabled && !trusted) || travelChallenge
+	if !challenge && !trusted && a.newDeviceChallenge {
+		challenge, err = a.unseenDevice(ctx, user.ID, device)
+		if err != nil {
+			log.Error("failed to check device", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if challenge {
+			log.Info("login from unseen device, requiring magic link", slog.Int64("user_id", user.ID))
+		}
+	}
+
+	if challenge {
+		// без включённой 2FA код уходит письмом: TOTP у пользователя может не быть
+		method := models.TwoFAMethodMagicLink
+		if status.IsEnabled && status.Method != nil && *status.Method == models.TwoFAMethodTOTP {
+			method = models.TwoFAMethodTOTP
+		}
+
+		var sessionID string
+		switch method {
+		case models.TwoFAMethodTOTP:
+			sessionID, err = a.TOTP.RequestChallenge(ctx, user.ID, app.ID, scopes, pendingSessionTTL)
+		default:
+			sessionID, err = a.TwoFA.RequestChallenge(ctx, user, app.ID, scopes, pendingSessionTTL)
+		}
 		if err != nil {
 			log.Error("failed to request 2fa challenge", sl.Err(err))
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 
-		return &LoginResult{TwoFactorPending: true, SessionID: sessionID}, nil
+		return &LoginResult{TwoFactorPending: true, SessionID: sessionID, TwoFactorMethod: method}, nil
 	}
 
-	accessToken, refreshToken, err := a.IssueTokens(ctx, user, app)
+	accessToken, refreshToken, err := a.IssueTokens(ctx, user, app, device, scopes)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &LoginResult{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+	return a.issuedResult(app, accessToken, refreshToken), nil
+}
+
+// * rehashPassword пересчитывает хеш пароля текущим алгоритмом: пароль
+// известен только при входе, поэтому старые bcrypt-хеши мигрируют на
+// Argon2id постепенно, без сброса паролей. Вход уже успешен, поэтому сбой
+// только логируется — попытка повторится при следующем входе.
+func (a *Auth) rehashPassword(ctx context.Context, user *models.User, password string) {
+	const op = "Auth.rehashPassword"
+
+	log := a.Log.With(slog.String("op", op), slog.Int64("user_id", user.ID))
+
+	passHash, err := a.Hasher.Hash(ctx, password)
+	if err != nil {
+		log.Error("failed to rehash password", sl.Err(err))
+		return
+	}
+
+	if err := a.UsrSaver.RehashPassword(ctx, user.ID, user.PassHash, passHash); err != nil {
+		log.Error("failed to save rehashed password", sl.Err(err))
+		return
+	}
+
+	log.Info("password rehashed")
+}
+
+func (a *Auth) issuedResult(app *models.App, accessToken, refreshToken string) *LoginResult {
+	expiresAt, _ := a.refreshExpiry(app, time.Now())
+
+	return &LoginResult{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		RefreshCookie:    app.RefreshTokenDelivery == models.RefreshTokenDeliveryCookie,
+		RefreshExpiresAt: expiresAt,
+	}
 }
 
 func (a *Auth) RegisterNewUser(
@@ -205,22 +556,29 @@ func (a *Auth) RegisterNewUser(
 	email string,
 	username string,
 	pass string,
-) (int64, error) {
+	appID int32,
+) (id int64, err error) {
 	const op = "auth.registerNewUser"
 
+	defer func() { a.observe(operationRegister, result(err)) }()
+
 	log := a.Log.With(
 		slog.String("op", op),
 	)
 
 	log.Info("Registering new user")
 
-	passHash, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.DefaultCost)
+	if err := a.Passwords.Check(ctx, pass, email, username); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	passHash, err := a.Hasher.Hash(ctx, pass)
 	if err != nil {
 		log.Error("failed to generate password hash", sl.Err(err))
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	id, err := a.UsrSaver.SaveUser(ctx, email, username, passHash)
+	id, err = a.UsrSaver.SaveUser(ctx, email, username, passHash, appID)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserAlreadyExists) {
 			log.Warn("User already exists")
@@ -228,6 +586,14 @@ func (a *Auth) RegisterNewUser(
 			return 0, storage.ErrUserAlreadyExists
 		}
 
+		if errors.Is(err, storage.ErrUsernameTaken) {
+			return 0, ErrUsernameTaken
+		}
+
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return 0, ErrInvalidAppID
+		}
+
 		log.Error("Failed to save user", sl.Err(err))
 
 		return 0, fmt.Errorf("%s: %w", op, err)
@@ -236,6 +602,27 @@ func (a *Auth) RegisterNewUser(
 	return id, nil
 }
 
+// CheckAppMembership возвращает ErrNotAppMember, если проверка членства
+// включена и пользователь не зарегистрирован в приложении.
+func (a *Auth) CheckAppMembership(ctx context.Context, userID int64, appID int32) error {
+	const op = "Auth.CheckAppMembership"
+
+	if !a.enforceMembership {
+		return nil
+	}
+
+	isMember, err := a.AppProvider.IsAppMember(ctx, userID, appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !isMember {
+		return ErrNotAppMember
+	}
+
+	return nil
+}
+
 func (a *Auth) CheckUserVerification(
 	ctx context.Context,
 	email string,
@@ -260,12 +647,44 @@ func (a *Auth) CheckUserVerification(
 	return userID, isVerified, nil
 }
 
+// * Refresh ротирует refresh-токен и возвращает срок нового. scopes пустой — access-токен получает
+// scope'ы, выданные при логине; иначе они должны быть их подмножеством.
+// orgID != 0 переключает сессию на организацию (пользователь должен в ней
+// состоять), 0 — сохраняет выбранную ранее.
 func (a *Auth) Refresh(
 	ctx context.Context,
 	refreshToken string,
+	scopes []string,
+	orgID int64,
+) (accessToken, newRefreshToken string, expiresAt time.Time, err error) {
+	return a.refresh(ctx, refreshToken, 0, scopes, orgID)
+}
+
+// * RefreshForApp — Refresh для OAuth-клиента: refresh-токен другого
+// приложения отклоняется как невалидный.
+func (a *Auth) RefreshForApp(
+	ctx context.Context,
+	refreshToken string,
+	appID int32,
 ) (string, string, error) {
+	accessToken, newRefreshToken, _, err := a.refresh(ctx, refreshToken, appID, nil, 0)
+	return accessToken, newRefreshToken, err
+}
+
+// refresh ротирует refresh-токен. appID != 0 — токен должен быть выдан
+// этому приложению. Ротированный токен сохраняет scope'ы логина, даже если
+// access-токен выпущен с более узкими.
+func (a *Auth) refresh(
+	ctx context.Context,
+	refreshToken string,
+	appID int32,
+	scopes []string,
+	orgID int64,
+) (_, _ string, _ time.Time, err error) {
 	const op = "auth.refresh"
 
+	defer func() { a.observe(operationRefresh, result(err)) }()
+
 	log := a.Log.With(
 		slog.String("op", op),
 	)
@@ -273,7 +692,7 @@ func (a *Auth) Refresh(
 	parts := strings.Split(refreshToken, ".")
 	if len(parts) != 2 {
 		log.Warn("invalid refresh token format")
-		return "", "", ErrInvalidCredentials
+		return "", "", time.Time{}, ErrInvalidCredentials
 	}
 
 	tokenID := parts[0]
@@ -281,45 +700,74 @@ func (a *Auth) Refresh(
 
 	uid, err := uuid.Parse(tokenID)
 	if err != nil {
-		return "", "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return "", "", time.Time{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
 	}
 
 	rt, err := a.UsrProvider.RefreshTokenByID(ctx, uid)
 	if err != nil {
 		log.Warn("refresh token not found", sl.Err(err))
-		return "", "", ErrInvalidCredentials
+		return "", "", time.Time{}, ErrInvalidCredentials
 	}
 
 	if time.Now().After(rt.ExpiresAt) {
 		log.Warn("refresh token expired")
-		return "", "", ErrInvalidCredentials
+		return "", "", time.Time{}, ErrInvalidCredentials
 	}
 	if !tokens.VerifyOpaqueToken(secret, rt.TokenHash) {
 		log.Warn("invalid refresh token")
-		return "", "", ErrInvalidCredentials
+		return "", "", time.Time{}, ErrInvalidCredentials
+	}
+
+	if appID != 0 && rt.AppID != appID {
+		log.Warn("refresh token belongs to another app")
+		return "", "", time.Time{}, ErrInvalidCredentials
+	}
+
+	scopes, err = narrowScopes(rt.Scopes, scopes)
+	if err != nil {
+		return "", "", time.Time{}, err
 	}
 
 	user, err := a.UsrProvider.UserByID(ctx, rt.UserID)
 	if err != nil {
 		log.Error("failed to load user", sl.Err(err))
-		return "", "", ErrInvalidCredentials
+		return "", "", time.Time{}, ErrInvalidCredentials
+	}
+
+	if err := checkTokenIssuance(user); err != nil {
+		return "", "", time.Time{}, err
 	}
 
 	app, err := a.AppProvider.App(ctx, rt.AppID)
 	if err != nil {
-		return "", "", ErrInvalidAppID
+		return "", "", time.Time{}, ErrInvalidAppID
+	}
+
+	org, err := a.sessionOrg(ctx, rt, orgID)
+	if err != nil {
+		return "", "", time.Time{}, err
 	}
 
-	accessToken, err := jwt.NewToken(*user, *app, a.tokenTTL)
+	accessToken, err := a.newAccessToken(ctx, user, app, scopes, org, 0)
 	if err != nil {
 		log.Error("failed to generate access token", sl.Err(err))
-		return "", "", err
+		return "", "", time.Time{}, err
 	}
 
 	_, newRefreshToken, newHash, err := tokens.NewRefreshToken(tokenID)
 	if err != nil {
 		log.Error("failed to generate refresh token", sl.Err(err))
-		return "", "", err
+		return "", "", time.Time{}, err
+	}
+
+	// sliding: срок сдвигается на TTL приложения, но не дальше предела
+	// сессии; без sliding остаётся сроком, назначенным при выдаче
+	expiresAt := rt.ExpiresAt
+	if a.refreshSliding {
+		expiresAt = time.Now().Add(a.refreshTTL(app))
+		if expiresAt.After(rt.AbsoluteExpiresAt) {
+			expiresAt = rt.AbsoluteExpiresAt
+		}
 	}
 
 	err = a.UsrSaver.UpdateRefreshToken(
@@ -327,14 +775,15 @@ func (a *Auth) Refresh(
 		rt.ID,
 		newHash,
 		rt.TokenHash,
-		time.Now().Add(a.refreshTTL),
+		expiresAt,
+		org.OrgID,
 	)
 	if err != nil {
 		log.Error("failed to update refresh token", sl.Err(err))
-		return "", "", err
+		return "", "", time.Time{}, err
 	}
 
-	return accessToken, newRefreshToken, nil
+	return accessToken, newRefreshToken, expiresAt, nil
 }
 
 func (a *Auth) VerifyUser(
@@ -348,7 +797,7 @@ func (a *Auth) VerifyUser(
 		slog.String("op", op),
 	)
 
-	user_id, err := verification.ParseVerificationToken(verificationToken, verificationTokenSecret)
+	user_id, err := verification.ParseVerificationToken(verificationToken, verificationTokenSecret, a.leeway)
 	if err != nil {
 		log.Error("failed to update parse verification token", sl.Err(err))
 
@@ -412,13 +861,6 @@ func (a *Auth) Forgot(ctx context.Context, email string) (string, error) {
 		return "", err
 	}
 
-	err = a.UsrSaver.DeleteAllResetTokens(ctx, uid)
-	if err != nil {
-		log.Error("Failed to delete reset tokens", sl.Err(err))
-
-		return "", err
-	}
-
 	tokenID, resetToken, hash, err := tokens.NewResetToken("")
 	if err != nil {
 		log.Error("Failed to generate reset token", sl.Err(err))
@@ -426,15 +868,28 @@ func (a *Auth) Forgot(ctx context.Context, email string) (string, error) {
 		return "", err
 	}
 
-	err = a.UsrSaver.SaveResetToken(
-		ctx,
-		uuid.MustParse(tokenID),
-		uid,
-		hash,
-		time.Now().Add(a.resetTTL),
-	)
+	// Удаление старых токенов и сохранение нового — одна транзакция:
+	// иначе при сбое между ними юзер остаётся без единого рабочего токена,
+	// а параллельный Forgot может оставить два активных.
+	err = a.UoW.Do(ctx, func(ctx context.Context, tx storage.Tx) error {
+		if err := tx.Tokens().DeleteAllResetTokens(ctx, uid); err != nil {
+			return fmt.Errorf("delete reset tokens: %w", err)
+		}
+
+		if err := tx.Tokens().SaveResetToken(
+			ctx,
+			uuid.MustParse(tokenID),
+			uid,
+			hash,
+			time.Now().Add(a.ttls().Reset),
+		); err != nil {
+			return fmt.Errorf("save reset token: %w", err)
+		}
+
+		return nil
+	})
 	if err != nil {
-		log.Error("Failed to save reset token", sl.Err(err))
+		log.Error("Failed to issue reset token", sl.Err(err))
 		return "", err
 	}
 
@@ -469,11 +924,19 @@ func (a *Auth) ResetPassword(ctx context.Context, tokenID, verifier, newPass str
 		return fmt.Errorf("%s: get user: %w", op, err)
 	}
 
-	if bcrypt.CompareHashAndPassword(user.PassHash, []byte(newPass)) == nil {
+	_, err = a.Hasher.Verify(ctx, user.PassHash, newPass)
+	switch {
+	case err == nil:
 		return ErrSamePassword
+	case errors.Is(err, passhash.ErrBusy):
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	passHash, err := bcrypt.GenerateFromPassword([]byte(newPass), bcrypt.DefaultCost)
+	if err := a.Passwords.Check(ctx, newPass, user.Email, user.Username); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	passHash, err := a.Hasher.Hash(ctx, newPass)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -482,29 +945,109 @@ func (a *Auth) ResetPassword(ctx context.Context, tokenID, verifier, newPass str
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	// refresh-токены удалены в той же транзакции, что и смена пароля; уже
+	// выданные access-токены тоже отзываются — сброс часто делают именно
+	// из-за утечки. Пароль уже сменён, поэтому сбой отзыва только логируется.
+	if _, err := a.AccessTokens.RevokeUserAccessTokens(ctx, rt.UserID, a.leeway); err != nil {
+		a.Log.Error("failed to revoke access tokens after password reset",
+			slog.String("op", op),
+			slog.Int64("user_id", rt.UserID),
+			sl.Err(err),
+		)
+	}
+
+	// доверенное устройство могло остаться у того, из-за кого пароль и сбрасывают
+	if _, err := a.TrustedDevices.RevokeAll(ctx, rt.UserID); err != nil {
+		a.Log.Error("failed to revoke trusted devices after password reset",
+			slog.String("op", op),
+			slog.Int64("user_id", rt.UserID),
+			sl.Err(err),
+		)
+	}
+
+	// сброс по ссылке из почты доказывает владение аккаунтом — блокировка
+	// входа после перебора старого пароля больше не нужна
+	a.Lockout.ResetAll(ctx, user.Email)
+
+	a.recordUserEvent(ctx, rt.UserID, models.AuditActionPasswordChanged, map[string]any{"method": "reset_link"})
+
+	a.notify(ctx, rt.UserID, user.Email, alertPasswordChanged, nil)
+
+	return nil
+}
+
+// * UnlockAccount снимает блокировку входа по ссылке из письма.
+func (a *Auth) UnlockAccount(ctx context.Context, rawToken string) error {
+	const op = "Auth.UnlockAccount"
+
+	if err := a.Lockout.Unlock(ctx, rawToken); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.Log.Info("account unlocked by email link", slog.String("op", op))
+
 	return nil
 }
 
 // * VerifyMagicLink подтверждает второй фактор и выдаёт токены.
-func (a *Auth) VerifyMagicLink(ctx context.Context, sessionID, rawToken string) (accessToken, refreshToken string, err error) {
+// rememberDevice — выдать токен доверенного устройства.
+func (a *Auth) VerifyMagicLink(
+	ctx context.Context,
+	sessionID, rawToken string,
+	device *models.Device,
+	rememberDevice bool,
+) (res *LoginResult, err error) {
 	const op = "Auth.VerifyMagicLink"
 
-	userID, appID, err := a.TwoFA.VerifyLogin(ctx, sessionID, rawToken)
+	defer func() { a.observe(operationTwoFactor, result(err)) }()
+
+	session, err := a.TwoFA.VerifyLogin(ctx, sessionID, rawToken)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
-	user, err := a.UsrProvider.UserByID(ctx, userID)
+	user, err := a.UsrProvider.UserByID(ctx, session.UserID)
 	if err != nil {
-		return "", "", fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	app, err := a.AppProvider.App(ctx, appID)
+	app, err := a.AppProvider.App(ctx, session.AppID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	accessToken, refreshToken, err := a.IssueTokens(ctx, user, app, device, session.Scopes)
 	if err != nil {
-		return "", "", fmt.Errorf("%s: %w", op, err)
+		return nil, err
+	}
+
+	res = a.issuedResult(app, accessToken, refreshToken)
+
+	if rememberDevice {
+		a.trustDevice(ctx, res, user.ID, device)
 	}
 
-	return a.IssueTokens(ctx, user, app)
+	return res, nil
+}
+
+// * trustDevice выдаёт токен доверенного устройства после пройденной 2FA.
+// Токены к этому моменту уже выданы, поэтому сбой только логируется:
+// второй фактор спросят при следующем входе.
+func (a *Auth) trustDevice(ctx context.Context, res *LoginResult, userID int64, device *models.Device) {
+	const op = "Auth.trustDevice"
+
+	if !a.TrustedDevices.Enabled() {
+		return
+	}
+
+	token, expiresAt, err := a.TrustedDevices.Trust(ctx, userID, device)
+	if err != nil {
+		a.Log.Error("failed to trust device", slog.String("op", op), slog.Int64("user_id", userID), sl.Err(err))
+		return
+	}
+
+	res.TrustToken = token
+	res.TrustExpiresAt = expiresAt
 }
 
 // * Enable2FA включает magic-link 2FA пользователю. Требует, чтобы у него уже
@@ -526,13 +1069,13 @@ func (a *Auth) Enable2FA(ctx context.Context, userID int64) error {
 	}
 
 	if !status.HasPassword {
-		hasOAuth, err := a.UsrProvider.HasOAuthAccounts(ctx, userID)
+		hasIdentities, err := a.UsrProvider.HasIdentities(ctx, userID)
 		if err != nil {
-			log.Error("failed to check oauth accounts", sl.Err(err))
+			log.Error("failed to check linked identities", sl.Err(err))
 			return fmt.Errorf("%s: %w", op, err)
 		}
 
-		if !hasOAuth {
+		if !hasIdentities {
 			return ErrNoAuthFactorAvailable
 		}
 	}
@@ -544,6 +1087,8 @@ func (a *Auth) Enable2FA(ctx context.Context, userID int64) error {
 
 	log.Info("2fa enabled", slog.Int64("user_id", userID))
 
+	a.recordUserEvent(ctx, userID, models.AuditActionTwoFAEnabled, nil)
+
 	return nil
 }
 
@@ -567,6 +1112,11 @@ func (a *Auth) Disable2FA(
 		return ErrTwoFANotEnabled
 	}
 
+	// TOTP выключается только кодом из приложения — через DisableTOTP
+	if status.Method != nil && *status.Method == models.TwoFAMethodTOTP {
+		return ErrTOTPEnabled
+	}
+
 	switch {
 	case status.HasPassword:
 		user, err := a.UsrProvider.UserByID(ctx, userID)
@@ -575,7 +1125,11 @@ func (a *Auth) Disable2FA(
 			return fmt.Errorf("%s: %w", op, err)
 		}
 
-		if bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)) != nil {
+		if _, err := a.Hasher.Verify(ctx, user.PassHash, password); err != nil {
+			if errors.Is(err, passhash.ErrBusy) {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+
 			log.Warn("disable 2fa: invalid password")
 			return ErrDisableConfirmation
 		}
@@ -599,12 +1153,29 @@ func (a *Auth) Disable2FA(
 
 	log.Info("2fa disabled", slog.Int64("user_id", userID))
 
+	a.recordUserEvent(ctx, userID, models.AuditActionTwoFADisabled, nil)
+
 	return nil
 }
 
-// * IssueTokens генерирует access и refresh токены и сохраняет refresh в БД.
-func (a *Auth) IssueTokens(ctx context.Context, user *models.User, app *models.App) (accessToken, refreshToken string, err error) {
-	accessToken, err = jwt.NewToken(*user, *app, a.tokenTTL)
+// * IssueTokens генерирует access и refresh токены и сохраняет refresh в БД
+// вместе с отпечатком устройства. Если передано устройство, прежняя сессия
+// на нём удаляется в той же транзакции — на одном device_id живёт одна
+// сессия. Вход с устройства, которого у пользователя ещё не было, пишется в
+// журнал и вызывает письмо-уведомление. scopes должны быть уже проверены по
+// приложению.
+func (a *Auth) IssueTokens(
+	ctx context.Context,
+	user *models.User,
+	app *models.App,
+	device *models.Device,
+	scopes []string,
+) (accessToken, refreshToken string, err error) {
+	if err := checkTokenIssuance(user); err != nil {
+		return "", "", err
+	}
+
+	accessToken, err = a.newAccessToken(ctx, user, app, scopes, nil, 0)
 	if err != nil {
 		a.Log.Error("failed to generate access token", sl.Err(err))
 		return "", "", err
@@ -616,53 +1187,502 @@ func (a *Auth) IssueTokens(ctx context.Context, user *models.User, app *models.A
 		return "", "", err
 	}
 
-	if err := a.UsrSaver.SaveRefreshToken(ctx, tokenID, user.ID, app.ID, hash, time.Now().Add(a.refreshTTL)); err != nil {
+	expiresAt, absoluteExpiresAt := a.refreshExpiry(app, time.Now())
+	fp := deviceFingerprint(ctx, device)
+
+	newDevice := false
+	var evicted []uuid.UUID
+
+	err = a.UoW.Do(ctx, func(ctx context.Context, tx storage.Tx) error {
+		evicted = nil
+
+		// access-токен прежней сессии устройства доживает свой TTL: отзыв по jti
+		// ведётся на пользователя целиком, а не на устройство
+		if device != nil {
+			if _, err := tx.Tokens().DeleteDeviceRefreshTokens(ctx, user.ID, device.ID); err != nil {
+				return err
+			}
+		}
+
+		if a.maxSessions > 0 {
+			evicted, err = a.enforceSessionLimit(ctx, tx, user.ID, app.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Tokens().SaveRefreshToken(ctx, tokenID, user.ID, app.ID, device, fp, hash, expiresAt, absoluteExpiresAt, scopes); err != nil {
+			return err
+		}
+
+		if fp.Hash == nil {
+			return nil
+		}
+
+		inserted, hadDevices, err := tx.Devices().TouchKnownDevice(ctx, user.ID, fp)
+		if err != nil {
+			return err
+		}
+
+		// первое устройство аккаунта новым не считается — это вход после регистрации
+		if !inserted || !hadDevices {
+			return nil
+		}
+
+		newDevice = true
+
+		metadata := map[string]any{"app_id": app.ID}
+		if device != nil {
+			metadata["device_id"] = device.ID
+			metadata["device_name"] = device.Name
+		}
+
+		return tx.Audit().SaveAuditEvent(ctx, userEvent(ctx, user.ID, models.AuditActionNewDevice, metadata))
+	})
+	if errors.Is(err, ErrSessionLimit) {
+		a.Log.Info("login rejected: session limit reached",
+			slog.Int64("user_id", user.ID),
+			slog.Int("app_id", int(app.ID)),
+		)
+		return "", "", err
+	}
+	if err != nil {
 		a.Log.Error("failed to save refresh token", sl.Err(err))
 		return "", "", err
 	}
 
+	if len(evicted) > 0 {
+		a.Log.Info("oldest sessions evicted over limit",
+			slog.Int64("user_id", user.ID),
+			slog.Int("app_id", int(app.ID)),
+			slog.Int("count", len(evicted)),
+		)
+	}
+
+	if newDevice {
+		data := map[string]string{"app_name": app.Name}
+		if device != nil {
+			data["device_name"] = device.Name
+		}
+
+		a.notify(ctx, user.ID, user.Email, alertNewDeviceLogin, data)
+	}
+
+	a.Geo.Record(ctx, user.ID, fp.IP)
+
 	return accessToken, refreshToken, nil
 }
 
+// enforceSessionLimit освобождает место под новую сессию: вытесняет самые
+// старые или, если так настроено, возвращает ErrSessionLimit. Вытеснение
+// пишется в журнал в той же транзакции.
+func (a *Auth) enforceSessionLimit(ctx context.Context, tx storage.Tx, userID int64, appID int32) ([]uuid.UUID, error) {
+	active, err := tx.Tokens().LockActiveSessions(ctx, userID, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	over := len(active) - a.maxSessions + 1
+	if over <= 0 {
+		return nil, nil
+	}
+
+	if a.rejectOverLimit {
+		return nil, ErrSessionLimit
+	}
+
+	evicted := active[:over]
+	sessionIDs := make([]string, 0, len(evicted))
+	for _, id := range evicted {
+		if err := tx.Tokens().DeleteRefreshToken(ctx, id); err != nil {
+			return nil, err
+		}
+		sessionIDs = append(sessionIDs, id.String())
+	}
+
+	metadata := map[string]any{
+		"app_id":      appID,
+		"session_ids": sessionIDs,
+		"reason":      "session_limit",
+	}
+	if err := tx.Audit().SaveAuditEvent(ctx, userEvent(ctx, userID, models.AuditActionSessionEvicted, metadata)); err != nil {
+		return nil, err
+	}
+
+	return evicted, nil
+}
+
+// newAccessToken выпускает access-токен в формате, выбранном приложением,
+// и регистрирует его jti — без регистрации токен нельзя будет отозвать
+// через ForceLogout. actor != 0 — токен получен обменом этим приложением
+// (claim act).
+func (a *Auth) newAccessToken(
+	ctx context.Context,
+	user *models.User,
+	app *models.App,
+	scopes []string,
+	org *models.OrgMember,
+	actor int32,
+) (string, error) {
+	const op = "Auth.newAccessToken"
+
+	if app.AccessTokenFormat == models.AccessTokenFormatOpaque {
+		return a.newOpaqueAccessToken(ctx, user, app, scopes, org, actor)
+	}
+
+	key, err := a.SigningKeys.ActiveKey(ctx, app)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	authz, err := a.authorization(ctx, user.ID, app.ID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	authz.Scopes = scopes
+	authz.Actor = actor
+	withOrg(&authz, org)
+
+	ttl := a.accessTTL(app)
+	expiresAt := time.Now().Add(ttl)
+
+	accessToken, jti, err := jwt.NewToken(*user, *app, key, authz, ttl)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.AccessTokens.TrackAccessToken(ctx, user.ID, jti, expiresAt); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return accessToken, nil
+}
+
+// newOpaqueAccessToken выпускает случайный access-токен, claims которого
+// материализуются в Redis. jti регистрируется так же, как у JWT, поэтому
+// отзыв через denylist работает для обоих форматов одинаково.
+func (a *Auth) newOpaqueAccessToken(
+	ctx context.Context,
+	user *models.User,
+	app *models.App,
+	scopes []string,
+	org *models.OrgMember,
+	actor int32,
+) (string, error) {
+	const op = "Auth.newOpaqueAccessToken"
+
+	authz, err := a.authorization(ctx, user.ID, app.ID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	authz.Scopes = scopes
+	withOrg(&authz, org)
+
+	accessToken, hash, err := tokens.NewOpaqueAccessToken()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	claims := jwt.Claims{
+		UserID:      user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		AppID:       app.ID,
+		ID:          uuid.NewString(),
+		ExpiresAt:   time.Now().Add(a.accessTTL(app)),
+		Roles:       authz.Roles,
+		Permissions: authz.Permissions,
+		Scopes:      authz.Scopes,
+		OrgID:       authz.OrgID,
+		OrgRole:     authz.OrgRole,
+		Actor:       actor,
+	}
+
+	if err := a.AccessTokens.SaveOpaqueAccessToken(ctx, hash, claims); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.AccessTokens.TrackAccessToken(ctx, user.ID, claims.ID, claims.ExpiresAt); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return accessToken, nil
+}
+
+// ForceLogout завершает все сессии пользователя: удаляет refresh-токены,
+// отзывает выданные access-токены по jti и пишет событие в аудит.
+// Используется поддержкой при подтверждённом захвате аккаунта.
+func (a *Auth) ForceLogout(ctx context.Context, userID int64, event models.AuditEvent) (*models.ForceLogoutResult, error) {
+	const op = "Auth.ForceLogout"
+
+	log := a.Log.With(slog.String("op", op), slog.Int64("user_id", userID))
+
+	if _, err := a.UsrProvider.UserByID(ctx, userID); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	event.UserID = userID
+	event.Action = models.AuditActionForceLogout
+
+	result, err := a.terminateSessions(ctx, &event)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user sessions terminated",
+		slog.String("actor", event.Actor),
+		slog.Int64("refresh_tokens_deleted", result.RefreshTokensDeleted),
+		slog.Int("access_tokens_revoked", result.AccessTokensRevoked),
+	)
+
+	return result, nil
+}
+
+// LogoutAll завершает все сессии пользователя по его собственному запросу —
+// например, если он подозревает, что аккаунт скомпрометирован.
+func (a *Auth) LogoutAll(ctx context.Context, userID int64) (*models.ForceLogoutResult, error) {
+	const op = "Auth.LogoutAll"
+
+	result, err := a.terminateSessions(ctx, userEvent(ctx, userID, models.AuditActionLogoutAll, nil))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.Log.Info("user logged out everywhere",
+		slog.Int64("user_id", userID),
+		slog.Int64("refresh_tokens_deleted", result.RefreshTokensDeleted),
+		slog.Int("access_tokens_revoked", result.AccessTokensRevoked),
+	)
+
+	return result, nil
+}
+
+// terminateSessions удаляет все refresh-токены пользователя event.UserID,
+// отзывает его access-токены и пишет event с их количеством.
+//
+// Отзыв access-токенов идёт внутри транзакции, после DELETE refresh-токенов:
+// параллельный Refresh либо уже зарегистрировал новый jti (и он будет
+// отозван), либо упрётся в удалённую строку и токен не получит.
+// Ошибка Redis откатывает удаление — операцию можно безопасно повторить.
+func (a *Auth) terminateSessions(ctx context.Context, event *models.AuditEvent) (*models.ForceLogoutResult, error) {
+	result := &models.ForceLogoutResult{}
+
+	err := a.UoW.Do(ctx, func(ctx context.Context, tx storage.Tx) error {
+		deleted, err := tx.Tokens().DeleteAllRefreshTokens(ctx, event.UserID)
+		if err != nil {
+			return err
+		}
+		result.RefreshTokensDeleted = deleted
+
+		revoked, err := a.AccessTokens.RevokeUserAccessTokens(ctx, event.UserID, a.leeway)
+		if err != nil {
+			return err
+		}
+		result.AccessTokensRevoked = revoked
+
+		if event.Metadata == nil {
+			event.Metadata = make(map[string]any, 2)
+		}
+		event.Metadata["refresh_tokens_deleted"] = result.RefreshTokensDeleted
+		event.Metadata["access_tokens_revoked"] = result.AccessTokensRevoked
+
+		return tx.Audit().SaveAuditEvent(ctx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// сессии уже завершены, поэтому сбой только логируется
+	if _, err := a.TrustedDevices.RevokeAll(ctx, event.UserID); err != nil {
+		a.Log.Error("failed to revoke trusted devices",
+			slog.Int64("user_id", event.UserID),
+			sl.Err(err),
+		)
+	}
+
+	return result, nil
+}
+
+// RequirePasswordReset помечает аккаунт как требующий смены пароля: до
+// успешного ResetPassword токены не выдаются ни входом (пароль, OAuth,
+// magic link, TOTP, OIDC), ни refresh'ем. Refresh-токены в той же
+// транзакции удаляются, уже выданные access-токены отзываются.
+func (a *Auth) RequirePasswordReset(ctx context.Context, userID int64, event models.AuditEvent) error {
+	const op = "Auth.RequirePasswordReset"
+
+	event.UserID = userID
+	event.Action = models.AuditActionRequirePasswordReset
+
+	err := a.UoW.Do(ctx, func(ctx context.Context, tx storage.Tx) error {
+		if err := tx.Users().SetMustResetPassword(ctx, userID, true); err != nil {
+			return err
+		}
+
+		if _, err := tx.Tokens().DeleteAllRefreshTokens(ctx, userID); err != nil {
+			return err
+		}
+
+		return tx.Audit().SaveAuditEvent(ctx, &event)
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// флаг уже стоит и refresh не пройдёт, поэтому сбой отзыва только логируется
+	if _, err := a.AccessTokens.RevokeUserAccessTokens(ctx, userID, a.leeway); err != nil {
+		a.Log.Error("failed to revoke access tokens after password reset requirement",
+			slog.String("op", op),
+			slog.Int64("user_id", userID),
+			sl.Err(err),
+		)
+	}
+
+	a.Log.Info("password reset required by admin",
+		slog.String("op", op),
+		slog.Int64("user_id", userID),
+		slog.String("actor", event.Actor),
+	)
+
+	return nil
+}
+
+// VerifyEmailManually подтверждает email без письма — когда пайплайн
+// отправки писем отказал, а владение адресом подтверждено поддержкой.
+func (a *Auth) VerifyEmailManually(ctx context.Context, userID int64, event models.AuditEvent) error {
+	const op = "Auth.VerifyEmailManually"
+
+	event.UserID = userID
+	event.Action = models.AuditActionManualEmailVerify
+
+	err := a.UoW.Do(ctx, func(ctx context.Context, tx storage.Tx) error {
+		user, err := tx.Users().UserByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if user.DeletedAt != nil {
+			return storage.ErrUserNotFound
+		}
+		if user.IsVerified {
+			return ErrEmailAlreadyVerified
+		}
+
+		if err := tx.Users().SetEmailVerified(ctx, userID); err != nil {
+			return err
+		}
+
+		if event.Metadata == nil {
+			event.Metadata = make(map[string]any, 1)
+		}
+		event.Metadata["email"] = user.Email
+
+		return tx.Audit().SaveAuditEvent(ctx, &event)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUserNotFound):
+			return ErrUserNotFound
+		case errors.Is(err, ErrEmailAlreadyVerified):
+			return err
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.Log.Info("email verified by admin",
+		slog.String("op", op),
+		slog.Int64("user_id", userID),
+		slog.String("actor", event.Actor),
+	)
+
+	return nil
+}
+
+// AccountDeletion — итог DeleteAccount. PurgeAt — когда аккаунт удалится
+// безвозвратно; до этого его можно восстановить. AlreadyDeleted — аккаунт
+// был удалён раньше, письмо повторно не отправляется.
+type AccountDeletion struct {
+	Email          string
+	PurgeAt        time.Time
+	AlreadyDeleted bool
+}
+
+// * DeleteAccount помечает аккаунт удалённым (status = pending_deletion),
+// отзывает все токены и сессии. Безвозвратно аккаунт удаляет задача
+// retention по истечении deletionGrace.
 func (a *Auth) DeleteAccount(
 	ctx context.Context,
 	userID int64,
 	password string,
 	sessionID, rawToken string,
-) error {
+) (*AccountDeletion, error) {
 	const op = "Auth.DeleteAccount"
 
 	user, err := a.UsrProvider.UserByID(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	switch {
 	case user.PassHash != nil:
-		if bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)) != nil {
-			return ErrDeleteConfirmation
+		if _, err := a.Hasher.Verify(ctx, user.PassHash, password); err != nil {
+			if errors.Is(err, passhash.ErrBusy) {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+
+			return nil, ErrDeleteConfirmation
 		}
 	default:
 		if sessionID == "" || rawToken == "" {
-			return ErrDeleteConfirmation
+			return nil, ErrDeleteConfirmation
 		}
 		if err := a.TwoFA.VerifyForAction(ctx, sessionID, rawToken, userID, models.ActionDeleteAccount); err != nil {
-			return ErrDeleteConfirmation
+			return nil, ErrDeleteConfirmation
 		}
 	}
 
 	if err := a.UsrSaver.DeleteAccount(ctx, userID); err != nil {
 		switch {
 		case errors.Is(err, storage.ErrUserAlreadyDeleted):
-			return nil
+			deletedAt := time.Now()
+			if user.DeletedAt != nil {
+				deletedAt = *user.DeletedAt
+			}
+
+			return &AccountDeletion{
+				Email:          user.Email,
+				PurgeAt:        deletedAt.Add(a.deletionGrace),
+				AlreadyDeleted: true,
+			}, nil
 		case errors.Is(err, storage.ErrUserNotFound):
-			return err
+			return nil, err
 		default:
-			return fmt.Errorf("%s: %w", op, err)
+			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 	}
 
-	return nil
+	// refresh-токены удалены в той же транзакции; access-токены отзываются
+	// отдельно, сбой только логируется — аккаунт уже удалён, а Login и
+	// Refresh его не пропустят
+	if _, err := a.AccessTokens.RevokeUserAccessTokens(ctx, userID, a.leeway); err != nil {
+		a.Log.Error("failed to revoke access tokens after account deletion",
+			slog.String("op", op),
+			slog.Int64("user_id", userID),
+			sl.Err(err),
+		)
+	}
+
+	a.recordUserEvent(ctx, userID, models.AuditActionAccountDeleted, nil)
+
+	return &AccountDeletion{
+		Email:   user.Email,
+		PurgeAt: time.Now().Add(a.deletionGrace),
+	}, nil
 }
 
 // RestoreAccount отменяет soft-delete, если юзер подтвердил личность
@@ -691,7 +1711,11 @@ func (a *Auth) RestoreAccount(
 
 	switch {
 	case user.PassHash != nil:
-		if bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)) != nil {
+		if _, err := a.Hasher.Verify(ctx, user.PassHash, password); err != nil {
+			if errors.Is(err, passhash.ErrBusy) {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+
 			log.Warn("restore account: invalid password", slog.Int64("user_id", user.ID))
 			return ErrRestoreConfirmation
 		}
@@ -705,7 +1729,7 @@ func (a *Auth) RestoreAccount(
 		}
 	}
 
-	if err := a.UsrSaver.RestoreAccount(ctx, user.ID); err != nil {
+	if err := a.UsrSaver.RestoreAccount(ctx, user.ID, a.deletionGrace); err != nil {
 		switch {
 		case errors.Is(err, storage.ErrNothingToRestore):
 			return storage.ErrNothingToRestore
@@ -719,6 +1743,8 @@ func (a *Auth) RestoreAccount(
 
 	log.Info("account restored", slog.Int64("user_id", user.ID))
 
+	a.recordUserEvent(ctx, user.ID, models.AuditActionAccountRestored, nil)
+
 	return nil
 }
 
@@ -0,0 +1,267 @@
+// Package authtest собирает auth.Auth на хранилище в памяти для тестов
+// сервисного слоя и хендлеров: Postgres заменяет storage/memory, Redis и
+// RabbitMQ — заглушки этого пакета. Настоящие здесь lockout и хеширование
+// паролей (bcrypt минимальной стоимости, чтобы тесты не тратили время).
+package authtest
+
+import (
+	"context"
+	"crypto/rand"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"auth_service/internal/auth"
+	"auth_service/internal/auth/lockout"
+	"auth_service/internal/config"
+	"auth_service/internal/lib/jwt"
+	"auth_service/internal/lib/passhash"
+	"auth_service/internal/models"
+	"auth_service/internal/storage/memory"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AccessTTL  = 15 * time.Minute
+	RefreshTTL = 24 * time.Hour
+	ResetTTL   = time.Hour
+)
+
+// Lockout — настройки блокировки входа в тестах: три неудачи с IP
+// блокируют вход с него, блокировки с двух IP — вход целиком.
+var Lockout = config.Lockout{
+	Enabled:             true,
+	MaxAttempts:         3,
+	Window:              15 * time.Minute,
+	BaseDelay:           time.Minute,
+	MaxDelay:            time.Hour,
+	AccountLockIPs:      2,
+	UnlockTokenTTL:      time.Hour,
+	UnlockEmailInterval: time.Hour,
+}
+
+type Env struct {
+	Auth         *auth.Auth
+	Store        *memory.MemoryRepo
+	LockoutStore *memory.LockoutRepo
+	Hasher       *Hasher
+	Mail         *Mailbox
+	AccessTokens *AccessTokens
+}
+
+// New собирает Auth без 2FA, проверки устройств и географии входа.
+func New(t testing.TB) *Env {
+	t.Helper()
+
+	log := slog.New(slog.DiscardHandler)
+
+	env := &Env{
+		Store:        memory.New(),
+		LockoutStore: memory.NewLockout(),
+		Hasher: &Hasher{Hasher: passhash.New(config.PasswordHashing{
+			Algorithm:  passhash.AlgorithmBcrypt,
+			BcryptCost: bcrypt.MinCost,
+			Workers:    4,
+		})},
+		Mail:         &Mailbox{},
+		AccessTokens: &AccessTokens{},
+	}
+
+	env.Auth = auth.New(log, auth.Options{
+		UserSaver:      env.Store,
+		UserProvider:   env.Store,
+		AppProvider:    env.Store,
+		UoW:            env.Store,
+		AccessTokens:   env.AccessTokens,
+		Lockout:        lockout.New(log, env.LockoutStore, env.Mail, "http://auth.test", Lockout),
+		SigningKeys:    signingKeys{},
+		Mail:           env.Mail,
+		Geo:            geoGuard{},
+		TrustedDevices: trustedDevices{},
+		Passwords:      passwordPolicy{},
+		Hasher:         env.Hasher,
+
+		TokenTTLs: auth.TokenTTLs{
+			Access:      AccessTTL,
+			Refresh:     RefreshTTL,
+			Reset:       ResetTTL,
+			EmailChange: time.Hour,
+		},
+		RefreshMaxLifetime: 7 * 24 * time.Hour,
+		DeletionGrace:      30 * 24 * time.Hour,
+	})
+
+	return env
+}
+
+// App создаёт приложение с HS256-подписью на случайном секрете.
+func (e *Env) App(t testing.TB, name string) *models.App {
+	t.Helper()
+
+	app := &models.App{Name: name, Secret: rand.Text()}
+	if err := e.Store.SaveApp(context.Background(), app); err != nil {
+		t.Fatalf("save app: %v", err)
+	}
+
+	return app
+}
+
+// User регистрирует пользователя в приложении и подтверждает его email.
+func (e *Env) User(t testing.TB, appID int32, email, password string) *models.User {
+	t.Helper()
+
+	ctx := context.Background()
+
+	id, err := e.Auth.RegisterNewUser(ctx, email, email, password, appID)
+	if err != nil {
+		t.Fatalf("register user: %v", err)
+	}
+
+	if err := e.Store.SetEmailVerified(ctx, id); err != nil {
+		t.Fatalf("verify email: %v", err)
+	}
+
+	user, err := e.Store.UserByID(ctx, id)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+
+	return user
+}
+
+// Hasher считает вызовы VerifyDummy — ими отвечает вход на
+// несуществующий email.
+type Hasher struct {
+	*passhash.Hasher
+
+	dummyCalls atomic.Int64
+}
+
+func (h *Hasher) VerifyDummy(ctx context.Context, password string) {
+	h.dummyCalls.Add(1)
+	h.Hasher.VerifyDummy(ctx, password)
+}
+
+func (h *Hasher) DummyCalls() int64 {
+	return h.dummyCalls.Load()
+}
+
+// Mailbox запоминает отправленные письма вместо публикации в RabbitMQ.
+type Mailbox struct {
+	mu       sync.Mutex
+	messages []models.Message
+}
+
+func (m *Mailbox) SendMessage(_ context.Context, msg models.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+
+	return nil
+}
+
+// Messages возвращает письма с заданным purpose.
+func (m *Mailbox) Messages(purpose string) []models.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var out []models.Message
+	for _, msg := range m.messages {
+		if msg.Purpose == purpose {
+			out = append(out, msg)
+		}
+	}
+
+	return out
+}
+
+// AccessTokens — реестр jti в памяти; отзыв помечает пользователя.
+type AccessTokens struct {
+	mu      sync.Mutex
+	tracked map[int64]int
+	revoked map[int64]bool
+}
+
+func (a *AccessTokens) TrackAccessToken(_ context.Context, userID int64, _ string, _ time.Time) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.tracked == nil {
+		a.tracked = make(map[int64]int)
+	}
+	a.tracked[userID]++
+
+	return nil
+}
+
+func (a *AccessTokens) SaveOpaqueAccessToken(context.Context, []byte, jwt.Claims) error {
+	return nil
+}
+
+func (a *AccessTokens) RevokeUserAccessTokens(_ context.Context, userID int64, _ time.Duration) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.revoked == nil {
+		a.revoked = make(map[int64]bool)
+	}
+	a.revoked[userID] = true
+
+	n := a.tracked[userID]
+	delete(a.tracked, userID)
+
+	return n, nil
+}
+
+// Revoked — отзывались ли access-токены пользователя.
+func (a *AccessTokens) Revoked(userID int64) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.revoked[userID]
+}
+
+// signingKeys — без ключей: токены подписываются HS256 на секрете приложения.
+type signingKeys struct{}
+
+func (signingKeys) ActiveKey(context.Context, *models.App) (*models.SigningKey, error) {
+	return nil, nil
+}
+
+type geoGuard struct{}
+
+func (geoGuard) Check(context.Context, int64, string) (*models.TravelAnomaly, error) {
+	return nil, nil
+}
+
+func (geoGuard) Record(context.Context, int64, string) {}
+
+func (geoGuard) Action() models.GeoAction {
+	return models.GeoActionNotify
+}
+
+type trustedDevices struct{}
+
+func (trustedDevices) Enabled() bool { return false }
+
+func (trustedDevices) Trust(context.Context, int64, *models.Device) (string, time.Time, error) {
+	return "", time.Time{}, nil
+}
+
+func (trustedDevices) Trusted(context.Context, int64, string) (bool, error) {
+	return false, nil
+}
+
+func (trustedDevices) RevokeAll(context.Context, int64) (int64, error) {
+	return 0, nil
+}
+
+type passwordPolicy struct{}
+
+func (passwordPolicy) Check(context.Context, string, ...string) error {
+	return nil
+}
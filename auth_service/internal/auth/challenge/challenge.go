@@ -0,0 +1,153 @@
+package challenge
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/bits"
+	"strings"
+	"time"
+
+	"auth_service/internal/config"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/storage"
+)
+
+var ErrInvalidSolution = errors.New("invalid or expired proof of work")
+
+// Store хранит счётчики неудач и выданные задачи. Общий для всех реплик:
+// атакующий распределяет попытки между ними.
+type Store interface {
+	IncrChallengeFailures(ctx context.Context, key string, window time.Duration) (int64, error)
+	ChallengeFailures(ctx context.Context, keys ...string) ([]int64, error)
+
+	SaveChallenge(ctx context.Context, nonce string, difficulty int, ttl time.Duration) error
+	ConsumeChallenge(ctx context.Context, nonce string) (int, error)
+}
+
+// Puzzle — задача для клиента: подобрать solution, при котором
+// SHA-256(nonce + solution) начинается с Difficulty нулевых бит.
+type Puzzle struct {
+	Nonce      string
+	Difficulty int
+}
+
+// Challenge включает proof-of-work перед обработкой запроса, когда с IP
+// или на email приходится больше cfg.Threshold неудач за cfg.Window.
+// Сложность растёт на бит с каждым удвоением неудач сверх порога. Сбои
+// Redis проверку не включают: защита дополнительная, как и lockout.
+type Challenge struct {
+	log   *slog.Logger
+	store Store
+	cfg   config.Challenge
+}
+
+func New(log *slog.Logger, store Store, cfg config.Challenge) *Challenge {
+	return &Challenge{log: log, store: store, cfg: cfg}
+}
+
+// * Required возвращает сложность задачи для запроса; 0 — проверка не нужна.
+func (c *Challenge) Required(ctx context.Context, ip, email string) int {
+	const op = "challenge.Required"
+
+	if !c.cfg.Enabled {
+		return 0
+	}
+
+	failures, err := c.store.ChallengeFailures(ctx, failureKeys(ip, email)...)
+	if err != nil {
+		c.log.Error("failed to get challenge failures", slog.String("op", op), sl.Err(err))
+		return 0
+	}
+
+	return c.difficulty(max(failures[0], failures[1]))
+}
+
+// * Issue выдаёт одноразовую задачу, действующую cfg.TTL.
+func (c *Challenge) Issue(ctx context.Context, difficulty int) (*Puzzle, error) {
+	const op = "challenge.Issue"
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	nonce := base64.RawURLEncoding.EncodeToString(b)
+
+	if err := c.store.SaveChallenge(ctx, nonce, difficulty, c.cfg.TTL); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Puzzle{Nonce: nonce, Difficulty: difficulty}, nil
+}
+
+// * Verify гасит задачу и проверяет решение. Задача, выданная до роста
+// сложности, не принимается: клиент получит новую.
+func (c *Challenge) Verify(ctx context.Context, nonce, solution string, difficulty int) error {
+	const op = "challenge.Verify"
+
+	issued, err := c.store.ConsumeChallenge(ctx, nonce)
+	if err != nil {
+		if errors.Is(err, storage.ErrChallengeNotFound) {
+			return ErrInvalidSolution
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if issued < difficulty || leadingZeroBits(sha256.Sum256([]byte(nonce+solution))) < issued {
+		return ErrInvalidSolution
+	}
+
+	return nil
+}
+
+// * RegisterFailure учитывает неудачный запрос с IP и на email.
+func (c *Challenge) RegisterFailure(ctx context.Context, ip, email string) {
+	const op = "challenge.RegisterFailure"
+
+	if !c.cfg.Enabled {
+		return
+	}
+
+	for _, key := range failureKeys(ip, email) {
+		if key == "" {
+			continue
+		}
+		if _, err := c.store.IncrChallengeFailures(ctx, key, c.cfg.Window); err != nil {
+			c.log.Error("failed to count challenge failure", slog.String("op", op), sl.Err(err))
+		}
+	}
+}
+
+func (c *Challenge) difficulty(failures int64) int {
+	if failures < c.cfg.Threshold {
+		return 0
+	}
+
+	// порог — базовая сложность, 2*порог — +1 бит, 4*порог — +2, ...
+	return min(c.cfg.Difficulty+bits.Len64(uint64(failures/c.cfg.Threshold))-1, c.cfg.MaxDifficulty)
+}
+
+// failureKeys — ключи счётчиков IP и email. Email приводится к виду, в
+// котором его сравнивает БД; пустой — пустой ключ, счётчик не ведётся.
+func failureKeys(ip, email string) []string {
+	keys := []string{"ip:" + ip, ""}
+	if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+		keys[1] = "email:" + email
+	}
+	return keys
+}
+
+func leadingZeroBits(sum [sha256.Size]byte) int {
+	n := 0
+	for _, b := range sum {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"auth_service/internal/lib/clientinfo"
+	"auth_service/internal/models"
+)
+
+// deviceFingerprint собирает отпечаток устройства из device_id клиента и
+// IP/User-Agent запроса. device_id надёжнее: User-Agent одинаков у всех
+// установок одной версии браузера и меняется при его обновлении.
+func deviceFingerprint(ctx context.Context, device *models.Device) models.Fingerprint {
+	info := clientinfo.FromContext(ctx)
+
+	fp := models.Fingerprint{
+		IP:        info.IP,
+		UserAgent: info.UserAgent,
+	}
+
+	var source string
+	switch {
+	case device != nil:
+		source = "device:" + device.ID
+	case info.UserAgent != "":
+		source = "ua:" + strings.ToLower(strings.TrimSpace(info.UserAgent))
+	default:
+		return fp
+	}
+
+	sum := sha256.Sum256([]byte(source))
+	fp.Hash = sum[:]
+
+	return fp
+}
+
+// unseenDevice — вход с устройства, которого у пользователя ещё не было.
+// Пока известных устройств нет вовсе, любое считается своим: иначе первый
+// вход после регистрации (и после включения учёта устройств) всегда
+// требовал бы подтверждения. Неопознаваемое устройство — всегда новое.
+func (a *Auth) unseenDevice(ctx context.Context, userID int64, device *models.Device) (bool, error) {
+	const op = "Auth.unseenDevice"
+
+	fp := deviceFingerprint(ctx, device)
+	if fp.Hash == nil {
+		return true, nil
+	}
+
+	known, hasDevices, err := a.UsrProvider.KnownDevice(ctx, userID, fp.Hash)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return hasDevices && !known, nil
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/lib/passhash"
+	"auth_service/internal/lib/tokens"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// EmailChangeRequest — запрос смены email, принятый к подтверждению. Token
+// уходит письмом на новый адрес, OldEmail — адрес для уведомления.
+type EmailChangeRequest struct {
+	Token     string
+	OldEmail  string
+	NewEmail  string
+	ExpiresAt time.Time
+}
+
+// * RequestEmailChange запускает смену email: проверяет текущий пароль (если
+// он у аккаунта есть) и выдаёт одноразовый токен подтверждения. Сам email
+// меняется только в ConfirmEmailChange — по ссылке с нового адреса.
+func (a *Auth) RequestEmailChange(
+	ctx context.Context,
+	userID int64,
+	newEmail string,
+	password string,
+) (*EmailChangeRequest, error) {
+	const op = "Auth.RequestEmailChange"
+
+	log := a.Log.With(slog.String("op", op), slog.Int64("user_id", userID))
+
+	user, err := a.Me(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.PassHash != nil {
+		if _, err := a.Hasher.Verify(ctx, user.PassHash, password); err != nil {
+			if errors.Is(err, passhash.ErrBusy) {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+
+			log.Warn("email change: invalid password")
+			return nil, ErrInvalidCredentials
+		}
+	}
+
+	if strings.EqualFold(user.Email, newEmail) {
+		return nil, ErrSameEmail
+	}
+
+	_, err = a.UsrProvider.UserIDByEmail(ctx, newEmail)
+	switch {
+	case err == nil:
+		return nil, ErrEmailTaken
+	case !errors.Is(err, storage.ErrUserNotFound):
+		return nil, fmt.Errorf("%s: check new email: %w", op, err)
+	}
+
+	token, hash, err := tokens.NewEmailChangeToken()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	change := models.EmailChange{
+		UserID:   user.ID,
+		OldEmail: user.Email,
+		NewEmail: newEmail,
+	}
+
+	ttl := a.ttls().EmailChange
+	if err := a.EmailChanges.SaveEmailChange(ctx, hash, change, ttl); err != nil {
+		log.Error("failed to save email change", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &EmailChangeRequest{
+		Token:     token,
+		OldEmail:  user.Email,
+		NewEmail:  newEmail,
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+// * ConfirmEmailChange меняет email по токену из письма. Смена атомарна:
+// если email успел измениться после запроса, токен отклоняется. Новый адрес
+// требует повторной верификации — до неё вход закрыт, как после регистрации.
+func (a *Auth) ConfirmEmailChange(ctx context.Context, rawToken string) (*models.User, error) {
+	const op = "Auth.ConfirmEmailChange"
+
+	change, err := a.EmailChanges.ConsumeEmailChange(ctx, tokens.HashEmailChangeToken(rawToken))
+	if err != nil {
+		if errors.Is(err, storage.ErrEmailChangeNotFound) {
+			return nil, ErrInvalidEmailChangeToken
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.UsrSaver.ChangeEmail(ctx, change.UserID, change.OldEmail, change.NewEmail); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUserAlreadyExists):
+			return nil, ErrEmailTaken
+		case errors.Is(err, storage.ErrUserNotFound):
+			return nil, ErrInvalidEmailChangeToken
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.Log.Info("email changed",
+		slog.String("op", op),
+		slog.Int64("user_id", change.UserID),
+	)
+
+	a.recordUserEvent(ctx, change.UserID, models.AuditActionEmailChanged, map[string]any{
+		"old_email": change.OldEmail,
+		"new_email": change.NewEmail,
+	})
+
+	// старый адрес — единственный канал, по которому владелец узнает о
+	// захвате аккаунта через смену email
+	a.notify(ctx, change.UserID, change.OldEmail, alertEmailChanged, map[string]string{
+		"new_email": change.NewEmail,
+	})
+
+	user, err := a.UsrProvider.UserByID(ctx, change.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
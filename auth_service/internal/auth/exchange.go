@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"auth_service/internal/lib/jwt"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// TokenExchange — запрос обмена токена (RFC 8693). Subject — claims уже
+// проверенного access-токена пользователя: подпись, срок и отзыв
+// проверяет вызывающий.
+type TokenExchange struct {
+	// Client — приложение, прошедшее AuthenticateClient
+	Client   *models.App
+	Subject  *jwt.Claims
+	Audience int32
+	// Scopes — пусто: все scope'ы subject-токена, которые принимает audience
+	Scopes []string
+}
+
+type ExchangedToken struct {
+	AccessToken string
+	Scopes      []string
+	ExpiresIn   time.Duration
+}
+
+// * AuthenticateClient проверяет credentials приложения-клиента. Вызывается
+// до разбора subject-токена: без credentials нельзя узнать, валиден ли
+// чужой токен.
+func (a *Auth) AuthenticateClient(ctx context.Context, clientID int32, secret string) (*models.App, error) {
+	const op = "Auth.AuthenticateClient"
+
+	client, err := a.AppProvider.App(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return nil, ErrInvalidClient
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(client.Secret)) != 1 {
+		return nil, ErrInvalidClient
+	}
+
+	return client, nil
+}
+
+// * ExchangeToken выпускает access-токен пользователя для другого
+// приложения — делегирование между сервисами. Клиент обменивает только
+// токены, выданные ему самому, и только на приложения из своего
+// apps.token_exchange_audiences. Scope'ы нового токена не шире исходных и
+// ограничены allowed_scopes audience; refresh-токен не выдаётся. Клиент
+// записывается в claim act.
+func (a *Auth) ExchangeToken(ctx context.Context, req TokenExchange) (_ *ExchangedToken, err error) {
+	const op = "Auth.ExchangeToken"
+
+	defer func() { a.observe(operationExchange, result(err)) }()
+
+	log := a.Log.With(
+		slog.String("op", op),
+		slog.Int("client_id", int(req.Client.ID)),
+		slog.Int("audience", int(req.Audience)),
+	)
+
+	client := req.Client
+
+	if req.Subject.AppID != client.ID {
+		log.Warn("subject token belongs to another app", slog.Int("subject_app_id", int(req.Subject.AppID)))
+		return nil, ErrInvalidSubjectToken
+	}
+
+	if !slices.Contains(client.TokenExchangeAudiences, req.Audience) {
+		log.Warn("token exchange to audience is not allowed")
+		return nil, ErrExchangeNotAllowed
+	}
+
+	audience, err := a.AppProvider.App(ctx, req.Audience)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return nil, ErrExchangeNotAllowed
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	scopes, err := narrowScopes(intersectScopes(req.Subject.Scopes, audience.AllowedScopes), req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.UsrProvider.UserByID(ctx, req.Subject.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return nil, ErrInvalidSubjectToken
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user.DeletedAt != nil {
+		return nil, ErrAccountDeleted
+	}
+
+	if err := checkTokenIssuance(user); err != nil {
+		return nil, err
+	}
+
+	// организация сессии переносится как есть: членство проверено при её
+	// выборе, а новый токен живёт не дольше обычного access-токена
+	var org *models.OrgMember
+	if req.Subject.OrgID != 0 {
+		org = &models.OrgMember{
+			OrgID: req.Subject.OrgID,
+			Role:  models.OrgRole(req.Subject.OrgRole),
+		}
+	}
+
+	accessToken, err := a.newAccessToken(ctx, user, audience, scopes, org, client.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("token exchanged", slog.Int64("user_id", user.ID))
+
+	return &ExchangedToken{
+		AccessToken: accessToken,
+		Scopes:      scopes,
+		ExpiresIn:   a.accessTTL(audience),
+	}, nil
+}
+
+// intersectScopes — scope'ы, которые есть в обоих наборах.
+func intersectScopes(a, b []string) []string {
+	result := make([]string, 0, len(a))
+	for _, scope := range a {
+		if slices.Contains(b, scope) && !slices.Contains(result, scope) {
+			result = append(result, scope)
+		}
+	}
+	return result
+}
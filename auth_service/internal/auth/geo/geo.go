@@ -0,0 +1,146 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"auth_service/internal/config"
+	"auth_service/internal/lib/geoip"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+const earthRadiusKm = 6371.0
+
+// Locator определяет местоположение IP по базе GeoIP.
+type Locator interface {
+	Lookup(ip string) (*models.GeoLocation, error)
+}
+
+// Store хранит место последнего входа пользователя.
+type Store interface {
+	LastLoginLocation(ctx context.Context, userID int64) (*models.GeoLocation, error)
+	SaveLoginLocation(ctx context.Context, userID int64, loc models.GeoLocation) error
+}
+
+// Service ищет «невозможное перемещение»: вход из точки, до которой от
+// места прошлого входа нельзя было добраться за прошедшее время. Без
+// базы GeoIP (locator == nil) проверка выключена и ничего не записывает.
+type Service struct {
+	log     *slog.Logger
+	locator Locator
+	store   Store
+	cfg     config.Geo
+}
+
+func New(log *slog.Logger, locator Locator, store Store, cfg config.Geo) *Service {
+	return &Service{
+		log:     log,
+		locator: locator,
+		store:   store,
+		cfg:     cfg,
+	}
+}
+
+// Action — реакция на подозрительный вход из конфига.
+func (s *Service) Action() models.GeoAction {
+	return models.GeoAction(s.cfg.Action)
+}
+
+// * Check сравнивает место входа с IP ip с местом прошлого входа. nil —
+// перемещение правдоподобно или его не с чем сравнить: IP не найден в
+// базе, пользователь раньше не входил. Близкие точки (меньше
+// MinDistanceKm) не проверяются: погрешность GeoIP в пределах одной
+// агломерации даёт огромную «скорость» у двух входов подряд.
+func (s *Service) Check(ctx context.Context, userID int64, ip string) (*models.TravelAnomaly, error) {
+	const op = "geo.Check"
+
+	if s.locator == nil || ip == "" {
+		return nil, nil
+	}
+
+	to, err := s.locator.Lookup(ip)
+	if err != nil {
+		if errors.Is(err, geoip.ErrUnknownLocation) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	from, err := s.store.LastLoginLocation(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrLoginLocationNotFound) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	to.SeenAt = time.Now()
+
+	distance := distanceKm(from, to)
+	if distance < s.cfg.MinDistanceKm {
+		return nil, nil
+	}
+
+	// не меньше минуты: два входа в одну секунду дали бы деление на ноль
+	hours := max(to.SeenAt.Sub(from.SeenAt), time.Minute).Hours()
+
+	speed := distance / hours
+	if speed <= s.cfg.MaxSpeedKmh {
+		return nil, nil
+	}
+
+	return &models.TravelAnomaly{
+		From:       *from,
+		To:         *to,
+		DistanceKm: distance,
+		SpeedKmh:   speed,
+	}, nil
+}
+
+// Record запоминает место успешного входа для следующей проверки. Ошибки
+// только логируются: вход уже состоялся.
+func (s *Service) Record(ctx context.Context, userID int64, ip string) {
+	const op = "geo.Record"
+
+	if s.locator == nil || ip == "" {
+		return
+	}
+
+	log := s.log.With(slog.String("op", op), slog.Int64("user_id", userID))
+
+	loc, err := s.locator.Lookup(ip)
+	if err != nil {
+		if !errors.Is(err, geoip.ErrUnknownLocation) {
+			log.Error("failed to lookup ip location", sl.Err(err))
+		}
+
+		return
+	}
+
+	loc.SeenAt = time.Now()
+
+	if err := s.store.SaveLoginLocation(ctx, userID, *loc); err != nil {
+		log.Error("failed to save login location", sl.Err(err))
+	}
+}
+
+// distanceKm — расстояние по большому кругу (формула гаверсинусов).
+func distanceKm(a, b *models.GeoLocation) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
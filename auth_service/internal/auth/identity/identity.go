@@ -0,0 +1,190 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+var (
+	ErrNotFound               = errors.New("identity not found")
+	ErrAlreadyLinked          = errors.New("identity already linked to another user")
+	ErrProviderAlreadyLinked  = errors.New("user already has this provider linked")
+	ErrLastAuthMethod         = errors.New("cannot unlink last authentication method")
+	ErrEmailConflict          = errors.New("account with this email already exists, log in and link instead")
+	ErrEmailBelongsToOther    = errors.New("identity email belongs to another account")
+	ErrAccountPendingDeletion = errors.New("account with this email is pending deletion")
+)
+
+type Repo interface {
+	SaveIdentity(ctx context.Context, identity *models.Identity) error
+	IdentityBySubject(ctx context.Context, provider, subject string) (*models.Identity, error)
+	IdentitiesByUserID(ctx context.Context, userID int64) ([]*models.Identity, error)
+	UnlinkIdentity(ctx context.Context, userID int64, provider string) error
+	SaveUserWithIdentity(ctx context.Context, username string, identity *models.Identity, appID int32) (int64, error)
+}
+
+type UserProvider interface {
+	UserByEmail(ctx context.Context, email string) (*models.User, error)
+}
+
+// Service управляет внешними учётками пользователей независимо от протокола
+// провайдера: OAuth сейчас, SAML и другие — через тот же API.
+type Service struct {
+	log   *slog.Logger
+	repo  Repo
+	users UserProvider
+}
+
+func New(log *slog.Logger, repo Repo, users UserProvider) *Service {
+	return &Service{
+		log:   log,
+		repo:  repo,
+		users: users,
+	}
+}
+
+// Find возвращает привязанную учётку по subject провайдера.
+func (s *Service) Find(ctx context.Context, provider, subject string) (*models.Identity, error) {
+	const op = "identity.Find"
+
+	identity, err := s.repo.IdentityBySubject(ctx, provider, subject)
+	if err != nil {
+		if errors.Is(err, storage.ErrIdentityNotFound) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return identity, nil
+}
+
+// Link привязывает внешнюю учётку к существующему пользователю. Если email
+// учётки уже занят другим локальным аккаунтом, привязка отклоняется: иначе
+// после неё один email вёл бы в два разных аккаунта.
+func (s *Service) Link(ctx context.Context, userID int64, identity *models.Identity) error {
+	const op = "identity.Link"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int64("user_id", userID),
+		slog.String("provider", identity.Provider),
+	)
+
+	if identity.Email != "" {
+		owner, err := s.users.UserByEmail(ctx, identity.Email)
+		switch {
+		case err == nil && owner.ID != userID:
+			return ErrEmailBelongsToOther
+		case err != nil && !errors.Is(err, storage.ErrUserNotFound):
+			return fmt.Errorf("%s: check email owner: %w", op, err)
+		}
+	}
+
+	identity.UserID = userID
+
+	if err := s.repo.SaveIdentity(ctx, identity); err != nil {
+		if mapped := mapStorageError(err); mapped != nil {
+			return mapped
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("identity linked", slog.String("kind", string(identity.Kind)))
+
+	return nil
+}
+
+// Register создаёт нового пользователя по внешней учётке. Существующий
+// локальный аккаунт с тем же email не привязывается автоматически:
+// пользователь входит паролем и привязывает провайдера сам.
+func (s *Service) Register(
+	ctx context.Context,
+	username string,
+	identity *models.Identity,
+	appID int32,
+) (int64, error) {
+	const op = "identity.Register"
+
+	user, err := s.users.UserByEmail(ctx, identity.Email)
+	switch {
+	case err == nil && user.DeletedAt != nil:
+		return 0, ErrAccountPendingDeletion
+	case err == nil:
+		return 0, ErrEmailConflict
+	case !errors.Is(err, storage.ErrUserNotFound):
+		return 0, fmt.Errorf("%s: check existing user: %w", op, err)
+	}
+
+	userID, err := s.repo.SaveUserWithIdentity(ctx, username, identity, appID)
+	if err != nil {
+		if mapped := mapStorageError(err); mapped != nil {
+			return 0, mapped
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("user registered via identity",
+		slog.String("op", op),
+		slog.Int64("user_id", userID),
+		slog.String("provider", identity.Provider),
+	)
+
+	return userID, nil
+}
+
+// Unlink отвязывает провайдера. Последний способ входа (нет пароля и других
+// учёток) отвязать нельзя.
+func (s *Service) Unlink(ctx context.Context, userID int64, provider string) error {
+	const op = "identity.Unlink"
+
+	if err := s.repo.UnlinkIdentity(ctx, userID, provider); err != nil {
+		if mapped := mapStorageError(err); mapped != nil {
+			return mapped
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("identity unlinked",
+		slog.String("op", op),
+		slog.Int64("user_id", userID),
+		slog.String("provider", provider),
+	)
+
+	return nil
+}
+
+// List — привязанные учётки пользователя, для профиля/настроек.
+func (s *Service) List(ctx context.Context, userID int64) ([]*models.Identity, error) {
+	const op = "identity.List"
+
+	identities, err := s.repo.IdentitiesByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return identities, nil
+}
+
+func mapStorageError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrIdentityNotFound):
+		return ErrNotFound
+	case errors.Is(err, storage.ErrIdentityAlreadyLinked):
+		return ErrAlreadyLinked
+	case errors.Is(err, storage.ErrProviderAlreadyLinked):
+		return ErrProviderAlreadyLinked
+	case errors.Is(err, storage.ErrLastAuthMethod):
+		return ErrLastAuthMethod
+	default:
+		return nil
+	}
+}
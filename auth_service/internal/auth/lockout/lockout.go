@@ -0,0 +1,287 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"auth_service/internal/config"
+	"auth_service/internal/lib/clientinfo"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/lib/tokens"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+var (
+	ErrAccountLocked      = errors.New("account temporarily locked")
+	ErrInvalidUnlockToken = errors.New("invalid or expired unlock token")
+)
+
+// LockedError — вход заблокирован ещё на RetryAfter. errors.Is(err,
+// ErrAccountLocked) для него истинно.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s for %s", ErrAccountLocked, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrAccountLocked
+}
+
+// Store хранит счётчики неудач и блокировки. Общий для всех реплик.
+// email — уже нормализованный; ip == "" в методах уровня блокировки — уровень
+// блокировки всего email.
+type Store interface {
+	IncrLoginFailures(ctx context.Context, email, ip string, window time.Duration) (int64, error)
+	LockLoginFromIP(ctx context.Context, email, ip string, d, levelTTL, window time.Duration) (lockedIPs int64, err error)
+	LockAccount(ctx context.Context, email string, d, levelTTL time.Duration) error
+	LockoutLevel(ctx context.Context, email, ip string) (int64, error)
+	LoginLockTTL(ctx context.Context, email, ip string) (time.Duration, error)
+	ResetLoginFailures(ctx context.Context, email, ip string) error
+	UnlockAccount(ctx context.Context, email string) error
+
+	AllowUnlockEmail(ctx context.Context, email string, interval time.Duration) (bool, error)
+	SaveUnlockToken(ctx context.Context, tokenHash []byte, email string, ttl time.Duration) error
+	ConsumeUnlockToken(ctx context.Context, tokenHash []byte) (string, error)
+}
+
+type Publisher interface {
+	SendMessage(ctx context.Context, msg models.Message) error
+}
+
+// Lockout блокирует вход по паролю после серии неудач. Счётчик ведётся на
+// пару email + IP клиента: перебор с одного адреса блокирует вход только с
+// него, и знание чужого email не позволяет закрыть владельцу вход. Вход на
+// email с любого IP блокируется, когда пары заблокированы с
+// cfg.AccountLockIPs разных адресов. Сбои Redis не блокируют вход: защита
+// от перебора дополнительная, грубый лимит по IP остаётся у rate limiter'а.
+type Lockout struct {
+	log       *slog.Logger
+	store     Store
+	publisher Publisher
+	baseURL   string
+	cfg       config.Lockout
+}
+
+func New(
+	log *slog.Logger,
+	store Store,
+	publisher Publisher,
+	baseURL string,
+	cfg config.Lockout,
+) *Lockout {
+	return &Lockout{
+		log:       log,
+		store:     store,
+		publisher: publisher,
+		baseURL:   baseURL,
+		cfg:       cfg,
+	}
+}
+
+// * Check возвращает *LockedError, если вход на email сейчас заблокирован —
+// целиком или с IP клиента.
+func (l *Lockout) Check(ctx context.Context, email string) error {
+	const op = "lockout.Check"
+
+	if !l.cfg.Enabled {
+		return nil
+	}
+
+	ttl, err := l.store.LoginLockTTL(ctx, normalize(email), clientinfo.FromContext(ctx).IP)
+	if err != nil {
+		l.log.Error("failed to check account lock", slog.String("op", op), sl.Err(err))
+		return nil
+	}
+
+	if ttl > 0 {
+		return &LockedError{RetryAfter: ttl}
+	}
+
+	return nil
+}
+
+// * RegisterFailure учитывает неверный пароль с IP клиента. На
+// cfg.MaxAttempts-й неудаче блокирует вход на email с этого IP, а при
+// блокировках с cfg.AccountLockIPs адресов — с любого, и возвращает
+// *LockedError. user — владелец email для письма со ссылкой разблокировки;
+// письмо уходит не чаще cfg.UnlockEmailInterval.
+func (l *Lockout) RegisterFailure(ctx context.Context, email string, user *models.User) error {
+	const op = "lockout.RegisterFailure"
+
+	if !l.cfg.Enabled {
+		return nil
+	}
+
+	email = normalize(email)
+	ip := clientinfo.FromContext(ctx).IP
+
+	log := l.log.With(
+		slog.String("op", op),
+		slog.String("ip", ip),
+	)
+	if user != nil {
+		log = log.With(slog.Int64("user_id", user.ID))
+	}
+
+	failures, err := l.store.IncrLoginFailures(ctx, email, ip, l.cfg.Window)
+	if err != nil {
+		log.Error("failed to count login failure", sl.Err(err))
+		return nil
+	}
+
+	if failures < l.cfg.MaxAttempts {
+		return nil
+	}
+
+	level, err := l.store.LockoutLevel(ctx, email, ip)
+	if err != nil {
+		log.Error("failed to get lockout level", sl.Err(err))
+	}
+
+	d := l.lockDuration(level)
+
+	lockedIPs, err := l.store.LockLoginFromIP(ctx, email, ip, d, l.cfg.MaxDelay, l.cfg.Window)
+	if err != nil {
+		log.Error("failed to lock login", sl.Err(err))
+		return nil
+	}
+
+	log.Warn("login locked for ip after failed attempts",
+		slog.Int64("failures", failures),
+		slog.Duration("duration", d),
+	)
+
+	if lockedIPs >= l.cfg.AccountLockIPs {
+		if d, err = l.lockAccount(ctx, email); err != nil {
+			log.Error("failed to lock account", sl.Err(err))
+		} else {
+			log.Warn("account locked after failed logins from multiple ips",
+				slog.Int64("ips", lockedIPs),
+				slog.Duration("duration", d),
+			)
+		}
+	}
+
+	if user != nil {
+		if err := l.sendUnlockEmail(ctx, email, user); err != nil {
+			log.Error("failed to send unlock email", sl.Err(err))
+		}
+	}
+
+	return &LockedError{RetryAfter: d}
+}
+
+func (l *Lockout) lockAccount(ctx context.Context, email string) (time.Duration, error) {
+	level, err := l.store.LockoutLevel(ctx, email, "")
+	if err != nil {
+		return 0, err
+	}
+
+	d := l.lockDuration(level)
+
+	if err := l.store.LockAccount(ctx, email, d, l.cfg.MaxDelay); err != nil {
+		return 0, err
+	}
+
+	return d, nil
+}
+
+// * Reset сбрасывает счётчик и блокировку email с IP клиента — после
+// успешного входа. Блокировки с других IP остаются.
+func (l *Lockout) Reset(ctx context.Context, email string) {
+	const op = "lockout.Reset"
+
+	if !l.cfg.Enabled {
+		return
+	}
+
+	if err := l.store.ResetLoginFailures(ctx, normalize(email), clientinfo.FromContext(ctx).IP); err != nil {
+		l.log.Error("failed to reset lockout", slog.String("op", op), sl.Err(err))
+	}
+}
+
+// * ResetAll снимает все блокировки email — после сброса пароля, который
+// доказывает владение аккаунтом.
+func (l *Lockout) ResetAll(ctx context.Context, email string) {
+	const op = "lockout.ResetAll"
+
+	if !l.cfg.Enabled {
+		return
+	}
+
+	if err := l.store.UnlockAccount(ctx, normalize(email)); err != nil {
+		l.log.Error("failed to reset lockout", slog.String("op", op), sl.Err(err))
+	}
+}
+
+// * Unlock снимает все блокировки email по одноразовому токену из письма.
+func (l *Lockout) Unlock(ctx context.Context, rawToken string) error {
+	const op = "lockout.Unlock"
+
+	email, err := l.store.ConsumeUnlockToken(ctx, tokens.HashUnlockToken(rawToken))
+	if err != nil {
+		if errors.Is(err, storage.ErrUnlockTokenNotFound) {
+			return ErrInvalidUnlockToken
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := l.store.UnlockAccount(ctx, email); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// lockDuration — base * 2^level, не больше MaxDelay.
+func (l *Lockout) lockDuration(level int64) time.Duration {
+	d := l.cfg.BaseDelay
+	for i := int64(0); i < level && d < l.cfg.MaxDelay; i++ {
+		d *= 2
+	}
+
+	return min(d, l.cfg.MaxDelay)
+}
+
+// sendUnlockEmail отправляет ссылку разблокировки, если за
+// cfg.UnlockEmailInterval письма на этот email ещё не было: иначе каждая
+// блокировка превращала бы lockout в рассылку писем по чужому адресу.
+func (l *Lockout) sendUnlockEmail(ctx context.Context, email string, user *models.User) error {
+	allowed, err := l.store.AllowUnlockEmail(ctx, email, l.cfg.UnlockEmailInterval)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return nil
+	}
+
+	token, hash, err := tokens.NewUnlockToken()
+	if err != nil {
+		return err
+	}
+
+	if err := l.store.SaveUnlockToken(ctx, hash, email, l.cfg.UnlockTokenTTL); err != nil {
+		return err
+	}
+
+	return l.publisher.SendMessage(ctx, models.Message{
+		Email:   user.Email,
+		Link:    fmt.Sprintf("%s/auth/unlock?token=%s", l.baseURL, token),
+		Purpose: "account_locked",
+	})
+}
+
+// normalize приводит email к виду, в котором его сравнивает БД, — как
+// ключи счётчиков challenge.
+func normalize(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
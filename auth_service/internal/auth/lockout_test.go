@@ -0,0 +1,123 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"auth_service/internal/auth/authtest"
+	"auth_service/internal/auth/lockout"
+)
+
+// failLogins исчерпывает попытки с IP ctx, после чего вход с него заблокирован.
+func failLogins(t *testing.T, ctx context.Context, env *authtest.Env, email string, appID int32) {
+	t.Helper()
+
+	for range authtest.Lockout.MaxAttempts {
+		_, _ = login(ctx, env, email, "wrong", appID)
+	}
+
+	if _, err := login(ctx, env, email, password, appID); !errors.Is(err, lockout.ErrAccountLocked) {
+		t.Fatalf("not locked after %d failures: %v", authtest.Lockout.MaxAttempts, err)
+	}
+}
+
+// Перебор с одного IP не закрывает владельцу вход с другого.
+func TestLockoutPerIP(t *testing.T) {
+	env := authtest.New(t)
+	app := env.App(t, "web")
+	user := env.User(t, app.ID, "alice@example.com", password)
+
+	failLogins(t, fromIP("10.0.0.1"), env, user.Email, app.ID)
+
+	if _, err := login(fromIP("10.0.0.2"), env, user.Email, password, app.ID); err != nil {
+		t.Fatalf("login from another ip: %v", err)
+	}
+}
+
+// Блокировки с AccountLockIPs адресов закрывают вход на email целиком.
+func TestLockoutAccountAfterManyIPs(t *testing.T) {
+	env := authtest.New(t)
+	app := env.App(t, "web")
+	user := env.User(t, app.ID, "alice@example.com", password)
+
+	failLogins(t, fromIP("10.0.0.1"), env, user.Email, app.ID)
+	failLogins(t, fromIP("10.0.0.2"), env, user.Email, app.ID)
+
+	if _, err := login(fromIP("10.0.0.3"), env, user.Email, password, app.ID); !errors.Is(err, lockout.ErrAccountLocked) {
+		t.Fatalf("got %v, want %v", err, lockout.ErrAccountLocked)
+	}
+
+	// письмо разблокировки — одно на интервал, сколько бы блокировок ни было
+	if got := len(env.Mail.Messages("account_locked")); got != 1 {
+		t.Fatalf("unlock emails = %d, want 1", got)
+	}
+}
+
+func TestLockoutResetBySuccessfulLogin(t *testing.T) {
+	env := authtest.New(t)
+	app := env.App(t, "web")
+	user := env.User(t, app.ID, "alice@example.com", password)
+
+	ctx := fromIP("10.0.0.1")
+	for range 2 {
+		for range authtest.Lockout.MaxAttempts - 1 {
+			_, _ = login(ctx, env, user.Email, "wrong", app.ID)
+		}
+
+		if _, err := login(ctx, env, user.Email, password, app.ID); err != nil {
+			t.Fatalf("login: %v", err)
+		}
+	}
+}
+
+func TestUnlockAccountByEmailLink(t *testing.T) {
+	env := authtest.New(t)
+	app := env.App(t, "web")
+	user := env.User(t, app.ID, "alice@example.com", password)
+
+	ctx := fromIP("10.0.0.1")
+	failLogins(t, ctx, env, user.Email, app.ID)
+
+	emails := env.Mail.Messages("account_locked")
+	if len(emails) != 1 || emails[0].Email != user.Email {
+		t.Fatalf("unlock emails = %+v", emails)
+	}
+
+	link, err := url.Parse(emails[0].Link)
+	if err != nil {
+		t.Fatalf("parse unlock link: %v", err)
+	}
+	token := link.Query().Get("token")
+
+	if err := env.Auth.UnlockAccount(t.Context(), token); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+
+	if _, err := login(ctx, env, user.Email, password, app.ID); err != nil {
+		t.Fatalf("login after unlock: %v", err)
+	}
+
+	if err := env.Auth.UnlockAccount(t.Context(), token); !errors.Is(err, lockout.ErrInvalidUnlockToken) {
+		t.Fatalf("reused unlock token: got %v, want %v", err, lockout.ErrInvalidUnlockToken)
+	}
+}
+
+// Сброс пароля доказывает владение аккаунтом и снимает все блокировки.
+func TestResetPasswordUnlocksAccount(t *testing.T) {
+	env := authtest.New(t)
+	app := env.App(t, "web")
+	user := env.User(t, app.ID, "alice@example.com", password)
+
+	failLogins(t, fromIP("10.0.0.1"), env, user.Email, app.ID)
+	failLogins(t, fromIP("10.0.0.2"), env, user.Email, app.ID)
+
+	if err := resetPassword(t, env, user.Email, newPassword); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+
+	if _, err := login(fromIP("10.0.0.1"), env, user.Email, newPassword, app.ID); err != nil {
+		t.Fatalf("login after reset: %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"auth_service/internal/auth"
+	"auth_service/internal/auth/authtest"
+	"auth_service/internal/auth/lockout"
+	"auth_service/internal/lib/clientinfo"
+)
+
+const password = "correct horse battery staple"
+
+func fromIP(ip string) context.Context {
+	return clientinfo.WithInfo(context.Background(), clientinfo.Info{IP: ip})
+}
+
+func login(ctx context.Context, env *authtest.Env, email, pass string, appID int32) (*auth.LoginResult, error) {
+	return env.Auth.Login(ctx, email, pass, appID, nil, nil, "", 0)
+}
+
+func TestLoginSucceeds(t *testing.T) {
+	env := authtest.New(t)
+	app := env.App(t, "web")
+	env.User(t, app.ID, "alice@example.com", password)
+
+	res, err := login(fromIP("10.0.0.1"), env, "alice@example.com", password, app.ID)
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	if res.AccessToken == "" || res.RefreshToken == "" {
+		t.Fatalf("tokens not issued: %+v", res)
+	}
+	if res.TwoFactorPending {
+		t.Fatal("2fa pending without 2fa enabled")
+	}
+}
+
+// Несуществующий email неотличим от неверного пароля: та же ошибка, и
+// пароль сверяется с фиктивным хешем, чтобы совпало время ответа.
+func TestLoginUnknownEmailLooksLikeWrongPassword(t *testing.T) {
+	env := authtest.New(t)
+	app := env.App(t, "web")
+	env.User(t, app.ID, "alice@example.com", password)
+
+	ctx := fromIP("10.0.0.1")
+
+	_, wrongPassErr := login(ctx, env, "alice@example.com", "wrong", app.ID)
+	if !errors.Is(wrongPassErr, auth.ErrInvalidCredentials) {
+		t.Fatalf("wrong password: got %v, want %v", wrongPassErr, auth.ErrInvalidCredentials)
+	}
+	if got := env.Hasher.DummyCalls(); got != 0 {
+		t.Fatalf("dummy hash verified for existing user: %d calls", got)
+	}
+
+	_, unknownErr := login(ctx, env, "ghost@example.com", "wrong", app.ID)
+	if !errors.Is(unknownErr, auth.ErrInvalidCredentials) {
+		t.Fatalf("unknown email: got %v, want %v", unknownErr, auth.ErrInvalidCredentials)
+	}
+	if got := env.Hasher.DummyCalls(); got != 1 {
+		t.Fatalf("dummy hash calls = %d, want 1", got)
+	}
+
+	if unknownErr.Error() != wrongPassErr.Error() {
+		t.Fatalf("errors differ: %q vs %q", unknownErr, wrongPassErr)
+	}
+}
+
+// Неудачи на несуществующий email считаются так же, как на настоящий:
+// блокировка наступает на той же попытке и с тем же сроком, иначе по её
+// отсутствию видно, что аккаунта нет.
+func TestLoginUnknownEmailLocksOutLikeExisting(t *testing.T) {
+	env := authtest.New(t)
+	app := env.App(t, "web")
+	env.User(t, app.ID, "alice@example.com", password)
+
+	attempts := func(email string) []error {
+		ctx := fromIP("10.0.0.1")
+
+		var errs []error
+		for range authtest.Lockout.MaxAttempts + 1 {
+			_, err := login(ctx, env, email, "wrong", app.ID)
+			errs = append(errs, err)
+		}
+		return errs
+	}
+
+	existing := attempts("alice@example.com")
+	unknown := attempts("ghost@example.com")
+
+	for i := range existing {
+		if existing[i].Error() != unknown[i].Error() {
+			t.Fatalf("attempt %d: existing %q, unknown %q", i+1, existing[i], unknown[i])
+		}
+	}
+
+	last := unknown[len(unknown)-1]
+	var locked *lockout.LockedError
+	if !errors.As(last, &locked) {
+		t.Fatalf("unknown email not locked after %d attempts: %v", len(unknown), last)
+	}
+	if locked.RetryAfter <= 0 || locked.RetryAfter > authtest.Lockout.BaseDelay {
+		t.Fatalf("retry after = %s, want up to %s", locked.RetryAfter, authtest.Lockout.BaseDelay)
+	}
+
+	// письмо разблокировки уходит только владельцу настоящего аккаунта
+	if got := len(env.Mail.Messages("account_locked")); got != 1 {
+		t.Fatalf("unlock emails = %d, want 1", got)
+	}
+}
+
+func TestLoginUnknownEmailLockoutIgnoresCase(t *testing.T) {
+	env := authtest.New(t)
+	app := env.App(t, "web")
+
+	ctx := fromIP("10.0.0.1")
+	for range authtest.Lockout.MaxAttempts {
+		_, _ = login(ctx, env, "Ghost@Example.com", "wrong", app.ID)
+	}
+
+	_, err := login(ctx, env, "ghost@example.com", "wrong", app.ID)
+	if !errors.Is(err, lockout.ErrAccountLocked) {
+		t.Fatalf("got %v, want %v", err, lockout.ErrAccountLocked)
+	}
+}
+
+// Пароль проверяется раньше статуса: неверный пароль к неподтверждённому
+// аккаунту — ErrInvalidCredentials, верный — ErrEmailNotVerified.
+func TestLoginUnverifiedEmail(t *testing.T) {
+	env := authtest.New(t)
+	app := env.App(t, "web")
+
+	ctx := fromIP("10.0.0.1")
+	if _, err := env.Auth.RegisterNewUser(ctx, "bob@example.com", "bob", password, app.ID); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	if _, err := login(ctx, env, "bob@example.com", "wrong", app.ID); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("wrong password: got %v, want %v", err, auth.ErrInvalidCredentials)
+	}
+
+	if _, err := login(ctx, env, "bob@example.com", password, app.ID); !errors.Is(err, auth.ErrEmailNotVerified) {
+		t.Fatalf("correct password: got %v, want %v", err, auth.ErrEmailNotVerified)
+	}
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"errors"
+
+	twoFactorAuth "auth_service/internal/auth/2fa"
+	"auth_service/internal/auth/lockout"
+	"auth_service/internal/auth/totp"
+	"auth_service/internal/storage"
+)
+
+// Операции в метрике auth_operations_total.
+const (
+	operationLogin     = "login"
+	operationRegister  = "register"
+	operationRefresh   = "refresh"
+	operationTwoFactor = "two_factor"
+	operationExchange  = "token_exchange"
+)
+
+// observe считает попытку операции с её итогом.
+func (a *Auth) observe(operation, result string) {
+	if a.metrics == nil {
+		return
+	}
+
+	a.metrics.AuthOperationsTotal.WithLabelValues(operation, result).Inc()
+}
+
+// result сводит ошибку операции к закрытому набору причин: текст ошибки
+// в label'ы не попадает, иначе кардинальность метрики не ограничена.
+func result(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, ErrInvalidCredentials), errors.Is(err, storage.ErrUserNotFound):
+		return "invalid_credentials"
+	case errors.Is(err, lockout.ErrAccountLocked):
+		return "account_locked"
+	case errors.Is(err, ErrEmailNotVerified):
+		return "email_not_verified"
+	case errors.Is(err, ErrPasswordResetRequired):
+		return "password_reset_required"
+	case errors.Is(err, ErrAccountDisabled):
+		return "account_disabled"
+	case errors.Is(err, ErrAccountSuspended):
+		return "account_suspended"
+	case errors.Is(err, ErrAccountBanned):
+		return "account_banned"
+	case errors.Is(err, ErrAccountDeleted):
+		return "account_deleted"
+	case errors.Is(err, ErrInvalidAppID), errors.Is(err, ErrInvalidClient):
+		return "invalid_app"
+	case errors.Is(err, ErrExchangeNotAllowed), errors.Is(err, ErrInvalidSubjectToken):
+		return "exchange_denied"
+	case errors.Is(err, ErrInvalidScope):
+		return "invalid_scope"
+	case errors.Is(err, ErrNotAppMember), errors.Is(err, ErrNotOrgMember):
+		return "not_member"
+	case errors.Is(err, ErrSuspiciousLogin):
+		return "suspicious_login"
+	case errors.Is(err, storage.ErrUserAlreadyExists):
+		return "user_exists"
+	case errors.Is(err, twoFactorAuth.ErrMagicLinkVerificationFailed),
+		errors.Is(err, totp.ErrInvalidCode),
+		errors.Is(err, storage.ErrPendingSessionNotFound):
+		return "invalid_code"
+	default:
+		return "error"
+	}
+}
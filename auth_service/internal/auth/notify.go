@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"auth_service/internal/lib/clientinfo"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/lib/mailer"
+)
+
+// Purpose писем-уведомлений безопасности — шаблоны в email_sender.
+const (
+	alertNewDeviceLogin  = "new_device_login"
+	alertPasswordChanged = "password_changed"
+	alertEmailChanged    = "email_changed"
+	alertSuspiciousLogin = "suspicious_login"
+)
+
+// notify отправляет уведомление о событии безопасности. В письмо попадают
+// время, IP и User-Agent запроса — по ним пользователь узнаёт, его ли это
+// действие. Ошибка только логируется: событие уже произошло, и отвечать на
+// него ошибкой из-за почты нельзя.
+func (a *Auth) notify(ctx context.Context, userID int64, email, purpose string, data map[string]string) {
+	info := clientinfo.FromContext(ctx)
+
+	if data == nil {
+		data = make(map[string]string, 3)
+	}
+	data["time"] = time.Now().UTC().Format("02.01.2006 15:04 MST")
+	if info.IP != "" {
+		data["ip"] = info.IP
+	}
+	if info.UserAgent != "" {
+		data["user_agent"] = info.UserAgent
+	}
+
+	if err := mailer.SendSecurityAlert(ctx, a.Mail, email, purpose, data); err != nil {
+		a.Log.Error("failed to send security alert",
+			slog.Int64("user_id", userID),
+			slog.String("purpose", purpose),
+			sl.Err(err),
+		)
+	}
+}
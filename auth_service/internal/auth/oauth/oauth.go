@@ -10,18 +10,16 @@ import (
 	"time"
 
 	"auth_service/internal/auth"
+	"auth_service/internal/auth/identity"
 	sl "auth_service/internal/lib/logger"
 	"auth_service/internal/models"
 	"auth_service/internal/storage"
 )
 
 var (
-	ErrOAuthStateInvalid      = errors.New("invalid or expired oauth state")
-	ErrOAuthEmailNotVerified  = errors.New("email not verified by provider")
-	ErrOAuthProviderNotFound  = errors.New("unknown oauth provider")
-	ErrOAuthAccountConflict   = errors.New("account with this email already exists, log in and link instead")
-	ErrOAuthLastAuthMethod    = errors.New("cannot unlink last authentication method")
-	ErrAccountPendingDeletion = errors.New("account with this email is pending deletion")
+	ErrOAuthStateInvalid     = errors.New("invalid or expired oauth state")
+	ErrOAuthEmailNotVerified = errors.New("email not verified by provider")
+	ErrOAuthProviderNotFound = errors.New("unknown oauth provider")
 )
 
 // OAuthProvider — внешний клиент конкретного провайдера (Google/GitHub).
@@ -41,14 +39,6 @@ type OAuthUser struct {
 	EmailVerified  bool
 }
 
-type OAuthAccountRepo interface {
-	SaveOAuthAccount(ctx context.Context, userID int64, provider, providerUserID, email string) error
-	OAuthAccountByProviderUserID(ctx context.Context, provider, providerUserID string) (*models.OAuthAccount, error)
-	OAuthAccountsByUserID(ctx context.Context, userID int64) ([]*models.OAuthAccount, error)
-	UnlinkOAuthAccount(ctx context.Context, userID int64, provider string) error
-	SaveOAuthUser(ctx context.Context, email, username, provider, providerUserID string) (int64, error)
-}
-
 // OAuthStateStore — доступ к state-токенам в Redis.
 type OAuthStateStore interface {
 	SaveOAuthState(ctx context.Context, state string, payload OAuthStatePayload, ttl time.Duration) error
@@ -66,9 +56,9 @@ type OAuthService struct {
 
 	log *slog.Logger
 
-	accountRepo OAuthAccountRepo
-	stateStore  OAuthStateStore
-	providers   map[string]OAuthProvider
+	identities *identity.Service
+	stateStore OAuthStateStore
+	providers  map[string]OAuthProvider
 
 	stateTTL time.Duration
 }
@@ -76,18 +66,18 @@ type OAuthService struct {
 func New(
 	base *auth.Auth,
 	log *slog.Logger,
-	accountRepo OAuthAccountRepo,
+	identities *identity.Service,
 	stateStore OAuthStateStore,
 	providers map[string]OAuthProvider,
 	stateTTL time.Duration,
 ) *OAuthService {
 	return &OAuthService{
-		auth:        base,
-		log:         log,
-		accountRepo: accountRepo,
-		stateStore:  stateStore,
-		providers:   providers,
-		stateTTL:    stateTTL,
+		auth:       base,
+		log:        log,
+		identities: identities,
+		stateStore: stateStore,
+		providers:  providers,
+		stateTTL:   stateTTL,
 	}
 }
 
@@ -176,25 +166,32 @@ func (s *OAuthService) Callback(
 		return "", "", auth.ErrInvalidAppID
 	}
 
+	ident := &models.Identity{
+		Kind:     models.IdentityKindOAuth,
+		Provider: providerName,
+		Subject:  oauthUser.ProviderUserID,
+		Email:    oauthUser.Email,
+	}
+
 	// Linking flow: юзер уже залогинен, привязываем provider к его аккаунту.
 	if payload.UserID != 0 {
-		if err := s.accountRepo.SaveOAuthAccount(ctx, payload.UserID, providerName, oauthUser.ProviderUserID, oauthUser.Email); err != nil {
-			return "", "", fmt.Errorf("%s: link account: %w", op, err)
-		}
-
 		user, err := s.auth.UsrProvider.UserByID(ctx, payload.UserID)
 		if err != nil {
 			return "", "", fmt.Errorf("%s: load linked user: %w", op, err)
 		}
 		if user.DeletedAt != nil {
-			return "", "", ErrAccountPendingDeletion
+			return "", "", identity.ErrAccountPendingDeletion
+		}
+
+		if err := s.identities.Link(ctx, user.ID, ident); err != nil {
+			return "", "", fmt.Errorf("%s: link account: %w", op, err)
 		}
 
-		return s.auth.IssueTokens(ctx, user, app)
+		return s.auth.IssueTokens(ctx, user, app, nil, nil)
 	}
 
 	// Обычный login/register.
-	existing, err := s.accountRepo.OAuthAccountByProviderUserID(ctx, providerName, oauthUser.ProviderUserID)
+	existing, err := s.identities.Find(ctx, providerName, oauthUser.ProviderUserID)
 	switch {
 	case err == nil:
 		user, err := s.auth.UsrProvider.UserByID(ctx, existing.UserID)
@@ -202,26 +199,17 @@ func (s *OAuthService) Callback(
 			return "", "", fmt.Errorf("%s: load user: %w", op, err)
 		}
 		if user.DeletedAt != nil {
-			return "", "", ErrAccountPendingDeletion
+			return "", "", identity.ErrAccountPendingDeletion
 		}
 
-		return s.auth.IssueTokens(ctx, user, app)
-
-	case errors.Is(err, storage.ErrOAuthAccountNotFound):
-		if user, err := s.auth.UsrProvider.UserByEmail(ctx, oauthUser.Email); err == nil {
-			switch {
-			case user.DeletedAt != nil:
-				return "", "", ErrAccountPendingDeletion
-			case !errors.Is(err, storage.ErrUserNotFound):
-				return "", "", fmt.Errorf("%s: check existing user: %w", op, err)
-			default:
-				return "", "", ErrOAuthAccountConflict
-			}
+		if err := s.auth.CheckAppMembership(ctx, user.ID, app.ID); err != nil {
+			return "", "", err
 		}
 
-		username := deriveUsername(oauthUser.Email)
+		return s.auth.IssueTokens(ctx, user, app, nil, nil)
 
-		userID, err := s.accountRepo.SaveOAuthUser(ctx, oauthUser.Email, username, providerName, oauthUser.ProviderUserID)
+	case errors.Is(err, identity.ErrNotFound):
+		userID, err := s.identities.Register(ctx, deriveUsername(oauthUser.Email), ident, app.ID)
 		if err != nil {
 			return "", "", fmt.Errorf("%s: create oauth user: %w", op, err)
 		}
@@ -231,7 +219,7 @@ func (s *OAuthService) Callback(
 			return "", "", fmt.Errorf("%s: load new user: %w", op, err)
 		}
 
-		return s.auth.IssueTokens(ctx, user, app)
+		return s.auth.IssueTokens(ctx, user, app, nil, nil)
 
 	default:
 		return "", "", fmt.Errorf("%s: lookup oauth account: %w", op, err)
@@ -240,24 +228,27 @@ func (s *OAuthService) Callback(
 
 // * Unlink отвязывает provider от юзера.
 func (s *OAuthService) Unlink(ctx context.Context, userID int64, providerName string) error {
-	const op = "OAuthService.Unlink"
+	return s.identities.Unlink(ctx, userID, providerName)
+}
 
-	if err := s.accountRepo.UnlinkOAuthAccount(ctx, userID, providerName); err != nil {
-		if errors.Is(err, storage.ErrOAuthLastAuthMethod) {
-			return ErrOAuthLastAuthMethod
-		}
-		if errors.Is(err, storage.ErrOAuthAccountNotFound) {
-			return err
-		}
-		return fmt.Errorf("%s: %w", op, err)
+// ListAccounts — привязанные OAuth-провайдеры юзера, для профиля/настроек.
+// Учётки других типов отдаёт /me/identities.
+func (s *OAuthService) ListAccounts(ctx context.Context, userID int64) ([]*models.Identity, error) {
+	const op = "OAuthService.ListAccounts"
+
+	identities, err := s.identities.List(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
-}
+	accounts := make([]*models.Identity, 0, len(identities))
+	for _, i := range identities {
+		if i.Kind == models.IdentityKindOAuth {
+			accounts = append(accounts, i)
+		}
+	}
 
-// ListAccounts — привязанные провайдеры юзера, для профиля/настроек.
-func (s *OAuthService) ListAccounts(ctx context.Context, userID int64) ([]*models.OAuthAccount, error) {
-	return s.accountRepo.OAuthAccountsByUserID(ctx, userID)
+	return accounts, nil
 }
 
 func generateState() (string, error) {
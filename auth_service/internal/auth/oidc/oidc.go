@@ -0,0 +1,422 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth_service/internal/auth"
+	"auth_service/internal/lib/jwt"
+	"auth_service/internal/lib/tokens"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// Ошибки соответствуют кодам ошибок RFC 6749 / OIDC Core. ErrInvalidClient и
+// ErrInvalidRedirectURI в /authorize нельзя отдавать редиректом: адрес
+// возврата не подтверждён.
+var (
+	ErrInvalidClient           = errors.New("invalid client")
+	ErrInvalidRedirectURI      = errors.New("redirect_uri is not registered for this client")
+	ErrInvalidRequest          = errors.New("invalid request")
+	ErrUnsupportedResponseType = errors.New("unsupported response type")
+	ErrInvalidScope            = errors.New("invalid scope")
+	ErrInvalidGrant            = errors.New("invalid grant")
+	ErrUnsupportedGrantType    = errors.New("unsupported grant type")
+	ErrAccessDenied            = errors.New("access denied")
+)
+
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+
+	ScopeOpenID = "openid"
+)
+
+// identityScopes — scope'ы OIDC Core, управляющие ID-токеном и userinfo.
+// Остальные запрошенные scope'ы — API-scope'ы приложения: они проверяются
+// по apps.allowed_scopes и попадают в claim scope access-токена.
+var identityScopes = []string{ScopeOpenID, "profile", "email"}
+
+// CodeStore хранит одноразовые коды авторизации.
+type CodeStore interface {
+	SaveAuthorizationCode(ctx context.Context, codeHash []byte, code models.AuthorizationCode, ttl time.Duration) error
+	ConsumeAuthorizationCode(ctx context.Context, codeHash []byte) (*models.AuthorizationCode, error)
+}
+
+// AuthorizeRequest — параметры /authorize. PKCE (S256) обязателен для всех
+// клиентов: фронтенды — публичные клиенты без секрета.
+type AuthorizeRequest struct {
+	ResponseType        string `json:"response_type" example:"code"`
+	ClientID            string `json:"client_id" example:"1"`
+	RedirectURI         string `json:"redirect_uri" example:"https://app.example.com/callback"`
+	Scope               string `json:"scope" example:"openid email profile"`
+	State               string `json:"state,omitempty" example:"af0ifjsldkj"`
+	Nonce               string `json:"nonce,omitempty" example:"n-0S6_WzA2Mj"`
+	CodeChallenge       string `json:"code_challenge" example:"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"`
+	CodeChallengeMethod string `json:"code_challenge_method" example:"S256"`
+}
+
+// TokenRequest — параметры /token. ClientSecret может быть пуст только у
+// публичных клиентов (apps.public_client).
+type TokenRequest struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+}
+
+type TokenResponse struct {
+	AccessToken  string
+	RefreshToken string
+	// IDToken выдаётся только при обмене кода со scope openid.
+	IDToken   string
+	Scope     string
+	ExpiresIn time.Duration
+}
+
+// Provider — OpenID Connect провайдер поверх Auth: приложения выступают
+// OAuth-клиентами, токены выпускаются тем же IssueTokens, что и у /auth/login.
+type Provider struct {
+	auth *auth.Auth
+
+	log   *slog.Logger
+	codes CodeStore
+
+	issuer     string
+	codeTTL    time.Duration
+	idTokenTTL time.Duration
+}
+
+func New(
+	base *auth.Auth,
+	log *slog.Logger,
+	codes CodeStore,
+	issuer string,
+	codeTTL, idTokenTTL time.Duration,
+) *Provider {
+	return &Provider{
+		auth:       base,
+		log:        log,
+		codes:      codes,
+		issuer:     strings.TrimSuffix(issuer, "/"),
+		codeTTL:    codeTTL,
+		idTokenTTL: idTokenTTL,
+	}
+}
+
+// Issuer — идентификатор провайдера (claim iss) без завершающего слеша.
+func (p *Provider) Issuer() string {
+	return p.issuer
+}
+
+// * ValidateAuthorize проверяет параметры /authorize до аутентификации
+// пользователя, чтобы ошибка клиента не доходила до страницы логина.
+func (p *Provider) ValidateAuthorize(ctx context.Context, req AuthorizeRequest) (*models.App, error) {
+	const op = "oidc.ValidateAuthorize"
+
+	app, err := p.client(ctx, req.ClientID)
+	if err != nil {
+		if errors.Is(err, ErrInvalidClient) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if req.RedirectURI == "" || !slices.Contains(app.RedirectURIs, req.RedirectURI) {
+		return nil, ErrInvalidRedirectURI
+	}
+
+	if req.ResponseType != "code" {
+		return nil, ErrUnsupportedResponseType
+	}
+
+	if !slices.Contains(strings.Fields(req.Scope), ScopeOpenID) {
+		return nil, fmt.Errorf("%w: scope must include openid", ErrInvalidScope)
+	}
+
+	for _, scope := range apiScopes(req.Scope) {
+		if !slices.Contains(app.AllowedScopes, scope) {
+			return nil, fmt.Errorf("%w: scope %q is not allowed for this client", ErrInvalidScope, scope)
+		}
+	}
+
+	if req.CodeChallenge == "" {
+		return nil, fmt.Errorf("%w: code_challenge is required", ErrInvalidRequest)
+	}
+
+	if req.CodeChallengeMethod != "S256" {
+		return nil, fmt.Errorf("%w: code_challenge_method must be S256", ErrInvalidRequest)
+	}
+
+	return app, nil
+}
+
+// * Authorize выдаёт код авторизации аутентифицированному пользователю и
+// возвращает redirect_uri клиента с code и state.
+func (p *Provider) Authorize(ctx context.Context, userID int64, req AuthorizeRequest) (string, error) {
+	const op = "oidc.Authorize"
+
+	app, err := p.ValidateAuthorize(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := p.auth.Me(ctx, userID); err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) || errors.Is(err, auth.ErrAccountDeleted) {
+			return "", ErrAccessDenied
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := p.auth.CheckAppMembership(ctx, userID, app.ID); err != nil {
+		if errors.Is(err, auth.ErrNotAppMember) {
+			return "", ErrAccessDenied
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	code, hash, err := tokens.NewAuthorizationCode()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = p.codes.SaveAuthorizationCode(ctx, hash, models.AuthorizationCode{
+		UserID:        userID,
+		AppID:         app.ID,
+		RedirectURI:   req.RedirectURI,
+		CodeChallenge: req.CodeChallenge,
+		Scope:         req.Scope,
+		Nonce:         req.Nonce,
+		AuthTime:      time.Now(),
+	}, p.codeTTL)
+	if err != nil {
+		return "", fmt.Errorf("%s: save code: %w", op, err)
+	}
+
+	params := url.Values{"code": {code}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+
+	return RedirectURL(req.RedirectURI, params), nil
+}
+
+// * Exchange обменивает код авторизации или refresh-токен на токены.
+func (p *Provider) Exchange(ctx context.Context, req TokenRequest) (*TokenResponse, error) {
+	const op = "oidc.Exchange"
+
+	app, err := p.client(ctx, req.ClientID)
+	if err != nil {
+		if errors.Is(err, ErrInvalidClient) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !authenticateClient(app, req.ClientSecret) {
+		return nil, ErrInvalidClient
+	}
+
+	switch req.GrantType {
+	case GrantTypeAuthorizationCode:
+		return p.exchangeCode(ctx, app, req)
+	case GrantTypeRefreshToken:
+		return p.refresh(ctx, app, req)
+	}
+
+	return nil, ErrUnsupportedGrantType
+}
+
+// * UserInfo возвращает профиль для /userinfo.
+func (p *Provider) UserInfo(ctx context.Context, userID int64) (*models.User, error) {
+	return p.auth.Me(ctx, userID)
+}
+
+func (p *Provider) exchangeCode(ctx context.Context, app *models.App, req TokenRequest) (*TokenResponse, error) {
+	const op = "oidc.exchangeCode"
+
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, fmt.Errorf("%w: code and code_verifier are required", ErrInvalidRequest)
+	}
+
+	// GETDEL до проверок: неудачная попытка тоже сжигает код
+	code, err := p.codes.ConsumeAuthorizationCode(ctx, tokens.HashAuthorizationCode(req.Code))
+	if err != nil {
+		if errors.Is(err, storage.ErrAuthorizationCodeNotFound) {
+			return nil, ErrInvalidGrant
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if code.AppID != app.ID || code.RedirectURI != req.RedirectURI {
+		return nil, ErrInvalidGrant
+	}
+
+	if !verifyPKCE(req.CodeVerifier, code.CodeChallenge) {
+		return nil, ErrInvalidGrant
+	}
+
+	user, err := p.auth.Me(ctx, code.UserID)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) || errors.Is(err, auth.ErrAccountDeleted) {
+			return nil, ErrInvalidGrant
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	accessToken, refreshToken, err := p.auth.IssueTokens(ctx, user, app, nil, apiScopes(code.Scope))
+	if err != nil {
+		if errors.Is(err, auth.ErrAccountDisabled) ||
+			errors.Is(err, auth.ErrAccountSuspended) ||
+			errors.Is(err, auth.ErrAccountBanned) ||
+			errors.Is(err, auth.ErrPasswordResetRequired) ||
+			errors.Is(err, auth.ErrSessionLimit) {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidGrant, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	resp := &TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		Scope:        code.Scope,
+		ExpiresIn:    p.auth.AccessTokenTTL(app),
+	}
+
+	if slices.Contains(strings.Fields(code.Scope), ScopeOpenID) {
+		resp.IDToken, err = p.idToken(ctx, user, app, code)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	p.log.Info("authorization code exchanged",
+		slog.Int64("user_id", user.ID),
+		slog.Int("app_id", int(app.ID)),
+	)
+
+	return resp, nil
+}
+
+func (p *Provider) refresh(ctx context.Context, app *models.App, req TokenRequest) (*TokenResponse, error) {
+	const op = "oidc.refresh"
+
+	if req.RefreshToken == "" {
+		return nil, fmt.Errorf("%w: refresh_token is required", ErrInvalidRequest)
+	}
+
+	accessToken, refreshToken, err := p.auth.RefreshForApp(ctx, req.RefreshToken, app.ID)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidCredentials) ||
+			errors.Is(err, auth.ErrAccountDisabled) ||
+			errors.Is(err, auth.ErrAccountSuspended) ||
+			errors.Is(err, auth.ErrAccountBanned) ||
+			errors.Is(err, auth.ErrPasswordResetRequired) {
+			return nil, ErrInvalidGrant
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    p.auth.AccessTokenTTL(app),
+	}, nil
+}
+
+// idToken подписывает ID-токен ключом приложения. Управляемый HS256-ключ
+// клиенту неизвестен, поэтому HS256 ID-токены, как требует OIDC Core,
+// подписываются client_secret.
+func (p *Provider) idToken(ctx context.Context, user *models.User, app *models.App, code *models.AuthorizationCode) (string, error) {
+	key, err := p.auth.SigningKeys.ActiveKey(ctx, app)
+	if err != nil {
+		return "", err
+	}
+	if key != nil && key.Alg == models.SigningAlgHS256 {
+		key = nil
+	}
+
+	return jwt.NewIDToken(jwt.IDToken{
+		Issuer:   p.issuer,
+		User:     *user,
+		Nonce:    code.Nonce,
+		AuthTime: code.AuthTime,
+	}, *app, key, p.idTokenTTL)
+}
+
+func (p *Provider) client(ctx context.Context, clientID string) (*models.App, error) {
+	id, err := strconv.ParseInt(clientID, 10, 32)
+	if err != nil || id <= 0 {
+		return nil, ErrInvalidClient
+	}
+
+	app, err := p.auth.AppProvider.App(ctx, int32(id))
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return nil, ErrInvalidClient
+		}
+		return nil, err
+	}
+
+	return app, nil
+}
+
+// authenticateClient — RFC 6749 §3.2.1: конфиденциальный клиент обязан
+// предъявить секрет на обоих grant'ах, публичный обходится без него —
+// код защищён PKCE, refresh-токен сам по себе. Переданный секрет
+// сверяется и у публичного клиента.
+func authenticateClient(app *models.App, secret string) bool {
+	if secret == "" {
+		return app.PublicClient
+	}
+
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(app.Secret)) == 1
+}
+
+// verifyPKCE — RFC 7636, метод S256.
+func verifyPKCE(verifier, challenge string) bool {
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+// RedirectURL добавляет params к redirect_uri клиента, сохраняя его query.
+func RedirectURL(redirectURI string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+// apiScopes — запрошенные scope'ы без scope'ов OIDC Core.
+func apiScopes(scope string) []string {
+	var result []string
+	for _, s := range strings.Fields(scope) {
+		if !slices.Contains(identityScopes, s) && !slices.Contains(result, s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
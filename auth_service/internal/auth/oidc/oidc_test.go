@@ -0,0 +1,83 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+
+	"auth_service/internal/auth/authtest"
+	"auth_service/internal/auth/oidc"
+	"auth_service/internal/lib/clientinfo"
+	"auth_service/internal/models"
+)
+
+const password = "correct horse battery staple"
+
+// newApp заводит приложение с пользователем <name>@example.com.
+func newApp(t *testing.T, env *authtest.Env, name string, public bool) *models.App {
+	t.Helper()
+
+	app := &models.App{Name: name, Secret: rand.Text(), PublicClient: public}
+	if err := env.Store.SaveApp(context.Background(), app); err != nil {
+		t.Fatalf("save app: %v", err)
+	}
+
+	env.User(t, app.ID, name+"@example.com", password)
+
+	return app
+}
+
+// refreshToken выдаёт пользователю приложения refresh-токен через Login.
+func refreshToken(t *testing.T, env *authtest.Env, app *models.App) string {
+	t.Helper()
+
+	ctx := clientinfo.WithInfo(context.Background(), clientinfo.Info{IP: "10.0.0.1"})
+	res, err := env.Auth.Login(ctx, app.Name+"@example.com", password, app.ID, nil, nil, "", 0)
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	return res.RefreshToken
+}
+
+func TestExchangeClientAuthentication(t *testing.T) {
+	env := authtest.New(t)
+	provider := oidc.New(env.Auth, slog.New(slog.DiscardHandler), nil, "https://auth.example.com", time.Minute, time.Minute)
+
+	confidential := newApp(t, env, "backend", false)
+	public := newApp(t, env, "spa", true)
+
+	tests := []struct {
+		name    string
+		app     *models.App
+		secret  string
+		wantErr error
+	}{
+		{"confidential without secret", confidential, "", oidc.ErrInvalidClient},
+		{"confidential with wrong secret", confidential, "wrong", oidc.ErrInvalidClient},
+		{"confidential with secret", confidential, confidential.Secret, nil},
+		{"public without secret", public, "", nil},
+		{"public with wrong secret", public, "wrong", oidc.ErrInvalidClient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := provider.Exchange(t.Context(), oidc.TokenRequest{
+				GrantType:    oidc.GrantTypeRefreshToken,
+				ClientID:     strconv.Itoa(int(tt.app.ID)),
+				ClientSecret: tt.secret,
+				RefreshToken: refreshToken(t, env, tt.app),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("exchange error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && res.AccessToken == "" {
+				t.Fatal("access token not issued")
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"auth_service/internal/lib/jwt"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// sessionOrg определяет организацию для токенов, выпускаемых при refresh.
+// Явно запрошенная организация требует членства — иначе ErrNotOrgMember.
+// Организация, выбранная в сессии раньше, после исключения пользователя
+// молча сбрасывается: токен без org_id не даёт доступа ни к одному тенанту.
+// Возвращает пустое членство (OrgID == 0), если организации нет.
+func (a *Auth) sessionOrg(ctx context.Context, rt *models.RefreshToken, requested int64) (*models.OrgMember, error) {
+	const op = "Auth.sessionOrg"
+
+	orgID := rt.OrgID
+	if requested != 0 {
+		orgID = requested
+	}
+
+	if orgID == 0 {
+		return &models.OrgMember{}, nil
+	}
+
+	member, err := a.UsrProvider.OrgMember(ctx, orgID, rt.UserID, rt.AppID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrOrgMemberNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if requested != 0 {
+			return nil, ErrNotOrgMember
+		}
+
+		a.Log.Info("session organization dropped: membership revoked",
+			slog.String("op", op),
+			slog.Int64("user_id", rt.UserID),
+			slog.Int64("org_id", orgID),
+		)
+
+		return &models.OrgMember{}, nil
+	}
+
+	return member, nil
+}
+
+func withOrg(authz *jwt.Authorization, org *models.OrgMember) {
+	if org == nil || org.OrgID == 0 {
+		return
+	}
+
+	authz.OrgID = org.OrgID
+	authz.OrgRole = string(org.Role)
+}
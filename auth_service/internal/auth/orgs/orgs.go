@@ -0,0 +1,449 @@
+package orgs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"auth_service/internal/lib/tokens"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+var (
+	// ErrNotFound — организации нет или пользователь в ней не состоит:
+	// ответы не раскрывают существование чужих тенантов.
+	ErrNotFound          = errors.New("organization not found")
+	ErrMemberNotFound    = errors.New("organization member not found")
+	ErrForbidden         = errors.New("insufficient organization role")
+	ErrAlreadyMember     = errors.New("user is already a member of the organization")
+	ErrInvalidInvitation = errors.New("invalid or expired invitation")
+	ErrLastOwner         = errors.New("cannot remove the last owner of the organization")
+	ErrInvalidRole       = errors.New("invalid organization role")
+	ErrAccountExists     = errors.New("account with this email already exists, log in and accept instead")
+)
+
+type Repo interface {
+	CreateOrganization(ctx context.Context, org *models.Organization, ownerID int64) error
+	OrganizationsByUserID(ctx context.Context, userID int64, appID int32) ([]models.OrgMember, error)
+	OrgMember(ctx context.Context, orgID, userID int64, appID int32) (*models.OrgMember, error)
+	OrgMembers(ctx context.Context, orgID int64) ([]models.OrgMember, error)
+	SetOrgMemberRole(ctx context.Context, orgID, userID int64, role models.OrgRole) error
+	RemoveOrgMember(ctx context.Context, orgID, userID int64) error
+	SaveOrgInvitation(ctx context.Context, inv *models.OrgInvitation) error
+	AcceptOrgInvitation(ctx context.Context, tokenHash []byte, userID int64, email string) (*models.OrgInvitation, error)
+	DeclineOrgInvitation(ctx context.Context, tokenHash []byte) (*models.OrgInvitation, error)
+	SaveUserFromOrgInvitation(
+		ctx context.Context,
+		tokenHash []byte,
+		username string,
+		passHash []byte,
+	) (int64, *models.OrgInvitation, error)
+}
+
+type PasswordHasher interface {
+	Hash(ctx context.Context, password string) ([]byte, error)
+}
+
+// PasswordPolicy возвращает *passwordpolicy.Error, если пароль нарушает политику.
+type PasswordPolicy interface {
+	Check(ctx context.Context, password string, userInputs ...string) error
+}
+
+type UserProvider interface {
+	UserByID(ctx context.Context, id int64) (*models.User, error)
+	UserByEmail(ctx context.Context, email string) (*models.User, error)
+}
+
+// Invitation — созданное приглашение. Token уходит письмом на Email.
+// NewUser — аккаунта с этим email ещё нет: письмо ведёт на регистрацию
+// по приглашению, а не на его принятие.
+type Invitation struct {
+	Token     string
+	Email     string
+	ExpiresAt time.Time
+	NewUser   bool
+}
+
+// Service управляет организациями приложения: созданием, участниками и
+// приглашениями. Все операции выполняются от имени участника (actor) и
+// ограничены приложением его access-токена.
+type Service struct {
+	log       *slog.Logger
+	repo      Repo
+	users     UserProvider
+	passwords PasswordPolicy
+	hasher    PasswordHasher
+
+	invitationTTL time.Duration
+}
+
+func New(
+	log *slog.Logger,
+	repo Repo,
+	users UserProvider,
+	passwords PasswordPolicy,
+	hasher PasswordHasher,
+	invitationTTL time.Duration,
+) *Service {
+	return &Service{
+		log:           log,
+		repo:          repo,
+		users:         users,
+		passwords:     passwords,
+		hasher:        hasher,
+		invitationTTL: invitationTTL,
+	}
+}
+
+// Create создаёт организацию в приложении; создатель становится владельцем.
+func (s *Service) Create(ctx context.Context, userID int64, appID int32, name string) (*models.Organization, error) {
+	const op = "orgs.Create"
+
+	org := &models.Organization{
+		AppID: appID,
+		Name:  name,
+	}
+
+	if err := s.repo.CreateOrganization(ctx, org, userID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("organization created",
+		slog.String("op", op),
+		slog.Int64("org_id", org.ID),
+		slog.Int64("user_id", userID),
+	)
+
+	return org, nil
+}
+
+// List — организации приложения, в которых состоит пользователь.
+func (s *Service) List(ctx context.Context, userID int64, appID int32) ([]models.OrgMember, error) {
+	const op = "orgs.List"
+
+	memberships, err := s.repo.OrganizationsByUserID(ctx, userID, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return memberships, nil
+}
+
+// Members — участники организации. Видны любому её участнику.
+func (s *Service) Members(ctx context.Context, actorID int64, appID int32, orgID int64) ([]models.OrgMember, error) {
+	const op = "orgs.Members"
+
+	if _, err := s.member(ctx, orgID, actorID, appID); err != nil {
+		return nil, err
+	}
+
+	members, err := s.repo.OrgMembers(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return members, nil
+}
+
+// Invite создаёт приглашение в организацию. Приглашать могут владельцы и
+// администраторы, и только на роль не выше admin.
+func (s *Service) Invite(
+	ctx context.Context,
+	actorID int64,
+	appID int32,
+	orgID int64,
+	email string,
+	role models.OrgRole,
+) (*Invitation, error) {
+	const op = "orgs.Invite"
+
+	if role != models.OrgRoleAdmin && role != models.OrgRoleMember {
+		return nil, ErrInvalidRole
+	}
+
+	actor, err := s.member(ctx, orgID, actorID, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.Role.CanManage(role) {
+		return nil, ErrForbidden
+	}
+
+	newUser := false
+
+	invitee, err := s.users.UserByEmail(ctx, email)
+	switch {
+	case errors.Is(err, storage.ErrUserNotFound):
+		newUser = true
+	case err != nil:
+		return nil, fmt.Errorf("%s: lookup invitee: %w", op, err)
+	default:
+		_, err := s.repo.OrgMember(ctx, orgID, invitee.ID, appID)
+		switch {
+		case err == nil:
+			return nil, ErrAlreadyMember
+		case !errors.Is(err, storage.ErrOrgMemberNotFound):
+			return nil, fmt.Errorf("%s: check membership: %w", op, err)
+		}
+	}
+
+	token, hash, err := tokens.NewOrgInvitationToken()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	inv := &models.OrgInvitation{
+		OrgID:     orgID,
+		Email:     strings.ToLower(email),
+		Role:      role,
+		TokenHash: hash,
+		InvitedBy: actorID,
+		ExpiresAt: time.Now().Add(s.invitationTTL),
+	}
+
+	if err := s.repo.SaveOrgInvitation(ctx, inv); err != nil {
+		if errors.Is(err, storage.ErrOrgNotFound) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("organization invitation created",
+		slog.String("op", op),
+		slog.Int64("org_id", orgID),
+		slog.Int64("invited_by", actorID),
+		slog.String("role", string(role)),
+		slog.Bool("new_user", newUser),
+	)
+
+	return &Invitation{
+		Token:     token,
+		Email:     inv.Email,
+		ExpiresAt: inv.ExpiresAt,
+		NewUser:   newUser,
+	}, nil
+}
+
+// AcceptInvitation добавляет пользователя в организацию по токену из
+// письма. Принять приглашение может только аккаунт с тем же email.
+func (s *Service) AcceptInvitation(ctx context.Context, userID int64, rawToken string) (*models.OrgInvitation, error) {
+	const op = "orgs.AcceptInvitation"
+
+	user, err := s.users.UserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	inv, err := s.repo.AcceptOrgInvitation(ctx, tokens.HashOrgInvitationToken(rawToken), userID, user.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrOrgInvitationNotFound):
+			return nil, ErrInvalidInvitation
+		case errors.Is(err, storage.ErrOrgMemberExists):
+			return nil, ErrAlreadyMember
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("organization invitation accepted",
+		slog.String("op", op),
+		slog.Int64("org_id", inv.OrgID),
+		slog.Int64("user_id", userID),
+	)
+
+	return inv, nil
+}
+
+// DeclineInvitation отклоняет приглашение по токену из письма. Вход не
+// нужен: владение токеном подтверждает доступ к почте.
+func (s *Service) DeclineInvitation(ctx context.Context, rawToken string) error {
+	const op = "orgs.DeclineInvitation"
+
+	inv, err := s.repo.DeclineOrgInvitation(ctx, tokens.HashOrgInvitationToken(rawToken))
+	if err != nil {
+		if errors.Is(err, storage.ErrOrgInvitationNotFound) {
+			return ErrInvalidInvitation
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("organization invitation declined",
+		slog.String("op", op),
+		slog.Int64("org_id", inv.OrgID),
+		slog.Int64("invitation_id", inv.ID),
+	)
+
+	return nil
+}
+
+// SignUp создаёт аккаунт приглашённому, у которого его ещё нет, и сразу
+// добавляет в организацию. Email берётся из приглашения и считается
+// подтверждённым. Если аккаунт с этим email уже есть, пользователь входит
+// и принимает приглашение через AcceptInvitation.
+func (s *Service) SignUp(
+	ctx context.Context,
+	rawToken string,
+	username string,
+	password string,
+) (int64, *models.OrgInvitation, error) {
+	const op = "orgs.SignUp"
+
+	if err := s.passwords.Check(ctx, password, username); err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	passHash, err := s.hasher.Hash(ctx, password)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	userID, inv, err := s.repo.SaveUserFromOrgInvitation(ctx, tokens.HashOrgInvitationToken(rawToken), username, passHash)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrOrgInvitationNotFound):
+			return 0, nil, ErrInvalidInvitation
+		case errors.Is(err, storage.ErrUserAlreadyExists):
+			return 0, nil, ErrAccountExists
+		}
+
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("user registered via organization invitation",
+		slog.String("op", op),
+		slog.Int64("org_id", inv.OrgID),
+		slog.Int64("user_id", userID),
+	)
+
+	return userID, inv, nil
+}
+
+// ChangeRole меняет роль участника. actor должен иметь право управлять и
+// текущей, и новой ролью участника.
+func (s *Service) ChangeRole(
+	ctx context.Context,
+	actorID int64,
+	appID int32,
+	orgID, userID int64,
+	role models.OrgRole,
+) error {
+	const op = "orgs.ChangeRole"
+
+	switch role {
+	case models.OrgRoleOwner, models.OrgRoleAdmin, models.OrgRoleMember:
+	default:
+		return ErrInvalidRole
+	}
+
+	actor, target, err := s.actorAndTarget(ctx, actorID, appID, orgID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !actor.Role.CanManage(target.Role) || !actor.Role.CanManage(role) {
+		return ErrForbidden
+	}
+
+	if err := s.repo.SetOrgMemberRole(ctx, orgID, userID, role); err != nil {
+		return mapMemberError(op, err)
+	}
+
+	s.log.Info("organization member role changed",
+		slog.String("op", op),
+		slog.Int64("org_id", orgID),
+		slog.Int64("user_id", userID),
+		slog.Int64("actor_id", actorID),
+		slog.String("role", string(role)),
+	)
+
+	return nil
+}
+
+// RemoveMember исключает участника из организации. Пользователь может
+// выйти сам; исключать других могут владельцы и администраторы.
+func (s *Service) RemoveMember(ctx context.Context, actorID int64, appID int32, orgID, userID int64) error {
+	const op = "orgs.RemoveMember"
+
+	actor, target, err := s.actorAndTarget(ctx, actorID, appID, orgID, userID)
+	if err != nil {
+		return err
+	}
+
+	if actorID != userID && !actor.Role.CanManage(target.Role) {
+		return ErrForbidden
+	}
+
+	if err := s.repo.RemoveOrgMember(ctx, orgID, userID); err != nil {
+		return mapMemberError(op, err)
+	}
+
+	s.log.Info("organization member removed",
+		slog.String("op", op),
+		slog.Int64("org_id", orgID),
+		slog.Int64("user_id", userID),
+		slog.Int64("actor_id", actorID),
+	)
+
+	return nil
+}
+
+func (s *Service) actorAndTarget(
+	ctx context.Context,
+	actorID int64,
+	appID int32,
+	orgID, userID int64,
+) (actor, target *models.OrgMember, err error) {
+	actor, err = s.member(ctx, orgID, actorID, appID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if userID == actorID {
+		return actor, actor, nil
+	}
+
+	target, err = s.repo.OrgMember(ctx, orgID, userID, appID)
+	if err != nil {
+		if errors.Is(err, storage.ErrOrgMemberNotFound) {
+			return nil, nil, ErrMemberNotFound
+		}
+
+		return nil, nil, fmt.Errorf("orgs.actorAndTarget: %w", err)
+	}
+
+	return actor, target, nil
+}
+
+// member — членство actor'а в организации приложения. Не участник —
+// ErrNotFound, как и несуществующая организация.
+func (s *Service) member(ctx context.Context, orgID, userID int64, appID int32) (*models.OrgMember, error) {
+	m, err := s.repo.OrgMember(ctx, orgID, userID, appID)
+	if err != nil {
+		if errors.Is(err, storage.ErrOrgMemberNotFound) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("orgs.member: %w", err)
+	}
+
+	return m, nil
+}
+
+func mapMemberError(op string, err error) error {
+	switch {
+	case errors.Is(err, storage.ErrLastOrgOwner):
+		return ErrLastOwner
+	case errors.Is(err, storage.ErrOrgMemberNotFound):
+		return ErrMemberNotFound
+	}
+
+	return fmt.Errorf("%s: %w", op, err)
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// Me возвращает профиль пользователя из access-токена.
+func (a *Auth) Me(ctx context.Context, userID int64) (*models.User, error) {
+	const op = "Auth.Me"
+
+	user, err := a.UsrProvider.UserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if user.DeletedAt != nil {
+		return nil, ErrAccountDeleted
+	}
+
+	return user, nil
+}
+
+// Sessions — активные сессии (refresh-токены) пользователя во всех приложениях.
+func (a *Auth) Sessions(ctx context.Context, userID int64) ([]models.Session, error) {
+	const op = "Auth.Sessions"
+
+	sessions, err := a.UsrProvider.SessionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sessions, nil
+}
+
+// UpdateProfile меняет редактируемые поля профиля. Пока это только username;
+// email меняется отдельным флоу с подтверждением.
+func (a *Auth) UpdateProfile(ctx context.Context, userID int64, username string) (*models.User, error) {
+	const op = "Auth.UpdateProfile"
+
+	if err := a.UsrSaver.UpdateUsername(ctx, userID, username); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUsernameTaken):
+			return nil, ErrUsernameTaken
+		case errors.Is(err, storage.ErrUserNotFound):
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return a.Me(ctx, userID)
+}
@@ -0,0 +1,210 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+var (
+	ErrRoleNotFound = errors.New("role not found")
+	ErrRoleExists   = errors.New("role already exists")
+	ErrAppNotFound  = errors.New("app not found")
+	ErrUserNotFound = errors.New("user not found")
+)
+
+type Repo interface {
+	CreateRole(ctx context.Context, role *models.Role) error
+	RolesByAppID(ctx context.Context, appID int32) ([]models.Role, error)
+	RoleByName(ctx context.Context, appID int32, name string) (*models.Role, error)
+	UserRoles(ctx context.Context, userID int64, appID int32) ([]models.Role, error)
+}
+
+// Service управляет ролями приложений и их назначением пользователям.
+// Сами роли и права попадают в access-токены через auth.Auth при выдаче.
+type Service struct {
+	log  *slog.Logger
+	repo Repo
+	uow  storage.UoW
+}
+
+func New(log *slog.Logger, repo Repo, uow storage.UoW) *Service {
+	return &Service{
+		log:  log,
+		repo: repo,
+		uow:  uow,
+	}
+}
+
+// CreateRole заводит роль приложения.
+func (s *Service) CreateRole(ctx context.Context, role *models.Role) error {
+	const op = "rbac.CreateRole"
+
+	if err := s.repo.CreateRole(ctx, role); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrRoleAlreadyExists):
+			return ErrRoleExists
+		case errors.Is(err, storage.ErrAppNotFound):
+			return ErrAppNotFound
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("role created",
+		slog.String("op", op),
+		slog.Int("app_id", int(role.AppID)),
+		slog.String("role", role.Name),
+	)
+
+	return nil
+}
+
+// ListRoles — роли приложения.
+func (s *Service) ListRoles(ctx context.Context, appID int32) ([]models.Role, error) {
+	const op = "rbac.ListRoles"
+
+	roles, err := s.repo.RolesByAppID(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return roles, nil
+}
+
+// UserRoles — роли пользователя в приложении.
+func (s *Service) UserRoles(ctx context.Context, userID int64, appID int32) ([]models.Role, error) {
+	const op = "rbac.UserRoles"
+
+	roles, err := s.repo.UserRoles(ctx, userID, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return roles, nil
+}
+
+// AssignRole назначает пользователю роль приложения и пишет событие в
+// аудит. Повторное назначение — no-op без записи в аудит.
+func (s *Service) AssignRole(
+	ctx context.Context,
+	userID int64,
+	appID int32,
+	roleName string,
+	event models.AuditEvent,
+) error {
+	const op = "rbac.AssignRole"
+
+	role, err := s.role(ctx, appID, roleName)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	event.UserID = userID
+	event.Action = models.AuditActionRoleAssigned
+
+	err = s.uow.Do(ctx, func(ctx context.Context, tx storage.Tx) error {
+		assigned, err := tx.Roles().AssignRole(ctx, userID, role.ID, event.Actor)
+		if err != nil || !assigned {
+			return err
+		}
+
+		return tx.Audit().SaveAuditEvent(ctx, withRole(event, role))
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUserNotFound):
+			return ErrUserNotFound
+		case errors.Is(err, storage.ErrRoleNotFound):
+			return ErrRoleNotFound
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("role assigned",
+		slog.String("op", op),
+		slog.Int64("user_id", userID),
+		slog.Int("app_id", int(appID)),
+		slog.String("role", role.Name),
+		slog.String("actor", event.Actor),
+	)
+
+	return nil
+}
+
+// RevokeRole снимает с пользователя роль приложения. Роль, которой у
+// пользователя нет, — ErrRoleNotFound.
+func (s *Service) RevokeRole(
+	ctx context.Context,
+	userID int64,
+	appID int32,
+	roleName string,
+	event models.AuditEvent,
+) error {
+	const op = "rbac.RevokeRole"
+
+	role, err := s.role(ctx, appID, roleName)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	event.UserID = userID
+	event.Action = models.AuditActionRoleRevoked
+
+	err = s.uow.Do(ctx, func(ctx context.Context, tx storage.Tx) error {
+		revoked, err := tx.Roles().RevokeRole(ctx, userID, role.ID)
+		if err != nil {
+			return err
+		}
+		if !revoked {
+			return ErrRoleNotFound
+		}
+
+		return tx.Audit().SaveAuditEvent(ctx, withRole(event, role))
+	})
+	if err != nil {
+		if errors.Is(err, ErrRoleNotFound) {
+			return ErrRoleNotFound
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("role revoked",
+		slog.String("op", op),
+		slog.Int64("user_id", userID),
+		slog.Int("app_id", int(appID)),
+		slog.String("role", role.Name),
+		slog.String("actor", event.Actor),
+	)
+
+	return nil
+}
+
+func (s *Service) role(ctx context.Context, appID int32, name string) (*models.Role, error) {
+	role, err := s.repo.RoleByName(ctx, appID, name)
+	if err != nil {
+		if errors.Is(err, storage.ErrRoleNotFound) {
+			return nil, ErrRoleNotFound
+		}
+
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func withRole(event models.AuditEvent, role *models.Role) *models.AuditEvent {
+	if event.Metadata == nil {
+		event.Metadata = make(map[string]any, 2)
+	}
+	event.Metadata["app_id"] = role.AppID
+	event.Metadata["role"] = role.Name
+
+	return &event
+}
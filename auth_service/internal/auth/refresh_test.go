@@ -0,0 +1,97 @@
+package auth_test
+
+import (
+	"errors"
+	"testing"
+
+	"auth_service/internal/auth"
+	"auth_service/internal/auth/authtest"
+	"auth_service/internal/models"
+)
+
+func loggedIn(t *testing.T, env *authtest.Env) (*models.App, *models.User, *auth.LoginResult) {
+	t.Helper()
+
+	app := env.App(t, "web")
+	user := env.User(t, app.ID, "alice@example.com", password)
+
+	res, err := login(fromIP("10.0.0.1"), env, user.Email, password, app.ID)
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	return app, user, res
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	env := authtest.New(t)
+	_, _, res := loggedIn(t, env)
+
+	ctx := t.Context()
+
+	access, rotated, _, err := env.Auth.Refresh(ctx, res.RefreshToken, nil, 0)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if access == "" {
+		t.Fatal("access token not issued")
+	}
+	if rotated == res.RefreshToken {
+		t.Fatal("refresh token not rotated")
+	}
+
+	// старый токен гаснет при ротации: повтор — признак кражи
+	if _, _, _, err := env.Auth.Refresh(ctx, res.RefreshToken, nil, 0); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("reused token: got %v, want %v", err, auth.ErrInvalidCredentials)
+	}
+
+	if _, _, _, err := env.Auth.Refresh(ctx, rotated, nil, 0); err != nil {
+		t.Fatalf("refresh with rotated token: %v", err)
+	}
+}
+
+func TestRefreshRejectsMalformedToken(t *testing.T) {
+	env := authtest.New(t)
+
+	for _, token := range []string{"", "no-dot", "not-a-uuid.secret", "0f8fad5b-d9cb-469f-a165-70867728950e.secret"} {
+		if _, _, _, err := env.Auth.Refresh(t.Context(), token, nil, 0); !errors.Is(err, auth.ErrInvalidCredentials) {
+			t.Fatalf("token %q: got %v, want %v", token, err, auth.ErrInvalidCredentials)
+		}
+	}
+}
+
+func TestRefreshForAppRejectsOtherApp(t *testing.T) {
+	env := authtest.New(t)
+	_, _, res := loggedIn(t, env)
+	other := env.App(t, "mobile")
+
+	if _, _, err := env.Auth.RefreshForApp(t.Context(), res.RefreshToken, other.ID); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("got %v, want %v", err, auth.ErrInvalidCredentials)
+	}
+}
+
+func TestRefreshBlockedUntilPasswordReset(t *testing.T) {
+	env := authtest.New(t)
+	_, user, res := loggedIn(t, env)
+
+	if err := env.Store.SetMustResetPassword(t.Context(), user.ID, true); err != nil {
+		t.Fatalf("set must reset: %v", err)
+	}
+
+	if _, _, _, err := env.Auth.Refresh(t.Context(), res.RefreshToken, nil, 0); !errors.Is(err, auth.ErrPasswordResetRequired) {
+		t.Fatalf("got %v, want %v", err, auth.ErrPasswordResetRequired)
+	}
+}
+
+func TestLogoutRevokesRefreshToken(t *testing.T) {
+	env := authtest.New(t)
+	_, _, res := loggedIn(t, env)
+
+	if err := env.Auth.Logout(t.Context(), res.RefreshToken); err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+
+	if _, _, _, err := env.Auth.Refresh(t.Context(), res.RefreshToken, nil, 0); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("got %v, want %v", err, auth.ErrInvalidCredentials)
+	}
+}
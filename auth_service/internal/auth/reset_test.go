@@ -0,0 +1,144 @@
+package auth_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"auth_service/internal/auth"
+	"auth_service/internal/auth/authtest"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+const newPassword = "another correct horse"
+
+func resetPassword(t *testing.T, env *authtest.Env, email, pass string) error {
+	t.Helper()
+
+	token, err := env.Auth.Forgot(t.Context(), email)
+	if err != nil {
+		t.Fatalf("forgot: %v", err)
+	}
+
+	id, verifier, _ := strings.Cut(token, ".")
+
+	return env.Auth.ResetPassword(t.Context(), id, verifier, pass)
+}
+
+func TestResetPasswordReplacesPassword(t *testing.T) {
+	env := authtest.New(t)
+	app, user, res := loggedIn(t, env)
+
+	if err := resetPassword(t, env, user.Email, newPassword); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+
+	ctx := fromIP("10.0.0.1")
+
+	if _, err := login(ctx, env, user.Email, password, app.ID); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("old password: got %v, want %v", err, auth.ErrInvalidCredentials)
+	}
+	if _, err := login(ctx, env, user.Email, newPassword, app.ID); err != nil {
+		t.Fatalf("new password: %v", err)
+	}
+
+	// сессии, открытые до сброса, закрываются
+	if _, _, _, err := env.Auth.Refresh(t.Context(), res.RefreshToken, nil, 0); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("refresh after reset: got %v, want %v", err, auth.ErrInvalidCredentials)
+	}
+	if !env.AccessTokens.Revoked(user.ID) {
+		t.Fatal("access tokens not revoked after reset")
+	}
+}
+
+func TestResetPasswordTokenIsSingleUse(t *testing.T) {
+	env := authtest.New(t)
+	app := env.App(t, "web")
+	user := env.User(t, app.ID, "alice@example.com", password)
+
+	token, err := env.Auth.Forgot(t.Context(), user.Email)
+	if err != nil {
+		t.Fatalf("forgot: %v", err)
+	}
+	id, verifier, _ := strings.Cut(token, ".")
+
+	if err := env.Auth.ResetPassword(t.Context(), id, verifier, newPassword); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+
+	// после сброса токены пользователя удаляются, а не только помечаются
+	if err := env.Auth.ResetPassword(t.Context(), id, verifier, "yet another password"); !errors.Is(err, storage.ErrResetTokenNotFound) {
+		t.Fatalf("second reset: got %v, want %v", err, storage.ErrResetTokenNotFound)
+	}
+}
+
+func TestResetPasswordRejects(t *testing.T) {
+	env := authtest.New(t)
+	app := env.App(t, "web")
+	user := env.User(t, app.ID, "alice@example.com", password)
+
+	token, err := env.Auth.Forgot(t.Context(), user.Email)
+	if err != nil {
+		t.Fatalf("forgot: %v", err)
+	}
+	id, verifier, _ := strings.Cut(token, ".")
+
+	if err := env.Auth.ResetPassword(t.Context(), id, "wrong-verifier", newPassword); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("wrong verifier: got %v, want %v", err, auth.ErrInvalidCredentials)
+	}
+
+	if err := env.Auth.ResetPassword(t.Context(), id, verifier, password); !errors.Is(err, auth.ErrSamePassword) {
+		t.Fatalf("same password: got %v, want %v", err, auth.ErrSamePassword)
+	}
+}
+
+// Новый запрос сброса гасит ссылку из прошлого письма.
+func TestForgotInvalidatesPreviousToken(t *testing.T) {
+	env := authtest.New(t)
+	app := env.App(t, "web")
+	user := env.User(t, app.ID, "alice@example.com", password)
+
+	first, err := env.Auth.Forgot(t.Context(), user.Email)
+	if err != nil {
+		t.Fatalf("forgot: %v", err)
+	}
+
+	if _, err := env.Auth.Forgot(t.Context(), user.Email); err != nil {
+		t.Fatalf("second forgot: %v", err)
+	}
+
+	id, verifier, _ := strings.Cut(first, ".")
+	if err := env.Auth.ResetPassword(t.Context(), id, verifier, newPassword); err == nil {
+		t.Fatal("previous reset token still valid")
+	}
+}
+
+func TestRequirePasswordReset(t *testing.T) {
+	env := authtest.New(t)
+	app, user, res := loggedIn(t, env)
+
+	if err := env.Auth.RequirePasswordReset(t.Context(), user.ID, models.AuditEvent{Actor: "admin"}); err != nil {
+		t.Fatalf("require reset: %v", err)
+	}
+
+	if !env.AccessTokens.Revoked(user.ID) {
+		t.Fatal("access tokens not revoked")
+	}
+	if _, _, _, err := env.Auth.Refresh(t.Context(), res.RefreshToken, nil, 0); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("refresh: got %v, want %v", err, auth.ErrInvalidCredentials)
+	}
+
+	ctx := fromIP("10.0.0.1")
+	if _, err := login(ctx, env, user.Email, password, app.ID); !errors.Is(err, auth.ErrPasswordResetRequired) {
+		t.Fatalf("login before reset: got %v, want %v", err, auth.ErrPasswordResetRequired)
+	}
+
+	if err := resetPassword(t, env, user.Email, newPassword); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+
+	if _, err := login(ctx, env, user.Email, newPassword, app.ID); err != nil {
+		t.Fatalf("login after reset: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"auth_service/internal/lib/jwt"
+)
+
+// authorization собирает роли пользователя в приложении и объединение их
+// прав для access-токена. Роли читаются при каждой выдаче токена, поэтому
+// изменения доходят до resource server'ов не позже следующего refresh.
+func (a *Auth) authorization(ctx context.Context, userID int64, appID int32) (jwt.Authorization, error) {
+	const op = "Auth.authorization"
+
+	roles, err := a.UsrProvider.UserRoles(ctx, userID, appID)
+	if err != nil {
+		return jwt.Authorization{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(roles) == 0 {
+		return jwt.Authorization{}, nil
+	}
+
+	authz := jwt.Authorization{Roles: make([]string, 0, len(roles))}
+	seen := make(map[string]struct{})
+
+	for _, role := range roles {
+		authz.Roles = append(authz.Roles, role.Name)
+
+		for _, p := range role.Permissions {
+			if _, ok := seen[p]; ok {
+				continue
+			}
+			seen[p] = struct{}{}
+			authz.Permissions = append(authz.Permissions, p)
+		}
+	}
+
+	sort.Strings(authz.Roles)
+	sort.Strings(authz.Permissions)
+
+	return authz, nil
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"slices"
+
+	"auth_service/internal/models"
+)
+
+// allowedScopes проверяет запрошенные при логине scope'ы по списку
+// приложения и убирает дубликаты. Неразрешённый scope отклоняет весь
+// запрос, а не отбрасывается молча: клиент должен знать, что получил
+// меньше, чем просил.
+func allowedScopes(app *models.App, requested []string) ([]string, error) {
+	return subsetScopes(app.AllowedScopes, requested)
+}
+
+// narrowScopes — scope'ы для access-токена при refresh: пустой запрос
+// сохраняет выданные при логине, иначе допускается только их подмножество.
+func narrowScopes(granted, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return granted, nil
+	}
+
+	return subsetScopes(granted, requested)
+}
+
+func subsetScopes(allowed, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, nil
+	}
+
+	result := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		if !slices.Contains(allowed, scope) {
+			return nil, ErrInvalidScope
+		}
+		if !slices.Contains(result, scope) {
+			result = append(result, scope)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,170 @@
+package signingkeys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"auth_service/internal/lib/jwt"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// Store хранит ключи подписи. У приложения один активный ключ, которым
+// подписываются новые токены, и сколько угодно выведенных, которые ещё
+// принимаются при проверке до expires_at.
+type Store interface {
+	App(ctx context.Context, appID int32) (*models.App, error)
+
+	ActiveSigningKey(ctx context.Context, appID int32) (*models.SigningKey, error)
+	RotateSigningKey(ctx context.Context, key *models.SigningKey, retiredExpiresAt time.Time) error
+	AppsDueForKeyRotation(ctx context.Context, createdBefore time.Time) ([]int32, error)
+	DeleteExpiredSigningKeys(ctx context.Context) (int64, error)
+}
+
+// Manager выдаёт активный ключ подписи приложения и ротирует ключи.
+// Выведенный ключ остаётся валидным ещё grace — этого должно хватать, чтобы
+// истекли все выданные им access-токены. Приложению со своим access TTL
+// grace продлевается до TTL + leeway.
+type Manager struct {
+	log              *slog.Logger
+	store            Store
+	rotationInterval time.Duration
+	grace            time.Duration
+	leeway           time.Duration
+}
+
+func New(log *slog.Logger, store Store, rotationInterval, grace, leeway time.Duration) *Manager {
+	return &Manager{
+		log:              log,
+		store:            store,
+		rotationInterval: rotationInterval,
+		grace:            grace,
+		leeway:           leeway,
+	}
+}
+
+// * ActiveKey возвращает ключ, которым подписывать токены приложения. nil —
+// HS256 на app.Secret: приложение ещё не переведено на управляемые ключи.
+// Для RS256/ES256 ключ создаётся при первом обращении, при смене
+// apps.signing_alg старый ключ выводится с grace-периодом.
+func (m *Manager) ActiveKey(ctx context.Context, app *models.App) (*models.SigningKey, error) {
+	const op = "signingkeys.ActiveKey"
+
+	alg := appAlg(app)
+
+	key, err := m.store.ActiveSigningKey(ctx, app.ID)
+	switch {
+	case err == nil:
+		if key.Alg == alg {
+			return key, nil
+		}
+	case errors.Is(err, storage.ErrSigningKeyNotFound):
+		if alg == models.SigningAlgHS256 {
+			return nil, nil
+		}
+	default:
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	key, err = m.rotate(ctx, app, alg)
+	if err != nil {
+		if !errors.Is(err, storage.ErrSigningKeyConflict) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		// ключ одновременно выпустила другая реплика — берём её ключ
+		key, err = m.store.ActiveSigningKey(ctx, app.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: reload: %w", op, err)
+		}
+	}
+
+	return key, nil
+}
+
+// * Rotate выпускает новый ключ приложения с его текущим алгоритмом и
+// выводит прежний. Для HS256-приложения первая ротация переводит его с
+// app.Secret на управляемые ключи.
+func (m *Manager) Rotate(ctx context.Context, appID int32) (*models.SigningKey, error) {
+	const op = "signingkeys.Rotate"
+
+	app, err := m.store.App(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	key, err := m.rotate(ctx, app, appAlg(app))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// * RotateDue ротирует ключи старше rotationInterval и удаляет ключи с
+// истёкшим grace-периодом. rotationInterval == 0 — плановая ротация
+// выключена, только чистка.
+func (m *Manager) RotateDue(ctx context.Context) error {
+	const op = "signingkeys.RotateDue"
+
+	if m.rotationInterval > 0 {
+		appIDs, err := m.store.AppsDueForKeyRotation(ctx, time.Now().Add(-m.rotationInterval))
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, appID := range appIDs {
+			// конфликт — ключ уже ротировали параллельно, это не ошибка
+			if _, err := m.Rotate(ctx, appID); err != nil && !errors.Is(err, storage.ErrSigningKeyConflict) {
+				m.log.Error("failed to rotate signing key",
+					slog.String("op", op),
+					slog.Int("app_id", int(appID)),
+					sl.Err(err),
+				)
+			}
+		}
+	}
+
+	deleted, err := m.store.DeleteExpiredSigningKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if deleted > 0 {
+		m.log.Info("expired signing keys deleted", slog.Int64("count", deleted))
+	}
+
+	return nil
+}
+
+func (m *Manager) rotate(ctx context.Context, app *models.App, alg models.SigningAlg) (*models.SigningKey, error) {
+	key, err := jwt.GenerateSigningKey(app.ID, alg)
+	if err != nil {
+		return nil, err
+	}
+
+	grace := max(m.grace, app.AccessTokenTTL+m.leeway)
+	if err := m.store.RotateSigningKey(ctx, key, time.Now().Add(grace)); err != nil {
+		return nil, err
+	}
+
+	m.log.Info("signing key rotated",
+		slog.Int("app_id", int(app.ID)),
+		slog.String("alg", string(alg)),
+		slog.String("kid", key.KID),
+	)
+
+	return key, nil
+}
+
+func appAlg(app *models.App) models.SigningAlg {
+	if app.SigningAlg == "" {
+		return models.SigningAlgHS256
+	}
+
+	return app.SigningAlg
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// statusTransitions — переходы, доступные администратору. pending_deletion
+// управляется самим пользователем через DeleteAccount/RestoreAccount.
+var statusTransitions = map[models.AccountStatus][]models.AccountStatus{
+	models.AccountStatusActive:    {models.AccountStatusDisabled, models.AccountStatusSuspended, models.AccountStatusBanned},
+	models.AccountStatusDisabled:  {models.AccountStatusActive, models.AccountStatusBanned},
+	models.AccountStatusSuspended: {models.AccountStatusActive, models.AccountStatusDisabled, models.AccountStatusBanned},
+	models.AccountStatusBanned:    {models.AccountStatusActive},
+}
+
+// checkAccountStatus — общая проверка перед выдачей токенов (Login,
+// Refresh, magic-link, OAuth). Soft-deleted аккаунты отсекаются раньше по
+// deleted_at, здесь только модерация.
+func checkAccountStatus(user *models.User) error {
+	switch user.Status {
+	case models.AccountStatusDisabled:
+		return ErrAccountDisabled
+	case models.AccountStatusSuspended:
+		return ErrAccountSuspended
+	case models.AccountStatusBanned:
+		return ErrAccountBanned
+	case models.AccountStatusPendingDeletion:
+		return ErrAccountDeleted
+	}
+	return nil
+}
+
+// checkTokenIssuance — checkAccountStatus плюс флаг обязательной смены
+// пароля: пока он стоит, токены не выдаются ни одним способом входа и не
+// продлеваются refresh'ем.
+func checkTokenIssuance(user *models.User) error {
+	if err := checkAccountStatus(user); err != nil {
+		return err
+	}
+	if user.MustResetPassword {
+		return ErrPasswordResetRequired
+	}
+	return nil
+}
+
+func canTransition(from, to models.AccountStatus) bool {
+	for _, allowed := range statusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangeStatus переводит аккаунт в новый статус. При отключении или
+// блокировке (disabled, suspended, banned) сессии завершаются так же, как
+// в ForceLogout: refresh-токены удаляются, access-токены отзываются по jti.
+func (a *Auth) ChangeStatus(
+	ctx context.Context,
+	userID int64,
+	status models.AccountStatus,
+	reason string,
+	event models.AuditEvent,
+) error {
+	const op = "Auth.ChangeStatus"
+
+	event.UserID = userID
+	event.Action = models.AuditActionStatusChange
+
+	err := a.UoW.Do(ctx, func(ctx context.Context, tx storage.Tx) error {
+		user, err := tx.Users().UserByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if !canTransition(user.Status, status) {
+			return ErrInvalidStatusTransition
+		}
+
+		if err := tx.Users().SetUserStatus(ctx, userID, user.Status, status, reason, event.Actor); err != nil {
+			return err
+		}
+
+		if event.Metadata == nil {
+			event.Metadata = make(map[string]any, 3)
+		}
+		event.Metadata["from"] = user.Status
+		event.Metadata["to"] = status
+
+		if status != models.AccountStatusActive {
+			deleted, err := tx.Tokens().DeleteAllRefreshTokens(ctx, userID)
+			if err != nil {
+				return err
+			}
+
+			revoked, err := a.AccessTokens.RevokeUserAccessTokens(ctx, userID, a.leeway)
+			if err != nil {
+				return err
+			}
+
+			event.Metadata["refresh_tokens_deleted"] = deleted
+			event.Metadata["access_tokens_revoked"] = revoked
+		}
+
+		return tx.Audit().SaveAuditEvent(ctx, &event)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUserNotFound):
+			return ErrUserNotFound
+		case errors.Is(err, storage.ErrUserStatusConflict), errors.Is(err, ErrInvalidStatusTransition):
+			return ErrInvalidStatusTransition
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.Log.Info("account status changed",
+		slog.String("op", op),
+		slog.Int64("user_id", userID),
+		slog.String("status", string(status)),
+		slog.String("actor", event.Actor),
+	)
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package auth_test
+
+import (
+	"errors"
+	"testing"
+
+	"auth_service/internal/auth"
+	"auth_service/internal/auth/authtest"
+	"auth_service/internal/models"
+)
+
+func changeStatus(t *testing.T, env *authtest.Env, userID int64, status models.AccountStatus) error {
+	t.Helper()
+
+	return env.Auth.ChangeStatus(t.Context(), userID, status, "", models.AuditEvent{Actor: "admin"})
+}
+
+func TestDisableClosesSessionsAndBlocksLogin(t *testing.T) {
+	env := authtest.New(t)
+	app, user, res := loggedIn(t, env)
+
+	if err := changeStatus(t, env, user.ID, models.AccountStatusDisabled); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+
+	if !env.AccessTokens.Revoked(user.ID) {
+		t.Fatal("access tokens not revoked")
+	}
+	if _, _, _, err := env.Auth.Refresh(t.Context(), res.RefreshToken, nil, 0); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("refresh: got %v, want %v", err, auth.ErrInvalidCredentials)
+	}
+
+	ctx := fromIP("10.0.0.1")
+	if _, err := login(ctx, env, user.Email, password, app.ID); !errors.Is(err, auth.ErrAccountDisabled) {
+		t.Fatalf("login while disabled: got %v, want %v", err, auth.ErrAccountDisabled)
+	}
+
+	if err := changeStatus(t, env, user.ID, models.AccountStatusActive); err != nil {
+		t.Fatalf("enable: %v", err)
+	}
+	if _, err := login(ctx, env, user.Email, password, app.ID); err != nil {
+		t.Fatalf("login after enable: %v", err)
+	}
+}
+
+func TestStatusTransitions(t *testing.T) {
+	tests := []struct {
+		name string
+		path []models.AccountStatus
+		err  error
+	}{
+		{"disabled to banned", []models.AccountStatus{models.AccountStatusDisabled, models.AccountStatusBanned}, nil},
+		{"suspended to disabled", []models.AccountStatus{models.AccountStatusSuspended, models.AccountStatusDisabled}, nil},
+		// отключённый аккаунт не блокируют за нарушение: сначала active
+		{"disabled to suspended", []models.AccountStatus{models.AccountStatusDisabled, models.AccountStatusSuspended}, auth.ErrInvalidStatusTransition},
+		{"banned to disabled", []models.AccountStatus{models.AccountStatusBanned, models.AccountStatusDisabled}, auth.ErrInvalidStatusTransition},
+		{"disabled twice", []models.AccountStatus{models.AccountStatusDisabled, models.AccountStatusDisabled}, auth.ErrInvalidStatusTransition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := authtest.New(t)
+			app := env.App(t, "web")
+			user := env.User(t, app.ID, "alice@example.com", password)
+
+			last := len(tt.path) - 1
+			for _, status := range tt.path[:last] {
+				if err := changeStatus(t, env, user.ID, status); err != nil {
+					t.Fatalf("%s: %v", status, err)
+				}
+			}
+
+			if err := changeStatus(t, env, user.ID, tt.path[last]); !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
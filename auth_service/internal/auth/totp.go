@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"auth_service/internal/auth/totp"
+	"auth_service/internal/models"
+
+	sl "auth_service/internal/lib/logger"
+)
+
+type TOTPService interface {
+	Enroll(ctx context.Context, userID int64, accountName string) (*totp.Enrollment, error)
+	Confirm(ctx context.Context, userID int64, code string) error
+	Verify(ctx context.Context, userID int64, code string) error
+	Disable(ctx context.Context, userID int64) error
+
+	RequestChallenge(ctx context.Context, userID int64, appID int32, scopes []string, pendingSessionTTL time.Duration) (sessionID string, err error)
+	VerifyLogin(ctx context.Context, sessionID, code string) (*models.PendingSession, error)
+}
+
+// * EnrollTOTP начинает подключение TOTP: выдаёт секрет и otpauth URI для
+// QR-кода. 2FA включается только после ConfirmTOTP.
+func (a *Auth) EnrollTOTP(ctx context.Context, userID int64) (*totp.Enrollment, error) {
+	const op = "Auth.EnrollTOTP"
+
+	log := a.Log.With(slog.String("op", op))
+
+	status, err := a.UsrProvider.TwoFAStatus(ctx, userID)
+	if err != nil {
+		log.Error("failed to get 2fa status", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// смена способа — через disable текущего: иначе access-токена хватило бы,
+	// чтобы подменить второй фактор
+	if status.IsEnabled {
+		return nil, ErrTwoFAAlreadyEnabled
+	}
+
+	user, err := a.UsrProvider.UserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	enrollment, err := a.TOTP.Enroll(ctx, userID, user.Email)
+	if err != nil {
+		if errors.Is(err, totp.ErrAlreadyConfirmed) {
+			return nil, ErrTwoFAAlreadyEnabled
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return enrollment, nil
+}
+
+// * ConfirmTOTP проверяет первый код из приложения и включает TOTP 2FA.
+func (a *Auth) ConfirmTOTP(ctx context.Context, userID int64, code string) error {
+	const op = "Auth.ConfirmTOTP"
+
+	if err := a.TOTP.Confirm(ctx, userID, code); err != nil {
+		if errors.Is(err, totp.ErrAlreadyConfirmed) {
+			return ErrTwoFAAlreadyEnabled
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.Log.Info("totp 2fa enabled", slog.String("op", op), slog.Int64("user_id", userID))
+
+	a.recordUserEvent(ctx, userID, models.AuditActionTwoFAEnabled, map[string]any{"method": models.TwoFAMethodTOTP})
+
+	return nil
+}
+
+// * DisableTOTP выключает TOTP 2FA по действующему коду из приложения.
+func (a *Auth) DisableTOTP(ctx context.Context, userID int64, code string) error {
+	const op = "Auth.DisableTOTP"
+
+	log := a.Log.With(slog.String("op", op))
+
+	status, err := a.UsrProvider.TwoFAStatus(ctx, userID)
+	if err != nil {
+		log.Error("failed to get 2fa status", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !status.IsEnabled || status.Method == nil || *status.Method != models.TwoFAMethodTOTP {
+		return ErrTwoFANotEnabled
+	}
+
+	if err := a.TOTP.Verify(ctx, userID, code); err != nil {
+		if errors.Is(err, totp.ErrInvalidCode) {
+			log.Warn("disable totp: invalid code")
+			return ErrDisableConfirmation
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.TOTP.Disable(ctx, userID); err != nil {
+		log.Error("failed to disable totp", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("totp 2fa disabled", slog.Int64("user_id", userID))
+
+	a.recordUserEvent(ctx, userID, models.AuditActionTwoFADisabled, map[string]any{"method": models.TwoFAMethodTOTP})
+
+	return nil
+}
+
+// * VerifyTOTPLogin подтверждает второй фактор кодом из приложения и
+// выдаёт токены. rememberDevice — выдать токен доверенного устройства.
+func (a *Auth) VerifyTOTPLogin(
+	ctx context.Context,
+	sessionID, code string,
+	device *models.Device,
+	rememberDevice bool,
+) (res *LoginResult, err error) {
+	const op = "Auth.VerifyTOTPLogin"
+
+	defer func() { a.observe(operationTwoFactor, result(err)) }()
+
+	session, err := a.TOTP.VerifyLogin(ctx, sessionID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.UsrProvider.UserByID(ctx, session.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app, err := a.AppProvider.App(ctx, session.AppID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	accessToken, refreshToken, err := a.IssueTokens(ctx, user, app, device, session.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	res = a.issuedResult(app, accessToken, refreshToken)
+
+	if rememberDevice {
+		a.trustDevice(ctx, res, user.ID, device)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,59 @@
+package totp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+var errMalformedCiphertext = errors.New("malformed ciphertext")
+
+// secretCipher шифрует TOTP-секреты перед записью в БД (AES-256-GCM).
+// Формат: nonce || ciphertext. user_id идёт в additional data — секрет
+// одного пользователя нельзя подложить другому копированием строки.
+type secretCipher struct {
+	aead cipher.AEAD
+}
+
+func newSecretCipher(key []byte) (*secretCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("totp.newSecretCipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("totp.newSecretCipher: %w", err)
+	}
+
+	return &secretCipher{aead: aead}, nil
+}
+
+func (c *secretCipher) encrypt(userID int64, secret string) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("totp.encrypt: %w", err)
+	}
+
+	return c.aead.Seal(nonce, nonce, []byte(secret), additionalData(userID)), nil
+}
+
+func (c *secretCipher) decrypt(userID int64, data []byte) (string, error) {
+	n := c.aead.NonceSize()
+	if len(data) < n {
+		return "", fmt.Errorf("totp.decrypt: %w", errMalformedCiphertext)
+	}
+
+	plain, err := c.aead.Open(nil, data[:n], data[n:], additionalData(userID))
+	if err != nil {
+		return "", fmt.Errorf("totp.decrypt: %w", err)
+	}
+
+	return string(plain), nil
+}
+
+func additionalData(userID int64) []byte {
+	return fmt.Appendf(nil, "totp:%d", userID)
+}
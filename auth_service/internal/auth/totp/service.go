@@ -0,0 +1,284 @@
+package totp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+var (
+	ErrNotConfigured    = errors.New("totp is not configured")
+	ErrInvalidCode      = errors.New("invalid totp code")
+	ErrNotEnrolled      = errors.New("totp is not enrolled")
+	ErrAlreadyConfirmed = errors.New("totp already confirmed")
+)
+
+type Repo interface {
+	SaveTOTPSecret(ctx context.Context, userID int64, secretEnc []byte) error
+	TOTPSecret(ctx context.Context, userID int64) (*models.TOTPSecret, error)
+	ConfirmTOTP(ctx context.Context, userID int64, step int64) error
+	UseTOTPStep(ctx context.Context, userID int64, step int64) error
+	DeleteTOTP(ctx context.Context, userID int64) error
+}
+
+type PendingSessions interface {
+	SetPendingSession(ctx context.Context, sessionID string, session models.PendingSession, ttl time.Duration) error
+	GetPendingSession(ctx context.Context, sessionID string) (*models.PendingSession, error)
+	DeletePendingSession(ctx context.Context, sessionID string) error
+}
+
+// Enrollment — данные для настройки приложения-аутентификатора.
+type Enrollment struct {
+	Secret string
+	URI    string
+}
+
+type Service struct {
+	log      *slog.Logger
+	repo     Repo
+	sessions PendingSessions
+	cipher   *secretCipher
+	issuer   string
+}
+
+// New создаёт TOTP-сервис. encryptionKey — base64 от 32 байт; пустой ключ
+// допустим — тогда TOTP выключен и все методы возвращают ErrNotConfigured.
+func New(
+	log *slog.Logger,
+	repo Repo,
+	sessions PendingSessions,
+	encryptionKey, issuer string,
+) (*Service, error) {
+	const op = "totp.New"
+
+	s := &Service{
+		log:      log,
+		repo:     repo,
+		sessions: sessions,
+		issuer:   issuer,
+	}
+
+	if encryptionKey == "" {
+		return s, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: decode key: %w", op, err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("%s: key must be 32 bytes, got %d", op, len(key))
+	}
+
+	s.cipher, err = newSecretCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s, nil
+}
+
+// * Enroll генерирует новый секрет и сохраняет его неподтверждённым.
+// Повторный вызов до подтверждения выдаёт новый секрет взамен старого.
+func (s *Service) Enroll(ctx context.Context, userID int64, accountName string) (*Enrollment, error) {
+	const op = "totp.Service.Enroll"
+
+	if s.cipher == nil {
+		return nil, ErrNotConfigured
+	}
+
+	secret, err := GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	enc, err := s.cipher.encrypt(userID, secret)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.repo.SaveTOTPSecret(ctx, userID, enc); err != nil {
+		if errors.Is(err, storage.ErrTOTPAlreadyConfirmed) {
+			return nil, ErrAlreadyConfirmed
+		}
+
+		return nil, fmt.Errorf("%s: save: %w", op, err)
+	}
+
+	return &Enrollment{
+		Secret: secret,
+		URI:    ProvisioningURI(s.issuer, accountName, secret),
+	}, nil
+}
+
+// * Confirm проверяет первый код из приложения и включает TOTP 2FA.
+func (s *Service) Confirm(ctx context.Context, userID int64, code string) error {
+	const op = "totp.Service.Confirm"
+
+	stored, secret, err := s.loadSecret(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if stored.ConfirmedAt != nil {
+		return ErrAlreadyConfirmed
+	}
+
+	step, ok := Validate(secret, code, time.Now())
+	if !ok {
+		return ErrInvalidCode
+	}
+
+	if err := s.repo.ConfirmTOTP(ctx, userID, step); err != nil {
+		if errors.Is(err, storage.ErrTOTPNotFound) {
+			return ErrNotEnrolled
+		}
+
+		return fmt.Errorf("%s: confirm: %w", op, err)
+	}
+
+	return nil
+}
+
+// * Verify проверяет код подтверждённого секрета и помечает его шаг
+// использованным.
+func (s *Service) Verify(ctx context.Context, userID int64, code string) error {
+	const op = "totp.Service.Verify"
+
+	stored, secret, err := s.loadSecret(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if stored.ConfirmedAt == nil {
+		return ErrNotEnrolled
+	}
+
+	step, ok := Validate(secret, code, time.Now())
+	if !ok {
+		return ErrInvalidCode
+	}
+
+	if err := s.repo.UseTOTPStep(ctx, userID, step); err != nil {
+		if errors.Is(err, storage.ErrTOTPCodeReused) {
+			s.log.Warn("totp code reuse rejected", slog.String("op", op), slog.Int64("user_id", userID))
+			return ErrInvalidCode
+		}
+
+		return fmt.Errorf("%s: use step: %w", op, err)
+	}
+
+	return nil
+}
+
+// * Disable удаляет секрет и выключает TOTP 2FA. Проверка кода — на
+// вызывающем.
+func (s *Service) Disable(ctx context.Context, userID int64) error {
+	const op = "totp.Service.Disable"
+
+	if err := s.repo.DeleteTOTP(ctx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// * RequestChallenge открывает pending-сессию логина, которую завершает
+// VerifyLogin с кодом из приложения.
+func (s *Service) RequestChallenge(
+	ctx context.Context,
+	userID int64,
+	appID int32,
+	scopes []string,
+	pendingSessionTTL time.Duration,
+) (string, error) {
+	const op = "totp.Service.RequestChallenge"
+
+	sessionID, err := generateSessionID()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	session := models.PendingSession{
+		UserID: userID,
+		AppID:  appID,
+		Action: models.ActionLoginTOTP,
+		Scopes: scopes,
+	}
+
+	if err := s.sessions.SetPendingSession(ctx, sessionID, session, pendingSessionTTL); err != nil {
+		return "", fmt.Errorf("%s: set pending session: %w", op, err)
+	}
+
+	return sessionID, nil
+}
+
+// * VerifyLogin проверяет код в рамках логина и завершает pending-сессию.
+func (s *Service) VerifyLogin(ctx context.Context, sessionID, code string) (*models.PendingSession, error) {
+	const op = "totp.Service.VerifyLogin"
+
+	pending, err := s.sessions.GetPendingSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, storage.ErrPendingSessionNotFound) {
+			return nil, storage.ErrPendingSessionNotFound
+		}
+
+		return nil, fmt.Errorf("%s: pending session: %w", op, err)
+	}
+
+	if pending.Action != models.ActionLoginTOTP {
+		return nil, ErrInvalidCode
+	}
+
+	if err := s.Verify(ctx, pending.UserID, code); err != nil {
+		if errors.Is(err, ErrInvalidCode) || errors.Is(err, ErrNotEnrolled) {
+			return nil, ErrInvalidCode
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.sessions.DeletePendingSession(ctx, sessionID); err != nil {
+		s.log.Warn("failed to delete pending session", slog.String("op", op), slog.Any("err", err))
+	}
+
+	return pending, nil
+}
+
+func (s *Service) loadSecret(ctx context.Context, userID int64) (*models.TOTPSecret, string, error) {
+	if s.cipher == nil {
+		return nil, "", ErrNotConfigured
+	}
+
+	stored, err := s.repo.TOTPSecret(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrTOTPNotFound) {
+			return nil, "", ErrNotEnrolled
+		}
+
+		return nil, "", fmt.Errorf("load: %w", err)
+	}
+
+	secret, err := s.cipher.decrypt(userID, stored.SecretEnc)
+	if err != nil {
+		return nil, "", fmt.Errorf("decrypt: %w", err)
+	}
+
+	return stored, secret, nil
+}
+
+func generateSessionID() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generateSessionID: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
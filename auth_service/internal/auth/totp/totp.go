@@ -0,0 +1,94 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Параметры RFC 6238 в варианте, который понимают все приложения-аутентификаторы
+// (Google Authenticator, Authy, 1Password): SHA1, 6 цифр, шаг 30 секунд.
+const (
+	secretSize = 20
+	digits     = 6
+	period     = 30 * time.Second
+
+	// skew — сколько соседних шагов принимается в каждую сторону, чтобы
+	// пережить расхождение часов телефона и время на ввод кода.
+	skew = 1
+)
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret возвращает случайный секрет в base32 — в таком виде он
+// показывается пользователю для ручного ввода.
+func GenerateSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("totp.GenerateSecret: %w", err)
+	}
+
+	return b32.EncodeToString(b), nil
+}
+
+// ProvisioningURI собирает otpauth:// URI для QR-кода.
+func ProvisioningURI(issuer, accountName, secret string) string {
+	label := url.PathEscape(issuer + ":" + accountName)
+
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(digits))
+	q.Set("period", fmt.Sprint(int(period/time.Second)))
+
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// Validate проверяет код на момент now с допуском ±skew шагов. Возвращает
+// номер совпавшего шага — по нему вызывающий отсекает повторное
+// использование кода.
+func Validate(secret, code string, now time.Time) (step int64, ok bool) {
+	key, err := b32.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) != digits {
+		return 0, false
+	}
+
+	current := now.Unix() / int64(period/time.Second)
+
+	for i := -skew; i <= skew; i++ {
+		candidate := current + int64(i)
+		if subtle.ConstantTimeCompare([]byte(generate(key, candidate)), []byte(code)) == 1 {
+			return candidate, true
+		}
+	}
+
+	return 0, false
+}
+
+// generate — HOTP (RFC 4226) для счётчика step.
+func generate(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", digits, value%1_000_000)
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"auth_service/internal/lib/clientinfo"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+)
+
+// GeoGuard проверяет вход на «невозможное перемещение» по GeoIP.
+type GeoGuard interface {
+	Check(ctx context.Context, userID int64, ip string) (*models.TravelAnomaly, error)
+	Record(ctx context.Context, userID int64, ip string)
+	Action() models.GeoAction
+}
+
+// checkTravel применяет к входу настроенную реакцию на невозможное
+// перемещение: block — ErrSuspiciousLogin, challenge — challenge == true
+// (вход подтверждается magic link), notify — только письмо. Сбой GeoIP
+// вход не блокирует: проверка дополнительная к паролю.
+func (a *Auth) checkTravel(ctx context.Context, user *models.User, app *models.App) (challenge bool, err error) {
+	const op = "Auth.checkTravel"
+
+	log := a.Log.With(slog.String("op", op), slog.Int64("user_id", user.ID))
+
+	anomaly, err := a.Geo.Check(ctx, user.ID, clientinfo.FromContext(ctx).IP)
+	if err != nil {
+		log.Error("failed to check login location", sl.Err(err))
+		return false, nil
+	}
+	if anomaly == nil {
+		return false, nil
+	}
+
+	action := a.Geo.Action()
+
+	log.Warn("impossible travel detected",
+		slog.String("from", anomaly.From.IP),
+		slog.String("to", anomaly.To.IP),
+		slog.Float64("distance_km", anomaly.DistanceKm),
+		slog.Float64("speed_kmh", anomaly.SpeedKmh),
+		slog.String("action", string(action)),
+	)
+
+	a.recordUserEvent(ctx, user.ID, models.AuditActionImpossibleTravel, map[string]any{
+		"app_id":       app.ID,
+		"from_country": anomaly.From.Country,
+		"from_city":    anomaly.From.City,
+		"to_country":   anomaly.To.Country,
+		"to_city":      anomaly.To.City,
+		"distance_km":  int64(anomaly.DistanceKm),
+		"speed_kmh":    int64(anomaly.SpeedKmh),
+		"action":       string(action),
+	})
+
+	switch action {
+	case models.GeoActionChallenge:
+		return true, nil
+	case models.GeoActionBlock:
+		a.notifyTravel(ctx, user, app, anomaly, true)
+		return false, ErrSuspiciousLogin
+	default:
+		a.notifyTravel(ctx, user, app, anomaly, false)
+		return false, nil
+	}
+}
+
+func (a *Auth) notifyTravel(ctx context.Context, user *models.User, app *models.App, anomaly *models.TravelAnomaly, blocked bool) {
+	data := map[string]string{
+		"app_name":      app.Name,
+		"from_location": location(anomaly.From),
+		"to_location":   location(anomaly.To),
+		"distance_km":   fmt.Sprintf("%.0f", anomaly.DistanceKm),
+	}
+	if blocked {
+		data["blocked"] = "true"
+	}
+
+	a.notify(ctx, user.ID, user.Email, alertSuspiciousLogin, data)
+}
+
+func location(loc models.GeoLocation) string {
+	switch {
+	case loc.City != "" && loc.Country != "":
+		return loc.City + ", " + loc.Country
+	case loc.Country != "":
+		return loc.Country
+	default:
+		return loc.IP
+	}
+}
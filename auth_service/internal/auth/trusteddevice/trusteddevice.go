@@ -0,0 +1,117 @@
+package trusteddevice
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"auth_service/internal/lib/clientinfo"
+	"auth_service/internal/lib/tokens"
+	"auth_service/internal/models"
+)
+
+type Store interface {
+	SaveTrustedDevice(ctx context.Context, d *models.TrustedDevice) error
+	TouchTrustedDevice(ctx context.Context, userID int64, tokenHash []byte) (bool, error)
+	TrustedDevices(ctx context.Context, userID int64) ([]models.TrustedDevice, error)
+	DeleteTrustedDevice(ctx context.Context, userID, id int64) error
+	DeleteTrustedDevices(ctx context.Context, userID int64) (int64, error)
+}
+
+// Service — «запомнить устройство» после 2FA: пока токен устройства
+// действует, логин с него не требует второго фактора. ttl == 0 выключает
+// функцию: токены не выдаются, выданные ранее не принимаются.
+type Service struct {
+	store Store
+	ttl   time.Duration
+}
+
+func New(store Store, ttl time.Duration) *Service {
+	return &Service{store: store, ttl: ttl}
+}
+
+func (s *Service) Enabled() bool {
+	return s.ttl > 0
+}
+
+// Trust выдаёт токен доверенного устройства. device может быть nil — тогда
+// в списке устройство узнаётся только по User-Agent.
+func (s *Service) Trust(ctx context.Context, userID int64, device *models.Device) (string, time.Time, error) {
+	const op = "trusteddevice.Trust"
+
+	token, hash, err := tokens.NewDeviceTrustToken()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	info := clientinfo.FromContext(ctx)
+
+	d := &models.TrustedDevice{
+		UserID:    userID,
+		TokenHash: hash,
+		IP:        info.IP,
+		UserAgent: info.UserAgent,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+	if device != nil {
+		d.DeviceID = device.ID
+		d.DeviceName = device.Name
+	}
+
+	if err := s.store.SaveTrustedDevice(ctx, d); err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return token, d.ExpiresAt, nil
+}
+
+// Trusted сообщает, что token — действующий токен устройства этого
+// пользователя. Токен чужого пользователя не подходит.
+func (s *Service) Trusted(ctx context.Context, userID int64, token string) (bool, error) {
+	const op = "trusteddevice.Trusted"
+
+	if !s.Enabled() || token == "" {
+		return false, nil
+	}
+
+	ok, err := s.store.TouchTrustedDevice(ctx, userID, tokens.HashDeviceTrustToken(token))
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ok, nil
+}
+
+func (s *Service) List(ctx context.Context, userID int64) ([]models.TrustedDevice, error) {
+	const op = "trusteddevice.List"
+
+	devices, err := s.store.TrustedDevices(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return devices, nil
+}
+
+// Revoke отзывает доверие к устройству: следующий вход с него снова
+// потребует второй фактор.
+func (s *Service) Revoke(ctx context.Context, userID, id int64) error {
+	const op = "trusteddevice.Revoke"
+
+	if err := s.store.DeleteTrustedDevice(ctx, userID, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) RevokeAll(ctx context.Context, userID int64) (int64, error) {
+	const op = "trusteddevice.RevokeAll"
+
+	n, err := s.store.DeleteTrustedDevices(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
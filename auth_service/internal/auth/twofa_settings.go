@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"auth_service/internal/models"
+
+	sl "auth_service/internal/lib/logger"
+)
+
+// TwoFASettingsUpdate — изменение настроек 2FA через /me/2fa. nil-поля не
+// меняются. Password или SessionID+Token нужны только для выключения 2FA —
+// так же, как в Disable2FA.
+type TwoFASettingsUpdate struct {
+	Enabled  *bool
+	Delivery *models.TwoFADelivery
+
+	Password  string
+	SessionID string
+	Token     string
+}
+
+// * TwoFASettings возвращает состояние 2FA, канал доставки magic link и
+// активные ссылки пользователя.
+func (a *Auth) TwoFASettings(ctx context.Context, userID int64) (*models.TwoFASettings, error) {
+	const op = "Auth.TwoFASettings"
+
+	status, err := a.UsrProvider.TwoFAStatus(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	delivery, err := a.TwoFA.Delivery(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	links, err := a.TwoFA.ActiveMagicLinks(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	settings := &models.TwoFASettings{
+		Enabled:    status.IsEnabled,
+		Delivery:   *delivery,
+		MagicLinks: links,
+	}
+	if status.IsEnabled && status.Method != nil {
+		settings.Method = *status.Method
+	}
+
+	return settings, nil
+}
+
+// * UpdateTwoFASettings применяет изменение настроек 2FA. Порядок важен:
+// канал меняется только при выключенной 2FA, поэтому выключение идёт до
+// смены канала, а включение — после. Состояние, которое уже совпадает с
+// запрошенным, не трогается — повторный PUT ничего не ломает.
+func (a *Auth) UpdateTwoFASettings(ctx context.Context, userID int64, upd TwoFASettingsUpdate) (*models.TwoFASettings, error) {
+	const op = "Auth.UpdateTwoFASettings"
+
+	status, err := a.UsrProvider.TwoFAStatus(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if upd.Enabled != nil && !*upd.Enabled && status.IsEnabled {
+		if err := a.Disable2FA(ctx, userID, upd.Password, upd.SessionID, upd.Token); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if upd.Delivery != nil {
+		if err := a.setTwoFAChannel(ctx, userID, *upd.Delivery); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if upd.Enabled != nil && *upd.Enabled && !status.IsEnabled {
+		if err := a.Enable2FA(ctx, userID); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return a.TwoFASettings(ctx, userID)
+}
+
+// * setTwoFAChannel выбирает, куда доставлять magic link: email, SMS или
+// Telegram. Пока 2FA включена, канал не меняется — как и способ 2FA, иначе
+// access-токена хватило бы, чтобы увести второй фактор на свой номер.
+func (a *Auth) setTwoFAChannel(ctx context.Context, userID int64, delivery models.TwoFADelivery) error {
+	const op = "Auth.setTwoFAChannel"
+
+	log := a.Log.With(slog.String("op", op))
+
+	status, err := a.UsrProvider.TwoFAStatus(ctx, userID)
+	if err != nil {
+		log.Error("failed to get 2fa status", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if status.IsEnabled {
+		return ErrTwoFAAlreadyEnabled
+	}
+
+	if err := a.TwoFA.SetChannel(ctx, userID, delivery); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("2fa channel changed", slog.Int64("user_id", userID), slog.String("channel", string(delivery.Channel)))
+
+	a.recordUserEvent(ctx, userID, models.AuditActionTwoFAChannel, map[string]any{"channel": delivery.Channel})
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package verifycode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth_service/internal/lib/tokens"
+	"auth_service/internal/storage"
+)
+
+var ErrInvalidCode = errors.New("invalid or expired verification code")
+
+// Store хранит хеши кодов с TTL и счётчиком неверных вводов.
+type Store interface {
+	SaveVerificationCode(ctx context.Context, userID int64, codeHash []byte, ttl time.Duration) error
+	ConsumeVerificationCode(ctx context.Context, userID int64, codeHash []byte, maxAttempts int) error
+}
+
+type UserProvider interface {
+	UserIDByEmail(ctx context.Context, email string) (int64, error)
+	SetEmailVerified(ctx context.Context, userID int64) error
+}
+
+// Service — подтверждение email 6-значным кодом из того же письма, что и
+// ссылка: пользователь выбирает удобный способ, любой из них подтверждает
+// адрес.
+type Service struct {
+	store       Store
+	users       UserProvider
+	secret      string
+	ttl         time.Duration
+	maxAttempts int
+}
+
+func New(
+	store Store,
+	users UserProvider,
+	secret string,
+	ttl time.Duration,
+	maxAttempts int,
+) *Service {
+	return &Service{
+		store:       store,
+		users:       users,
+		secret:      secret,
+		ttl:         ttl,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// * Issue выпускает новый код для пользователя; прежний код перестаёт
+// действовать.
+func (s *Service) Issue(ctx context.Context, userID int64) (string, error) {
+	const op = "verifycode.Issue"
+
+	code, hash, err := tokens.NewVerificationCode(s.secret)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.store.SaveVerificationCode(ctx, userID, hash, s.ttl); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return code, nil
+}
+
+// * Verify проверяет код и подтверждает email. Неизвестный адрес и неверный
+// код неразличимы снаружи — ErrInvalidCode.
+func (s *Service) Verify(ctx context.Context, email, code string) (int64, error) {
+	const op = "verifycode.Verify"
+
+	userID, err := s.users.UserIDByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return 0, ErrInvalidCode
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.store.ConsumeVerificationCode(ctx, userID, tokens.HashVerificationCode(code, s.secret), s.maxAttempts)
+	if err != nil {
+		if errors.Is(err, storage.ErrVerificationCodeNotFound) {
+			return 0, ErrInvalidCode
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.users.SetEmailVerified(ctx, userID); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return 0, ErrInvalidCode
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
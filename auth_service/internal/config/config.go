@@ -1,41 +1,365 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/XdMishaXd/auth_service/secrets"
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	Env           string `yaml:"env" env-default:"local"`
-	Tokens        `yaml:"tokens"`
-	RabbitMQ      `yaml:"rabbitmq"`
-	Postgres      `yaml:"postgres"`
-	Redis         `yaml:"redis"`
-	HTTPServer    `yaml:"http_server"`
-	TwoFactorAuth `yaml:"two_factor_auth"`
-	Swagger       `yaml:"swagger"`
-	OAuth         `yaml:"oauth"`
+	Env             string `yaml:"env" env:"APP_ENV" env-default:"local"`
+	LogLevel        string `yaml:"log_level" env:"LOG_LEVEL"` // debug | info | warn | error; пусто — по env
+	Tokens          `yaml:"tokens"`
+	RabbitMQ        `yaml:"rabbitmq"`
+	Storage         `yaml:"storage"`
+	Postgres        `yaml:"postgres"`
+	MySQL           `yaml:"mysql"`
+	SQLite          `yaml:"sqlite"`
+	Redis           `yaml:"redis"`
+	HTTPServer      `yaml:"http_server"`
+	RefreshCookie   `yaml:"refresh_cookie"`
+	TwoFactorAuth   `yaml:"two_factor_auth"`
+	Swagger         `yaml:"swagger"`
+	OAuth           `yaml:"oauth"`
+	Mail            `yaml:"mail"`
+	Scheduler       `yaml:"scheduler"`
+	Admin           `yaml:"admin"`
+	Retention       `yaml:"retention"`
+	GraphQL         `yaml:"graphql"`
+	Apps            `yaml:"apps"`
+	Maintenance     `yaml:"maintenance"`
+	Lockout         `yaml:"lockout"`
+	SigningKeys     `yaml:"signing_keys"`
+	OIDC            `yaml:"oidc"`
+	Geo             `yaml:"geo"`
+	PasswordPolicy  `yaml:"password_policy"`
+	PasswordHashing `yaml:"password_hashing"`
+	RateLimits      `yaml:"rate_limits"`
+	Sessions        `yaml:"sessions"`
+	Challenge       `yaml:"challenge"`
+	IPFilter        `yaml:"ip_filter"`
+
+	// Secrets — внешнее хранилище секретов. Секрет — набор пар «имя
+	// env-переменной конфига → значение» (POSTGRES_PASSWORD,
+	// VERIFICATION_TOKEN_SECRET, ...): при старте они подставляются вместо
+	// незаданных переменных окружения, затем каждые RefreshInterval
+	// перечитываются, и ротация применяется без рестарта там, где это
+	// возможно (пароль Postgres или MySQL).
+	Secrets secrets.Config `yaml:"secrets"`
+}
+
+const (
+	StorageDriverPostgres = "postgres"
+	StorageDriverMySQL    = "mysql"
+	StorageDriverSQLite   = "sqlite"
+)
+
+const (
+	SessionLimitEvictOldest = "evict_oldest"
+	SessionLimitReject      = "reject"
+)
+
+// Sessions ограничивает число активных refresh-токенов пользователя в одном
+// приложении. MaxPerApp == 0 — без ограничения. OnLimit — что делать при
+// логине сверх лимита: evict_oldest завершает самые старые сессии, reject
+// отклоняет вход.
+type Sessions struct {
+	MaxPerApp int    `yaml:"max_per_app" env:"SESSIONS_MAX_PER_APP" env-default:"0"`
+	OnLimit   string `yaml:"on_limit" env:"SESSIONS_ON_LIMIT" env-default:"evict_oldest"`
+}
+
+// RateLimits переопределяет встроенные лимиты эндпоинтов. Ключ —
+// "<эндпоинт>:<ключ лимита>", например "login:ip" или "login:email".
+// Меняется без рестарта по SIGHUP.
+type RateLimits struct {
+	Overrides map[string]RateLimitPolicy `yaml:"overrides"`
+}
+
+type RateLimitPolicy struct {
+	Burst  int           `yaml:"burst"`
+	Rate   int           `yaml:"rate"`
+	Period time.Duration `yaml:"period"`
+}
+
+// PasswordHashing — алгоритм хеширования новых паролей: argon2id или
+// bcrypt. Хеши другого алгоритма или с другими параметрами по-прежнему
+// проверяются и перехешируются текущим при успешном входе.
+type PasswordHashing struct {
+	Algorithm string         `yaml:"algorithm" env:"PASSWORD_HASH_ALGORITHM" env-default:"argon2id"`
+	Argon2id  Argon2id       `yaml:"argon2id"`
+	Pepper    PasswordPepper `yaml:"pepper"`
+	// BcryptCost — cost новых bcrypt-хешей; хеши с другим cost
+	// перехешируются при входе.
+	BcryptCost int `yaml:"bcrypt_cost" env:"PASSWORD_BCRYPT_COST" env-default:"10"`
+	// Workers — сколько паролей хешируется одновременно. Остальные запросы
+	// ждут свободного воркера не дольше своего таймаута: всплеск регистраций
+	// не займёт все ядра и память (Argon2id берёт memory_kib на хеш) и не
+	// задушит HTTP-сервер. 0 — половина GOMAXPROCS, но не меньше одного.
+	Workers int `yaml:"workers" env:"PASSWORD_HASH_WORKERS" env-default:"0"`
+}
+
+// PasswordPepper — HMAC-перец: секрет вне БД, которым пароль обрабатывается
+// перед хешированием, — по одной утёкшей базе хеши не перебрать. Keys — все
+// действующие перцы по ID (PASSWORD_PEPPERS="v1:secret,v2:secret"),
+// CurrentID — которым перчить новые хеши; пустой — без перца. ID хранится
+// вместе с хешем, поэтому при ротации старые хеши проверяются своим перцем
+// и перехешируются текущим при входе. Старый перец можно убрать, только
+// когда хешей с ним не осталось.
+type PasswordPepper struct {
+	CurrentID string            `yaml:"current_id" env:"PASSWORD_PEPPER_ID"`
+	Keys      map[string]string `yaml:"-" env:"PASSWORD_PEPPERS"`
+}
+
+// Argon2id — параметры Argon2id (RFC 9106). Значения по умолчанию — второй
+// рекомендуемый профиль: 64 MiB памяти, 3 прохода.
+type Argon2id struct {
+	MemoryKiB   uint32 `yaml:"memory_kib" env:"PASSWORD_ARGON2ID_MEMORY_KIB" env-default:"65536"`
+	Iterations  uint32 `yaml:"iterations" env:"PASSWORD_ARGON2ID_ITERATIONS" env-default:"3"`
+	Parallelism uint32 `yaml:"parallelism" env:"PASSWORD_ARGON2ID_PARALLELISM" env-default:"2"`
+	SaltLength  uint32 `yaml:"salt_length" env:"PASSWORD_ARGON2ID_SALT_LENGTH" env-default:"16"`
+	KeyLength   uint32 `yaml:"key_length" env:"PASSWORD_ARGON2ID_KEY_LENGTH" env-default:"32"`
+}
+
+// PasswordPolicy — требования к новому паролю: при регистрации, сбросе и
+// регистрации по приглашению в организацию. Длина считается в символах,
+// MaxLength — в байтах: bcrypt учитывает только первые 72 байта.
+// MinScore — минимальная оценка стойкости по шкале zxcvbn (0-4), 0 —
+// оценка не проверяется.
+type PasswordPolicy struct {
+	MinLength     int  `yaml:"min_length" env:"PASSWORD_MIN_LENGTH" env-default:"8"`
+	MaxLength     int  `yaml:"max_length" env:"PASSWORD_MAX_LENGTH" env-default:"72"`
+	RequireUpper  bool `yaml:"require_upper" env:"PASSWORD_REQUIRE_UPPER" env-default:"false"`
+	RequireLower  bool `yaml:"require_lower" env:"PASSWORD_REQUIRE_LOWER" env-default:"false"`
+	RequireDigit  bool `yaml:"require_digit" env:"PASSWORD_REQUIRE_DIGIT" env-default:"false"`
+	RequireSymbol bool `yaml:"require_symbol" env:"PASSWORD_REQUIRE_SYMBOL" env-default:"false"`
+	MinScore      int  `yaml:"min_score" env:"PASSWORD_MIN_SCORE" env-default:"2"`
+
+	BreachCheck PasswordBreachCheck `yaml:"breach_check"`
+}
+
+// PasswordBreachCheck — проверка пароля по базе утечек HaveIBeenPwned через
+// k-anonymity API: наружу уходят только первые 5 символов SHA-1 пароля.
+// FailOpen — при недоступности API пропустить проверку, а не отклонять пароль.
+type PasswordBreachCheck struct {
+	Enabled  bool          `yaml:"enabled" env:"PASSWORD_BREACH_CHECK_ENABLED" env-default:"false"`
+	Endpoint string        `yaml:"endpoint" env:"PASSWORD_BREACH_CHECK_ENDPOINT" env-default:"https://api.pwnedpasswords.com"`
+	Timeout  time.Duration `yaml:"timeout" env:"PASSWORD_BREACH_CHECK_TIMEOUT" env-default:"3s"`
+	FailOpen bool          `yaml:"fail_open" env:"PASSWORD_BREACH_CHECK_FAIL_OPEN" env-default:"true"`
+}
+
+// Geo — проверка входов на «невозможное перемещение» по базе GeoIP
+// (MaxMind GeoLite2/GeoIP2 City). Пустой DatabasePath выключает проверку.
+// Вход подозрителен, если от места прошлого входа больше MinDistanceKm и
+// добраться оттуда можно только быстрее MaxSpeedKmh. Action — что делать с
+// подозрительным входом: notify (письмо), challenge (magic link) или block.
+type Geo struct {
+	DatabasePath  string  `yaml:"database_path" env:"GEOIP_DATABASE_PATH"`
+	MaxSpeedKmh   float64 `yaml:"max_speed_kmh" env:"GEO_MAX_SPEED_KMH" env-default:"900"`
+	MinDistanceKm float64 `yaml:"min_distance_km" env:"GEO_MIN_DISTANCE_KM" env-default:"300"`
+	Action        string  `yaml:"action" env:"GEO_ACTION" env-default:"notify"`
+}
+
+// OIDC — режим OpenID Connect провайдера (authorization code + PKCE).
+// Issuer — публичный URL сервиса, попадает в iss ID-токенов и discovery.
+// LoginURL — страница фронтенда, куда /oauth2/authorize отправляет
+// пользователя логиниться.
+type OIDC struct {
+	Enabled    bool          `yaml:"enabled" env:"OIDC_ENABLED" env-default:"false"`
+	Issuer     string        `yaml:"issuer" env:"OIDC_ISSUER"`
+	LoginURL   string        `yaml:"login_url" env:"OIDC_LOGIN_URL"`
+	CodeTTL    time.Duration `yaml:"code_ttl" env:"OIDC_CODE_TTL" env-default:"1m"`
+	IDTokenTTL time.Duration `yaml:"id_token_ttl" env:"OIDC_ID_TOKEN_TTL" env-default:"15m"`
+}
+
+// SigningKeys — ротация ключей подписи access-токенов. Выведенный ключ
+// принимается ещё GracePeriod, но не меньше AccessTokenTTL + Leeway, иначе
+// ротация оборвала бы выданные им токены. RotationInterval == 0 выключает
+// плановую ротацию, ключи ротируются только через админку.
+type SigningKeys struct {
+	RotationInterval time.Duration `yaml:"rotation_interval" env:"SIGNING_KEYS_ROTATION_INTERVAL" env-default:"720h"`
+	GracePeriod      time.Duration `yaml:"grace_period" env:"SIGNING_KEYS_GRACE_PERIOD" env-default:"1h"`
+	CheckInterval    time.Duration `yaml:"check_interval" env:"SIGNING_KEYS_CHECK_INTERVAL" env-default:"1h"`
+}
+
+// Lockout — блокировка входа после серии неверных паролей. MaxAttempts
+// неудач за Window с одного IP блокируют вход на этот email только с этого
+// IP; блокировки с AccountLockIPs разных IP за Window — вход на email с
+// любого IP. Длительность растёт вдвое с каждой блокировкой подряд: base,
+// 2*base, ... до max.
+type Lockout struct {
+	Enabled     bool          `yaml:"enabled" env:"LOCKOUT_ENABLED" env-default:"true"`
+	MaxAttempts int64         `yaml:"max_attempts" env:"LOCKOUT_MAX_ATTEMPTS" env-default:"5"`
+	Window      time.Duration `yaml:"window" env:"LOCKOUT_WINDOW" env-default:"15m"`
+	BaseDelay   time.Duration `yaml:"base_delay" env:"LOCKOUT_BASE_DELAY" env-default:"1m"`
+	MaxDelay    time.Duration `yaml:"max_delay" env:"LOCKOUT_MAX_DELAY" env-default:"24h"`
+	// AccountLockIPs — со скольких IP нужны блокировки, чтобы закрыть вход
+	// на email целиком.
+	AccountLockIPs int64 `yaml:"account_lock_ips" env:"LOCKOUT_ACCOUNT_LOCK_IPS" env-default:"3"`
+	// UnlockTokenTTL — срок жизни ссылки разблокировки из письма.
+	UnlockTokenTTL time.Duration `yaml:"unlock_token_ttl" env:"LOCKOUT_UNLOCK_TOKEN_TTL" env-default:"1h"`
+	// UnlockEmailInterval — не чаще одного письма разблокировки на email за
+	// интервал, сколько бы блокировок ни было.
+	UnlockEmailInterval time.Duration `yaml:"unlock_email_interval" env:"LOCKOUT_UNLOCK_EMAIL_INTERVAL" env-default:"1h"`
+}
+
+// Challenge — proof-of-work перед входом, когда с IP или на email за Window
+// пришлось Threshold и больше неудачных попыток. Difficulty — число нулевых
+// бит в начале хеша на пороге; с каждым удвоением неудач сложность растёт
+// на бит, но не выше MaxDifficulty. TTL — срок жизни выданной задачи.
+type Challenge struct {
+	Enabled       bool          `yaml:"enabled" env:"CHALLENGE_ENABLED" env-default:"false"`
+	Threshold     int64         `yaml:"threshold" env:"CHALLENGE_THRESHOLD" env-default:"10"`
+	Window        time.Duration `yaml:"window" env:"CHALLENGE_WINDOW" env-default:"15m"`
+	Difficulty    int           `yaml:"difficulty" env:"CHALLENGE_DIFFICULTY" env-default:"18"`
+	MaxDifficulty int           `yaml:"max_difficulty" env:"CHALLENGE_MAX_DIFFICULTY" env-default:"24"`
+	TTL           time.Duration `yaml:"ttl" env:"CHALLENGE_TTL" env-default:"2m"`
+}
+
+// IPFilter — правила доступа по IP из /admin/ip-rules. Правила хранятся в
+// Postgres, кешируются в Redis на CacheTTL (кеш сбрасывается при изменении)
+// и в памяти реплики на LocalCacheTTL.
+type IPFilter struct {
+	CacheTTL      time.Duration `yaml:"cache_ttl" env:"IP_FILTER_CACHE_TTL" env-default:"5m"`
+	LocalCacheTTL time.Duration `yaml:"local_cache_ttl" env:"IP_FILTER_LOCAL_CACHE_TTL" env-default:"5s"`
+	AutoBlock     IPAutoBlock   `yaml:"auto_block"`
+}
+
+// IPAutoBlock — временный deny на адрес, получивший Threshold ответов
+// 401 и 429 за Window. Адреса из allow-правил не блокируются.
+type IPAutoBlock struct {
+	Enabled   bool          `yaml:"enabled" env:"IP_FILTER_AUTO_BLOCK_ENABLED" env-default:"false"`
+	Threshold int64         `yaml:"threshold" env:"IP_FILTER_AUTO_BLOCK_THRESHOLD" env-default:"100"`
+	Window    time.Duration `yaml:"window" env:"IP_FILTER_AUTO_BLOCK_WINDOW" env-default:"10m"`
+	Duration  time.Duration `yaml:"duration" env:"IP_FILTER_AUTO_BLOCK_DURATION" env-default:"1h"`
+}
+
+// Maintenance — режим обслуживания. Enabled — аварийный рубильник без
+// Redis, снимается рестартом; окна в рантайме задаются через /admin/maintenance.
+type Maintenance struct {
+	Enabled bool   `yaml:"enabled" env:"MAINTENANCE_ENABLED" env-default:"false"`
+	Reason  string `yaml:"reason" env:"MAINTENANCE_REASON"`
+	// RetryAfter — значение Retry-After для окон без известного конца.
+	RetryAfter time.Duration `yaml:"retry_after" env:"MAINTENANCE_RETRY_AFTER" env-default:"5m"`
+	// CacheTTL — как долго реплика не перечитывает окно из Redis.
+	CacheTTL time.Duration `yaml:"cache_ttl" env:"MAINTENANCE_CACHE_TTL" env-default:"2s"`
+}
+
+// Apps — разделение пользователей между продуктами, использующими сервис.
+type Apps struct {
+	// EnforceMembership — Login пускает только в приложения, в которых
+	// пользователь зарегистрирован. Выключено — пул пользователей общий.
+	EnforceMembership bool `yaml:"enforce_membership" env:"APPS_ENFORCE_MEMBERSHIP" env-default:"false"`
+	// CacheTTL — сколько строка apps живёт в памяти реплики: новый секрет
+	// после ротации доходит до сервиса не позже, чем через это время.
+	// 0 — без кеша, каждое чтение идёт в Postgres.
+	CacheTTL time.Duration `yaml:"cache_ttl" env:"APPS_CACHE_TTL" env-default:"10s"`
+}
+
+// GraphQL — опциональный фасад /graphql над тем же сервисным слоем.
+type GraphQL struct {
+	Enabled bool `yaml:"enabled" env:"GRAPHQL_ENABLED" env-default:"false"`
+}
+
+// Retention — сроки хранения данных. Очистку выполняет планировщик на
+// реплике-лидере; нулевой срок отключает очистку таблицы.
+type Retention struct {
+	Interval   time.Duration `yaml:"interval" env:"RETENTION_INTERVAL" env-default:"1h"`
+	JobTimeout time.Duration `yaml:"job_timeout" env:"RETENTION_JOB_TIMEOUT" env-default:"5m"`
+	BatchSize  int           `yaml:"batch_size" env:"RETENTION_BATCH_SIZE" env-default:"1000"`
+
+	AuditEvents    time.Duration `yaml:"audit_events" env:"RETENTION_AUDIT_EVENTS" env-default:"2160h"`
+	UsedMagicLinks time.Duration `yaml:"used_magic_links" env:"RETENTION_USED_MAGIC_LINKS" env-default:"168h"`
+	// ExpiredTrustedDevices — сколько хранить истёкшие доверенные устройства.
+	ExpiredTrustedDevices time.Duration `yaml:"expired_trusted_devices" env:"RETENTION_EXPIRED_TRUSTED_DEVICES" env-default:"24h"`
+	// ExpiredRefreshTokens — сколько хранить истёкшие refresh-токены.
+	ExpiredRefreshTokens time.Duration `yaml:"expired_refresh_tokens" env:"RETENTION_EXPIRED_REFRESH_TOKENS" env-default:"24h"`
+	// ExpiredIPRules — сколько хранить истёкшие временные правила по IP.
+	ExpiredIPRules time.Duration `yaml:"expired_ip_rules" env:"RETENTION_EXPIRED_IP_RULES" env-default:"24h"`
+	// DeletedAccounts — grace period удалённого аккаунта: всё это время его
+	// можно восстановить, потом он удаляется безвозвратно. Отключить нельзя.
+	DeletedAccounts time.Duration `yaml:"deleted_accounts" env:"RETENTION_DELETED_ACCOUNTS" env-default:"168h"`
+}
+
+// Admin — basic auth для /admin/*. Пока credentials не заданы,
+// админские эндпоинты отвечают 404.
+type Admin struct {
+	Username        string        `yaml:"-" env:"ADMIN_USERNAME"`
+	Password        string        `yaml:"-" env:"ADMIN_PASSWORD"`
+	HandlersTimeout time.Duration `yaml:"handlers_timeout" env:"ADMIN_HANDLERS_TIMEOUT" env-default:"10s"`
+}
+
+// Scheduler — фоновые задачи. Выполняются только на реплике, которая
+// держит блокировку LeaderLockKey в основном хранилище.
+type Scheduler struct {
+	LeaderLockKey    int64         `yaml:"leader_lock_key" env:"SCHEDULER_LEADER_LOCK_KEY" env-default:"727100001"`
+	ElectionInterval time.Duration `yaml:"election_interval" env:"SCHEDULER_ELECTION_INTERVAL" env-default:"10s"`
+
+	MagicLinkCleanupInterval time.Duration `yaml:"magic_link_cleanup_interval" env:"SCHEDULER_MAGIC_LINK_CLEANUP_INTERVAL" env-default:"10m"`
+}
+
+type Mail struct {
+	// Sandbox — письма не уходят в RabbitMQ, а пишутся в лог со ссылкой.
+	// Для локального стенда без брокера и email_sender; в prod запрещено.
+	Sandbox bool `yaml:"sandbox" env:"MAIL_SANDBOX" env-default:"false"`
 }
 
 type Swagger struct {
 	Username string `yaml:"username" env:"SWAGGER_USERNAME" env-default:"admin"`
 	Password string `yaml:"password" env:"SWAGGER_PASSWORD" env-default:"admin"`
-	Enabled  bool   `yaml:"enabled" env-default:"false"`
+	Enabled  bool   `yaml:"enabled" env:"SWAGGER_ENABLED" env-default:"false"`
 }
 
 type HTTPServer struct {
-	Address         string        `yaml:"address" env-default:"localhost:8080"`
-	Timeout         time.Duration `yaml:"timeout" env-default:"4s"`
-	IdleTimeout     time.Duration `yaml:"idle_timeout" env-default:"60s"`
-	HandlersTimeout time.Duration `yaml:"handlers_timeout" env-default:"5s"`
+	Address string `yaml:"address" env:"HTTP_SERVER_ADDRESS" env-default:"localhost:8080"`
+	// PublicBaseURL — адрес сервиса снаружи (схема, хост, префикс пути за
+	// reverse proxy). От него строятся все ссылки в письмах.
+	PublicBaseURL   string        `yaml:"public_base_url" env:"PUBLIC_BASE_URL" env-default:"http://localhost:8082"`
+	Timeout         time.Duration `yaml:"timeout" env:"HTTP_SERVER_TIMEOUT" env-default:"4s"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" env:"HTTP_SERVER_IDLE_TIMEOUT" env-default:"60s"`
+	HandlersTimeout time.Duration `yaml:"handlers_timeout" env:"HTTP_SERVER_HANDLERS_TIMEOUT" env-default:"5s"`
+	// ShutdownTimeout — сколько при остановке ждать завершения запросов в
+	// работе и фоновых задач обработчиков, прежде чем закрыть соединения
+	// принудительно.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"HTTP_SERVER_SHUTDOWN_TIMEOUT" env-default:"30s"`
+	// CompressionLevel — уровень gzip/deflate (1-9), 0 отключает сжатие.
+	CompressionLevel int `yaml:"compression_level" env:"HTTP_SERVER_COMPRESSION_LEVEL" env-default:"5"`
+	// CacheMaxAge — max-age для кешируемых публичных документов (спека, JWKS, discovery).
+	CacheMaxAge time.Duration `yaml:"cache_max_age" env:"HTTP_SERVER_CACHE_MAX_AGE" env-default:"5m"`
+	// TrustedProxies — сети балансировщиков и ingress, от которых
+	// принимаются X-Forwarded-For и X-Real-IP. Пусто — заголовки
+	// игнорируются, клиент — адрес соединения.
+	TrustedProxies []string `yaml:"trusted_proxies" env:"HTTP_SERVER_TRUSTED_PROXIES"`
+}
+
+// RefreshCookie — refresh-токен в cookie для приложений с
+// refresh_token_delivery = cookie. Рядом ставится CSRF-cookie CSRFName,
+// доступная JavaScript: фронтенд повторяет её значение в заголовке
+// X-CSRF-Token на /auth/refresh и /auth/logout (double-submit).
+type RefreshCookie struct {
+	Name     string `yaml:"name" env:"REFRESH_COOKIE_NAME" env-default:"refresh_token"`
+	CSRFName string `yaml:"csrf_name" env:"REFRESH_COOKIE_CSRF_NAME" env-default:"csrf_token"`
+	Domain   string `yaml:"domain" env:"REFRESH_COOKIE_DOMAIN"`
+	// Path — refresh-cookie уходит только на эндпоинты под этим путём.
+	Path   string `yaml:"path" env:"REFRESH_COOKIE_PATH" env-default:"/auth"`
+	Secure bool   `yaml:"secure" env:"REFRESH_COOKIE_SECURE" env-default:"true"`
+	// SameSite — strict, lax или none (none требует secure).
+	SameSite string `yaml:"same_site" env:"REFRESH_COOKIE_SAME_SITE" env-default:"strict"`
+	// DeviceTrustName — cookie с токеном доверенного устройства, живёт
+	// под тем же Path, что и refresh-cookie.
+	DeviceTrustName string `yaml:"device_trust_name" env:"REFRESH_COOKIE_DEVICE_TRUST_NAME" env-default:"device_trust"`
 }
 
 type OAuth struct {
-	StateTTL             time.Duration `yaml:"state_ttl" env-default:"5m"`
-	HandlersTimeout      time.Duration `yaml:"handlers_timeout" env-default:"10s"`
-	AllowedRedirectHosts []string      `yaml:"allowed_redirect_hosts" env-default:"localhost"`
+	StateTTL             time.Duration `yaml:"state_ttl" env:"OAUTH_STATE_TTL" env-default:"5m"`
+	HandlersTimeout      time.Duration `yaml:"handlers_timeout" env:"OAUTH_HANDLERS_TIMEOUT" env-default:"10s"`
+	AllowedRedirectHosts []string      `yaml:"allowed_redirect_hosts" env:"OAUTH_ALLOWED_REDIRECT_HOSTS" env-default:"localhost"`
 
 	GoogleClientID     string `yaml:"-" env:"GOOGLE_CLIENT_ID" env-required:"true"`
 	GoogleClientSecret string `yaml:"-" env:"GOOGLE_CLIENT_SECRET" env-required:"true"`
@@ -47,50 +371,511 @@ type OAuth struct {
 }
 
 type TwoFactorAuth struct {
-	TokenTTL          time.Duration `yaml:"token_ttl" env-default:"10m"`
+	TokenTTL          time.Duration `yaml:"token_ttl" env:"TWO_FACTOR_TOKEN_TTL" env-default:"10m"`
 	TokenSecret       string        `yaml:"-" env:"TWO_FACTOR_TOKEN_SECRET" env-required:"true"`
-	RedirectURL       string        `yaml:"redirect_url" env-default:"http://localhost:8082"`
-	PendingSessionTTL time.Duration `yaml:"pending_session_ttl" env-default:"10m"`
+	RedirectURL       string        `yaml:"redirect_url" env:"TWO_FACTOR_REDIRECT_URL" env-default:"http://localhost:8082"`
+	PendingSessionTTL time.Duration `yaml:"pending_session_ttl" env:"TWO_FACTOR_PENDING_SESSION_TTL" env-default:"10m"`
+
+	// TOTPEncryptionKey — base64 от 32 байт, ключ AES-GCM для TOTP-секретов
+	// в БД. Пустой — TOTP выключен, эндпоинты отвечают 501.
+	TOTPEncryptionKey string `yaml:"-" env:"TOTP_ENCRYPTION_KEY"`
+	TOTPIssuer        string `yaml:"totp_issuer" env:"TWO_FACTOR_TOTP_ISSUER" env-default:"auth_service"`
+
+	// NewDeviceChallenge — вход с устройства, с которого пользователь ещё не
+	// входил, подтверждается magic link даже без включённой 2FA.
+	NewDeviceChallenge bool `yaml:"new_device_challenge" env:"TWO_FACTOR_NEW_DEVICE_CHALLENGE" env-default:"false"`
+
+	// SMS и Telegram — каналы доставки magic link помимо email, пользователь
+	// выбирает канал сам. Канал без учётных данных выключен.
+	SMS      TwoFASMS      `yaml:"sms"`
+	Telegram TwoFATelegram `yaml:"telegram"`
+
+	// TrustedDeviceTTL — сколько после 2FA не спрашивать второй фактор на
+	// устройстве, которое пользователь попросил запомнить. 0 — выключено.
+	TrustedDeviceTTL time.Duration `yaml:"trusted_device_ttl" env:"TWO_FACTOR_TRUSTED_DEVICE_TTL" env-default:"720h"`
 }
 
+// TwoFASMS — отправка magic link через Twilio. From — номер или
+// Messaging Service SID отправителя.
+type TwoFASMS struct {
+	AccountSID string        `yaml:"-" env:"TWILIO_ACCOUNT_SID"`
+	AuthToken  string        `yaml:"-" env:"TWILIO_AUTH_TOKEN"`
+	From       string        `yaml:"from" env:"TWILIO_FROM"`
+	Timeout    time.Duration `yaml:"timeout" env:"TWILIO_TIMEOUT" env-default:"10s"`
+}
+
+// TwoFATelegram — отправка magic link через Telegram-бота. Пользователь
+// указывает chat_id, предварительно написав боту.
+type TwoFATelegram struct {
+	BotToken string        `yaml:"-" env:"TELEGRAM_BOT_TOKEN"`
+	Timeout  time.Duration `yaml:"timeout" env:"TELEGRAM_TIMEOUT" env-default:"10s"`
+}
+
+// Storage выбирает СУБД основного хранилища. Настройки подключения — в
+// секции выбранного драйвера; обязательные переменные окружения
+// проверяются только у неё.
+type Storage struct {
+	// Driver — postgres | mysql | sqlite.
+	Driver string `yaml:"driver" env:"STORAGE_DRIVER" env-default:"postgres"`
+}
+
+// Postgres: POSTGRES_USER, POSTGRES_PASSWORD и POSTGRES_DB обязательны при
+// storage.driver=postgres.
 type Postgres struct {
-	Host     string `yaml:"host" env-default:"postgres"`
-	Port     int    `yaml:"port" env-default:"5432"`
-	User     string `yaml:"-" env:"POSTGRES_USER" env-required:"true"`
-	Password string `yaml:"-" env:"POSTGRES_PASSWORD" env-required:"true"`
-	DBName   string `yaml:"-" env:"POSTGRES_DB" env-required:"true"`
-	SSLMode  string `yaml:"sslmode" env-default:"disable"`
+	Host     string `yaml:"host" env:"POSTGRES_HOST" env-default:"postgres"`
+	Port     int    `yaml:"port" env:"POSTGRES_PORT" env-default:"5432"`
+	User     string `yaml:"-" env:"POSTGRES_USER"`
+	Password string `yaml:"-" env:"POSTGRES_PASSWORD"`
+	DBName   string `yaml:"-" env:"POSTGRES_DB"`
+	SSLMode  string `yaml:"sslmode" env:"POSTGRES_SSLMODE" env-default:"disable"`
+
+	// Migrate — применить встроенные миграции при старте. Включается и
+	// флагом --migrate.
+	Migrate bool `yaml:"migrate" env:"POSTGRES_MIGRATE" env-default:"false"`
+
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" env:"POSTGRES_SLOW_QUERY_THRESHOLD" env-default:"200ms"`
+
+	ReadTimeout    time.Duration `yaml:"read_timeout" env:"POSTGRES_READ_TIMEOUT" env-default:"2s"`
+	WriteTimeout   time.Duration `yaml:"write_timeout" env:"POSTGRES_WRITE_TIMEOUT" env-default:"3s"`
+	CleanupTimeout time.Duration `yaml:"cleanup_timeout" env:"POSTGRES_CLEANUP_TIMEOUT" env-default:"30s"`
+
+	// Replicas — реплики для чтения в формате host:port; учётные данные,
+	// база и sslmode — те же, что у primary. Пусто — всё идёт в primary.
+	Replicas []string `yaml:"replicas" env:"POSTGRES_REPLICAS"`
+	// ReplicaRetryInterval — сколько реплика, на которой запрос упал с
+	// ошибкой соединения, не получает запросов; они идут в primary.
+	ReplicaRetryInterval time.Duration `yaml:"replica_retry_interval" env:"POSTGRES_REPLICA_RETRY_INTERVAL" env-default:"30s"`
+
+	// StatementTimeout — statement_timeout сессии на сервере: страховка на
+	// случай, когда отмена по дедлайну контекста до сервера не дошла и
+	// запрос продолжает держать соединение. 0 — без ограничения.
+	StatementTimeout time.Duration `yaml:"statement_timeout" env:"POSTGRES_STATEMENT_TIMEOUT" env-default:"30s"`
+	// QueryExecMode — режим выполнения запросов pgx: cache_statement
+	// (prepared statements в кеше соединения), cache_describe,
+	// describe_exec, exec, simple_protocol. За PgBouncer в режиме
+	// transaction нужен exec или simple_protocol.
+	QueryExecMode string `yaml:"query_exec_mode" env:"POSTGRES_QUERY_EXEC_MODE" env-default:"cache_statement"`
+	// StatementCacheCapacity — размер кеша prepared statements (или описаний
+	// для cache_describe) на соединение.
+	StatementCacheCapacity int `yaml:"statement_cache_capacity" env:"POSTGRES_STATEMENT_CACHE_CAPACITY" env-default:"512"`
+
+	CircuitBreaker PostgresCircuitBreaker `yaml:"circuit_breaker"`
+}
+
+// PostgresCircuitBreaker — после Threshold подряд запросов к primary,
+// упавших по таймауту или ошибке соединения, запросы Cooldown отклоняются
+// сразу, не занимая соединений пула; затем пропускается один пробный.
+// Threshold 0 — выключен.
+type PostgresCircuitBreaker struct {
+	Threshold int           `yaml:"threshold" env:"POSTGRES_CIRCUIT_BREAKER_THRESHOLD" env-default:"5"`
+	Cooldown  time.Duration `yaml:"cooldown" env:"POSTGRES_CIRCUIT_BREAKER_COOLDOWN" env-default:"10s"`
+}
+
+// MySQL — хранилище на MySQL 8.0+: MYSQL_USER, MYSQL_PASSWORD и
+// MYSQL_DATABASE обязательны при storage.driver=mysql. Реплик и circuit
+// breaker, как у Postgres, нет.
+type MySQL struct {
+	Host     string `yaml:"host" env:"MYSQL_HOST" env-default:"mysql"`
+	Port     int    `yaml:"port" env:"MYSQL_PORT" env-default:"3306"`
+	User     string `yaml:"-" env:"MYSQL_USER"`
+	Password string `yaml:"-" env:"MYSQL_PASSWORD"`
+	DBName   string `yaml:"-" env:"MYSQL_DATABASE"`
+	// TLS — параметр tls драйвера: false, true, skip-verify, preferred.
+	TLS string `yaml:"tls" env:"MYSQL_TLS" env-default:"false"`
+
+	// Migrate — применить встроенные миграции при старте. Включается и
+	// флагом --migrate.
+	Migrate bool `yaml:"migrate" env:"MYSQL_MIGRATE" env-default:"false"`
+
+	ReadTimeout    time.Duration `yaml:"read_timeout" env:"MYSQL_READ_TIMEOUT" env-default:"2s"`
+	WriteTimeout   time.Duration `yaml:"write_timeout" env:"MYSQL_WRITE_TIMEOUT" env-default:"3s"`
+	CleanupTimeout time.Duration `yaml:"cleanup_timeout" env:"MYSQL_CLEANUP_TIMEOUT" env-default:"30s"`
+
+	MaxOpenConns int `yaml:"max_open_conns" env:"MYSQL_MAX_OPEN_CONNS" env-default:"10"`
+}
+
+// SQLite — хранилище в одном файле для разработки и небольших установок с
+// одной репликой: блокировка лидера держится в памяти процесса и другие
+// процессы её не видят.
+type SQLite struct {
+	Path string `yaml:"path" env:"SQLITE_PATH" env-default:"data/auth_service.db"`
+
+	// Migrate — применить встроенные миграции при старте. Включается и
+	// флагом --migrate.
+	Migrate bool `yaml:"migrate" env:"SQLITE_MIGRATE" env-default:"false"`
+
+	// BusyTimeout — сколько запрос ждёт, пока файл заблокирован пишущей
+	// транзакцией другого соединения.
+	BusyTimeout time.Duration `yaml:"busy_timeout" env:"SQLITE_BUSY_TIMEOUT" env-default:"5s"`
+
+	ReadTimeout    time.Duration `yaml:"read_timeout" env:"SQLITE_READ_TIMEOUT" env-default:"2s"`
+	WriteTimeout   time.Duration `yaml:"write_timeout" env:"SQLITE_WRITE_TIMEOUT" env-default:"10s"`
+	CleanupTimeout time.Duration `yaml:"cleanup_timeout" env:"SQLITE_CLEANUP_TIMEOUT" env-default:"30s"`
 }
 
 type Redis struct {
-	Addr     string `yaml:"addr" env-default:"redis:6379"`
+	Addr     string `yaml:"addr" env:"REDIS_ADDR" env-default:"redis:6379"`
 	Password string `yaml:"-" env:"REDIS_PASSWORD" env-required:"true"`
-	Db       int    `yaml:"db" env-default:"1"`
+	Db       int    `yaml:"db" env:"REDIS_DB" env-default:"1"`
 }
 
+// Tokens.AccessTokenTTL и RefreshTokenTTL — значения по умолчанию: у
+// приложения они переопределяются колонками apps.access_token_ttl и
+// apps.refresh_token_ttl.
 type Tokens struct {
-	AccessTokenTTL          time.Duration `yaml:"access_token_ttl" env-default:"1h"`
-	RefreshTokenTTL         time.Duration `yaml:"refresh_token_ttl" env-default:"168h"`
-	VerificationTokenTTL    time.Duration `yaml:"verification_token_ttl" env-default:"15m"`
-	ResetTokenTTL           time.Duration `yaml:"reset_token_ttl" env-default:"15m"`
+	AccessTokenTTL       time.Duration `yaml:"access_token_ttl" env:"TOKENS_ACCESS_TOKEN_TTL" env-default:"1h"`
+	RefreshTokenTTL      time.Duration `yaml:"refresh_token_ttl" env:"TOKENS_REFRESH_TOKEN_TTL" env-default:"168h"`
+	VerificationTokenTTL time.Duration `yaml:"verification_token_ttl" env:"TOKENS_VERIFICATION_TOKEN_TTL" env-default:"15m"`
+	ResetTokenTTL        time.Duration `yaml:"reset_token_ttl" env:"TOKENS_RESET_TOKEN_TTL" env-default:"15m"`
+	EmailChangeTokenTTL  time.Duration `yaml:"email_change_token_ttl" env:"TOKENS_EMAIL_CHANGE_TOKEN_TTL" env-default:"30m"`
+	OrgInvitationTTL     time.Duration `yaml:"org_invitation_ttl" env:"TOKENS_ORG_INVITATION_TTL" env-default:"168h"`
+	// RefreshSliding — каждая ротация продлевает refresh-токен на
+	// RefreshTokenTTL, но не дальше RefreshMaxLifetime от логина. Выключено —
+	// срок фиксируется при выдаче и ротация его не меняет.
+	RefreshSliding     bool          `yaml:"refresh_sliding" env:"TOKENS_REFRESH_SLIDING" env-default:"true"`
+	RefreshMaxLifetime time.Duration `yaml:"refresh_max_lifetime" env:"TOKENS_REFRESH_MAX_LIFETIME" env-default:"2160h"`
+	// Leeway — допуск на расхождение часов при проверке exp/nbf/iat.
+	Leeway                  time.Duration `yaml:"leeway" env:"TOKENS_LEEWAY" env-default:"30s"`
 	VerificationTokenSecret string        `yaml:"-" env:"VERIFICATION_TOKEN_SECRET" env-required:"true"`
+	// VerificationCodeMaxAttempts — сколько неверных вводов 6-значного кода
+	// подтверждения email допускается, прежде чем код сгорит. Код живёт
+	// VerificationTokenTTL, как и ссылка из того же письма.
+	VerificationCodeMaxAttempts int `yaml:"verification_code_max_attempts" env:"TOKENS_VERIFICATION_CODE_MAX_ATTEMPTS" env-default:"5"`
 }
 
 type RabbitMQ struct {
-	URL       string `yaml:"-" env:"RABBITMQ_URL" env-required:"true"`
-	QueueName string `yaml:"queue_name" env-default:"notificationsQueue"`
+	URL       string `yaml:"-" env:"RABBITMQ_URL"` // обязателен, если mail.sandbox выключен
+	QueueName string `yaml:"queue_name" env:"RABBITMQ_QUEUE_NAME" env-default:"notificationsQueue"`
+
+	Username       string           `yaml:"-" env:"RABBITMQ_USERNAME"`
+	Password       string           `yaml:"-" env:"RABBITMQ_PASSWORD"`
+	ConnectionName string           `yaml:"connection_name" env:"RABBITMQ_CONNECTION_NAME" env-default:"auth_service"`
+	Heartbeat      time.Duration    `yaml:"heartbeat" env:"RABBITMQ_HEARTBEAT" env-default:"10s"`
+	TLS            RabbitMQTLS      `yaml:"tls"`
+	Queue          RabbitMQQueue    `yaml:"queue"`
+	Topology       RabbitMQTopology `yaml:"topology"`
+
+	// PublishChannels — размер пула каналов для публикации. amqp.Channel
+	// нельзя использовать из нескольких горутин одновременно.
+	PublishChannels int `yaml:"publish_channels" env:"RABBITMQ_PUBLISH_CHANNELS" env-default:"4"`
+
+	Reconnect RabbitMQReconnect `yaml:"reconnect"`
+	// RetryBufferSize — сколько неотправленных писем держать в памяти, пока
+	// брокер недоступен. Переполнение — ошибка публикации.
+	RetryBufferSize int `yaml:"retry_buffer_size" env:"RABBITMQ_RETRY_BUFFER_SIZE" env-default:"1000"`
+	// RetryInterval — как часто повторять отправку отложенных писем.
+	RetryInterval time.Duration `yaml:"retry_interval" env:"RABBITMQ_RETRY_INTERVAL" env-default:"5s"`
+}
+
+// RabbitMQReconnect — пауза между попытками переподключения растёт вдвое
+// от MinBackoff до MaxBackoff.
+type RabbitMQReconnect struct {
+	MinBackoff time.Duration `yaml:"min_backoff" env:"RABBITMQ_RECONNECT_MIN_BACKOFF" env-default:"500ms"`
+	MaxBackoff time.Duration `yaml:"max_backoff" env:"RABBITMQ_RECONNECT_MAX_BACKOFF" env-default:"30s"`
+}
+
+// RabbitMQTopology — дополнительные exchange'и, очереди и биндинги, которые
+// сервис идемпотентно объявляет при старте. Секция одинакова в auth_service
+// и email_sender: кто бы ни стартовал первым, топология будет на месте.
+type RabbitMQTopology struct {
+	Exchanges []RabbitMQExchange      `yaml:"exchanges"`
+	Queues    []RabbitMQTopologyQueue `yaml:"queues"`
+	Bindings  []RabbitMQBinding       `yaml:"bindings"`
+}
+
+type RabbitMQExchange struct {
+	Name       string         `yaml:"name"`
+	Kind       string         `yaml:"kind"` // direct | fanout | topic | headers
+	Durable    bool           `yaml:"durable"`
+	AutoDelete bool           `yaml:"auto_delete"`
+	Arguments  map[string]any `yaml:"arguments"`
+}
+
+type RabbitMQTopologyQueue struct {
+	Name       string         `yaml:"name"`
+	Durable    bool           `yaml:"durable"`
+	AutoDelete bool           `yaml:"auto_delete"`
+	Arguments  map[string]any `yaml:"arguments"`
+}
+
+type RabbitMQBinding struct {
+	Queue      string         `yaml:"queue"`
+	Exchange   string         `yaml:"exchange"`
+	RoutingKey string         `yaml:"routing_key"`
+	Arguments  map[string]any `yaml:"arguments"`
+}
+
+// RabbitMQQueue — аргументы объявления основной очереди и её DLQ.
+type RabbitMQQueue struct {
+	Type                 string        `yaml:"type" env:"RABBITMQ_QUEUE_TYPE" env-default:"classic"` // classic | quorum | lazy
+	MessageTTL           time.Duration `yaml:"message_ttl" env:"RABBITMQ_QUEUE_MESSAGE_TTL"`
+	MaxLength            int           `yaml:"max_length" env:"RABBITMQ_QUEUE_MAX_LENGTH"`
+	Overflow             string        `yaml:"overflow" env:"RABBITMQ_QUEUE_OVERFLOW"` // drop-head | reject-publish | reject-publish-dlx
+	DeadLetterExchange   string        `yaml:"dead_letter_exchange" env:"RABBITMQ_QUEUE_DEAD_LETTER_EXCHANGE" env-default:"email.dlx"`
+	DeadLetterRoutingKey string        `yaml:"dead_letter_routing_key" env:"RABBITMQ_QUEUE_DEAD_LETTER_ROUTING_KEY"`
+	DeadLetterQueue      string        `yaml:"dead_letter_queue" env:"RABBITMQ_QUEUE_DEAD_LETTER_QUEUE" env-default:"email.verification.dlq"`
+}
+
+// RabbitMQTLS применяется только для amqps:// URL.
+type RabbitMQTLS struct {
+	CAFile     string `yaml:"ca_file" env:"RABBITMQ_TLS_CA_FILE"`
+	CertFile   string `yaml:"cert_file" env:"RABBITMQ_TLS_CERT_FILE"`
+	KeyFile    string `yaml:"key_file" env:"RABBITMQ_TLS_KEY_FILE"`
+	ServerName string `yaml:"server_name" env:"RABBITMQ_TLS_SERVER_NAME"`
+}
+
+// StorageCleanupTimeout — cleanup_timeout секции выбранного storage.driver:
+// дедлайн фоновых задач, которые чистят хранилище.
+func (c *Config) StorageCleanupTimeout() time.Duration {
+	switch c.Storage.Driver {
+	case StorageDriverMySQL:
+		return c.MySQL.CleanupTimeout
+	case StorageDriverSQLite:
+		return c.SQLite.CleanupTimeout
+	default:
+		return c.Postgres.CleanupTimeout
+	}
+}
+
+// EnableMigrate включает применение миграций при старте для любого
+// storage.driver — флаг --migrate и authctl migrate.
+func (c *Config) EnableMigrate() {
+	c.Postgres.Migrate = true
+	c.MySQL.Migrate = true
+	c.SQLite.Migrate = true
 }
 
 func MustLoad(configPath string) *Config {
+	cfg, err := Load(configPath)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return cfg
+}
+
+// Load читает и проверяет конфиг. Нужна там, где ошибка не должна ронять
+// процесс, — при перечитывании конфига на лету.
+func Load(configPath string) (*Config, error) {
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		panic("Config file does not exist: " + configPath)
+		return nil, fmt.Errorf("config file does not exist: %s", configPath)
+	}
+
+	if err := loadSecretFiles(); err != nil {
+		return nil, fmt.Errorf("failed to read secret file: %w", err)
+	}
+
+	if err := loadProviderSecrets(configPath); err != nil {
+		return nil, fmt.Errorf("failed to load secrets: %w", err)
 	}
 
 	var cfg Config
 
+	// значения из YAML — умолчания: заданная env-переменная их перекрывает
 	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
-		panic("Failed to read config: " + err.Error())
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if cfg.Mail.Sandbox && cfg.Env == "prod" {
+		return nil, errors.New("mail.sandbox is not allowed in prod")
+	}
+
+	if !cfg.Mail.Sandbox && cfg.RabbitMQ.URL == "" {
+		return nil, errors.New("RABBITMQ_URL is required unless mail.sandbox is enabled")
+	}
+
+	base, err := url.Parse(cfg.HTTPServer.PublicBaseURL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, errors.New("http_server.public_base_url must be an absolute http(s) URL")
+	}
+	cfg.HTTPServer.PublicBaseURL = strings.TrimRight(cfg.HTTPServer.PublicBaseURL, "/")
+
+	if cfg.HTTPServer.ShutdownTimeout <= 0 {
+		return nil, errors.New("http_server.shutdown_timeout must be positive")
+	}
+
+	if cfg.OIDC.Enabled && (cfg.OIDC.Issuer == "" || cfg.OIDC.LoginURL == "") {
+		return nil, errors.New("oidc.issuer and oidc.login_url are required when oidc is enabled")
+	}
+
+	switch cfg.RefreshCookie.SameSite {
+	case "strict", "lax":
+	case "none":
+		if !cfg.RefreshCookie.Secure {
+			return nil, errors.New("refresh_cookie.same_site=none requires refresh_cookie.secure")
+		}
+	default:
+		return nil, errors.New("refresh_cookie.same_site must be one of strict, lax, none")
+	}
+
+	switch cfg.Geo.Action {
+	case "notify", "challenge", "block":
+	default:
+		return nil, errors.New("geo.action must be one of notify, challenge, block")
+	}
+
+	if cfg.RabbitMQ.RetryInterval <= 0 || cfg.RabbitMQ.Reconnect.MinBackoff <= 0 ||
+		cfg.RabbitMQ.Reconnect.MaxBackoff < cfg.RabbitMQ.Reconnect.MinBackoff {
+		return nil, errors.New("rabbitmq.retry_interval and rabbitmq.reconnect backoffs must be positive, max_backoff >= min_backoff")
+	}
+
+	switch cfg.Storage.Driver {
+	case StorageDriverPostgres:
+		if cfg.Postgres.User == "" || cfg.Postgres.Password == "" || cfg.Postgres.DBName == "" {
+			return nil, errors.New("POSTGRES_USER, POSTGRES_PASSWORD and POSTGRES_DB are required for storage.driver=postgres")
+		}
+	case StorageDriverMySQL:
+		if cfg.MySQL.User == "" || cfg.MySQL.Password == "" || cfg.MySQL.DBName == "" {
+			return nil, errors.New("MYSQL_USER, MYSQL_PASSWORD and MYSQL_DATABASE are required for storage.driver=mysql")
+		}
+		if cfg.MySQL.MaxOpenConns <= 0 {
+			return nil, errors.New("mysql.max_open_conns must be positive")
+		}
+	case StorageDriverSQLite:
+		if cfg.SQLite.Path == "" {
+			return nil, errors.New("sqlite.path is required for storage.driver=sqlite")
+		}
+		if cfg.SQLite.BusyTimeout <= 0 {
+			return nil, errors.New("sqlite.busy_timeout must be positive")
+		}
+	default:
+		return nil, errors.New("storage.driver must be one of postgres, mysql, sqlite")
+	}
+
+	for _, replica := range cfg.Postgres.Replicas {
+		if _, _, err := net.SplitHostPort(replica); err != nil {
+			return nil, fmt.Errorf("postgres.replicas: %q must be host:port", replica)
+		}
+	}
+	if len(cfg.Postgres.Replicas) > 0 && cfg.Postgres.ReplicaRetryInterval <= 0 {
+		return nil, errors.New("postgres.replica_retry_interval must be positive")
+	}
+
+	// серверный таймаут — страховка поверх клиентских, а не замена им:
+	// меньшее значение обрывало бы очистку раньше cleanup_timeout
+	if cfg.Postgres.StatementTimeout < 0 || (cfg.Postgres.StatementTimeout > 0 &&
+		cfg.Postgres.StatementTimeout < max(cfg.Postgres.ReadTimeout, cfg.Postgres.WriteTimeout, cfg.Postgres.CleanupTimeout)) {
+		return nil, errors.New("postgres.statement_timeout must be 0 or >= read, write and cleanup timeouts")
+	}
+	switch cfg.Postgres.QueryExecMode {
+	case "cache_statement", "cache_describe", "describe_exec", "exec", "simple_protocol":
+	default:
+		return nil, errors.New("postgres.query_exec_mode must be one of cache_statement, cache_describe, describe_exec, exec, simple_protocol")
+	}
+	if cfg.Postgres.StatementCacheCapacity <= 0 {
+		return nil, errors.New("postgres.statement_cache_capacity must be positive")
+	}
+	if cfg.Postgres.CircuitBreaker.Threshold < 0 {
+		return nil, errors.New("postgres.circuit_breaker.threshold must be >= 0")
+	}
+	if cfg.Postgres.CircuitBreaker.Threshold > 0 && cfg.Postgres.CircuitBreaker.Cooldown <= 0 {
+		return nil, errors.New("postgres.circuit_breaker.cooldown must be positive")
+	}
+
+	if cfg.Lockout.Enabled && (cfg.Lockout.MaxAttempts <= 0 || cfg.Lockout.AccountLockIPs <= 0) {
+		return nil, errors.New("lockout.max_attempts and lockout.account_lock_ips must be positive")
+	}
+
+	if cfg.Tokens.RefreshMaxLifetime <= 0 {
+		return nil, errors.New("tokens.refresh_max_lifetime must be positive")
+	}
+
+	if cfg.Tokens.VerificationCodeMaxAttempts <= 0 {
+		return nil, errors.New("tokens.verification_code_max_attempts must be positive")
+	}
+
+	if cfg.TwoFactorAuth.TrustedDeviceTTL < 0 {
+		return nil, errors.New("two_factor_auth.trusted_device_ttl must be >= 0")
+	}
+
+	if cfg.TwoFactorAuth.SMS.AccountSID != "" && (cfg.TwoFactorAuth.SMS.AuthToken == "" || cfg.TwoFactorAuth.SMS.From == "") {
+		return nil, errors.New("TWILIO_AUTH_TOKEN and two_factor_auth.sms.from are required when TWILIO_ACCOUNT_SID is set")
+	}
+
+	if cfg.PasswordPolicy.MinLength <= 0 || cfg.PasswordPolicy.MaxLength < cfg.PasswordPolicy.MinLength || cfg.PasswordPolicy.MaxLength > 72 {
+		return nil, errors.New("password_policy.min_length must be positive, max_length between min_length and 72")
+	}
+
+	if cfg.PasswordPolicy.MinScore < 0 || cfg.PasswordPolicy.MinScore > 4 {
+		return nil, errors.New("password_policy.min_score must be between 0 and 4")
+	}
+
+	switch cfg.PasswordHashing.Algorithm {
+	case "argon2id", "bcrypt":
+	default:
+		return nil, errors.New("password_hashing.algorithm must be one of argon2id, bcrypt")
+	}
+
+	if a := cfg.PasswordHashing.Argon2id; a.MemoryKiB < 8*a.Parallelism || a.Iterations == 0 ||
+		a.Parallelism == 0 || a.Parallelism > 255 || a.SaltLength < 8 || a.KeyLength < 16 {
+		return nil, errors.New("password_hashing.argon2id: parallelism must be 1-255, memory_kib >= 8*parallelism, iterations > 0, salt_length >= 8, key_length >= 16")
+	}
+
+	if cfg.PasswordHashing.BcryptCost < 4 || cfg.PasswordHashing.BcryptCost > 31 {
+		return nil, errors.New("password_hashing.bcrypt_cost must be between 4 and 31")
+	}
+
+	if cfg.PasswordHashing.Workers < 0 {
+		return nil, errors.New("password_hashing.workers must be >= 0")
+	}
+
+	for id, key := range cfg.PasswordHashing.Pepper.Keys {
+		if id == "" || strings.Contains(id, "$") || len(key) < 32 {
+			return nil, errors.New("PASSWORD_PEPPERS: ids must be non-empty without '$', keys at least 32 bytes")
+		}
+	}
+
+	if id := cfg.PasswordHashing.Pepper.CurrentID; id != "" {
+		if _, ok := cfg.PasswordHashing.Pepper.Keys[id]; !ok {
+			return nil, errors.New("password_hashing.pepper.current_id must be one of PASSWORD_PEPPERS ids")
+		}
+	}
+
+	if cfg.Retention.BatchSize <= 0 {
+		return nil, errors.New("retention.batch_size must be positive")
+	}
+
+	if cfg.Retention.DeletedAccounts <= 0 {
+		return nil, errors.New("retention.deleted_accounts must be positive")
+	}
+
+	switch cfg.LogLevel {
+	case "", "debug", "info", "warn", "error":
+	default:
+		return nil, errors.New("log_level must be one of debug, info, warn, error")
+	}
+
+	if cfg.Sessions.MaxPerApp < 0 {
+		return nil, errors.New("sessions.max_per_app must be >= 0")
+	}
+
+	if cfg.Sessions.OnLimit != SessionLimitEvictOldest && cfg.Sessions.OnLimit != SessionLimitReject {
+		return nil, fmt.Errorf("sessions.on_limit must be one of evict_oldest, reject, got %q", cfg.Sessions.OnLimit)
+	}
+
+	if cfg.Challenge.Enabled && (cfg.Challenge.Threshold <= 0 || cfg.Challenge.Window <= 0 || cfg.Challenge.TTL <= 0 ||
+		cfg.Challenge.Difficulty <= 0 || cfg.Challenge.MaxDifficulty < cfg.Challenge.Difficulty || cfg.Challenge.MaxDifficulty > 32) {
+		return nil, errors.New("challenge: threshold, window and ttl must be positive, 0 < difficulty <= max_difficulty <= 32")
+	}
+
+	if cfg.Apps.CacheTTL < 0 {
+		return nil, errors.New("apps: cache_ttl must be >= 0")
+	}
+
+	if cfg.IPFilter.CacheTTL <= 0 || cfg.IPFilter.LocalCacheTTL < 0 {
+		return nil, errors.New("ip_filter: cache_ttl must be positive, local_cache_ttl >= 0")
+	}
+
+	if cfg.IPFilter.AutoBlock.Enabled && (cfg.IPFilter.AutoBlock.Threshold <= 0 ||
+		cfg.IPFilter.AutoBlock.Window <= 0 || cfg.IPFilter.AutoBlock.Duration <= 0) {
+		return nil, errors.New("ip_filter.auto_block: threshold, window and duration must be positive")
+	}
+
+	for key, p := range cfg.RateLimits.Overrides {
+		if !strings.Contains(key, ":") || p.Burst <= 0 || p.Rate <= 0 || p.Period <= 0 {
+			return nil, fmt.Errorf("rate_limits.overrides.%s: key must be <endpoint>:<key>, burst, rate and period positive", key)
+		}
 	}
 
-	return &cfg
+	return &cfg, nil
 }
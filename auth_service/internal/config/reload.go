@@ -0,0 +1,64 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Reloadable — часть конфига, которая применяется без рестарта при
+// перечитывании по SIGHUP.
+type Reloadable struct {
+	LogLevel   string
+	RateLimits RateLimits
+
+	AccessTokenTTL      time.Duration
+	RefreshTokenTTL     time.Duration
+	ResetTokenTTL       time.Duration
+	EmailChangeTokenTTL time.Duration
+}
+
+func (c *Config) Reloadable() Reloadable {
+	return Reloadable{
+		LogLevel:            c.LogLevel,
+		RateLimits:          c.RateLimits,
+		AccessTokenTTL:      c.Tokens.AccessTokenTTL,
+		RefreshTokenTTL:     c.Tokens.RefreshTokenTTL,
+		ResetTokenTTL:       c.Tokens.ResetTokenTTL,
+		EmailChangeTokenTTL: c.Tokens.EmailChangeTokenTTL,
+	}
+}
+
+// SetReloadable подменяет применяемые на лету поля, остальные не трогает.
+func (c *Config) SetReloadable(r Reloadable) {
+	c.LogLevel = r.LogLevel
+	c.RateLimits = r.RateLimits
+	c.Tokens.AccessTokenTTL = r.AccessTokenTTL
+	c.Tokens.RefreshTokenTTL = r.RefreshTokenTTL
+	c.Tokens.ResetTokenTTL = r.ResetTokenTTL
+	c.Tokens.EmailChangeTokenTTL = r.EmailChangeTokenTTL
+}
+
+// RestartRequired возвращает секции (имена из YAML), изменения в которых
+// без рестарта не применить: подключения к Postgres, Redis, RabbitMQ, адрес
+// сервера, секреты и т.п. Применяемые на лету поля не сравниваются.
+func RestartRequired(running, loaded *Config) []string {
+	a, b := *running, *loaded
+	a.SetReloadable(Reloadable{})
+	b.SetReloadable(Reloadable{})
+
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	t := va.Type()
+
+	var sections []string
+	for i := range t.NumField() {
+		if reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			continue
+		}
+
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		sections = append(sections, name)
+	}
+
+	return sections
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/XdMishaXd/auth_service/secrets"
+	"github.com/ilyakaznacheev/cleanenv"
+)
+
+// secretFileSuffix — переменная <NAME>_FILE указывает на файл со значением
+// <NAME>. Так значения передаются через Docker/Kubernetes secrets: секрет
+// монтируется файлом и не светится в docker inspect и /proc/<pid>/environ.
+const secretFileSuffix = "_FILE"
+
+// loadSecretFiles выставляет каждую env-переменную конфига, для которой
+// задан <NAME>_FILE, из содержимого файла. Явно заданная переменная важнее
+// файла. Конечный перевод строки отрезается — его оставляют редакторы и
+// echo при создании секрета.
+func loadSecretFiles() error {
+	for _, name := range envNames(reflect.TypeFor[Config]()) {
+		path, ok := os.LookupEnv(name + secretFileSuffix)
+		if !ok || path == "" {
+			continue
+		}
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+
+		value, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("%s%s: %w", name, secretFileSuffix, err)
+		}
+
+		if err := os.Setenv(name, strings.TrimRight(string(value), "\r\n")); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// loadProviderSecrets подставляет секреты хранилища в окружение до чтения
+// конфига. Порядок приоритета: явная env-переменная (и *_FILE), затем
+// хранилище, затем YAML. Ключи, которых нет в конфиге, пропускаются.
+func loadProviderSecrets(configPath string) error {
+	var bootstrap struct {
+		Secrets secrets.Config `yaml:"secrets"`
+	}
+	if err := cleanenv.ReadConfig(configPath, &bootstrap); err != nil {
+		return err
+	}
+
+	return secrets.Load(bootstrap.Secrets, envNames(reflect.TypeFor[Config]()))
+}
+
+// envNames собирает теги env всех полей конфига, включая вложенные секции.
+func envNames(t reflect.Type) []string {
+	var names []string
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		if name := field.Tag.Get("env"); name != "" {
+			names = append(names, name)
+			continue
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			names = append(names, envNames(field.Type)...)
+		}
+	}
+
+	return names
+}
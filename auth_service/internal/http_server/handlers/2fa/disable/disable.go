@@ -26,6 +26,10 @@ type Request struct {
 	Token     string `json:"token,omitempty" example:"fkajeDJ1p3FJ..."`
 }
 
+type TwoFADisabler interface {
+	Disable2FA(ctx context.Context, userID int64, password string, sessionID, rawToken string) error
+}
+
 // New godoc
 // @Summary      Отключить magic-link 2FA
 // @Description  Отключает magic-link 2FA. Подтверждение зависит от того, есть
@@ -38,13 +42,13 @@ type Request struct {
 // @Produce      json
 // @Param        request  body  object{password=string,session_id=string,token=string}  false  "Подтверждение отключения (один из наборов полей)"
 // @Success      200  {object}  object{status=string}  "2FA отключена"
-// @Failure      401  {object}  object{status=string,error=string}  "Access token отсутствует, невалиден или истёк, либо неверное подтверждение (пароль/magic-link код)"
-// @Failure      409  {object}  object{status=string,error=string}  "2FA не включена"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк, либо неверное подтверждение (пароль/magic-link код)"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "2FA не включена или включена через TOTP (отключается через /auth/2fa/totp/disable)"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
 // @Router       /auth/2fa/magic-link/disable [post]
 func New(
 	log *slog.Logger,
-	authMiddleware *auth.Auth,
+	disabler TwoFADisabler,
 	handlerTimeout time.Duration,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -58,7 +62,7 @@ func New(
 		claims, ok := claimsParser.ClaimsFromContext(r.Context())
 		if !ok {
 			render.Status(r, http.StatusUnauthorized)
-			render.JSON(w, r, resp.Error("invalid or expired access token"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
 			return
 		}
 
@@ -66,14 +70,14 @@ func New(
 
 		if err := render.DecodeJSON(r.Body, &req); err != nil {
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("Failed to decode request"))
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
 			return
 		}
 
 		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
 		defer cancel()
 
-		err := authMiddleware.Disable2FA(
+		err := disabler.Disable2FA(
 			ctx,
 			claims.UserID,
 			req.Password,
@@ -84,17 +88,21 @@ func New(
 			switch {
 			case errors.Is(err, auth.ErrTwoFANotEnabled):
 				render.Status(r, http.StatusConflict)
-				render.JSON(w, r, resp.Error("2fa is not enabled"))
+				render.JSON(w, r, resp.Error(resp.CodeTwoFANotEnabled, "2fa is not enabled"))
+				return
+			case errors.Is(err, auth.ErrTOTPEnabled):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error(resp.CodeTwoFAMethodMismatch, "2fa is enabled via totp, use /auth/2fa/totp/disable"))
 				return
 			case errors.Is(err, auth.ErrDisableConfirmation):
 				render.Status(r, http.StatusUnauthorized)
-				render.JSON(w, r, resp.Error("invalid confirmation"))
+				render.JSON(w, r, resp.Error(resp.CodeInvalidConfirmation, "invalid confirmation"))
 				return
 			}
 
 			log.Error("failed to disable 2fa", sl.Err(err))
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("Internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
 			return
 		}
 
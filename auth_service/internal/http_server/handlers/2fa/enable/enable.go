@@ -22,6 +22,10 @@ type Response struct {
 	resp.Response
 }
 
+type TwoFAEnabler interface {
+	Enable2FA(ctx context.Context, userID int64) error
+}
+
 // New godoc
 // @Summary      Включить magic-link 2FA
 // @Description  Включает magic-link 2FA для текущего пользователя. Требует,
@@ -32,13 +36,13 @@ type Response struct {
 // @Security     BearerAuth
 // @Produce      json
 // @Success      200  {object}  object{status=string}  "2FA включена"
-// @Failure      401  {object}  object{status=string,error=string}  "Access token отсутствует, невалиден или истёк"
-// @Failure      409  {object}  object{status=string,error=string}  "2FA уже включена, либо нет ни одного доступного фактора для будущего disable"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "2FA уже включена, либо нет ни одного доступного фактора для будущего disable"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
 // @Router       /auth/2fa/magic-link/enable [post]
 func New(
 	log *slog.Logger,
-	authMiddleware *auth.Auth,
+	enabler TwoFAEnabler,
 	handlerTimeout time.Duration,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -52,34 +56,34 @@ func New(
 		claims, ok := claimsParser.ClaimsFromContext(r.Context())
 		if !ok {
 			render.Status(r, http.StatusUnauthorized)
-			render.JSON(w, r, resp.Error("invalid or expired access token"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
 			return
 		}
 
 		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
 		defer cancel()
 
-		err := authMiddleware.Enable2FA(ctx, claims.UserID)
+		err := enabler.Enable2FA(ctx, claims.UserID)
 		if err != nil {
 			switch {
 			case errors.Is(err, auth.ErrTwoFAAlreadyEnabled):
 				render.Status(r, http.StatusConflict)
-				render.JSON(w, r, resp.Error("2fa already enabled"))
+				render.JSON(w, r, resp.Error(resp.CodeTwoFAAlreadyEnabled, "2fa already enabled"))
 				return
 			case errors.Is(err, auth.ErrNoAuthFactorAvailable):
 				render.Status(r, http.StatusConflict)
-				render.JSON(w, r, resp.Error("no password or linked oauth account to enable 2fa"))
+				render.JSON(w, r, resp.Error(resp.CodeTwoFANoFactor, "no password or linked oauth account to enable 2fa"))
 				return
 			case errors.Is(err, storage.ErrUserNotFound):
 				render.Status(r, http.StatusNotFound)
-				render.JSON(w, r, resp.Error("user not found"))
+				render.JSON(w, r, resp.Error(resp.CodeUserNotFound, "user not found"))
 				return
 			}
 
 			log.Error("failed to enable 2fa", sl.Err(err))
 
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("Internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
 
 			return
 		}
@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"time"
 
-	"auth_service/internal/auth"
 	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
 	resp "auth_service/internal/lib/api/response"
 	sl "auth_service/internal/lib/logger"
@@ -21,6 +20,16 @@ type Response struct {
 	SessionID string `json:"session_id" example:"abcDEF123..."`
 }
 
+type ActionConfirmationRequester interface {
+	RequestActionConfirmation(
+		ctx context.Context,
+		userID int64,
+		appID int32,
+		action models.Action,
+		pendingSessionTTL time.Duration,
+	) (string, error)
+}
+
 // NewDisable2FA godoc
 // @Summary      Запросить подтверждение отключения 2FA через magic link
 // @Description  Отправляет magic-link код на email текущего пользователя для
@@ -33,16 +42,16 @@ type Response struct {
 // @Security     BearerAuth
 // @Produce      json
 // @Success      200  {object}  object{status=string,session_id=string}  "Код отправлен на email"
-// @Failure      401  {object}  object{status=string,error=string}  "Access token отсутствует, невалиден или истёк"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
 // @Router       /auth/2fa/disable/request-confirmation [post]
 func NewDisable2FA(
 	log *slog.Logger,
-	authMiddleware *auth.Auth,
+	requester ActionConfirmationRequester,
 	handlerTimeout time.Duration,
 	pendingSessionTTL time.Duration,
 ) http.HandlerFunc {
-	return newActionConfirmationHandler(log, authMiddleware, models.ActionDisable2FA, handlerTimeout, pendingSessionTTL)
+	return newActionConfirmationHandler(log, requester, models.ActionDisable2FA, handlerTimeout, pendingSessionTTL)
 }
 
 // NewDeleteAccount godoc
@@ -57,16 +66,16 @@ func NewDisable2FA(
 // @Security     BearerAuth
 // @Produce      json
 // @Success      200  {object}  object{status=string,session_id=string}  "Код отправлен на email"
-// @Failure      401  {object}  object{status=string,error=string}  "Access token отсутствует, невалиден или истёк"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
 // @Router       /account/delete/request-confirmation [post]
 func NewDeleteAccount(
 	log *slog.Logger,
-	authMiddleware *auth.Auth,
+	requester ActionConfirmationRequester,
 	handlerTimeout time.Duration,
 	pendingSessionTTL time.Duration,
 ) http.HandlerFunc {
-	return newActionConfirmationHandler(log, authMiddleware, models.ActionDeleteAccount, handlerTimeout, pendingSessionTTL)
+	return newActionConfirmationHandler(log, requester, models.ActionDeleteAccount, handlerTimeout, pendingSessionTTL)
 }
 
 // newActionConfirmationHandler — общее ядро для всех chувствительных действий,
@@ -75,7 +84,7 @@ func NewDeleteAccount(
 // запросить confirmation для одного действия и подтвердить им другое.
 func newActionConfirmationHandler(
 	log *slog.Logger,
-	authMiddleware *auth.Auth,
+	requester ActionConfirmationRequester,
 	action models.Action,
 	handlerTimeout time.Duration,
 	pendingSessionTTL time.Duration,
@@ -92,14 +101,14 @@ func newActionConfirmationHandler(
 		claims, ok := claimsParser.ClaimsFromContext(r.Context())
 		if !ok {
 			render.Status(r, http.StatusUnauthorized)
-			render.JSON(w, r, resp.Error("invalid or expired access token"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
 			return
 		}
 
 		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
 		defer cancel()
 
-		sessionID, err := authMiddleware.TwoFA.RequestActionConfirmation(
+		sessionID, err := requester.RequestActionConfirmation(
 			ctx,
 			claims.UserID, claims.AppID,
 			action,
@@ -108,7 +117,7 @@ func newActionConfirmationHandler(
 		if err != nil {
 			log.Error("failed to request action confirmation", sl.Err(err))
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("Internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
 			return
 		}
 
@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"time"
 
-	"auth_service/internal/auth"
 	resp "auth_service/internal/lib/api/response"
 	sl "auth_service/internal/lib/logger"
 	"auth_service/internal/storage"
@@ -25,6 +24,10 @@ type Response struct {
 	resp.Response
 }
 
+type MagicLinkResender interface {
+	Resend(ctx context.Context, sessionID string) error
+}
+
 // New godoc
 // @Summary      Повторно отправить magic-link
 // @Description  Инвалидирует предыдущую активную ссылку и высылает новую в
@@ -36,15 +39,15 @@ type Response struct {
 // @Produce      json
 // @Param        request  body  object{session_id=string}  true  "Идентификатор pending-сессии"
 // @Success      200  {object}  object{status=string}  "Новая ссылка отправлена (либо попытка предпринята)"
-// @Failure      400  {object}  object{status=string,error=string}  "Невалидное тело запроса"
-// @Failure      401  {object}  object{status=string,error=string}  "Pending-сессия не найдена или истекла — нужно начать логин заново"
-// @Failure      429  {object}  object{status=string,error=string}  "Слишком частые запросы на повторную отправку"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Невалидное тело запроса"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Pending-сессия не найдена или истекла — нужно начать логин заново"
+// @Failure      429  {object}  object{status=string,code=string,error=string}  "Слишком частые запросы на повторную отправку"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
 // @Router       /auth/2fa/magic-link/resend [post]
 func New(
 	log *slog.Logger,
 	validate *validator.Validate,
-	authMiddleware *auth.Auth,
+	resender MagicLinkResender,
 	handlerTimeout time.Duration,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -59,7 +62,7 @@ func New(
 
 		if err := render.DecodeJSON(r.Body, &req); err != nil {
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("Failed to decode request"))
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
 			return
 		}
 
@@ -73,24 +76,24 @@ func New(
 			}
 
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
 			return
 		}
 
 		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
 		defer cancel()
 
-		if err := authMiddleware.TwoFA.Resend(ctx, req.SessionID); err != nil {
+		if err := resender.Resend(ctx, req.SessionID); err != nil {
 			if errors.Is(err, storage.ErrPendingSessionNotFound) {
 				log.Warn("resend failed: pending session not found", sl.Err(err))
 				render.Status(r, http.StatusUnauthorized)
-				render.JSON(w, r, resp.Error("session expired, please log in again"))
+				render.JSON(w, r, resp.Error(resp.CodeSessionExpired, "session expired, please log in again"))
 				return
 			}
 
 			log.Error("failed to resend magic link", sl.Err(err))
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("Internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
 			return
 		}
 
@@ -0,0 +1,151 @@
+package totpHandler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth_service/internal/auth"
+	"auth_service/internal/auth/totp"
+	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type CodeRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric" example:"123456"`
+}
+
+type Response struct {
+	resp.Response
+}
+
+type Confirmer interface {
+	ConfirmTOTP(ctx context.Context, userID int64, code string) error
+}
+
+// NewConfirm godoc
+// @Summary      Подтвердить подключение TOTP 2FA
+// @Description  Проверяет первый код из приложения-аутентификатора и включает
+// @Description  TOTP 2FA. Со следующего логина вторым фактором будет код из приложения.
+// @Tags         2fa
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  object{code=string}  true  "Код из приложения"
+// @Success      200  {object}  object{status=string}  "TOTP 2FA включена"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Невалидное тело запроса или ошибка валидации"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token невалиден, либо неверный код"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Подключение не начато (/auth/2fa/totp/enroll)"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "2FA уже включена"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      501  {object}  object{status=string,code=string,error=string}  "TOTP не настроен на сервере"
+// @Router       /auth/2fa/totp/confirm [post]
+func NewConfirm(
+	log *slog.Logger,
+	validate *validator.Validate,
+	confirmer Confirmer,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.totp.NewConfirm"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		var req CodeRequest
+		if !decodeCode(w, r, log, validate, &req) {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		err := confirmer.ConfirmTOTP(ctx, claims.UserID, req.Code)
+		if err != nil {
+			switch {
+			case errors.Is(err, totp.ErrInvalidCode):
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidCode, "invalid code"))
+				return
+			case errors.Is(err, totp.ErrNotEnrolled):
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeTOTPNotEnrolled, "totp enrollment not started"))
+				return
+			case errors.Is(err, auth.ErrTwoFAAlreadyEnabled):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error(resp.CodeTwoFAAlreadyEnabled, "2fa already enabled"))
+				return
+			case errors.Is(err, totp.ErrNotConfigured):
+				render.Status(r, http.StatusNotImplemented)
+				render.JSON(w, r, resp.Error(resp.CodeNotImplemented, "totp is not configured"))
+				return
+			}
+
+			log.Error("failed to confirm totp", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		log.Info("totp 2fa enabled", slog.Int64("user_id", claims.UserID))
+
+		ResponseOK(w, r)
+	}
+}
+
+// decodeCode разбирает и валидирует тело с кодом; при ошибке сам пишет ответ.
+func decodeCode(
+	w http.ResponseWriter,
+	r *http.Request,
+	log *slog.Logger,
+	validate *validator.Validate,
+	req any,
+) bool {
+	if err := render.DecodeJSON(r.Body, req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+		return false
+	}
+
+	if err := validate.Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+
+		if errors.As(err, &validateErr) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validateErr))
+			return false
+		}
+
+		log.Error("unexpected validation error type", sl.Err(err))
+
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+		return false
+	}
+
+	return true
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, Response{
+		Response: resp.OK(),
+	})
+}
@@ -0,0 +1,99 @@
+package totpHandler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth_service/internal/auth"
+	"auth_service/internal/auth/totp"
+	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Disabler interface {
+	DisableTOTP(ctx context.Context, userID int64, code string) error
+}
+
+// NewDisable godoc
+// @Summary      Отключить TOTP 2FA
+// @Description  Отключает TOTP 2FA и удаляет секрет. Подтверждается
+// @Description  действующим кодом из приложения-аутентификатора.
+// @Tags         2fa
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  object{code=string}  true  "Код из приложения"
+// @Success      200  {object}  object{status=string}  "TOTP 2FA отключена"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Невалидное тело запроса или ошибка валидации"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token невалиден, либо неверный код"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "TOTP 2FA не включена"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      501  {object}  object{status=string,code=string,error=string}  "TOTP не настроен на сервере"
+// @Router       /auth/2fa/totp/disable [post]
+func NewDisable(
+	log *slog.Logger,
+	validate *validator.Validate,
+	disabler Disabler,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.totp.NewDisable"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		var req CodeRequest
+		if !decodeCode(w, r, log, validate, &req) {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		err := disabler.DisableTOTP(ctx, claims.UserID, req.Code)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrTwoFANotEnabled):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error(resp.CodeTwoFANotEnabled, "totp 2fa is not enabled"))
+				return
+			case errors.Is(err, auth.ErrDisableConfirmation):
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidCode, "invalid code"))
+				return
+			case errors.Is(err, totp.ErrNotConfigured):
+				render.Status(r, http.StatusNotImplemented)
+				render.JSON(w, r, resp.Error(resp.CodeNotImplemented, "totp is not configured"))
+				return
+			}
+
+			log.Error("failed to disable totp", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		log.Info("totp 2fa disabled", slog.Int64("user_id", claims.UserID))
+
+		ResponseOK(w, r)
+	}
+}
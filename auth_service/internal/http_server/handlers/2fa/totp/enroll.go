@@ -0,0 +1,109 @@
+package totpHandler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth_service/internal/auth"
+	"auth_service/internal/auth/totp"
+	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/storage"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type EnrollResponse struct {
+	resp.Response
+	Secret          string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	ProvisioningURI string `json:"provisioning_uri" example:"otpauth://totp/auth_service:user@example.com?secret=...&issuer=auth_service"`
+}
+
+type Enroller interface {
+	EnrollTOTP(ctx context.Context, userID int64) (*totp.Enrollment, error)
+}
+
+// NewEnroll godoc
+// @Summary      Начать подключение TOTP 2FA
+// @Description  Генерирует секрет для приложения-аутентификатора и возвращает
+// @Description  его вместе с otpauth:// URI для QR-кода. 2FA включается только
+// @Description  после подтверждения первым кодом через /auth/2fa/totp/confirm.
+// @Description  Повторный вызов до подтверждения выдаёт новый секрет взамен старого.
+// @Tags         2fa
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  object{status=string,secret=string,provisioning_uri=string}  "Секрет выдан"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "2FA уже включена"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      501  {object}  object{status=string,code=string,error=string}  "TOTP не настроен на сервере"
+// @Router       /auth/2fa/totp/enroll [post]
+func NewEnroll(
+	log *slog.Logger,
+	enroller Enroller,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.totp.NewEnroll"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		enrollment, err := enroller.EnrollTOTP(ctx, claims.UserID)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrTwoFAAlreadyEnabled):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error(resp.CodeTwoFAAlreadyEnabled, "2fa already enabled"))
+				return
+			case errors.Is(err, totp.ErrNotConfigured):
+				render.Status(r, http.StatusNotImplemented)
+				render.JSON(w, r, resp.Error(resp.CodeNotImplemented, "totp is not configured"))
+				return
+			case errors.Is(err, storage.ErrUserNotFound):
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeUserNotFound, "user not found"))
+				return
+			}
+
+			log.Error("failed to enroll totp", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		log.Info("totp enrollment started", slog.Int64("user_id", claims.UserID))
+
+		// секрет показывается один раз — не даём его закешировать
+		w.Header().Set("Cache-Control", "no-store")
+
+		ResponseEnrollOK(w, r, enrollment)
+	}
+}
+
+func ResponseEnrollOK(w http.ResponseWriter, r *http.Request, enrollment *totp.Enrollment) {
+	render.JSON(w, r, EnrollResponse{
+		Response:        resp.OK(),
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.URI,
+	})
+}
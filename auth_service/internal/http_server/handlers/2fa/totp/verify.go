@@ -0,0 +1,168 @@
+package totpHandler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth_service/internal/auth"
+	"auth_service/internal/auth/totp"
+	resp "auth_service/internal/lib/api/response"
+	"auth_service/internal/lib/cookie"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type VerifyRequest struct {
+	SessionID string `json:"session_id" validate:"required" example:"abcDEF123..."`
+	Code      string `json:"code" validate:"required,len=6,numeric" example:"123456"`
+	// DeviceID — стабильный идентификатор установки клиента; новая сессия
+	// на том же устройстве заменяет прежнюю
+	DeviceID   string `json:"device_id,omitempty" validate:"omitempty,max=128" example:"c3f1a2e4-iphone"`
+	DeviceName string `json:"device_name,omitempty" validate:"omitempty,max=64" example:"iPhone 15"`
+	// RememberDevice — не спрашивать второй фактор на этом устройстве
+	// two_factor_auth.trusted_device_ttl
+	RememberDevice bool `json:"remember_device,omitempty" example:"true"`
+}
+
+type VerifyResponse struct {
+	resp.Response
+	AccessToken  string `json:"access_token" example:"asffhr3FJ..."`
+	RefreshToken string `json:"refresh_token,omitempty" example:"dgsadfgDJ1p3FJ..."`
+	// CSRFToken — для приложений с refresh-токеном в cookie, см. /auth/login
+	CSRFToken string `json:"csrf_token,omitempty" example:"Qm9vZjRhc2Rm..."`
+	// DeviceTrustToken — при remember_device: передавать в X-Device-Trust
+	// на /auth/login. Приложениям с refresh-токеном в cookie приходит cookie.
+	DeviceTrustToken string `json:"device_trust_token,omitempty" example:"dHJ1c3RlZC1kZXZpY2U..."`
+}
+
+type LoginVerifier interface {
+	VerifyTOTPLogin(ctx context.Context, sessionID, code string, device *models.Device, rememberDevice bool) (*auth.LoginResult, error)
+}
+
+// NewVerify godoc
+// @Summary      Подтверждение TOTP 2FA при логине
+// @Description  Завершает второй фактор аутентификации: проверяет код из
+// @Description  приложения-аутентификатора в связке с session_id, полученным на
+// @Description  этапе /auth/login (two_factor_method=totp), и при успехе выдаёт
+// @Description  access/refresh токены. Один и тот же код нельзя использовать дважды.
+// @Tags         2fa
+// @Accept       json
+// @Produce      json
+// @Param        request  body  object{session_id=string,code=string,device_id=string,device_name=string,remember_device=bool}  true  "Данные для подтверждения"
+// @Success      200  {object}  object{status=string,access_token=string,refresh_token=string,csrf_token=string,device_trust_token=string}  "2FA подтверждена, выданы токены"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Невалидное тело запроса или ошибка валидации"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Код неверен или уже использован, либо сессия истекла"
+// @Failure      403  {object}  object{status=string,code=string,error=string}  "Аккаунт заблокирован администратором или требуется смена пароля"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "Достигнут лимит активных сессий"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      501  {object}  object{status=string,code=string,error=string}  "TOTP не настроен на сервере"
+// @Router       /auth/2fa/totp/verify [post]
+func NewVerify(
+	log *slog.Logger,
+	validate *validator.Validate,
+	verifier LoginVerifier,
+	cookies *cookie.Jar,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.totp.NewVerify"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req VerifyRequest
+		if !decodeCode(w, r, log, validate, &req) {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		res, err := verifier.VerifyTOTPLogin(ctx, req.SessionID, req.Code, models.NewDevice(req.DeviceID, req.DeviceName), req.RememberDevice)
+		if err != nil {
+			switch {
+			case errors.Is(err, totp.ErrInvalidCode),
+				errors.Is(err, storage.ErrPendingSessionNotFound):
+				log.Warn("totp verification failed", sl.Err(err))
+
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidCode, "invalid code or expired session"))
+
+				return
+			case errors.Is(err, auth.ErrAccountDisabled):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodeAccountDisabled, "Account disabled"))
+
+				return
+			case errors.Is(err, auth.ErrAccountSuspended):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodeAccountSuspended, "Account suspended"))
+
+				return
+			case errors.Is(err, auth.ErrAccountBanned):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodeAccountBanned, "Account banned"))
+
+				return
+			case errors.Is(err, auth.ErrPasswordResetRequired):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodePasswordResetRequired, "Password reset required"))
+
+				return
+			case errors.Is(err, auth.ErrSessionLimit):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error(resp.CodeSessionLimit, "Active session limit reached"))
+
+				return
+			case errors.Is(err, totp.ErrNotConfigured):
+				render.Status(r, http.StatusNotImplemented)
+				render.JSON(w, r, resp.Error(resp.CodeNotImplemented, "totp is not configured"))
+
+				return
+			}
+
+			log.Error("totp verification: internal error", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		refreshToken, csrfToken, err := cookies.Deliver(ctx, w, res.RefreshToken, res.RefreshExpiresAt, res.RefreshCookie)
+		if err != nil {
+			log.Error("failed to set refresh cookie", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		log.Info("totp verified, tokens issued")
+
+		trustToken := cookies.DeliverDeviceTrust(w, res.TrustToken, res.TrustExpiresAt, res.RefreshCookie)
+
+		ResponseVerifyOK(w, r, res.AccessToken, refreshToken, csrfToken, trustToken)
+	}
+}
+
+func ResponseVerifyOK(w http.ResponseWriter, r *http.Request, accessToken, refreshToken, csrfToken, trustToken string) {
+	render.JSON(w, r, VerifyResponse{
+		Response:         resp.OK(),
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		CSRFToken:        csrfToken,
+		DeviceTrustToken: trustToken,
+	})
+}
@@ -10,7 +10,9 @@ import (
 	"auth_service/internal/auth"
 	twoFactorAuth "auth_service/internal/auth/2fa"
 	resp "auth_service/internal/lib/api/response"
+	"auth_service/internal/lib/cookie"
 	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
 	"auth_service/internal/storage"
 
 	"github.com/go-chi/chi/v5/middleware"
@@ -21,12 +23,28 @@ import (
 type Request struct {
 	SessionID string `json:"session_id" validate:"required" example:"abcDEF123..."`
 	Token     string `json:"token" validate:"required" example:"fkajeDJ1p3FJ..."`
+	// DeviceID — стабильный идентификатор установки клиента; новая сессия
+	// на том же устройстве заменяет прежнюю
+	DeviceID   string `json:"device_id,omitempty" validate:"omitempty,max=128" example:"c3f1a2e4-iphone"`
+	DeviceName string `json:"device_name,omitempty" validate:"omitempty,max=64" example:"iPhone 15"`
+	// RememberDevice — не спрашивать второй фактор на этом устройстве
+	// two_factor_auth.trusted_device_ttl
+	RememberDevice bool `json:"remember_device,omitempty" example:"true"`
 }
 
 type Response struct {
 	resp.Response
 	AccessToken  string `json:"access_token" example:"asffhr3FJ..."`
-	RefreshToken string `json:"refresh_token" example:"dgsadfgDJ1p3FJ..."`
+	RefreshToken string `json:"refresh_token,omitempty" example:"dgsadfgDJ1p3FJ..."`
+	// CSRFToken — для приложений с refresh-токеном в cookie, см. /auth/login
+	CSRFToken string `json:"csrf_token,omitempty" example:"Qm9vZjRhc2Rm..."`
+	// DeviceTrustToken — при remember_device: передавать в X-Device-Trust
+	// на /auth/login. Приложениям с refresh-токеном в cookie приходит cookie.
+	DeviceTrustToken string `json:"device_trust_token,omitempty" example:"dHJ1c3RlZC1kZXZpY2U..."`
+}
+
+type MagicLinkVerifier interface {
+	VerifyMagicLink(ctx context.Context, sessionID, rawToken string, device *models.Device, rememberDevice bool) (*auth.LoginResult, error)
 }
 
 // New godoc
@@ -35,19 +53,23 @@ type Response struct {
 // @Description  письма в связке с session_id, полученным на этапе /auth/login,
 // @Description  и при успехе выдаёт access/refresh токены. Токен одноразовый —
 // @Description  повторное использование того же токена или session_id отклоняется.
+// @Description  device_id и device_name, как и в /auth/login, привязывают сессию к устройству.
 // @Tags         2fa
 // @Accept       json
 // @Produce      json
-// @Param        request  body  object{session_id=string,token=string}  true  "Данные для подтверждения"
-// @Success      200  {object}  object{status=string,access_token=string,refresh_token=string}  "2FA подтверждена, выданы токены"
-// @Failure      400  {object}  object{status=string,error=string}  "Невалидное тело запроса или ошибка валидации"
-// @Failure      401  {object}  object{status=string,error=string}  "Токен невалиден, истёк, уже использован, либо сессия истекла"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка сервера"
+// @Param        request  body  object{session_id=string,token=string,device_id=string,device_name=string,remember_device=bool}  true  "Данные для подтверждения"
+// @Success      200  {object}  object{status=string,access_token=string,refresh_token=string,csrf_token=string,device_trust_token=string}  "2FA подтверждена, выданы токены"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Невалидное тело запроса или ошибка валидации"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Токен невалиден, истёк, уже использован, либо сессия истекла"
+// @Failure      403  {object}  object{status=string,code=string,error=string}  "Аккаунт заблокирован администратором или требуется смена пароля"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "Достигнут лимит активных сессий"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
 // @Router       /auth/2fa/magic-link/verify [post]
 func New(
 	log *slog.Logger,
 	validate *validator.Validate,
-	authMiddleware *auth.Auth,
+	verifier MagicLinkVerifier,
+	cookies *cookie.Jar,
 	handlerTimeout time.Duration,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -64,7 +86,7 @@ func New(
 			log.Error("failed to decode request body", sl.Err(err))
 
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("Failed to decode request"))
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
 
 			return
 		}
@@ -82,7 +104,7 @@ func New(
 			log.Error("unexpected validation error type", sl.Err(err))
 
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
 
 			return
 		}
@@ -90,15 +112,41 @@ func New(
 		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
 		defer cancel()
 
-		accessToken, refreshToken, err := authMiddleware.VerifyMagicLink(ctx, req.SessionID, req.Token)
+		res, err := verifier.VerifyMagicLink(ctx, req.SessionID, req.Token, models.NewDevice(req.DeviceID, req.DeviceName), req.RememberDevice)
 		if err != nil {
 			switch {
 			case errors.Is(err, twoFactorAuth.ErrMagicLinkVerificationFailed),
+				errors.Is(err, twoFactorAuth.ErrActionMismatch),
 				errors.Is(err, storage.ErrPendingSessionNotFound):
 				log.Warn("magic link verification failed", sl.Err(err))
 
 				render.Status(r, http.StatusUnauthorized)
-				render.JSON(w, r, resp.Error("invalid or expired confirmation"))
+				render.JSON(w, r, resp.Error(resp.CodeInvalidConfirmation, "invalid or expired confirmation"))
+
+				return
+			case errors.Is(err, auth.ErrAccountDisabled):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodeAccountDisabled, "Account disabled"))
+
+				return
+			case errors.Is(err, auth.ErrAccountSuspended):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodeAccountSuspended, "Account suspended"))
+
+				return
+			case errors.Is(err, auth.ErrAccountBanned):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodeAccountBanned, "Account banned"))
+
+				return
+			case errors.Is(err, auth.ErrPasswordResetRequired):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodePasswordResetRequired, "Password reset required"))
+
+				return
+			case errors.Is(err, auth.ErrSessionLimit):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error(resp.CodeSessionLimit, "Active session limit reached"))
 
 				return
 			}
@@ -106,7 +154,17 @@ func New(
 			log.Error("magic link verification: internal error", sl.Err(err))
 
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("Internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		refreshToken, csrfToken, err := cookies.Deliver(ctx, w, res.RefreshToken, res.RefreshExpiresAt, res.RefreshCookie)
+		if err != nil {
+			log.Error("failed to set refresh cookie", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
 
 			return
 		}
@@ -115,14 +173,18 @@ func New(
 
 		// ? redirect
 
-		ResponseOK(w, r, accessToken, refreshToken)
+		trustToken := cookies.DeliverDeviceTrust(w, res.TrustToken, res.TrustExpiresAt, res.RefreshCookie)
+
+		ResponseOK(w, r, res.AccessToken, refreshToken, csrfToken, trustToken)
 	}
 }
 
-func ResponseOK(w http.ResponseWriter, r *http.Request, accessToken, refreshToken string) {
+func ResponseOK(w http.ResponseWriter, r *http.Request, accessToken, refreshToken, csrfToken, trustToken string) {
 	render.JSON(w, r, Response{
-		Response:     resp.OK(),
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+		Response:         resp.OK(),
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		CSRFToken:        csrfToken,
+		DeviceTrustToken: trustToken,
 	})
 }
@@ -0,0 +1,262 @@
+package changeEmail
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth_service/internal/auth"
+	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/lib/mailer"
+	"auth_service/internal/lib/verification"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type EmailChanger interface {
+	RequestEmailChange(ctx context.Context, userID int64, newEmail, password string) (*auth.EmailChangeRequest, error)
+	ConfirmEmailChange(ctx context.Context, rawToken string) (*models.User, error)
+}
+
+type Request struct {
+	NewEmail string `json:"new_email" validate:"required,email" example:"new@domain.com"`
+	// Password — текущий пароль; не нужен, если пароль у аккаунта не задан (вход только через OAuth).
+	Password string `json:"password,omitempty" example:"SecurePass123!"`
+}
+
+type RequestResponse struct {
+	resp.Response
+	ExpiresAt time.Time `json:"expires_at" example:"2026-07-24T12:30:00Z"`
+}
+
+type Response struct {
+	resp.Response
+}
+
+// NewRequest godoc
+// @Summary      Запросить смену email
+// @Description  ## Описание
+// @Description  Отправляет ссылку подтверждения на новый адрес и уведомление о запросе на
+// @Description  текущий. Email меняется только после перехода по ссылке из письма.
+// @Description
+// @Description  ### Особенности:
+// @Description  - Требует текущий пароль, если он у аккаунта задан
+// @Description  - Действует последний запрос: ссылки из предыдущих писем перестают работать
+// @Description  - Ссылка живёт tokens.email_change_token_ttl
+// @Tags         account
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "Новый email и текущий пароль"
+// @Success      202  {object}  RequestResponse  "Ссылка подтверждения отправлена на новый адрес"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Невалидный запрос или новый email совпадает с текущим"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует/невалиден или неверный пароль"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "Email уже занят другим аккаунтом"
+// @Failure      410  {object}  object{status=string,code=string,error=string}  "Аккаунт удалён"
+// @Failure      429  {object}  object{status=string,code=string,error=string}  "Превышен лимит запросов"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /account/email [post]
+func NewRequest(
+	log *slog.Logger,
+	validate *validator.Validate,
+	changer EmailChanger,
+	msgSender mailer.Publisher,
+	address string,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.account.change_email.NewRequest"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		var req Request
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+
+			if errors.As(err, &validateErr) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+
+				return
+			}
+
+			log.Error("unexpected validation error type", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		change, err := changer.RequestEmailChange(ctx, claims.UserID, req.NewEmail, req.Password)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrInvalidCredentials):
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidCredentials, "invalid password"))
+			case errors.Is(err, auth.ErrSameEmail):
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error(resp.CodeSameEmail, "new email is the same as the current one"))
+			case errors.Is(err, auth.ErrEmailTaken):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error(resp.CodeEmailTaken, "email already taken"))
+			case errors.Is(err, auth.ErrAccountDeleted), errors.Is(err, auth.ErrUserNotFound):
+				render.Status(r, http.StatusGone)
+				render.JSON(w, r, resp.Error(resp.CodeAccountDeleted, "Account deleted"))
+			default:
+				log.Error("failed to request email change", sl.Err(err))
+
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+			}
+
+			return
+		}
+
+		if err := mailer.SendEmailChangeConfirmation(ctx, msgSender, change.Token, address, change.NewEmail); err != nil {
+			log.Error("failed to send email change confirmation", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		// уведомление старого адреса — вторичное: запрос уже принят
+		if err := mailer.SendEmailChangeNotice(ctx, msgSender, change.OldEmail); err != nil {
+			log.Error("failed to send email change notice", sl.Err(err))
+		}
+
+		log.Info("email change requested", slog.Int64("user_id", claims.UserID))
+
+		ResponseRequested(w, r, change.ExpiresAt)
+	}
+}
+
+// NewConfirm godoc
+// @Summary      Подтвердить смену email
+// @Description  ## Описание
+// @Description  Переход по ссылке из письма, отправленного на новый адрес. Email меняется
+// @Description  атомарно; новый адрес требует верификации — на него сразу уходит письмо со
+// @Description  ссылкой /auth/verify, до подтверждения вход закрыт.
+// @Description
+// @Description  Токен одноразовый. Если email изменился после запроса, токен отклоняется.
+// @Tags         account
+// @Produce      json
+// @Param        token  query  string  true  "Токен подтверждения из письма"
+// @Success      200  {object}  Response  "Email изменён"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Токен отсутствует в URL"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Токен невалиден, истёк или уже использован"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "Email успели занять другим аккаунтом"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /account/email/confirm [get]
+func NewConfirm(
+	log *slog.Logger,
+	changer EmailChanger,
+	msgSender mailer.Publisher,
+	codes verification.CodeIssuer,
+	verificationTokenTTL time.Duration,
+	verificationTokenSecret string,
+	address string,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.account.change_email.NewConfirm"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "missing token"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		user, err := changer.ConfirmEmailChange(ctx, token)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrInvalidEmailChangeToken):
+				log.Warn("invalid email change token")
+
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidToken, "invalid or expired token"))
+			case errors.Is(err, auth.ErrEmailTaken):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error(resp.CodeEmailTaken, "email already taken"))
+			default:
+				log.Error("failed to confirm email change", sl.Err(err))
+
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+			}
+
+			return
+		}
+
+		// email уже сменён; если письмо не ушло, его можно запросить через /auth/verify/resend
+		if err := verification.VerifyUserEmail(
+			ctx,
+			log,
+			msgSender,
+			codes,
+			verificationTokenTTL,
+			verificationTokenSecret,
+			user.ID,
+			address,
+			user.Email,
+		); err != nil {
+			log.Error("failed to send verification email", sl.Err(err))
+		}
+
+		ResponseOK(w, r)
+	}
+}
+
+func ResponseRequested(w http.ResponseWriter, r *http.Request, expiresAt time.Time) {
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, RequestResponse{
+		Response:  resp.OK(),
+		ExpiresAt: expiresAt,
+	})
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, Response{
+		Response: resp.OK(),
+	})
+}
@@ -11,6 +11,7 @@ import (
 	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
 	resp "auth_service/internal/lib/api/response"
 	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/lib/mailer"
 	"auth_service/internal/storage"
 
 	"github.com/go-chi/chi/v5/middleware"
@@ -28,30 +29,39 @@ type Response struct {
 	resp.Response
 }
 
+type AccountDeleter interface {
+	DeleteAccount(ctx context.Context, userID int64, password string, sessionID, rawToken string) (*auth.AccountDeletion, error)
+}
+
 // New godoc
 // @Summary      Удалить аккаунт
-// @Description  Помечает аккаунт как удалённый (soft delete, grace period 7
-// @Description  дней). Требует подтверждения: паролем (если он установлен)
+// @Description  Помечает аккаунт как удалённый (soft delete, status =
+// @Description  pending_deletion). Требует подтверждения: паролем (если он установлен)
 // @Description  либо magic-link кодом, полученным через
 // @Description  /account/delete/request-confirmation (для oauth-only
-// @Description  пользователей без пароля). Все refresh-токены и активные
-// @Description  сессии немедленно отзываются. Идемпотентно — повторный вызов
-// @Description  на уже удалённый аккаунт не является ошибкой.
+// @Description  пользователей без пароля). Все refresh- и access-токены
+// @Description  немедленно отзываются, на email уходит письмо об удалении.
+// @Description  Аккаунт можно восстановить в течение retention.deleted_accounts
+// @Description  (по умолчанию 7 дней), затем он удаляется безвозвратно.
+// @Description  Идемпотентно — повторный вызов на уже удалённый аккаунт не
+// @Description  является ошибкой. Доступен также как DELETE /me.
 // @Tags         account
 // @Security     BearerAuth
 // @Accept       json
 // @Produce      json
 // @Param        request  body      Request  true  "Пароль ИЛИ session_id+code"
 // @Success      204  "Аккаунт удалён"
-// @Failure      400  {object}  object{status=string,error=string}  "Невалидный запрос"
-// @Failure      401  {object}  object{status=string,error=string}  "Access token отсутствует/невалиден, либо неверный пароль/код подтверждения"
-// @Failure      429  {object}  object{status=string,error=string}  "Превышен лимит запросов"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Невалидный запрос"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует/невалиден, либо неверный пароль/код подтверждения"
+// @Failure      429  {object}  object{status=string,code=string,error=string}  "Превышен лимит запросов"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
 // @Router       /account [delete]
+// @Router       /me [delete]
 func New(
 	log *slog.Logger,
 	validate *validator.Validate,
-	authService *auth.Auth,
+	deleter AccountDeleter,
+	msgSender mailer.Publisher,
 	handlerTimeout time.Duration,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -65,7 +75,7 @@ func New(
 		claims, ok := claimsParser.ClaimsFromContext(r.Context())
 		if !ok {
 			render.Status(r, http.StatusUnauthorized)
-			render.JSON(w, r, resp.Error("invalid or expired access token"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
 
 			return
 		}
@@ -77,7 +87,7 @@ func New(
 			log.Error("Failed to decode request body", sl.Err(err))
 
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("Failed to decode request"))
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
 
 			return
 		}
@@ -96,7 +106,7 @@ func New(
 
 			log.Error("unexpected validation error type", sl.Err(err))
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
 
 			return
 		}
@@ -108,7 +118,7 @@ func New(
 		hasMagicLink := req.SessionID != "" && req.Token != ""
 		if hasPassword == hasMagicLink { // оба заполнены или оба пустые
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("provide either password or session_id+code, not both or neither"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "provide either password or session_id+code, not both or neither"))
 
 			return
 		}
@@ -116,7 +126,7 @@ func New(
 		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
 		defer cancel()
 
-		err = authService.DeleteAccount(
+		deletion, err := deleter.DeleteAccount(
 			ctx,
 			claims.UserID,
 			req.Password,
@@ -128,22 +138,36 @@ func New(
 			case errors.Is(err, auth.ErrDeleteConfirmation):
 				log.Warn("delete account: confirmation failed", slog.Int64("user_id", claims.UserID))
 				render.Status(r, http.StatusUnauthorized)
-				render.JSON(w, r, resp.Error("invalid confirmation"))
+				render.JSON(w, r, resp.Error(resp.CodeInvalidConfirmation, "invalid confirmation"))
 				return
 			case errors.Is(err, storage.ErrUserNotFound):
 				log.Warn("user not found", slog.Int64("user_id", claims.UserID))
 				render.Status(r, http.StatusNotFound)
-				render.JSON(w, r, resp.Error("user not found"))
+				render.JSON(w, r, resp.Error(resp.CodeUserNotFound, "user not found"))
 				return
 			default:
 				log.Error("failed to delete account", sl.Err(err), slog.Int64("user_id", claims.UserID))
 				render.Status(r, http.StatusInternalServerError)
-				render.JSON(w, r, resp.Error("Internal error"))
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
 				return
 			}
 		}
 
-		log.Info("account deleted", slog.Int64("user_id", claims.UserID))
+		if deletion.AlreadyDeleted {
+			render.Status(r, http.StatusNoContent)
+			ResponseOK(w, r)
+			return
+		}
+
+		log.Info("account deleted",
+			slog.Int64("user_id", claims.UserID),
+			slog.Time("purge_at", deletion.PurgeAt),
+		)
+
+		// аккаунт уже удалён — письмо информационное, его сбой только логируется
+		if err := mailer.SendAccountDeletedEmail(ctx, msgSender, deletion.Email); err != nil {
+			log.Error("failed to send account deletion email", sl.Err(err))
+		}
 
 		render.Status(r, http.StatusNoContent)
 		ResponseOK(w, r)
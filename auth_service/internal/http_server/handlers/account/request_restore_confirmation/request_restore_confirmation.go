@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"time"
 
-	"auth_service/internal/auth"
 	resp "auth_service/internal/lib/api/response"
 	sl "auth_service/internal/lib/logger"
 
@@ -25,6 +24,10 @@ type Response struct {
 	SessionID string `json:"session_id" example:"abcDEF123..."`
 }
 
+type RestoreConfirmationRequester interface {
+	RequestRestoreConfirmation(ctx context.Context, email string, appID int32, pendingSessionTTL time.Duration) (string, error)
+}
+
 // NewRequestConfirmation godoc
 // @Summary      Запросить подтверждение восстановления аккаунта через magic link
 // @Description  Отправляет magic-link код на email указанного (soft-deleted)
@@ -36,14 +39,14 @@ type Response struct {
 // @Produce      json
 // @Param        request  body  Request  true  "Email и app_id"
 // @Success      200  {object}  object{status=string,session_id=string}  "Код отправлен на email"
-// @Failure      400  {object}  object{status=string,error=string}  "Невалидный запрос"
-// @Failure      429  {object}  object{status=string,error=string}  "Превышен лимит запросов"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Невалидный запрос"
+// @Failure      429  {object}  object{status=string,code=string,error=string}  "Превышен лимит запросов"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
 // @Router       /account/restore/request-confirmation [post]
 func New(
 	log *slog.Logger,
 	validate *validator.Validate,
-	authService *auth.Auth,
+	requester RestoreConfirmationRequester,
 	handlerTimeout time.Duration,
 	pendingSessionTTL time.Duration,
 ) http.HandlerFunc {
@@ -58,19 +61,19 @@ func New(
 		var req Request
 		if err := render.DecodeJSON(r.Body, &req); err != nil {
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("invalid request"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid request"))
 			return
 		}
 		if err := validate.Struct(req); err != nil {
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("invalid request"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid request"))
 			return
 		}
 
 		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
 		defer cancel()
 
-		sessionID, err := authService.RequestRestoreConfirmation(ctx, req.Email, req.AppID, pendingSessionTTL)
+		sessionID, err := requester.RequestRestoreConfirmation(ctx, req.Email, req.AppID, pendingSessionTTL)
 		if err != nil {
 			// Nameренно не различаем "не найден"/"не удалён" на HTTP-уровне —
 			// см. обсуждение enumeration risk. Один и тот же ответ клиенту
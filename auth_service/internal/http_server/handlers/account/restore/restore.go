@@ -27,9 +27,13 @@ type Request struct {
 	Token     string `json:"token,omitempty" example:"abcDEF123..."`
 }
 
+type AccountRestorer interface {
+	RestoreAccount(ctx context.Context, email, password string, sessionID, rawToken string) error
+}
+
 // New godoc
 // @Summary      Восстановить удалённый аккаунт
-// @Description  Отменяет soft-delete, если grace period (7 дней) ещё не
+// @Description  Отменяет soft-delete, если grace period (retention.deleted_accounts) ещё не
 // @Description  истёк. Требует подтверждения: паролем (если он установлен)
 // @Description  либо magic-link кодом, полученным через
 // @Description  /account/restore/request-confirmation (для oauth-only
@@ -39,15 +43,15 @@ type Request struct {
 // @Produce      json
 // @Param        request  body  Request  true  "Email + (пароль ИЛИ session_id+code)"
 // @Success      204  "Аккаунт восстановлен"
-// @Failure      400  {object}  object{status=string,error=string}  "Невалидный запрос"
-// @Failure      401  {object}  object{status=string,error=string}  "Неверный пароль или код подтверждения, аккаунт не найден, не был удалён или grace period истёк"
-// @Failure      429  {object}  object{status=string,error=string}  "Превышен лимит запросов"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Невалидный запрос"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверный пароль или код подтверждения, аккаунт не найден, не был удалён или grace period истёк"
+// @Failure      429  {object}  object{status=string,code=string,error=string}  "Превышен лимит запросов"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
 // @Router       /account/restore [post]
 func New(
 	log *slog.Logger,
 	validate *validator.Validate,
-	authService *auth.Auth,
+	restorer AccountRestorer,
 	handlerTimeout time.Duration,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -61,12 +65,12 @@ func New(
 		var req Request
 		if err := render.DecodeJSON(r.Body, &req); err != nil {
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("invalid request"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid request"))
 			return
 		}
 		if err := validate.Struct(req); err != nil {
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("invalid request"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid request"))
 			return
 		}
 
@@ -74,14 +78,14 @@ func New(
 		hasMagicLink := req.SessionID != "" && req.Token != ""
 		if hasPassword == hasMagicLink {
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("provide either password or session_id+code, not both or neither"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "provide either password or session_id+code, not both or neither"))
 			return
 		}
 
 		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
 		defer cancel()
 
-		err := authService.RestoreAccount(ctx, req.Email, req.Password, req.SessionID, req.Token)
+		err := restorer.RestoreAccount(ctx, req.Email, req.Password, req.SessionID, req.Token)
 		if err != nil {
 			switch {
 			case errors.Is(err, auth.ErrRestoreConfirmation),
@@ -89,12 +93,12 @@ func New(
 				errors.Is(err, storage.ErrNothingToRestore):
 				log.Info("restore rejected", sl.Err(err), slog.String("email", req.Email))
 				render.Status(r, http.StatusUnauthorized)
-				render.JSON(w, r, resp.Error("invalid confirmation"))
+				render.JSON(w, r, resp.Error(resp.CodeInvalidConfirmation, "invalid confirmation"))
 				return
 			default:
 				log.Error("failed to restore account", sl.Err(err), slog.String("email", req.Email))
 				render.Status(r, http.StatusInternalServerError)
-				render.JSON(w, r, resp.Error("Internal error"))
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
 				return
 			}
 		}
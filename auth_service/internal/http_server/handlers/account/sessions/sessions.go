@@ -0,0 +1,113 @@
+package sessions
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type SessionLister interface {
+	Sessions(ctx context.Context, userID int64) ([]models.Session, error)
+}
+
+type Session struct {
+	ID         uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	AppID      int32     `json:"app_id" example:"1"`
+	DeviceID   string    `json:"device_id,omitempty" example:"3f2b7c1e-ios"`
+	DeviceName string    `json:"device_name,omitempty" example:"iPhone 15"`
+	IP         string    `json:"ip,omitempty" example:"203.0.113.7"`
+	UserAgent  string    `json:"user_agent,omitempty" example:"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X)"`
+	CreatedAt  time.Time `json:"created_at" example:"2026-07-24T12:00:00Z"`
+	ExpiresAt  time.Time `json:"expires_at" example:"2026-08-24T12:00:00Z"`
+}
+
+type Response struct {
+	resp.Response
+	Sessions []Session `json:"sessions"`
+}
+
+// New godoc
+// @Summary      Список активных сессий
+// @Description  Возвращает активные refresh-сессии текущего пользователя.
+// @Description  Для сессий, открытых с device_id, показывается имя устройства;
+// @Description  IP и User-Agent — логина, открывшего сессию.
+// @Tags         account
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  Response  "Список сессий"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /account/sessions [get]
+func New(
+	log *slog.Logger,
+	lister SessionLister,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.account.sessions.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		sessions, err := lister.Sessions(ctx, claims.UserID)
+		if err != nil {
+			log.Error("failed to list sessions", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
+
+			return
+		}
+
+		ResponseOK(w, r, toSessions(sessions))
+	}
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request, sessions []Session) {
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, Response{
+		Response: resp.OK(),
+		Sessions: sessions,
+	})
+}
+
+func toSessions(sessions []models.Session) []Session {
+	result := make([]Session, 0, len(sessions))
+
+	for _, s := range sessions {
+		result = append(result, Session{
+			ID:         s.ID,
+			AppID:      s.AppID,
+			DeviceID:   s.DeviceID,
+			DeviceName: s.DeviceName,
+			IP:         s.IP,
+			UserAgent:  s.UserAgent,
+			CreatedAt:  s.CreatedAt,
+			ExpiresAt:  s.ExpiresAt,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,331 @@
+package apiKeys
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth_service/internal/auth/apikeys"
+	"auth_service/internal/http_server/handlers/admin"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type KeyManager interface {
+	Create(
+		ctx context.Context,
+		appID int32,
+		name string,
+		scopes []string,
+		expiresAt *time.Time,
+		createdBy string,
+	) (*models.APIKey, string, error)
+	List(ctx context.Context, appID int32) ([]models.APIKey, error)
+	Revoke(ctx context.Context, appID int32, id uuid.UUID) error
+}
+
+type Request struct {
+	Name string `json:"name" validate:"required,max=128" example:"nightly-export"`
+	// Scopes — подмножество allowed_scopes приложения
+	Scopes []string `json:"scopes" validate:"dive,required,max=128" example:"reports:read"`
+	// ExpiresAt — без значения ключ бессрочный
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2027-01-01T00:00:00Z"`
+}
+
+type APIKey struct {
+	ID         string     `json:"id" example:"5f0c3a4e-8d1b-4f7a-9c2e-1b3d5e7f9a0b"`
+	Name       string     `json:"name" example:"nightly-export"`
+	Scopes     []string   `json:"scopes" example:"reports:read"`
+	CreatedBy  string     `json:"created_by" example:"admin:root"`
+	CreatedAt  time.Time  `json:"created_at" example:"2026-10-16T12:00:00Z"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" example:"2027-01-01T00:00:00Z"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" example:"2026-10-16T13:00:00Z"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type CreateResponse struct {
+	resp.Response
+	APIKey APIKey `json:"api_key"`
+	// Key — сам ключ; показывается только в этом ответе
+	Key string `json:"key" example:"ak_5f0c3a4e-8d1b-4f7a-9c2e-1b3d5e7f9a0b.Xq3v..."`
+}
+
+type ListResponse struct {
+	resp.Response
+	APIKeys []APIKey `json:"api_keys"`
+}
+
+// NewCreate godoc
+// @Summary      Выпуск API-ключа приложения
+// @Description  ## Описание
+// @Description  Выпускает долгоживущий ключ для машинного клиента (batch-задачи), который не может
+// @Description  пройти интерактивный логин. Ключ передаётся в заголовке `X-API-Key`.
+// @Description
+// @Description  ### Особенности:
+// @Description  - Ключ возвращается один раз — сохраняется только его хеш
+// @Description  - scopes — подмножество allowed_scopes приложения
+// @Description  - Без expires_at ключ бессрочный; отозвать его можно в любой момент
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id       path  int      true  "ID приложения"
+// @Param        request  body  Request  true  "Ключ"
+// @Success      201  {object}  CreateResponse  "Ключ выпущен"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный ID приложения, тело запроса или scope"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Приложение не найдено"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /admin/apps/{id}/api-keys [post]
+func NewCreate(
+	log *slog.Logger,
+	validate *validator.Validate,
+	manager KeyManager,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.api_keys.NewCreate"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		appID, ok := admin.AppID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidApp, "invalid app id"))
+
+			return
+		}
+
+		var req Request
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+
+			if errors.As(err, &validateErr) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+
+				return
+			}
+
+			log.Error("unexpected validation error type", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "expires_at must be in the future"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		createdBy := admin.AuditEvent(r, "").Actor
+
+		key, rawKey, err := manager.Create(ctx, appID, req.Name, req.Scopes, req.ExpiresAt, createdBy)
+		if err != nil {
+			switch {
+			case errors.Is(err, apikeys.ErrInvalidScope):
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidScope, "scope is not allowed for this app"))
+			case errors.Is(err, apikeys.ErrAppNotFound):
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeAppNotFound, "app not found"))
+			default:
+				log.Error("failed to create api key", sl.Err(err))
+
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+			}
+
+			return
+		}
+
+		ResponseCreated(w, r, key, rawKey)
+	}
+}
+
+// NewList godoc
+// @Summary      API-ключи приложения
+// @Description  Возвращает ключи приложения, включая отозванные и истёкшие, новые первыми.
+// @Description  Сами ключи не возвращаются.
+// @Tags         admin
+// @Produce      json
+// @Param        id  path  int  true  "ID приложения"
+// @Success      200  {object}  ListResponse  "Ключи приложения"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный ID приложения"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /admin/apps/{id}/api-keys [get]
+func NewList(
+	log *slog.Logger,
+	manager KeyManager,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.api_keys.NewList"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		appID, ok := admin.AppID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidApp, "invalid app id"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		keys, err := manager.List(ctx, appID)
+		if err != nil {
+			log.Error("failed to list api keys", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		ResponseList(w, r, ToAPIKeys(keys))
+	}
+}
+
+// NewRevoke godoc
+// @Summary      Отзыв API-ключа
+// @Description  Отзывает ключ приложения. Запросы с ним отклоняются сразу.
+// @Tags         admin
+// @Produce      json
+// @Param        id      path  int     true  "ID приложения"
+// @Param        key_id  path  string  true  "ID ключа"
+// @Success      200  {object}  object{status=string}  "Ключ отозван"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный ID приложения или ключа"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Ключ не найден или уже отозван"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /admin/apps/{id}/api-keys/{key_id} [delete]
+func NewRevoke(
+	log *slog.Logger,
+	manager KeyManager,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.api_keys.NewRevoke"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		appID, ok := admin.AppID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidApp, "invalid app id"))
+
+			return
+		}
+
+		keyID, err := uuid.Parse(chi.URLParam(r, "key_id"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid api key id"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		if err := manager.Revoke(ctx, appID, keyID); err != nil {
+			if errors.Is(err, apikeys.ErrNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeAPIKeyNotFound, "api key not found"))
+
+				return
+			}
+
+			log.Error("failed to revoke api key", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+func ResponseCreated(w http.ResponseWriter, r *http.Request, key *models.APIKey, rawKey string) {
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, CreateResponse{
+		Response: resp.OK(),
+		APIKey:   toAPIKey(*key),
+		Key:      rawKey,
+	})
+}
+
+func ResponseList(w http.ResponseWriter, r *http.Request, keys []APIKey) {
+	render.JSON(w, r, ListResponse{
+		Response: resp.OK(),
+		APIKeys:  keys,
+	})
+}
+
+// ToAPIKeys — представление ключей в ответах админских эндпоинтов.
+func ToAPIKeys(keys []models.APIKey) []APIKey {
+	result := make([]APIKey, 0, len(keys))
+
+	for _, key := range keys {
+		result = append(result, toAPIKey(key))
+	}
+
+	return result
+}
+
+func toAPIKey(key models.APIKey) APIKey {
+	scopes := key.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	return APIKey{
+		ID:         key.ID.String(),
+		Name:       key.Name,
+		Scopes:     scopes,
+		CreatedBy:  key.CreatedBy,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
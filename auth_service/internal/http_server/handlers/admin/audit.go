@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"auth_service/internal/lib/clientinfo"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Request — общее тело админских remediation-эндпоинтов. Необязательно.
+type Request struct {
+	// Reason — причина для журнала аудита (номер обращения и т.п.)
+	Reason string `json:"reason" validate:"max=500" example:"ticket #4821: подтверждён захват аккаунта"`
+}
+
+// UserID достаёт {id} из пути /admin/users/{id}/...
+func UserID(r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// AppID достаёт {id} из пути /admin/apps/{id}/...
+func AppID(r *http.Request) (int32, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// AuditEvent собирает событие аудита из запроса администратора. Action и
+// UserID заполняет сервисный слой.
+func AuditEvent(r *http.Request, reason string) models.AuditEvent {
+	// adminAuth уже проверил basic auth — логин администратора и есть actor
+	actor, _, _ := r.BasicAuth()
+
+	info := clientinfo.FromContext(r.Context())
+
+	event := models.AuditEvent{
+		Actor:     "admin:" + actor,
+		IP:        info.IP,
+		UserAgent: info.UserAgent,
+	}
+	if reason != "" {
+		event.Metadata = map[string]any{"reason": reason}
+	}
+
+	return event
+}
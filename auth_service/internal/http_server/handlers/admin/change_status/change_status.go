@@ -0,0 +1,155 @@
+package changeStatus
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth_service/internal/auth"
+	"auth_service/internal/http_server/handlers/admin"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	Status models.AccountStatus `json:"status" validate:"required,oneof=active disabled suspended banned" example:"suspended"`
+	// Reason сохраняется в users.status_reason и в журнал аудита
+	Reason string `json:"reason" validate:"max=500" example:"ticket #4821: спам-рассылка"`
+}
+
+type Response struct {
+	resp.Response
+}
+
+type StatusChanger interface {
+	ChangeStatus(ctx context.Context, userID int64, status models.AccountStatus, reason string, event models.AuditEvent) error
+}
+
+// New godoc
+// @Summary      Изменить статус аккаунта
+// @Description  ## Описание
+// @Description  Переводит аккаунт между состояниями: active, disabled, suspended, banned.
+// @Description
+// @Description  ### Допустимые переходы:
+// @Description  - active → disabled, suspended, banned
+// @Description  - disabled → active, banned
+// @Description  - suspended → active, disabled, banned
+// @Description  - banned → active
+// @Description
+// @Description  ### Особенности:
+// @Description  - При переводе в disabled/suspended/banned все сессии завершаются (как /admin/users/{id}/logout)
+// @Description  - pending_deletion выставляется только самим пользователем при удалении аккаунта
+// @Description  - disabled — обратимое отключение аккаунта, suspended — временная блокировка за нарушение
+// @Description  - В журнал аудита пишется событие status_change с исходным и новым статусом
+// @Description  - Требует basic auth администратора (ADMIN_USERNAME / ADMIN_PASSWORD)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id    path  int                                 true  "ID пользователя"
+// @Param        body  body  object{status=string,reason=string}  true  "Новый статус и причина"
+// @Success      200  {object}  object{status=string}  "Статус изменён"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный ID пользователя или тело запроса"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Пользователь не найден"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "Переход из текущего статуса недопустим"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /admin/users/{id}/status [post]
+func New(
+	log *slog.Logger,
+	validate *validator.Validate,
+	svc StatusChanger,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.change_status.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := admin.UserID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid user id"))
+
+			return
+		}
+
+		var req Request
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+
+			if errors.As(err, &validateErr) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+
+				return
+			}
+
+			log.Error("unexpected validation error type", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		event := admin.AuditEvent(r, req.Reason)
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		if err := svc.ChangeStatus(ctx, userID, req.Status, req.Reason, event); err != nil {
+			switch {
+			case errors.Is(err, auth.ErrUserNotFound):
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeUserNotFound, "user not found"))
+
+				return
+			case errors.Is(err, auth.ErrInvalidStatusTransition):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidStatusChange, "status transition not allowed"))
+
+				return
+			}
+
+			log.Error("failed to change account status", slog.Int64("user_id", userID), sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		log.Info("account status changed",
+			slog.Int64("user_id", userID),
+			slog.String("status", string(req.Status)),
+			slog.String("actor", event.Actor),
+		)
+
+		ResponseOK(w, r)
+	}
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, Response{
+		Response: resp.OK(),
+	})
+}
@@ -0,0 +1,146 @@
+package forceLogout
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth_service/internal/auth"
+	"auth_service/internal/http_server/handlers/admin"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Response struct {
+	resp.Response
+	RefreshTokensDeleted int64 `json:"refresh_tokens_deleted"`
+	AccessTokensRevoked  int   `json:"access_tokens_revoked"`
+}
+
+type SessionTerminator interface {
+	ForceLogout(ctx context.Context, userID int64, event models.AuditEvent) (*models.ForceLogoutResult, error)
+}
+
+// New godoc
+// @Summary      Принудительный выход пользователя
+// @Description  ## Описание
+// @Description  Завершает все сессии пользователя во всех приложениях. Используется поддержкой,
+// @Description  когда захват аккаунта подтверждён.
+// @Description
+// @Description  ### Что происходит:
+// @Description  1. Удаляются все refresh токены пользователя
+// @Description  2. Все выданные и ещё не истёкшие access токены попадают в denylist по jti
+// @Description  3. В журнал аудита пишется событие force_logout с логином администратора и причиной
+// @Description
+// @Description  ### Особенности:
+// @Description  - Требует basic auth администратора (ADMIN_USERNAME / ADMIN_PASSWORD)
+// @Description  - Если credentials администратора не заданы, эндпоинт возвращает 404
+// @Description  - Операция идемпотентна: повторный вызов вернёт нулевые счётчики
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id    path  int                   true   "ID пользователя"
+// @Param        body  body  object{reason=string}  false  "Причина для журнала аудита"
+// @Success      200  {object}  object{status=string,refresh_tokens_deleted=int,access_tokens_revoked=int}  "Сессии завершены"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный ID пользователя или тело запроса"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Пользователь не найден"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /admin/users/{id}/logout [post]
+func New(
+	log *slog.Logger,
+	validate *validator.Validate,
+	terminator SessionTerminator,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.force_logout.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := admin.UserID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid user id"))
+
+			return
+		}
+
+		// тело необязательно — без причины событие всё равно пишется
+		var req admin.Request
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil && !errors.Is(err, io.EOF) {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+
+			if errors.As(err, &validateErr) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+
+				return
+			}
+
+			log.Error("unexpected validation error type", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		event := admin.AuditEvent(r, req.Reason)
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		result, err := terminator.ForceLogout(ctx, userID, event)
+		if err != nil {
+			if errors.Is(err, auth.ErrUserNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeUserNotFound, "user not found"))
+
+				return
+			}
+
+			log.Error("failed to force logout user", slog.Int64("user_id", userID), sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		log.Info("user force logged out",
+			slog.Int64("user_id", userID),
+			slog.String("actor", event.Actor),
+		)
+
+		ResponseOK(w, r, result)
+	}
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request, result *models.ForceLogoutResult) {
+	render.JSON(w, r, Response{
+		Response:             resp.OK(),
+		RefreshTokensDeleted: result.RefreshTokensDeleted,
+		AccessTokensRevoked:  result.AccessTokensRevoked,
+	})
+}
@@ -0,0 +1,310 @@
+package ipRules
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth_service/internal/http_server/handlers/admin"
+	"auth_service/internal/ipfilter"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type RuleManager interface {
+	List(ctx context.Context) ([]models.IPRule, error)
+	Create(ctx context.Context, rule *models.IPRule) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type Request struct {
+	// CIDR — сеть или одиночный адрес (тогда /32 или /128)
+	CIDR   string `json:"cidr" validate:"required,max=64" example:"203.0.113.0/24"`
+	Action string `json:"action" validate:"required,oneof=allow deny" example:"deny"`
+	Reason string `json:"reason" validate:"max=500" example:"ticket #4821: credential stuffing"`
+	// ExpiresAt — без значения правило бессрочное
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2026-11-01T00:00:00Z"`
+}
+
+type IPRule struct {
+	ID        int64      `json:"id" example:"42"`
+	CIDR      string     `json:"cidr" example:"203.0.113.0/24"`
+	Action    string     `json:"action" example:"deny"`
+	Reason    string     `json:"reason" example:"ticket #4821: credential stuffing"`
+	CreatedBy string     `json:"created_by" example:"admin:root"`
+	CreatedAt time.Time  `json:"created_at" example:"2026-10-16T12:00:00Z"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2026-11-01T00:00:00Z"`
+}
+
+type CreateResponse struct {
+	resp.Response
+	IPRule IPRule `json:"ip_rule"`
+}
+
+type ListResponse struct {
+	resp.Response
+	IPRules []IPRule `json:"ip_rules"`
+}
+
+// NewCreate godoc
+// @Summary      Правило доступа по IP
+// @Description  ## Описание
+// @Description  Добавляет правило для сети: deny блокирует запросы с неё (403), allow освобождает
+// @Description  её от блокировок, в том числе автоматических.
+// @Description
+// @Description  ### Особенности:
+// @Description  - При пересечении сетей действует правило с самой узкой
+// @Description  - На одну сеть — одно правило; чтобы поменять действие, удалите старое
+// @Description  - Без expires_at правило бессрочное
+// @Description  - Реплики применяют изменение в течение ip_filter.local_cache_ttl
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "Правило"
+// @Success      201  {object}  CreateResponse  "Правило добавлено"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректное тело запроса, CIDR или expires_at"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "Для этой сети правило уже есть"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /admin/ip-rules [post]
+func NewCreate(
+	log *slog.Logger,
+	validate *validator.Validate,
+	manager RuleManager,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.ip_rules.NewCreate"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+
+			if errors.As(err, &validateErr) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+
+				return
+			}
+
+			log.Error("unexpected validation error type", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		prefix, ok := parsePrefix(req.CIDR)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid cidr"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		rule := &models.IPRule{
+			Prefix:    prefix,
+			Action:    models.IPRuleAction(req.Action),
+			Reason:    req.Reason,
+			CreatedBy: admin.AuditEvent(r, "").Actor,
+			ExpiresAt: req.ExpiresAt,
+		}
+
+		if err := manager.Create(ctx, rule); err != nil {
+			switch {
+			case errors.Is(err, ipfilter.ErrRuleExists):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error(resp.CodeIPRuleExists, "ip rule for this network already exists"))
+			case errors.Is(err, ipfilter.ErrInvalidExpiry):
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "expires_at must be in the future"))
+			case errors.Is(err, ipfilter.ErrInvalidAction):
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "action must be allow or deny"))
+			default:
+				log.Error("failed to create ip rule", sl.Err(err))
+
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+			}
+
+			return
+		}
+
+		log.Info("ip rule created",
+			slog.String("cidr", rule.Prefix.String()),
+			slog.String("action", string(rule.Action)),
+			slog.String("created_by", rule.CreatedBy),
+		)
+
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, CreateResponse{
+			Response: resp.OK(),
+			IPRule:   toIPRule(*rule),
+		})
+	}
+}
+
+// NewList godoc
+// @Summary      Правила доступа по IP
+// @Description  Возвращает действующие правила, включая временные блокировки детектора
+// @Description  злоупотреблений (created_by = system:abuse). Самые узкие сети первыми.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  ListResponse  "Действующие правила"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /admin/ip-rules [get]
+func NewList(
+	log *slog.Logger,
+	manager RuleManager,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.ip_rules.NewList"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		rules, err := manager.List(ctx)
+		if err != nil {
+			log.Error("failed to list ip rules", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		result := make([]IPRule, 0, len(rules))
+		for _, rule := range rules {
+			result = append(result, toIPRule(rule))
+		}
+
+		render.JSON(w, r, ListResponse{
+			Response: resp.OK(),
+			IPRules:  result,
+		})
+	}
+}
+
+// NewDelete godoc
+// @Summary      Удаление правила доступа по IP
+// @Description  Удаляет правило, в том числе временную блокировку детектора злоупотреблений.
+// @Tags         admin
+// @Produce      json
+// @Param        id  path  int  true  "ID правила"
+// @Success      200  {object}  object{status=string}  "Правило удалено"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный ID правила"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Правило не найдено"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /admin/ip-rules/{id} [delete]
+func NewDelete(
+	log *slog.Logger,
+	manager RuleManager,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.ip_rules.NewDelete"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil || id <= 0 {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid ip rule id"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		if err := manager.Delete(ctx, id); err != nil {
+			if errors.Is(err, ipfilter.ErrNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeIPRuleNotFound, "ip rule not found"))
+
+				return
+			}
+
+			log.Error("failed to delete ip rule", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+// parsePrefix принимает CIDR или одиночный адрес.
+func parsePrefix(s string) (netip.Prefix, bool) {
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, false
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), true
+	}
+
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+
+	return prefix, true
+}
+
+func toIPRule(rule models.IPRule) IPRule {
+	return IPRule{
+		ID:        rule.ID,
+		CIDR:      rule.Prefix.String(),
+		Action:    string(rule.Action),
+		Reason:    rule.Reason,
+		CreatedBy: rule.CreatedBy,
+		CreatedAt: rule.CreatedAt,
+		ExpiresAt: rule.ExpiresAt,
+	}
+}
@@ -0,0 +1,219 @@
+package maintenanceHandler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/maintenance"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Mode interface {
+	State(ctx context.Context) maintenance.State
+	Schedule(ctx context.Context, w models.MaintenanceWindow) error
+	Clear(ctx context.Context) error
+}
+
+type Request struct {
+	Reason string `json:"reason" validate:"max=500" example:"миграция БД"`
+	// StartsAt — начало окна, пусто — немедленно.
+	StartsAt *time.Time `json:"starts_at,omitempty" example:"2026-07-24T12:00:00Z"`
+	// EndsAt — конец окна, пусто — до выключения через DELETE.
+	EndsAt *time.Time `json:"ends_at,omitempty" example:"2026-07-24T12:30:00Z"`
+}
+
+type Window struct {
+	Reason   string     `json:"reason,omitempty" example:"миграция БД"`
+	StartsAt time.Time  `json:"starts_at" example:"2026-07-24T12:00:00Z"`
+	EndsAt   *time.Time `json:"ends_at,omitempty" example:"2026-07-24T12:30:00Z"`
+}
+
+type Response struct {
+	resp.Response
+	Active bool    `json:"active" example:"true"`
+	Source string  `json:"source,omitempty" example:"redis"`
+	Reason string  `json:"reason,omitempty" example:"миграция БД"`
+	Window *Window `json:"window,omitempty"`
+}
+
+// NewGet godoc
+// @Summary      Состояние режима обслуживания
+// @Description  Возвращает, активен ли режим обслуживания, откуда он включён (config или redis)
+// @Description  и окно из Redis, в том числе запланированное и ещё не начавшееся.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  Response  "Состояние режима обслуживания"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Router       /admin/maintenance [get]
+func NewGet(mode Mode) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ResponseOK(w, r, mode.State(r.Context()))
+	}
+}
+
+// NewSchedule godoc
+// @Summary      Включить режим обслуживания
+// @Description  ## Описание
+// @Description  Включает режим обслуживания на всех репликах сразу или планирует окно на будущее.
+// @Description
+// @Description  ### Поведение в режиме обслуживания:
+// @Description  - GET-эндпоинты продолжают работать
+// @Description  - Выдача токенов и остальные изменяющие запросы получают 503 с Retry-After
+// @Description  - /health отвечает 200 с maintenance.active = true
+// @Description  - /admin/* не блокируется
+// @Description
+// @Description  Новое окно заменяет ранее заданное. Окно с ends_at выключается само.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        body  body  object{reason=string,starts_at=string,ends_at=string}  false  "Окно обслуживания"
+// @Success      200  {object}  Response  "Окно сохранено"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректное тело или окно заканчивается раньше начала"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /admin/maintenance [put]
+func NewSchedule(
+	log *slog.Logger,
+	validate *validator.Validate,
+	mode Mode,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.maintenance.NewSchedule"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+
+			if errors.As(err, &validateErr) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+
+				return
+			}
+
+			log.Error("unexpected validation error type", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		window := models.MaintenanceWindow{Reason: req.Reason, EndsAt: req.EndsAt}
+		if req.StartsAt != nil {
+			window.StartsAt = *req.StartsAt
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		if err := mode.Schedule(ctx, window); err != nil {
+			if errors.Is(err, maintenance.ErrInvalidWindow) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "maintenance window must end after it starts"))
+
+				return
+			}
+
+			log.Error("failed to schedule maintenance", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		actor, _, _ := r.BasicAuth()
+		log.Warn("maintenance window scheduled",
+			slog.String("actor", "admin:"+actor),
+			slog.String("reason", req.Reason),
+		)
+
+		ResponseOK(w, r, mode.State(ctx))
+	}
+}
+
+// NewClear godoc
+// @Summary      Выключить режим обслуживания
+// @Description  Удаляет окно обслуживания из Redis. Режим, включённый через конфиг
+// @Description  (MAINTENANCE_ENABLED), снимается только рестартом.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  Response  "Окно удалено"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /admin/maintenance [delete]
+func NewClear(
+	log *slog.Logger,
+	mode Mode,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.maintenance.NewClear"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		if err := mode.Clear(ctx); err != nil {
+			log.Error("failed to clear maintenance", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		actor, _, _ := r.BasicAuth()
+		log.Warn("maintenance window cleared", slog.String("actor", "admin:"+actor))
+
+		ResponseOK(w, r, mode.State(ctx))
+	}
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request, state maintenance.State) {
+	response := Response{
+		Response: resp.OK(),
+		Active:   state.Active,
+		Source:   state.Source,
+		Reason:   state.Reason,
+	}
+
+	if state.Window != nil {
+		response.Window = &Window{
+			Reason:   state.Window.Reason,
+			StartsAt: state.Window.StartsAt,
+			EndsAt:   state.Window.EndsAt,
+		}
+	}
+
+	render.JSON(w, r, response)
+}
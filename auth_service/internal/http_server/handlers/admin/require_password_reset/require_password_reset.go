@@ -0,0 +1,137 @@
+package requirePasswordReset
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth_service/internal/auth"
+	"auth_service/internal/http_server/handlers/admin"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Response struct {
+	resp.Response
+}
+
+type PasswordResetRequirer interface {
+	RequirePasswordReset(ctx context.Context, userID int64, event models.AuditEvent) error
+}
+
+// New godoc
+// @Summary      Потребовать смену пароля
+// @Description  ## Описание
+// @Description  Помечает аккаунт как требующий смены пароля. Пока пользователь не сбросит пароль
+// @Description  через /auth/password/forgot и /auth/password/reset, вход любым способом и refresh возвращают 403.
+// @Description
+// @Description  ### Особенности:
+// @Description  - Активные сессии завершаются: refresh-токены удаляются, выданные access-токены отзываются
+// @Description  - Флаг снимается автоматически при успешном сбросе пароля
+// @Description  - В журнал аудита пишется событие require_password_reset
+// @Description  - Требует basic auth администратора (ADMIN_USERNAME / ADMIN_PASSWORD)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id    path  int                   true   "ID пользователя"
+// @Param        body  body  object{reason=string}  false  "Причина для журнала аудита"
+// @Success      200  {object}  object{status=string}  "Флаг выставлен"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный ID пользователя или тело запроса"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Пользователь не найден или удалён"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /admin/users/{id}/require-password-reset [post]
+func New(
+	log *slog.Logger,
+	validate *validator.Validate,
+	svc PasswordResetRequirer,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.require_password_reset.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := admin.UserID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid user id"))
+
+			return
+		}
+
+		// тело необязательно — без причины событие всё равно пишется
+		var req admin.Request
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil && !errors.Is(err, io.EOF) {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+
+			if errors.As(err, &validateErr) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+
+				return
+			}
+
+			log.Error("unexpected validation error type", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		event := admin.AuditEvent(r, req.Reason)
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		if err := svc.RequirePasswordReset(ctx, userID, event); err != nil {
+			if errors.Is(err, auth.ErrUserNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeUserNotFound, "user not found"))
+
+				return
+			}
+
+			log.Error("failed to require password reset", slog.Int64("user_id", userID), sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		log.Info("password reset required",
+			slog.Int64("user_id", userID),
+			slog.String("actor", event.Actor),
+		)
+
+		ResponseOK(w, r)
+	}
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, Response{
+		Response: resp.OK(),
+	})
+}
@@ -0,0 +1,242 @@
+package roles
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth_service/internal/auth/rbac"
+	"auth_service/internal/http_server/handlers/admin"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type RoleManager interface {
+	CreateRole(ctx context.Context, role *models.Role) error
+	ListRoles(ctx context.Context, appID int32) ([]models.Role, error)
+}
+
+type Request struct {
+	Name        string `json:"name" validate:"required,max=64" example:"editor"`
+	Description string `json:"description,omitempty" validate:"max=500" example:"Редактор контента"`
+	// Permissions — права роли; формат строк определяет приложение.
+	Permissions []string `json:"permissions" validate:"dive,required,max=128" example:"posts:write"`
+}
+
+type Role struct {
+	ID          int64     `json:"id" example:"3"`
+	Name        string    `json:"name" example:"editor"`
+	Description string    `json:"description" example:"Редактор контента"`
+	Permissions []string  `json:"permissions" example:"posts:write"`
+	CreatedAt   time.Time `json:"created_at" example:"2026-07-24T12:00:00Z"`
+}
+
+type Response struct {
+	resp.Response
+	Role Role `json:"role"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Roles []Role `json:"roles"`
+}
+
+// NewCreate godoc
+// @Summary      Создание роли приложения
+// @Description  ## Описание
+// @Description  Заводит роль с набором прав. Роли пользователя и объединение их прав попадают
+// @Description  в claims roles/permissions access-токенов этого приложения.
+// @Description
+// @Description  ### Особенности:
+// @Description  - Имя роли уникально в пределах приложения
+// @Description  - Требует basic auth администратора
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id       path  int      true  "ID приложения"
+// @Param        request  body  Request  true  "Роль"
+// @Success      201  {object}  Response  "Роль создана"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный ID приложения или тело запроса"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Приложение не найдено"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "Роль с таким именем уже есть"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /admin/apps/{id}/roles [post]
+func NewCreate(
+	log *slog.Logger,
+	validate *validator.Validate,
+	manager RoleManager,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.roles.NewCreate"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		appID, ok := admin.AppID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidApp, "invalid app id"))
+
+			return
+		}
+
+		var req Request
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+
+			if errors.As(err, &validateErr) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+
+				return
+			}
+
+			log.Error("unexpected validation error type", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		role := &models.Role{
+			AppID:       appID,
+			Name:        req.Name,
+			Description: req.Description,
+			Permissions: req.Permissions,
+		}
+
+		if err := manager.CreateRole(ctx, role); err != nil {
+			switch {
+			case errors.Is(err, rbac.ErrRoleExists):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error(resp.CodeRoleAlreadyExists, "role already exists"))
+			case errors.Is(err, rbac.ErrAppNotFound):
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeAppNotFound, "app not found"))
+			default:
+				log.Error("failed to create role", sl.Err(err))
+
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+			}
+
+			return
+		}
+
+		ResponseCreated(w, r, role)
+	}
+}
+
+// NewList godoc
+// @Summary      Роли приложения
+// @Description  Возвращает все роли приложения с их правами, по имени.
+// @Tags         admin
+// @Produce      json
+// @Param        id  path  int  true  "ID приложения"
+// @Success      200  {object}  ListResponse  "Роли приложения"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный ID приложения"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /admin/apps/{id}/roles [get]
+func NewList(
+	log *slog.Logger,
+	manager RoleManager,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.roles.NewList"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		appID, ok := admin.AppID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidApp, "invalid app id"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		roles, err := manager.ListRoles(ctx, appID)
+		if err != nil {
+			log.Error("failed to list roles", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		ResponseList(w, r, ToRoles(roles))
+	}
+}
+
+func ResponseCreated(w http.ResponseWriter, r *http.Request, role *models.Role) {
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, Response{
+		Response: resp.OK(),
+		Role:     toRole(*role),
+	})
+}
+
+func ResponseList(w http.ResponseWriter, r *http.Request, roles []Role) {
+	render.JSON(w, r, ListResponse{
+		Response: resp.OK(),
+		Roles:    roles,
+	})
+}
+
+// ToRoles — представление ролей в ответах админских эндпоинтов.
+func ToRoles(roles []models.Role) []Role {
+	result := make([]Role, 0, len(roles))
+
+	for _, role := range roles {
+		result = append(result, toRole(role))
+	}
+
+	return result
+}
+
+func toRole(role models.Role) Role {
+	permissions := role.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	return Role{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+		CreatedAt:   role.CreatedAt,
+	}
+}
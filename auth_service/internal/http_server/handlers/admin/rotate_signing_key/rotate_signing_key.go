@@ -0,0 +1,113 @@
+package rotateSigningKey
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth_service/internal/http_server/handlers/admin"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	resp.Response
+	KID string `json:"kid" example:"mX3f1y0sVb2cQk9a"`
+	Alg string `json:"alg" example:"RS256"`
+}
+
+type KeyRotator interface {
+	Rotate(ctx context.Context, appID int32) (*models.SigningKey, error)
+}
+
+// New godoc
+// @Summary      Ротация ключа подписи приложения
+// @Description  ## Описание
+// @Description  Выпускает новый ключ подписи access-токенов приложения с его текущим
+// @Description  алгоритмом (apps.signing_alg) и выводит прежний.
+// @Description
+// @Description  ### Особенности:
+// @Description  - Новые токены подписываются новым ключом сразу на всех репликах
+// @Description  - Выведенный ключ принимается при проверке до конца grace-периода
+// @Description    (не меньше TTL access-токена), для RS256/ES256 остаётся в JWKS
+// @Description  - HS256-приложение первой ротацией переводится с секрета приложения
+// @Description    на управляемые ключи; токены на старом секрете продолжают проверяться
+// @Description  - Плановая ротация выполняется и без вызова — раз в signing_keys.rotation_interval
+// @Tags         admin
+// @Produce      json
+// @Param        id  path  int  true  "ID приложения"
+// @Success      200  {object}  Response  "Ключ ротирован"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный ID приложения"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Приложение не найдено"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "Ключ одновременно ротирован другим запросом"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /admin/apps/{id}/signing-keys/rotate [post]
+func New(
+	log *slog.Logger,
+	rotator KeyRotator,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.rotate_signing_key.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		appID, ok := admin.AppID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidApp, "invalid app id"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		key, err := rotator.Rotate(ctx, appID)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrAppNotFound):
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeAppNotFound, "app not found"))
+			case errors.Is(err, storage.ErrSigningKeyConflict):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error(resp.CodeSigningKeyConflict, "signing key rotated concurrently, retry"))
+			default:
+				log.Error("failed to rotate signing key", slog.Int("app_id", int(appID)), sl.Err(err))
+
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+			}
+
+			return
+		}
+
+		actor, _, _ := r.BasicAuth()
+		log.Info("signing key rotated by admin",
+			slog.Int("app_id", int(appID)),
+			slog.String("kid", key.KID),
+			slog.String("actor", "admin:"+actor),
+		)
+
+		ResponseOK(w, r, key)
+	}
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request, key *models.SigningKey) {
+	render.JSON(w, r, Response{
+		Response: resp.OK(),
+		KID:      key.KID,
+		Alg:      string(key.Alg),
+	})
+}
@@ -0,0 +1,318 @@
+package userRoles
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"auth_service/internal/auth/rbac"
+	"auth_service/internal/http_server/handlers/admin"
+	"auth_service/internal/http_server/handlers/admin/roles"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type RoleAssigner interface {
+	UserRoles(ctx context.Context, userID int64, appID int32) ([]models.Role, error)
+	AssignRole(ctx context.Context, userID int64, appID int32, roleName string, event models.AuditEvent) error
+	RevokeRole(ctx context.Context, userID int64, appID int32, roleName string, event models.AuditEvent) error
+}
+
+type AssignRequest struct {
+	AppID int32  `json:"app_id" validate:"required,gt=0" example:"1"`
+	Role  string `json:"role" validate:"required,max=64" example:"editor"`
+	admin.Request
+}
+
+type ListResponse struct {
+	resp.Response
+	Roles []roles.Role `json:"roles"`
+}
+
+type Response struct {
+	resp.Response
+}
+
+// NewList godoc
+// @Summary      Роли пользователя в приложении
+// @Description  Возвращает роли пользователя в приложении — те же, что попадут в claims
+// @Description  roles/permissions его следующего access-токена.
+// @Tags         admin
+// @Produce      json
+// @Param        id      path   int  true  "ID пользователя"
+// @Param        app_id  query  int  true  "ID приложения"
+// @Success      200  {object}  ListResponse  "Роли пользователя"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный ID пользователя или приложения"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /admin/users/{id}/roles [get]
+func NewList(
+	log *slog.Logger,
+	assigner RoleAssigner,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.user_roles.NewList"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := admin.UserID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid user id"))
+
+			return
+		}
+
+		appID, ok := queryAppID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidApp, "invalid app id"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		userRoles, err := assigner.UserRoles(ctx, userID, appID)
+		if err != nil {
+			log.Error("failed to list user roles", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		ResponseList(w, r, roles.ToRoles(userRoles))
+	}
+}
+
+// NewAssign godoc
+// @Summary      Назначение роли пользователю
+// @Description  ## Описание
+// @Description  Назначает пользователю роль приложения и пишет событие role_assigned в журнал аудита.
+// @Description
+// @Description  ### Особенности:
+// @Description  - Роль попадает в access-токены, выпущенные после назначения (не позже следующего refresh)
+// @Description  - Повторное назначение той же роли — no-op
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id       path  int            true  "ID пользователя"
+// @Param        request  body  AssignRequest  true  "Приложение, роль и причина"
+// @Success      200  {object}  Response  "Роль назначена"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный ID пользователя или тело запроса"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Пользователь или роль не найдены"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /admin/users/{id}/roles [post]
+func NewAssign(
+	log *slog.Logger,
+	validate *validator.Validate,
+	assigner RoleAssigner,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.user_roles.NewAssign"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := admin.UserID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid user id"))
+
+			return
+		}
+
+		var req AssignRequest
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if !validRequest(w, r, log, validate, req) {
+			return
+		}
+
+		event := admin.AuditEvent(r, req.Reason)
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		if err := assigner.AssignRole(ctx, userID, req.AppID, req.Role, event); err != nil {
+			switch {
+			case errors.Is(err, rbac.ErrUserNotFound):
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeUserNotFound, "user not found"))
+			case errors.Is(err, rbac.ErrRoleNotFound):
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeRoleNotFound, "role not found"))
+			default:
+				log.Error("failed to assign role", slog.Int64("user_id", userID), sl.Err(err))
+
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+			}
+
+			return
+		}
+
+		ResponseOK(w, r)
+	}
+}
+
+// NewRevoke godoc
+// @Summary      Снятие роли с пользователя
+// @Description  ## Описание
+// @Description  Снимает с пользователя роль приложения и пишет событие role_revoked в журнал аудита.
+// @Description  Уже выданные access-токены сохраняют роль до истечения; чтобы отозвать её сразу,
+// @Description  используйте /admin/users/{id}/logout.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id      path   int                    true   "ID пользователя"
+// @Param        role    path   string                 true   "Имя роли"
+// @Param        app_id  query  int                    true   "ID приложения"
+// @Param        body    body   object{reason=string}  false  "Причина для журнала аудита"
+// @Success      200  {object}  Response  "Роль снята"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректные параметры запроса"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Роль не найдена или не назначена пользователю"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /admin/users/{id}/roles/{role} [delete]
+func NewRevoke(
+	log *slog.Logger,
+	validate *validator.Validate,
+	assigner RoleAssigner,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.user_roles.NewRevoke"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := admin.UserID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid user id"))
+
+			return
+		}
+
+		appID, ok := queryAppID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidApp, "invalid app id"))
+
+			return
+		}
+
+		// тело необязательно — без причины событие всё равно пишется
+		var req admin.Request
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil && !errors.Is(err, io.EOF) {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if !validRequest(w, r, log, validate, req) {
+			return
+		}
+
+		event := admin.AuditEvent(r, req.Reason)
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		if err := assigner.RevokeRole(ctx, userID, appID, chi.URLParam(r, "role"), event); err != nil {
+			if errors.Is(err, rbac.ErrRoleNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeRoleNotFound, "role not found"))
+
+				return
+			}
+
+			log.Error("failed to revoke role", slog.Int64("user_id", userID), sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		ResponseOK(w, r)
+	}
+}
+
+func ResponseList(w http.ResponseWriter, r *http.Request, userRoles []roles.Role) {
+	render.JSON(w, r, ListResponse{
+		Response: resp.OK(),
+		Roles:    userRoles,
+	})
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, Response{
+		Response: resp.OK(),
+	})
+}
+
+func validRequest(w http.ResponseWriter, r *http.Request, log *slog.Logger, validate *validator.Validate, req any) bool {
+	if err := validate.Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+
+		if errors.As(err, &validateErr) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return false
+		}
+
+		log.Error("unexpected validation error type", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+
+		return false
+	}
+
+	return true
+}
+
+func queryAppID(r *http.Request) (int32, bool) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("app_id"), 10, 32)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return int32(id), true
+}
@@ -0,0 +1,143 @@
+package verifyEmail
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth_service/internal/auth"
+	"auth_service/internal/http_server/handlers/admin"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Response struct {
+	resp.Response
+}
+
+type EmailVerifier interface {
+	VerifyEmailManually(ctx context.Context, userID int64, event models.AuditEvent) error
+}
+
+// New godoc
+// @Summary      Подтвердить email вручную
+// @Description  ## Описание
+// @Description  Отмечает email пользователя подтверждённым без письма. Для случаев, когда отправка
+// @Description  писем сломалась, а владение адресом подтверждено поддержкой.
+// @Description
+// @Description  ### Особенности:
+// @Description  - В журнал аудита пишется событие manual_email_verify с адресом и причиной
+// @Description  - Для уже подтверждённого email возвращается 409, событие не пишется
+// @Description  - Требует basic auth администратора (ADMIN_USERNAME / ADMIN_PASSWORD)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id    path  int                   true   "ID пользователя"
+// @Param        body  body  object{reason=string}  false  "Причина для журнала аудита"
+// @Success      200  {object}  object{status=string}  "Email подтверждён"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный ID пользователя или тело запроса"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials администратора"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Пользователь не найден или удалён"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "Email уже подтверждён"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /admin/users/{id}/verify-email [post]
+func New(
+	log *slog.Logger,
+	validate *validator.Validate,
+	svc EmailVerifier,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.verify_email.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := admin.UserID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid user id"))
+
+			return
+		}
+
+		// тело необязательно — без причины событие всё равно пишется
+		var req admin.Request
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil && !errors.Is(err, io.EOF) {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+
+			if errors.As(err, &validateErr) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+
+				return
+			}
+
+			log.Error("unexpected validation error type", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		event := admin.AuditEvent(r, req.Reason)
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		if err := svc.VerifyEmailManually(ctx, userID, event); err != nil {
+			switch {
+			case errors.Is(err, auth.ErrUserNotFound):
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeUserNotFound, "user not found"))
+
+				return
+			case errors.Is(err, auth.ErrEmailAlreadyVerified):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error(resp.CodeEmailAlreadyVerified, "email already verified"))
+
+				return
+			}
+
+			log.Error("failed to verify email manually", slog.Int64("user_id", userID), sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		log.Info("email verified manually",
+			slog.Int64("user_id", userID),
+			slog.String("actor", event.Actor),
+		)
+
+		ResponseOK(w, r)
+	}
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, Response{
+		Response: resp.OK(),
+	})
+}
@@ -0,0 +1,59 @@
+package self
+
+import (
+	"net/http"
+	"time"
+
+	apiKeyAuth "auth_service/internal/http_server/middleware/api_key_auth"
+	resp "auth_service/internal/lib/api/response"
+
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	resp.Response
+	KeyID     string     `json:"key_id" example:"5f0c3a4e-8d1b-4f7a-9c2e-1b3d5e7f9a0b"`
+	AppID     int32      `json:"app_id" example:"1"`
+	Name      string     `json:"name" example:"nightly-export"`
+	Scopes    []string   `json:"scopes" example:"reports:read"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2027-01-01T00:00:00Z"`
+}
+
+// New godoc
+// @Summary      Данные API-ключа
+// @Description  ## Описание
+// @Description  Проверяет ключ из заголовка `X-API-Key` и возвращает приложение и scope'ы, к которым
+// @Description  он привязан. Сервисы, принимающие ключи от batch-задач, вызывают этот эндпоинт
+// @Description  вместо интроспекции access-токена.
+// @Tags         api-keys
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Success      200  {object}  Response  "Ключ действителен"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Ключ отсутствует, неизвестен, истёк или отозван"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /api-keys/self [get]
+func New() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, ok := apiKeyAuth.FromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAPIKey, "invalid, expired or revoked api key"))
+
+			return
+		}
+
+		scopes := key.Scopes
+		if scopes == nil {
+			scopes = []string{}
+		}
+
+		render.JSON(w, r, Response{
+			Response:  resp.OK(),
+			KeyID:     key.ID.String(),
+			AppID:     key.AppID,
+			Name:      key.Name,
+			Scopes:    scopes,
+			ExpiresAt: key.ExpiresAt,
+		})
+	}
+}
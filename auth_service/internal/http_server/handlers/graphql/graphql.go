@@ -0,0 +1,106 @@
+package graphqlHandler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/graphql-go/graphql"
+)
+
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// New godoc
+// @Summary      GraphQL API
+// @Description  ## Описание
+// @Description  GraphQL-фасад над тем же сервисным слоем, что и REST. Для фронтендов,
+// @Description  которые работают только с GraphQL.
+// @Description
+// @Description  ### Операции:
+// @Description  - query: me, sessions — требуют Bearer access токен
+// @Description  - mutation: login, refresh, logout — без токена
+// @Description  - mutation: updateProfile — требует Bearer access токен
+// @Description
+// @Description  ### Особенности:
+// @Description  - Ошибки возвращаются в errors[] с кодом в extensions.code
+// @Description  - login ограничен по email тем же счётчиком, что и /auth/login (код RATE_LIMITED)
+// @Description  - Эндпоинт включается флагом graphql.enabled
+// @Tags         graphql
+// @Accept       json
+// @Produce      json
+// @Param        body  body  object{query=string,operationName=string,variables=object}  true  "GraphQL запрос"
+// @Success      200  {object}  object{data=object,errors=[]object}  "Результат выполнения"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректное тело запроса"
+// @Failure      401  {object}  object{error=string}  "Передан невалидный access токен"
+// @Router       /graphql [post]
+func New(
+	log *slog.Logger,
+	validate *validator.Validate,
+	authService AuthService,
+	limiter LoginLimiter,
+	pendingSessionTTL time.Duration,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	schema, err := newSchema(&resolver{
+		log:               log,
+		validate:          validate,
+		auth:              authService,
+		limiter:           limiter,
+		pendingSessionTTL: pendingSessionTTL,
+	})
+	if err != nil {
+		// схема статична — ошибка здесь означает баг в коде
+		panic("graphql: invalid schema: " + err.Error())
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.graphql.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if req.Query == "" {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "query is required"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		result := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  req.Query,
+			OperationName:  req.OperationName,
+			VariableValues: req.Variables,
+			Context:        ctx,
+		})
+
+		render.JSON(w, r, result)
+	}
+}
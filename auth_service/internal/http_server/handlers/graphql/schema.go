@@ -0,0 +1,336 @@
+package graphqlHandler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"auth_service/internal/auth"
+	"auth_service/internal/auth/lockout"
+	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+	rateLimit "auth_service/internal/ratelimit"
+	"auth_service/internal/storage"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/graphql-go/graphql"
+)
+
+// AuthService — часть auth.Auth, которую отдаёт GraphQL-фасад. Логика та же,
+// что у REST-хендлеров; здесь только маппинг аргументов и ошибок.
+type AuthService interface {
+	Login(ctx context.Context, email, password string, appID int32, scopes []string, device *models.Device, trustToken string, pendingSessionTTL time.Duration) (*auth.LoginResult, error)
+	Refresh(ctx context.Context, rawRefreshToken string, scopes []string, orgID int64) (string, string, time.Time, error)
+	Logout(ctx context.Context, rawRefreshToken string) error
+
+	Me(ctx context.Context, userID int64) (*models.User, error)
+	Sessions(ctx context.Context, userID int64) ([]models.Session, error)
+	UpdateProfile(ctx context.Context, userID int64, username string) (*models.User, error)
+}
+
+// LoginLimiter — per-email лимит входа. Лимит по IP на /graphql стоит в
+// роутере, а email виден только после разбора аргументов мутации.
+type LoginLimiter interface {
+	AllowLogin(ctx context.Context, email string) (rateLimit.Decision, error)
+}
+
+type loginArgs struct {
+	Email      string `validate:"required,email"`
+	Pass       string `validate:"required"`
+	AppID      int32  `validate:"required,gt=0"`
+	DeviceID   string `validate:"omitempty,max=128"`
+	DeviceName string `validate:"omitempty,max=64"`
+	Scope      string `validate:"omitempty,max=1024"`
+}
+
+type profileArgs struct {
+	Username string `validate:"required"`
+}
+
+// gqlError — ошибка для клиента: безопасное сообщение и код в extensions.
+// Внутренние ошибки логируются и наружу уходят как "internal error".
+type gqlError struct {
+	message string
+	code    string
+}
+
+func (e *gqlError) Error() string { return e.message }
+
+func (e *gqlError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+var errUnauthenticated = &gqlError{message: "authentication required", code: "UNAUTHENTICATED"}
+
+type resolver struct {
+	log               *slog.Logger
+	validate          *validator.Validate
+	auth              AuthService
+	limiter           LoginLimiter
+	pendingSessionTTL time.Duration
+}
+
+func newSchema(r *resolver) (graphql.Schema, error) {
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":         &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: field(func(u *models.User) any { return u.ID })},
+			"email":      &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: field(func(u *models.User) any { return u.Email })},
+			"username":   &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: field(func(u *models.User) any { return u.Username })},
+			"isVerified": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Resolve: field(func(u *models.User) any { return u.IsVerified })},
+			"status":     &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: field(func(u *models.User) any { return string(u.Status) })},
+		},
+	})
+
+	sessionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Session",
+		Fields: graphql.Fields{
+			"id":         &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: field(func(s models.Session) any { return s.ID.String() })},
+			"appId":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: field(func(s models.Session) any { return s.AppID })},
+			"deviceId":   &graphql.Field{Type: graphql.String, Resolve: field(func(s models.Session) any { return nullable(s.DeviceID) })},
+			"deviceName": &graphql.Field{Type: graphql.String, Resolve: field(func(s models.Session) any { return nullable(s.DeviceName) })},
+			"createdAt":  &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: field(func(s models.Session) any { return s.CreatedAt })},
+			"expiresAt":  &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: field(func(s models.Session) any { return s.ExpiresAt })},
+		},
+	})
+
+	tokensType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "AuthPayload",
+		Fields: graphql.Fields{
+			"accessToken":      &graphql.Field{Type: graphql.String},
+			"refreshToken":     &graphql.Field{Type: graphql.String},
+			"twoFactorPending": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"sessionId":        &graphql.Field{Type: graphql.String},
+			"twoFactorMethod":  &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": &graphql.Field{
+				Type:    graphql.NewNonNull(userType),
+				Resolve: r.me,
+			},
+			"sessions": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(sessionType))),
+				Resolve: r.sessions,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"login": &graphql.Field{
+				Type: graphql.NewNonNull(tokensType),
+				Args: graphql.FieldConfigArgument{
+					"email":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"password":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"appId":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"deviceId":   &graphql.ArgumentConfig{Type: graphql.String},
+					"deviceName": &graphql.ArgumentConfig{Type: graphql.String},
+					"scope":      &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: r.login,
+			},
+			"refresh": &graphql.Field{
+				Type: graphql.NewNonNull(tokensType),
+				Args: graphql.FieldConfigArgument{
+					"refreshToken": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"scope":        &graphql.ArgumentConfig{Type: graphql.String},
+					"orgId":        &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: r.refresh,
+			},
+			"logout": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"refreshToken": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: r.logout,
+			},
+			"updateProfile": &graphql.Field{
+				Type: graphql.NewNonNull(userType),
+				Args: graphql.FieldConfigArgument{
+					"username": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: r.updateProfile,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    query,
+		Mutation: mutation,
+	})
+}
+
+// field — резолвер поля по типизированному источнику.
+func field[T any](get func(T) any) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		src, ok := p.Source.(T)
+		if !ok {
+			return nil, nil
+		}
+		return get(src), nil
+	}
+}
+
+func (r *resolver) me(p graphql.ResolveParams) (any, error) {
+	claims, ok := claimsParser.ClaimsFromContext(p.Context)
+	if !ok {
+		return nil, errUnauthenticated
+	}
+
+	user, err := r.auth.Me(p.Context, claims.UserID)
+	if err != nil {
+		return nil, r.mapError(err)
+	}
+
+	return user, nil
+}
+
+func (r *resolver) sessions(p graphql.ResolveParams) (any, error) {
+	claims, ok := claimsParser.ClaimsFromContext(p.Context)
+	if !ok {
+		return nil, errUnauthenticated
+	}
+
+	sessions, err := r.auth.Sessions(p.Context, claims.UserID)
+	if err != nil {
+		return nil, r.mapError(err)
+	}
+
+	return sessions, nil
+}
+
+func (r *resolver) login(p graphql.ResolveParams) (any, error) {
+	args := loginArgs{
+		Email: p.Args["email"].(string),
+		Pass:  p.Args["password"].(string),
+		AppID: int32(p.Args["appId"].(int)),
+	}
+	args.DeviceID, _ = p.Args["deviceId"].(string)
+	args.DeviceName, _ = p.Args["deviceName"].(string)
+	args.Scope, _ = p.Args["scope"].(string)
+
+	if err := r.validate.Struct(args); err != nil {
+		return nil, &gqlError{message: "invalid login arguments", code: "BAD_USER_INPUT"}
+	}
+
+	decision, err := r.limiter.AllowLogin(p.Context, args.Email)
+	if err != nil {
+		r.log.Error("login rate limiter failed", sl.Err(err))
+		return nil, &gqlError{message: "service temporarily unavailable", code: "SERVICE_UNAVAILABLE"}
+	}
+	if !decision.Allowed {
+		return nil, &gqlError{message: "rate limit exceeded", code: "RATE_LIMITED"}
+	}
+
+	res, err := r.auth.Login(p.Context, args.Email, args.Pass, args.AppID, strings.Fields(args.Scope), models.NewDevice(args.DeviceID, args.DeviceName), "", r.pendingSessionTTL)
+	if err != nil {
+		return nil, r.mapError(err)
+	}
+
+	return map[string]any{
+		"accessToken":      nullable(res.AccessToken),
+		"refreshToken":     nullable(res.RefreshToken),
+		"twoFactorPending": res.TwoFactorPending,
+		"sessionId":        nullable(res.SessionID),
+		"twoFactorMethod":  nullable(res.TwoFactorMethod),
+	}, nil
+}
+
+func (r *resolver) refresh(p graphql.ResolveParams) (any, error) {
+	scope, _ := p.Args["scope"].(string)
+	orgID, _ := p.Args["orgId"].(int)
+
+	accessToken, refreshToken, _, err := r.auth.Refresh(p.Context, p.Args["refreshToken"].(string), strings.Fields(scope), int64(orgID))
+	if err != nil {
+		return nil, r.mapError(err)
+	}
+
+	return map[string]any{
+		"accessToken":      accessToken,
+		"refreshToken":     refreshToken,
+		"twoFactorPending": false,
+	}, nil
+}
+
+func (r *resolver) logout(p graphql.ResolveParams) (any, error) {
+	if err := r.auth.Logout(p.Context, p.Args["refreshToken"].(string)); err != nil {
+		return nil, r.mapError(err)
+	}
+
+	return true, nil
+}
+
+func (r *resolver) updateProfile(p graphql.ResolveParams) (any, error) {
+	claims, ok := claimsParser.ClaimsFromContext(p.Context)
+	if !ok {
+		return nil, errUnauthenticated
+	}
+
+	args := profileArgs{Username: p.Args["username"].(string)}
+	if err := r.validate.Struct(args); err != nil {
+		return nil, &gqlError{message: "invalid username", code: "BAD_USER_INPUT"}
+	}
+
+	user, err := r.auth.UpdateProfile(p.Context, claims.UserID, args.Username)
+	if err != nil {
+		return nil, r.mapError(err)
+	}
+
+	return user, nil
+}
+
+// mapError повторяет маппинг REST-хендлеров (login, refresh, logout).
+func (r *resolver) mapError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrUserNotFound),
+		errors.Is(err, auth.ErrInvalidCredentials),
+		errors.Is(err, auth.ErrNotAppMember):
+		return &gqlError{message: "invalid credentials", code: "UNAUTHENTICATED"}
+	case errors.Is(err, auth.ErrInvalidAppID):
+		return &gqlError{message: "invalid app id", code: "BAD_USER_INPUT"}
+	case errors.Is(err, auth.ErrInvalidScope):
+		return &gqlError{message: "invalid scope", code: "BAD_USER_INPUT"}
+	case errors.Is(err, auth.ErrNotOrgMember):
+		return &gqlError{message: "not a member of this organization", code: "FORBIDDEN"}
+	case errors.Is(err, auth.ErrEmailNotVerified):
+		return &gqlError{message: "email is not verified", code: "FORBIDDEN"}
+	case errors.Is(err, auth.ErrPasswordResetRequired):
+		return &gqlError{message: "password reset required", code: "FORBIDDEN"}
+	case errors.Is(err, auth.ErrAccountDisabled):
+		return &gqlError{message: "account disabled", code: "FORBIDDEN"}
+	case errors.Is(err, auth.ErrAccountSuspended):
+		return &gqlError{message: "account suspended", code: "FORBIDDEN"}
+	case errors.Is(err, auth.ErrAccountBanned):
+		return &gqlError{message: "account banned", code: "FORBIDDEN"}
+	case errors.Is(err, auth.ErrSessionLimit):
+		return &gqlError{message: "active session limit reached", code: "CONFLICT"}
+	case errors.Is(err, auth.ErrSuspiciousLogin):
+		return &gqlError{message: "login blocked as suspicious", code: "FORBIDDEN"}
+	case errors.Is(err, auth.ErrAccountDeleted), errors.Is(err, auth.ErrUserNotFound):
+		return &gqlError{message: "account deleted", code: "GONE"}
+	case errors.Is(err, lockout.ErrAccountLocked):
+		return &gqlError{message: "account temporarily locked", code: "ACCOUNT_LOCKED"}
+	case errors.Is(err, auth.ErrUsernameTaken):
+		return &gqlError{message: "username already taken", code: "CONFLICT"}
+	}
+
+	r.log.Error("graphql resolver failed", sl.Err(err))
+
+	return &gqlError{message: "internal error", code: "INTERNAL"}
+}
+
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
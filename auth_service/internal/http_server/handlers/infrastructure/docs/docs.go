@@ -3,6 +3,9 @@ package docsHandler
 import (
 	"net/http"
 
+	resp "auth_service/internal/lib/api/response"
+
+	"github.com/go-chi/render"
 	"github.com/swaggo/swag"
 
 	_ "auth_service/docs"
@@ -16,7 +19,8 @@ func New() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		doc, err := swag.ReadDoc()
 		if err != nil {
-			http.Error(w, "failed to read swagger doc", http.StatusInternalServerError)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "failed to read swagger doc"))
 			return
 		}
 
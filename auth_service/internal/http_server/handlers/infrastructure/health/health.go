@@ -1,33 +1,56 @@
 package health
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	resp "auth_service/internal/lib/api/response"
+	"auth_service/internal/maintenance"
 
 	"github.com/go-chi/render"
 )
 
+type MaintenanceState interface {
+	State(ctx context.Context) maintenance.State
+}
+
+type Maintenance struct {
+	Active bool       `json:"active" example:"true"`
+	Reason string     `json:"reason,omitempty" example:"миграция БД"`
+	Until  *time.Time `json:"until,omitempty" example:"2026-07-24T12:30:00Z"`
+}
+
 type Response struct {
 	resp.Response
+	Maintenance Maintenance `json:"maintenance"`
 }
 
 // New godoc
 //
 //	@Summary		Проверка работоспособности
 //	@Description	Проверяет, запущен ли сервис и готов ли он обрабатывать запросы.
+//	@Description	В режиме обслуживания ответ остаётся 200, а maintenance.active = true:
+//	@Description	реплика жива, просто не выдаёт токены.
 //	@Tags			System
 //	@Produce		json
 //	@Success		200	{object}	health.Response
 //	@Router			/health [get]
-func New() http.HandlerFunc {
+func New(state MaintenanceState) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ResponseOK(w, r)
+		s := state.State(r.Context())
+
+		ResponseOK(w, r, Maintenance{
+			Active: s.Active,
+			Reason: s.Reason,
+			Until:  s.Until,
+		})
 	}
 }
 
-func ResponseOK(w http.ResponseWriter, r *http.Request) {
+func ResponseOK(w http.ResponseWriter, r *http.Request, m Maintenance) {
 	render.JSON(w, r, Response{
-		Response: resp.OK(),
+		Response:    resp.OK(),
+		Maintenance: m,
 	})
 }
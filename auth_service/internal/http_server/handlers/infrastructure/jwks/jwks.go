@@ -0,0 +1,80 @@
+package jwks
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	resp "auth_service/internal/lib/api/response"
+	"auth_service/internal/lib/jwt"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type KeySet interface {
+	PublicSigningKeys(ctx context.Context) ([]models.SigningKey, error)
+}
+
+// Response — JWK Set (RFC 7517). Без обёртки resp.Response: формат
+// фиксирован стандартом, клиенты — готовые JWT-библиотеки.
+type Response struct {
+	Keys []jwt.JWK `json:"keys"`
+}
+
+// New godoc
+//
+//	@Summary		JSON Web Key Set
+//	@Description	Публичные ключи, которыми подписаны access-токены приложений
+//	@Description	с RS256/ES256. Resource server выбирает ключ по kid из заголовка
+//	@Description	токена и проверяет подпись без секрета приложения. Токены
+//	@Description	приложений с HS256 этими ключами не проверяются.
+//	@Tags			System
+//	@Produce		json
+//	@Success		200	{object}	jwks.Response
+//	@Failure		500	{object}	object{status=string,code=string,error=string}	"Внутренняя ошибка сервера"
+//	@Router			/.well-known/jwks.json [get]
+func New(log *slog.Logger, keys KeySet, handlerTimeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.jwks.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		stored, err := keys.PublicSigningKeys(ctx)
+		if err != nil {
+			log.Error("failed to load signing keys", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		set := make([]jwt.JWK, 0, len(stored))
+		for _, key := range stored {
+			jwk, err := jwt.PublicJWK(key)
+			if err != nil {
+				// один битый ключ не должен ломать проверку остальных
+				log.Error("skipping invalid signing key", slog.String("kid", key.KID), sl.Err(err))
+				continue
+			}
+
+			set = append(set, jwk)
+		}
+
+		ResponseOK(w, r, set)
+	}
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request, keys []jwt.JWK) {
+	render.JSON(w, r, Response{Keys: keys})
+}
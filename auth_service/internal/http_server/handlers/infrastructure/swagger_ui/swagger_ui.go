@@ -0,0 +1,32 @@
+package swaggerUIHandler
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// New отдаёт HTML-страницу Swagger UI для спека по specURL (например
+// "/swagger/doc.json"): запросы можно отправить прямо со страницы.
+func New(specURL string) http.HandlerFunc {
+	page := fmt.Sprintf(`<!doctype html>
+<html>
+<head>
+  <title>Auth Service API — Swagger UI</title>
+  <meta charset="utf-8" />
+  <meta name="viewport" content="width=device-width, initial-scale=1" />
+  <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: %q, dom_id: "#swagger-ui", deepLinking: true });
+  </script>
+</body>
+</html>`, specURL)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(page))
+	}
+}
@@ -4,12 +4,19 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"auth_service/internal/auth"
+	"auth_service/internal/auth/lockout"
 	resp "auth_service/internal/lib/api/response"
+	"auth_service/internal/lib/cookie"
 	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/lib/passhash"
+	"auth_service/internal/models"
 	"auth_service/internal/storage"
 
 	"github.com/go-chi/chi/v5/middleware"
@@ -21,6 +28,13 @@ type Request struct {
 	Email string `json:"email" validate:"required,email" example:"example@domain.com"`
 	Pass  string `json:"password" validate:"required" example:"SecurePass123!"`
 	AppID int32  `json:"app_id" validate:"required,gt=0" example:"1"`
+	// DeviceID — стабильный идентификатор установки клиента; новая сессия
+	// на том же устройстве заменяет прежнюю
+	DeviceID   string `json:"device_id,omitempty" validate:"omitempty,max=128" example:"c3f1a2e4-iphone"`
+	DeviceName string `json:"device_name,omitempty" validate:"omitempty,max=64" example:"iPhone 15"`
+	// Scope — запрашиваемые scope'ы через пробел; каждый должен быть
+	// разрешён приложением
+	Scope string `json:"scope,omitempty" validate:"omitempty,max=1024" example:"profile orders:read"`
 }
 
 type Response struct {
@@ -29,52 +43,107 @@ type Response struct {
 	RefreshToken     string `json:"refresh_token,omitempty" example:"fkajeDJ1p3FJ..."`
 	TwoFactorPending bool   `json:"two_factor_pending,omitempty" example:"true"`
 	SessionID        string `json:"session_id,omitempty" example:"afsjeDJ1p3FJ..."`
+	TwoFactorMethod  string `json:"two_factor_method,omitempty" example:"totp"`
+	// CSRFToken — для приложений с refresh-токеном в cookie: повторяется
+	// в заголовке X-CSRF-Token на /auth/refresh и /auth/logout
+	CSRFToken string `json:"csrf_token,omitempty" example:"Qm9vZjRhc2Rm..."`
+}
+
+type Authenticator interface {
+	Login(
+		ctx context.Context,
+		email, password string,
+		appID int32,
+		scopes []string,
+		device *models.Device,
+		trustToken string,
+		pendingSessionTTL time.Duration,
+	) (*auth.LoginResult, error)
 }
 
 // New godoc
 // @Summary      Аутентификация пользователя
 // @Description  ## Описание
 // @Description  Выполняет аутентификацию пользователя по email и паролю. Если
-// @Description  у пользователя включена magic-link 2FA, вместо токенов
-// @Description  возвращается session_id для подтверждения через
-// @Description  /auth/2fa/magic-link/verify; access/refresh в этом случае не выдаются.
+// @Description  у пользователя включена 2FA, вместо токенов возвращается
+// @Description  session_id и two_factor_method: magic_link — подтверждение через
+// @Description  /auth/2fa/magic-link/verify, totp — через /auth/2fa/totp/verify;
+// @Description  access/refresh в этом случае не выдаются.
 // @Description
 // @Description  ### Процесс аутентификации:
 // @Description  1. Валидация входных данных (email формат, наличие пароля)
 // @Description  2. Проверка существования пользователя в базе данных
-// @Description  3. Верификация пароля (bcrypt hash comparison)
+// @Description  3. Верификация пароля (Argon2id или bcrypt; bcrypt-хеш после успешного входа перехешируется в Argon2id)
 // @Description  4. Проверка статуса email (должен быть подтвержден)
 // @Description  5. Валидация app_id (приложение должно существовать)
 // @Description  6. Проверка статуса 2FA:
 // @Description     - если выключена — генерация JWT токенов (access и refresh)
-// @Description     - если включена — создание pending-сессии, отправка magic link на email, возврат session_id без токенов
+// @Description     - если включена — создание pending-сессии (для magic-link — с отправкой письма), возврат session_id и two_factor_method без токенов
 // @Description
 // @Description  ### Токены:
 // @Description  - **Access Token**: JWT токен для доступа к защищенным ресурсам (TTL: 15 минут)
 // @Description  - **Refresh Token**: JWT токен для обновления access токена (TTL: 30 дней)
-// @Description  - **Session ID** (при включённой 2FA): используется для подтверждения через /auth/2fa/magic-link/verify, не является токеном доступа
+// @Description  - **Session ID** (при включённой 2FA): используется для подтверждения второго фактора, не является токеном доступа
+// @Description
+// @Description  ### Устройства:
+// @Description  - Необязательные device_id и device_name привязывают сессию к устройству
+// @Description  - Новый логин с тем же device_id завершает прежнюю сессию на этом устройстве
+// @Description  - device_name показывается в списке сессий; по умолчанию равен device_id
+// @Description  - Устройство узнаётся по device_id, без него — по User-Agent; вход с нового устройства приходит письмом
+// @Description  - При two_factor_auth.new_device_challenge вход с нового устройства требует magic link даже без 2FA
+// @Description
+// @Description  ### Доверенные устройства:
+// @Description  - Токен доверенного устройства из /auth/2fa/*/verify (remember_device) передаётся в заголовке X-Device-Trust или приходит cookie device_trust
+// @Description  - Пока токен действует, 2FA и проверка нового устройства не требуются; невозможное перемещение проверяется всё равно
+// @Description
+// @Description  ### Невозможное перемещение:
+// @Description  - При заданной geo.database_path место входа по IP сравнивается с местом прошлого входа
+// @Description  - Если добраться оттуда можно только быстрее geo.max_speed_kmh, срабатывает geo.action: notify — письмо, challenge — magic link, block — 403
+// @Description
+// @Description  ### Refresh-токен в cookie:
+// @Description  - Приложениям с refresh_token_delivery = cookie refresh-токен ставится HttpOnly cookie, в теле его нет
+// @Description  - В теле и в cookie csrf_token приходит CSRF-токен: его нужно передавать в заголовке X-CSRF-Token на /auth/refresh и /auth/logout
+// @Description
+// @Description  ### Proof-of-work:
+// @Description  - При challenge.enabled после серии неудачных входов с IP или на email вход требует решённой задачи
+// @Description  - Ответ 428 несёт задачу в X-Challenge-Nonce и X-Challenge-Difficulty: нужно подобрать строку, при которой SHA-256(nonce + строка) начинается с difficulty нулевых бит
+// @Description  - Запрос повторяется с X-Challenge-Nonce и решением в X-Challenge-Solution; задача одноразовая
+// @Description
+// @Description  ### Scope'ы:
+// @Description  - Необязательный scope — список через пробел, каждый должен входить в allowed_scopes приложения
+// @Description  - Выданные scope'ы попадают в claim scope access-токена и сохраняются за сессией для refresh
 // @Description
 // @Description  ### Коды ошибок:
-// @Description  - `400` - Некорректные данные (невалидный email, отсутствие полей, невалидный app_id)
-// @Description  - `401` - Неверные credentials (пароль не совпадает; используется и для несуществующего email — не различается намеренно, во избежание user enumeration)
-// @Description  - `403` - Email не подтвержден
+// @Description  - `400` - Некорректные данные (невалидный email, отсутствие полей, невалидный app_id или scope)
+// @Description  - `401` - Неверные credentials (пароль не совпадает; используется и для несуществующего email — не различается намеренно, во избежание user enumeration). Для несуществующего email пароль сверяется с фиктивным хешем, поэтому время ответа тоже одинаковое
+// @Description  - `403` - Email не подтвержден или вход заблокирован как подозрительный (невозможное перемещение)
+// @Description  - `410` - Аккаунт удалён и ждёт окончательного удаления (только при верном пароле, иначе 401)
+// @Description  - `428` - Нужно решить proof-of-work (см. выше)
+// @Description  - `429` - Вход временно заблокирован после серии неверных паролей (Retry-After — сколько ждать; ссылка для разблокировки отправляется на email)
 // @Description  - `500` - Внутренняя ошибка сервера
+// @Description  - `503` - Все воркеры хеширования паролей заняты дольше таймаута запроса (Retry-After)
 // @Tags         auth
 // @Accept       json
 // @Produce      json
-// @Param        credentials  body  object{email=string,password=string,app_id=int}  true  "Данные для входа"
-// @Success      200  {object}  object{status=string,access_token=string,refresh_token=string}  "Успешная аутентификация без 2FA"
-// @Success      200  {object}  object{status=string,two_factor_pending=bool,session_id=string}  "Пароль верен, требуется подтверждение magic-link 2FA"
-// @Failure      400  {object}  object{status=string,error=string}  "Ошибка валидации или невалидный app_id"
-// @Failure      401  {object}  object{status=string,error=string}  "Неверные credentials"
-// @Failure      403  {object}  object{status=string,error=string}  "Email не подтвержден"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка"
+// @Param        credentials  body  object{email=string,password=string,app_id=int,device_id=string,device_name=string,scope=string}  true  "Данные для входа"
+// @Success      200  {object}  object{status=string,access_token=string,refresh_token=string,csrf_token=string}  "Успешная аутентификация без 2FA"
+// @Success      200  {object}  object{status=string,two_factor_pending=bool,session_id=string,two_factor_method=string}  "Пароль верен, требуется подтверждение 2FA"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Ошибка валидации, невалидный app_id или scope"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials"
+// @Failure      403  {object}  object{status=string,code=string,error=string}  "Email не подтвержден, требуется смена пароля, аккаунт заблокирован или вход подозрителен"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "Достигнут лимит активных сессий (sessions.on_limit = reject)"
+// @Failure      410  {object}  object{status=string,code=string,error=string}  "Аккаунт удалён, его можно восстановить"
+// @Failure      428  {object}  object{status=string,code=string,error=string}  "Требуется proof-of-work: задача в X-Challenge-Nonce и X-Challenge-Difficulty"
+// @Failure      429  {object}  object{status=string,code=string,error=string}  "Вход временно заблокирован после серии неверных паролей"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка"
+// @Failure      503  {object}  object{status=string,code=string,error=string}  "Все воркеры хеширования паролей заняты дольше таймаута запроса"
 // @Router       /auth/login [post]
 // @x-order      1
 func New(
 	log *slog.Logger,
 	validate *validator.Validate,
-	authMiddleware *auth.Auth,
+	authenticator Authenticator,
+	cookies *cookie.Jar,
 	handlerTimeout time.Duration,
 	pendingSessionTTL time.Duration,
 ) http.HandlerFunc {
@@ -93,7 +162,7 @@ func New(
 			log.Error("Failed to decode request body", sl.Err(err))
 
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("Failed to decode request"))
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
 
 			return
 		}
@@ -112,7 +181,7 @@ func New(
 
 			log.Error("unexpected validation error type", sl.Err(err))
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
 
 			return
 		}
@@ -120,59 +189,117 @@ func New(
 		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
 		defer cancel()
 
-		loginResult, err := authMiddleware.Login(ctx, req.Email, req.Pass, req.AppID, pendingSessionTTL)
+		loginResult, err := authenticator.Login(ctx, req.Email, req.Pass, req.AppID, strings.Fields(req.Scope), models.NewDevice(req.DeviceID, req.DeviceName), cookies.DeviceTrust(r), pendingSessionTTL)
 		if err != nil {
 			switch {
-			case errors.Is(err, storage.ErrUserNotFound), errors.Is(err, auth.ErrInvalidCredentials):
+			// не-участник приложения неотличим от неверного пароля: ответ не
+			// должен раскрывать, что аккаунт есть в другом продукте
+			case errors.Is(err, storage.ErrUserNotFound),
+				errors.Is(err, auth.ErrInvalidCredentials),
+				errors.Is(err, auth.ErrNotAppMember):
 				render.Status(r, http.StatusUnauthorized)
-				render.JSON(w, r, resp.Error("Invalid credentials"))
+				render.JSON(w, r, resp.Error(resp.CodeInvalidCredentials, "Invalid credentials"))
 				return
 			case errors.Is(err, auth.ErrInvalidAppID):
 				render.Status(r, http.StatusBadRequest)
-				render.JSON(w, r, resp.Error("Invalid app id"))
+				render.JSON(w, r, resp.Error(resp.CodeInvalidApp, "Invalid app id"))
+				return
+			case errors.Is(err, auth.ErrInvalidScope):
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidScope, "Invalid scope"))
 				return
 			case errors.Is(err, auth.ErrEmailNotVerified):
 				render.Status(r, http.StatusForbidden)
-				render.JSON(w, r, resp.Error("Email is not verified"))
+				render.JSON(w, r, resp.Error(resp.CodeEmailNotVerified, "Email is not verified"))
+				return
+			case errors.Is(err, auth.ErrAccountDisabled):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodeAccountDisabled, "Account disabled"))
+				return
+			case errors.Is(err, auth.ErrAccountSuspended):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodeAccountSuspended, "Account suspended"))
+				return
+			case errors.Is(err, auth.ErrAccountBanned):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodeAccountBanned, "Account banned"))
+				return
+			case errors.Is(err, auth.ErrSessionLimit):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error(resp.CodeSessionLimit, "Active session limit reached"))
+				return
+			case errors.Is(err, auth.ErrPasswordResetRequired):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodePasswordResetRequired, "Password reset required"))
+				return
+			case errors.Is(err, auth.ErrSuspiciousLogin):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodeSuspiciousLogin, "Login blocked as suspicious"))
 				return
 			case errors.Is(err, auth.ErrAccountDeleted):
 				render.Status(r, http.StatusGone)
-				render.JSON(w, r, resp.Error("Account deleted"))
+				render.JSON(w, r, resp.Error(resp.CodeAccountDeleted, "Account deleted"))
+				return
+			case errors.Is(err, lockout.ErrAccountLocked):
+				var locked *lockout.LockedError
+				if errors.As(err, &locked) {
+					w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+				}
+
+				render.Status(r, http.StatusTooManyRequests)
+				render.JSON(w, r, resp.Error(resp.CodeAccountLocked, "Too many failed login attempts, account temporarily locked"))
+				return
+			case errors.Is(err, passhash.ErrBusy):
+				w.Header().Set("Retry-After", "1")
+				render.Status(r, http.StatusServiceUnavailable)
+				render.JSON(w, r, resp.Error(resp.CodeServiceUnavailable, "service temporarily unavailable"))
 				return
 			}
 
 			log.Error("failed to login user", sl.Err(err))
 
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("Internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
 
 			return
 		}
 
 		if loginResult.TwoFactorPending {
 			log.Info("password verified, 2fa challenge issued")
-			ResponseTwoFAPending(w, r, loginResult.SessionID)
+			ResponseTwoFAPending(w, r, loginResult.SessionID, loginResult.TwoFactorMethod)
+			return
+		}
+
+		refreshToken, csrfToken, err := cookies.Deliver(ctx, w, loginResult.RefreshToken, loginResult.RefreshExpiresAt, loginResult.RefreshCookie)
+		if err != nil {
+			log.Error("failed to set refresh cookie", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
 			return
 		}
 
 		log.Info("User logged in successfully")
 
-		ResponseOK(w, r, loginResult.AccessToken, loginResult.RefreshToken)
+		ResponseOK(w, r, loginResult.AccessToken, refreshToken, csrfToken)
 	}
 }
 
-func ResponseOK(w http.ResponseWriter, r *http.Request, accessToken, refreshToken string) {
+func ResponseOK(w http.ResponseWriter, r *http.Request, accessToken, refreshToken, csrfToken string) {
 	render.JSON(w, r, Response{
 		Response:     resp.OK(),
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
+		CSRFToken:    csrfToken,
 	})
 }
 
-func ResponseTwoFAPending(w http.ResponseWriter, r *http.Request, sessionID string) {
+func ResponseTwoFAPending(w http.ResponseWriter, r *http.Request, sessionID, method string) {
 	render.JSON(w, r, Response{
 		Response:         resp.OK(),
 		TwoFactorPending: true,
 		SessionID:        sessionID,
+		TwoFactorMethod:  method,
 	})
 }
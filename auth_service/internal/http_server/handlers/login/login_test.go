@@ -0,0 +1,165 @@
+package login_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"auth_service/internal/auth/authtest"
+	"auth_service/internal/config"
+	"auth_service/internal/http_server/handlers/login"
+	resp "auth_service/internal/lib/api/response"
+	"auth_service/internal/lib/cookie"
+	customValidator "auth_service/internal/lib/validation/custom_validator"
+)
+
+const password = "correct horse battery staple"
+
+func newHandler(env *authtest.Env) http.HandlerFunc {
+	return login.New(
+		slog.New(slog.DiscardHandler),
+		customValidator.New(),
+		env.Auth,
+		cookie.New(config.RefreshCookie{}, nil),
+		5*time.Second,
+		time.Minute,
+	)
+}
+
+func post(t *testing.T, h http.HandlerFunc, email, pass string, appID int32) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]any{"email": email, "password": pass, "app_id": appID})
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(string(body)))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	h(w, r)
+
+	return w
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder) login.Response {
+	t.Helper()
+
+	var res login.Response
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("decode response %q: %v", w.Body.String(), err)
+	}
+
+	return res
+}
+
+func TestLoginOK(t *testing.T) {
+	env := authtest.New(t)
+	app := env.App(t, "web")
+	env.User(t, app.ID, "alice@example.com", password)
+
+	w := post(t, newHandler(env), "alice@example.com", password, app.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+
+	res := decode(t, w)
+	if res.AccessToken == "" || res.RefreshToken == "" {
+		t.Fatalf("tokens missing: %s", w.Body)
+	}
+}
+
+// 401 — неверные credentials, 403 — верный пароль к аккаунту, которому
+// вход пока запрещён. Статус аккаунта не раскрывается без пароля.
+func TestLoginUnauthorizedVsForbidden(t *testing.T) {
+	env := authtest.New(t)
+	app := env.App(t, "web")
+
+	if _, err := env.Auth.RegisterNewUser(t.Context(), "bob@example.com", "bob", password, app.ID); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	h := newHandler(env)
+
+	tests := []struct {
+		name   string
+		pass   string
+		status int
+		code   resp.Code
+	}{
+		{"wrong password", "wrong", http.StatusUnauthorized, resp.CodeInvalidCredentials},
+		{"unverified email", password, http.StatusForbidden, resp.CodeEmailNotVerified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := post(t, h, "bob@example.com", tt.pass, app.ID)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+
+			if res := decode(t, w); res.Code != tt.code {
+				t.Fatalf("code = %q, want %q", res.Code, tt.code)
+			}
+		})
+	}
+}
+
+// Ответы на несуществующий email и на неверный пароль совпадают побайтно
+// — и до блокировки, и после неё.
+func TestLoginUnknownEmailResponseMatchesWrongPassword(t *testing.T) {
+	env := authtest.New(t)
+	app := env.App(t, "web")
+	env.User(t, app.ID, "alice@example.com", password)
+
+	h := newHandler(env)
+
+	for i := range authtest.Lockout.MaxAttempts + 1 {
+		existing := post(t, h, "alice@example.com", "wrong", app.ID)
+		unknown := post(t, h, "ghost@example.com", "wrong", app.ID)
+
+		want := http.StatusUnauthorized
+		if i >= authtest.Lockout.MaxAttempts-1 {
+			want = http.StatusTooManyRequests
+		}
+
+		if existing.Code != want || unknown.Code != want {
+			t.Fatalf("attempt %d: status existing %d, unknown %d, want %d", i+1, existing.Code, unknown.Code, want)
+		}
+		if existing.Body.String() != unknown.Body.String() {
+			t.Fatalf("attempt %d: bodies differ:\n%s\n%s", i+1, existing.Body, unknown.Body)
+		}
+		if existing.Header().Get("Retry-After") != unknown.Header().Get("Retry-After") {
+			t.Fatalf("attempt %d: Retry-After differs: %q vs %q",
+				i+1, existing.Header().Get("Retry-After"), unknown.Header().Get("Retry-After"))
+		}
+	}
+}
+
+// Для несуществующего email пароль сверяется с фиктивным хешем, для
+// существующего — только с его собственным.
+func TestLoginUnknownEmailVerifiesDummyHash(t *testing.T) {
+	env := authtest.New(t)
+	app := env.App(t, "web")
+	env.User(t, app.ID, "alice@example.com", password)
+
+	h := newHandler(env)
+
+	post(t, h, "alice@example.com", "wrong", app.ID)
+	if got := env.Hasher.DummyCalls(); got != 0 {
+		t.Fatalf("dummy hash calls after wrong password = %d, want 0", got)
+	}
+
+	w := post(t, h, "ghost@example.com", "wrong", app.ID)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if got := env.Hasher.DummyCalls(); got != 1 {
+		t.Fatalf("dummy hash calls after unknown email = %d, want 1", got)
+	}
+}
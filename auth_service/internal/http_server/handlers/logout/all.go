@@ -0,0 +1,84 @@
+package logout
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type AllLogouter interface {
+	LogoutAll(ctx context.Context, userID int64) (*models.ForceLogoutResult, error)
+}
+
+type AllResponse struct {
+	resp.Response
+	SessionsTerminated int64 `json:"sessions_terminated" example:"3"`
+}
+
+// NewAll godoc
+// @Summary      Выход со всех устройств
+// @Description  ## Описание
+// @Description  Завершает все сессии текущего пользователя: удаляет все refresh-токены и
+// @Description  отзывает все выданные access-токены, включая тот, с которым пришёл запрос.
+// @Description
+// @Description  Используется, если пользователь подозревает, что аккаунт скомпрометирован.
+// @Description  Событие попадает в ленту активности (/me/activity).
+// @Tags         auth
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  AllResponse  "Все сессии завершены"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /auth/logout/all [post]
+func NewAll(
+	log *slog.Logger,
+	svc AllLogouter,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.logout.NewAll"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		result, err := svc.LogoutAll(ctx, claims.UserID)
+		if err != nil {
+			log.Error("failed to logout from all sessions", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		ResponseAllOK(w, r, result.RefreshTokensDeleted)
+	}
+}
+
+func ResponseAllOK(w http.ResponseWriter, r *http.Request, terminated int64) {
+	render.JSON(w, r, AllResponse{
+		Response:           resp.OK(),
+		SessionsTerminated: terminated,
+	})
+}
@@ -3,12 +3,14 @@ package logout
 import (
 	"context"
 	"errors"
+	"io"
 	"log/slog"
 	"net/http"
 	"time"
 
 	"auth_service/internal/auth"
 	resp "auth_service/internal/lib/api/response"
+	"auth_service/internal/lib/cookie"
 	sl "auth_service/internal/lib/logger"
 
 	"github.com/go-chi/chi/v5/middleware"
@@ -17,13 +19,18 @@ import (
 )
 
 type Request struct {
-	RefreshToken string `json:"refresh_token" validate:"required,refresh_token_format" example:"fkajeDJ1p3FJ..."`
+	// RefreshToken — пусто для приложений с refresh-токеном в cookie
+	RefreshToken string `json:"refresh_token" validate:"omitempty,refresh_token_format" example:"fkajeDJ1p3FJ..."`
 }
 
 type Response struct {
 	resp.Response
 }
 
+type Logouter interface {
+	Logout(ctx context.Context, rawRefreshToken string) error
+}
+
 // New godoc
 // @Summary      Выход из системы
 // @Description  ## Описание
@@ -40,7 +47,8 @@ type Response struct {
 // @Description  - После logout refresh токен больше нельзя использовать для получения новых access токенов
 // @Description  - Access токен технически остается валидным до истечения TTL (~15 минут)
 // @Description  - Для немедленной инвалидации access токена используется blacklist в Redis
-// @Description  - Поддержка "logout from all devices" (опционально)
+// @Description  - Выход со всех устройств — /auth/logout/all
+// @Description  - Без refresh_token в теле токен берётся из HttpOnly cookie (нужен заголовок X-CSRF-Token); cookie удаляются
 // @Description
 // @Description  ### Безопасность:
 // @Description  - Токен в blacklist хранится только до истечения его TTL
@@ -49,17 +57,20 @@ type Response struct {
 // @Tags         auth
 // @Accept       json
 // @Produce      json
-// @Param        token  body  object{refresh_token=string}  true  "Refresh токен для инвалидации"
+// @Param        token  body  object{refresh_token=string}  false  "Refresh токен для инвалидации"
+// @Param        X-CSRF-Token  header  string  false  "CSRF-токен, если refresh-токен в cookie"
 // @Success      200  {object}  object{status=string}  "Успешный выход из системы"
-// @Failure      400  {object}  object{status=string,error=string}  "Ошибка валидации: токен не передан или некорректный JSON"
-// @Failure      401  {object}  object{status=string,error=string}  "Невалидный или истекший refresh токен"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Ошибка валидации: токен не передан или некорректный JSON"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Невалидный или истекший refresh токен"
+// @Failure      403  {object}  object{status=string,code=string,error=string}  "CSRF-токен не совпал"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
 // @Router       /auth/logout [post]
 // @x-order      4
 func New(
 	log *slog.Logger,
 	validate *validator.Validate,
-	authMiddleware *auth.Auth,
+	logouter Logouter,
+	cookies *cookie.Jar,
 	handlerTimeout time.Duration,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -72,12 +83,13 @@ func New(
 
 		var req Request
 
+		// тело может быть пустым: refresh-токен тогда берётся из cookie
 		err := render.DecodeJSON(r.Body, &req)
-		if err != nil {
+		if err != nil && !errors.Is(err, io.EOF) {
 			log.Error("Failed to decode request body", sl.Err(err))
 
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("Failed to decode request"))
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
 
 			return
 		}
@@ -96,31 +108,49 @@ func New(
 
 			log.Error("unexpected validation error type", sl.Err(err))
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
 
 			return
 		}
 
+		fromCookie := req.RefreshToken == ""
+		if fromCookie {
+			// CSRF-заголовок уже сверил csrfGuard
+			req.RefreshToken, err = cookies.Read(r)
+			if err != nil {
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidCredentials, "invalid credentials"))
+
+				return
+			}
+		}
+
 		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
 		defer cancel()
 
-		if err := authMiddleware.Logout(ctx, req.RefreshToken); err != nil {
+		if err := logouter.Logout(ctx, req.RefreshToken); err != nil {
 			log.Error("failed to logout user", sl.Err(err))
 
 			if errors.Is(err, auth.ErrInvalidCredentials) {
 				render.Status(r, http.StatusUnauthorized)
 
-				render.JSON(w, r, resp.Error("invalid credentials"))
+				render.JSON(w, r, resp.Error(resp.CodeInvalidCredentials, "invalid credentials"))
 
 				return
 			}
 
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("Internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
 
 			return
 		}
 
+		if fromCookie {
+			if err := cookies.Clear(ctx, w, req.RefreshToken); err != nil {
+				log.Error("failed to clear refresh cookie", sl.Err(err))
+			}
+		}
+
 		log.Info("user logged out successfully")
 
 		ResponseOK(w, r)
@@ -0,0 +1,186 @@
+package activity
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+type ActivityProvider interface {
+	Activity(ctx context.Context, userID, beforeID int64, limit int) ([]models.AuditEvent, error)
+}
+
+type Event struct {
+	ID     int64  `json:"id" example:"8812"`
+	Action string `json:"action" example:"new_device"`
+	// InitiatedBy — user, admin или system.
+	InitiatedBy string    `json:"initiated_by" example:"user"`
+	IP          string    `json:"ip,omitempty" example:"203.0.113.7"`
+	UserAgent   string    `json:"user_agent,omitempty" example:"Mozilla/5.0"`
+	DeviceName  string    `json:"device_name,omitempty" example:"iPhone 15"`
+	CreatedAt   time.Time `json:"created_at" example:"2026-07-24T12:00:00Z"`
+}
+
+type Response struct {
+	resp.Response
+	Events []Event `json:"events"`
+	// NextCursor — передать в cursor за следующей страницей. Пусто — страниц больше нет.
+	NextCursor string `json:"next_cursor,omitempty" example:"8790"`
+}
+
+// New godoc
+// @Summary      Лента активности аккаунта
+// @Description  ## Описание
+// @Description  Возвращает события безопасности текущего пользователя от новых к старым:
+// @Description  смена пароля, вход с нового устройства, включение и отключение 2FA,
+// @Description  завершение сессий, удаление и восстановление аккаунта, действия поддержки.
+// @Description
+// @Description  ### Пагинация:
+// @Description  - limit — размер страницы, по умолчанию 20, максимум 100
+// @Description  - cursor — next_cursor из предыдущего ответа
+// @Description
+// @Description  Для действий поддержки IP и User-Agent не возвращаются — это данные администратора.
+// @Tags         me
+// @Security     BearerAuth
+// @Produce      json
+// @Param        limit   query  int     false  "Размер страницы (1-100)"
+// @Param        cursor  query  string  false  "Курсор следующей страницы"
+// @Success      200  {object}  Response  "Страница событий"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный limit или cursor"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /me/activity [get]
+func New(
+	log *slog.Logger,
+	provider ActivityProvider,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.me.activity.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		limit, beforeID, ok := parsePage(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid limit or cursor"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		events, err := provider.Activity(ctx, claims.UserID, beforeID, limit)
+		if err != nil {
+			log.Error("failed to load activity", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
+
+			return
+		}
+
+		var nextCursor string
+		if len(events) == limit {
+			nextCursor = strconv.FormatInt(events[len(events)-1].ID, 10)
+		}
+
+		ResponseOK(w, r, toEvents(events), nextCursor)
+	}
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request, events []Event, nextCursor string) {
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, Response{
+		Response:   resp.OK(),
+		Events:     events,
+		NextCursor: nextCursor,
+	})
+}
+
+func parsePage(r *http.Request) (limit int, beforeID int64, ok bool) {
+	limit = defaultLimit
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxLimit {
+			return 0, 0, false
+		}
+		limit = n
+	}
+
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			return 0, 0, false
+		}
+		beforeID = id
+	}
+
+	return limit, beforeID, true
+}
+
+func toEvents(events []models.AuditEvent) []Event {
+	result := make([]Event, 0, len(events))
+
+	for _, e := range events {
+		event := Event{
+			ID:          e.ID,
+			Action:      string(e.Action),
+			InitiatedBy: initiatedBy(e.Actor),
+			CreatedAt:   e.CreatedAt,
+		}
+
+		if event.InitiatedBy == "user" {
+			event.IP = e.IP
+			event.UserAgent = e.UserAgent
+		}
+
+		if name, ok := e.Metadata["device_name"].(string); ok {
+			event.DeviceName = name
+		}
+
+		result = append(result, event)
+	}
+
+	return result
+}
+
+// initiatedBy скрывает логин администратора: пользователю достаточно
+// знать, что действие выполнила поддержка.
+func initiatedBy(actor string) string {
+	switch {
+	case actor == "user":
+		return "user"
+	case strings.HasPrefix(actor, "admin:"):
+		return "admin"
+	default:
+		return "system"
+	}
+}
@@ -0,0 +1,168 @@
+package identities
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth_service/internal/auth/identity"
+	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type IdentityManager interface {
+	List(ctx context.Context, userID int64) ([]*models.Identity, error)
+	Unlink(ctx context.Context, userID int64, provider string) error
+}
+
+type Identity struct {
+	// Kind — oauth или saml.
+	Kind      string    `json:"kind" example:"oauth"`
+	Provider  string    `json:"provider" example:"google"`
+	Email     string    `json:"email" example:"example@domain.com"`
+	CreatedAt time.Time `json:"created_at" example:"2026-07-24T12:00:00Z"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Identities []Identity `json:"identities"`
+}
+
+// NewList godoc
+// @Summary      Список привязанных внешних учёток
+// @Description  Возвращает все внешние учётки (OAuth-провайдеры, SAML), привязанные
+// @Description  к аккаунту текущего пользователя, в порядке привязки.
+// @Tags         me
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  ListResponse  "Список привязанных учёток"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /me/identities [get]
+func NewList(
+	log *slog.Logger,
+	manager IdentityManager,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.me.identities.NewList"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		identities, err := manager.List(ctx, claims.UserID)
+		if err != nil {
+			log.Error("failed to list identities", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
+
+			return
+		}
+
+		ResponseList(w, r, toIdentities(identities))
+	}
+}
+
+// NewUnlink godoc
+// @Summary      Отвязка внешней учётки
+// @Description  Удаляет привязку провайдера к аккаунту текущего пользователя.
+// @Description  Последний способ входа (нет пароля и других учёток) отвязать нельзя,
+// @Description  чтобы пользователь не потерял доступ к аккаунту.
+// @Tags         me
+// @Security     BearerAuth
+// @Produce      json
+// @Param        provider  path  string  true  "Название провайдера (например: google, github)"
+// @Success      204  "Учётка отвязана"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      403  {object}  object{status=string,code=string,error=string}  "Нельзя отвязать последний способ входа"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Провайдер не привязан"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /me/identities/{provider} [delete]
+func NewUnlink(
+	log *slog.Logger,
+	manager IdentityManager,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.me.identities.NewUnlink"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		if err := manager.Unlink(ctx, claims.UserID, chi.URLParam(r, "provider")); err != nil {
+			switch {
+			case errors.Is(err, identity.ErrLastAuthMethod):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodeLastAuthMethod, "cannot unlink last authentication method"))
+			case errors.Is(err, identity.ErrNotFound):
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeIdentityNotFound, "identity not found"))
+			default:
+				log.Error("failed to unlink identity", sl.Err(err))
+
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
+			}
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func ResponseList(w http.ResponseWriter, r *http.Request, identities []Identity) {
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, ListResponse{
+		Response:   resp.OK(),
+		Identities: identities,
+	})
+}
+
+func toIdentities(identities []*models.Identity) []Identity {
+	result := make([]Identity, 0, len(identities))
+
+	for _, i := range identities {
+		result = append(result, Identity{
+			Kind:      string(i.Kind),
+			Provider:  i.Provider,
+			Email:     i.Email,
+			CreatedAt: i.CreatedAt,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,237 @@
+package trustedDevices
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type TrustedDeviceManager interface {
+	List(ctx context.Context, userID int64) ([]models.TrustedDevice, error)
+	Revoke(ctx context.Context, userID, id int64) error
+	RevokeAll(ctx context.Context, userID int64) (int64, error)
+}
+
+type Device struct {
+	ID         int64     `json:"id" example:"42"`
+	DeviceID   string    `json:"device_id,omitempty" example:"b7c1e0a2-5f3d-4c1e-9a8b-2d6f0e4c7a91"`
+	DeviceName string    `json:"device_name,omitempty" example:"iPhone 15"`
+	IP         string    `json:"ip,omitempty" example:"203.0.113.7"`
+	UserAgent  string    `json:"user_agent,omitempty" example:"Mozilla/5.0"`
+	CreatedAt  time.Time `json:"created_at" example:"2026-07-24T12:00:00Z"`
+	LastUsedAt time.Time `json:"last_used_at" example:"2026-07-30T08:15:00Z"`
+	ExpiresAt  time.Time `json:"expires_at" example:"2026-08-23T12:00:00Z"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Devices []Device `json:"devices"`
+}
+
+type RevokeAllResponse struct {
+	resp.Response
+	Revoked int64 `json:"revoked" example:"3"`
+}
+
+// NewList godoc
+// @Summary      Список доверенных устройств
+// @Description  Возвращает устройства, на которых пользователь при подтверждении 2FA
+// @Description  выбрал remember_device и вход с которых пока не требует второго фактора.
+// @Description  Истёкшие устройства в список не попадают.
+// @Tags         me
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  ListResponse  "Список доверенных устройств"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /me/trusted-devices [get]
+func NewList(
+	log *slog.Logger,
+	manager TrustedDeviceManager,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.me.trustedDevices.NewList"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		devices, err := manager.List(ctx, claims.UserID)
+		if err != nil {
+			log.Error("failed to list trusted devices", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
+
+			return
+		}
+
+		ResponseList(w, r, toDevices(devices))
+	}
+}
+
+// NewRevoke godoc
+// @Summary      Отзыв доверия к устройству
+// @Description  Следующий вход с этого устройства снова потребует второй фактор.
+// @Tags         me
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id  path  int  true  "ID доверенного устройства"
+// @Success      204  "Доверие отозвано"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный id"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Устройство не найдено"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /me/trusted-devices/{id} [delete]
+func NewRevoke(
+	log *slog.Logger,
+	manager TrustedDeviceManager,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.me.trustedDevices.NewRevoke"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil || id <= 0 {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid trusted device id"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		if err := manager.Revoke(ctx, claims.UserID, id); err != nil {
+			if errors.Is(err, storage.ErrTrustedDeviceNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeTrustedDeviceNotFound, "trusted device not found"))
+				return
+			}
+
+			log.Error("failed to revoke trusted device", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// NewRevokeAll godoc
+// @Summary      Отзыв доверия ко всем устройствам
+// @Description  Все устройства пользователя снова будут спрашивать второй фактор.
+// @Description  Смена пароля и завершение всех сессий отзывают доверие так же.
+// @Tags         me
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  RevokeAllResponse  "Число отозванных устройств"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /me/trusted-devices [delete]
+func NewRevokeAll(
+	log *slog.Logger,
+	manager TrustedDeviceManager,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.me.trustedDevices.NewRevokeAll"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		revoked, err := manager.RevokeAll(ctx, claims.UserID)
+		if err != nil {
+			log.Error("failed to revoke trusted devices", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
+
+			return
+		}
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, RevokeAllResponse{
+			Response: resp.OK(),
+			Revoked:  revoked,
+		})
+	}
+}
+
+func ResponseList(w http.ResponseWriter, r *http.Request, devices []Device) {
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, ListResponse{
+		Response: resp.OK(),
+		Devices:  devices,
+	})
+}
+
+func toDevices(devices []models.TrustedDevice) []Device {
+	result := make([]Device, 0, len(devices))
+
+	for _, d := range devices {
+		result = append(result, Device{
+			ID:         d.ID,
+			DeviceID:   d.DeviceID,
+			DeviceName: d.DeviceName,
+			IP:         d.IP,
+			UserAgent:  d.UserAgent,
+			CreatedAt:  d.CreatedAt,
+			LastUsedAt: d.LastUsedAt,
+			ExpiresAt:  d.ExpiresAt,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,258 @@
+package twoFactor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth_service/internal/auth"
+	twoFactorAuth "auth_service/internal/auth/2fa"
+	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type SettingsProvider interface {
+	TwoFASettings(ctx context.Context, userID int64) (*models.TwoFASettings, error)
+	UpdateTwoFASettings(ctx context.Context, userID int64, upd auth.TwoFASettingsUpdate) (*models.TwoFASettings, error)
+}
+
+type Request struct {
+	Enabled *bool `json:"enabled,omitempty" example:"true"`
+	// Channel — куда доставлять magic link; destination — номер в E.164
+	// для sms, chat_id для telegram.
+	Channel     string `json:"channel,omitempty" validate:"omitempty,oneof=email sms telegram" example:"sms"`
+	Destination string `json:"destination,omitempty" validate:"required_if=Channel sms,required_if=Channel telegram" example:"+79991234567"`
+	// Подтверждение выключения 2FA — как в /auth/2fa/magic-link/disable.
+	Password  string `json:"password,omitempty" example:"SecurePass123!"`
+	SessionID string `json:"session_id,omitempty" example:"abcDEF123..."`
+	Token     string `json:"token,omitempty" example:"fkajeDJ1p3FJ..."`
+}
+
+type MagicLink struct {
+	ID        int64     `json:"id" example:"412"`
+	AppID     int32     `json:"app_id" example:"1"`
+	IP        string    `json:"ip,omitempty" example:"203.0.113.7"`
+	UserAgent string    `json:"user_agent,omitempty" example:"Mozilla/5.0"`
+	CreatedAt time.Time `json:"created_at" example:"2026-07-24T12:00:00Z"`
+	ExpiresAt time.Time `json:"expires_at" example:"2026-07-24T12:10:00Z"`
+}
+
+type Response struct {
+	resp.Response
+	Enabled bool `json:"enabled" example:"true"`
+	// Method — magic_link или totp, пусто при выключенной 2FA.
+	Method      string      `json:"method,omitempty" example:"magic_link"`
+	Channel     string      `json:"channel" example:"sms"`
+	Destination string      `json:"destination,omitempty" example:"+79991234567"`
+	MagicLinks  []MagicLink `json:"active_magic_links"`
+}
+
+// NewGet godoc
+// @Summary      Настройки 2FA
+// @Description  Возвращает, включена ли 2FA и каким способом, куда приходят
+// @Description  magic link (email, sms, telegram) и ещё не использованные
+// @Description  ссылки с адресом и клиентом, откуда их запросили.
+// @Tags         me
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  Response  "Настройки 2FA"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /me/2fa [get]
+func NewGet(
+	log *slog.Logger,
+	provider SettingsProvider,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.me.twoFactor.NewGet"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		settings, err := provider.TwoFASettings(ctx, claims.UserID)
+		if err != nil {
+			log.Error("failed to load 2fa settings", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
+
+			return
+		}
+
+		ResponseOK(w, r, settings)
+	}
+}
+
+// NewUpdate godoc
+// @Summary      Изменить настройки 2FA
+// @Description  ## Описание
+// @Description  Включает или выключает magic-link 2FA и выбирает канал
+// @Description  доставки ссылок. Не переданные поля не меняются.
+// @Description
+// @Description  ### Правила:
+// @Description  - канал меняется только при выключенной 2FA; если в одном запросе
+// @Description    выключить 2FA и сменить канал, сначала выполняется выключение
+// @Description  - для sms и telegram в канал сначала уходит проверочное сообщение
+// @Description  - выключение требует password, а у аккаунта без пароля — session_id
+// @Description    и token из /auth/2fa/magic-link/disable/request-confirmation
+// @Description  - TOTP 2FA выключается через /auth/2fa/totp/disable
+// @Tags         me
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "Изменения настроек"
+// @Success      200  {object}  Response  "Настройки после изменения"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Невалидное тело запроса, неверный номер или chat_id"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token невалиден, либо неверное подтверждение выключения"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "Смена канала при включённой 2FA, TOTP 2FA, либо нет фактора для будущего выключения"
+// @Failure      422  {object}  object{status=string,code=string,error=string}  "Проверочное сообщение не доставлено"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      501  {object}  object{status=string,code=string,error=string}  "Канал не настроен на сервере"
+// @Router       /me/2fa [put]
+func NewUpdate(
+	log *slog.Logger,
+	validate *validator.Validate,
+	provider SettingsProvider,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.me.twoFactor.NewUpdate"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		var req Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+
+			if errors.As(err, &validateErr) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+				return
+			}
+
+			log.Error("unexpected validation error type", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+			return
+		}
+
+		upd := auth.TwoFASettingsUpdate{
+			Enabled:   req.Enabled,
+			Password:  req.Password,
+			SessionID: req.SessionID,
+			Token:     req.Token,
+		}
+		if req.Channel != "" {
+			upd.Delivery = &models.TwoFADelivery{
+				Channel:     models.TwoFAChannel(req.Channel),
+				Destination: req.Destination,
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		settings, err := provider.UpdateTwoFASettings(ctx, claims.UserID, upd)
+		if err != nil {
+			writeUpdateError(w, r, log, err)
+			return
+		}
+
+		log.Info("2fa settings updated", slog.Int64("user_id", claims.UserID))
+
+		ResponseOK(w, r, settings)
+	}
+}
+
+func writeUpdateError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, twoFactorAuth.ErrInvalidDestination):
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid destination for channel"))
+	case errors.Is(err, auth.ErrDisableConfirmation):
+		render.Status(r, http.StatusUnauthorized)
+		render.JSON(w, r, resp.Error(resp.CodeInvalidConfirmation, "invalid confirmation"))
+	case errors.Is(err, auth.ErrTwoFAAlreadyEnabled):
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, resp.Error(resp.CodeTwoFAAlreadyEnabled, "disable 2fa before changing its channel"))
+	case errors.Is(err, auth.ErrTOTPEnabled):
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, resp.Error(resp.CodeTwoFAMethodMismatch, "2fa is enabled via totp, use /auth/2fa/totp/disable"))
+	case errors.Is(err, auth.ErrNoAuthFactorAvailable):
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, resp.Error(resp.CodeTwoFANoFactor, "no password or linked oauth account to enable 2fa"))
+	case errors.Is(err, twoFactorAuth.ErrChannelDelivery):
+		render.Status(r, http.StatusUnprocessableEntity)
+		render.JSON(w, r, resp.Error(resp.CodeTwoFAChannelUndeliverable, "failed to deliver test message"))
+	case errors.Is(err, twoFactorAuth.ErrChannelUnavailable):
+		render.Status(r, http.StatusNotImplemented)
+		render.JSON(w, r, resp.Error(resp.CodeTwoFAChannelUnavailable, "channel is not configured"))
+	default:
+		log.Error("failed to update 2fa settings", sl.Err(err))
+
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
+	}
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request, settings *models.TwoFASettings) {
+	links := make([]MagicLink, 0, len(settings.MagicLinks))
+	for _, l := range settings.MagicLinks {
+		links = append(links, MagicLink{
+			ID:        l.ID,
+			AppID:     l.AppID,
+			IP:        l.IPAddress,
+			UserAgent: l.UserAgent,
+			CreatedAt: l.CreatedAt,
+			ExpiresAt: l.ExpiresAt,
+		})
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, Response{
+		Response:    resp.OK(),
+		Enabled:     settings.Enabled,
+		Method:      settings.Method,
+		Channel:     string(settings.Delivery.Channel),
+		Destination: settings.Delivery.Destination,
+		MagicLinks:  links,
+	})
+}
@@ -30,12 +30,14 @@ type Response struct {
 // @Summary      Список привязанных OAuth-аккаунтов
 // @Description  Возвращает все OAuth-провайдеры, привязанные к аккаунту
 // @Description  текущего аутентифицированного пользователя.
+// @Description  Устарел: используйте GET /me/identities.
 // @Tags         oauth
+// @Deprecated
 // @Security     BearerAuth
 // @Produce      json
 // @Success      200  {object}  Response  "Список привязанных аккаунтов"
-// @Failure      401  {object}  object{status=string,error=string}  "Access token отсутствует, невалиден или истёк"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
 // @Router       /auth/oauth/accounts [get]
 func New(
 	log *slog.Logger,
@@ -52,7 +54,7 @@ func New(
 		claims, ok := claimsParser.ClaimsFromContext(r.Context())
 		if !ok {
 			render.Status(r, http.StatusUnauthorized)
-			render.JSON(w, r, resp.Error("invalid or expired access token"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
 			return
 		}
 
@@ -61,7 +63,7 @@ func New(
 			log.Error("failed to list oauth accounts", sl.Err(err))
 
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("internal server error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
 
 			return
 		}
@@ -78,7 +80,7 @@ func ResponseOK(w http.ResponseWriter, r *http.Request, accounts []Account) {
 	})
 }
 
-func toAccounts(accounts []*models.OAuthAccount) []Account {
+func toAccounts(accounts []*models.Identity) []Account {
 	result := make([]Account, 0, len(accounts))
 
 	for _, a := range accounts {
@@ -8,10 +8,10 @@ import (
 	"time"
 
 	"auth_service/internal/auth"
+	"auth_service/internal/auth/identity"
 	"auth_service/internal/auth/oauth"
 	resp "auth_service/internal/lib/api/response"
 	sl "auth_service/internal/lib/logger"
-	"auth_service/internal/storage"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -37,11 +37,11 @@ type Response struct {
 // @Param        state     query  string  true  "Токен состояния (state), должен совпадать со значением, выданным при начале авторизации или привязки аккаунта"
 // @Param        error     query  string  false "Код ошибки, возвращаемый OAuth-провайдером, если пользователь отказал в доступе"
 // @Success      200  {object}  Response  "Успешная авторизация или привязка аккаунта"
-// @Failure      400  {object}  object{status=string,error=string}  "Пользователь отказал в доступе, отсутствуют параметры code/state, указан некорректный app_id либо state недействителен или истёк"
-// @Failure      403  {object}  object{status=string,error=string}  "Email, полученный от OAuth-провайдера, не подтверждён"
-// @Failure      404  {object}  object{status=string,error=string}  "Указанный OAuth-провайдер не поддерживается"
-// @Failure      409  {object}  object{status=string,error=string}  "Конфликт данных: аккаунт с таким email уже существует либо OAuth-провайдер уже привязан к другому аккаунту"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Пользователь отказал в доступе, отсутствуют параметры code/state, указан некорректный app_id либо state недействителен или истёк"
+// @Failure      403  {object}  object{status=string,code=string,error=string}  "Email, полученный от OAuth-провайдера, не подтверждён, аккаунт заблокирован или требуется смена пароля"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Указанный OAuth-провайдер не поддерживается"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "Конфликт данных: аккаунт с таким email уже существует либо OAuth-провайдер уже привязан к другому аккаунту, либо достигнут лимит активных сессий"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
 // @Router       /auth/oauth/{provider}/callback [get]
 func New(
 	log *slog.Logger,
@@ -62,7 +62,7 @@ func New(
 		if errParam := r.URL.Query().Get("error"); errParam != "" {
 			log.Warn("oauth provider returned error", slog.String("provider_error", errParam))
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("access denied by user"))
+			render.JSON(w, r, resp.Error(resp.CodeAccessDenied, "access denied by user"))
 			return
 		}
 
@@ -71,7 +71,7 @@ func New(
 
 		if code == "" || state == "" {
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("code and state are required"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "code and state are required"))
 			return
 		}
 
@@ -80,13 +80,13 @@ func New(
 
 		accessToken, refreshToken, err := authMiddleware.Callback(ctx, providerName, code, state)
 		if err != nil {
-			status, msg := mapOAuthCallbackError(err)
+			status, body := mapOAuthCallbackError(err)
 			if status == http.StatusInternalServerError {
 				log.Error("oauth callback failed", sl.Err(err))
 			}
 
 			render.Status(r, status)
-			render.JSON(w, r, resp.Error(msg))
+			render.JSON(w, r, body)
 
 			return
 		}
@@ -103,25 +103,41 @@ func ResponseOK(w http.ResponseWriter, r *http.Request, accessToken, refreshToke
 	})
 }
 
-func mapOAuthCallbackError(err error) (int, string) {
+func mapOAuthCallbackError(err error) (int, resp.Response) {
 	switch {
 	case errors.Is(err, oauth.ErrOAuthProviderNotFound):
-		return http.StatusNotFound, "unknown oauth provider"
+		return http.StatusNotFound, resp.Error(resp.CodeUnknownProvider, "unknown oauth provider")
 	case errors.Is(err, oauth.ErrOAuthStateInvalid):
-		return http.StatusBadRequest, "invalid or expired oauth state"
+		return http.StatusBadRequest, resp.Error(resp.CodeInvalidOAuthState, "invalid or expired oauth state")
 	case errors.Is(err, oauth.ErrOAuthEmailNotVerified):
-		return http.StatusForbidden, "email not verified by provider"
-	case errors.Is(err, oauth.ErrOAuthAccountConflict):
-		return http.StatusConflict, "account with this email already exists, log in and link instead"
-	case errors.Is(err, storage.ErrOAuthAccountAlreadyLinked):
-		return http.StatusConflict, "this oauth account is already linked to another user"
-	case errors.Is(err, storage.ErrOAuthProviderAlreadyLinked):
-		return http.StatusConflict, "you already have this provider linked"
+		return http.StatusForbidden, resp.Error(resp.CodeProviderEmailUnverified, "email not verified by provider")
+	case errors.Is(err, identity.ErrEmailConflict):
+		return http.StatusConflict, resp.Error(resp.CodeIdentityConflict, "account with this email already exists, log in and link instead")
+	case errors.Is(err, identity.ErrEmailBelongsToOther):
+		return http.StatusConflict, resp.Error(resp.CodeIdentityConflict, "email of this oauth account belongs to another user")
+	case errors.Is(err, identity.ErrAlreadyLinked):
+		return http.StatusConflict, resp.Error(resp.CodeIdentityConflict, "this oauth account is already linked to another user")
+	case errors.Is(err, identity.ErrProviderAlreadyLinked):
+		return http.StatusConflict, resp.Error(resp.CodeIdentityConflict, "you already have this provider linked")
 	case errors.Is(err, auth.ErrInvalidAppID):
-		return http.StatusBadRequest, "invalid app id"
-	case errors.Is(err, oauth.ErrAccountPendingDeletion):
-		return http.StatusGone, "Account deleted"
+		return http.StatusBadRequest, resp.Error(resp.CodeInvalidApp, "invalid app id")
+	case errors.Is(err, auth.ErrNotAppMember):
+		return http.StatusForbidden, resp.Error(resp.CodeNotAppMember, "account is not registered in this app")
+	case errors.Is(err, identity.ErrAccountPendingDeletion):
+		return http.StatusGone, resp.Error(resp.CodeAccountDeleted, "Account deleted")
+	case errors.Is(err, auth.ErrAccountDeleted):
+		return http.StatusGone, resp.Error(resp.CodeAccountDeleted, "Account deleted")
+	case errors.Is(err, auth.ErrAccountDisabled):
+		return http.StatusForbidden, resp.Error(resp.CodeAccountDisabled, "Account disabled")
+	case errors.Is(err, auth.ErrAccountSuspended):
+		return http.StatusForbidden, resp.Error(resp.CodeAccountSuspended, "Account suspended")
+	case errors.Is(err, auth.ErrAccountBanned):
+		return http.StatusForbidden, resp.Error(resp.CodeAccountBanned, "Account banned")
+	case errors.Is(err, auth.ErrPasswordResetRequired):
+		return http.StatusForbidden, resp.Error(resp.CodePasswordResetRequired, "Password reset required")
+	case errors.Is(err, auth.ErrSessionLimit):
+		return http.StatusConflict, resp.Error(resp.CodeSessionLimit, "Active session limit reached")
 	default:
-		return http.StatusInternalServerError, "internal server error"
+		return http.StatusInternalServerError, resp.Error(resp.CodeInternal, "internal server error")
 	}
 }
@@ -37,10 +37,10 @@ type Response struct {
 // @Param        provider      path   string  true  "Название OAuth-провайдера (например: google, github)"
 // @Param        redirect_uri  query  string  true  "URL, на который будет выполнено перенаправление после завершения авторизации. Должен входить в список разрешённых адресов."
 // @Success      200  {object}  Response  "Ссылка для перехода к OAuth-провайдеру"
-// @Failure      400  {object}  object{status=string,error=string}  "Некорректный redirect_uri или app_id"
-// @Failure      401  {object}  object{status=string,error=string}  "Access-токен отсутствует, недействителен или истёк"
-// @Failure      404  {object}  object{status=string,error=string}  "OAuth-провайдер не поддерживается"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный redirect_uri или app_id"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access-токен отсутствует, недействителен или истёк"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "OAuth-провайдер не поддерживается"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
 // @Router       /auth/oauth/{provider}/link [get]
 func New(
 	log *slog.Logger,
@@ -59,7 +59,7 @@ func New(
 		claims, ok := claimsParser.ClaimsFromContext(r.Context())
 		if !ok {
 			render.Status(r, http.StatusUnauthorized)
-			render.JSON(w, r, resp.Error("invalid or expired access token"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
 
 			return
 		}
@@ -69,7 +69,7 @@ func New(
 		redirectURI, err := oauthutil.ValidateRedirectURI(r.URL.Query().Get("redirect_uri"), allowedHosts)
 		if err != nil {
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error(err.Error()))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidRedirectURI, err.Error()))
 			return
 		}
 
@@ -78,13 +78,13 @@ func New(
 
 		authURL, err := authService.StartLogin(ctx, providerName, claims.AppID, redirectURI, claims.UserID)
 		if err != nil {
-			status, msg := mapStartLoginError(err)
+			status, body := mapStartLoginError(err)
 			if status == http.StatusInternalServerError {
 				log.Error("failed to start oauth link flow", sl.Err(err))
 			}
 
 			render.Status(r, status)
-			render.JSON(w, r, resp.Error(msg))
+			render.JSON(w, r, body)
 
 			return
 		}
@@ -100,13 +100,13 @@ func ResponseOK(w http.ResponseWriter, r *http.Request, redirectURL string) {
 	})
 }
 
-func mapStartLoginError(err error) (int, string) {
+func mapStartLoginError(err error) (int, resp.Response) {
 	switch {
 	case errors.Is(err, oauth.ErrOAuthProviderNotFound):
-		return http.StatusNotFound, "unknown oauth provider"
+		return http.StatusNotFound, resp.Error(resp.CodeUnknownProvider, "unknown oauth provider")
 	case errors.Is(err, auth.ErrInvalidAppID):
-		return http.StatusBadRequest, "invalid app id"
+		return http.StatusBadRequest, resp.Error(resp.CodeInvalidApp, "invalid app id")
 	default:
-		return http.StatusInternalServerError, "internal server error"
+		return http.StatusInternalServerError, resp.Error(resp.CodeInternal, "internal server error")
 	}
 }
@@ -27,9 +27,9 @@ import (
 // @Param        app_id        query  integer  true  "Идентификатор клиентского приложения"
 // @Param        redirect_uri  query  string   true  "URL для перенаправления после завершения авторизации. Должен входить в список разрешённых адресов."
 // @Success      302  "Перенаправление на страницу авторизации OAuth-провайдера"
-// @Failure      400  {object}  object{status=string,error=string}  "Некорректный app_id или redirect_uri не прошёл проверку"
-// @Failure      404  {object}  object{status=string,error=string}  "OAuth-провайдер не поддерживается"
-// @Failure      500  {object}  object{status=string,error=string}  "Не удалось сформировать URL для авторизации"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный app_id или redirect_uri не прошёл проверку"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "OAuth-провайдер не поддерживается"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Не удалось сформировать URL для авторизации"
 // @Router       /auth/oauth/{provider}/login [get]
 func New(
 	log *slog.Logger,
@@ -50,7 +50,7 @@ func New(
 		appID64, err := strconv.ParseInt(appIDStr, 10, 32)
 		if err != nil {
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("invalid app_id"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidApp, "invalid app_id"))
 			return
 		}
 
@@ -62,7 +62,7 @@ func New(
 			)
 
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error(err.Error()))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidRedirectURI, err.Error()))
 
 			return
 		}
@@ -76,7 +76,7 @@ func New(
 				render.Status(r, http.StatusInternalServerError)
 			}
 
-			render.JSON(w, r, resp.Error("Internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
 
 			return
 		}
@@ -7,11 +7,11 @@ import (
 	"net/http"
 	"time"
 
+	"auth_service/internal/auth/identity"
 	"auth_service/internal/auth/oauth"
 	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
 	resp "auth_service/internal/lib/api/response"
 	sl "auth_service/internal/lib/logger"
-	"auth_service/internal/storage"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -28,14 +28,16 @@ type Response struct {
 // @Description  Если этот способ входа является последним доступным методом
 // @Description  аутентификации, операция будет отклонена, чтобы предотвратить
 // @Description  потерю доступа к аккаунту.
+// @Description  Устарел: используйте DELETE /me/identities/{provider}.
 // @Tags         oauth
+// @Deprecated
 // @Security     BearerAuth
 // @Param        provider  path  string  true  "Название OAuth-провайдера (например: google, github)"
 // @Success      204  "OAuth-провайдер успешно отвязан"
-// @Failure      401  {object}  object{status=string,error=string}  "Access-токен отсутствует, недействителен или истёк"
-// @Failure      403  {object}  object{status=string,error=string}  "Нельзя отвязать последний доступный способ аутентификации"
-// @Failure      404  {object}  object{status=string,error=string}  "У пользователя отсутствует привязка к указанному OAuth-провайдеру"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access-токен отсутствует, недействителен или истёк"
+// @Failure      403  {object}  object{status=string,code=string,error=string}  "Нельзя отвязать последний доступный способ аутентификации"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "У пользователя отсутствует привязка к указанному OAuth-провайдеру"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
 // @Router       /auth/oauth/{provider} [delete]
 func New(
 	log *slog.Logger,
@@ -53,7 +55,7 @@ func New(
 		claims, ok := claimsParser.ClaimsFromContext(r.Context())
 		if !ok {
 			render.Status(r, http.StatusUnauthorized)
-			render.JSON(w, r, resp.Error("invalid or expired access token"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
 			return
 		}
 
@@ -64,12 +66,12 @@ func New(
 
 		err := authService.Unlink(ctx, claims.UserID, providerName)
 		if err != nil {
-			status, msg := mapUnlinkError(err)
+			status, body := mapUnlinkError(err)
 			if status == http.StatusInternalServerError {
 				log.Error("failed to unlink oauth account", sl.Err(err))
 			}
 			render.Status(r, status)
-			render.JSON(w, r, resp.Error(msg))
+			render.JSON(w, r, body)
 			return
 		}
 
@@ -78,14 +80,14 @@ func New(
 	}
 }
 
-func mapUnlinkError(err error) (int, string) {
+func mapUnlinkError(err error) (int, resp.Response) {
 	switch {
-	case errors.Is(err, oauth.ErrOAuthLastAuthMethod):
-		return http.StatusForbidden, "cannot unlink last authentication method"
-	case errors.Is(err, storage.ErrOAuthAccountNotFound):
-		return http.StatusNotFound, "oauth account not found"
+	case errors.Is(err, identity.ErrLastAuthMethod):
+		return http.StatusForbidden, resp.Error(resp.CodeLastAuthMethod, "cannot unlink last authentication method")
+	case errors.Is(err, identity.ErrNotFound):
+		return http.StatusNotFound, resp.Error(resp.CodeIdentityNotFound, "oauth account not found")
 	default:
-		return http.StatusInternalServerError, "internal server error"
+		return http.StatusInternalServerError, resp.Error(resp.CodeInternal, "internal server error")
 	}
 }
 
@@ -0,0 +1,206 @@
+package oidcHandler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"auth_service/internal/auth/oidc"
+	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Authorizer interface {
+	ValidateAuthorize(ctx context.Context, req oidc.AuthorizeRequest) (*models.App, error)
+	Authorize(ctx context.Context, userID int64, req oidc.AuthorizeRequest) (string, error)
+}
+
+type ApproveResponse struct {
+	resp.Response
+	// RedirectTo — redirect_uri клиента с code и state (или error), куда
+	// страница логина отправляет браузер.
+	RedirectTo string `json:"redirect_to" example:"https://app.example.com/callback?code=SplxlOBeZQQYbYS6WxSbIA&state=af0ifjsldkj"`
+}
+
+// NewAuthorize godoc
+// @Summary      OIDC authorization endpoint
+// @Description  ## Описание
+// @Description  Начало authorization code flow с PKCE. Приложения — OAuth-клиенты:
+// @Description  client_id = ID приложения, redirect_uri должен быть в apps.redirect_uris.
+// @Description
+// @Description  ### Поведение:
+// @Description  - Параметры валидны — редирект 302 на страницу логина (oidc.login_url) с теми же
+// @Description    query-параметрами. Страница логинит пользователя через /auth/login и вызывает
+// @Description    POST /oauth2/authorize с access-токеном
+// @Description  - Неизвестный client_id или незарегистрированный redirect_uri — 400, без редиректа
+// @Description  - Остальные ошибки — редирект на redirect_uri с error и state (RFC 6749 §4.1.2.1)
+// @Description
+// @Description  ### Требования:
+// @Description  - response_type=code, scope содержит openid
+// @Description  - code_challenge обязателен, code_challenge_method=S256
+// @Tags         oidc
+// @Produce      json
+// @Param        response_type          query  string  true   "code"
+// @Param        client_id              query  string  true   "ID приложения"
+// @Param        redirect_uri           query  string  true   "Зарегистрированный redirect_uri"
+// @Param        scope                  query  string  true   "openid email profile"
+// @Param        state                  query  string  false  "Непрозрачное значение клиента"
+// @Param        nonce                  query  string  false  "Попадёт в ID-токен"
+// @Param        code_challenge         query  string  true   "PKCE challenge"
+// @Param        code_challenge_method  query  string  true   "S256"
+// @Success      302  "Редирект на страницу логина или на redirect_uri с ошибкой"
+// @Failure      400  {object}  ErrorResponse  "Неизвестный клиент или redirect_uri"
+// @Failure      500  {object}  ErrorResponse  "Внутренняя ошибка сервера"
+// @Router       /oauth2/authorize [get]
+func NewAuthorize(
+	log *slog.Logger,
+	authorizer Authorizer,
+	loginURL string,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.oidc.NewAuthorize"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		req := authorizeRequestFromQuery(r.URL.Query())
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		if _, err := authorizer.ValidateAuthorize(ctx, req); err != nil {
+			code, status, ok := errorCode(err)
+			switch {
+			case !ok:
+				log.Error("failed to validate authorize request", sl.Err(err))
+				renderError(w, r, code, status, "internal error")
+			case redirectable(err):
+				http.Redirect(w, r, errorRedirectURL(req, code, err), http.StatusFound)
+			default:
+				// адрес возврата не подтверждён — редирект на него открыл бы open redirect
+				renderError(w, r, code, http.StatusBadRequest, err.Error())
+			}
+
+			return
+		}
+
+		http.Redirect(w, r, loginURL+"?"+r.URL.RawQuery, http.StatusFound)
+	}
+}
+
+// NewApprove godoc
+// @Summary      Выдача кода авторизации OIDC
+// @Description  ## Описание
+// @Description  Вызывается страницей логина после входа пользователя: выдаёт одноразовый код
+// @Description  для клиента и возвращает redirect_uri с code и state. Параметры — те же, что
+// @Description  пришли в GET /oauth2/authorize.
+// @Description
+// @Description  ### Особенности:
+// @Description  - Требует access-токен пользователя
+// @Description  - Код живёт oidc.code_ttl и обменивается один раз
+// @Description  - Ошибки после проверки клиента возвращаются в redirect_to с error и state
+// @Tags         oidc
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body  oidc.AuthorizeRequest  true  "Параметры authorization request"
+// @Success      200  {object}  ApproveResponse  "Код выдан или ошибка для клиента в redirect_to"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Невалидное тело, неизвестный клиент или redirect_uri"
+// @Failure      401  {object}  object{error=string}  "Access-токен невалиден"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /oauth2/authorize [post]
+func NewApprove(
+	log *slog.Logger,
+	authorizer Authorizer,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.oidc.NewApprove"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		var req oidc.AuthorizeRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		redirectTo, err := authorizer.Authorize(ctx, claims.UserID, req)
+		if err != nil {
+			code, _, ok := errorCode(err)
+			switch {
+			case !ok:
+				log.Error("failed to authorize", sl.Err(err))
+
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+			case redirectable(err):
+				ResponseApproved(w, r, errorRedirectURL(req, code, err))
+			// на redirect_uri нельзя вернуть только ошибки клиента и адреса
+			case errors.Is(err, oidc.ErrInvalidClient):
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidClient, err.Error()))
+			default:
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidRedirectURI, err.Error()))
+			}
+
+			return
+		}
+
+		log.Info("authorization code issued",
+			slog.Int64("user_id", claims.UserID),
+			slog.String("client_id", req.ClientID),
+		)
+
+		ResponseApproved(w, r, redirectTo)
+	}
+}
+
+func ResponseApproved(w http.ResponseWriter, r *http.Request, redirectTo string) {
+	render.JSON(w, r, ApproveResponse{
+		Response:   resp.OK(),
+		RedirectTo: redirectTo,
+	})
+}
+
+func authorizeRequestFromQuery(q url.Values) oidc.AuthorizeRequest {
+	return oidc.AuthorizeRequest{
+		ResponseType:        q.Get("response_type"),
+		ClientID:            q.Get("client_id"),
+		RedirectURI:         q.Get("redirect_uri"),
+		Scope:               q.Get("scope"),
+		State:               q.Get("state"),
+		Nonce:               q.Get("nonce"),
+		CodeChallenge:       q.Get("code_challenge"),
+		CodeChallengeMethod: q.Get("code_challenge_method"),
+	}
+}
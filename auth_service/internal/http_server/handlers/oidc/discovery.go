@@ -0,0 +1,60 @@
+package oidcHandler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+// Discovery — OpenID Provider Metadata (OIDC Discovery 1.0 §3).
+type Discovery struct {
+	Issuer                            string   `json:"issuer" example:"https://auth.example.com"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint" example:"https://auth.example.com/oauth2/authorize"`
+	TokenEndpoint                     string   `json:"token_endpoint" example:"https://auth.example.com/oauth2/token"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint" example:"https://auth.example.com/oauth2/userinfo"`
+	JWKSURI                           string   `json:"jwks_uri" example:"https://auth.example.com/.well-known/jwks.json"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint" example:"https://auth.example.com/token/introspect"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// NewDiscovery godoc
+// @Summary      OpenID Connect discovery
+// @Description  Метаданные провайдера: адреса эндпоинтов, JWKS и поддерживаемые
+// @Description  параметры. Стандартные OIDC-библиотеки настраиваются по issuer.
+// @Tags         oidc
+// @Produce      json
+// @Success      200  {object}  Discovery
+// @Router       /.well-known/openid-configuration [get]
+func NewDiscovery(issuer string) http.HandlerFunc {
+	// документ не меняется без рестарта — собираем один раз
+	doc := Discovery{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/oauth2/authorize",
+		TokenEndpoint:                     issuer + "/oauth2/token",
+		UserInfoEndpoint:                  issuer + "/oauth2/userinfo",
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		IntrospectionEndpoint:             issuer + "/token/introspect",
+		ScopesSupported:                   []string{"openid", "email", "profile"},
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", "refresh_token"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256", "ES256", "HS256"},
+		TokenEndpointAuthMethodsSupported: []string{"none", "client_secret_post", "client_secret_basic"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported: []string{
+			"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce",
+			"email", "email_verified", "preferred_username",
+		},
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, doc)
+	}
+}
@@ -0,0 +1,68 @@
+package oidcHandler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"auth_service/internal/auth/oidc"
+
+	"github.com/go-chi/render"
+)
+
+// ErrorResponse — ошибка в формате RFC 6749 §5.2. Без обёртки
+// resp.Response: клиенты — готовые OAuth/OIDC-библиотеки.
+type ErrorResponse struct {
+	Error            string `json:"error" example:"invalid_grant"`
+	ErrorDescription string `json:"error_description,omitempty" example:"invalid grant"`
+}
+
+// errorCode переводит ошибку провайдера в код RFC 6749. ok == false —
+// внутренняя ошибка.
+func errorCode(err error) (code string, status int, ok bool) {
+	switch {
+	case errors.Is(err, oidc.ErrInvalidClient):
+		return "invalid_client", http.StatusUnauthorized, true
+	case errors.Is(err, oidc.ErrInvalidRedirectURI), errors.Is(err, oidc.ErrInvalidRequest):
+		return "invalid_request", http.StatusBadRequest, true
+	case errors.Is(err, oidc.ErrUnsupportedResponseType):
+		return "unsupported_response_type", http.StatusBadRequest, true
+	case errors.Is(err, oidc.ErrInvalidScope):
+		return "invalid_scope", http.StatusBadRequest, true
+	case errors.Is(err, oidc.ErrInvalidGrant):
+		return "invalid_grant", http.StatusBadRequest, true
+	case errors.Is(err, oidc.ErrUnsupportedGrantType):
+		return "unsupported_grant_type", http.StatusBadRequest, true
+	case errors.Is(err, oidc.ErrAccessDenied):
+		return "access_denied", http.StatusForbidden, true
+	}
+
+	return "server_error", http.StatusInternalServerError, false
+}
+
+// redirectable — ошибку можно вернуть клиенту на redirect_uri: клиент и
+// адрес возврата уже проверены.
+func redirectable(err error) bool {
+	return !errors.Is(err, oidc.ErrInvalidClient) && !errors.Is(err, oidc.ErrInvalidRedirectURI)
+}
+
+// errorRedirectURL — redirect_uri клиента с error и state (RFC 6749 §4.1.2.1).
+func errorRedirectURL(req oidc.AuthorizeRequest, code string, err error) string {
+	params := url.Values{
+		"error":             {code},
+		"error_description": {err.Error()},
+	}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+
+	return oidc.RedirectURL(req.RedirectURI, params)
+}
+
+func renderError(w http.ResponseWriter, r *http.Request, code string, status int, description string) {
+	render.Status(r, status)
+	render.JSON(w, r, ErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
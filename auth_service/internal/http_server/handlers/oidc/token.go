@@ -0,0 +1,132 @@
+package oidcHandler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth_service/internal/auth/oidc"
+	sl "auth_service/internal/lib/logger"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Exchanger interface {
+	Exchange(ctx context.Context, req oidc.TokenRequest) (*oidc.TokenResponse, error)
+}
+
+// TokenResponse — ответ token endpoint (RFC 6749 §5.1, OIDC Core §3.1.3.3).
+type TokenResponse struct {
+	AccessToken  string `json:"access_token" example:"eyJhbGciOiJSUzI1NiIsImtpZCI6..."`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	ExpiresIn    int64  `json:"expires_in" example:"900"`
+	RefreshToken string `json:"refresh_token,omitempty" example:"3b241101-e2bb-4255-8caf-4136c566a962.dgsadfg..."`
+	IDToken      string `json:"id_token,omitempty" example:"eyJhbGciOiJSUzI1NiIsImtpZCI6..."`
+	Scope        string `json:"scope,omitempty" example:"openid email profile"`
+}
+
+// NewToken godoc
+// @Summary      OIDC token endpoint
+// @Description  ## Описание
+// @Description  Обменивает код авторизации на access, refresh и ID-токены или ротирует
+// @Description  refresh-токен клиента.
+// @Description
+// @Description  ### grant_type=authorization_code
+// @Description  - code, redirect_uri (тот же, что в /authorize), client_id, code_verifier
+// @Description  - Код одноразовый: повторный обмен — invalid_grant
+// @Description
+// @Description  ### grant_type=refresh_token
+// @Description  - refresh_token, client_id; токен другого приложения — invalid_grant
+// @Description
+// @Description  ### Аутентификация клиента:
+// @Description  - Публичные клиенты (apps.public_client) — без секрета, защищены PKCE
+// @Description  - Конфиденциальные — обязательно client_secret в форме или HTTP Basic `client_id:client_secret`
+// @Tags         oidc
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        grant_type     formData  string  true   "authorization_code или refresh_token"
+// @Param        client_id      formData  string  false  "ID приложения (если не передан в Basic)"
+// @Param        client_secret  formData  string  false  "Секрет приложения"
+// @Param        code           formData  string  false  "Код авторизации"
+// @Param        redirect_uri   formData  string  false  "redirect_uri из запроса авторизации"
+// @Param        code_verifier  formData  string  false  "PKCE verifier"
+// @Param        refresh_token  formData  string  false  "Refresh-токен"
+// @Success      200  {object}  TokenResponse  "Токены выданы"
+// @Failure      400  {object}  ErrorResponse  "invalid_request, invalid_grant, unsupported_grant_type"
+// @Failure      401  {object}  ErrorResponse  "invalid_client"
+// @Failure      500  {object}  ErrorResponse  "Внутренняя ошибка сервера"
+// @Router       /oauth2/token [post]
+func NewToken(
+	log *slog.Logger,
+	exchanger Exchanger,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.oidc.NewToken"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// RFC 6749 §5.1: ответы с токенами не кешируются
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Pragma", "no-cache")
+
+		if err := r.ParseForm(); err != nil {
+			renderError(w, r, "invalid_request", http.StatusBadRequest, "failed to parse form")
+			return
+		}
+
+		req := oidc.TokenRequest{
+			GrantType:    r.PostForm.Get("grant_type"),
+			ClientID:     r.PostForm.Get("client_id"),
+			ClientSecret: r.PostForm.Get("client_secret"),
+			Code:         r.PostForm.Get("code"),
+			RedirectURI:  r.PostForm.Get("redirect_uri"),
+			CodeVerifier: r.PostForm.Get("code_verifier"),
+			RefreshToken: r.PostForm.Get("refresh_token"),
+		}
+
+		if user, pass, ok := r.BasicAuth(); ok {
+			req.ClientID, req.ClientSecret = user, pass
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		tokens, err := exchanger.Exchange(ctx, req)
+		if err != nil {
+			code, status, ok := errorCode(err)
+			if !ok {
+				log.Error("failed to exchange token", slog.String("grant_type", req.GrantType), sl.Err(err))
+				renderError(w, r, code, status, "internal error")
+
+				return
+			}
+
+			if status == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+			}
+
+			renderError(w, r, code, status, err.Error())
+
+			return
+		}
+
+		ResponseOK(w, r, tokens)
+	}
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request, tokens *oidc.TokenResponse) {
+	render.JSON(w, r, TokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(tokens.ExpiresIn.Seconds()),
+		RefreshToken: tokens.RefreshToken,
+		IDToken:      tokens.IDToken,
+		Scope:        tokens.Scope,
+	})
+}
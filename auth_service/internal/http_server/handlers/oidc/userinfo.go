@@ -0,0 +1,98 @@
+package oidcHandler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"auth_service/internal/auth"
+	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type UserInfoProvider interface {
+	UserInfo(ctx context.Context, userID int64) (*models.User, error)
+}
+
+// UserInfoResponse — стандартные claims OIDC Core §5.1.
+type UserInfoResponse struct {
+	Subject           string `json:"sub" example:"234"`
+	Email             string `json:"email" example:"user@example.com"`
+	EmailVerified     bool   `json:"email_verified" example:"true"`
+	PreferredUsername string `json:"preferred_username" example:"newUser2008"`
+}
+
+// NewUserInfo godoc
+// @Summary      OIDC userinfo endpoint
+// @Description  Возвращает claims пользователя по access-токену, выданному через
+// @Description  /oauth2/token или обычный /auth/login.
+// @Tags         oidc
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  UserInfoResponse  "Claims пользователя"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access-токен невалиден или аккаунт удалён"
+// @Failure      500  {object}  ErrorResponse  "Внутренняя ошибка сервера"
+// @Router       /oauth2/userinfo [get]
+func NewUserInfo(
+	log *slog.Logger,
+	provider UserInfoProvider,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.oidc.NewUserInfo"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			unauthorized(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		user, err := provider.UserInfo(ctx, claims.UserID)
+		if err != nil {
+			if errors.Is(err, auth.ErrUserNotFound) || errors.Is(err, auth.ErrAccountDeleted) {
+				unauthorized(w, r)
+				return
+			}
+
+			log.Error("failed to load user", slog.Int64("user_id", claims.UserID), sl.Err(err))
+			renderError(w, r, "server_error", http.StatusInternalServerError, "internal error")
+
+			return
+		}
+
+		ResponseUserInfo(w, r, user)
+	}
+}
+
+func ResponseUserInfo(w http.ResponseWriter, r *http.Request, user *models.User) {
+	render.JSON(w, r, UserInfoResponse{
+		Subject:           strconv.FormatInt(user.ID, 10),
+		Email:             user.Email,
+		EmailVerified:     user.IsVerified,
+		PreferredUsername: user.Username,
+	})
+}
+
+// unauthorized — RFC 6750 §3: ошибка bearer-токена в WWW-Authenticate.
+func unauthorized(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+
+	render.Status(r, http.StatusUnauthorized)
+	render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+}
@@ -0,0 +1,521 @@
+package orgInvitations
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	orgsService "auth_service/internal/auth/orgs"
+	"auth_service/internal/http_server/handlers/orgs"
+	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/lib/mailer"
+	"auth_service/internal/lib/passwordpolicy"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Inviter interface {
+	Invite(
+		ctx context.Context,
+		actorID int64,
+		appID int32,
+		orgID int64,
+		email string,
+		role models.OrgRole,
+	) (*orgsService.Invitation, error)
+	AcceptInvitation(ctx context.Context, userID int64, rawToken string) (*models.OrgInvitation, error)
+	DeclineInvitation(ctx context.Context, rawToken string) error
+	SignUp(ctx context.Context, rawToken, username, password string) (int64, *models.OrgInvitation, error)
+}
+
+type InviteRequest struct {
+	Email string `json:"email" validate:"required,email" example:"example@domain.com"`
+	Role  string `json:"role" validate:"required,oneof=admin member" example:"member"`
+}
+
+type InviteResponse struct {
+	resp.Response
+	ExpiresAt time.Time `json:"expires_at" example:"2026-07-31T12:00:00Z"`
+}
+
+type AcceptRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type AcceptResponse struct {
+	resp.Response
+	OrgID int64  `json:"org_id" example:"12"`
+	Role  string `json:"role" example:"member"`
+}
+
+type DeclineRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type SignUpRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Username string `json:"username" validate:"required" example:"newUser2008"`
+	Pass     string `json:"password" validate:"required" example:"SecurePass123!"`
+}
+
+type SignUpResponse struct {
+	resp.Response
+	UserID int64  `json:"user_id" example:"42"`
+	OrgID  int64  `json:"org_id" example:"12"`
+	Role   string `json:"role" example:"member"`
+}
+
+type Response struct {
+	resp.Response
+}
+
+// NewInvite godoc
+// @Summary      Приглашение в организацию
+// @Description  ## Описание
+// @Description  Отправляет на email письмо со ссылкой-приглашением в организацию.
+// @Description
+// @Description  ### Особенности:
+// @Description  - Приглашать могут owner и admin; роль приглашения — admin или member
+// @Description  - admin может пригласить только на роль, которой управляет
+// @Description  - Ссылка живёт tokens.org_invitation_ttl
+// @Description  - Если аккаунта с этим email нет, письмо ведёт на регистрацию по приглашению
+// @Tags         orgs
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path  int            true  "ID организации"
+// @Param        request  body  InviteRequest  true  "Email и роль приглашаемого"
+// @Success      202  {object}  InviteResponse  "Приглашение отправлено"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Невалидный запрос"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      403  {object}  object{status=string,code=string,error=string}  "Недостаточно прав в организации"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Организация не найдена или пользователь в ней не состоит"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "Приглашаемый уже состоит в организации"
+// @Failure      429  {object}  object{status=string,code=string,error=string}  "Превышен лимит запросов"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /orgs/{id}/invitations [post]
+func NewInvite(
+	log *slog.Logger,
+	validate *validator.Validate,
+	inviter Inviter,
+	msgSender mailer.Publisher,
+	address string,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.orgs.invitations.NewInvite"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		orgID, ok := orgs.OrgID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid organization id"))
+			return
+		}
+
+		var req InviteRequest
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+
+			if errors.As(err, &validateErr) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+
+				return
+			}
+
+			log.Error("unexpected validation error type", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		inv, err := inviter.Invite(ctx, claims.UserID, claims.AppID, orgID, req.Email, models.OrgRole(req.Role))
+		if err != nil {
+			switch {
+			case errors.Is(err, orgsService.ErrNotFound):
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeOrgNotFound, "organization not found"))
+			case errors.Is(err, orgsService.ErrForbidden):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodeOrgForbidden, "insufficient organization role"))
+			case errors.Is(err, orgsService.ErrInvalidRole):
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidRole, "invalid role"))
+			case errors.Is(err, orgsService.ErrAlreadyMember):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error(resp.CodeOrgAlreadyMember, "user is already a member of the organization"))
+			default:
+				log.Error("failed to create organization invitation", sl.Err(err))
+
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
+			}
+
+			return
+		}
+
+		send := mailer.SendOrgInvitation
+		if inv.NewUser {
+			send = mailer.SendOrgInvitationSignup
+		}
+
+		if err := send(ctx, msgSender, inv.Token, address, inv.Email); err != nil {
+			log.Error("failed to send organization invitation", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
+
+			return
+		}
+
+		log.Info("organization invitation sent", slog.Int64("org_id", orgID))
+
+		ResponseInvited(w, r, inv.ExpiresAt)
+	}
+}
+
+// NewAccept godoc
+// @Summary      Принятие приглашения в организацию
+// @Description  ## Описание
+// @Description  Добавляет текущего пользователя в организацию по токену из письма-приглашения.
+// @Description
+// @Description  ### Особенности:
+// @Description  - Email аккаунта должен совпадать с адресом, на который ушло приглашение
+// @Description  - Токен одноразовый
+// @Description  - Организация попадёт в токены после refresh с org_id
+// @Tags         orgs
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  AcceptRequest  true  "Токен из письма"
+// @Success      200  {object}  AcceptResponse  "Пользователь добавлен в организацию"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Невалидный запрос"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Приглашение не найдено, истекло или адресовано другому email"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "Пользователь уже состоит в организации"
+// @Failure      429  {object}  object{status=string,code=string,error=string}  "Превышен лимит запросов"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /orgs/invitations/accept [post]
+func NewAccept(
+	log *slog.Logger,
+	validate *validator.Validate,
+	inviter Inviter,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.orgs.invitations.NewAccept"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		var req AcceptRequest
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+
+			if errors.As(err, &validateErr) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+
+				return
+			}
+
+			log.Error("unexpected validation error type", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		inv, err := inviter.AcceptInvitation(ctx, claims.UserID, req.Token)
+		if err != nil {
+			switch {
+			case errors.Is(err, orgsService.ErrInvalidInvitation):
+				log.Warn("invalid organization invitation token")
+
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidInvitation, "invalid or expired invitation"))
+			case errors.Is(err, orgsService.ErrAlreadyMember):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error(resp.CodeOrgAlreadyMember, "already a member of the organization"))
+			default:
+				log.Error("failed to accept organization invitation", sl.Err(err))
+
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
+			}
+
+			return
+		}
+
+		ResponseAccepted(w, r, inv)
+	}
+}
+
+// NewDecline godoc
+// @Summary      Отклонение приглашения в организацию
+// @Description  ## Описание
+// @Description  Отклоняет приглашение по токену из письма. Вход не требуется.
+// @Description  После отклонения токен не принимается ни для принятия, ни для регистрации.
+// @Tags         orgs
+// @Accept       json
+// @Produce      json
+// @Param        request  body  DeclineRequest  true  "Токен из письма"
+// @Success      200  {object}  Response  "Приглашение отклонено"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Невалидный запрос"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Приглашение не найдено, истекло или уже использовано"
+// @Failure      429  {object}  object{status=string,code=string,error=string}  "Превышен лимит запросов"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /orgs/invitations/decline [post]
+func NewDecline(
+	log *slog.Logger,
+	validate *validator.Validate,
+	inviter Inviter,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.orgs.invitations.NewDecline"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req DeclineRequest
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+
+			if errors.As(err, &validateErr) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+
+				return
+			}
+
+			log.Error("unexpected validation error type", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		if err := inviter.DeclineInvitation(ctx, req.Token); err != nil {
+			if errors.Is(err, orgsService.ErrInvalidInvitation) {
+				log.Warn("invalid organization invitation token")
+
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidInvitation, "invalid or expired invitation"))
+
+				return
+			}
+
+			log.Error("failed to decline organization invitation", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
+
+			return
+		}
+
+		ResponseOK(w, r)
+	}
+}
+
+// NewSignUp godoc
+// @Summary      Регистрация по приглашению в организацию
+// @Description  ## Описание
+// @Description  Создаёт аккаунт приглашённому, у которого его ещё нет, и добавляет его в
+// @Description  организацию. Ссылка на этот шаг приходит в письме-приглашении.
+// @Description
+// @Description  ### Особенности:
+// @Description  - Email берётся из приглашения и сразу считается подтверждённым
+// @Description  - Аккаунт становится участником приложения организации
+// @Description  - Если аккаунт с этим email уже есть, нужно войти и принять приглашение
+// @Description  - Токены не выдаются: после регистрации пользователь входит через /auth/login
+// @Tags         orgs
+// @Accept       json
+// @Produce      json
+// @Param        request  body  SignUpRequest  true  "Токен из письма, имя пользователя и пароль"
+// @Success      201  {object}  SignUpResponse  "Аккаунт создан, пользователь добавлен в организацию"
+// @Failure      400  {object}  response.PasswordPolicyResponse  "Невалидный запрос или пароль не соответствует политике (code=ACCOUNT_WEAK_PASSWORD, violations — нарушенные правила)"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Приглашение не найдено, истекло или уже использовано"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "Аккаунт с этим email уже существует"
+// @Failure      429  {object}  object{status=string,code=string,error=string}  "Превышен лимит запросов"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /orgs/invitations/signup [post]
+func NewSignUp(
+	log *slog.Logger,
+	validate *validator.Validate,
+	inviter Inviter,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.orgs.invitations.NewSignUp"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req SignUpRequest
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+
+			if errors.As(err, &validateErr) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+
+				return
+			}
+
+			log.Error("unexpected validation error type", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		userID, inv, err := inviter.SignUp(ctx, req.Token, req.Username, req.Pass)
+		if err != nil {
+			var policyErr *passwordpolicy.Error
+
+			switch {
+			case errors.As(err, &policyErr):
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.PasswordPolicyError(policyErr))
+			case errors.Is(err, orgsService.ErrInvalidInvitation):
+				log.Warn("invalid organization invitation token")
+
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidInvitation, "invalid or expired invitation"))
+			case errors.Is(err, orgsService.ErrAccountExists):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error(resp.CodeInviteeAccountExists, "account already exists, log in to accept the invitation"))
+			default:
+				log.Error("failed to sign up via organization invitation", sl.Err(err))
+
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
+			}
+
+			return
+		}
+
+		log.Info("user registered via organization invitation", slog.Int64("user_id", userID))
+
+		ResponseSignedUp(w, r, userID, inv)
+	}
+}
+
+func ResponseInvited(w http.ResponseWriter, r *http.Request, expiresAt time.Time) {
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, InviteResponse{
+		Response:  resp.OK(),
+		ExpiresAt: expiresAt,
+	})
+}
+
+func ResponseAccepted(w http.ResponseWriter, r *http.Request, inv *models.OrgInvitation) {
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, AcceptResponse{
+		Response: resp.OK(),
+		OrgID:    inv.OrgID,
+		Role:     string(inv.Role),
+	})
+}
+
+func ResponseSignedUp(w http.ResponseWriter, r *http.Request, userID int64, inv *models.OrgInvitation) {
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, SignUpResponse{
+		Response: resp.OK(),
+		UserID:   userID,
+		OrgID:    inv.OrgID,
+		Role:     string(inv.Role),
+	})
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, Response{
+		Response: resp.OK(),
+	})
+}
@@ -0,0 +1,332 @@
+package orgMembers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	orgsService "auth_service/internal/auth/orgs"
+	"auth_service/internal/http_server/handlers/orgs"
+	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type MemberManager interface {
+	Members(ctx context.Context, actorID int64, appID int32, orgID int64) ([]models.OrgMember, error)
+	ChangeRole(ctx context.Context, actorID int64, appID int32, orgID, userID int64, role models.OrgRole) error
+	RemoveMember(ctx context.Context, actorID int64, appID int32, orgID, userID int64) error
+}
+
+type Request struct {
+	Role string `json:"role" validate:"required,oneof=owner admin member" example:"admin"`
+}
+
+type Member struct {
+	UserID    int64     `json:"user_id" example:"42"`
+	Email     string    `json:"email" example:"example@domain.com"`
+	Role      string    `json:"role" example:"member"`
+	CreatedAt time.Time `json:"created_at" example:"2026-07-24T12:00:00Z"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Members []Member `json:"members"`
+}
+
+type Response struct {
+	resp.Response
+}
+
+// NewList godoc
+// @Summary      Участники организации
+// @Description  Возвращает участников организации с их ролями. Доступно любому участнику.
+// @Tags         orgs
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id  path  int  true  "ID организации"
+// @Success      200  {object}  ListResponse  "Список участников"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный ID организации"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Организация не найдена или пользователь в ней не состоит"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /orgs/{id}/members [get]
+func NewList(
+	log *slog.Logger,
+	manager MemberManager,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.orgs.members.NewList"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		orgID, ok := orgs.OrgID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid organization id"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		members, err := manager.Members(ctx, claims.UserID, claims.AppID, orgID)
+		if err != nil {
+			if errors.Is(err, orgsService.ErrNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeOrgNotFound, "organization not found"))
+				return
+			}
+
+			log.Error("failed to list organization members", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
+
+			return
+		}
+
+		ResponseList(w, r, toMembers(members))
+	}
+}
+
+// NewUpdate godoc
+// @Summary      Смена роли участника
+// @Description  ## Описание
+// @Description  Меняет роль участника организации.
+// @Description
+// @Description  ### Особенности:
+// @Description  - owner управляет всеми ролями, admin — только admin и member
+// @Description  - Последнего владельца понизить нельзя
+// @Description  - Claim org_role в выданных токенах обновится при следующем refresh
+// @Tags         orgs
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path  int      true  "ID организации"
+// @Param        userID   path  int      true  "ID участника"
+// @Param        request  body  Request  true  "Новая роль"
+// @Success      200  {object}  Response  "Роль изменена"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный ID или роль"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      403  {object}  object{status=string,code=string,error=string}  "Недостаточно прав в организации"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Организация или участник не найдены"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "Нельзя понизить последнего владельца"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /orgs/{id}/members/{userID} [put]
+func NewUpdate(
+	log *slog.Logger,
+	validate *validator.Validate,
+	manager MemberManager,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.orgs.members.NewUpdate"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		orgID, ok := orgs.OrgID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid organization id"))
+			return
+		}
+
+		userID, ok := orgs.MemberID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid user id"))
+			return
+		}
+
+		var req Request
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+
+			if errors.As(err, &validateErr) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+
+				return
+			}
+
+			log.Error("unexpected validation error type", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		err := manager.ChangeRole(ctx, claims.UserID, claims.AppID, orgID, userID, models.OrgRole(req.Role))
+		if err != nil {
+			renderMemberError(w, r, log, err, "failed to change organization member role")
+			return
+		}
+
+		ResponseOK(w, r)
+	}
+}
+
+// NewRemove godoc
+// @Summary      Исключение участника
+// @Description  ## Описание
+// @Description  Исключает участника из организации. Пользователь может выйти из организации
+// @Description  сам, передав свой ID.
+// @Description
+// @Description  ### Особенности:
+// @Description  - owner исключает кого угодно, admin — только admin и member
+// @Description  - Последнего владельца исключить нельзя
+// @Description  - Сессии с этой организацией при следующем refresh продолжатся без org_id
+// @Tags         orgs
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id      path  int  true  "ID организации"
+// @Param        userID  path  int  true  "ID участника"
+// @Success      204  "Участник исключён"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный ID"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      403  {object}  object{status=string,code=string,error=string}  "Недостаточно прав в организации"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Организация или участник не найдены"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "Нельзя исключить последнего владельца"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /orgs/{id}/members/{userID} [delete]
+func NewRemove(
+	log *slog.Logger,
+	manager MemberManager,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.orgs.members.NewRemove"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		orgID, ok := orgs.OrgID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid organization id"))
+			return
+		}
+
+		userID, ok := orgs.MemberID(r)
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "invalid user id"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		if err := manager.RemoveMember(ctx, claims.UserID, claims.AppID, orgID, userID); err != nil {
+			renderMemberError(w, r, log, err, "failed to remove organization member")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func renderMemberError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error, msg string) {
+	switch {
+	case errors.Is(err, orgsService.ErrNotFound):
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, resp.Error(resp.CodeOrgNotFound, "organization not found"))
+	case errors.Is(err, orgsService.ErrMemberNotFound):
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, resp.Error(resp.CodeOrgMemberNotFound, "member not found"))
+	case errors.Is(err, orgsService.ErrForbidden):
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, resp.Error(resp.CodeOrgForbidden, "insufficient organization role"))
+	case errors.Is(err, orgsService.ErrLastOwner):
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, resp.Error(resp.CodeOrgLastOwner, "organization must keep at least one owner"))
+	case errors.Is(err, orgsService.ErrInvalidRole):
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, resp.Error(resp.CodeInvalidRole, "invalid role"))
+	default:
+		log.Error(msg, sl.Err(err))
+
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
+	}
+}
+
+func ResponseList(w http.ResponseWriter, r *http.Request, members []Member) {
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, ListResponse{
+		Response: resp.OK(),
+		Members:  members,
+	})
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, Response{
+		Response: resp.OK(),
+	})
+}
+
+func toMembers(members []models.OrgMember) []Member {
+	result := make([]Member, 0, len(members))
+
+	for _, m := range members {
+		result = append(result, Member{
+			UserID:    m.UserID,
+			Email:     m.Email,
+			Role:      string(m.Role),
+			CreatedAt: m.CreatedAt,
+		})
+	}
+
+	return result
+}
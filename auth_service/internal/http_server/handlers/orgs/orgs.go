@@ -0,0 +1,230 @@
+package orgs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type OrgManager interface {
+	Create(ctx context.Context, userID int64, appID int32, name string) (*models.Organization, error)
+	List(ctx context.Context, userID int64, appID int32) ([]models.OrgMember, error)
+}
+
+type Request struct {
+	Name string `json:"name" validate:"required,max=128" example:"Acme Inc."`
+}
+
+type Organization struct {
+	ID   int64  `json:"id" example:"12"`
+	Name string `json:"name" example:"Acme Inc."`
+	// Role — роль текущего пользователя в организации.
+	Role string `json:"role" example:"owner"`
+}
+
+type Response struct {
+	resp.Response
+	Organization Organization `json:"organization"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Organizations []Organization `json:"organizations"`
+}
+
+// NewCreate godoc
+// @Summary      Создание организации
+// @Description  ## Описание
+// @Description  Создаёт организацию в приложении access-токена. Создатель становится её
+// @Description  владельцем (owner).
+// @Description
+// @Description  Организация выбирается в сессии через /auth/refresh с org_id — после этого
+// @Description  access-токены содержат claims org_id и org_role.
+// @Tags         orgs
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "Название организации"
+// @Success      201  {object}  Response  "Организация создана"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Невалидный запрос"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /orgs [post]
+func NewCreate(
+	log *slog.Logger,
+	validate *validator.Validate,
+	manager OrgManager,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.orgs.NewCreate"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		var req Request
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+
+			if errors.As(err, &validateErr) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+
+				return
+			}
+
+			log.Error("unexpected validation error type", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		org, err := manager.Create(ctx, claims.UserID, claims.AppID, req.Name)
+		if err != nil {
+			log.Error("failed to create organization", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
+
+			return
+		}
+
+		ResponseCreated(w, r, Organization{
+			ID:   org.ID,
+			Name: org.Name,
+			Role: string(models.OrgRoleOwner),
+		})
+	}
+}
+
+// NewList godoc
+// @Summary      Организации пользователя
+// @Description  Возвращает организации приложения access-токена, в которых состоит текущий
+// @Description  пользователь, вместе с его ролью в каждой.
+// @Tags         orgs
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  ListResponse  "Список организаций"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Access token отсутствует, невалиден или истёк"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /orgs [get]
+func NewList(
+	log *slog.Logger,
+	manager OrgManager,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.orgs.NewList"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := claimsParser.ClaimsFromContext(r.Context())
+		if !ok {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		memberships, err := manager.List(ctx, claims.UserID, claims.AppID)
+		if err != nil {
+			log.Error("failed to list organizations", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal server error"))
+
+			return
+		}
+
+		ResponseList(w, r, toOrganizations(memberships))
+	}
+}
+
+// OrgID достаёт {id} из пути /orgs/{id}/...
+func OrgID(r *http.Request) (int64, bool) {
+	return pathID(r, "id")
+}
+
+// MemberID достаёт {userID} из пути /orgs/{id}/members/{userID}
+func MemberID(r *http.Request) (int64, bool) {
+	return pathID(r, "userID")
+}
+
+func pathID(r *http.Request, param string) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, param), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+func ResponseCreated(w http.ResponseWriter, r *http.Request, org Organization) {
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, Response{
+		Response:     resp.OK(),
+		Organization: org,
+	})
+}
+
+func ResponseList(w http.ResponseWriter, r *http.Request, orgs []Organization) {
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, ListResponse{
+		Response:      resp.OK(),
+		Organizations: orgs,
+	})
+}
+
+func toOrganizations(memberships []models.OrgMember) []Organization {
+	result := make([]Organization, 0, len(memberships))
+
+	for _, m := range memberships {
+		result = append(result, Organization{
+			ID:   m.OrgID,
+			Name: m.OrgName,
+			Role: string(m.Role),
+		})
+	}
+
+	return result
+}
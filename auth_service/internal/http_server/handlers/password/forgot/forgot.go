@@ -7,8 +7,8 @@ import (
 	"net/http"
 	"time"
 
-	"auth_service/internal/auth"
 	resp "auth_service/internal/lib/api/response"
+	"auth_service/internal/lib/background"
 	sl "auth_service/internal/lib/logger"
 	"auth_service/internal/lib/mailer"
 	"auth_service/internal/storage"
@@ -26,28 +26,35 @@ type Response struct {
 	resp.Response
 }
 
+type ResetRequester interface {
+	Forgot(ctx context.Context, email string) (string, error)
+}
+
 // @Summary      Запрос на сброс пароля
 // @Description  Запускает процесс сброса пароля для указанного адреса электронной почты.
 // @Description  Независимо от того, существует ли аккаунт с указанным email,
 // @Description  всегда возвращает ответ с кодом 200, чтобы исключить возможность
 // @Description  определения существования аккаунтов.
 // @Description  Если аккаунт существует, на указанный email будет отправлено
-// @Description  письмо со ссылкой для сброса пароля. Ошибки отправки письма
+// @Description  письмо со ссылкой для сброса пароля.
+// @Description  Поиск аккаунта, выпуск токена и отправка письма идут в фоне уже
+// @Description  после ответа, поэтому ни статус, ни тело, ни время ответа не
+// @Description  зависят от того, есть ли аккаунт. Ошибки фоновой части
 // @Description  фиксируются на стороне сервера и не влияют на ответ API.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        request  body  object{email=string}  true  "Адрес электронной почты пользователя"
 // @Success      200  {object}  object{status=string}  "Запрос успешно принят"
-// @Failure      400  {object}  object{status=string,error=string}  "Некорректное тело запроса или ошибка валидации"
-// @Failure      429  {object}  object{status=string,error=string}  "Превышен допустимый лимит запросов"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректное тело запроса или ошибка валидации"
+// @Failure      429  {object}  object{status=string,code=string,error=string}  "Превышен допустимый лимит запросов"
 // @Router       /auth/password/forgot [post]
 func New(
 	log *slog.Logger,
 	validate *validator.Validate,
 	msgSender mailer.Publisher,
-	authMiddleware *auth.Auth,
+	requester ResetRequester,
+	tasks *background.Tasks,
 	address string,
 	handlerTimeout time.Duration,
 ) http.HandlerFunc {
@@ -66,7 +73,7 @@ func New(
 			log.Error("Failed to decode request body", sl.Err(err))
 
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("Failed to decode request"))
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
 
 			return
 		}
@@ -85,37 +92,44 @@ func New(
 
 			log.Error("unexpected validation error type", sl.Err(err))
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
 
 			return
 		}
 
-		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
-		defer cancel()
-
-		resetToken, err := authMiddleware.Forgot(ctx, req.Email)
-		if err != nil {
-			if errors.Is(err, storage.ErrUserNotFound) {
-				log.Info("forgot password requested for non-existent email")
-				ResponseOK(w, r)
-				return
-			}
-
-			log.Error("failed to generate reset token", sl.Err(err))
+		// запрос не должен ждать работы, которая есть только у существующего
+		// аккаунта: иначе его выдаёт время ответа или 500 при сбое БД
+		log := log
+		tasks.Go(r.Context(), handlerTimeout, func(ctx context.Context) {
+			sendResetEmail(ctx, log, msgSender, requester, address, req.Email)
+		})
 
-			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("Internal error"))
+		ResponseOK(w, r)
+	}
+}
 
+func sendResetEmail(
+	ctx context.Context,
+	log *slog.Logger,
+	msgSender mailer.Publisher,
+	requester ResetRequester,
+	address string,
+	email string,
+) {
+	resetToken, err := requester.Forgot(ctx, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Info("forgot password requested for non-existent email")
 			return
 		}
 
-		if err := mailer.SendResetPassEmail(ctx, msgSender, resetToken, address, req.Email); err != nil {
-			log.Error("failed to send reset email, user will not receive it", sl.Err(err))
-			ResponseOK(w, r)
-			return
-		}
+		log.Error("failed to generate reset token", sl.Err(err))
 
-		ResponseOK(w, r)
+		return
+	}
+
+	if err := mailer.SendResetPassEmail(ctx, msgSender, resetToken, address, email); err != nil {
+		log.Error("failed to send reset email, user will not receive it", sl.Err(err))
 	}
 }
 
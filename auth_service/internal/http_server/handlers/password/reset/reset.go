@@ -11,6 +11,7 @@ import (
 	"auth_service/internal/auth"
 	resp "auth_service/internal/lib/api/response"
 	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/lib/passwordpolicy"
 	"auth_service/internal/storage"
 
 	"github.com/go-chi/chi/v5/middleware"
@@ -21,13 +22,17 @@ import (
 
 type Request struct {
 	Token   string `json:"token" validate:"required,reset_token_format" example:"abcDEF123..."`
-	NewPass string `json:"password" validate:"required,min=8" example:"SecurePass123!"`
+	NewPass string `json:"password" validate:"required" example:"SecurePass123!"`
 }
 
 type Response struct {
 	resp.Response
 }
 
+type PasswordResetter interface {
+	ResetPassword(ctx context.Context, tokenID, verifier, newPass string) error
+}
+
 // @Summary      Сброс пароля
 // @Description  Сбрасывает пароль пользователя с использованием токена,
 // @Description  полученного по электронной почте.
@@ -36,20 +41,25 @@ type Response struct {
 // @Description  строка в формате URL-safe Base64.
 // @Description  Токен можно использовать только один раз. После успешного
 // @Description  сброса пароля он становится недействительным.
-// @Description  Новый пароль должен содержать не менее 8 символов и
-// @Description  отличаться от текущего пароля.
+// @Description  Новый пароль должен соответствовать парольной политике
+// @Description  (password_policy: длина, классы символов, оценка стойкости,
+// @Description  опционально — отсутствие в базе утечек HaveIBeenPwned) и
+// @Description  отличаться от текущего пароля. Нарушенные правила
+// @Description  возвращаются списком violations с code=ACCOUNT_WEAK_PASSWORD.
+// @Description  После сброса завершаются все сессии пользователя: refresh-токены
+// @Description  удаляются, уже выданные access-токены отзываются.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        request  body  object{token=string,password=string}  true  "Токен для сброса пароля и новый пароль"
 // @Success      200  {object}  object{status=string}  "Пароль успешно сброшен"
-// @Failure      400  {object}  object{status=string,error=string}  "Некорректный формат токена, токен недействителен, истёк или уже был использован, пароль не соответствует требованиям либо совпадает с текущим"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Некорректный формат токена, токен недействителен, истёк или уже был использован, пароль не соответствует требованиям либо совпадает с текущим"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
 // @Router       /auth/password/reset [post]
 func New(
 	log *slog.Logger,
 	validate *validator.Validate,
-	authMiddleware *auth.Auth,
+	resetter PasswordResetter,
 	handlerTimeout time.Duration,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -67,7 +77,7 @@ func New(
 			log.Error("Failed to decode request body", sl.Err(err))
 
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("Failed to decode request"))
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
 
 			return
 		}
@@ -86,7 +96,7 @@ func New(
 
 			log.Error("unexpected validation error type", sl.Err(err))
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
 
 			return
 		}
@@ -97,7 +107,7 @@ func New(
 			log.Warn("invalid reset token format")
 
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("invalid token"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidToken, "invalid token"))
 			return
 		}
 
@@ -105,15 +115,17 @@ func New(
 			log.Warn("invalid token id", sl.Err(err))
 
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("invalid token"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidToken, "invalid token"))
 			return
 		}
 
 		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
 		defer cancel()
 
-		err = authMiddleware.ResetPassword(ctx, parts[0], parts[1], req.NewPass)
+		err = resetter.ResetPassword(ctx, parts[0], parts[1], req.NewPass)
 		if err != nil {
+			var policyErr *passwordpolicy.Error
+
 			switch {
 			case errors.Is(err, auth.ErrInvalidCredentials),
 				errors.Is(err, storage.ErrResetTokenNotFound),
@@ -121,20 +133,23 @@ func New(
 				errors.Is(err, auth.ErrResetTokenUsed):
 				log.Warn("reset password rejected", sl.Err(err))
 				render.Status(r, http.StatusBadRequest)
-				render.JSON(w, r, resp.Error("Invalid or expired token"))
+				render.JSON(w, r, resp.Error(resp.CodeInvalidToken, "Invalid or expired token"))
 			case errors.Is(err, storage.ErrUserNotFound):
 				// не должно светиться отдельным сообщением наружу — тот же генерик-ответ
 				log.Error("reset token valid but user missing (data inconsistency)", sl.Err(err))
 				render.Status(r, http.StatusBadRequest)
-				render.JSON(w, r, resp.Error("Invalid or expired token"))
+				render.JSON(w, r, resp.Error(resp.CodeInvalidToken, "Invalid or expired token"))
 			case errors.Is(err, auth.ErrSamePassword):
 				log.Warn("new password same as current")
 				render.Status(r, http.StatusBadRequest)
-				render.JSON(w, r, resp.Error("New password must differ from your current password"))
+				render.JSON(w, r, resp.Error(resp.CodeSamePassword, "New password must differ from your current password"))
+			case errors.As(err, &policyErr):
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.PasswordPolicyError(policyErr))
 			default:
 				log.Error("failed to reset password", sl.Err(err))
 				render.Status(r, http.StatusInternalServerError)
-				render.JSON(w, r, resp.Error("internal error"))
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
 			}
 
 			return
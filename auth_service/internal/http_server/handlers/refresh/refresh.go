@@ -3,12 +3,15 @@ package refresh
 import (
 	"context"
 	"errors"
+	"io"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"auth_service/internal/auth"
 	resp "auth_service/internal/lib/api/response"
+	"auth_service/internal/lib/cookie"
 	sl "auth_service/internal/lib/logger"
 
 	"github.com/go-chi/chi/v5/middleware"
@@ -17,13 +20,24 @@ import (
 )
 
 type Request struct {
-	RefreshToken string `json:"refresh_token" validate:"required,refresh_token_format" example:"fkajeDJ1p3FJ..."`
+	// RefreshToken — пусто для приложений с refresh-токеном в cookie
+	RefreshToken string `json:"refresh_token" validate:"omitempty,refresh_token_format" example:"fkajeDJ1p3FJ..."`
+	// Scope — сузить scope'ы нового access-токена; пусто — выданные при логине
+	Scope string `json:"scope,omitempty" validate:"omitempty,max=1024" example:"orders:read"`
+	// OrgID — переключить сессию на организацию; не задан — сохраняется текущая
+	OrgID int64 `json:"org_id,omitempty" validate:"omitempty,gt=0" example:"42"`
 }
 
 type Response struct {
 	resp.Response
 	AccessToken  string `json:"access_token" example:"abcDEF123..."`
-	RefreshToken string `json:"refresh_token" example:"fkajeDJ1p3FJ..."`
+	RefreshToken string `json:"refresh_token,omitempty" example:"fkajeDJ1p3FJ..."`
+	// CSRFToken — новый CSRF-токен, если refresh-токен пришёл в cookie
+	CSRFToken string `json:"csrf_token,omitempty" example:"Qm9vZjRhc2Rm..."`
+}
+
+type Refresher interface {
+	Refresh(ctx context.Context, refreshToken string, scopes []string, orgID int64) (accessToken, newRefreshToken string, expiresAt time.Time, err error)
 }
 
 // New godoc
@@ -57,25 +71,43 @@ type Response struct {
 // @Description  - Превентивное обновление перед истечением access токена
 // @Description  - После длительного простоя приложения
 // @Description
+// @Description  ### Scope'ы:
+// @Description  - Без scope новый access-токен получает scope'ы, выданные при логине
+// @Description  - scope может только сузить их; refresh-токен сохраняет исходный набор
+// @Description
+// @Description  ### Организации:
+// @Description  - org_id переключает сессию на организацию: claim org_id/org_role появляются в access-токене
+// @Description  - Выбор запоминается за сессией и действует на следующих refresh без org_id
+// @Description  - Если пользователя исключили из организации, следующий refresh выдаёт токен без org_id
+// @Description
+// @Description  ### Refresh-токен в cookie:
+// @Description  - Без refresh_token в теле токен берётся из HttpOnly cookie; тело можно не передавать вовсе
+// @Description  - Такой запрос требует заголовок X-CSRF-Token со значением CSRF-токена из логина
+// @Description  - Новый refresh-токен ставится в cookie, в теле — новый csrf_token
+// @Description
 // @Description  ### Ошибки:
-// @Description  - `400`: Невалидный JSON или отсутствует refresh_token
-// @Description  - `401`: Токен истек, невалиден или уже использован
-// @Description  - `403`: Пользователь заблокирован
+// @Description  - `400`: Невалидный JSON или scope шире выданного при логине
+// @Description  - `401`: Токен отсутствует, истек, невалиден или уже использован
+// @Description  - `403`: Пользователь заблокирован, не состоит в организации org_id или CSRF-токен не совпал
 // @Description  - `500`: Ошибка БД или генерации токенов
 // @Tags         auth
 // @Accept       json
 // @Produce      json
-// @Param        token  body  object{refresh_token=string}  true  "Текущий refresh токен"
-// @Success      200  {object}  object{status=string,access_token=string,refresh_token=string}  "Новая пара токенов"
-// @Failure      400  {object}  object{status=string,error=string}  "Ошибка валидации"
-// @Failure      401  {object}  object{status=string,error=string}  "Невалидный или истекший токен"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка"
+// @Param        token  body  object{refresh_token=string,scope=string,org_id=int}  false  "Текущий refresh токен"
+// @Param        X-CSRF-Token  header  string  false  "CSRF-токен, если refresh-токен в cookie"
+// @Success      200  {object}  object{status=string,access_token=string,refresh_token=string,csrf_token=string}  "Новая пара токенов"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Ошибка валидации"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Невалидный или истекший токен"
+// @Failure      403  {object}  object{status=string,code=string,error=string}  "Аккаунт заблокирован администратором, требуется смена пароля, пользователь не состоит в организации или CSRF-токен не совпал"
+// @Failure      410  {object}  object{status=string,code=string,error=string}  "Аккаунт удалён"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка"
 // @Router       /auth/refresh [post]
 // @x-order      3
 func New(
 	log *slog.Logger,
 	validate *validator.Validate,
-	authMiddleware *auth.Auth,
+	refresher Refresher,
+	cookies *cookie.Jar,
 	handlerTimeout time.Duration,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -88,12 +120,13 @@ func New(
 
 		var req Request
 
+		// тело может быть пустым: refresh-токен тогда берётся из cookie
 		err := render.DecodeJSON(r.Body, &req)
-		if err != nil {
+		if err != nil && !errors.Is(err, io.EOF) {
 			log.Error("Failed to decode request body", sl.Err(err))
 
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("Failed to decode request"))
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
 
 			return
 		}
@@ -112,19 +145,74 @@ func New(
 
 			log.Error("unexpected validation error type", sl.Err(err))
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
 
 			return
 		}
 
+		fromCookie := req.RefreshToken == ""
+		if fromCookie {
+			// CSRF-заголовок уже сверил csrfGuard
+			req.RefreshToken, err = cookies.Read(r)
+			if err != nil {
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidCredentials, "Invalid credentials"))
+
+				return
+			}
+		}
+
 		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
 		defer cancel()
 
-		accessToken, newRefreshToken, err := authMiddleware.Refresh(ctx, req.RefreshToken)
+		accessToken, newRefreshToken, expiresAt, err := refresher.Refresh(ctx, req.RefreshToken, strings.Fields(req.Scope), req.OrgID)
 		if err != nil {
-			if errors.Is(err, auth.ErrInvalidCredentials) {
+			switch {
+			case errors.Is(err, auth.ErrInvalidCredentials):
+				// мёртвую cookie браузер иначе присылал бы до истечения max-age
+				if fromCookie {
+					if err := cookies.Clear(ctx, w, req.RefreshToken); err != nil {
+						log.Error("failed to clear refresh cookie", sl.Err(err))
+					}
+				}
+
 				render.Status(r, http.StatusUnauthorized)
-				render.JSON(w, r, resp.Error("Invalid credentials"))
+				render.JSON(w, r, resp.Error(resp.CodeInvalidCredentials, "Invalid credentials"))
+
+				return
+			case errors.Is(err, auth.ErrInvalidScope):
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidScope, "Invalid scope"))
+
+				return
+			case errors.Is(err, auth.ErrNotOrgMember):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodeNotOrgMember, "Not a member of this organization"))
+
+				return
+			case errors.Is(err, auth.ErrAccountDisabled):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodeAccountDisabled, "Account disabled"))
+
+				return
+			case errors.Is(err, auth.ErrAccountSuspended):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodeAccountSuspended, "Account suspended"))
+
+				return
+			case errors.Is(err, auth.ErrAccountBanned):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodeAccountBanned, "Account banned"))
+
+				return
+			case errors.Is(err, auth.ErrPasswordResetRequired):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodePasswordResetRequired, "Password reset required"))
+
+				return
+			case errors.Is(err, auth.ErrAccountDeleted):
+				render.Status(r, http.StatusGone)
+				render.JSON(w, r, resp.Error(resp.CodeAccountDeleted, "Account deleted"))
 
 				return
 			}
@@ -132,21 +220,33 @@ func New(
 			log.Error("failed to refresh tokens", sl.Err(err))
 
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("Internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+			return
+		}
+
+		// новый токен отдаётся тем же способом, каким пришёл старый
+		refreshToken, csrfToken, err := cookies.Deliver(ctx, w, newRefreshToken, expiresAt, fromCookie)
+		if err != nil {
+			log.Error("failed to set refresh cookie", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
 
 			return
 		}
 
 		log.Info("Tokens refreshed successfully")
 
-		ResponseOK(w, r, accessToken, newRefreshToken)
+		ResponseOK(w, r, accessToken, refreshToken, csrfToken)
 	}
 }
 
-func ResponseOK(w http.ResponseWriter, r *http.Request, accessToken, refreshToken string) {
+func ResponseOK(w http.ResponseWriter, r *http.Request, accessToken, refreshToken, csrfToken string) {
 	render.JSON(w, r, Response{
 		Response:     resp.OK(),
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
+		CSRFToken:    csrfToken,
 	})
 }
@@ -11,6 +11,8 @@ import (
 	resp "auth_service/internal/lib/api/response"
 	sl "auth_service/internal/lib/logger"
 	"auth_service/internal/lib/mailer"
+	"auth_service/internal/lib/passhash"
+	"auth_service/internal/lib/passwordpolicy"
 	"auth_service/internal/lib/verification"
 	"auth_service/internal/storage"
 
@@ -22,12 +24,18 @@ import (
 type Request struct {
 	Email    string `json:"email" validate:"required,email" example:"example@domain.com"`
 	Username string `json:"username" validate:"required" example:"newUser2008"`
-	Pass     string `json:"password" validate:"required,min=8" example:"SecurePass123!"`
+	Pass     string `json:"password" validate:"required" example:"SecurePass123!"`
+	AppID    int32  `json:"app_id" validate:"required,gt=0" example:"1"`
 }
 
+// Response одинаков для нового и уже зарегистрированного email, поэтому
+// в нём нет user_id: по ответу нельзя узнать, есть ли аккаунт.
 type Response struct {
 	resp.Response
-	UserID int64 `json:"user_id" example:"234"`
+}
+
+type UserRegisterer interface {
+	RegisterNewUser(ctx context.Context, email, username, pass string, appID int32) (int64, error)
 }
 
 // New godoc
@@ -37,16 +45,28 @@ type Response struct {
 // @Description
 // @Description  ### Процесс регистрации:
 // @Description  1. Валидация входных данных (email формат, наличие username и пароля)
-// @Description  2. Проверка уникальности email и username в базе данных
-// @Description  3. Хеширование пароля с использованием bcrypt (cost factor 12)
+// @Description  2. Проверка уникальности username в базе данных
+// @Description  3. Хеширование пароля алгоритмом из password_hashing (по умолчанию Argon2id)
 // @Description  4. Создание записи пользователя в БД со статусом `email_verified = false`
 // @Description  5. Генерация JWT токена верификации (валиден 24 часа)
 // @Description  6. Отправка email с ссылкой подтверждения через RabbitMQ
 // @Description
 // @Description  ### Требования к данным:
-// @Description  - **Email**: Валидный email формат (example@domain.com), должен быть уникальным
+// @Description  - **Email**: Валидный email формат (example@domain.com)
 // @Description  - **Username**: Минимум 3 символа, только буквы, цифры и подчеркивание, должен быть уникальным
-// @Description  - **Password**: Минимум 8 символов, рекомендуется использовать заглавные буквы, цифры и спецсимволы
+// @Description  - **Password**: по парольной политике (password_policy): длина (по умолчанию от 8 символов),
+// @Description    обязательные классы символов, оценка стойкости по шкале zxcvbn (без словарных слов,
+// @Description    email и username), опционально — отсутствие в базе утечек HaveIBeenPwned.
+// @Description    Нарушенные правила возвращаются разом в violations с code=ACCOUNT_WEAK_PASSWORD
+// @Description
+// @Description  ### Уже зарегистрированный email:
+// @Description  - Ответ тот же, что и при успешной регистрации (201 без user_id) — по нему нельзя узнать, есть ли аккаунт
+// @Description  - Владельцу адреса уходит письмо «у вас уже есть аккаунт» со ссылкой на сброс пароля
+// @Description  - Username публичен, поэтому занятый username по-прежнему возвращает 409
+// @Description
+// @Description  ### Приложение:
+// @Description  - **app_id**: приложение, в котором регистрируется пользователь; он становится его участником
+// @Description  - При включённой проверке членства войти можно только в приложения, где пользователь зарегистрирован
 // @Description
 // @Description  ### Email верификация:
 // @Description  - Письмо отправляется асинхронно через RabbitMQ (не блокирует ответ)
@@ -59,18 +79,20 @@ type Response struct {
 // @Tags         auth
 // @Accept       json
 // @Produce      json
-// @Param        user  body  object{email=string,username=string,password=string}  true  "Данные нового пользователя"
-// @Success      201  {object}  object{status=string,user_id=int}  "Пользователь успешно создан, письмо отправлено"
-// @Failure      400  {object}  object{status=string,error=string}  "Ошибка валидации: некорректный email, слишком короткий пароль или отсутствуют обязательные поля"
-// @Failure      409  {object}  object{status=string,error=string}  "Пользователь с таким email или username уже существует"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка: проблемы с БД, RabbitMQ или email сервисом"
+// @Param        user  body  object{email=string,username=string,password=string,app_id=int}  true  "Данные нового пользователя"
+// @Success      201  {object}  object{status=string}  "Запрос принят, письмо отправлено на указанный email"
+// @Failure      400  {object}  response.PasswordPolicyResponse  "Ошибка валидации: некорректный email, пароль не соответствует политике, неизвестный app_id или отсутствуют обязательные поля"
+// @Failure      409  {object}  object{status=string,code=string,error=string}  "Username уже занят"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка: проблемы с БД, RabbitMQ или email сервисом"
+// @Failure      503  {object}  object{status=string,code=string,error=string}  "Все воркеры хеширования паролей заняты дольше таймаута запроса"
 // @Router       /auth/register [post]
 // @x-order      2
 func New(
 	log *slog.Logger,
 	validate *validator.Validate,
-	authMiddleware *auth.Auth,
+	registerer UserRegisterer,
 	msgSender mailer.Publisher,
+	codes verification.CodeIssuer,
 	verificationTokenTTL time.Duration,
 	verificationTokenSecret string,
 	address string,
@@ -91,7 +113,7 @@ func New(
 			log.Error("Failed to decode request body", sl.Err(err))
 
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("Failed to decode request"))
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
 
 			return
 		}
@@ -110,7 +132,7 @@ func New(
 
 			log.Error("unexpected validation error type", sl.Err(err))
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
 
 			return
 		}
@@ -118,13 +140,56 @@ func New(
 		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
 		defer cancel()
 
-		userID, err := authMiddleware.RegisterNewUser(ctx, req.Email, req.Username, req.Pass)
+		userID, err := registerer.RegisterNewUser(ctx, req.Email, req.Username, req.Pass, req.AppID)
 		if err != nil {
 			if errors.Is(err, storage.ErrUserAlreadyExists) {
-				log.Error("Failed to register user: user already exists")
+				log.Info("registration for existing email, sending account exists email")
+
+				// сбой отправки отвечает так же, как сбой письма верификации
+				// нового пользователя, иначе 500 выдаёт существующий email
+				if err := mailer.SendAccountExistsEmail(ctx, msgSender, address, req.Email); err != nil {
+					log.Error("failed to send account exists email", sl.Err(err))
+
+					render.Status(r, http.StatusInternalServerError)
+					render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+					return
+				}
 
+				render.Status(r, http.StatusCreated)
+				ResponseOK(w, r)
+
+				return
+			}
+
+			if errors.Is(err, auth.ErrUsernameTaken) {
 				render.Status(r, http.StatusConflict)
-				render.JSON(w, r, resp.Error("User already exists"))
+				render.JSON(w, r, resp.Error(resp.CodeUsernameTaken, "Username already taken"))
+
+				return
+			}
+
+			var policyErr *passwordpolicy.Error
+			if errors.As(err, &policyErr) {
+				log.Info("password rejected by policy", slog.Any("violations", policyErr.Violations))
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.PasswordPolicyError(policyErr))
+
+				return
+			}
+
+			if errors.Is(err, passhash.ErrBusy) {
+				w.Header().Set("Retry-After", "1")
+				render.Status(r, http.StatusServiceUnavailable)
+				render.JSON(w, r, resp.Error(resp.CodeServiceUnavailable, "service temporarily unavailable"))
+
+				return
+			}
+
+			if errors.Is(err, auth.ErrInvalidAppID) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidApp, "Invalid app id"))
 
 				return
 			}
@@ -132,7 +197,7 @@ func New(
 			log.Error("failed to register user", sl.Err(err))
 
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("Internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
 
 			return
 		}
@@ -143,6 +208,7 @@ func New(
 			ctx,
 			log,
 			msgSender,
+			codes,
 			verificationTokenTTL,
 			verificationTokenSecret,
 			userID,
@@ -153,19 +219,18 @@ func New(
 			log.Error("Failed to send verification email", sl.Err(err))
 
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("Internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
 
 			return
 		}
 
 		render.Status(r, http.StatusCreated)
-		ResponseOK(w, r, userID)
+		ResponseOK(w, r)
 	}
 }
 
-func ResponseOK(w http.ResponseWriter, r *http.Request, userID int64) {
+func ResponseOK(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, Response{
 		Response: resp.OK(),
-		UserID:   userID,
 	})
 }
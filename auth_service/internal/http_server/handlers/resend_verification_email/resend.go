@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"time"
 
-	"auth_service/internal/auth"
 	resp "auth_service/internal/lib/api/response"
 	sl "auth_service/internal/lib/logger"
 	"auth_service/internal/lib/mailer"
@@ -27,6 +26,10 @@ type Response struct {
 	resp.Response
 }
 
+type VerificationChecker interface {
+	CheckUserVerification(ctx context.Context, email string) (int64, bool, error)
+}
+
 // New godoc
 // @Summary      Повторная отправка письма верификации
 // @Description  ## Описание
@@ -63,16 +66,17 @@ type Response struct {
 // @Produce      json
 // @Param        email  body  object{email=string}  true  "Email пользователя"  example({"email": "user@example.com"})
 // @Success      200  {object}  object{status=string}  "Письмо отправлено (или email уже подтвержден)"
-// @Failure      400  {object}  object{status=string,error=string}  "Ошибка валидации: некорректный email формат"
-// @Failure      404  {object}  object{status=string,error=string}  "Пользователь не найден"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Ошибка валидации: некорректный email формат"
+// @Failure      404  {object}  object{status=string,code=string,error=string}  "Пользователь не найден"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
 // @Router       /auth/verify/resend [post]
 // @x-order      6
 func New(
 	log *slog.Logger,
 	validate *validator.Validate,
-	authMiddleware *auth.Auth,
+	checker VerificationChecker,
 	msgSender mailer.Publisher,
+	codes verification.CodeIssuer,
 	verificationTokenTTL time.Duration,
 	verificationTokenSecret string,
 	address string,
@@ -93,7 +97,7 @@ func New(
 			log.Error("Failed to decode request body", sl.Err(err))
 
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("Failed to decode request"))
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
 
 			return
 		}
@@ -112,7 +116,7 @@ func New(
 
 			log.Error("unexpected validation error type", sl.Err(err))
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
 
 			return
 		}
@@ -120,13 +124,13 @@ func New(
 		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
 		defer cancel()
 
-		userID, isVerified, err := authMiddleware.CheckUserVerification(ctx, req.Email)
+		userID, isVerified, err := checker.CheckUserVerification(ctx, req.Email)
 		if err != nil {
 			if errors.Is(err, storage.ErrUserNotFound) {
 				log.Info("User not found")
 
 				render.Status(r, http.StatusNotFound)
-				render.JSON(w, r, resp.Error("User not found"))
+				render.JSON(w, r, resp.Error(resp.CodeUserNotFound, "User not found"))
 
 				return
 			}
@@ -134,7 +138,7 @@ func New(
 			log.Error("failed to check user verification", sl.Err(err))
 
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("Internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
 
 			return
 		}
@@ -144,6 +148,7 @@ func New(
 				ctx,
 				log,
 				msgSender,
+				codes,
 				verificationTokenTTL,
 				verificationTokenSecret,
 				userID,
@@ -154,7 +159,7 @@ func New(
 				log.Error("Failed to send verification email", sl.Err(err))
 
 				render.Status(r, http.StatusInternalServerError)
-				render.JSON(w, r, resp.Error("Internal error"))
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
 
 				return
 			}
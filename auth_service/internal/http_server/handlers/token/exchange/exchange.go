@@ -0,0 +1,226 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth_service/internal/auth"
+	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	"auth_service/internal/lib/jwt"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Идентификаторы RFC 8693.
+const (
+	GrantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	TokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+type Exchanger interface {
+	AuthenticateClient(ctx context.Context, clientID int32, secret string) (*models.App, error)
+	ExchangeToken(ctx context.Context, req auth.TokenExchange) (*auth.ExchangedToken, error)
+}
+
+// Response — ответ RFC 8693 §2.2.1.
+type Response struct {
+	AccessToken     string `json:"access_token" example:"eyJhbGciOiJSUzI1NiIsImtpZCI6..."`
+	IssuedTokenType string `json:"issued_token_type" example:"urn:ietf:params:oauth:token-type:access_token"`
+	TokenType       string `json:"token_type" example:"Bearer"`
+	ExpiresIn       int64  `json:"expires_in" example:"900"`
+	Scope           string `json:"scope,omitempty" example:"orders:read"`
+}
+
+// ErrorResponse — ошибка в формате RFC 6749 §5.2: клиенты — готовые
+// OAuth-библиотеки.
+type ErrorResponse struct {
+	Error            string `json:"error" example:"invalid_target"`
+	ErrorDescription string `json:"error_description,omitempty" example:"token exchange to this audience is not allowed"`
+}
+
+// New godoc
+// @Summary      Обмен токена (RFC 8693)
+// @Description  ## Описание
+// @Description  Сервис, получивший access-токен пользователя, обменивает его на токен для другого
+// @Description  сервиса (audience) — делегирование между микросервисами. Новый токен несёт claim
+// @Description  `act` с client_id обменявшего сервиса.
+// @Description
+// @Description  ### Ограничения:
+// @Description  - Клиент аутентифицируется HTTP Basic `app_id:secret`
+// @Description  - subject_token должен быть выдан самому клиенту
+// @Description  - audience должен быть в apps.token_exchange_audiences клиента
+// @Description  - scope не шире scope'ов subject_token и allowed_scopes audience; без scope — их пересечение
+// @Description  - Выдаётся только access-токен, refresh не выдаётся
+// @Tags         token
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        grant_type            formData  string  true   "urn:ietf:params:oauth:grant-type:token-exchange"
+// @Param        subject_token         formData  string  true   "Access-токен пользователя"
+// @Param        subject_token_type    formData  string  true   "urn:ietf:params:oauth:token-type:access_token"
+// @Param        audience              formData  string  true   "ID приложения, для которого нужен токен"
+// @Param        scope                 formData  string  false  "Scope'ы нового токена через пробел"
+// @Param        requested_token_type  formData  string  false  "urn:ietf:params:oauth:token-type:access_token"
+// @Success      200  {object}  Response       "Токен выдан"
+// @Failure      400  {object}  ErrorResponse  "invalid_request, invalid_scope, invalid_target, unsupported_grant_type"
+// @Failure      401  {object}  ErrorResponse  "invalid_client"
+// @Failure      500  {object}  ErrorResponse  "Внутренняя ошибка сервера"
+// @Failure      503  {object}  ErrorResponse  "Хранилище отзыва недоступно"
+// @Router       /token/exchange [post]
+func New(
+	log *slog.Logger,
+	exchanger Exchanger,
+	apps jwt.KeyProvider,
+	leeway time.Duration,
+	store claimsParser.AccessTokenStore,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.token.exchange.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// RFC 6749 §5.1: ответы с токенами не кешируются
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Pragma", "no-cache")
+
+		if err := r.ParseForm(); err != nil {
+			renderError(w, r, "invalid_request", http.StatusBadRequest, "failed to parse form")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		clientID, secret, _ := r.BasicAuth()
+		id, err := strconv.ParseInt(clientID, 10, 32)
+		if err != nil || id <= 0 {
+			unauthorized(w, r)
+			return
+		}
+
+		client, err := exchanger.AuthenticateClient(ctx, int32(id), secret)
+		if err != nil {
+			if errors.Is(err, auth.ErrInvalidClient) {
+				unauthorized(w, r)
+				return
+			}
+
+			log.Error("failed to authenticate client", sl.Err(err))
+			renderError(w, r, "server_error", http.StatusInternalServerError, "internal error")
+
+			return
+		}
+
+		if r.PostForm.Get("grant_type") != GrantTypeTokenExchange {
+			renderError(w, r, "unsupported_grant_type", http.StatusBadRequest, "unsupported grant type")
+			return
+		}
+
+		subjectToken := r.PostForm.Get("subject_token")
+		if subjectToken == "" || r.PostForm.Get("subject_token_type") != TokenTypeAccessToken {
+			renderError(w, r, "invalid_request", http.StatusBadRequest, "subject_token must be an access token")
+			return
+		}
+
+		if t := r.PostForm.Get("requested_token_type"); t != "" && t != TokenTypeAccessToken {
+			renderError(w, r, "invalid_request", http.StatusBadRequest, "only access tokens can be requested")
+			return
+		}
+
+		audience, err := strconv.ParseInt(r.PostForm.Get("audience"), 10, 32)
+		if err != nil || audience <= 0 {
+			renderError(w, r, "invalid_target", http.StatusBadRequest, "audience must be an app id")
+			return
+		}
+
+		subject, err := claimsParser.Verify(ctx, subjectToken, apps, leeway, store)
+		if err != nil {
+			if errors.Is(err, claimsParser.ErrTokenStoreUnavailable) {
+				log.Error("access token store unavailable", sl.Err(err))
+				renderError(w, r, "temporarily_unavailable", http.StatusServiceUnavailable, "service temporarily unavailable")
+
+				return
+			}
+
+			renderError(w, r, "invalid_request", http.StatusBadRequest, "invalid subject_token")
+
+			return
+		}
+
+		token, err := exchanger.ExchangeToken(ctx, auth.TokenExchange{
+			Client:   client,
+			Subject:  subject,
+			Audience: int32(audience),
+			Scopes:   strings.Fields(r.PostForm.Get("scope")),
+		})
+		if err != nil {
+			code, status, ok := errorCode(err)
+			if !ok {
+				log.Error("failed to exchange token", sl.Err(err))
+				renderError(w, r, code, status, "internal error")
+
+				return
+			}
+
+			renderError(w, r, code, status, err.Error())
+
+			return
+		}
+
+		ResponseOK(w, r, token)
+	}
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request, token *auth.ExchangedToken) {
+	render.JSON(w, r, Response{
+		AccessToken:     token.AccessToken,
+		IssuedTokenType: TokenTypeAccessToken,
+		TokenType:       "Bearer",
+		ExpiresIn:       int64(token.ExpiresIn.Seconds()),
+		Scope:           strings.Join(token.Scopes, " "),
+	})
+}
+
+// errorCode переводит ошибку обмена в код RFC 8693 §2.2.2. ok == false —
+// внутренняя ошибка.
+func errorCode(err error) (code string, status int, ok bool) {
+	switch {
+	case errors.Is(err, auth.ErrExchangeNotAllowed):
+		return "invalid_target", http.StatusBadRequest, true
+	case errors.Is(err, auth.ErrInvalidScope):
+		return "invalid_scope", http.StatusBadRequest, true
+	case errors.Is(err, auth.ErrInvalidSubjectToken),
+		errors.Is(err, auth.ErrAccountDisabled),
+		errors.Is(err, auth.ErrAccountSuspended),
+		errors.Is(err, auth.ErrAccountBanned),
+		errors.Is(err, auth.ErrAccountDeleted),
+		errors.Is(err, auth.ErrPasswordResetRequired):
+		return "invalid_request", http.StatusBadRequest, true
+	}
+
+	return "server_error", http.StatusInternalServerError, false
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+	renderError(w, r, "invalid_client", http.StatusUnauthorized, "invalid client credentials")
+}
+
+func renderError(w http.ResponseWriter, r *http.Request, code string, status int, description string) {
+	render.Status(r, status)
+	render.JSON(w, r, ErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
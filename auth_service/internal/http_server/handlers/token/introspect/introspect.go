@@ -0,0 +1,210 @@
+package introspect
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
+	resp "auth_service/internal/lib/api/response"
+	"auth_service/internal/lib/jwt"
+	sl "auth_service/internal/lib/logger"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Request struct {
+	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	// TokenTypeHint принимается для совместимости с RFC 7662 и игнорируется:
+	// интроспекция поддерживает только access-токены.
+	TokenTypeHint string `json:"token_type_hint,omitempty" example:"access_token"`
+}
+
+// Response — ответ в формате RFC 7662. Для неактивного токена заполнено
+// только active.
+type Response struct {
+	Active    bool   `json:"active" example:"true"`
+	Subject   string `json:"sub,omitempty" example:"234"`
+	ClientID  string `json:"client_id,omitempty" example:"1"`
+	AppID     int32  `json:"app_id,omitempty" example:"1"`
+	Username  string `json:"username,omitempty" example:"newUser2008"`
+	Exp       int64  `json:"exp,omitempty" example:"1784894400"`
+	JTI       string `json:"jti,omitempty" example:"3b241101-e2bb-4255-8caf-4136c566a962"`
+	TokenType string `json:"token_type,omitempty" example:"access_token"`
+	// Roles и Permissions — роли пользователя в приложении токена и их права.
+	Roles       []string `json:"roles,omitempty" example:"editor"`
+	Permissions []string `json:"permissions,omitempty" example:"posts:write"`
+	// Scope — scope'ы токена через пробел.
+	Scope string `json:"scope,omitempty" example:"profile orders:read"`
+	// OrgID и OrgRole — организация сессии и роль пользователя в ней.
+	OrgID   int64  `json:"org_id,omitempty" example:"42"`
+	OrgRole string `json:"org_role,omitempty" example:"admin"`
+	// Act — сервис, получивший токен обменом (/token/exchange).
+	Act *Actor `json:"act,omitempty"`
+}
+
+// Actor — claim act (RFC 8693 §4.1).
+type Actor struct {
+	ClientID string `json:"client_id" example:"2"`
+}
+
+// New godoc
+// @Summary      Интроспекция access-токена (RFC 7662)
+// @Description  ## Описание
+// @Description  Проверяет access-токен за resource-сервер: подпись и срок JWT, claims opaque-токена
+// @Description  и отзыв по jti. Сервисам не нужно самим разбирать JWT и ходить в denylist.
+// @Description
+// @Description  ### Аутентификация вызывающего:
+// @Description  - HTTP Basic: `app_id:secret` приложения
+// @Description  - Токены других приложений возвращаются как `{"active": false}`
+// @Description
+// @Description  ### Формат запроса:
+// @Description  - `application/x-www-form-urlencoded` с полем token (как в RFC 7662) или JSON
+// @Description  - Недействительный, истёкший или отозванный токен — 200 с `{"active": false}`
+// @Tags         token
+// @Accept       x-www-form-urlencoded
+// @Accept       json
+// @Produce      json
+// @Param        token  formData  string  true  "Access token"
+// @Success      200  {object}  Response  "Результат интроспекции"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Не передан token"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Неверные credentials приложения"
+// @Failure      503  {object}  object{status=string,code=string,error=string}  "Хранилище отзыва недоступно"
+// @Router       /token/introspect [post]
+func New(
+	log *slog.Logger,
+	apps jwt.KeyProvider,
+	leeway time.Duration,
+	store claimsParser.AccessTokenStore,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.token.introspect.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// ответ интроспекции нельзя кешировать — токен может быть отозван в любой момент
+		w.Header().Set("Cache-Control", "no-store")
+
+		appID, ok := authenticateApp(r, apps)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="introspection"`)
+
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidClient, "invalid client credentials"))
+
+			return
+		}
+
+		req, err := decodeRequest(r)
+		if err != nil {
+			log.Warn("failed to decode request", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "Failed to decode request"))
+
+			return
+		}
+
+		if req.Token == "" {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "token is required"))
+
+			return
+		}
+
+		claims, err := claimsParser.Verify(r.Context(), req.Token, apps, leeway, store)
+		switch {
+		case errors.Is(err, claimsParser.ErrTokenStoreUnavailable):
+			log.Error("access token store unavailable", sl.Err(err))
+
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, resp.Error(resp.CodeServiceUnavailable, "service temporarily unavailable"))
+
+			return
+		case err != nil, claims.AppID != appID:
+			ResponseOK(w, r, Response{Active: false})
+
+			return
+		}
+
+		var act *Actor
+		if claims.Actor != 0 {
+			act = &Actor{ClientID: strconv.FormatInt(int64(claims.Actor), 10)}
+		}
+
+		ResponseOK(w, r, Response{
+			Active:    true,
+			Subject:   strconv.FormatInt(claims.UserID, 10),
+			ClientID:  strconv.FormatInt(int64(claims.AppID), 10),
+			AppID:     claims.AppID,
+			Username:  claims.Username,
+			Exp:       claims.ExpiresAt.Unix(),
+			JTI:       claims.ID,
+			TokenType: "access_token",
+
+			Roles:       claims.Roles,
+			Permissions: claims.Permissions,
+			Scope:       strings.Join(claims.Scopes, " "),
+			OrgID:       claims.OrgID,
+			OrgRole:     claims.OrgRole,
+			Act:         act,
+		})
+	}
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request, response Response) {
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response)
+}
+
+// authenticateApp проверяет basic auth `app_id:secret` вызывающего сервиса.
+func authenticateApp(r *http.Request, apps jwt.KeyProvider) (int32, bool) {
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return 0, false
+	}
+
+	id, err := strconv.ParseInt(user, 10, 32)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+
+	secret, err := apps.AppSecret(r.Context(), int32(id))
+	if err != nil {
+		return 0, false
+	}
+
+	if subtle.ConstantTimeCompare([]byte(pass), []byte(secret)) != 1 {
+		return 0, false
+	}
+
+	return int32(id), true
+}
+
+func decodeRequest(r *http.Request) (Request, error) {
+	var req Request
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		err := render.DecodeJSON(r.Body, &req)
+		return req, err
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return req, err
+	}
+
+	req.Token = r.PostForm.Get("token")
+	req.TokenTypeHint = r.PostForm.Get("token_type_hint")
+
+	return req, nil
+}
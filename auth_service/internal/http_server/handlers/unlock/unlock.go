@@ -0,0 +1,87 @@
+package unlock
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth_service/internal/auth/lockout"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	resp.Response
+}
+
+type AccountUnlocker interface {
+	UnlockAccount(ctx context.Context, rawToken string) error
+}
+
+// New godoc
+// @Summary      Разблокировка входа по ссылке из письма
+// @Description  После серии неверных паролей вход по паролю блокируется на
+// @Description  время, растущее с каждой блокировкой подряд, а на email уходит
+// @Description  письмо со ссылкой сюда. Переход по ссылке снимает блокировку и
+// @Description  сбрасывает счётчики. Токен одноразовый.
+// @Tags         auth
+// @Produce      json
+// @Param        token  query  string  true  "Токен разблокировки из письма"
+// @Success      200  {object}  object{status=string}  "Вход разблокирован"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Токен отсутствует в URL"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Токен невалиден, истёк или уже использован"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /auth/unlock [get]
+func New(
+	log *slog.Logger,
+	unlocker AccountUnlocker,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.unlock.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "missing token"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		if err := unlocker.UnlockAccount(ctx, token); err != nil {
+			if errors.Is(err, lockout.ErrInvalidUnlockToken) {
+				log.Warn("invalid unlock token")
+
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidToken, "invalid or expired token"))
+				return
+			}
+
+			log.Error("failed to unlock account", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+			return
+		}
+
+		ResponseOK(w, r)
+	}
+}
+
+func ResponseOK(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, Response{
+		Response: resp.OK(),
+	})
+}
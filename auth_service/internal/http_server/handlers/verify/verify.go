@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"time"
 
-	"auth_service/internal/auth"
 	resp "auth_service/internal/lib/api/response"
 	sl "auth_service/internal/lib/logger"
 	"auth_service/internal/lib/verification"
@@ -19,6 +18,10 @@ type Response struct {
 	resp.Response
 }
 
+type UserVerifier interface {
+	VerifyUser(ctx context.Context, verificationToken, verificationTokenSecret string) error
+}
+
 // New godoc
 // @Summary      Подтверждение email адреса
 // @Description  ## Описание
@@ -52,15 +55,16 @@ type Response struct {
 // @Produce      json
 // @Param        token  query  string  true  "JWT токен верификации из email"
 // @Success      200  {object}  object{status=string}  "Email успешно подтвержден, можно входить в систему"
-// @Failure      400  {object}  object{status=string,error=string}  "Токен отсутствует в URL"
-// @Failure      401  {object}  object{status=string,error=string}  "Токен невалидный, истек или уже использован"
-// @Failure      500  {object}  object{status=string,error=string}  "Внутренняя ошибка сервера"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Токен отсутствует в URL"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Токен невалидный, истек или уже использован"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
 // @Router       /auth/verify [get]
 // @x-order      5
 func New(
 	log *slog.Logger,
-	authMiddleware *auth.Auth,
+	verifier UserVerifier,
 	tokenSecret string,
+	leeway time.Duration,
 	handlerTimeout time.Duration,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -76,17 +80,17 @@ func New(
 			log.Warn("missing verification token")
 
 			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("missing token"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidParameter, "missing token"))
 
 			return
 		}
 
-		userID, err := verification.ParseVerificationToken(token, tokenSecret)
+		userID, err := verification.ParseVerificationToken(token, tokenSecret, leeway)
 		if err != nil {
 			log.Warn("invalid verification token", sl.Err(err))
 
 			render.Status(r, http.StatusUnauthorized)
-			render.JSON(w, r, resp.Error("invalid or expired token"))
+			render.JSON(w, r, resp.Error(resp.CodeInvalidToken, "invalid or expired token"))
 
 			return
 		}
@@ -94,11 +98,11 @@ func New(
 		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
 		defer cancel()
 
-		if err := authMiddleware.VerifyUser(ctx, token, tokenSecret); err != nil {
+		if err := verifier.VerifyUser(ctx, token, tokenSecret); err != nil {
 			log.Error("failed to mark user as verified", sl.Err(err))
 
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("internal error"))
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
 
 			return
 		}
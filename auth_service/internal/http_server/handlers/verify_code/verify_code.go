@@ -0,0 +1,124 @@
+package verifyCode
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth_service/internal/auth/verifycode"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	Email string `json:"email" validate:"required,email" example:"example@domain.com"`
+	Code  string `json:"code" validate:"required,len=6,numeric" example:"042917"`
+}
+
+type Response struct {
+	resp.Response
+}
+
+type CodeVerifier interface {
+	Verify(ctx context.Context, email, code string) (int64, error)
+}
+
+// New godoc
+// @Summary      Подтверждение email кодом
+// @Description  ## Описание
+// @Description  Подтверждает email 6-значным кодом из письма верификации — альтернатива ссылке для
+// @Description  мобильных приложений, которым неудобно перехватывать deep link. Код приходит в том же
+// @Description  письме, что и ссылка, и действует столько же (`tokens.verification_token_ttl`).
+// @Description
+// @Description  ### Особенности:
+// @Description  - Код одноразовый; новое письмо (`/auth/verify/resend`) отменяет прежний код
+// @Description  - После `tokens.verification_code_max_attempts` неверных вводов код сгорает — нужно запросить новое письмо
+// @Description  - Неизвестный email и неверный код дают одинаковый ответ `401`
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "Email и код из письма"
+// @Success      200  {object}  object{status=string}  "Email подтверждён"
+// @Failure      400  {object}  object{status=string,code=string,error=string}  "Ошибка валидации"
+// @Failure      401  {object}  object{status=string,code=string,error=string}  "Код неверный, истёк или сгорел по числу попыток"
+// @Failure      429  {object}  object{status=string,code=string,error=string}  "Слишком много попыток"
+// @Failure      500  {object}  object{status=string,code=string,error=string}  "Внутренняя ошибка сервера"
+// @Router       /auth/verify/code [post]
+func New(
+	log *slog.Logger,
+	validate *validator.Validate,
+	verifier CodeVerifier,
+	handlerTimeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.verifyCode.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(resp.CodeMalformedRequest, "failed to decode request"))
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+
+			if errors.As(err, &validateErr) {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ValidationError(validateErr))
+
+				return
+			}
+
+			log.Error("unexpected validation error type", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		userID, err := verifier.Verify(ctx, req.Email, req.Code)
+		if err != nil {
+			if errors.Is(err, verifycode.ErrInvalidCode) {
+				log.Warn("invalid verification code")
+
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidVerificationCode, "invalid or expired code"))
+
+				return
+			}
+
+			log.Error("failed to verify code", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		log.Info("email verified by code", slog.Int64("uid", userID))
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+		})
+	}
+}
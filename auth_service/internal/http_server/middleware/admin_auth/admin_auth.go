@@ -0,0 +1,39 @@
+package adminAuth
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	resp "auth_service/internal/lib/api/response"
+
+	"github.com/go-chi/render"
+)
+
+// * basic auth для админских эндпоинтов (/admin/*)
+func New(username, password string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Если credentials пустые, админка недоступна
+			if username == "" || password == "" {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeNotFound, "not found"))
+				return
+			}
+
+			user, pass, ok := r.BasicAuth()
+
+			usernameMatch := subtle.ConstantTimeCompare([]byte(user), []byte(username)) == 1
+			passwordMatch := subtle.ConstantTimeCompare([]byte(pass), []byte(password)) == 1
+
+			if !ok || !usernameMatch || !passwordMatch {
+				w.Header().Set("WWW-Authenticate", `Basic realm="auth_service admin"`)
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidCredentials, "invalid credentials"))
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
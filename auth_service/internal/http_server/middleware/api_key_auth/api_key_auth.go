@@ -0,0 +1,72 @@
+package apiKeyAuth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"auth_service/internal/auth/apikeys"
+	resp "auth_service/internal/lib/api/response"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const Header = "X-API-Key"
+
+type contextKey struct{}
+
+var apiKeyContextKey = contextKey{}
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, rawKey string) (*models.APIKey, error)
+}
+
+// New аутентифицирует машинного клиента по заголовку X-API-Key и кладёт
+// ключ в контекст. Нет ключа или он недействителен — 401.
+func New(log *slog.Logger, authenticator Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rawKey := r.Header.Get(Header)
+			if rawKey == "" {
+				unauthorized(w, r)
+				return
+			}
+
+			key, err := authenticator.Authenticate(r.Context(), rawKey)
+			if err != nil {
+				if errors.Is(err, apikeys.ErrInvalidKey) {
+					unauthorized(w, r)
+					return
+				}
+
+				log.Error("failed to authenticate api key",
+					sl.Err(err),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+				)
+
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), apiKeyContextKey, key)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// FromContext возвращает ключ, положенный New.
+func FromContext(ctx context.Context) (*models.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyContextKey).(*models.APIKey)
+	return key, ok
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request) {
+	render.Status(r, http.StatusUnauthorized)
+	render.JSON(w, r, resp.Error(resp.CodeInvalidAPIKey, "invalid, expired or revoked api key"))
+}
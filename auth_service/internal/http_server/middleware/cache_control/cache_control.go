@@ -0,0 +1,90 @@
+package cacheControl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// New — Cache-Control и ETag для публичных, редко меняющихся ответов
+// (спека OpenAPI, JWKS, OIDC discovery). Ответ буферизуется, ETag считается
+// по телу; совпавший If-None-Match получает 304 без тела.
+//
+// ETag слабый (W/): middleware.Compress снаружи может отдать то же
+// содержимое в gzip или deflate, побайтно это разные представления.
+func New(maxAge time.Duration) func(http.Handler) http.Handler {
+	cacheControl := "public, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &recorder{header: w.Header(), status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			// ошибки не кешируем — отдаём как есть
+			if rec.status != http.StatusOK {
+				w.WriteHeader(rec.status)
+				_, _ = w.Write(rec.body.Bytes())
+				return
+			}
+
+			sum := sha256.Sum256(rec.body.Bytes())
+			etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", cacheControl)
+
+			if etagMatch(r.Header.Get("If-None-Match"), etag) {
+				// без Content-Type middleware.Compress не считает ответ сжимаемым
+				// и не допишет в 304 пустой gzip-поток
+				w.Header().Del("Content-Type")
+				w.Header().Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if r.Method != http.MethodHead {
+				_, _ = w.Write(rec.body.Bytes())
+			}
+		})
+	}
+}
+
+// etagMatch — слабое сравнение по RFC 9110: префикс W/ игнорируется.
+func etagMatch(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header { return r.header }
+
+func (r *recorder) WriteHeader(status int) { r.status = status }
+
+func (r *recorder) Write(b []byte) (int, error) { return r.body.Write(b) }
@@ -0,0 +1,114 @@
+package challengeGuard
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"auth_service/internal/auth/challenge"
+	emailParser "auth_service/internal/http_server/middleware/email_parser"
+	resp "auth_service/internal/lib/api/response"
+	"auth_service/internal/lib/clientinfo"
+	sl "auth_service/internal/lib/logger"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	headerNonce      = "X-Challenge-Nonce"
+	headerDifficulty = "X-Challenge-Difficulty"
+	headerSolution   = "X-Challenge-Solution"
+)
+
+type Challenger interface {
+	Required(ctx context.Context, ip, email string) int
+	Issue(ctx context.Context, difficulty int) (*challenge.Puzzle, error)
+	Verify(ctx context.Context, nonce, solution string, difficulty int) error
+	RegisterFailure(ctx context.Context, ip, email string)
+}
+
+// New требует решённую задачу proof-of-work, пока по IP или email запроса
+// идёт волна неудач. Без решения (или с неверным) отвечает 428 с новой
+// задачей в X-Challenge-Nonce и X-Challenge-Difficulty; клиент повторяет
+// запрос с X-Challenge-Nonce и X-Challenge-Solution.
+//
+// Неудачей считается ответ 401. Email берётся из контекста, поэтому
+// middleware ставится после emailParser (цепочки rate limiter'а).
+func New(log *slog.Logger, challenger Challenger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "middleware.challengeGuard.New"
+
+			ctx := r.Context()
+			ip := clientinfo.FromContext(ctx).IP
+			email := emailParser.FromContext(ctx)
+
+			if difficulty := challenger.Required(ctx, ip, email); difficulty > 0 && !solved(log, challenger, r, difficulty) {
+				puzzle, err := challenger.Issue(ctx, difficulty)
+				if err != nil {
+					log.Error("failed to issue challenge", slog.String("op", op), sl.Err(err))
+
+					render.Status(r, http.StatusServiceUnavailable)
+					render.JSON(w, r, resp.Error(resp.CodeServiceUnavailable, "service temporarily unavailable"))
+					return
+				}
+
+				w.Header().Set(headerNonce, puzzle.Nonce)
+				w.Header().Set(headerDifficulty, strconv.Itoa(puzzle.Difficulty))
+
+				render.Status(r, http.StatusPreconditionRequired)
+				render.JSON(w, r, resp.Error(resp.CodeChallengeRequired, "proof of work required"))
+				return
+			}
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			if rec.status == http.StatusUnauthorized {
+				challenger.RegisterFailure(ctx, ip, email)
+			}
+		})
+	}
+}
+
+// solved проверяет решение из заголовков. Сбой хранилища приравнивается к
+// неверному решению: клиент получит новую задачу.
+func solved(log *slog.Logger, challenger Challenger, r *http.Request, difficulty int) bool {
+	const op = "middleware.challengeGuard.solved"
+
+	nonce, solution := r.Header.Get(headerNonce), r.Header.Get(headerSolution)
+	if nonce == "" || solution == "" {
+		return false
+	}
+
+	err := challenger.Verify(r.Context(), nonce, solution, difficulty)
+	if err != nil && !errors.Is(err, challenge.ErrInvalidSolution) {
+		log.Error("failed to verify challenge", slog.String("op", op), sl.Err(err))
+	}
+
+	return err == nil
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.wroteHeader {
+		return
+	}
+	r.status = code
+	r.wroteHeader = true
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	return r.ResponseWriter.Write(b)
+}
@@ -2,10 +2,15 @@ package claimsParser
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
+	resp "auth_service/internal/lib/api/response"
 	"auth_service/internal/lib/jwt"
+	"auth_service/internal/lib/tokens"
+	"auth_service/internal/storage"
 
 	"github.com/go-chi/render"
 )
@@ -14,22 +19,48 @@ type contextKey string
 
 const claimsContextKey contextKey = "claims"
 
-func RequireAuth(apps jwt.AppSecretProvider) func(http.Handler) http.Handler {
+var (
+	errNoToken = errors.New("no bearer token")
+
+	ErrInvalidToken          = errors.New("invalid or expired access token")
+	ErrTokenStoreUnavailable = errors.New("access token store unavailable")
+)
+
+// AccessTokenStore — серверное состояние access-токенов: denylist
+// отозванных jti (force-logout) и claims opaque-токенов.
+type AccessTokenStore interface {
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	OpaqueAccessToken(ctx context.Context, tokenHash []byte) (*jwt.Claims, error)
+}
+
+func RequireAuth(apps jwt.KeyProvider, leeway time.Duration, store AccessTokenStore) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			header := r.Header.Get("Authorization")
-			const prefix = "Bearer "
-
-			if !strings.HasPrefix(header, prefix) {
-				unauthorized(w, r)
+			claims, err := authenticate(r, apps, leeway, store)
+			if err != nil {
+				fail(w, r, err)
 				return
 			}
 
-			tokenString := strings.TrimPrefix(header, prefix)
+			ctx := context.WithValue(r.Context(), claimsContextKey, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
 
-			claims, err := jwt.ParseAndVerify(r.Context(), tokenString, apps)
-			if err != nil {
-				unauthorized(w, r)
+// OptionalAuth кладёт claims в контекст, если передан Bearer-токен, и
+// пропускает запрос без него. Невалидный токен — по-прежнему 401: клиент,
+// приславший токен, ожидает, что его узнают.
+func OptionalAuth(apps jwt.KeyProvider, leeway time.Duration, store AccessTokenStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, err := authenticate(r, apps, leeway, store)
+			switch {
+			case errors.Is(err, errNoToken):
+				next.ServeHTTP(w, r)
+				return
+			case err != nil:
+				fail(w, r, err)
 				return
 			}
 
@@ -39,9 +70,77 @@ func RequireAuth(apps jwt.AppSecretProvider) func(http.Handler) http.Handler {
 	}
 }
 
+func authenticate(r *http.Request, apps jwt.KeyProvider, leeway time.Duration, store AccessTokenStore) (*jwt.Claims, error) {
+	header := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+
+	if !strings.HasPrefix(header, prefix) {
+		return nil, errNoToken
+	}
+
+	return Verify(r.Context(), strings.TrimPrefix(header, prefix), apps, leeway, store)
+}
+
+// Verify проверяет access-токен любого формата и denylist отзыва. Ошибка —
+// ErrInvalidToken или ErrTokenStoreUnavailable (хранилище недоступно,
+// ответить «токен действителен» нельзя).
+func Verify(ctx context.Context, tokenString string, apps jwt.KeyProvider, leeway time.Duration, store AccessTokenStore) (*jwt.Claims, error) {
+	claims, err := resolveClaims(ctx, tokenString, apps, leeway, store)
+	if err != nil {
+		return nil, err
+	}
+
+	// токены без jti выпущены до появления отзыва — проверять нечего
+	if claims.ID != "" {
+		isRevoked, err := store.IsAccessTokenRevoked(ctx, claims.ID)
+		if err != nil {
+			// fail closed: без denylist нельзя гарантировать, что токен не отозван
+			return nil, ErrTokenStoreUnavailable
+		}
+		if isRevoked {
+			return nil, ErrInvalidToken
+		}
+	}
+
+	return claims, nil
+}
+
+// resolveClaims проверяет подпись JWT или, для opaque-токена, достаёт его
+// claims из хранилища. exp opaque-токена отдельно не проверяется — запись
+// удаляется из хранилища вместе с истечением токена.
+func resolveClaims(ctx context.Context, tokenString string, apps jwt.KeyProvider, leeway time.Duration, store AccessTokenStore) (*jwt.Claims, error) {
+	if !tokens.IsOpaqueAccessToken(tokenString) {
+		claims, err := jwt.ParseAndVerify(ctx, tokenString, apps, leeway)
+		if err != nil {
+			return nil, ErrInvalidToken
+		}
+		return claims, nil
+	}
+
+	claims, err := store.OpaqueAccessToken(ctx, tokens.HashOpaqueAccessToken(tokenString))
+	if err != nil {
+		if errors.Is(err, storage.ErrAccessTokenNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, ErrTokenStoreUnavailable
+	}
+
+	return claims, nil
+}
+
+func fail(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrTokenStoreUnavailable) {
+		render.Status(r, http.StatusServiceUnavailable)
+		render.JSON(w, r, resp.Error(resp.CodeServiceUnavailable, "service temporarily unavailable"))
+		return
+	}
+
+	unauthorized(w, r)
+}
+
 func unauthorized(w http.ResponseWriter, r *http.Request) {
 	render.Status(r, http.StatusUnauthorized)
-	render.JSON(w, r, map[string]string{"error": "invalid or expired access token"})
+	render.JSON(w, r, resp.Error(resp.CodeInvalidAccessToken, "invalid or expired access token"))
 }
 
 func ClaimsFromContext(ctx context.Context) (*jwt.Claims, bool) {
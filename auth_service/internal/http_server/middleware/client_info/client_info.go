@@ -0,0 +1,50 @@
+package clientInfo
+
+import (
+	"net"
+	"net/http"
+
+	"auth_service/internal/lib/clientinfo"
+
+	"golang.org/x/text/language"
+)
+
+// New кладёт IP, User-Agent и язык клиента в контекст запроса. Должен стоять
+// после realIP: RemoteAddr к этому моменту уже переписан.
+func New() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := clientinfo.WithInfo(r.Context(), clientinfo.Info{
+				IP:        clientIP(r),
+				UserAgent: r.UserAgent(),
+				Locale:    preferredLocale(r.Header.Get("Accept-Language")),
+			})
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// preferredLocale — язык с наибольшим q. Некорректный заголовок — как
+// отсутствующий: язык письма не повод отклонять запрос.
+func preferredLocale(header string) string {
+	if header == "" {
+		return ""
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(header)
+	if err != nil || len(tags) == 0 || tags[0] == language.Und {
+		return ""
+	}
+
+	return tags[0].String()
+}
+
+// clientIP — без прокси в RemoteAddr остаётся порт.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
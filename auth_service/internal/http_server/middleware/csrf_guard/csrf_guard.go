@@ -0,0 +1,56 @@
+package csrfGuard
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	resp "auth_service/internal/lib/api/response"
+	"auth_service/internal/lib/cookie"
+	sl "auth_service/internal/lib/logger"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Verifier interface {
+	Verify(r *http.Request) error
+}
+
+// New проверяет CSRF-токен на изменяющих запросах (не GET, HEAD, OPTIONS),
+// которые несут refresh-cookie. Запросы без cookie — клиенты с токеном в
+// теле — пропускаются: браузер не подставит такой токен сам, и подделать
+// запрос чужой сайт не может.
+func New(log *slog.Logger, verifier Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			err := verifier.Verify(r)
+			switch {
+			case err == nil, errors.Is(err, cookie.ErrNoRefreshCookie):
+				next.ServeHTTP(w, r)
+			case errors.Is(err, cookie.ErrCSRFMismatch):
+				log.Warn("csrf token mismatch",
+					slog.String("path", r.URL.Path),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+				)
+
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodeCSRFMismatch, "CSRF token mismatch"))
+			default:
+				log.Error("failed to verify csrf token",
+					sl.Err(err),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+				)
+
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "Internal error"))
+			}
+		})
+	}
+}
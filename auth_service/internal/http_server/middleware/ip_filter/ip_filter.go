@@ -0,0 +1,69 @@
+package ipFilter
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	resp "auth_service/internal/lib/api/response"
+	"auth_service/internal/lib/clientinfo"
+
+	"github.com/go-chi/render"
+)
+
+type Filter interface {
+	Blocked(ctx context.Context, ip string) bool
+	RegisterAbuse(ctx context.Context, ip string)
+}
+
+// New отклоняет запросы с заблокированных адресов с 403. Ответы 401 и 429
+// пропущенным запросам передаются детектору злоупотреблений: перебор
+// паролей и упор в rate limit — повод для временной блокировки.
+//
+// Ставится после clientInfo: адрес берётся оттуда.
+func New(log *slog.Logger, filter Filter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "middleware.ipFilter.New"
+
+			ip := clientinfo.FromContext(r.Context()).IP
+
+			if filter.Blocked(r.Context(), ip) {
+				log.Debug("request from blocked ip rejected", slog.String("op", op), slog.String("ip", ip))
+
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error(resp.CodeIPBlocked, "access from this address is blocked"))
+				return
+			}
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			if rec.status == http.StatusUnauthorized || rec.status == http.StatusTooManyRequests {
+				filter.RegisterAbuse(r.Context(), ip)
+			}
+		})
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.wroteHeader {
+		return
+	}
+	r.status = code
+	r.wroteHeader = true
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	return r.ResponseWriter.Write(b)
+}
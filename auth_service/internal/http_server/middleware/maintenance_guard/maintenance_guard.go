@@ -0,0 +1,73 @@
+package maintenanceGuard
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	resp "auth_service/internal/lib/api/response"
+	"auth_service/internal/maintenance"
+
+	"github.com/go-chi/render"
+)
+
+type StateProvider interface {
+	State(ctx context.Context) maintenance.State
+}
+
+// Guard отклоняет запросы с 503 и Retry-After, пока включён режим
+// обслуживания. retryAfter — подсказка клиенту для окон без известного конца.
+type Guard struct {
+	state      StateProvider
+	retryAfter time.Duration
+}
+
+func New(state StateProvider, retryAfter time.Duration) *Guard {
+	return &Guard{state: state, retryAfter: retryAfter}
+}
+
+// Writes пропускает безопасные методы (GET, HEAD, OPTIONS) и отклоняет
+// остальные: чтение продолжает работать, выдача токенов и запись — нет.
+func (g *Guard) Writes() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			g.serve(next, w, r)
+		})
+	}
+}
+
+// All отклоняет любые запросы — для GET-эндпоинтов, которые выдают
+// токены или пишут в БД (OAuth callback, подтверждение email).
+func (g *Guard) All() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			g.serve(next, w, r)
+		})
+	}
+}
+
+func (g *Guard) serve(next http.Handler, w http.ResponseWriter, r *http.Request) {
+	state := g.state.State(r.Context())
+	if !state.Active {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	retryAfter := g.retryAfter
+	if state.Until != nil {
+		retryAfter = time.Until(*state.Until)
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(max(retryAfter, time.Second).Seconds()))))
+
+	render.Status(r, http.StatusServiceUnavailable)
+	render.JSON(w, r, resp.Error(resp.CodeMaintenance, "service is under maintenance"))
+}
@@ -1,17 +1,23 @@
 package httpRateLimit
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"net"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	claimsParser "auth_service/internal/http_server/middleware/claims_parser"
 	emailParser "auth_service/internal/http_server/middleware/email_parser"
 	sessionIDParser "auth_service/internal/http_server/middleware/session_id_parser"
+	resp "auth_service/internal/lib/api/response"
 	rateLimit "auth_service/internal/ratelimit"
+
+	"github.com/go-chi/render"
 )
 
 type FailMode int
@@ -24,22 +30,80 @@ const (
 type RateLimit struct {
 	limiter *rateLimit.Limiter
 	log     *slog.Logger
+
+	// overrides — лимиты из конфига поверх встроенных, ключ
+	// "<эндпоинт>:<ключ лимита>"; подменяется целиком при перечитывании
+	overrides atomic.Pointer[map[string]rateLimit.Policy]
 }
 
-func New(limiter *rateLimit.Limiter, log *slog.Logger) *RateLimit {
-	return &RateLimit{limiter: limiter, log: log}
+func New(limiter *rateLimit.Limiter, log *slog.Logger, overrides map[string]rateLimit.Policy) *RateLimit {
+	rl := &RateLimit{limiter: limiter, log: log}
+	rl.SetOverrides(overrides)
+
+	return rl
+}
+
+// SetOverrides применяется к следующим запросам; уже набранные счётчики в
+// Redis сохраняются.
+func (rl *RateLimit) SetOverrides(overrides map[string]rateLimit.Policy) {
+	rl.overrides.Store(&overrides)
 }
 
 func (rl *RateLimit) Register() func(http.Handler) http.Handler {
 	return rl.byIP("register", rateLimit.Policy{Burst: 2, Rate: 5, Period: time.Hour})
 }
 
+// loginEmailPolicy — общий per-email лимит входа для REST и GraphQL:
+// счётчик один, перебор пароля не обходится сменой API.
+var loginEmailPolicy = rateLimit.Policy{Burst: 3, Rate: 5, Period: time.Minute}
+
 func (rl *RateLimit) Login() func(http.Handler) http.Handler {
 	ip := rl.byIP("login", rateLimit.Policy{Burst: 5, Rate: 20, Period: time.Minute})
-	email := rl.byEmail("login", rateLimit.Policy{Burst: 3, Rate: 5, Period: time.Minute})
+	email := rl.byEmail("login", loginEmailPolicy)
 	return chain(emailParser.New, ip, email)
 }
 
+// AllowLogin — per-email лимит входа вне HTTP-цепочки: в GraphQL email
+// приходит аргументом мутации, и middleware его не видит.
+func (rl *RateLimit) AllowLogin(ctx context.Context, email string) (rateLimit.Decision, error) {
+	email = normalizeEmail(email)
+	key := rateLimit.BuildKey("login", "email", email)
+
+	return rl.limiter.Allow(ctx, key, rl.policy("login", "email", loginEmailPolicy))
+}
+
+// GraphQL — один лимит на все операции /graphql. Политика как у IP-лимита
+// логина: через GraphQL доступна мутация login.
+func (rl *RateLimit) GraphQL() func(http.Handler) http.Handler {
+	return rl.byIP("graphql", rateLimit.Policy{Burst: 5, Rate: 20, Period: time.Minute})
+}
+
+// Introspect — интроспекцию вызывают resource-сервера на каждый запрос
+// своих клиентов, лимит на порядок выше пользовательских.
+func (rl *RateLimit) Introspect() func(http.Handler) http.Handler {
+	return rl.byIP("introspect", rateLimit.Policy{Burst: 100, Rate: 1200, Period: time.Minute})
+}
+
+// TokenExchange — обмен токенов между сервисами, как и интроспекция,
+// идёт на запросы их клиентов.
+func (rl *RateLimit) TokenExchange() func(http.Handler) http.Handler {
+	return rl.byIP("token_exchange", rateLimit.Policy{Burst: 50, Rate: 600, Period: time.Minute})
+}
+
+// APIKeys — проверка X-API-Key; лимит по IP ограничивает и перебор ключей.
+func (rl *RateLimit) APIKeys() func(http.Handler) http.Handler {
+	return rl.byIP("api_keys", rateLimit.Policy{Burst: 50, Rate: 600, Period: time.Minute})
+}
+
+func (rl *RateLimit) OIDCAuthorize() func(http.Handler) http.Handler {
+	return rl.byIP("oidc_authorize", rateLimit.Policy{Burst: 10, Rate: 30, Period: time.Minute})
+}
+
+// OIDCToken — обмен кода и refresh; политика как у /auth/refresh.
+func (rl *RateLimit) OIDCToken() func(http.Handler) http.Handler {
+	return rl.byIP("oidc_token", rateLimit.Policy{Burst: 10, Rate: 30, Period: time.Minute})
+}
+
 func (rl *RateLimit) Refresh() func(http.Handler) http.Handler {
 	return rl.byIP("refresh", rateLimit.Policy{Burst: 10, Rate: 30, Period: time.Minute})
 }
@@ -48,10 +112,26 @@ func (rl *RateLimit) Logout() func(http.Handler) http.Handler {
 	return rl.byIP("logout", rateLimit.Policy{Burst: 10, Rate: 30, Period: time.Minute})
 }
 
+func (rl *RateLimit) LogoutAll() func(http.Handler) http.Handler {
+	return rl.byUserID("logout_all", rateLimit.Policy{Burst: 3, Rate: 10, Period: time.Hour})
+}
+
 func (rl *RateLimit) Verify() func(http.Handler) http.Handler {
 	return rl.byIP("verify", rateLimit.Policy{Burst: 10, Rate: 30, Period: time.Minute})
 }
 
+// VerifyCode — ввод кода подтверждения email. Перебор кода по адресу
+// ограничивает ещё и счётчик попыток самого кода.
+func (rl *RateLimit) VerifyCode() func(http.Handler) http.Handler {
+	ip := rl.byIP("verify_code", rateLimit.Policy{Burst: 10, Rate: 30, Period: time.Hour})
+	email := rl.byEmail("verify_code", rateLimit.Policy{Burst: 3, Rate: 10, Period: time.Hour})
+	return chain(emailParser.New, ip, email)
+}
+
+func (rl *RateLimit) Unlock() func(http.Handler) http.Handler {
+	return rl.byIP("unlock", rateLimit.Policy{Burst: 10, Rate: 30, Period: time.Minute})
+}
+
 func (rl *RateLimit) ResendVerificationEmail() func(http.Handler) http.Handler {
 	ip := rl.byIP("verify_resend", rateLimit.Policy{Burst: 5, Rate: 20, Period: time.Hour})
 	email := rl.byEmail("verify_resend", rateLimit.Policy{Burst: 1, Rate: 3, Period: time.Hour})
@@ -104,6 +184,24 @@ func (rl *RateLimit) MagicLinkDisable() func(http.Handler) http.Handler {
 	return rl.byUserID("2fa_magiclink_disable", rateLimit.Policy{Burst: 3, Rate: 10, Period: time.Hour})
 }
 
+// MeTwoFA — изменение настроек 2FA: смена канала шлёт проверочное SMS или
+// сообщение в Telegram, выключение принимает пароль.
+func (rl *RateLimit) MeTwoFA() func(http.Handler) http.Handler {
+	return rl.byUserID("me_2fa", rateLimit.Policy{Burst: 3, Rate: 5, Period: time.Hour})
+}
+
+func (rl *RateLimit) TOTPVerify() func(http.Handler) http.Handler {
+	ip := rl.byIP("2fa_totp_verify", rateLimit.Policy{Burst: 10, Rate: 30, Period: time.Minute})
+	session := rl.bySessionID("2fa_totp_verify", rateLimit.Policy{Burst: 5, Rate: 5, Period: 10 * time.Minute})
+	return chain(sessionIDParser.New, ip, session)
+}
+
+// TOTPManage — enroll/confirm/disable; confirm и disable принимают код,
+// поэтому лимит жёстче, чем у magic-link enable.
+func (rl *RateLimit) TOTPManage() func(http.Handler) http.Handler {
+	return rl.byUserID("2fa_totp_manage", rateLimit.Policy{Burst: 5, Rate: 10, Period: time.Hour})
+}
+
 func (rl *RateLimit) Disable2FARequestConfirmation() func(http.Handler) http.Handler {
 	return rl.byUserID("2fa_disable_request_confirmation", rateLimit.Policy{Burst: 3, Rate: 10, Period: time.Hour})
 }
@@ -128,15 +226,35 @@ func (rl *RateLimit) AccountRestore() func(http.Handler) http.Handler {
 	return chain(emailParser.New, ip, email)
 }
 
+func (rl *RateLimit) EmailChange() func(http.Handler) http.Handler {
+	return rl.byUserID("email_change", rateLimit.Policy{Burst: 2, Rate: 5, Period: time.Hour})
+}
+
+func (rl *RateLimit) EmailChangeConfirm() func(http.Handler) http.Handler {
+	return rl.byIP("email_change_confirm", rateLimit.Policy{Burst: 10, Rate: 30, Period: time.Minute})
+}
+
+func (rl *RateLimit) OrgInvite() func(http.Handler) http.Handler {
+	return rl.byUserID("org_invite", rateLimit.Policy{Burst: 10, Rate: 50, Period: time.Hour})
+}
+
+func (rl *RateLimit) OrgInviteAccept() func(http.Handler) http.Handler {
+	return rl.byUserID("org_invite_accept", rateLimit.Policy{Burst: 5, Rate: 20, Period: time.Hour})
+}
+
+func (rl *RateLimit) OrgInviteRespond() func(http.Handler) http.Handler {
+	return rl.byIP("org_invite_respond", rateLimit.Policy{Burst: 5, Rate: 20, Period: time.Hour})
+}
+
 func (rl *RateLimit) byIP(endpoint string, policy rateLimit.Policy) func(http.Handler) http.Handler {
 	return rl.build(endpoint, policy, func(r *http.Request) (string, string) {
-		return "ip", stripPort(r.RemoteAddr) // RealIP уже подменил RemoteAddr выше по цепочке
+		return "ip", stripPort(r.RemoteAddr) // realIP уже подменил RemoteAddr выше по цепочке
 	}, FailClosed)
 }
 
 func (rl *RateLimit) byEmail(endpoint string, policy rateLimit.Policy) func(http.Handler) http.Handler {
 	return rl.build(endpoint, policy, func(r *http.Request) (string, string) {
-		return "email", emailParser.FromContext(r.Context())
+		return "email", normalizeEmail(emailParser.FromContext(r.Context()))
 	}, FailClosed)
 }
 
@@ -167,7 +285,7 @@ func (rl *RateLimit) build(
 			keyType, identifier := keyFunc(r)
 			key := rateLimit.BuildKey(endpoint, keyType, identifier)
 
-			decision, err := rl.limiter.Allow(r.Context(), key, policy)
+			decision, err := rl.limiter.Allow(r.Context(), key, rl.policy(endpoint, keyType, policy))
 			if err != nil {
 				if errors.Is(err, rateLimit.ErrRedisUnavailable) {
 					rl.log.Error("rate limiter redis unavailable",
@@ -177,22 +295,23 @@ func (rl *RateLimit) build(
 						slog.Any("error", err),
 					)
 					if onFail == FailClosed {
-						w.WriteHeader(http.StatusServiceUnavailable)
-						_, _ = w.Write([]byte(`{"status":"error","error":"service temporarily unavailable"}`))
+						render.Status(r, http.StatusServiceUnavailable)
+						render.JSON(w, r, resp.Error(resp.CodeServiceUnavailable, "service temporarily unavailable"))
 						return
 					}
 					next.ServeHTTP(w, r)
 					return
 				}
 				rl.log.Error("rate limiter internal error", slog.Any("error", err))
-				w.WriteHeader(http.StatusInternalServerError)
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error(resp.CodeInternal, "internal error"))
 				return
 			}
 
 			if !decision.Allowed {
 				w.Header().Set("Retry-After", strconv.Itoa(int(decision.RetryAfter/time.Second)+1))
-				w.WriteHeader(http.StatusTooManyRequests)
-				_, _ = w.Write([]byte(`{"status":"error","error":"rate limit exceeded"}`))
+				render.Status(r, http.StatusTooManyRequests)
+				render.JSON(w, r, resp.Error(resp.CodeRateLimited, "rate limit exceeded"))
 				return
 			}
 
@@ -202,6 +321,14 @@ func (rl *RateLimit) build(
 	}
 }
 
+// policy возвращает лимит из конфига, если он задан, иначе встроенный.
+func (rl *RateLimit) policy(endpoint, keyType string, def rateLimit.Policy) rateLimit.Policy {
+	if override, ok := (*rl.overrides.Load())[endpoint+":"+keyType]; ok {
+		return override
+	}
+	return def
+}
+
 // chain объединяет несколько middleware в один — chi.With() принимает
 // только один middleware на вызов в вашем стиле (r.With(rateLimit.Login())),
 // поэтому композиция должна произойти здесь, а не в роутере.
@@ -223,6 +350,12 @@ func stripPort(addr string) string {
 	return host
 }
 
+// normalizeEmail приводит адрес к виду, в котором его сравнивает БД
+// (users.email — CITEXT): иначе смена регистра даёт новый счётчик.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
 func failModeString(m FailMode) string {
 	if m == FailClosed {
 		return "fail_closed"
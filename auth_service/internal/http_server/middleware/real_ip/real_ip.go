@@ -0,0 +1,117 @@
+package realIP
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// New подменяет RemoteAddr адресом клиента — дальше по цепочке его берут
+// rate limiter, журнал аудита и учёт устройств. X-Forwarded-For и
+// X-Real-IP учитываются, только если соединение пришло от доверенного
+// прокси: иначе клиент подставил бы в них любой адрес и обошёл лимиты.
+//
+// X-Forwarded-For читается справа налево: клиент — первый адрес, не
+// входящий в trusted. Так цепочка из нескольких своих прокси (LB → ingress)
+// не выдаёт себя за клиента, а подделанное клиентом начало списка
+// игнорируется.
+func New(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	isTrusted := func(addr netip.Addr) bool {
+		for _, p := range trusted {
+			if p.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer, ok := parseAddr(r.RemoteAddr)
+			if !ok || !isTrusted(peer) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			client := peer
+
+			if hops := forwardedFor(r); len(hops) > 0 {
+				for i := len(hops) - 1; i >= 0; i-- {
+					addr, ok := parseAddr(hops[i])
+					if !ok {
+						break
+					}
+					client = addr
+					if !isTrusted(addr) {
+						break
+					}
+				}
+			} else if addr, ok := parseAddr(r.Header.Get("X-Real-IP")); ok {
+				client = addr
+			}
+
+			r.RemoteAddr = client.String()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ParseTrusted разбирает список сетей доверенных прокси. Одиночный адрес —
+// сеть из одного адреса.
+func ParseTrusted(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+
+	for _, s := range cidrs {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy %q: %w", s, err)
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", s, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+// forwardedFor собирает адреса из всех заголовков X-Forwarded-For по
+// порядку: прокси может как дописать адрес в строку, так и добавить
+// отдельный заголовок.
+func forwardedFor(r *http.Request) []string {
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(header, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	return hops
+}
+
+// parseAddr принимает адрес с портом и без.
+func parseAddr(s string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
@@ -0,0 +1,74 @@
+package requestLogger
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"auth_service/internal/lib/redact"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// New пишет по строке на запрос через общий slog-логгер сервиса, вместо
+// middleware.Logger с его собственным текстовым форматом. Тела и заголовки
+// не логируются, значения секретных query-параметров маскируются.
+func New(log *slog.Logger) func(http.Handler) http.Handler {
+	log = log.With(slog.String("component", "http"))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+
+				attrs := []slog.Attr{
+					slog.String("method", r.Method),
+					slog.String("pattern", routePattern(r)),
+					slog.String("path", r.URL.Path),
+					slog.Int("status", status),
+					slog.Int("bytes", ww.BytesWritten()),
+					slog.Duration("duration", time.Since(start)),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+					slog.String("remote_addr", r.RemoteAddr),
+					slog.String("user_agent", r.UserAgent()),
+				}
+				if q := redact.Query(r.URL.Query()); q != "" {
+					attrs = append(attrs, slog.String("query", q))
+				}
+
+				log.LogAttrs(r.Context(), levelFor(status), "request completed", attrs...)
+			}()
+
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
+
+func levelFor(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// routePattern — как в metricsCollector: шаблон роута chi вместо сырого
+// пути, чтобы запросы одного эндпоинта группировались в логах.
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.RoutePattern() == "" {
+		return "unmatched"
+	}
+	return rctx.RoutePattern()
+}
@@ -3,6 +3,10 @@ package swaggerAuth
 import (
 	"crypto/subtle"
 	"net/http"
+
+	resp "auth_service/internal/lib/api/response"
+
+	"github.com/go-chi/render"
 )
 
 // * middleware для защиты Swagger
@@ -11,7 +15,8 @@ func New(username, password string) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Если credentials пустые, Swagger недоступен
 			if username == "" || password == "" {
-				http.Error(w, "Not Found", http.StatusNotFound)
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error(resp.CodeNotFound, "not found"))
 				return
 			}
 
@@ -22,8 +27,8 @@ func New(username, password string) func(http.Handler) http.Handler {
 
 			if !ok || !usernameMatch || !passwordMatch {
 				w.Header().Set("WWW-Authenticate", `Basic realm="Swagger Documentation"`)
-				w.WriteHeader(http.StatusUnauthorized)
-				_, _ = w.Write([]byte("Unauthorized"))
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, resp.Error(resp.CodeInvalidCredentials, "invalid credentials"))
 
 				return
 			}
@@ -0,0 +1,35 @@
+package traceContext
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "auth_service/http"
+
+// New достаёт W3C traceparent/tracestate из входящего запроса и открывает
+// server-span, дочерний к нему. Дальше контекст запроса уезжает в хендлер,
+// а оттуда — в заголовки AMQP при публикации письма, так что трейс
+// register → email_sender остаётся единым.
+//
+// Пока глобальный TracerProvider не сконфигурирован, span no-op, но
+// родительский span context всё равно протягивается насквозь.
+func New() func(http.Handler) http.Handler {
+	tracer := otel.Tracer(tracerName)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			ctx, span := tracer.Start(ctx, r.Method+" "+r.URL.Path,
+				trace.WithSpanKind(trace.SpanKindServer),
+			)
+			defer span.End()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package ipfilter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"sync"
+	"time"
+
+	"auth_service/internal/config"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+var (
+	ErrNotFound      = errors.New("ip rule not found")
+	ErrRuleExists    = errors.New("ip rule for this network already exists")
+	ErrInvalidAction = errors.New("ip rule action must be allow or deny")
+	ErrInvalidExpiry = errors.New("ip rule must expire in the future")
+)
+
+// autoBlockActor — created_by правил, поставленных детектором.
+const autoBlockActor = "system:abuse"
+
+// Repo хранит правила. Источник истины — Postgres.
+type Repo interface {
+	SaveIPRule(ctx context.Context, rule *models.IPRule) error
+	BlockIPTemporarily(ctx context.Context, prefix netip.Prefix, reason, createdBy string, expiresAt time.Time) (bool, error)
+	ActiveIPRules(ctx context.Context) ([]models.IPRule, error)
+	DeleteIPRule(ctx context.Context, id int64) error
+}
+
+// Cache — общий для реплик кеш правил и счётчики злоупотреблений.
+type Cache interface {
+	CachedIPRules(ctx context.Context) ([]models.IPRule, bool, error)
+	CacheIPRules(ctx context.Context, rules []models.IPRule, ttl time.Duration) error
+	InvalidateIPRules(ctx context.Context) error
+	IncrIPAbuse(ctx context.Context, ip string, window time.Duration) (int64, error)
+}
+
+// Filter решает, пускать ли запрос с адреса. Самое узкое совпавшее правило
+// выигрывает; allow освобождает сеть от блокировок. Правила читаются из
+// памяти реплики, затем из Redis, затем из Postgres. Недоступность
+// хранилищ никого не блокирует: остаются последние известные правила.
+type Filter struct {
+	log   *slog.Logger
+	repo  Repo
+	cache Cache
+	cfg   config.IPFilter
+
+	mu       sync.Mutex
+	rules    []models.IPRule
+	loadedAt time.Time
+}
+
+func New(log *slog.Logger, repo Repo, cache Cache, cfg config.IPFilter) *Filter {
+	return &Filter{
+		log:   log,
+		repo:  repo,
+		cache: cache,
+		cfg:   cfg,
+	}
+}
+
+// * Blocked проверяет адрес клиента. Нераспознанный адрес не блокируется.
+func (f *Filter) Blocked(ctx context.Context, ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	rule, ok := match(f.activeRules(ctx), addr.Unmap(), time.Now())
+
+	return ok && rule.Action == models.IPRuleDeny
+}
+
+// * List возвращает действующие правила, самые узкие сети первыми.
+func (f *Filter) List(ctx context.Context) ([]models.IPRule, error) {
+	const op = "ipfilter.List"
+
+	rules, err := f.repo.ActiveIPRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rules, nil
+}
+
+// * Create добавляет правило. Адрес хоста в prefix обнуляется: 10.0.0.5/8
+// сохраняется как 10.0.0.0/8.
+func (f *Filter) Create(ctx context.Context, rule *models.IPRule) error {
+	const op = "ipfilter.Create"
+
+	if rule.Action != models.IPRuleAllow && rule.Action != models.IPRuleDeny {
+		return ErrInvalidAction
+	}
+	if rule.ExpiresAt != nil && !rule.ExpiresAt.After(time.Now()) {
+		return ErrInvalidExpiry
+	}
+
+	rule.Prefix = rule.Prefix.Masked()
+
+	if err := f.repo.SaveIPRule(ctx, rule); err != nil {
+		if errors.Is(err, storage.ErrIPRuleExists) {
+			return ErrRuleExists
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	f.invalidate(ctx)
+
+	return nil
+}
+
+// * Delete удаляет правило, в том числе временную блокировку детектора.
+func (f *Filter) Delete(ctx context.Context, id int64) error {
+	const op = "ipfilter.Delete"
+
+	if err := f.repo.DeleteIPRule(ctx, id); err != nil {
+		if errors.Is(err, storage.ErrIPRuleNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	f.invalidate(ctx)
+
+	return nil
+}
+
+// * RegisterAbuse учитывает подозрительный ответ адресу (401, 429). На
+// cfg.AutoBlock.Threshold-м за окно адрес блокируется на
+// cfg.AutoBlock.Duration.
+func (f *Filter) RegisterAbuse(ctx context.Context, ip string) {
+	const op = "ipfilter.RegisterAbuse"
+
+	if !f.cfg.AutoBlock.Enabled {
+		return
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return
+	}
+	addr = addr.Unmap()
+
+	log := f.log.With(slog.String("op", op), slog.String("ip", addr.String()))
+
+	count, err := f.cache.IncrIPAbuse(ctx, addr.String(), f.cfg.AutoBlock.Window)
+	if err != nil {
+		log.Error("failed to count ip abuse", sl.Err(err))
+		return
+	}
+
+	if count != f.cfg.AutoBlock.Threshold {
+		return
+	}
+
+	// allow-правило — доверенная сеть (офис, NAT партнёра): не блокируем
+	if rule, ok := match(f.activeRules(ctx), addr, time.Now()); ok && rule.Action == models.IPRuleAllow {
+		return
+	}
+
+	prefix := netip.PrefixFrom(addr, addr.BitLen())
+	reason := fmt.Sprintf("%d failed or rate-limited requests in %s", count, f.cfg.AutoBlock.Window)
+
+	blocked, err := f.repo.BlockIPTemporarily(ctx, prefix, reason, autoBlockActor, time.Now().Add(f.cfg.AutoBlock.Duration))
+	if err != nil {
+		log.Error("failed to block ip", sl.Err(err))
+		return
+	}
+	if !blocked {
+		return
+	}
+
+	log.Warn("ip blocked temporarily", slog.Int64("events", count), slog.Duration("duration", f.cfg.AutoBlock.Duration))
+
+	f.invalidate(ctx)
+}
+
+func (f *Filter) activeRules(ctx context.Context) []models.IPRule {
+	const op = "ipfilter.activeRules"
+
+	f.mu.Lock()
+	if !f.loadedAt.IsZero() && time.Since(f.loadedAt) < f.cfg.LocalCacheTTL {
+		rules := f.rules
+		f.mu.Unlock()
+		return rules
+	}
+	f.mu.Unlock()
+
+	rules, err := f.load(ctx)
+	if err != nil {
+		f.log.Error("failed to load ip rules", slog.String("op", op), sl.Err(err))
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.rules
+	}
+
+	f.mu.Lock()
+	f.rules = rules
+	f.loadedAt = time.Now()
+	f.mu.Unlock()
+
+	return rules
+}
+
+func (f *Filter) load(ctx context.Context) ([]models.IPRule, error) {
+	rules, ok, err := f.cache.CachedIPRules(ctx)
+	if err != nil {
+		f.log.Warn("failed to read ip rules cache", sl.Err(err))
+	}
+	if ok {
+		return rules, nil
+	}
+
+	rules, err = f.repo.ActiveIPRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.cache.CacheIPRules(ctx, rules, f.cfg.CacheTTL); err != nil {
+		f.log.Warn("failed to cache ip rules", sl.Err(err))
+	}
+
+	return rules, nil
+}
+
+// invalidate сбрасывает кеш в Redis и в памяти этой реплики; остальные
+// реплики увидят изменение через LocalCacheTTL.
+func (f *Filter) invalidate(ctx context.Context) {
+	if err := f.cache.InvalidateIPRules(ctx); err != nil {
+		f.log.Error("failed to invalidate ip rules cache", sl.Err(err))
+	}
+
+	f.mu.Lock()
+	f.loadedAt = time.Time{}
+	f.mu.Unlock()
+}
+
+// match ищет самое узкое действующее правило для адреса. Правила из
+// хранилища уже отсортированы по длине маски, но кеш мог пережить срок
+// временного правила — он проверяется здесь.
+func match(rules []models.IPRule, addr netip.Addr, now time.Time) (models.IPRule, bool) {
+	var (
+		best  models.IPRule
+		found bool
+	)
+
+	for _, rule := range rules {
+		if rule.ExpiresAt != nil && !now.Before(*rule.ExpiresAt) {
+			continue
+		}
+		if !rule.Prefix.Contains(addr) {
+			continue
+		}
+		if !found || rule.Prefix.Bits() > best.Prefix.Bits() {
+			best, found = rule, true
+		}
+	}
+
+	return best, found
+}
@@ -0,0 +1,112 @@
+package response
+
+// Code — машиночитаемый код ошибки в поле code ответа. Клиенты ветвятся по
+// нему, а не по тексту error: текст может меняться, коды — только
+// добавляться.
+type Code string
+
+// Запрос.
+const (
+	CodeMalformedRequest  Code = "REQUEST_MALFORMED"
+	CodeValidationFailed  Code = "REQUEST_VALIDATION_FAILED"
+	CodeInvalidParameter  Code = "REQUEST_INVALID_PARAMETER"
+	CodeRateLimited       Code = "REQUEST_RATE_LIMITED"
+	CodeChallengeRequired Code = "REQUEST_CHALLENGE_REQUIRED"
+	CodeIPBlocked         Code = "REQUEST_IP_BLOCKED"
+	CodeNotFound          Code = "REQUEST_NOT_FOUND"
+)
+
+// Сервис.
+const (
+	CodeInternal           Code = "INTERNAL_ERROR"
+	CodeServiceUnavailable Code = "SERVICE_UNAVAILABLE"
+	CodeMaintenance        Code = "SERVICE_MAINTENANCE"
+	CodeNotImplemented     Code = "SERVICE_NOT_IMPLEMENTED"
+)
+
+// Аутентификация.
+const (
+	CodeInvalidCredentials      Code = "AUTH_INVALID_CREDENTIALS"
+	CodeInvalidAccessToken      Code = "AUTH_INVALID_ACCESS_TOKEN"
+	CodeInvalidToken            Code = "AUTH_INVALID_TOKEN"
+	CodeSessionExpired          Code = "AUTH_SESSION_EXPIRED"
+	CodeEmailNotVerified        Code = "AUTH_EMAIL_NOT_VERIFIED"
+	CodePasswordResetRequired   Code = "AUTH_PASSWORD_RESET_REQUIRED"
+	CodeAccountLocked           Code = "AUTH_ACCOUNT_LOCKED"
+	CodeSuspiciousLogin         Code = "AUTH_SUSPICIOUS_LOGIN"
+	CodeInvalidApp              Code = "AUTH_INVALID_APP"
+	CodeInvalidScope            Code = "AUTH_INVALID_SCOPE"
+	CodeNotAppMember            Code = "AUTH_NOT_APP_MEMBER"
+	CodeInvalidClient           Code = "AUTH_INVALID_CLIENT"
+	CodeInvalidConfirmation     Code = "AUTH_INVALID_CONFIRMATION"
+	CodeCSRFMismatch            Code = "AUTH_CSRF_MISMATCH"
+	CodeInvalidAPIKey           Code = "AUTH_INVALID_API_KEY"
+	CodeInvalidVerificationCode Code = "AUTH_INVALID_VERIFICATION_CODE"
+	CodeSessionLimit            Code = "AUTH_SESSION_LIMIT_REACHED"
+)
+
+// Второй фактор.
+const (
+	CodeTwoFAAlreadyEnabled       Code = "TWO_FACTOR_ALREADY_ENABLED"
+	CodeTwoFANotEnabled           Code = "TWO_FACTOR_NOT_ENABLED"
+	CodeTwoFAMethodMismatch       Code = "TWO_FACTOR_METHOD_MISMATCH"
+	CodeTwoFANoFactor             Code = "TWO_FACTOR_NO_FACTOR_AVAILABLE"
+	CodeInvalidCode               Code = "TWO_FACTOR_INVALID_CODE"
+	CodeTOTPNotEnrolled           Code = "TWO_FACTOR_TOTP_NOT_ENROLLED"
+	CodeTwoFAChannelUnavailable   Code = "TWO_FACTOR_CHANNEL_UNAVAILABLE"
+	CodeTwoFAChannelUndeliverable Code = "TWO_FACTOR_CHANNEL_UNDELIVERABLE"
+	CodeTrustedDeviceNotFound     Code = "TWO_FACTOR_TRUSTED_DEVICE_NOT_FOUND"
+)
+
+// Аккаунт.
+const (
+	CodeUserNotFound         Code = "ACCOUNT_NOT_FOUND"
+	CodeUserAlreadyExists    Code = "ACCOUNT_ALREADY_EXISTS"
+	CodeAccountDeleted       Code = "ACCOUNT_DELETED"
+	CodeAccountDisabled      Code = "ACCOUNT_DISABLED"
+	CodeAccountSuspended     Code = "ACCOUNT_SUSPENDED"
+	CodeAccountBanned        Code = "ACCOUNT_BANNED"
+	CodeInvalidStatusChange  Code = "ACCOUNT_INVALID_STATUS_TRANSITION"
+	CodeEmailTaken           Code = "ACCOUNT_EMAIL_TAKEN"
+	CodeUsernameTaken        Code = "ACCOUNT_USERNAME_TAKEN"
+	CodeSameEmail            Code = "ACCOUNT_SAME_EMAIL"
+	CodeEmailAlreadyVerified Code = "ACCOUNT_EMAIL_ALREADY_VERIFIED"
+	CodeSamePassword         Code = "ACCOUNT_SAME_PASSWORD"
+	CodeWeakPassword         Code = "ACCOUNT_WEAK_PASSWORD"
+)
+
+// Внешние учётки.
+const (
+	CodeUnknownProvider         Code = "IDENTITY_UNKNOWN_PROVIDER"
+	CodeIdentityNotFound        Code = "IDENTITY_NOT_FOUND"
+	CodeIdentityConflict        Code = "IDENTITY_CONFLICT"
+	CodeLastAuthMethod          Code = "IDENTITY_LAST_AUTH_METHOD"
+	CodeInvalidOAuthState       Code = "IDENTITY_INVALID_STATE"
+	CodeProviderEmailUnverified Code = "IDENTITY_EMAIL_NOT_VERIFIED"
+	CodeAccessDenied            Code = "IDENTITY_ACCESS_DENIED"
+	CodeInvalidRedirectURI      Code = "IDENTITY_INVALID_REDIRECT_URI"
+)
+
+// Организации и роли.
+const (
+	CodeOrgNotFound          Code = "ORG_NOT_FOUND"
+	CodeOrgMemberNotFound    Code = "ORG_MEMBER_NOT_FOUND"
+	CodeNotOrgMember         Code = "ORG_NOT_MEMBER"
+	CodeOrgForbidden         Code = "ORG_FORBIDDEN"
+	CodeOrgAlreadyMember     Code = "ORG_ALREADY_MEMBER"
+	CodeOrgLastOwner         Code = "ORG_LAST_OWNER"
+	CodeInvalidInvitation    Code = "ORG_INVALID_INVITATION"
+	CodeInviteeAccountExists Code = "ORG_INVITEE_ACCOUNT_EXISTS"
+	CodeInvalidRole          Code = "ROLE_INVALID"
+	CodeRoleNotFound         Code = "ROLE_NOT_FOUND"
+	CodeRoleAlreadyExists    Code = "ROLE_ALREADY_EXISTS"
+)
+
+// Администрирование.
+const (
+	CodeAppNotFound        Code = "APP_NOT_FOUND"
+	CodeSigningKeyConflict Code = "SIGNING_KEY_CONFLICT"
+	CodeAPIKeyNotFound     Code = "API_KEY_NOT_FOUND"
+	CodeIPRuleNotFound     Code = "IP_RULE_NOT_FOUND"
+	CodeIPRuleExists       Code = "IP_RULE_ALREADY_EXISTS"
+)
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 
+	"auth_service/internal/lib/passwordpolicy"
+
 	"github.com/go-playground/validator/v10"
 )
 
@@ -14,6 +16,7 @@ const (
 
 type Response struct {
 	Status string `json:"status" example:"ok"`
+	Code   Code   `json:"code,omitempty" example:"AUTH_INVALID_CREDENTIALS"`
 	Error  string `json:"error,omitempty" example:"error"`
 }
 
@@ -23,9 +26,12 @@ func OK() Response {
 	}
 }
 
-func Error(msg string) Response {
+// Error — ответ с ошибкой. code — из каталога codes.go, msg — пояснение
+// для человека.
+func Error(code Code, msg string) Response {
 	return Response{
 		Status: StatusError,
+		Code:   code,
 		Error:  msg,
 	}
 }
@@ -44,6 +50,21 @@ func ValidationError(errs validator.ValidationErrors) Response {
 
 	return Response{
 		Status: StatusError,
+		Code:   CodeValidationFailed,
 		Error:  strings.Join(errMsgs, ", "),
 	}
 }
+
+// PasswordPolicyResponse — пароль не прошёл политику. Violations — все
+// нарушенные правила, чтобы клиент показал их разом.
+type PasswordPolicyResponse struct {
+	Response
+	Violations []passwordpolicy.Violation `json:"violations"`
+}
+
+func PasswordPolicyError(err *passwordpolicy.Error) PasswordPolicyResponse {
+	return PasswordPolicyResponse{
+		Response:   Error(CodeWeakPassword, "password does not satisfy policy"),
+		Violations: err.Violations,
+	}
+}
@@ -0,0 +1,49 @@
+package background
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Tasks — работа, которую обработчик запускает после ответа клиенту
+// (например, письмо сброса пароля). Запрос её не ждёт, но при остановке
+// сервиса её нужно дождаться: иначе письмо теряется вместе с процессом,
+// а Postgres и RabbitMQ закрываются под ещё работающей задачей.
+type Tasks struct {
+	wg sync.WaitGroup
+}
+
+func New() *Tasks {
+	return &Tasks{}
+}
+
+// Go запускает fn с контекстом, который не отменяется вместе с запросом
+// ctx, но ограничен timeout. Значения ctx (request_id, trace) сохраняются.
+func (t *Tasks) Go(ctx context.Context, timeout time.Duration, fn func(ctx context.Context)) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+
+	t.wg.Go(func() {
+		defer cancel()
+		fn(ctx)
+	})
+}
+
+// Wait ждёт завершения всех запущенных задач, но не дольше ctx.
+func (t *Tasks) Wait(ctx context.Context) error {
+	const op = "background.Wait"
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	}
+}
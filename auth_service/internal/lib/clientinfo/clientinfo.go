@@ -0,0 +1,27 @@
+package clientinfo
+
+import "context"
+
+type contextKey struct{}
+
+// Info — откуда пришёл запрос. Кладётся в контекст HTTP-middleware, чтобы
+// сервисный слой мог записать IP и User-Agent в журнал, не принимая их
+// параметрами каждого метода.
+type Info struct {
+	IP        string
+	UserAgent string
+	// Locale — предпочитаемый язык из Accept-Language ("en", "pt-BR"),
+	// пусто — не указан. По нему выбирается перевод писем.
+	Locale string
+}
+
+func WithInfo(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// FromContext возвращает пустой Info, если запрос пришёл не по HTTP
+// (фоновые задачи).
+func FromContext(ctx context.Context) Info {
+	info, _ := ctx.Value(contextKey{}).(Info)
+	return info
+}
@@ -0,0 +1,236 @@
+package cookie
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"auth_service/internal/config"
+	"auth_service/internal/lib/tokens"
+	"auth_service/internal/storage"
+)
+
+// CSRFHeader — заголовок, в котором фронтенд повторяет CSRF-токен.
+const CSRFHeader = "X-CSRF-Token"
+
+// DeviceTrustHeader — заголовок, в котором клиент без cookie присылает
+// токен доверенного устройства на /auth/login.
+const DeviceTrustHeader = "X-Device-Trust"
+
+var (
+	ErrNoRefreshCookie = errors.New("refresh token cookie is missing")
+	ErrCSRFMismatch    = errors.New("csrf token is missing or does not match")
+)
+
+// CSRFStore хранит хеш CSRF-токена на сессию. Сессия — id refresh-токена:
+// он не меняется при ротации.
+type CSRFStore interface {
+	SaveCSRFToken(ctx context.Context, sessionID string, tokenHash []byte, ttl time.Duration) error
+	CSRFTokenHash(ctx context.Context, sessionID string) ([]byte, error)
+	DeleteCSRFToken(ctx context.Context, sessionID string) error
+}
+
+// Jar ставит и читает refresh-cookie. Refresh-токен лежит в HttpOnly
+// cookie и недоступен JavaScript; CSRF-токен сессии отдаётся в теле ответа
+// и в читаемой cookie, фронтенд повторяет его в заголовке CSRFHeader. Чужой
+// сайт может заставить браузер отправить cookie, но не может прочитать
+// токен, поэтому запрос без совпадающего заголовка считается поддельным.
+type Jar struct {
+	cfg   config.RefreshCookie
+	store CSRFStore
+}
+
+func New(cfg config.RefreshCookie, store CSRFStore) *Jar {
+	return &Jar{cfg: cfg, store: store}
+}
+
+// Set ставит refresh-cookie и выпускает для сессии новый CSRF-токен; обе
+// живут до expiresAt — срока refresh-токена, он у приложений разный.
+// Возвращает CSRF-токен — его отдают и в теле ответа: фронтенд на другом
+// поддомене cookie API не прочитает.
+func (j *Jar) Set(
+	ctx context.Context,
+	w http.ResponseWriter,
+	refreshToken string,
+	expiresAt time.Time,
+) (csrfToken string, err error) {
+	const op = "cookie.Set"
+
+	csrfToken, hash, err := tokens.NewCSRFToken()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	ttl := time.Until(expiresAt)
+	if err := j.store.SaveCSRFToken(ctx, sessionID(refreshToken), hash, ttl); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	maxAge := int(ttl.Seconds())
+
+	http.SetCookie(w, j.refreshCookie(refreshToken, maxAge))
+	http.SetCookie(w, j.csrfCookie(csrfToken, maxAge))
+
+	return csrfToken, nil
+}
+
+// Deliver отдаёт refresh-токен так, как его принимает клиент: asCookie —
+// в cookie, тогда bodyToken пустой; иначе — в теле ответа, как раньше.
+func (j *Jar) Deliver(
+	ctx context.Context,
+	w http.ResponseWriter,
+	refreshToken string,
+	expiresAt time.Time,
+	asCookie bool,
+) (bodyToken, csrfToken string, err error) {
+	if !asCookie {
+		return refreshToken, "", nil
+	}
+
+	csrfToken, err = j.Set(ctx, w, refreshToken, expiresAt)
+	if err != nil {
+		return "", "", err
+	}
+
+	return "", csrfToken, nil
+}
+
+// Clear удаляет обе cookie и CSRF-токен сессии — после logout или
+// отклонённого refresh. Cookie удаляются и при ошибке хранилища.
+func (j *Jar) Clear(ctx context.Context, w http.ResponseWriter, refreshToken string) error {
+	const op = "cookie.Clear"
+
+	http.SetCookie(w, j.refreshCookie("", -1))
+	http.SetCookie(w, j.csrfCookie("", -1))
+
+	if err := j.store.DeleteCSRFToken(ctx, sessionID(refreshToken)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Read возвращает refresh-токен из cookie. CSRF проверяет Verify — его
+// вызывает middleware до обработчика.
+func (j *Jar) Read(r *http.Request) (string, error) {
+	refresh, err := r.Cookie(j.cfg.Name)
+	if err != nil || refresh.Value == "" {
+		return "", ErrNoRefreshCookie
+	}
+
+	return refresh.Value, nil
+}
+
+// Verify сверяет заголовок CSRFHeader с CSRF-токеном сессии из
+// refresh-cookie. Без refresh-cookie — ErrNoRefreshCookie.
+func (j *Jar) Verify(r *http.Request) error {
+	const op = "cookie.Verify"
+
+	refreshToken, err := j.Read(r)
+	if err != nil {
+		return err
+	}
+
+	header := r.Header.Get(CSRFHeader)
+	if header == "" {
+		return ErrCSRFMismatch
+	}
+
+	stored, err := j.store.CSRFTokenHash(r.Context(), sessionID(refreshToken))
+	if err != nil {
+		if errors.Is(err, storage.ErrCSRFTokenNotFound) {
+			return ErrCSRFMismatch
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if subtle.ConstantTimeCompare(tokens.HashCSRFToken(header), stored) != 1 {
+		return ErrCSRFMismatch
+	}
+
+	return nil
+}
+
+// DeliverDeviceTrust отдаёт токен доверенного устройства так же, как
+// refresh-токен: asCookie — в HttpOnly cookie до expiresAt, тогда bodyToken
+// пустой; иначе — в теле ответа.
+func (j *Jar) DeliverDeviceTrust(w http.ResponseWriter, token string, expiresAt time.Time, asCookie bool) (bodyToken string) {
+	if token == "" || !asCookie {
+		return token
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     j.cfg.DeviceTrustName,
+		Value:    token,
+		Path:     j.cfg.Path,
+		Domain:   j.cfg.Domain,
+		MaxAge:   int(time.Until(expiresAt).Seconds()),
+		Secure:   j.cfg.Secure,
+		HttpOnly: true,
+		SameSite: sameSite(j.cfg.SameSite),
+	})
+
+	return ""
+}
+
+// DeviceTrust возвращает токен доверенного устройства из заголовка
+// DeviceTrustHeader или из cookie. Пусто — клиент его не прислал.
+func (j *Jar) DeviceTrust(r *http.Request) string {
+	if token := r.Header.Get(DeviceTrustHeader); token != "" {
+		return token
+	}
+
+	if c, err := r.Cookie(j.cfg.DeviceTrustName); err == nil {
+		return c.Value
+	}
+
+	return ""
+}
+
+func (j *Jar) refreshCookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     j.cfg.Name,
+		Value:    value,
+		Path:     j.cfg.Path,
+		Domain:   j.cfg.Domain,
+		MaxAge:   maxAge,
+		Secure:   j.cfg.Secure,
+		HttpOnly: true,
+		SameSite: sameSite(j.cfg.SameSite),
+	}
+}
+
+// csrfCookie видна на всём сайте: её читает JavaScript любой страницы.
+func (j *Jar) csrfCookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     j.cfg.CSRFName,
+		Value:    value,
+		Path:     "/",
+		Domain:   j.cfg.Domain,
+		MaxAge:   maxAge,
+		Secure:   j.cfg.Secure,
+		SameSite: sameSite(j.cfg.SameSite),
+	}
+}
+
+// sessionID — id refresh-токена (часть до точки).
+func sessionID(refreshToken string) string {
+	id, _, _ := strings.Cut(refreshToken, ".")
+	return id
+}
+
+func sameSite(mode string) http.SameSite {
+	switch mode {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteStrictMode
+	}
+}
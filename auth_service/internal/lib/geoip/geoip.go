@@ -0,0 +1,64 @@
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"auth_service/internal/models"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// ErrUnknownLocation — IP нет в базе или у записи нет координат
+// (приватные сети, anycast, часть мобильных операторов).
+var ErrUnknownLocation = errors.New("ip location unknown")
+
+// Reader ищет IP в базе MaxMind City (GeoLite2 или GeoIP2). База читается
+// с диска один раз при открытии; для обновления сервис перезапускается.
+type Reader struct {
+	db *geoip2.Reader
+}
+
+func Open(path string) (*Reader, error) {
+	const op = "geoip.Open"
+
+	db, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Reader{db: db}, nil
+}
+
+// Lookup возвращает местоположение IP. SeenAt не заполняется.
+func (r *Reader) Lookup(ip string) (*models.GeoLocation, error) {
+	const op = "geoip.Lookup"
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil, ErrUnknownLocation
+	}
+
+	record, err := r.db.City(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// у записей без координат Location остаётся нулевым
+	if record.Location.Latitude == 0 && record.Location.Longitude == 0 {
+		return nil, ErrUnknownLocation
+	}
+
+	return &models.GeoLocation{
+		IP:        ip,
+		Country:   record.Country.IsoCode,
+		City:      record.City.Names["en"],
+		Latitude:  record.Location.Latitude,
+		Longitude: record.Location.Longitude,
+	}, nil
+}
+
+func (r *Reader) Close() error {
+	return r.db.Close()
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"auth_service/internal/models"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 var (
@@ -17,8 +20,11 @@ var (
 	ErrAppNotFound  = errors.New("app not found")
 )
 
-type AppSecretProvider interface {
+// KeyProvider отдаёт ключи проверки подписи: секрет приложения для HS256
+// без kid и ключ по kid для остальных токенов.
+type KeyProvider interface {
 	AppSecret(ctx context.Context, appID int32) (string, error)
+	SigningKeyByKID(ctx context.Context, kid string) (*models.SigningKey, error)
 }
 
 type Claims struct {
@@ -26,45 +32,202 @@ type Claims struct {
 	Username string
 	Email    string
 	AppID    int32
+	// ID — jti, по нему токен можно отозвать до истечения exp.
+	// Пустой у токенов, выпущенных до появления jti.
+	ID        string
+	ExpiresAt time.Time
+	// Roles и Permissions — авторизационные данные пользователя в
+	// приложении токена. Пустые, если ролей нет.
+	Roles       []string
+	Permissions []string
+	// Scopes — scope'ы, запрошенные клиентом при логине (claim scope).
+	Scopes []string
+	// OrgID и OrgRole — организация, выбранная в сессии, и роль в ней.
+	// OrgID == 0 — токен без организации.
+	OrgID   int64
+	OrgRole string
+	// Actor — приложение, получившее токен обменом (RFC 8693, claim act).
+	// 0 — токен выдан пользователю напрямую.
+	Actor int32
 }
 
-func NewToken(user models.User, app models.App, duration time.Duration) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
+// Authorization — роли, права, scope'ы и организация, которые кладутся в
+// access-токен.
+type Authorization struct {
+	Roles       []string
+	Permissions []string
+	Scopes      []string
+	OrgID       int64
+	OrgRole     string
+	Actor       int32
+}
+
+// NewToken подписывает access-токен и возвращает его вместе с jti. key ==
+// nil — HS256 на секрете приложения без kid, иначе подпись ключом key
+// (HS256, RS256 или ES256) с его kid в заголовке.
+func NewToken(
+	user models.User,
+	app models.App,
+	key *models.SigningKey,
+	authz Authorization,
+	duration time.Duration,
+) (string, string, error) {
+	now := time.Now()
+	jti := uuid.NewString()
 
-	claims := token.Claims.(jwt.MapClaims)
-	claims["uid"] = user.ID
-	claims["username"] = user.Username
-	claims["email"] = user.Email
-	claims["exp"] = time.Now().Add(duration).Unix()
-	claims["app_id"] = app.ID
+	claims := jwt.MapClaims{
+		"jti":      jti,
+		"uid":      user.ID,
+		"username": user.Username,
+		"email":    user.Email,
+		"iat":      now.Unix(),
+		"nbf":      now.Unix(),
+		"exp":      now.Add(duration).Unix(),
+		"app_id":   app.ID,
+	}
+	if len(authz.Roles) > 0 {
+		claims["roles"] = authz.Roles
+	}
+	if len(authz.Permissions) > 0 {
+		claims["permissions"] = authz.Permissions
+	}
+	// scope — строка через пробел, как в RFC 8693 и RFC 9068
+	if len(authz.Scopes) > 0 {
+		claims["scope"] = strings.Join(authz.Scopes, " ")
+	}
+	if authz.OrgID != 0 {
+		claims["org_id"] = authz.OrgID
+		claims["org_role"] = authz.OrgRole
+	}
+	// act — RFC 8693 §4.1: кто действует от имени пользователя
+	if authz.Actor != 0 {
+		claims["act"] = map[string]string{"client_id": strconv.FormatInt(int64(authz.Actor), 10)}
+	}
 
-	tokenString, err := token.SignedString([]byte(app.Secret))
+	tokenString, err := sign(claims, app, key)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	return tokenString, nil
+	return tokenString, jti, nil
 }
 
-// ParseAndVerify достаёт app_id из непроверенного токена, получает секрет
-// приложения и валидирует подпись этим секретом.
-func ParseAndVerify(ctx context.Context, tokenString string, apps AppSecretProvider) (*Claims, error) {
-	appID, err := unverifiedAppID(tokenString)
-	if err != nil {
-		return nil, err
+// IDToken — claims OIDC ID-токена.
+type IDToken struct {
+	Issuer   string
+	User     models.User
+	Nonce    string
+	AuthTime time.Time
+}
+
+// NewIDToken подписывает OIDC ID-токен для приложения-клиента (aud =
+// app.ID). Ключ выбирается так же, как для access-токена.
+func NewIDToken(idt IDToken, app models.App, key *models.SigningKey, duration time.Duration) (string, error) {
+	now := time.Now()
+
+	claims := jwt.MapClaims{
+		"iss":                idt.Issuer,
+		"sub":                strconv.FormatInt(idt.User.ID, 10),
+		"aud":                strconv.FormatInt(int64(app.ID), 10),
+		"iat":                now.Unix(),
+		"exp":                now.Add(duration).Unix(),
+		"auth_time":          idt.AuthTime.Unix(),
+		"email":              idt.User.Email,
+		"email_verified":     idt.User.IsVerified,
+		"preferred_username": idt.User.Username,
+		// app_id — чтобы ParseAndVerify нашёл ключ проверки
+		"app_id": app.ID,
 	}
+	if idt.Nonce != "" {
+		claims["nonce"] = idt.Nonce
+	}
+
+	return sign(claims, app, key)
+}
+
+// sign подписывает claims: key == nil — HS256 на секрете приложения без
+// kid, иначе ключом key с его kid в заголовке.
+func sign(claims jwt.MapClaims, app models.App, key *models.SigningKey) (string, error) {
+	method := jwt.SigningMethod(jwt.SigningMethodHS256)
+	var signKey interface{} = []byte(app.Secret)
+
+	if key != nil {
+		var err error
+
+		method, err = signingMethod(key.Alg)
+		if err != nil {
+			return "", err
+		}
+
+		signKey, err = signingSecret(key)
+		if err != nil {
+			return "", fmt.Errorf("parse signing key %s: %w", key.KID, err)
+		}
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	if key != nil {
+		token.Header["kid"] = key.KID
+	}
+
+	return token.SignedString(signKey)
+}
 
-	secret, err := apps.AppSecret(ctx, appID)
+// ParseAndVerify достаёт app_id из непроверенного токена и валидирует
+// подпись ключом по kid, который должен принадлежать тому же приложению и
+// алгоритму. Выведенные ротацией ключи отдаются провайдером до конца
+// grace-периода, поэтому выданные ими токены доживают свой TTL. HS256 без
+// kid проверяется секретом приложения — так подписаны токены до появления
+// ключей. exp/nbf/iat проверяются с допуском leeway на расхождение часов
+// между сервисами.
+func ParseAndVerify(ctx context.Context, tokenString string, apps KeyProvider, leeway time.Duration) (*Claims, error) {
+	appID, err := unverifiedAppID(tokenString)
 	if err != nil {
-		return nil, ErrAppNotFound
+		return nil, err
 	}
 
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+	keyFunc := func(t *jwt.Token) (interface{}, error) {
+		switch t.Method.(type) {
+		case *jwt.SigningMethodHMAC, *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		default:
 			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 		}
-		return []byte(secret), nil
-	})
+
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, errors.New("missing kid")
+			}
+
+			secret, err := apps.AppSecret(ctx, appID)
+			if err != nil {
+				return nil, ErrAppNotFound
+			}
+			return []byte(secret), nil
+		}
+
+		key, err := apps.SigningKeyByKID(ctx, kid)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %q: %w", kid, err)
+		}
+
+		// kid другого приложения или алгоритма — подмена, а не ротация
+		if key.AppID != appID || string(key.Alg) != t.Method.Alg() {
+			return nil, fmt.Errorf("signing key %q does not match token", kid)
+		}
+
+		if key.Alg == models.SigningAlgHS256 {
+			return []byte(key.PrivateKey), nil
+		}
+
+		return parsePublicKey(key.PublicKey)
+	}
+
+	token, err := jwt.Parse(tokenString, keyFunc,
+		jwt.WithLeeway(leeway),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
 			return nil, ErrTokenExpired
@@ -118,10 +281,52 @@ func extractClaims(claims jwt.MapClaims) (*Claims, error) {
 		return nil, ErrInvalidToken
 	}
 
+	jti, _ := claims["jti"].(string)
+	scope, _ := claims["scope"].(string)
+	orgID, _ := claims["org_id"].(float64)
+	orgRole, _ := claims["org_role"].(string)
+
+	var actor int64
+	if act, ok := claims["act"].(map[string]interface{}); ok {
+		clientID, _ := act["client_id"].(string)
+		actor, _ = strconv.ParseInt(clientID, 10, 32)
+	}
+
+	var expiresAt time.Time
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		expiresAt = exp.Time
+	}
+
 	return &Claims{
-		UserID:   int64(uidFloat),
-		Username: username,
-		Email:    email,
-		AppID:    int32(appIDFloat),
+		UserID:      int64(uidFloat),
+		Username:    username,
+		Email:       email,
+		AppID:       int32(appIDFloat),
+		ID:          jti,
+		ExpiresAt:   expiresAt,
+		Roles:       stringSlice(claims["roles"]),
+		Permissions: stringSlice(claims["permissions"]),
+		Scopes:      strings.Fields(scope),
+		OrgID:       int64(orgID),
+		OrgRole:     orgRole,
+		Actor:       int32(actor),
 	}, nil
 }
+
+// stringSlice разбирает JSON-массив строк из claims; элементы других типов
+// пропускаются.
+func stringSlice(v interface{}) []string {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,191 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"auth_service/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrUnsupportedAlg = errors.New("unsupported signing algorithm")
+
+const rsaKeyBits = 2048
+
+// JWK — публичный ключ в формате RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+const hmacKeyBytes = 32
+
+// GenerateSigningKey создаёт ключ для приложения: RSA 2048 для RS256, P-256
+// для ES256, случайный 256-битный секрет для HS256 (хранится в PrivateKey,
+// PublicKey пустой).
+func GenerateSigningKey(appID int32, alg models.SigningAlg) (*models.SigningKey, error) {
+	const op = "jwt.GenerateSigningKey"
+
+	kid := make([]byte, 12)
+	if _, err := rand.Read(kid); err != nil {
+		return nil, fmt.Errorf("%s: kid: %w", op, err)
+	}
+
+	if alg == models.SigningAlgHS256 {
+		secret := make([]byte, hmacKeyBytes)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		return &models.SigningKey{
+			KID:        base64.RawURLEncoding.EncodeToString(kid),
+			AppID:      appID,
+			Alg:        alg,
+			PrivateKey: base64.RawURLEncoding.EncodeToString(secret),
+		}, nil
+	}
+
+	var (
+		private crypto.Signer
+		err     error
+	)
+
+	switch alg {
+	case models.SigningAlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case models.SigningAlgES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("%s: %q: %w", op, alg, ErrUnsupportedAlg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("%s: marshal private: %w", op, err)
+	}
+
+	pubDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return nil, fmt.Errorf("%s: marshal public: %w", op, err)
+	}
+
+	return &models.SigningKey{
+		KID:        base64.RawURLEncoding.EncodeToString(kid),
+		AppID:      appID,
+		Alg:        alg,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})),
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})),
+	}, nil
+}
+
+// PublicJWK переводит публичную часть ключа в JWK.
+func PublicJWK(key models.SigningKey) (JWK, error) {
+	const op = "jwt.PublicJWK"
+
+	pub, err := parsePublicKey(key.PublicKey)
+	if err != nil {
+		return JWK{}, fmt.Errorf("%s: %s: %w", op, key.KID, err)
+	}
+
+	jwk := JWK{Use: "sig", Alg: string(key.Alg), Kid: key.KID}
+
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		ecdh, err := pub.ECDH()
+		if err != nil {
+			return JWK{}, fmt.Errorf("%s: %s: %w", op, key.KID, err)
+		}
+
+		// несжатая точка: 0x04 || X || Y, координаты уже дополнены до 32 байт
+		point := ecdh.Bytes()
+		size := (len(point) - 1) / 2
+
+		jwk.Kty = "EC"
+		jwk.Crv = "P-256"
+		jwk.X = base64.RawURLEncoding.EncodeToString(point[1 : 1+size])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(point[1+size:])
+	default:
+		return JWK{}, fmt.Errorf("%s: %s: %w", op, key.KID, ErrUnsupportedAlg)
+	}
+
+	return jwk, nil
+}
+
+func signingMethod(alg models.SigningAlg) (jwt.SigningMethod, error) {
+	switch alg {
+	case models.SigningAlgHS256:
+		return jwt.SigningMethodHS256, nil
+	case models.SigningAlgRS256:
+		return jwt.SigningMethodRS256, nil
+	case models.SigningAlgES256:
+		return jwt.SigningMethodES256, nil
+	}
+
+	return nil, ErrUnsupportedAlg
+}
+
+// signingSecret возвращает ключ подписи в виде, который ждёт golang-jwt:
+// []byte для HS256, crypto.Signer для RS256/ES256.
+func signingSecret(key *models.SigningKey) (interface{}, error) {
+	if key.Alg == models.SigningAlgHS256 {
+		return []byte(key.PrivateKey), nil
+	}
+
+	return parsePrivateKey(key.PrivateKey)
+}
+
+func parsePrivateKey(pemData string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(pemData))
+	if block == nil {
+		return nil, errors.New("invalid private key pem")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupportedAlg
+	}
+
+	return signer, nil
+}
+
+func parsePublicKey(pemData string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pemData))
+	if block == nil {
+		return nil, errors.New("invalid public key pem")
+	}
+
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
@@ -25,6 +25,88 @@ func SendResetPassEmail(ctx context.Context, pub Publisher, resetToken, url, ema
 	return err
 }
 
+// SendEmailChangeConfirmation отправляет на новый адрес ссылку подтверждения смены email.
+func SendEmailChangeConfirmation(ctx context.Context, pub Publisher, token, url, newEmail string) error {
+	msg := models.Message{
+		Email:   newEmail,
+		Link:    fmt.Sprintf("%s/account/email/confirm?token=%s", url, token),
+		Purpose: "email_change_confirm",
+	}
+
+	return pub.SendMessage(ctx, msg)
+}
+
+// SendEmailChangeNotice предупреждает старый адрес о запрошенной смене email.
+func SendEmailChangeNotice(ctx context.Context, pub Publisher, oldEmail string) error {
+	msg := models.Message{
+		Email:   oldEmail,
+		Purpose: "email_change_notice",
+	}
+
+	return pub.SendMessage(ctx, msg)
+}
+
+// SendAccountDeletedEmail подтверждает удаление аккаунта и напоминает, что
+// до конца grace period его можно восстановить.
+func SendAccountDeletedEmail(ctx context.Context, pub Publisher, email string) error {
+	msg := models.Message{
+		Email:   email,
+		Purpose: "account_deleted",
+	}
+
+	return pub.SendMessage(ctx, msg)
+}
+
+// SendAccountExistsEmail отвечает на регистрацию с уже занятым адресом:
+// владельцу приходит письмо со ссылкой на сброс пароля, а регистрирующийся
+// получает тот же ответ, что и при успехе, и не узнаёт, есть ли аккаунт.
+func SendAccountExistsEmail(ctx context.Context, pub Publisher, url, email string) error {
+	msg := models.Message{
+		Email:   email,
+		Link:    fmt.Sprintf("%s/auth/password/forgot", url),
+		Purpose: "account_exists",
+	}
+
+	return pub.SendMessage(ctx, msg)
+}
+
+// SendOrgInvitation отправляет приглашение в организацию со ссылкой на его
+// принятие.
+func SendOrgInvitation(ctx context.Context, pub Publisher, token, url, email string) error {
+	msg := models.Message{
+		Email:   email,
+		Link:    fmt.Sprintf("%s/orgs/invitations/accept?token=%s", url, token),
+		Purpose: "org_invitation",
+	}
+
+	return pub.SendMessage(ctx, msg)
+}
+
+// SendOrgInvitationSignup отправляет приглашение адресу без аккаунта: ссылка
+// ведёт на регистрацию по приглашению.
+func SendOrgInvitationSignup(ctx context.Context, pub Publisher, token, url, email string) error {
+	msg := models.Message{
+		Email:   email,
+		Link:    fmt.Sprintf("%s/orgs/invitations/signup?token=%s", url, token),
+		Purpose: "org_invitation_signup",
+	}
+
+	return pub.SendMessage(ctx, msg)
+}
+
+// SendSecurityAlert уведомляет пользователя о чувствительном событии в
+// аккаунте (вход с нового устройства, смена пароля или email). Ссылки нет:
+// письмо только сообщает, что произошло, и откуда.
+func SendSecurityAlert(ctx context.Context, pub Publisher, email, purpose string, data map[string]string) error {
+	msg := models.Message{
+		Email:   email,
+		Purpose: purpose,
+		Data:    data,
+	}
+
+	return pub.SendMessage(ctx, msg)
+}
+
 func SendVerificationEmail(ctx context.Context, pub Publisher, msg models.Message) error {
 	err := pub.SendMessage(ctx, msg)
 
@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"context"
+	"log/slog"
+
+	"auth_service/internal/models"
+)
+
+// SandboxPublisher вместо публикации в RabbitMQ пишет письмо в лог вместе
+// со ссылкой — для локального стенда без брокера, email_sender и SMTP.
+type SandboxPublisher struct {
+	log *slog.Logger
+}
+
+func NewSandboxPublisher(log *slog.Logger) *SandboxPublisher {
+	return &SandboxPublisher{log: log}
+}
+
+func (p *SandboxPublisher) SendMessage(_ context.Context, msg models.Message) error {
+	p.log.Info("sandbox email",
+		slog.String("to", msg.Email),
+		slog.String("purpose", msg.Purpose),
+		slog.String("link", msg.Link),
+		slog.Any("data", msg.Data),
+	)
+
+	return nil
+}
+
+func (p *SandboxPublisher) Close(context.Context) error {
+	return nil
+}
@@ -0,0 +1,60 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+
+	"auth_service/internal/models"
+)
+
+// Назначения сообщений второго фактора.
+const (
+	PurposeMagicLink   = "2fa"
+	PurposeChannelTest = "2fa_channel_test"
+)
+
+// Notification — сообщение второго фактора. Каждый канал оформляет его
+// по-своему: email — шаблоном в email_sender, SMS и Telegram — текстом.
+type Notification struct {
+	Purpose string
+	Link    string
+}
+
+type Publisher interface {
+	SendMessage(ctx context.Context, msg models.Message) error
+}
+
+// Email отправляет сообщение письмом через очередь email_sender.
+type Email struct {
+	pub Publisher
+}
+
+func NewEmail(pub Publisher) *Email {
+	return &Email{pub: pub}
+}
+
+func (e *Email) Notify(ctx context.Context, to string, n Notification) error {
+	msg := models.Message{
+		Email:   to,
+		Link:    n.Link,
+		Purpose: n.Purpose,
+	}
+
+	if err := e.pub.SendMessage(ctx, msg); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+
+	return nil
+}
+
+// text — текст сообщения для SMS и Telegram.
+func text(n Notification) (string, error) {
+	switch n.Purpose {
+	case PurposeMagicLink:
+		return "Ссылка для входа: " + n.Link + "\nЕсли вы не входили в аккаунт, не переходите по ней.", nil
+	case PurposeChannelTest:
+		return "Сюда будут приходить ссылки для подтверждения входа.", nil
+	}
+
+	return "", fmt.Errorf("unknown purpose %q", n.Purpose)
+}
@@ -0,0 +1,78 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const telegramAPIURL = "https://api.telegram.org/bot"
+
+// Telegram отправляет сообщение от имени бота через Bot API.
+type Telegram struct {
+	client   *http.Client
+	botToken string
+}
+
+func NewTelegram(botToken string, timeout time.Duration) *Telegram {
+	return &Telegram{
+		client:   &http.Client{Timeout: timeout},
+		botToken: botToken,
+	}
+}
+
+// Notify: to — chat_id пользователя. Бот может писать только тем, кто
+// сам начал с ним диалог.
+func (t *Telegram) Notify(ctx context.Context, to string, n Notification) error {
+	body, err := text(n)
+	if err != nil {
+		return fmt.Errorf("telegram: %w", err)
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"chat_id":                  to,
+		"text":                     body,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return fmt.Errorf("telegram: marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, telegramAPIURL+t.botToken+"/sendMessage", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("telegram: build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// url.Error содержит адрес запроса, а в нём токен бота
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+
+		return fmt.Errorf("telegram: send: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result); err != nil {
+		return fmt.Errorf("telegram: status %d: decode response: %w", resp.StatusCode, err)
+	}
+
+	if !result.OK {
+		return fmt.Errorf("telegram: status %d: %w", resp.StatusCode, errors.New(result.Description))
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const twilioAPIURL = "https://api.twilio.com/2010-04-01/Accounts/"
+
+// Twilio отправляет сообщение SMS через Twilio Messages API.
+type Twilio struct {
+	client     *http.Client
+	accountSID string
+	authToken  string
+	from       string
+}
+
+func NewTwilio(accountSID, authToken, from string, timeout time.Duration) *Twilio {
+	return &Twilio{
+		client:     &http.Client{Timeout: timeout},
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       from,
+	}
+}
+
+// Notify: to — номер в формате E.164.
+func (t *Twilio) Notify(ctx context.Context, to string, n Notification) error {
+	body, err := text(n)
+	if err != nil {
+		return fmt.Errorf("twilio: %w", err)
+	}
+
+	form := url.Values{
+		"To":   {to},
+		"Body": {body},
+	}
+
+	// MG... — Messaging Service, номер отправителя Twilio выберет сам
+	if strings.HasPrefix(t.from, "MG") {
+		form.Set("MessagingServiceSid", t.from)
+	} else {
+		form.Set("From", t.from)
+	}
+
+	endpoint := twilioAPIURL + url.PathEscape(t.accountSID) + "/Messages.json"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("twilio: build request: %w", err)
+	}
+	req.SetBasicAuth(t.accountSID, t.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("twilio: send: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		var apiErr struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&apiErr)
+
+		return fmt.Errorf("twilio: status %d: code %d: %w", resp.StatusCode, apiErr.Code, errors.New(apiErr.Message))
+	}
+
+	return nil
+}
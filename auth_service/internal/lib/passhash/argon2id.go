@@ -0,0 +1,98 @@
+package passhash
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"auth_service/internal/config"
+
+	"golang.org/x/crypto/argon2"
+)
+
+var argon2idPrefix = []byte("$argon2id$")
+
+// Argon2id хранит хеш в формате PHC:
+// $argon2id$v=19$m=65536,t=3,p=2$<соль>$<хеш>, соль и хеш — base64 без
+// паддинга. Параметры записаны в самом хеше, поэтому их смена в конфиге не
+// ломает проверку старых хешей.
+type Argon2id struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	saltLength  uint32
+	keyLength   uint32
+}
+
+func NewArgon2id(cfg config.Argon2id) *Argon2id {
+	return &Argon2id{
+		memory:      cfg.MemoryKiB,
+		iterations:  cfg.Iterations,
+		parallelism: uint8(cfg.Parallelism),
+		saltLength:  cfg.SaltLength,
+		keyLength:   cfg.KeyLength,
+	}
+}
+
+func (a *Argon2id) Hash(password []byte) ([]byte, error) {
+	salt := make([]byte, a.saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("argon2id: generate salt: %w", err)
+	}
+
+	key := argon2.IDKey(password, salt, a.iterations, a.memory, a.parallelism, a.keyLength)
+
+	return fmt.Appendf(nil, "$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		a.memory, a.iterations, a.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a *Argon2id) Identify(hash []byte) bool {
+	return bytes.HasPrefix(hash, argon2idPrefix)
+}
+
+func (a *Argon2id) Verify(hash, password []byte) (bool, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", соль, хеш
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 {
+		return false, ErrUnknownFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, ErrUnknownFormat
+	}
+
+	var (
+		memory, iterations uint32
+		parallelism        uint8
+	)
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism); err != nil {
+		return false, ErrUnknownFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, ErrUnknownFormat
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return false, ErrUnknownFormat
+	}
+
+	got := argon2.IDKey(password, salt, iterations, memory, parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(got, key) != 1 {
+		return false, ErrMismatch
+	}
+
+	stale := memory != a.memory || iterations != a.iterations || parallelism != a.parallelism ||
+		uint32(len(salt)) != a.saltLength || uint32(len(key)) != a.keyLength
+
+	return stale, nil
+}
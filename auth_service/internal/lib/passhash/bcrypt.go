@@ -0,0 +1,45 @@
+package passhash
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Bcrypt — алгоритм, которым хешировались пароли до Argon2id. Хеши
+// $2a$/$2b$/$2y$ проверяются всегда, новые создаются, только если он
+// выбран текущим.
+type Bcrypt struct {
+	cost int
+}
+
+func NewBcrypt(cost int) *Bcrypt {
+	return &Bcrypt{cost: cost}
+}
+
+func (b *Bcrypt) Hash(password []byte) ([]byte, error) {
+	return bcrypt.GenerateFromPassword(password, b.cost)
+}
+
+func (b *Bcrypt) Identify(hash []byte) bool {
+	return bytes.HasPrefix(hash, []byte("$2a$")) ||
+		bytes.HasPrefix(hash, []byte("$2b$")) ||
+		bytes.HasPrefix(hash, []byte("$2y$"))
+}
+
+func (b *Bcrypt) Verify(hash, password []byte) (bool, error) {
+	if err := bcrypt.CompareHashAndPassword(hash, password); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, ErrMismatch
+		}
+		return false, ErrUnknownFormat
+	}
+
+	cost, err := bcrypt.Cost(hash)
+	if err != nil {
+		return false, ErrUnknownFormat
+	}
+
+	return cost != b.cost, nil
+}
@@ -0,0 +1,170 @@
+package passhash
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"runtime"
+
+	"auth_service/internal/config"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+var (
+	ErrMismatch      = errors.New("password does not match hash")
+	ErrUnknownFormat = errors.New("unknown password hash format")
+	ErrUnknownPepper = errors.New("password hash pepper is not configured")
+	ErrBusy          = errors.New("password hashing workers are busy")
+)
+
+// Algorithm — один алгоритм хеширования паролей.
+type Algorithm interface {
+	Hash(password []byte) ([]byte, error)
+	// Identify сообщает, что хеш записан в формате этого алгоритма.
+	Identify(hash []byte) bool
+	// Verify: stale — хеш верный, но параметры отличаются от текущих.
+	Verify(hash, password []byte) (stale bool, err error)
+}
+
+// Hasher хеширует новые пароли текущим алгоритмом и проверяет хеши всех
+// известных алгоритмов. Хеш не текущего алгоритма или с устаревшими
+// параметрами помечается к перехешированию: пароль известен только в
+// момент входа, поэтому миграция идёт постепенно, без сброса паролей.
+// То же с перцем: хеш без перца или со старым перцем перехешируется
+// текущим.
+//
+// Хеширование намеренно дорогое, поэтому одновременно считается не больше
+// workers хешей, остальные ждут слота до отмены своего ctx.
+type Hasher struct {
+	current Algorithm
+	known   []Algorithm
+
+	pepperID string
+	peppers  map[string][]byte
+
+	slots chan struct{}
+
+	// dummy — хеш случайного пароля текущим алгоритмом для VerifyDummy.
+	dummy []byte
+}
+
+func New(cfg config.PasswordHashing) *Hasher {
+	argon := NewArgon2id(cfg.Argon2id)
+	bcrypt := NewBcrypt(cfg.BcryptCost)
+
+	current := Algorithm(argon)
+	if cfg.Algorithm == AlgorithmBcrypt {
+		current = bcrypt
+	}
+
+	peppers := make(map[string][]byte, len(cfg.Pepper.Keys))
+	for id, key := range cfg.Pepper.Keys {
+		peppers[id] = []byte(key)
+	}
+
+	workers := cfg.Workers
+	if workers == 0 {
+		workers = max(runtime.GOMAXPROCS(0)/2, 1)
+	}
+
+	h := &Hasher{
+		current:  current,
+		known:    []Algorithm{argon, bcrypt},
+		pepperID: cfg.Pepper.CurrentID,
+		peppers:  peppers,
+		slots:    make(chan struct{}, workers),
+	}
+
+	// crypto/rand не возвращает ошибок, поэтому хеш создаётся всегда
+	h.dummy, _ = h.Hash(context.Background(), rand.Text())
+
+	return h
+}
+
+// Hash возвращает ErrBusy, если ctx отменён раньше, чем освободился воркер.
+func (h *Hasher) Hash(ctx context.Context, password string) ([]byte, error) {
+	if err := h.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer h.release()
+
+	if h.pepperID == "" {
+		return h.current.Hash([]byte(password))
+	}
+
+	hash, err := h.current.Hash(pepper(h.peppers[h.pepperID], password))
+	if err != nil {
+		return nil, err
+	}
+
+	return withPepperID(h.pepperID, hash), nil
+}
+
+// Verify возвращает ErrMismatch, если пароль не подходит, и ErrUnknownFormat
+// для пустого или нераспознанного хеша (например, у аккаунта без пароля).
+// ErrUnknownPepper — хеш создан перцем, которого нет в конфиге, ErrBusy —
+// не дождались воркера. needsRehash — пароль верный, но хеш пора
+// пересчитать через Hash.
+func (h *Hasher) Verify(ctx context.Context, hash []byte, password string) (needsRehash bool, err error) {
+	pepperID, hash := splitPepperID(hash)
+
+	secret := []byte(password)
+	if pepperID != "" {
+		key, ok := h.peppers[pepperID]
+		if !ok {
+			return false, fmt.Errorf("%w: %q", ErrUnknownPepper, pepperID)
+		}
+		secret = pepper(key, password)
+	}
+
+	for _, alg := range h.known {
+		if !alg.Identify(hash) {
+			continue
+		}
+
+		if err := h.acquire(ctx); err != nil {
+			return false, err
+		}
+		stale, err := alg.Verify(hash, secret)
+		h.release()
+		if err != nil {
+			return false, err
+		}
+
+		return stale || alg != h.current || pepperID != h.pepperID, nil
+	}
+
+	// аккаунт без пароля не должен отвечать быстрее, чем с неверным паролем
+	h.VerifyDummy(ctx, password)
+
+	return false, ErrUnknownFormat
+}
+
+// VerifyDummy тратит на проверку столько же времени, сколько Verify
+// настоящего хеша, и ничего не возвращает. Нужна там, где пользователя нет:
+// иначе по времени ответа видно, существует ли аккаунт.
+func (h *Hasher) VerifyDummy(ctx context.Context, password string) {
+	if len(h.dummy) == 0 {
+		return
+	}
+
+	_, _ = h.Verify(ctx, h.dummy, password)
+}
+
+func (h *Hasher) acquire(ctx context.Context) error {
+	select {
+	case h.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", ErrBusy, ctx.Err())
+	}
+}
+
+func (h *Hasher) release() {
+	<-h.slots
+}
@@ -0,0 +1,45 @@
+package passhash
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// pepperPrefix открывает хеш с перцем: $pepper$<id>$<хеш алгоритма>.
+// Хеш алгоритма сам начинается с '$', поэтому ID отделён от него им же.
+var pepperPrefix = []byte("$pepper$")
+
+// pepper — HMAC-SHA256 пароля на перце в base64 без паддинга: ровно 43
+// байта без NUL, годится и для bcrypt с его лимитом в 72 байта.
+func pepper(key []byte, password string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(password))
+
+	return []byte(base64.RawStdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+func withPepperID(id string, hash []byte) []byte {
+	out := make([]byte, 0, len(pepperPrefix)+len(id)+len(hash))
+	out = append(out, pepperPrefix...)
+	out = append(out, id...)
+
+	return append(out, hash...)
+}
+
+// splitPepperID отделяет ID перца от хеша алгоритма. Хеш без перца
+// возвращается как есть с пустым ID.
+func splitPepperID(hash []byte) (string, []byte) {
+	rest, ok := bytes.CutPrefix(hash, pepperPrefix)
+	if !ok {
+		return "", hash
+	}
+
+	i := bytes.IndexByte(rest, '$')
+	if i <= 0 {
+		return "", hash
+	}
+
+	return string(rest[:i]), rest[i:]
+}
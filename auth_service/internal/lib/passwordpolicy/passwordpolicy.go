@@ -0,0 +1,152 @@
+package passwordpolicy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"auth_service/internal/config"
+	sl "auth_service/internal/lib/logger"
+)
+
+// Правила политики — значения Violation.Rule, стабильны для клиентов.
+const (
+	RuleMinLength = "min_length"
+	RuleMaxLength = "max_length"
+	RuleUpper     = "uppercase"
+	RuleLower     = "lowercase"
+	RuleDigit     = "digit"
+	RuleSymbol    = "symbol"
+	RuleWeak      = "weak"
+	RuleBreached  = "breached"
+)
+
+// Violation — одно нарушенное правило политики.
+type Violation struct {
+	Rule    string `json:"rule" example:"min_length"`
+	Message string `json:"message" example:"password must be at least 8 characters long"`
+}
+
+// Error — пароль не прошёл политику. Violations — все нарушенные правила
+// сразу, чтобы клиент не подбирал пароль по одному правилу за запрос.
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	rules := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		rules = append(rules, v.Rule)
+	}
+
+	return "password does not satisfy policy: " + strings.Join(rules, ", ")
+}
+
+// Checker проверяет новый пароль по политике из конфига.
+type Checker struct {
+	log      *slog.Logger
+	cfg      config.PasswordPolicy
+	breaches *Pwned
+}
+
+func New(log *slog.Logger, cfg config.PasswordPolicy) *Checker {
+	c := &Checker{
+		log: log,
+		cfg: cfg,
+	}
+
+	if cfg.BreachCheck.Enabled {
+		c.breaches = NewPwned(cfg.BreachCheck.Endpoint, cfg.BreachCheck.Timeout)
+	}
+
+	return c
+}
+
+// Check возвращает *Error, если пароль нарушает политику. userInputs —
+// email, username и прочее, что известно о пользователе: пароль из них
+// оценивается как словарный. Прочие ошибки — сбой проверки по базе утечек
+// при выключенном fail_open.
+func (c *Checker) Check(ctx context.Context, password string, userInputs ...string) error {
+	const op = "passwordpolicy.Check"
+
+	violations := c.local(password, userInputs)
+
+	// пароль, отклонённый локально, в HIBP не отправляется: лишний запрос
+	if len(violations) == 0 && c.breaches != nil {
+		count, err := c.breaches.Count(ctx, password)
+		switch {
+		case err != nil && c.cfg.BreachCheck.FailOpen:
+			c.log.Warn("breach check unavailable, skipping", slog.String("op", op), sl.Err(err))
+		case err != nil:
+			return fmt.Errorf("%s: %w", op, err)
+		case count > 0:
+			violations = append(violations, Violation{
+				Rule:    RuleBreached,
+				Message: "password has appeared in a data breach, choose another one",
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &Error{Violations: violations}
+	}
+
+	return nil
+}
+
+func (c *Checker) local(password string, userInputs []string) []Violation {
+	var violations []Violation
+
+	if utf8.RuneCountInString(password) < c.cfg.MinLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMinLength,
+			Message: fmt.Sprintf("password must be at least %d characters long", c.cfg.MinLength),
+		})
+	}
+
+	if len(password) > c.cfg.MaxLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMaxLength,
+			Message: fmt.Sprintf("password must be at most %d bytes long", c.cfg.MaxLength),
+		})
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r):
+			hasSymbol = true
+		}
+	}
+
+	if c.cfg.RequireUpper && !hasUpper {
+		violations = append(violations, Violation{Rule: RuleUpper, Message: "password must contain an uppercase letter"})
+	}
+	if c.cfg.RequireLower && !hasLower {
+		violations = append(violations, Violation{Rule: RuleLower, Message: "password must contain a lowercase letter"})
+	}
+	if c.cfg.RequireDigit && !hasDigit {
+		violations = append(violations, Violation{Rule: RuleDigit, Message: "password must contain a digit"})
+	}
+	if c.cfg.RequireSymbol && !hasSymbol {
+		violations = append(violations, Violation{Rule: RuleSymbol, Message: "password must contain a symbol"})
+	}
+
+	if c.cfg.MinScore > 0 && Score(password, userInputs...) < c.cfg.MinScore {
+		violations = append(violations, Violation{
+			Rule:    RuleWeak,
+			Message: "password is too easy to guess: avoid common words, personal data, repeats and sequences",
+		})
+	}
+
+	return violations
+}
@@ -0,0 +1,74 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pwned — клиент Pwned Passwords API (HaveIBeenPwned). Используется
+// k-anonymity: запрашиваются все хеши с теми же первыми 5 символами SHA-1,
+// сам пароль и его полный хеш сервис не видит.
+type Pwned struct {
+	client   *http.Client
+	endpoint string
+}
+
+func NewPwned(endpoint string, timeout time.Duration) *Pwned {
+	return &Pwned{
+		client:   &http.Client{Timeout: timeout},
+		endpoint: strings.TrimRight(endpoint, "/"),
+	}
+}
+
+// Count возвращает, сколько раз пароль встречался в утечках. 0 — не встречался.
+func (p *Pwned) Count(ctx context.Context, password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoint+"/range/"+prefix, nil)
+	if err != nil {
+		return 0, fmt.Errorf("pwned: %w", err)
+	}
+	// паддинг выравнивает размер ответа: по нему не угадать префикс
+	req.Header.Set("Add-Padding", "true")
+	req.Header.Set("User-Agent", "auth_service")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("pwned: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("pwned: unexpected status %d", res.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		// строка ответа: SUFFIX:COUNT, у записей паддинга COUNT = 0
+		line, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || line != suffix {
+			continue
+		}
+
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return 0, fmt.Errorf("pwned: parse count: %w", err)
+		}
+
+		return n, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("pwned: read response: %w", err)
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,312 @@
+package passwordpolicy
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Score оценивает стойкость пароля по шкале zxcvbn: 0 — угадывается
+// мгновенно, 4 — очень стойкий. Оценка упрощённая, в духе zxcvbn: словарные
+// слова (в том числе с заменами вида p@ssw0rd), данные пользователя,
+// повторы, последовательности и ряды клавиатуры стоят как догадка из
+// небольшого множества, остальные символы — как перебор по алфавиту их
+// классов. Пороги те же, что у zxcvbn: 10^3, 10^6, 10^8, 10^10 догадок.
+func Score(password string, userInputs ...string) int {
+	g := guessesLog10(password, userInputs)
+
+	switch {
+	case g < 3:
+		return 0
+	case g < 6:
+		return 1
+	case g < 8:
+		return 2
+	case g < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// guessesLog10 — десятичный логарифм числа догадок, за которое атакующий
+// со словарём найдёт пароль.
+func guessesLog10(password string, userInputs []string) float64 {
+	runes := []rune(password)
+
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	plain := unleet(lower)
+
+	covered := make([]bool, len(runes))
+	var total float64
+
+	// словарь: сначала длинные слова, чтобы password не разбился на pass+word
+	for _, w := range candidates(userInputs) {
+		for i := 0; i+len(w.word) <= len(plain); i++ {
+			if !slices.Equal(plain[i:i+len(w.word)], w.word) || slices.Contains(covered[i:i+len(w.word)], true) {
+				continue
+			}
+
+			total += math.Log10(float64(w.rank)) + variations(runes[i:i+len(w.word)], lower[i:i+len(w.word)], plain[i:i+len(w.word)])
+			cover(covered, i, len(w.word))
+		}
+	}
+
+	for _, row := range keyboardRows {
+		total += matchRuns(lower, covered, 4, func(a, b rune) bool {
+			i := strings.IndexRune(row, a)
+			return i >= 0 && i+1 < len(row) && rune(row[i+1]) == b
+		}, func(_, n int) float64 {
+			return math.Log10(float64(len(keyboardRows) * len(row) * n))
+		})
+	}
+
+	// последовательности abc, 321: база — алфавит, ×2 за обратный порядок
+	for _, step := range []rune{1, -1} {
+		total += matchRuns(lower, covered, 3, func(a, b rune) bool {
+			return b-a == step && sameClass(a, b)
+		}, func(start, n int) float64 {
+			guesses := float64(charset(lower[start]) * n)
+			if step < 0 {
+				guesses *= 2
+			}
+			return math.Log10(guesses)
+		})
+	}
+
+	total += matchRuns(lower, covered, 3, func(a, b rune) bool {
+		return a == b
+	}, func(start, n int) float64 {
+		return math.Log10(float64(charset(lower[start]) * n))
+	})
+
+	// остальное — перебор по алфавиту встретившихся классов символов
+	var rest int
+	classes := make(map[int]bool)
+	for i, r := range runes {
+		if covered[i] {
+			continue
+		}
+		rest++
+		classes[class(r)] = true
+	}
+
+	var alphabet int
+	for c := range classes {
+		alphabet += classSize[c]
+	}
+	if rest > 0 {
+		total += float64(rest) * math.Log10(float64(alphabet))
+	}
+
+	return total
+}
+
+// matchRuns находит непокрытые серии длиной от minLen, где каждый соседний
+// символ связан с предыдущим по next, и возвращает суммарную цену серий.
+func matchRuns(runes []rune, covered []bool, minLen int, next func(a, b rune) bool, cost func(start, n int) float64) float64 {
+	var total float64
+
+	for i := 0; i < len(runes); {
+		j := i + 1
+		for j < len(runes) && !covered[j-1] && !covered[j] && next(runes[j-1], runes[j]) {
+			j++
+		}
+
+		if n := j - i; n >= minLen && !covered[i] {
+			total += cost(i, n)
+			cover(covered, i, n)
+		}
+
+		i = j
+	}
+
+	return total
+}
+
+func cover(covered []bool, from, n int) {
+	for k := from; k < from+n; k++ {
+		covered[k] = true
+	}
+}
+
+// variations — цена регистра и замен вида a → @ в словарном слове.
+func variations(orig, lower, plain []rune) float64 {
+	var v float64
+
+	if !slices.Equal(orig, lower) {
+		upper := true
+		for _, r := range orig {
+			if unicode.IsLower(r) {
+				upper = false
+				break
+			}
+		}
+		// Password и PASSWORD — первое, что пробуют после password
+		if upper || (unicode.IsUpper(orig[0]) && slices.Equal(orig[1:], lower[1:])) {
+			v += math.Log10(2)
+		} else {
+			v += 1
+		}
+	}
+
+	if !slices.Equal(lower, plain) {
+		v += math.Log10(2)
+	}
+
+	return v
+}
+
+const (
+	classLower = iota
+	classUpper
+	classDigit
+	classSymbol
+	classOther
+)
+
+// classSize — размер алфавита класса; для не-ASCII символов оценка грубая.
+var classSize = map[int]int{
+	classLower:  26,
+	classUpper:  26,
+	classDigit:  10,
+	classSymbol: 33,
+	classOther:  100,
+}
+
+func class(r rune) int {
+	switch {
+	case r > unicode.MaxASCII:
+		return classOther
+	case unicode.IsLower(r):
+		return classLower
+	case unicode.IsUpper(r):
+		return classUpper
+	case unicode.IsDigit(r):
+		return classDigit
+	default:
+		return classSymbol
+	}
+}
+
+func charset(r rune) int {
+	return classSize[class(r)]
+}
+
+func sameClass(a, b rune) bool {
+	return class(a) == class(b) && class(a) != classSymbol
+}
+
+var keyboardRows = []string{
+	"`1234567890-=",
+	"qwertyuiop[]",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+}
+
+var leet = map[rune]rune{
+	'4': 'a',
+	'@': 'a',
+	'8': 'b',
+	'(': 'c',
+	'3': 'e',
+	'6': 'g',
+	'1': 'i',
+	'!': 'i',
+	'0': 'o',
+	'5': 's',
+	'$': 's',
+	'7': 't',
+	'+': 't',
+	'2': 'z',
+}
+
+func unleet(runes []rune) []rune {
+	plain := make([]rune, len(runes))
+	for i, r := range runes {
+		if p, ok := leet[r]; ok {
+			r = p
+		}
+		plain[i] = r
+	}
+
+	return plain
+}
+
+type dictWord struct {
+	word []rune
+	rank int
+}
+
+// candidates — данные пользователя (ранг 1: их пробуют первыми) и словарь
+// популярных паролей, от длинных слов к коротким.
+func candidates(userInputs []string) []dictWord {
+	words := make([]dictWord, 0, len(dictionary)+len(userInputs))
+
+	for _, input := range userInputs {
+		input = strings.ToLower(input)
+		if local, _, ok := strings.Cut(input, "@"); ok {
+			input = local
+		}
+
+		for _, part := range append(strings.FieldsFunc(input, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}), input) {
+			if len([]rune(part)) >= 3 {
+				words = append(words, dictWord{word: unleet([]rune(part)), rank: 1})
+			}
+		}
+	}
+
+	words = append(words, dictionary...)
+
+	slices.SortStableFunc(words, func(a, b dictWord) int {
+		return len(b.word) - len(a.word)
+	})
+
+	return words
+}
+
+// dictionary — популярные пароли по убыванию частоты, приведённые через
+// unleet: p@ssw0rd находится так же, как password.
+var dictionary = func() []dictWord {
+	seen := make(map[string]bool)
+	words := make([]dictWord, 0, len(commonPasswords))
+
+	for i, p := range commonPasswords {
+		w := unleet([]rune(p))
+		if seen[string(w)] {
+			continue
+		}
+		seen[string(w)] = true
+
+		words = append(words, dictWord{word: w, rank: i + 1})
+	}
+
+	return words
+}()
+
+var commonPasswords = []string{
+	"123456", "password", "12345678", "qwerty", "123456789", "12345", "1234",
+	"111111", "1234567", "dragon", "123123", "baseball", "abc123", "football",
+	"monkey", "letmein", "696969", "shadow", "master", "666666", "qwertyuiop",
+	"123321", "mustang", "1234567890", "michael", "654321", "superman",
+	"1qaz2wsx", "7777777", "121212", "000000", "qazwsx", "123qwe", "killer",
+	"trustno1", "jordan", "jennifer", "zxcvbnm", "asdfgh", "hunter", "buster",
+	"soccer", "harley", "batman", "andrew", "tigger", "sunshine", "iloveyou",
+	"2000", "charlie", "robert", "thomas", "hockey", "ranger", "daniel",
+	"starwars", "klaster", "112233", "george", "computer", "michelle",
+	"jessica", "pepper", "1111", "zxcvbn", "555555", "11111111", "131313",
+	"freedom", "777777", "pass", "maggie", "159753", "aaaaaa", "ginger",
+	"princess", "joshua", "cheese", "amanda", "summer", "love", "ashley",
+	"nicole", "chelsea", "biteme", "matthew", "access", "yankees", "987654321",
+	"dallas", "austin", "thunder", "taylor", "matrix", "admin", "welcome",
+	"login", "secret", "hello", "whatever", "flower", "passw0rd", "qwe123",
+	"ytrewq", "parol", "privet", "lubov", "marina", "natasha", "qwerty123",
+	"zaq12wsx", "1q2w3e4r", "1q2w3e", "q1w2e3r4", "asdf", "qwer", "test",
+	"guest", "root", "user", "changeme", "default", "google", "internet",
+}
@@ -0,0 +1,38 @@
+package redact
+
+import (
+	"net/url"
+	"strings"
+)
+
+// sensitiveParams — query-параметры, в которых ходят одноразовые токены
+// и секреты (verify?token=, oauth callback ?code=&state= и т.п.).
+var sensitiveParams = map[string]struct{}{
+	"token":         {},
+	"code":          {},
+	"state":         {},
+	"secret":        {},
+	"password":      {},
+	"refresh_token": {},
+	"access_token":  {},
+	"session_id":    {},
+}
+
+// Query маскирует значения чувствительных параметров, остальные оставляет
+// как есть — по ним удобно разбирать запросы в логах.
+func Query(values url.Values) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	masked := make(url.Values, len(values))
+	for k, v := range values {
+		if _, ok := sensitiveParams[strings.ToLower(k)]; ok {
+			masked[k] = []string{"***"}
+			continue
+		}
+		masked[k] = v
+	}
+
+	return masked.Encode()
+}
@@ -1,11 +1,14 @@
 package tokens
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
 	"fmt"
+	"math/big"
+	"strings"
 
 	"github.com/google/uuid"
 )
@@ -41,7 +44,187 @@ func NewResetToken(id string) (string, string, []byte, error) {
 	return generateOpaque(id)
 }
 
+// APIKeyPrefix отличает API-ключ от access-токенов.
+const APIKeyPrefix = "ak_"
+
+// NewAPIKey — долгоживущий ключ машинного клиента: префикс, id для поиска
+// и секрет, от которого хранится только хеш.
+func NewAPIKey() (string, string, []byte, error) {
+	id, key, hash, err := generateOpaque("")
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	return id, APIKeyPrefix + key, hash, nil
+}
+
+// ParseAPIKey разбирает ключ на id и секрет.
+func ParseAPIKey(key string) (id, secret string, ok bool) {
+	rest, ok := strings.CutPrefix(key, APIKeyPrefix)
+	if !ok {
+		return "", "", false
+	}
+
+	return strings.Cut(rest, ".")
+}
+
+// OpaqueAccessTokenPrefix отличает opaque access-токен от JWT без похода
+// в хранилище: у JWT первый сегмент всегда начинается с "eyJ".
+const OpaqueAccessTokenPrefix = "oat_"
+
+// NewOpaqueAccessToken — access-токен без содержимого. Claims хранятся на
+// сервере под хешем токена, сам токен нигде не сохраняется.
+func NewOpaqueAccessToken() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("generate random bytes: %w", err)
+	}
+
+	token := OpaqueAccessTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	return token, HashOpaqueAccessToken(token), nil
+}
+
+func IsOpaqueAccessToken(token string) bool {
+	return strings.HasPrefix(token, OpaqueAccessTokenPrefix)
+}
+
+func HashOpaqueAccessToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
 func VerifyOpaqueToken(verifier string, storedHash []byte) bool {
 	sum := sha256.Sum256([]byte(verifier))
 	return subtle.ConstantTimeCompare(storedHash, sum[:]) == 1
 }
+
+// NewUnlockToken — одноразовый токен разблокировки входа из письма. Как и
+// у opaque access-токена, на сервере хранится только хеш.
+func NewUnlockToken() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("generate random bytes: %w", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return token, HashUnlockToken(token), nil
+}
+
+func HashUnlockToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// NewVerificationCode — 6-значный код подтверждения email для ручного
+// ввода (мобильным приложениям неудобно перехватывать ссылки). Кодов всего
+// миллион, поэтому хеш — HMAC на секрете сервиса: по утёкшему хешу код не
+// перебрать офлайн. Онлайн-перебор ограничивает число попыток в хранилище.
+func NewVerificationCode(secret string) (string, []byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", nil, fmt.Errorf("generate random code: %w", err)
+	}
+
+	code := fmt.Sprintf("%06d", n.Int64())
+
+	return code, HashVerificationCode(code, secret), nil
+}
+
+func HashVerificationCode(code, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(code))
+	return mac.Sum(nil)
+}
+
+// NewEmailChangeToken — одноразовый токен подтверждения нового email из
+// письма. На сервере хранится только хеш.
+func NewEmailChangeToken() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("generate random bytes: %w", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return token, HashEmailChangeToken(token), nil
+}
+
+func HashEmailChangeToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// NewOrgInvitationToken — одноразовый токен приглашения в организацию из
+// письма. На сервере хранится только хеш.
+func NewOrgInvitationToken() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("generate random bytes: %w", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return token, HashOrgInvitationToken(token), nil
+}
+
+func HashOrgInvitationToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// NewCSRFToken — CSRF-токен сессии с refresh-токеном в cookie. На сервере
+// хранится только хеш.
+func NewCSRFToken() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("generate random bytes: %w", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return token, HashCSRFToken(token), nil
+}
+
+func HashCSRFToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// NewAuthorizationCode — одноразовый код OIDC authorization code flow.
+// На сервере хранится только хеш.
+func NewAuthorizationCode() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("generate random bytes: %w", err)
+	}
+
+	code := base64.RawURLEncoding.EncodeToString(b)
+
+	return code, HashAuthorizationCode(code), nil
+}
+
+func HashAuthorizationCode(code string) []byte {
+	sum := sha256.Sum256([]byte(code))
+	return sum[:]
+}
+
+// NewDeviceTrustToken — токен доверенного устройства: пока он действует,
+// вход с этого устройства не требует второго фактора. На сервере хранится
+// только хеш.
+func NewDeviceTrustToken() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("generate random bytes: %w", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return token, HashDeviceTrustToken(token), nil
+}
+
+func HashDeviceTrustToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
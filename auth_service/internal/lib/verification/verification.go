@@ -13,10 +13,17 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// CodeIssuer выпускает 6-значный код, который уходит в письме вместе со
+// ссылкой.
+type CodeIssuer interface {
+	Issue(ctx context.Context, userID int64) (string, error)
+}
+
 func VerifyUserEmail(
 	ctx context.Context,
 	log *slog.Logger,
 	pub mailer.Publisher,
+	codes CodeIssuer,
 	tokenTTL time.Duration,
 	tokenSecret string,
 	userID int64,
@@ -37,6 +44,14 @@ func VerifyUserEmail(
 		Purpose: "email_verification",
 	}
 
+	// без кода письмо всё равно полезно: ссылка подтверждает адрес
+	code, err := codes.Issue(ctx, userID)
+	if err != nil {
+		log.Error("failed to issue verification code", slog.Any("err", err))
+	} else {
+		msg.Data = map[string]string{"code": code}
+	}
+
 	if err := mailer.SendVerificationEmail(ctx, pub, msg); err != nil {
 		log.Error("failed to send verification link", slog.Any("err", err))
 	}
@@ -44,17 +59,24 @@ func VerifyUserEmail(
 	return nil
 }
 
-func ParseVerificationToken(tokenStr, secret string) (int64, error) {
+// ParseVerificationToken проверяет подпись, purpose и exp/nbf с допуском
+// leeway на расхождение часов.
+func ParseVerificationToken(tokenStr, secret string, leeway time.Duration) (int64, error) {
 	const op = "verification.ParseVerificationToken"
 
 	claims := jwt.MapClaims{}
 
-	parsedToken, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+	keyFunc := func(t *jwt.Token) (interface{}, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("%s: unexpected signing method", op)
 		}
 		return []byte(secret), nil
-	})
+	}
+
+	parsedToken, err := jwt.ParseWithClaims(tokenStr, claims, keyFunc,
+		jwt.WithLeeway(leeway),
+		jwt.WithExpirationRequired(),
+	)
 	if err != nil {
 		return 0, fmt.Errorf("%s: failed to parse token: %w", op, err)
 	}
@@ -67,14 +89,6 @@ func ParseVerificationToken(tokenStr, secret string) (int64, error) {
 		return 0, fmt.Errorf("%s: invalid token purpose", op)
 	}
 
-	if expFloat, ok := claims["exp"].(float64); ok {
-		if time.Now().Unix() > int64(expFloat) {
-			return 0, fmt.Errorf("%s: token expired", op)
-		}
-	} else {
-		return 0, fmt.Errorf("%s: missing exp claim", op)
-	}
-
 	subFloat, ok := claims["sub"].(float64)
 	if !ok {
 		return 0, fmt.Errorf("%s: missing sub claim", op)
@@ -84,10 +98,14 @@ func ParseVerificationToken(tokenStr, secret string) (int64, error) {
 }
 
 func generateVerificationToken(userID int64, tokenTTL time.Duration, secret string) (string, error) {
+	now := time.Now()
+
 	claims := jwt.MapClaims{
 		"sub":     userID,
 		"purpose": "email_verification",
-		"exp":     time.Now().Add(tokenTTL).Unix(),
+		"iat":     now.Unix(),
+		"nbf":     now.Unix(),
+		"exp":     now.Add(tokenTTL).Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -0,0 +1,152 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+)
+
+var ErrInvalidWindow = errors.New("maintenance window must end after it starts")
+
+const (
+	SourceConfig = "config"
+	SourceRedis  = "redis"
+)
+
+// Store хранит окно, включённое администратором в рантайме. Общее для
+// всех реплик.
+type Store interface {
+	MaintenanceWindow(ctx context.Context) (*models.MaintenanceWindow, error)
+	SetMaintenanceWindow(ctx context.Context, w models.MaintenanceWindow) error
+	ClearMaintenanceWindow(ctx context.Context) error
+}
+
+// State — режим обслуживания на текущий момент.
+type State struct {
+	Active bool
+	Reason string
+	Source string
+	// Until — ожидаемый конец окна, nil — неизвестен.
+	Until *time.Time
+	// Window — окно из Store, в том числе ещё не начавшееся.
+	Window *models.MaintenanceWindow
+}
+
+// Mode объединяет аварийный флаг из конфига (включается рестартом,
+// работает без Redis) и окно из Store. Окно кешируется на cacheTTL, чтобы
+// не ходить в Redis на каждый запрос.
+type Mode struct {
+	log      *slog.Logger
+	store    Store
+	enabled  bool
+	reason   string
+	cacheTTL time.Duration
+
+	mu       sync.Mutex
+	cached   *models.MaintenanceWindow
+	cachedAt time.Time
+}
+
+func New(log *slog.Logger, store Store, enabled bool, reason string, cacheTTL time.Duration) *Mode {
+	return &Mode{
+		log:      log,
+		store:    store,
+		enabled:  enabled,
+		reason:   reason,
+		cacheTTL: cacheTTL,
+	}
+}
+
+// State возвращает текущее состояние. Недоступность Redis не включает
+// режим обслуживания: это не защитный механизм, и падать из-за него
+// вместе с Redis нельзя.
+func (m *Mode) State(ctx context.Context) State {
+	window := m.window(ctx)
+
+	if m.enabled {
+		return State{Active: true, Reason: m.reason, Source: SourceConfig, Window: window}
+	}
+
+	if window == nil || !window.IsActive(time.Now()) {
+		return State{Window: window}
+	}
+
+	return State{
+		Active: true,
+		Reason: window.Reason,
+		Source: SourceRedis,
+		Until:  window.EndsAt,
+		Window: window,
+	}
+}
+
+// Schedule включает окно обслуживания. Нулевой StartsAt — немедленно.
+func (m *Mode) Schedule(ctx context.Context, w models.MaintenanceWindow) error {
+	const op = "maintenance.Schedule"
+
+	if w.StartsAt.IsZero() {
+		w.StartsAt = time.Now()
+	}
+	if w.EndsAt != nil && !w.EndsAt.After(w.StartsAt) {
+		return ErrInvalidWindow
+	}
+
+	if err := m.store.SetMaintenanceWindow(ctx, w); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	m.setCached(&w)
+
+	return nil
+}
+
+// Clear снимает окно из Store. Флаг из конфига снимается только рестартом.
+func (m *Mode) Clear(ctx context.Context) error {
+	const op = "maintenance.Clear"
+
+	if err := m.store.ClearMaintenanceWindow(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	m.setCached(nil)
+
+	return nil
+}
+
+func (m *Mode) window(ctx context.Context) *models.MaintenanceWindow {
+	m.mu.Lock()
+	if time.Since(m.cachedAt) < m.cacheTTL {
+		w := m.cached
+		m.mu.Unlock()
+		return w
+	}
+	m.mu.Unlock()
+
+	w, err := m.store.MaintenanceWindow(ctx)
+	if err != nil {
+		m.log.Warn("failed to load maintenance window", sl.Err(err))
+
+		// последнее известное значение лучше, чем внезапное выключение
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return m.cached
+	}
+
+	m.setCached(w)
+
+	return w
+}
+
+func (m *Mode) setCached(w *models.MaintenanceWindow) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cached = w
+	m.cachedAt = time.Now()
+}
@@ -12,7 +12,20 @@ type Metrics struct {
 	HTTPRequestsTotal   *prometheus.CounterVec
 	HTTPRequestDuration *prometheus.HistogramVec
 
+	AuthOperationsTotal *prometheus.CounterVec
+
 	EmailPublishFailuresTotal *prometheus.CounterVec
+	EmailPublishBuffered      prometheus.Gauge
+
+	DBQueryDuration    *prometheus.HistogramVec
+	DBSlowQueriesTotal *prometheus.CounterVec
+
+	RetentionPurgedRowsTotal    *prometheus.CounterVec
+	RetentionPurgeFailuresTotal *prometheus.CounterVec
+
+	SchedulerJobRunsTotal   *prometheus.CounterVec
+	SchedulerJobDuration    *prometheus.HistogramVec
+	SchedulerJobLastSuccess *prometheus.GaugeVec
 }
 
 func New() *Metrics {
@@ -42,21 +55,111 @@ func New() *Metrics {
 			[]string{"pattern", "method"},
 		),
 
+		AuthOperationsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "auth_operations_total",
+				Help: "Count of login, registration, refresh and 2FA attempts, labeled by operation and result",
+			},
+			// result — success, two_factor_pending (только login) или причина
+			// отказа (invalid_credentials, account_locked, ...); набор
+			// значений закрыт, см. auth.result
+			[]string{"operation", "result"},
+		),
+
 		EmailPublishFailuresTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "email_publish_failures_total",
 				Help: "Count of failed RabbitMQ publishes for outgoing emails",
 			},
-			// reason будет заполняться из internal/rabbitmq после того как
-			// увидим реальные типы ошибок publisher'а (connection/channel/confirm timeout)
+			// reason — timeout, buffer_full, connection_closed или publish,
+			// см. rabbitmq.failureReason
 			[]string{"reason"},
 		),
+
+		EmailPublishBuffered: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "email_publish_buffered",
+				Help: "Number of outgoing emails held in memory until RabbitMQ becomes available",
+			},
+		),
+
+		DBQueryDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: "db_query_duration_seconds",
+				Help: "Postgres query duration in seconds, labeled by repository operation and status",
+				// Запросы к PK/уникальным индексам — единицы миллисекунд,
+				// DefBuckets начинаются с 5мс и размазали бы их в один bucket.
+				Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+			},
+			// op — const op метода репозитория (напр. "storage.postgres.RefreshTokenByID")
+			[]string{"op", "status"},
+		),
+
+		DBSlowQueriesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "db_slow_queries_total",
+				Help: "Count of Postgres queries exceeding the configured slow query threshold",
+			},
+			[]string{"op"},
+		),
+
+		RetentionPurgedRowsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "retention_purged_rows_total",
+				Help: "Count of rows deleted by data retention policies, labeled by table",
+			},
+			[]string{"table"},
+		),
+
+		RetentionPurgeFailuresTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "retention_purge_failures_total",
+				Help: "Count of failed retention purge runs, labeled by table",
+			},
+			[]string{"table"},
+		),
+
+		SchedulerJobRunsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "scheduler_job_runs_total",
+				Help: "Count of background job runs on the leader replica, labeled by job and result",
+			},
+			// result — success, failure или panic
+			[]string{"job", "result"},
+		),
+
+		SchedulerJobDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: "scheduler_job_duration_seconds",
+				Help: "Background job run duration in seconds, labeled by job",
+				// очистка пачками может идти минутами, DefBuckets обрываются на 10с
+				Buckets: []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+			},
+			[]string{"job"},
+		),
+
+		SchedulerJobLastSuccess: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "scheduler_job_last_success_timestamp_seconds",
+				Help: "Unix time of the last successful background job run, labeled by job",
+			},
+			[]string{"job"},
+		),
 	}
 
 	reg.MustRegister(
 		m.HTTPRequestsTotal,
 		m.HTTPRequestDuration,
+		m.AuthOperationsTotal,
 		m.EmailPublishFailuresTotal,
+		m.EmailPublishBuffered,
+		m.DBQueryDuration,
+		m.DBSlowQueriesTotal,
+		m.RetentionPurgedRowsTotal,
+		m.RetentionPurgeFailuresTotal,
+		m.SchedulerJobRunsTotal,
+		m.SchedulerJobDuration,
+		m.SchedulerJobLastSuccess,
 		collectors.NewGoCollector(),
 		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
 	)
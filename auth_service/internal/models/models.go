@@ -1,8 +1,10 @@
 package models
 
 import (
+	"net/netip"
 	"time"
 
+	"github.com/XdMishaXd/auth_service/contract"
 	"github.com/google/uuid"
 )
 
@@ -10,33 +12,159 @@ type Action string
 
 const (
 	ActionLogin2FA       Action = "login_2fa"
+	ActionLoginTOTP      Action = "login_totp"
 	ActionDisable2FA     Action = "disable_2fa"
 	ActionDeleteAccount  Action = "delete_account"
 	ActionRestoreAccount Action = "restore_account"
 )
 
+// AccountStatus — состояние аккаунта. pending_deletion выставляется только
+// вместе с deleted_at (soft-delete пользователем), остальные — администратором.
+//
+// disabled — обратимое отключение аккаунта без модерационного оттенка
+// (например, по запросу владельца или на время увольнения сотрудника),
+// suspended — временная блокировка за нарушение. Оба, как banned,
+// закрывают выдачу токенов: Login, Refresh, magic-link и OAuth.
+type AccountStatus string
+
+const (
+	AccountStatusActive          AccountStatus = "active"
+	AccountStatusDisabled        AccountStatus = "disabled"
+	AccountStatusSuspended       AccountStatus = "suspended"
+	AccountStatusBanned          AccountStatus = "banned"
+	AccountStatusPendingDeletion AccountStatus = "pending_deletion"
+)
+
 type User struct {
 	ID         int64
 	Email      string
 	Username   string
 	PassHash   []byte
 	IsVerified bool
-	DeletedAt  *time.Time
+	// MustResetPassword — администратор потребовал сменить пароль: до
+	// сброса токены не выдаются и не продлеваются.
+	MustResetPassword bool
+	Status            AccountStatus
+	StatusReason      *string
+	DeletedAt         *time.Time
+}
+
+// IdentityKind — тип внешнего провайдера учётки.
+type IdentityKind string
+
+const (
+	IdentityKindOAuth IdentityKind = "oauth"
+	IdentityKindSAML  IdentityKind = "saml"
+)
+
+// Identity — внешняя учётка (OAuth, SAML), привязанная к пользователю.
+// Subject — ID пользователя у провайдера, уникален в пределах Provider.
+type Identity struct {
+	ID        int64
+	UserID    int64
+	Kind      IdentityKind
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
+
+// Role — роль пользователя в приложении. Имена ролей и объединение их прав
+// попадают в claims access-токена этого приложения.
+type Role struct {
+	ID          int64
+	AppID       int32
+	Name        string
+	Description string
+	Permissions []string
+	CreatedAt   time.Time
 }
 
-type OAuthAccount struct {
-	ID             int64
-	UserID         int64
-	Provider       string
-	ProviderUserID string
-	Email          string
-	CreatedAt      time.Time
+// APIKey — ключ машинного клиента. Действует от имени приложения, а не
+// пользователя; KeyHash — хеш секретной части, сам ключ не хранится.
+type APIKey struct {
+	ID         uuid.UUID
+	AppID      int32
+	Name       string
+	KeyHash    []byte
+	Scopes     []string
+	CreatedBy  string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
 }
 
+// AccessTokenFormat — формат access-токенов, выдаваемых приложению.
+type AccessTokenFormat string
+
+const (
+	AccessTokenFormatJWT AccessTokenFormat = "jwt"
+	// AccessTokenFormatOpaque — случайная строка без содержимого, claims
+	// хранятся на сервере. Отзывается мгновенно и ничего не раскрывает
+	// при утечке, но каждая проверка идёт в хранилище.
+	AccessTokenFormatOpaque AccessTokenFormat = "opaque"
+)
+
+// RefreshTokenDelivery — как приложение получает refresh-токен.
+type RefreshTokenDelivery string
+
+const (
+	RefreshTokenDeliveryBody RefreshTokenDelivery = "body"
+	// RefreshTokenDeliveryCookie — HttpOnly cookie с CSRF double-submit:
+	// токен недоступен JavaScript и не утекает при XSS.
+	RefreshTokenDeliveryCookie RefreshTokenDelivery = "cookie"
+)
+
+// SigningAlg — алгоритм подписи JWT access-токенов приложения.
+type SigningAlg string
+
+const (
+	// SigningAlgHS256 — HMAC на секрете приложения; проверить токен может
+	// только тот, кто знает секрет.
+	SigningAlgHS256 SigningAlg = "HS256"
+	SigningAlgRS256 SigningAlg = "RS256"
+	SigningAlgES256 SigningAlg = "ES256"
+)
+
 type App struct {
-	ID     int32
-	Name   string
-	Secret string
+	ID                int32
+	Name              string
+	Secret            string
+	AccessTokenFormat AccessTokenFormat
+	SigningAlg        SigningAlg
+	// RedirectURIs — разрешённые redirect_uri для OIDC authorization code.
+	RedirectURIs []string
+	// PublicClient — OAuth-клиент без секрета (SPA, мобильное приложение):
+	// на /oauth2/token он защищён только PKCE. Конфиденциальный клиент
+	// обязан предъявлять client_secret.
+	PublicClient bool
+	// AllowedScopes — scope'ы, которые можно запросить при логине.
+	AllowedScopes        []string
+	RefreshTokenDelivery RefreshTokenDelivery
+	// TokenExchangeAudiences — приложения, на токены которых можно обменять
+	// токен пользователя этого приложения (RFC 8693).
+	TokenExchangeAudiences []int32
+	// AccessTokenTTL и RefreshTokenTTL — сроки токенов приложения; 0 —
+	// глобальные tokens.* из конфига.
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// SigningKey — ключ подписи токенов приложения. Для RS256/ES256 ключи
+// хранятся в PEM: PrivateKey — PKCS#8, PublicKey — PKIX. Для HS256
+// PrivateKey — секрет HMAC, PublicKey пустой.
+type SigningKey struct {
+	KID        string
+	AppID      int32
+	Alg        SigningAlg
+	PrivateKey string
+	PublicKey  string
+	CreatedAt  time.Time
+	// RetiredAt — ключ выведен: новые токены им не подписываются.
+	RetiredAt *time.Time
+	// ExpiresAt — после этого момента выведенный ключ не принимается.
+	ExpiresAt *time.Time
 }
 
 type RefreshToken struct {
@@ -45,8 +173,100 @@ type RefreshToken struct {
 	UserID    int64
 	AppID     int32
 	ExpiresAt time.Time
+	// AbsoluteExpiresAt — предел сессии: ротация не продлевает ExpiresAt
+	// дальше него.
+	AbsoluteExpiresAt time.Time
+	// Scopes — выданные при логине; refresh может только сузить их.
+	Scopes []string
+	// OrgID — организация, выбранная в сессии; 0 — без организации.
+	OrgID int64
+}
+
+// Device — устройство, которое клиент назвал при логине. ID стабилен для
+// установки приложения, Name показывается в списке сессий.
+type Device struct {
+	ID   string
+	Name string
+}
+
+// NewDevice возвращает nil, если клиент не передал device_id: сессия без
+// устройства не участвует в правиле «одна сессия на устройство».
+func NewDevice(id, name string) *Device {
+	if id == "" {
+		return nil
+	}
+	if name == "" {
+		name = id
+	}
+	return &Device{ID: id, Name: name}
+}
+
+// TrustedDevice — устройство, на котором после 2FA пользователь попросил
+// больше не спрашивать второй фактор. Сам токен есть только у клиента.
+type TrustedDevice struct {
+	ID         int64
+	UserID     int64
+	TokenHash  []byte
+	DeviceID   string
+	DeviceName string
+	IP         string
+	UserAgent  string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+}
+
+// Fingerprint — отпечаток устройства при выдаче токенов. Hash считается из
+// device_id клиента, а без него — из User-Agent; IP в хеш не входит, он
+// меняется слишком часто. Пустой Hash — устройство опознать нечем.
+type Fingerprint struct {
+	Hash      []byte
+	IP        string
+	UserAgent string
+}
+
+// Session — активный refresh-токен глазами пользователя, без хеша.
+// DeviceID/DeviceName пустые, если клиент не передал устройство;
+// IP/UserAgent — логина, открывшего сессию.
+type Session struct {
+	ID         uuid.UUID
+	AppID      int32
+	DeviceID   string
+	DeviceName string
+	IP         string
+	UserAgent  string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
 }
 
+// GeoLocation — где по базе GeoIP находится IP входа. SeenAt — время входа.
+type GeoLocation struct {
+	IP        string
+	Country   string
+	City      string
+	Latitude  float64
+	Longitude float64
+	SeenAt    time.Time
+}
+
+// TravelAnomaly — «невозможное перемещение»: между прошлым и текущим входом
+// SpeedKmh выше правдоподобной скорости.
+type TravelAnomaly struct {
+	From       GeoLocation
+	To         GeoLocation
+	DistanceKm float64
+	SpeedKmh   float64
+}
+
+// GeoAction — реакция на вход с невозможным перемещением.
+type GeoAction string
+
+const (
+	GeoActionNotify    GeoAction = "notify"
+	GeoActionChallenge GeoAction = "challenge"
+	GeoActionBlock     GeoAction = "block"
+)
+
 type ResetToken struct {
 	ID        uuid.UUID
 	TokenHash []byte
@@ -55,10 +275,69 @@ type ResetToken struct {
 	ExpiresAt time.Time
 }
 
-type Message struct {
-	Email   string `json:"to"`
-	Link    string `json:"link"`
-	Purpose string `json:"purpose"`
+// Message — письмо для email_sender; формат общий с ним и живёт в модуле
+// contract. Пустой ID SendMessage заменит на UUID.
+type Message = contract.EmailMessage
+
+// Organization — тенант внутри приложения.
+type Organization struct {
+	ID        int64
+	AppID     int32
+	Name      string
+	CreatedAt time.Time
+}
+
+// OrgRole — роль участника в организации.
+type OrgRole string
+
+const (
+	OrgRoleOwner  OrgRole = "owner"
+	OrgRoleAdmin  OrgRole = "admin"
+	OrgRoleMember OrgRole = "member"
+)
+
+// CanManage сообщает, может ли роль приглашать и исключать участников с
+// ролью target. Владельцами управляют только владельцы.
+func (r OrgRole) CanManage(target OrgRole) bool {
+	switch r {
+	case OrgRoleOwner:
+		return true
+	case OrgRoleAdmin:
+		return target == OrgRoleMember || target == OrgRoleAdmin
+	default:
+		return false
+	}
+}
+
+// OrgMember — членство пользователя в организации.
+type OrgMember struct {
+	OrgID     int64
+	OrgName   string
+	UserID    int64
+	Email     string
+	Role      OrgRole
+	CreatedAt time.Time
+}
+
+// OrgInvitation — приглашение в организацию по email.
+type OrgInvitation struct {
+	ID         int64
+	OrgID      int64
+	Email      string
+	Role       OrgRole
+	TokenHash  []byte
+	InvitedBy  int64
+	ExpiresAt  time.Time
+	AcceptedAt *time.Time
+	DeclinedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// EmailChange — запрошенная смена email, ждущая подтверждения с нового адреса.
+type EmailChange struct {
+	UserID   int64  `json:"user_id"`
+	OldEmail string `json:"old_email"`
+	NewEmail string `json:"new_email"`
 }
 
 type SendMagicLinkRequest struct {
@@ -79,6 +358,8 @@ type MagicLink struct {
 	AppID     int32      `json:"app_id"`
 	TokenHash []byte     `json:"token_hash"`
 	SessionID string     `json:"session_id"`
+	IPAddress string     `json:"ip_address,omitempty"`
+	UserAgent string     `json:"user_agent,omitempty"`
 	Used      bool       `json:"used"`
 	UsedAt    *time.Time `json:"used_at,omitempty"`
 	ExpiresAt time.Time  `json:"expires_at"`
@@ -93,12 +374,166 @@ type TwoFAStatus struct {
 	HasPassword bool
 }
 
+// TwoFAMethod — способ второго фактора (users.two_fa_method).
+const (
+	TwoFAMethodMagicLink = "magic_link"
+	TwoFAMethodTOTP      = "totp"
+)
+
+// TwoFAChannel — куда доставлять magic link второго фактора.
+type TwoFAChannel string
+
+const (
+	TwoFAChannelEmail    TwoFAChannel = "email"
+	TwoFAChannelSMS      TwoFAChannel = "sms"
+	TwoFAChannelTelegram TwoFAChannel = "telegram"
+)
+
+// TwoFADelivery — выбранный пользователем канал 2FA и адрес в нём. Для
+// email Destination пустой: ссылка уходит на текущий адрес аккаунта.
+type TwoFADelivery struct {
+	Channel     TwoFAChannel `json:"channel"`
+	Destination string       `json:"destination,omitempty"`
+}
+
+// TwoFASettings — настройки 2FA пользователя. Method пустой, если 2FA
+// выключена; MagicLinks — выданные и ещё не использованные ссылки.
+type TwoFASettings struct {
+	Enabled    bool
+	Method     string
+	Delivery   TwoFADelivery
+	MagicLinks []MagicLink
+}
+
+// TOTPSecret — зашифрованный TOTP-секрет пользователя. ConfirmedAt == nil —
+// enroll начат, но не подтверждён кодом.
+type TOTPSecret struct {
+	UserID       int64
+	SecretEnc    []byte
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
 // PendingSession — состояние логина между успешной проверкой пароля и
-// подтверждением второго фактора (magic link).
+// подтверждением второго фактора (magic link или TOTP).
 type PendingSession struct {
 	UserID int64
 	AppID  int32
 	Action Action
+	// Scopes — запрошенные при логине, уже проверенные по приложению.
+	Scopes []string
+}
+
+type AuditAction string
+
+const (
+	AuditActionForceLogout          AuditAction = "force_logout"
+	AuditActionRequirePasswordReset AuditAction = "require_password_reset"
+	AuditActionManualEmailVerify    AuditAction = "manual_email_verify"
+	AuditActionStatusChange         AuditAction = "status_change"
+	AuditActionRoleAssigned         AuditAction = "role_assigned"
+	AuditActionRoleRevoked          AuditAction = "role_revoked"
+
+	// действия самого пользователя
+	AuditActionPasswordChanged  AuditAction = "password_changed"
+	AuditActionTwoFAEnabled     AuditAction = "two_factor_enabled"
+	AuditActionTwoFADisabled    AuditAction = "two_factor_disabled"
+	AuditActionTwoFAChannel     AuditAction = "two_factor_channel_changed"
+	AuditActionNewDevice        AuditAction = "new_device"
+	AuditActionAccountDeleted   AuditAction = "account_deleted"
+	AuditActionAccountRestored  AuditAction = "account_restored"
+	AuditActionLogoutAll        AuditAction = "logout_all"
+	AuditActionEmailChanged     AuditAction = "email_changed"
+	AuditActionImpossibleTravel AuditAction = "impossible_travel"
+	AuditActionSessionEvicted   AuditAction = "session_evicted"
+)
+
+// ActivityActions — события, которые пользователь видит в ленте
+// активности аккаунта. Остальные остаются только в журнале аудита.
+var ActivityActions = []AuditAction{
+	AuditActionPasswordChanged,
+	AuditActionTwoFAEnabled,
+	AuditActionTwoFADisabled,
+	AuditActionTwoFAChannel,
+	AuditActionNewDevice,
+	AuditActionAccountDeleted,
+	AuditActionAccountRestored,
+	AuditActionLogoutAll,
+	AuditActionEmailChanged,
+	AuditActionImpossibleTravel,
+	AuditActionSessionEvicted,
+	AuditActionForceLogout,
+	AuditActionRequirePasswordReset,
+	AuditActionManualEmailVerify,
+	AuditActionStatusChange,
+	AuditActionRoleAssigned,
+	AuditActionRoleRevoked,
+}
+
+// AuditEvent — запись журнала действий над аккаунтом. Actor — кто
+// выполнил действие (логин администратора, "user", "system").
+type AuditEvent struct {
+	ID        int64
+	UserID    int64
+	Actor     string
+	Action    AuditAction
+	IP        string
+	UserAgent string
+	Metadata  map[string]any
+	CreatedAt time.Time
+}
+
+// IPRuleAction — действие правила доступа по IP.
+type IPRuleAction string
+
+const (
+	IPRuleAllow IPRuleAction = "allow"
+	IPRuleDeny  IPRuleAction = "deny"
+)
+
+// IPRule — правило доступа для сети. ExpiresAt == nil — бессрочное.
+type IPRule struct {
+	ID        int64
+	Prefix    netip.Prefix
+	Action    IPRuleAction
+	Reason    string
+	CreatedBy string
+	CreatedAt time.Time
+	ExpiresAt *time.Time
+}
+
+// MaintenanceWindow — запланированное или аварийное окно обслуживания.
+// EndsAt == nil — до явного выключения.
+type MaintenanceWindow struct {
+	Reason   string
+	StartsAt time.Time
+	EndsAt   *time.Time
+}
+
+// IsActive проверяет, попадает ли now в окно.
+func (w *MaintenanceWindow) IsActive(now time.Time) bool {
+	if now.Before(w.StartsAt) {
+		return false
+	}
+	return w.EndsAt == nil || now.Before(*w.EndsAt)
+}
+
+// AuthorizationCode — одноразовый код OIDC authorization code flow. Хранится
+// в Redis под хешем кода до обмена в /oauth2/token.
+type AuthorizationCode struct {
+	UserID        int64     `json:"user_id"`
+	AppID         int32     `json:"app_id"`
+	RedirectURI   string    `json:"redirect_uri"`
+	CodeChallenge string    `json:"code_challenge"`
+	Scope         string    `json:"scope"`
+	Nonce         string    `json:"nonce,omitempty"`
+	AuthTime      time.Time `json:"auth_time"`
+}
+
+type ForceLogoutResult struct {
+	RefreshTokensDeleted int64
+	AccessTokensRevoked  int
 }
 
 // * IsExpired проверяет, истек ли срок действия ссылки
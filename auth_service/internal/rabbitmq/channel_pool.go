@@ -0,0 +1,122 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var errPoolClosed = errors.New("channel pool closed")
+
+// channelPool раздаёт каналы публикующим горутинам по одному: amqp.Channel
+// не потокобезопасен, а HTTP-хендлеры публикуют параллельно. Канал, который
+// брокер закрыл (например, после channel-level ошибки), при возврате в пул
+// заменяется новым. Все каналы в режиме publisher confirms.
+type channelPool struct {
+	conn     *amqp.Connection
+	channels chan *amqp.Channel
+	closed   chan struct{}
+}
+
+func newChannelPool(conn *amqp.Connection, size int) (*channelPool, error) {
+	const op = "rabbitmq.newChannelPool"
+
+	if size <= 0 {
+		size = 1
+	}
+
+	p := &channelPool{
+		conn:     conn,
+		channels: make(chan *amqp.Channel, size),
+		closed:   make(chan struct{}),
+	}
+
+	for range size {
+		ch, err := openChannel(conn)
+		if err != nil {
+			p.close()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		p.channels <- ch
+	}
+
+	return p, nil
+}
+
+// acquire ждёт свободный канал или отмену ctx.
+func (p *channelPool) acquire(ctx context.Context) (*amqp.Channel, error) {
+	select {
+	case <-p.closed:
+		return nil, errPoolClosed
+	default:
+	}
+
+	select {
+	case ch := <-p.channels:
+		return ch, nil
+	case <-p.closed:
+		return nil, errPoolClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// release возвращает канал в пул. Закрытый канал пересоздаётся; если это не
+// удалось (упало соединение), в пул возвращается старый — публикация на нём
+// быстро вернёт amqp.ErrClosed, а слот не потеряется и acquire не зависнет.
+func (p *channelPool) release(ch *amqp.Channel) {
+	if ch.IsClosed() {
+		if fresh, err := openChannel(p.conn); err == nil {
+			ch = fresh
+		}
+	}
+
+	select {
+	case <-p.closed:
+		_ = ch.Close()
+	default:
+		p.channels <- ch
+	}
+}
+
+// openChannel открывает канал в режиме publisher confirms: без них
+// публикация в упавший брокер выглядит успешной.
+func openChannel(conn *amqp.Connection) (*amqp.Channel, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		_ = ch.Close()
+		return nil, err
+	}
+
+	return ch, nil
+}
+
+func (p *channelPool) close() error {
+	select {
+	case <-p.closed:
+		return nil
+	default:
+		close(p.closed)
+	}
+
+	var errs []error
+	for {
+		select {
+		case ch := <-p.channels:
+			if ch.IsClosed() {
+				continue
+			}
+			if err := ch.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		default:
+			return errors.Join(errs...)
+		}
+	}
+}
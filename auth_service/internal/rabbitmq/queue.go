@@ -0,0 +1,113 @@
+package rabbitmq
+
+import (
+	"fmt"
+
+	"auth_service/internal/config"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	queueTypeClassic = "classic"
+	queueTypeQuorum  = "quorum"
+	queueTypeLazy    = "lazy"
+)
+
+// queueArgs собирает аргументы QueueDeclare из конфига. Аргументы должны
+// совпадать у всех, кто объявляет очередь (auth_service и email_sender),
+// иначе брокер ответит PRECONDITION_FAILED на повторный declare — поэтому
+// оба сервиса читают одну и ту же секцию rabbitmq.queue.
+func queueArgs(cfg config.RabbitMQQueue) (amqp.Table, error) {
+	args := amqp.Table{
+		"x-dead-letter-exchange": cfg.DeadLetterExchange,
+	}
+
+	switch cfg.Type {
+	case "", queueTypeClassic:
+	case queueTypeQuorum:
+		args["x-queue-type"] = queueTypeQuorum
+	case queueTypeLazy:
+		// lazy — режим classic-очереди (сообщения сразу на диск), а не
+		// отдельный тип; для quorum-очередей он не имеет смысла.
+		args["x-queue-mode"] = queueTypeLazy
+	default:
+		return nil, fmt.Errorf("unknown queue type %q", cfg.Type)
+	}
+
+	if cfg.MessageTTL > 0 {
+		args["x-message-ttl"] = cfg.MessageTTL.Milliseconds()
+	}
+
+	if cfg.MaxLength > 0 {
+		args["x-max-length"] = cfg.MaxLength
+	}
+
+	if cfg.Overflow != "" {
+		args["x-overflow"] = cfg.Overflow
+	}
+
+	if cfg.DeadLetterRoutingKey != "" {
+		args["x-dead-letter-routing-key"] = cfg.DeadLetterRoutingKey
+	}
+
+	return args, nil
+}
+
+// declareQueue объявляет основную очередь вместе с DLX/DLQ. Объявление
+// идемпотентно, поэтому его делают оба сервиса: кто стартует первым, тот
+// и создаёт топологию.
+func declareQueue(ch *amqp.Channel, queueName string, cfg config.RabbitMQQueue) (amqp.Queue, error) {
+	const op = "rabbitmq.declareQueue"
+
+	args, err := queueArgs(cfg)
+	if err != nil {
+		return amqp.Queue{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := declareDeadLetterInfra(ch, queueName, cfg.DeadLetterExchange, cfg.DeadLetterQueue); err != nil {
+		return amqp.Queue{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	q, err := ch.QueueDeclare(
+		queueName,
+		true,  // durable
+		false, // autoDelete
+		false, // exclusive
+		false, // noWait
+		args,
+	)
+	if err != nil {
+		return amqp.Queue{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return q, nil
+}
+
+// declareDeadLetterInfra объявляет DLX-exchange и DLQ, куда попадают
+// сообщения, которые consumer явно nack'нул без requeue.
+func declareDeadLetterInfra(ch *amqp.Channel, mainQueueName, dlxName, dlqName string) error {
+	const op = "rabbitmq.declareDeadLetterInfra"
+
+	if err := ch.ExchangeDeclare(
+		dlxName,
+		"direct",
+		true, false, false, false,
+		nil,
+	); err != nil {
+		return fmt.Errorf("%s: exchange declare: %w", op, err)
+	}
+
+	if _, err := ch.QueueDeclare(
+		dlqName,
+		true, false, false, false, nil,
+	); err != nil {
+		return fmt.Errorf("%s: queue declare: %w", op, err)
+	}
+
+	if err := ch.QueueBind(dlqName, mainQueueName, dlxName, false, nil); err != nil {
+		return fmt.Errorf("%s: queue bind: %w", op, err)
+	}
+
+	return nil
+}
@@ -2,98 +2,231 @@ package rabbitmq
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"sync"
 	"time"
 
+	"auth_service/internal/config"
+	"auth_service/internal/lib/clientinfo"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/metrics"
 	"auth_service/internal/models"
 
+	"github.com/XdMishaXd/auth_service/contract"
+	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
-const (
-	dlxExchangeName = "email.dlx"
-	dlqName         = "email.verification.dlq"
+var (
+	errNacked     = errors.New("message nacked by broker")
+	errBufferFull = errors.New("retry buffer full")
 )
 
+// RabbitMQClient публикует письма в очередь. Разрыв соединения не ломает
+// отправку навсегда: supervise переподключается в фоне, а сообщения, которые
+// брокер не подтвердил, ждут в памяти (retryBuffer) и уходят после
+// восстановления. Доставка at-least-once: сообщение, confirm которого
+// потерялся вместе с соединением, может прийти дважды.
 type RabbitMQClient struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
-	queue   amqp.Queue
+	cfg     config.RabbitMQ
+	log     *slog.Logger
+	metrics *metrics.Metrics
+
+	mu   sync.RWMutex
+	conn *amqp.Connection
+	pool *channelPool
+
+	retry *retryBuffer
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	done      chan struct{}
 }
 
-func New(urlForConn string, queueName string) (*RabbitMQClient, error) {
+func New(cfg config.RabbitMQ, log *slog.Logger, m *metrics.Metrics) (*RabbitMQClient, error) {
 	const op = "rabbimq.New"
 
-	conn, err := amqp.Dial(urlForConn)
+	r := &RabbitMQClient{
+		cfg:     cfg,
+		log:     log,
+		metrics: m,
+		retry:   newRetryBuffer(cfg.RetryBufferSize, m.EmailPublishBuffered),
+		closed:  make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	// при старте брокер обязан быть доступен — ошибку конфигурации лучше
+	// увидеть сразу, а не в логах переподключения
+	conn, pool, err := r.connect()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	r.conn, r.pool = conn, pool
+
+	go r.supervise()
+
+	return r, nil
+}
+
+// connect открывает соединение, объявляет топологию и пул каналов.
+func (r *RabbitMQClient) connect() (*amqp.Connection, *channelPool, error) {
+	conn, err := dial(r.cfg)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	ch, err := conn.Channel()
 	if err != nil {
 		conn.Close()
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return nil, nil, err
 	}
 
-	if err := declareDeadLetterInfra(ch, queueName, dlxExchangeName, dlqName); err != nil {
-		ch.Close()
+	_, err = declareQueue(ch, r.cfg.QueueName, r.cfg.Queue)
+	if err == nil {
+		err = declareTopology(ch, r.cfg.Topology)
+	}
+	ch.Close() // канал нужен только для объявления топологии
+	if err != nil {
 		conn.Close()
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return nil, nil, err
 	}
 
-	q, err := ch.QueueDeclare(
-		queueName,
-		true,  // durable
-		false, // autoDelete
-		false, // exclusive
-		false, // noWait
-		amqp.Table{
-			"x-dead-letter-exchange": dlxExchangeName,
-		},
-	)
+	pool, err := newChannelPool(conn, r.cfg.PublishChannels)
 	if err != nil {
-		ch.Close()
 		conn.Close()
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return nil, nil, err
 	}
 
-	return &RabbitMQClient{conn: conn, channel: ch, queue: q}, nil
+	return conn, pool, nil
 }
 
-func (r *RabbitMQClient) SendMessage(ctx context.Context, msg models.Message) error {
+// SendMessage публикует письмо и ждёт confirm брокера. Не удалось —
+// сообщение откладывается в буфер и ошибки нет; ошибка только при
+// переполненном буфере.
+func (r *RabbitMQClient) SendMessage(ctx context.Context, msg models.Message) (err error) {
 	const op = "rabbimq.SendMessage"
 
-	body, err := json.Marshal(msg)
+	defer func() {
+		if err != nil {
+			r.metrics.EmailPublishFailuresTotal.WithLabelValues(failureReason(err)).Inc()
+		}
+	}()
+
+	// язык письма — язык запроса, который его вызвал, если вызывающий не
+	// задал его явно
+	if msg.Locale == "" {
+		msg.Locale = clientinfo.FromContext(ctx).Locale
+	}
+
+	if msg.ID == "" {
+		msg.ID = uuid.NewString()
+	}
+
+	body, err := contract.Encode(msg)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	return r.channel.PublishWithContext(
-		ctx,
-		"",
-		r.queue.Name,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType:  "application/json",
-			Body:         body,
-			DeliveryMode: amqp.Persistent,
-			Timestamp:    time.Now(),
-		},
+	ctx, span := otel.Tracer(tracerName).Start(ctx, r.cfg.QueueName+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
 	)
+	defer span.End()
+
+	publishing := amqp.Publishing{
+		ContentType:  "application/json",
+		MessageId:    msg.ID,
+		Headers:      traceHeaders(ctx),
+		Body:         body,
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+	}
+
+	publishErr := r.publish(ctx, publishing)
+	if publishErr == nil {
+		return nil
+	}
+
+	if !r.retry.push(publishing) {
+		return fmt.Errorf("%s: %w: %w", op, errBufferFull, publishErr)
+	}
+
+	r.log.Warn("publish failed, message buffered for retry",
+		slog.Int("pending", r.retry.len()),
+		sl.Err(publishErr),
+	)
+
+	return nil
 }
 
+// publish — одна попытка публикации с ожиданием confirm'а.
+func (r *RabbitMQClient) publish(ctx context.Context, msg amqp.Publishing) error {
+	r.mu.RLock()
+	pool := r.pool
+	r.mu.RUnlock()
+
+	ch, err := pool.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer pool.release(ch)
+
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, "", r.cfg.QueueName, false, false, msg)
+	if err != nil {
+		return err
+	}
+
+	// при разрыве соединения неподтверждённые публикации завершаются nack'ом
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return errNacked
+	}
+
+	return nil
+}
+
+// failureReason — label reason метрики email_publish_failures_total.
+func failureReason(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return "timeout"
+	case errors.Is(err, errBufferFull):
+		return "buffer_full"
+	case errors.Is(err, amqp.ErrClosed), errors.Is(err, errPoolClosed):
+		return "connection_closed"
+	default:
+		return "publish"
+	}
+}
+
+// Close останавливает переподключение, последний раз пробует отправить
+// отложенные сообщения и закрывает соединение.
 func (r *RabbitMQClient) Close(ctx context.Context) error {
 	done := make(chan error, 1)
 
 	go func() {
+		r.closeOnce.Do(func() { close(r.closed) })
+		<-r.done
+
+		r.flush(ctx)
+		if pending := r.retry.len(); pending > 0 {
+			r.log.Error("rabbitmq closed with undelivered messages", slog.Int("count", pending))
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
 		var errs []error
-		if err := r.channel.Close(); err != nil {
-			errs = append(errs, fmt.Errorf("channel close: %w", err))
+		if err := r.pool.close(); err != nil {
+			errs = append(errs, fmt.Errorf("channel pool close: %w", err))
 		}
-		if err := r.conn.Close(); err != nil {
+		if err := r.conn.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
 			errs = append(errs, fmt.Errorf("conn close: %w", err))
 		}
 		done <- errors.Join(errs...)
@@ -106,31 +239,3 @@ func (r *RabbitMQClient) Close(ctx context.Context) error {
 		return fmt.Errorf("rabbitmq close timed out: %w", ctx.Err())
 	}
 }
-
-// declareDeadLetterInfra объявляет DLX-exchange и DLQ, куда попадают
-// сообщения, которые consumer явно nack'нул без requeue.
-func declareDeadLetterInfra(ch *amqp.Channel, mainQueueName, dlxName, dlqName string) error {
-	const op = "rabbimq.declareDeadLetterInfra"
-
-	if err := ch.ExchangeDeclare(
-		dlxName, // используем параметр, а не глобальную константу
-		"direct",
-		true, false, false, false,
-		nil,
-	); err != nil {
-		return fmt.Errorf("%s: exchange declare: %w", op, err)
-	}
-
-	if _, err := ch.QueueDeclare(
-		dlqName,
-		true, false, false, false, nil,
-	); err != nil {
-		return fmt.Errorf("%s: queue declare: %w", op, err)
-	}
-
-	if err := ch.QueueBind(dlqName, mainQueueName, dlxName, false, nil); err != nil {
-		return fmt.Errorf("%s: queue bind: %w", op, err)
-	}
-
-	return nil
-}
@@ -0,0 +1,126 @@
+package rabbitmq
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	sl "auth_service/internal/lib/logger"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// flushPublishTimeout — ожидание confirm'а одного отложенного сообщения.
+const flushPublishTimeout = 5 * time.Second
+
+// supervise следит за соединением: после разрыва переподключается с
+// экспоненциальной паузой, а между разрывами раз в RetryInterval
+// дополняет в брокер отложенные сообщения.
+func (r *RabbitMQClient) supervise() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.cfg.RetryInterval)
+	defer ticker.Stop()
+
+	for {
+		r.mu.RLock()
+		conn := r.conn
+		r.mu.RUnlock()
+
+		// на уже закрытом соединении канал закрывается сразу
+		closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+	watch:
+		for {
+			select {
+			case <-r.closed:
+				return
+			case amqpErr := <-closed:
+				reason := "connection closed"
+				if amqpErr != nil {
+					reason = amqpErr.Reason
+				}
+				r.log.Warn("rabbitmq connection lost, reconnecting", slog.String("reason", reason))
+
+				break watch
+			case <-ticker.C:
+				r.flush(context.Background())
+			}
+		}
+
+		if !r.reconnect() {
+			return
+		}
+
+		r.flush(context.Background())
+	}
+}
+
+// reconnect поднимает соединение и пул каналов заново. Пауза между
+// попытками растёт вдвое от Reconnect.MinBackoff до Reconnect.MaxBackoff.
+// false — клиент закрыли, пока брокер был недоступен.
+func (r *RabbitMQClient) reconnect() bool {
+	backoff := r.cfg.Reconnect.MinBackoff
+
+	for attempt := 1; ; attempt++ {
+		conn, pool, err := r.connect()
+		if err == nil {
+			r.mu.Lock()
+			oldConn, oldPool := r.conn, r.pool
+			r.conn, r.pool = conn, pool
+			r.mu.Unlock()
+
+			// горутины, ждущие канал старого пула, получат errPoolClosed и
+			// отложат сообщение в буфер
+			_ = oldPool.close()
+			_ = oldConn.Close()
+
+			r.log.Info("rabbitmq reconnected", slog.Int("attempt", attempt))
+
+			return true
+		}
+
+		r.log.Warn("rabbitmq reconnect failed",
+			slog.Int("attempt", attempt),
+			slog.Duration("retry_in", backoff),
+			sl.Err(err),
+		)
+
+		select {
+		case <-r.closed:
+			return false
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, r.cfg.Reconnect.MaxBackoff)
+	}
+}
+
+// flush публикует отложенные сообщения по порядку до первой ошибки —
+// остальные дождутся следующего вызова.
+func (r *RabbitMQClient) flush(ctx context.Context) {
+	sent := 0
+
+	for {
+		msg, ok := r.retry.peek()
+		if !ok {
+			break
+		}
+
+		publishCtx, cancel := context.WithTimeout(ctx, flushPublishTimeout)
+		err := r.publish(publishCtx, msg)
+		cancel()
+
+		if err != nil {
+			r.log.Debug("retry publish failed", slog.Int("pending", r.retry.len()), sl.Err(err))
+			break
+		}
+
+		r.retry.pop()
+		sent++
+	}
+
+	if sent > 0 {
+		r.log.Info("buffered messages published", slog.Int("count", sent), slog.Int("pending", r.retry.len()))
+	}
+}
@@ -0,0 +1,68 @@
+package rabbitmq
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// retryBuffer — ограниченная FIFO-очередь сообщений, которые не удалось
+// опубликовать. Пишут в неё публикующие горутины, читает только
+// supervise (и Close после его остановки), поэтому peek + pop не гоняются.
+type retryBuffer struct {
+	mu    sync.Mutex
+	items []amqp.Publishing
+	size  int
+	gauge prometheus.Gauge
+}
+
+func newRetryBuffer(size int, gauge prometheus.Gauge) *retryBuffer {
+	return &retryBuffer{size: size, gauge: gauge}
+}
+
+// push откладывает сообщение. false — буфер полон.
+func (b *retryBuffer) push(msg amqp.Publishing) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.items) >= b.size {
+		return false
+	}
+
+	b.items = append(b.items, msg)
+	b.gauge.Set(float64(len(b.items)))
+
+	return true
+}
+
+func (b *retryBuffer) peek() (amqp.Publishing, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.items) == 0 {
+		return amqp.Publishing{}, false
+	}
+
+	return b.items[0], true
+}
+
+func (b *retryBuffer) pop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.items) == 0 {
+		return
+	}
+
+	b.items[0] = amqp.Publishing{}
+	b.items = b.items[1:]
+	b.gauge.Set(float64(len(b.items)))
+}
+
+func (b *retryBuffer) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.items)
+}
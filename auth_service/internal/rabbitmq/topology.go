@@ -0,0 +1,73 @@
+package rabbitmq
+
+import (
+	"errors"
+	"fmt"
+
+	"auth_service/internal/config"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var errInvalidTopology = errors.New("invalid topology")
+
+// declareTopology объявляет exchange'и, очереди и биндинги из конфига — в
+// этом порядке, чтобы биндинги ссылались на уже существующие объекты.
+// Все объявления идемпотентны; расхождение аргументов с уже существующим
+// объектом брокер вернёт как PRECONDITION_FAILED.
+func declareTopology(ch *amqp.Channel, t config.RabbitMQTopology) error {
+	const op = "rabbitmq.declareTopology"
+
+	for _, ex := range t.Exchanges {
+		if ex.Name == "" || ex.Kind == "" {
+			return fmt.Errorf("%s: exchange name and kind are required: %w", op, errInvalidTopology)
+		}
+
+		if err := ch.ExchangeDeclare(
+			ex.Name,
+			ex.Kind,
+			ex.Durable,
+			ex.AutoDelete,
+			false, // internal
+			false, // noWait
+			amqp.Table(ex.Arguments),
+		); err != nil {
+			return fmt.Errorf("%s: exchange %q: %w", op, ex.Name, err)
+		}
+	}
+
+	for _, q := range t.Queues {
+		if q.Name == "" {
+			return fmt.Errorf("%s: queue name is required: %w", op, errInvalidTopology)
+		}
+
+		if _, err := ch.QueueDeclare(
+			q.Name,
+			q.Durable,
+			q.AutoDelete,
+			false, // exclusive
+			false, // noWait
+			amqp.Table(q.Arguments),
+		); err != nil {
+			return fmt.Errorf("%s: queue %q: %w", op, q.Name, err)
+		}
+	}
+
+	for _, b := range t.Bindings {
+		if b.Queue == "" || b.Exchange == "" {
+			return fmt.Errorf("%s: binding queue and exchange are required: %w", op, errInvalidTopology)
+		}
+
+		if err := ch.QueueBind(
+			b.Queue,
+			b.RoutingKey,
+			b.Exchange,
+			false, // noWait
+			amqp.Table(b.Arguments),
+		); err != nil {
+			return fmt.Errorf("%s: bind %q -> %q: %w", op, b.Exchange, b.Queue, err)
+		}
+	}
+
+	return nil
+}
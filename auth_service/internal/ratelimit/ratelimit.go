@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"auth_service/internal/storage/redis"
@@ -43,9 +44,11 @@ type Decision struct {
 // Limiter — потокобезопасный rate limiter поверх AtomicOpRunner.
 // Один экземпляр переиспользуется на весь процесс (операция регистрируется
 // один раз в New, повторная регистрация происходит лениво при NOSCRIPT).
+// Счётчики живут в Redis, поэтому лимит общий для всех реплик.
 type Limiter struct {
 	redis *redis.RedisRepo
-	opID  string
+	// opID подменяется при NOSCRIPT из параллельных запросов
+	opID atomic.Pointer[string]
 }
 
 func (p Policy) ratePerSecond() float64 {
@@ -81,7 +84,10 @@ func New(ctx context.Context, r *redis.RedisRepo) (*Limiter, error) {
 		return nil, fmt.Errorf("ratelimiter: failed to register script: %w", err)
 	}
 
-	return &Limiter{redis: r, opID: opID}, nil
+	l := &Limiter{redis: r}
+	l.opID.Store(&opID)
+
+	return l, nil
 }
 
 // Allow атомарно проверяет и расходует один токен по ключу key согласно policy.
@@ -90,13 +96,10 @@ func (l *Limiter) Allow(ctx context.Context, key string, policy Policy) (Decisio
 		return Decision{}, err
 	}
 
-	now := time.Now().UnixMilli()
-
-	res, err := l.redis.ExecuteAtomicOp(ctx, l.opID, []string{key},
+	res, err := l.redis.ExecuteAtomicOp(ctx, *l.opID.Load(), []string{key},
 		policy.Burst,
 		policy.ratePerSecond(),
 		1,
-		now,
 	)
 	if err != nil {
 		if isNoScript(err) {
@@ -104,13 +107,12 @@ func (l *Limiter) Allow(ctx context.Context, key string, policy Policy) (Decisio
 			if regErr != nil {
 				return Decision{}, fmt.Errorf("%w: script re-registration failed: %v", ErrRedisUnavailable, regErr)
 			}
-			l.opID = opID
+			l.opID.Store(&opID)
 
-			res, err = l.redis.ExecuteAtomicOp(ctx, l.opID, []string{key},
+			res, err = l.redis.ExecuteAtomicOp(ctx, opID, []string{key},
 				policy.Burst,
 				policy.ratePerSecond(),
 				1,
-				now,
 			)
 		}
 
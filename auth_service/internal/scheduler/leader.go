@@ -0,0 +1,96 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/storage"
+)
+
+type Locker interface {
+	TryAdvisoryLock(ctx context.Context, key int64) (storage.Lock, bool, error)
+}
+
+// Elector выбирает одну реплику-лидера через блокировку в основном
+// хранилище (advisory lock в Postgres, GET_LOCK в MySQL). Лидер держит
+// блокировку, пока живо его соединение; остальные раз в interval пробуют
+// её перехватить.
+type Elector struct {
+	log      *slog.Logger
+	locker   Locker
+	key      int64
+	interval time.Duration
+
+	leader atomic.Bool
+}
+
+func NewElector(log *slog.Logger, locker Locker, key int64, interval time.Duration) *Elector {
+	return &Elector{
+		log:      log,
+		locker:   locker,
+		key:      key,
+		interval: interval,
+	}
+}
+
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run крутит выборы до отмены ctx и при выходе отдаёт лидерство.
+func (e *Elector) Run(ctx context.Context) {
+	const op = "scheduler.Elector.Run"
+
+	log := e.log.With(slog.String("op", op), slog.Int64("lock_key", e.key))
+
+	var lock storage.Lock
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		if lock == nil {
+			l, ok, err := e.locker.TryAdvisoryLock(ctx, e.key)
+			switch {
+			case err != nil:
+				log.Warn("leader election failed", sl.Err(err))
+			case ok:
+				lock = l
+				e.leader.Store(true)
+				log.Info("became leader")
+			}
+		} else if err := e.checkAlive(ctx, lock); err != nil {
+			// соединение с блокировкой потеряно — СУБД уже сняла её,
+			// лидером может стать другая реплика
+			e.leader.Store(false)
+			_ = lock.Release(context.WithoutCancel(ctx))
+			lock = nil
+			log.Warn("lost leadership", sl.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			if lock != nil {
+				e.leader.Store(false)
+
+				releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+				if err := lock.Release(releaseCtx); err != nil {
+					log.Warn("failed to release leader lock", sl.Err(err))
+				}
+				cancel()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) checkAlive(ctx context.Context, lock storage.Lock) error {
+	ctx, cancel := context.WithTimeout(ctx, e.interval)
+	defer cancel()
+
+	return lock.Alive(ctx)
+}
@@ -0,0 +1,112 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/metrics"
+)
+
+// Job — периодическая фоновая задача. Timeout ограничивает один запуск.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Timeout  time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler запускает задачи по интервалу только на реплике-лидере, чтобы
+// очистка и прочие фоновые работы не выполнялись N раз при N репликах.
+type Scheduler struct {
+	log     *slog.Logger
+	metrics *metrics.Metrics
+	elector *Elector
+	jobs    []Job
+}
+
+func New(log *slog.Logger, m *metrics.Metrics, elector *Elector) *Scheduler {
+	return &Scheduler{
+		log:     log,
+		metrics: m,
+		elector: elector,
+	}
+}
+
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Run блокируется до отмены ctx; к выходу все запущенные задачи
+// завершены, а лидерство отдано.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.elector.Run(ctx)
+	}()
+
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, job)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !s.elector.IsLeader() {
+				continue
+			}
+			s.runOnce(ctx, job)
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	const op = "scheduler.runOnce"
+
+	log := s.log.With(slog.String("op", op), slog.String("job", job.Name))
+
+	ctx, cancel := context.WithTimeout(ctx, job.Timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	defer func() {
+		s.metrics.SchedulerJobDuration.WithLabelValues(job.Name).Observe(time.Since(start).Seconds())
+	}()
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			s.metrics.SchedulerJobRunsTotal.WithLabelValues(job.Name, "panic").Inc()
+			log.Error("job panicked", slog.Any("panic", rec))
+		}
+	}()
+
+	if err := job.Run(ctx); err != nil {
+		s.metrics.SchedulerJobRunsTotal.WithLabelValues(job.Name, "failure").Inc()
+		log.Error("job failed", sl.Err(err), slog.Duration("duration", time.Since(start)))
+		return
+	}
+
+	s.metrics.SchedulerJobRunsTotal.WithLabelValues(job.Name, "success").Inc()
+	s.metrics.SchedulerJobLastSuccess.WithLabelValues(job.Name).SetToCurrentTime()
+
+	log.Debug("job completed", slog.Duration("duration", time.Since(start)))
+}
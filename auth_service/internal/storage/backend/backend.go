@@ -0,0 +1,42 @@
+// Package backend выбирает реализацию основного хранилища по
+// storage.driver. Вынесен из storage, чтобы интерфейсы не зависели от
+// драйверов СУБД.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"auth_service/internal/config"
+	"auth_service/internal/metrics"
+	"auth_service/internal/storage"
+	"auth_service/internal/storage/postgres"
+	"auth_service/internal/storage/sqldb"
+)
+
+// Open подключается к хранилищу из cfg.Storage.Driver. Миграции
+// применяются при включённом migrate секции драйвера, затем схема
+// сверяется со встроенными миграциями.
+func Open(ctx context.Context, cfg *config.Config, log *slog.Logger, m *metrics.Metrics) (storage.Repository, error) {
+	const op = "storage.backend.Open"
+
+	switch cfg.Storage.Driver {
+	case config.StorageDriverPostgres:
+		repo, err := postgres.New(ctx, cfg, log, m)
+		if err != nil {
+			return nil, err
+		}
+
+		return repo, nil
+	case config.StorageDriverMySQL, config.StorageDriverSQLite:
+		repo, err := sqldb.New(ctx, cfg, log)
+		if err != nil {
+			return nil, err
+		}
+
+		return repo, nil
+	default:
+		return nil, fmt.Errorf("%s: unknown storage driver %q", op, cfg.Storage.Driver)
+	}
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// SaveApp заводит приложение. Незаполненные настройки получают те же
+// значения по умолчанию, что колонки apps; app.ID == 0 — id выдаётся.
+func (r *MemoryRepo) SaveApp(ctx context.Context, app *models.App) error {
+	defer r.lock()()
+
+	for _, a := range r.st.apps {
+		if a.ID == app.ID || a.Name == app.Name || a.Secret == app.Secret {
+			return storage.ErrAppAlreadyExists
+		}
+	}
+
+	if app.ID == 0 {
+		app.ID = int32(r.st.nextID())
+	}
+	// явный id не должен совпасть с выданным позже
+	r.st.lastID = max(r.st.lastID, int64(app.ID))
+	if app.AccessTokenFormat == "" {
+		app.AccessTokenFormat = models.AccessTokenFormatJWT
+	}
+	if app.SigningAlg == "" {
+		app.SigningAlg = models.SigningAlgHS256
+	}
+	if app.RefreshTokenDelivery == "" {
+		app.RefreshTokenDelivery = models.RefreshTokenDeliveryBody
+	}
+
+	stored := *app
+	stored.RedirectURIs = slices.Clone(app.RedirectURIs)
+	stored.AllowedScopes = slices.Clone(app.AllowedScopes)
+	stored.TokenExchangeAudiences = slices.Clone(app.TokenExchangeAudiences)
+	r.st.apps[app.ID] = stored
+
+	return nil
+}
+
+func (r *MemoryRepo) App(ctx context.Context, appID int32) (*models.App, error) {
+	defer r.lock()()
+
+	app, ok := r.st.apps[appID]
+	if !ok {
+		return nil, storage.ErrAppNotFound
+	}
+
+	return &app, nil
+}
+
+func (r *MemoryRepo) IsAppMember(ctx context.Context, userID int64, appID int32) (bool, error) {
+	defer r.lock()()
+
+	_, ok := r.st.appMembers[membership{userID: userID, appID: appID}]
+
+	return ok, nil
+}
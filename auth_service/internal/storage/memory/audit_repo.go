@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"auth_service/internal/models"
+)
+
+func (r *MemoryRepo) SaveAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	defer r.lock()()
+
+	event.ID = r.st.nextID()
+	event.CreatedAt = time.Now()
+
+	stored := *event
+	stored.Metadata = maps.Clone(event.Metadata)
+	if stored.Metadata == nil {
+		stored.Metadata = map[string]any{}
+	}
+	r.st.audit = append(r.st.audit, stored)
+
+	return nil
+}
+
+// AuditEventsByUserID возвращает события пользователя с заданными action,
+// от новых к старым; beforeID > 0 — только события с id меньше него.
+func (r *MemoryRepo) AuditEventsByUserID(
+	ctx context.Context,
+	userID int64,
+	actions []models.AuditAction,
+	beforeID int64,
+	limit int,
+) ([]models.AuditEvent, error) {
+	defer r.lock()()
+
+	var events []models.AuditEvent
+	for _, e := range slices.Backward(r.st.audit) {
+		if len(events) == limit {
+			break
+		}
+		if e.UserID != userID || !slices.Contains(actions, e.Action) {
+			continue
+		}
+		if beforeID != 0 && e.ID >= beforeID {
+			continue
+		}
+
+		e.Metadata = maps.Clone(e.Metadata)
+		events = append(events, e)
+	}
+
+	return events, nil
+}
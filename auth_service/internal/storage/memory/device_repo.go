@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"auth_service/internal/models"
+)
+
+// TouchKnownDevice запоминает устройство или обновляет время последнего
+// входа с него. inserted — устройство встречено впервые, hadDevices — до
+// этого у пользователя были другие известные устройства.
+func (r *MemoryRepo) TouchKnownDevice(
+	ctx context.Context,
+	userID int64,
+	fp models.Fingerprint,
+) (inserted, hadDevices bool, err error) {
+	defer r.lock()()
+
+	key := knownDevice{userID: userID, fingerprint: string(fp.Hash)}
+	_, seen := r.st.knownDevices[key]
+	hadDevices = r.st.hasKnownDevices(userID)
+
+	r.st.knownDevices[key] = time.Now()
+
+	return !seen, hadDevices, nil
+}
+
+func (r *MemoryRepo) KnownDevice(ctx context.Context, userID int64, fingerprint []byte) (known, hasDevices bool, err error) {
+	defer r.lock()()
+
+	_, known = r.st.knownDevices[knownDevice{userID: userID, fingerprint: string(fingerprint)}]
+
+	return known, r.st.hasKnownDevices(userID), nil
+}
+
+func (s *state) hasKnownDevices(userID int64) bool {
+	for key := range s.knownDevices {
+		if key.userID == userID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// SaveIdentity привязывает внешнюю учётку к существующему пользователю.
+func (r *MemoryRepo) SaveIdentity(ctx context.Context, identity *models.Identity) error {
+	defer r.lock()()
+
+	if _, ok := r.st.users[identity.UserID]; !ok {
+		return storage.ErrUserNotFound
+	}
+	for _, i := range r.st.identities {
+		if i.Provider == identity.Provider && i.Subject == identity.Subject {
+			return storage.ErrIdentityAlreadyLinked
+		}
+		if i.UserID == identity.UserID && i.Provider == identity.Provider {
+			return storage.ErrProviderAlreadyLinked
+		}
+	}
+
+	identity.ID = r.st.nextID()
+	identity.CreatedAt = time.Now()
+	r.st.identities[identity.ID] = *identity
+
+	return nil
+}
+
+func (r *MemoryRepo) HasIdentities(ctx context.Context, userID int64) (bool, error) {
+	defer r.lock()()
+
+	for _, i := range r.st.identities {
+		if i.UserID == userID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,216 @@
+package memory
+
+import (
+	"context"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"auth_service/internal/auth/lockout"
+	"auth_service/internal/storage"
+)
+
+var _ lockout.Store = (*LockoutRepo)(nil)
+
+// LockoutRepo — lockout.Store в памяти с семантикой storage/redis: ключи
+// живут до своего срока, окно счётчика отсчитывается от первой неудачи.
+// В отличие от MemoryRepo не участвует в транзакциях — как и Redis.
+type LockoutRepo struct {
+	mu sync.Mutex
+
+	failures     map[string]expiring[int64]
+	locks        map[string]time.Time
+	levels       map[string]expiring[int64]
+	lockedIPs    map[string]map[string]time.Time
+	unlockEmails map[string]time.Time
+	unlockTokens map[string]expiring[string]
+}
+
+type expiring[T any] struct {
+	value     T
+	expiresAt time.Time
+}
+
+func (e expiring[T]) alive(now time.Time) bool {
+	return now.Before(e.expiresAt)
+}
+
+func NewLockout() *LockoutRepo {
+	return &LockoutRepo{
+		failures:     make(map[string]expiring[int64]),
+		locks:        make(map[string]time.Time),
+		levels:       make(map[string]expiring[int64]),
+		lockedIPs:    make(map[string]map[string]time.Time),
+		unlockEmails: make(map[string]time.Time),
+		unlockTokens: make(map[string]expiring[string]),
+	}
+}
+
+// pairKey — ключ пары email + IP; ip == "" — email целиком.
+func pairKey(email, ip string) string {
+	return email + "|" + ip
+}
+
+func (r *LockoutRepo) IncrLoginFailures(ctx context.Context, email, ip string, window time.Duration) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	key := pairKey(email, ip)
+
+	f, ok := r.failures[key]
+	if !ok || !f.alive(now) {
+		f = expiring[int64]{expiresAt: now.Add(window)}
+	}
+	f.value++
+	r.failures[key] = f
+
+	return f.value, nil
+}
+
+func (r *LockoutRepo) LockLoginFromIP(ctx context.Context, email, ip string, d, levelTTL, window time.Duration) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	key := pairKey(email, ip)
+
+	r.locks[key] = now.Add(d)
+	delete(r.failures, key)
+	r.bumpLevel(key, levelTTL, now)
+
+	ips, ok := r.lockedIPs[email]
+	if !ok {
+		ips = make(map[string]time.Time)
+		r.lockedIPs[email] = ips
+	}
+	ips[ip] = now
+
+	var count int64
+	for _, lockedAt := range ips {
+		if !lockedAt.Before(now.Add(-window)) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *LockoutRepo) LockAccount(ctx context.Context, email string, d, levelTTL time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	key := pairKey(email, "")
+
+	r.locks[key] = now.Add(d)
+	r.bumpLevel(key, levelTTL, now)
+
+	return nil
+}
+
+func (r *LockoutRepo) bumpLevel(key string, levelTTL time.Duration, now time.Time) {
+	level := r.levels[key]
+	if !level.alive(now) {
+		level.value = 0
+	}
+	level.value++
+	level.expiresAt = now.Add(levelTTL)
+	r.levels[key] = level
+}
+
+func (r *LockoutRepo) LockoutLevel(ctx context.Context, email, ip string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	level, ok := r.levels[pairKey(email, ip)]
+	if !ok || !level.alive(time.Now()) {
+		return 0, nil
+	}
+
+	return level.value, nil
+}
+
+func (r *LockoutRepo) LoginLockTTL(ctx context.Context, email, ip string) (time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	account := r.locks[pairKey(email, "")].Sub(now)
+	pair := r.locks[pairKey(email, ip)].Sub(now)
+
+	return max(account, pair, 0), nil
+}
+
+func (r *LockoutRepo) ResetLoginFailures(ctx context.Context, email, ip string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := pairKey(email, ip)
+	delete(r.locks, key)
+	delete(r.failures, key)
+	delete(r.levels, key)
+	delete(r.lockedIPs[email], ip)
+
+	return nil
+}
+
+func (r *LockoutRepo) UnlockAccount(ctx context.Context, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for ip := range r.lockedIPs[email] {
+		key := pairKey(email, ip)
+		delete(r.locks, key)
+		delete(r.failures, key)
+		delete(r.levels, key)
+	}
+	delete(r.lockedIPs, email)
+
+	key := pairKey(email, "")
+	delete(r.locks, key)
+	delete(r.levels, key)
+
+	return nil
+}
+
+func (r *LockoutRepo) AllowUnlockEmail(ctx context.Context, email string, interval time.Duration) (bool, error) {
+	if interval <= 0 {
+		return true, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(r.unlockEmails[email]) {
+		return false, nil
+	}
+	r.unlockEmails[email] = now.Add(interval)
+
+	return true, nil
+}
+
+func (r *LockoutRepo) SaveUnlockToken(ctx context.Context, tokenHash []byte, email string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.unlockTokens[hex.EncodeToString(tokenHash)] = expiring[string]{value: email, expiresAt: time.Now().Add(ttl)}
+
+	return nil
+}
+
+func (r *LockoutRepo) ConsumeUnlockToken(ctx context.Context, tokenHash []byte) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := hex.EncodeToString(tokenHash)
+
+	token, ok := r.unlockTokens[key]
+	delete(r.unlockTokens, key)
+	if !ok || !token.alive(time.Now()) {
+		return "", storage.ErrUnlockTokenNotFound
+	}
+
+	return token.value, nil
+}
@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+func (r *MemoryRepo) SaveMagicLink(ctx context.Context, link *models.MagicLink) error {
+	defer r.lock()()
+
+	link.ID = r.st.nextID()
+	link.CreatedAt = time.Now()
+
+	stored := *link
+	stored.TokenHash = bytes.Clone(link.TokenHash)
+	r.st.magicLinks[link.ID] = stored
+
+	return nil
+}
+
+// ConsumeMagicLink гасит неиспользованную и неистёкшую ссылку по хешу токена.
+func (r *MemoryRepo) ConsumeMagicLink(ctx context.Context, tokenHash []byte) (*models.MagicLink, error) {
+	defer r.lock()()
+
+	now := time.Now()
+	for id, link := range r.st.magicLinks {
+		if !bytes.Equal(link.TokenHash, tokenHash) || link.UsedAt != nil || !link.ExpiresAt.After(now) {
+			continue
+		}
+
+		link.UsedAt = &now
+		r.st.magicLinks[id] = link
+
+		return &link, nil
+	}
+
+	return nil, storage.ErrMagicLinkNotFound
+}
+
+func (r *MemoryRepo) InvalidateMagicLinksByUserID(ctx context.Context, userID int64) (int64, error) {
+	defer r.lock()()
+
+	return r.st.invalidateMagicLinks(userID, time.Now()), nil
+}
+
+func (s *state) invalidateMagicLinks(userID int64, now time.Time) int64 {
+	var invalidated int64
+	for id, link := range s.magicLinks {
+		if link.UserID != userID || link.UsedAt != nil || !link.ExpiresAt.After(now) {
+			continue
+		}
+
+		link.UsedAt = &now
+		s.magicLinks[id] = link
+		invalidated++
+	}
+
+	return invalidated
+}
+
+func (r *MemoryRepo) EnableMagicLink2FA(ctx context.Context, userID int64) error {
+	defer r.lock()()
+
+	u, ok := r.st.activeUser(userID)
+	if !ok {
+		return storage.ErrUserNotFound
+	}
+
+	method := models.TwoFAMethodMagicLink
+	u.twoFAMethod = &method
+	r.st.users[userID] = u
+
+	return nil
+}
+
+func (r *MemoryRepo) DisableMagicLink2FA(ctx context.Context, userID int64) error {
+	defer r.lock()()
+
+	u, ok := r.st.activeUser(userID)
+	if !ok {
+		return storage.ErrUserNotFound
+	}
+
+	u.twoFAMethod = nil
+	r.st.users[userID] = u
+
+	return nil
+}
+
+func (r *MemoryRepo) TwoFAStatus(ctx context.Context, userID int64) (*models.TwoFAStatus, error) {
+	defer r.lock()()
+
+	u, ok := r.st.activeUser(userID)
+	if !ok {
+		return nil, storage.ErrUserNotFound
+	}
+
+	return &models.TwoFAStatus{
+		IsEnabled:   u.twoFAMethod != nil,
+		Method:      u.twoFAMethod,
+		HasPassword: u.PassHash != nil,
+	}, nil
+}
@@ -0,0 +1,194 @@
+// Package memory — хранилище в памяти процесса с той же семантикой, что
+// у storage/postgres, в объёме, нужном auth.Auth: пользователи,
+// приложения, токены, magic links, устройства, аудит, роли и членство в
+// организациях. Предназначено для тестов сервисного слоя без Postgres.
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+var (
+	_ storage.UoW          = (*MemoryRepo)(nil)
+	_ storage.Tx           = (*MemoryRepo)(nil)
+	_ storage.UserSaver    = (*MemoryRepo)(nil)
+	_ storage.UserProvider = (*MemoryRepo)(nil)
+	_ storage.AppProvider  = (*MemoryRepo)(nil)
+)
+
+// MemoryRepo безопасен для конкурентного использования. Транзакции Do
+// выполняются по одной над копией данных и при успехе подменяют их
+// целиком: запись вне транзакции, сделанная, пока она открыта, теряется.
+// Для тестов это приемлемо, для продакшена — нет.
+type MemoryRepo struct {
+	mu   *sync.Mutex
+	txMu *sync.Mutex
+	st   *state
+
+	// inTx — копия репозитория внутри Do: данные видны только ей,
+	// блокировка не нужна
+	inTx bool
+}
+
+func New() *MemoryRepo {
+	return &MemoryRepo{
+		mu:   &sync.Mutex{},
+		txMu: &sync.Mutex{},
+		st:   newState(),
+	}
+}
+
+type user struct {
+	models.User
+	twoFAMethod *string
+}
+
+type membership struct {
+	userID int64
+	appID  int32
+}
+
+type refreshToken struct {
+	models.RefreshToken
+	deviceID   string
+	deviceName string
+	ip         string
+	userAgent  string
+	createdAt  time.Time
+}
+
+type knownDevice struct {
+	userID      int64
+	fingerprint string
+}
+
+type userRole struct {
+	userID int64
+	roleID int64
+}
+
+type orgMember struct {
+	orgID  int64
+	userID int64
+}
+
+// state — «таблицы». Значения в map хранятся по значению, а вложенные
+// срезы и map после записи не меняются — поэтому для снимка транзакции
+// достаточно поверхностной копии.
+type state struct {
+	lastID int64
+
+	users         map[int64]user
+	apps          map[int32]models.App
+	appMembers    map[membership]struct{}
+	refreshTokens map[uuid.UUID]refreshToken
+	resetTokens   map[uuid.UUID]models.ResetToken
+	magicLinks    map[int64]models.MagicLink
+	knownDevices  map[knownDevice]time.Time
+	audit         []models.AuditEvent
+	identities    map[int64]models.Identity
+	roles         map[int64]models.Role
+	userRoles     map[userRole]string
+	orgs          map[int64]models.Organization
+	orgMembers    map[orgMember]models.OrgMember
+}
+
+func newState() *state {
+	return &state{
+		users:         make(map[int64]user),
+		apps:          make(map[int32]models.App),
+		appMembers:    make(map[membership]struct{}),
+		refreshTokens: make(map[uuid.UUID]refreshToken),
+		resetTokens:   make(map[uuid.UUID]models.ResetToken),
+		magicLinks:    make(map[int64]models.MagicLink),
+		knownDevices:  make(map[knownDevice]time.Time),
+		identities:    make(map[int64]models.Identity),
+		roles:         make(map[int64]models.Role),
+		userRoles:     make(map[userRole]string),
+		orgs:          make(map[int64]models.Organization),
+		orgMembers:    make(map[orgMember]models.OrgMember),
+	}
+}
+
+func (s *state) clone() *state {
+	return &state{
+		lastID:        s.lastID,
+		users:         maps.Clone(s.users),
+		apps:          maps.Clone(s.apps),
+		appMembers:    maps.Clone(s.appMembers),
+		refreshTokens: maps.Clone(s.refreshTokens),
+		resetTokens:   maps.Clone(s.resetTokens),
+		magicLinks:    maps.Clone(s.magicLinks),
+		knownDevices:  maps.Clone(s.knownDevices),
+		audit:         slices.Clone(s.audit),
+		identities:    maps.Clone(s.identities),
+		roles:         maps.Clone(s.roles),
+		userRoles:     maps.Clone(s.userRoles),
+		orgs:          maps.Clone(s.orgs),
+		orgMembers:    maps.Clone(s.orgMembers),
+	}
+}
+
+// nextID — общий для всех «таблиц» счётчик, как BIGSERIAL без пропусков.
+func (s *state) nextID() int64 {
+	s.lastID++
+	return s.lastID
+}
+
+// lock берёт блокировку вне транзакции; использование — defer r.lock()().
+func (r *MemoryRepo) lock() func() {
+	if r.inTx {
+		return func() {}
+	}
+
+	r.mu.Lock()
+	return r.mu.Unlock
+}
+
+// * Do реализует storage.UoW. fn работает с копией данных; ошибка или
+// паника fn её отбрасывает, nil — копия становится текущими данными.
+// Вложенный Do выполняется в той же транзакции.
+func (r *MemoryRepo) Do(ctx context.Context, fn func(ctx context.Context, tx storage.Tx) error) error {
+	if r.inTx {
+		return fn(ctx, r)
+	}
+
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+
+	r.mu.Lock()
+	snapshot := r.st.clone()
+	r.mu.Unlock()
+
+	tx := &MemoryRepo{mu: r.mu, txMu: r.txMu, st: snapshot, inTx: true}
+	if err := fn(ctx, tx); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	*r.st = *snapshot
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *MemoryRepo) Users() storage.UserRepo { return r }
+
+func (r *MemoryRepo) Tokens() storage.TokenRepo { return r }
+
+func (r *MemoryRepo) MagicLinks() storage.MagicLinkRepo { return r }
+
+func (r *MemoryRepo) Audit() storage.AuditRepo { return r }
+
+func (r *MemoryRepo) Roles() storage.RoleRepo { return r }
+
+func (r *MemoryRepo) Devices() storage.DeviceRepo { return r }
@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// CreateOrganization создаёт организацию и делает создателя её владельцем.
+func (r *MemoryRepo) CreateOrganization(ctx context.Context, org *models.Organization, ownerID int64) error {
+	defer r.lock()()
+
+	if _, ok := r.st.apps[org.AppID]; !ok {
+		return storage.ErrAppNotFound
+	}
+	if _, ok := r.st.users[ownerID]; !ok {
+		return storage.ErrUserNotFound
+	}
+
+	org.ID = r.st.nextID()
+	org.CreatedAt = time.Now()
+	r.st.orgs[org.ID] = *org
+
+	r.st.orgMembers[orgMember{orgID: org.ID, userID: ownerID}] = models.OrgMember{
+		OrgID:     org.ID,
+		UserID:    ownerID,
+		Role:      models.OrgRoleOwner,
+		CreatedAt: org.CreatedAt,
+	}
+
+	return nil
+}
+
+// OrgMember — членство пользователя в организации. appID != 0 —
+// организация должна принадлежать этому приложению.
+func (r *MemoryRepo) OrgMember(ctx context.Context, orgID, userID int64, appID int32) (*models.OrgMember, error) {
+	defer r.lock()()
+
+	m, ok := r.st.orgMembers[orgMember{orgID: orgID, userID: userID}]
+	if !ok {
+		return nil, storage.ErrOrgMemberNotFound
+	}
+
+	org := r.st.orgs[orgID]
+	if appID != 0 && org.AppID != appID {
+		return nil, storage.ErrOrgMemberNotFound
+	}
+
+	m.OrgName = org.Name
+	m.Email = r.st.users[userID].Email
+
+	return &m, nil
+}
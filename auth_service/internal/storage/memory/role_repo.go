@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// CreateRole заводит роль приложения; имя уникально в пределах приложения.
+func (r *MemoryRepo) CreateRole(ctx context.Context, role *models.Role) error {
+	defer r.lock()()
+
+	if _, ok := r.st.apps[role.AppID]; !ok {
+		return storage.ErrAppNotFound
+	}
+	for _, existing := range r.st.roles {
+		if existing.AppID == role.AppID && existing.Name == role.Name {
+			return storage.ErrRoleAlreadyExists
+		}
+	}
+
+	role.ID = r.st.nextID()
+	role.CreatedAt = time.Now()
+
+	stored := *role
+	stored.Permissions = slices.Clone(role.Permissions)
+	r.st.roles[role.ID] = stored
+
+	return nil
+}
+
+// UserRoles — роли пользователя в приложении по имени.
+func (r *MemoryRepo) UserRoles(ctx context.Context, userID int64, appID int32) ([]models.Role, error) {
+	defer r.lock()()
+
+	roles := []models.Role{}
+	for key := range r.st.userRoles {
+		if key.userID != userID {
+			continue
+		}
+		if role := r.st.roles[key.roleID]; role.AppID == appID {
+			roles = append(roles, role)
+		}
+	}
+
+	slices.SortFunc(roles, func(a, b models.Role) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return roles, nil
+}
+
+// AssignRole назначает роль пользователю. false — роль уже была назначена.
+func (r *MemoryRepo) AssignRole(ctx context.Context, userID, roleID int64, grantedBy string) (bool, error) {
+	defer r.lock()()
+
+	if _, ok := r.st.users[userID]; !ok {
+		return false, storage.ErrUserNotFound
+	}
+	if _, ok := r.st.roles[roleID]; !ok {
+		return false, storage.ErrRoleNotFound
+	}
+
+	key := userRole{userID: userID, roleID: roleID}
+	if _, ok := r.st.userRoles[key]; ok {
+		return false, nil
+	}
+	r.st.userRoles[key] = grantedBy
+
+	return true, nil
+}
+
+// RevokeRole снимает роль с пользователя. false — роли у него не было.
+func (r *MemoryRepo) RevokeRole(ctx context.Context, userID, roleID int64) (bool, error) {
+	defer r.lock()()
+
+	key := userRole{userID: userID, roleID: roleID}
+	if _, ok := r.st.userRoles[key]; !ok {
+		return false, nil
+	}
+	delete(r.st.userRoles, key)
+
+	return true, nil
+}
@@ -0,0 +1,282 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+func (r *MemoryRepo) SaveRefreshToken(
+	ctx context.Context,
+	id string,
+	userID int64,
+	appID int32,
+	device *models.Device,
+	fp models.Fingerprint,
+	tokenHash []byte,
+	expiresAt time.Time,
+	absoluteExpiresAt time.Time,
+	scopes []string,
+) error {
+	const op = "storage.memory.SaveRefreshToken"
+
+	tokenID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer r.lock()()
+
+	if _, ok := r.st.refreshTokens[tokenID]; ok {
+		return fmt.Errorf("%s: duplicate refresh token id %s", op, tokenID)
+	}
+
+	rt := refreshToken{
+		RefreshToken: models.RefreshToken{
+			ID:                tokenID,
+			TokenHash:         bytes.Clone(tokenHash),
+			UserID:            userID,
+			AppID:             appID,
+			ExpiresAt:         expiresAt,
+			AbsoluteExpiresAt: absoluteExpiresAt,
+			Scopes:            slices.Clone(scopes),
+		},
+		ip:        fp.IP,
+		userAgent: fp.UserAgent,
+		createdAt: time.Now(),
+	}
+	if device != nil {
+		rt.deviceID, rt.deviceName = device.ID, device.Name
+	}
+	if rt.Scopes == nil {
+		rt.Scopes = []string{}
+	}
+
+	r.st.refreshTokens[tokenID] = rt
+
+	return nil
+}
+
+// UpdateRefreshToken ротирует токен, только если его хеш всё ещё oldTokenHash.
+func (r *MemoryRepo) UpdateRefreshToken(
+	ctx context.Context,
+	id uuid.UUID,
+	newTokenHash []byte,
+	oldTokenHash []byte,
+	expiresAt time.Time,
+	orgID int64,
+) error {
+	defer r.lock()()
+
+	rt, ok := r.st.refreshTokens[id]
+	if !ok || !bytes.Equal(rt.TokenHash, oldTokenHash) {
+		return storage.ErrRefreshTokenConflict
+	}
+
+	rt.TokenHash = bytes.Clone(newTokenHash)
+	rt.ExpiresAt = expiresAt
+	rt.OrgID = orgID
+	r.st.refreshTokens[id] = rt
+
+	return nil
+}
+
+func (r *MemoryRepo) RefreshTokenByID(ctx context.Context, id uuid.UUID) (*models.RefreshToken, error) {
+	defer r.lock()()
+
+	rt, ok := r.st.refreshTokens[id]
+	if !ok {
+		return nil, storage.ErrRefreshTokenNotFound
+	}
+
+	return &rt.RefreshToken, nil
+}
+
+// SessionsByUserID — неистёкшие refresh-токены пользователя, новые первыми.
+func (r *MemoryRepo) SessionsByUserID(ctx context.Context, userID int64) ([]models.Session, error) {
+	defer r.lock()()
+
+	active := r.st.activeRefreshTokens(userID, 0)
+	slices.Reverse(active)
+
+	sessions := make([]models.Session, 0, len(active))
+	for _, rt := range active {
+		sessions = append(sessions, models.Session{
+			ID:         rt.ID,
+			AppID:      rt.AppID,
+			DeviceID:   rt.deviceID,
+			DeviceName: rt.deviceName,
+			IP:         rt.ip,
+			UserAgent:  rt.userAgent,
+			CreatedAt:  rt.createdAt,
+			ExpiresAt:  rt.ExpiresAt,
+		})
+	}
+
+	return sessions, nil
+}
+
+// LockActiveSessions — неистёкшие сессии пользователя в приложении, старые
+// первыми. Блокировка строки пользователя не нужна: транзакции Do и так
+// выполняются по одной.
+func (r *MemoryRepo) LockActiveSessions(ctx context.Context, userID int64, appID int32) ([]uuid.UUID, error) {
+	defer r.lock()()
+
+	active := r.st.activeRefreshTokens(userID, appID)
+
+	ids := make([]uuid.UUID, 0, len(active))
+	for _, rt := range active {
+		ids = append(ids, rt.ID)
+	}
+
+	return ids, nil
+}
+
+// activeRefreshTokens — неистёкшие токены пользователя по возрастанию
+// created_at, затем id. appID == 0 — во всех приложениях.
+func (s *state) activeRefreshTokens(userID int64, appID int32) []refreshToken {
+	now := time.Now()
+
+	var active []refreshToken
+	for _, rt := range s.refreshTokens {
+		if rt.UserID != userID || !rt.ExpiresAt.After(now) {
+			continue
+		}
+		if appID != 0 && rt.AppID != appID {
+			continue
+		}
+		active = append(active, rt)
+	}
+
+	slices.SortFunc(active, func(a, b refreshToken) int {
+		return cmp.Or(a.createdAt.Compare(b.createdAt), bytes.Compare(a.ID[:], b.ID[:]))
+	})
+
+	return active
+}
+
+func (r *MemoryRepo) DeleteRefreshToken(ctx context.Context, id uuid.UUID) error {
+	defer r.lock()()
+
+	delete(r.st.refreshTokens, id)
+
+	return nil
+}
+
+// DeleteAllRefreshTokens удаляет refresh-токены пользователя во всех
+// приложениях и возвращает их количество.
+func (r *MemoryRepo) DeleteAllRefreshTokens(ctx context.Context, userID int64) (int64, error) {
+	defer r.lock()()
+
+	var deleted int64
+	for id, rt := range r.st.refreshTokens {
+		if rt.UserID == userID {
+			delete(r.st.refreshTokens, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (r *MemoryRepo) DeleteDeviceRefreshTokens(ctx context.Context, userID int64, deviceID string) (int64, error) {
+	defer r.lock()()
+
+	var deleted int64
+	for id, rt := range r.st.refreshTokens {
+		if rt.UserID == userID && rt.deviceID != "" && rt.deviceID == deviceID {
+			delete(r.st.refreshTokens, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (r *MemoryRepo) SaveResetToken(
+	ctx context.Context,
+	tokenID uuid.UUID,
+	userID int64,
+	tokenHash []byte,
+	expiresAt time.Time,
+) error {
+	const op = "storage.memory.SaveResetToken"
+
+	defer r.lock()()
+
+	if _, ok := r.st.resetTokens[tokenID]; ok {
+		return fmt.Errorf("%s: duplicate reset token id %s", op, tokenID)
+	}
+	if _, ok := r.st.users[userID]; !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	r.st.resetTokens[tokenID] = models.ResetToken{
+		ID:        tokenID,
+		TokenHash: bytes.Clone(tokenHash),
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}
+
+	return nil
+}
+
+func (r *MemoryRepo) ResetTokenByID(ctx context.Context, tokenID uuid.UUID) (*models.ResetToken, error) {
+	defer r.lock()()
+
+	rt, ok := r.st.resetTokens[tokenID]
+	if !ok {
+		return nil, storage.ErrResetTokenNotFound
+	}
+
+	return &rt, nil
+}
+
+func (r *MemoryRepo) DeleteAllResetTokens(ctx context.Context, uid int64) error {
+	defer r.lock()()
+
+	for id, rt := range r.st.resetTokens {
+		if rt.UserID == uid {
+			delete(r.st.resetTokens, id)
+		}
+	}
+
+	return nil
+}
+
+// ResetPassword гасит reset-токен и меняет пароль; все сессии и прочие
+// reset-токены пользователя удаляются, активные magic links гасятся.
+func (r *MemoryRepo) ResetPassword(
+	ctx context.Context,
+	userID int64,
+	tokenID uuid.UUID,
+	newPasswordHash []byte,
+) error {
+	defer r.lock()()
+
+	rt, ok := r.st.resetTokens[tokenID]
+	if !ok || rt.UserID != userID || rt.UsedAt != nil {
+		return storage.ErrResetTokenUsed
+	}
+
+	u, ok := r.st.activeUser(userID)
+	if !ok {
+		return storage.ErrUserNotFound
+	}
+
+	u.PassHash = bytes.Clone(newPasswordHash)
+	u.MustResetPassword = false
+	r.st.users[userID] = u
+
+	r.st.revokeCredentials(userID, time.Now())
+
+	return nil
+}
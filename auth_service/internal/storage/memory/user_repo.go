@@ -0,0 +1,273 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// findByEmail сравнивает email без учёта регистра — как CITEXT в Postgres.
+func (s *state) findByEmail(email string) (user, bool) {
+	for _, u := range s.users {
+		if strings.EqualFold(u.Email, email) {
+			return u, true
+		}
+	}
+	return user{}, false
+}
+
+// activeUser — пользователь, не помеченный удалённым.
+func (s *state) activeUser(id int64) (user, bool) {
+	u, ok := s.users[id]
+	if !ok || u.DeletedAt != nil {
+		return user{}, false
+	}
+	return u, true
+}
+
+func (s *state) usernameTaken(username string, exceptID int64) bool {
+	for _, u := range s.users {
+		if u.ID != exceptID && strings.EqualFold(u.Username, username) {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveUser создаёт пользователя и делает его участником приложения.
+func (r *MemoryRepo) SaveUser(ctx context.Context, email, username string, passHash []byte, appID int32) (int64, error) {
+	defer r.lock()()
+
+	if _, ok := r.st.findByEmail(email); ok {
+		return 0, storage.ErrUserAlreadyExists
+	}
+	if r.st.usernameTaken(username, 0) {
+		return 0, storage.ErrUsernameTaken
+	}
+	if _, ok := r.st.apps[appID]; !ok {
+		return 0, storage.ErrAppNotFound
+	}
+
+	id := r.st.nextID()
+	r.st.users[id] = user{User: models.User{
+		ID:       id,
+		Email:    email,
+		Username: username,
+		PassHash: bytes.Clone(passHash),
+		Status:   models.AccountStatusActive,
+	}}
+	r.st.appMembers[membership{userID: id, appID: appID}] = struct{}{}
+
+	return id, nil
+}
+
+func (r *MemoryRepo) UserByEmail(ctx context.Context, email string) (*models.User, error) {
+	defer r.lock()()
+
+	u, ok := r.st.findByEmail(email)
+	if !ok {
+		return nil, storage.ErrUserNotFound
+	}
+
+	return &u.User, nil
+}
+
+func (r *MemoryRepo) UserByID(ctx context.Context, id int64) (*models.User, error) {
+	defer r.lock()()
+
+	u, ok := r.st.users[id]
+	if !ok {
+		return nil, storage.ErrUserNotFound
+	}
+
+	return &u.User, nil
+}
+
+func (r *MemoryRepo) UserIDByEmail(ctx context.Context, email string) (int64, error) {
+	defer r.lock()()
+
+	u, ok := r.st.findByEmail(email)
+	if !ok || u.DeletedAt != nil {
+		return 0, storage.ErrUserNotFound
+	}
+
+	return u.ID, nil
+}
+
+func (r *MemoryRepo) CheckIfUserVerified(ctx context.Context, email string) (int64, bool, error) {
+	defer r.lock()()
+
+	u, ok := r.st.findByEmail(email)
+	if !ok || u.DeletedAt != nil {
+		return 0, false, storage.ErrUserNotFound
+	}
+
+	return u.ID, u.IsVerified, nil
+}
+
+func (r *MemoryRepo) SetEmailVerified(ctx context.Context, userID int64) error {
+	defer r.lock()()
+
+	u, ok := r.st.activeUser(userID)
+	if !ok {
+		return storage.ErrUserNotFound
+	}
+
+	u.IsVerified = true
+	r.st.users[userID] = u
+
+	return nil
+}
+
+func (r *MemoryRepo) UpdateUsername(ctx context.Context, userID int64, username string) error {
+	defer r.lock()()
+
+	u, ok := r.st.activeUser(userID)
+	if !ok {
+		return storage.ErrUserNotFound
+	}
+	if r.st.usernameTaken(username, userID) {
+		return storage.ErrUsernameTaken
+	}
+
+	u.Username = username
+	r.st.users[userID] = u
+
+	return nil
+}
+
+// RehashPassword не перезаписывает пароль, сменённый конкурентно.
+func (r *MemoryRepo) RehashPassword(ctx context.Context, userID int64, oldHash, newHash []byte) error {
+	defer r.lock()()
+
+	u, ok := r.st.activeUser(userID)
+	if !ok || !bytes.Equal(u.PassHash, oldHash) {
+		return nil
+	}
+
+	u.PassHash = bytes.Clone(newHash)
+	r.st.users[userID] = u
+
+	return nil
+}
+
+func (r *MemoryRepo) ChangeEmail(ctx context.Context, userID int64, oldEmail, newEmail string) error {
+	defer r.lock()()
+
+	u, ok := r.st.activeUser(userID)
+	if !ok || !strings.EqualFold(u.Email, oldEmail) {
+		return storage.ErrUserNotFound
+	}
+	if other, ok := r.st.findByEmail(newEmail); ok && other.ID != userID {
+		return storage.ErrUserAlreadyExists
+	}
+
+	u.Email = newEmail
+	u.IsVerified = false
+	r.st.users[userID] = u
+
+	return nil
+}
+
+func (r *MemoryRepo) SetMustResetPassword(ctx context.Context, userID int64, mustReset bool) error {
+	defer r.lock()()
+
+	u, ok := r.st.activeUser(userID)
+	if !ok {
+		return storage.ErrUserNotFound
+	}
+
+	u.MustResetPassword = mustReset
+	r.st.users[userID] = u
+
+	return nil
+}
+
+// SetUserStatus переводит аккаунт из from в to; статус, уже изменённый
+// другим вызовом, — storage.ErrUserStatusConflict.
+func (r *MemoryRepo) SetUserStatus(
+	ctx context.Context,
+	userID int64,
+	from, to models.AccountStatus,
+	reason, actor string,
+) error {
+	defer r.lock()()
+
+	u, ok := r.st.users[userID]
+	if !ok || u.Status != from {
+		return storage.ErrUserStatusConflict
+	}
+
+	u.Status = to
+	u.StatusReason = nil
+	if reason != "" {
+		u.StatusReason = &reason
+	}
+	r.st.users[userID] = u
+
+	return nil
+}
+
+func (r *MemoryRepo) DeleteAccount(ctx context.Context, userID int64) error {
+	defer r.lock()()
+
+	u, ok := r.st.users[userID]
+	if !ok {
+		return storage.ErrUserNotFound
+	}
+	if u.DeletedAt != nil {
+		return storage.ErrUserAlreadyDeleted
+	}
+
+	now := time.Now()
+	u.DeletedAt = &now
+	u.Status = models.AccountStatusPendingDeletion
+	u.StatusReason = nil
+	r.st.users[userID] = u
+
+	r.st.revokeCredentials(userID, now)
+
+	return nil
+}
+
+// RestoreAccount снимает soft-delete, если grace period ещё не истёк.
+func (r *MemoryRepo) RestoreAccount(ctx context.Context, userID int64, gracePeriod time.Duration) error {
+	defer r.lock()()
+
+	u, ok := r.st.users[userID]
+	if !ok {
+		return storage.ErrUserNotFound
+	}
+	if u.DeletedAt == nil {
+		return storage.ErrNothingToRestore
+	}
+	if u.DeletedAt.Before(time.Now().Add(-gracePeriod)) {
+		return storage.ErrRestoreWindowExpired
+	}
+
+	u.DeletedAt = nil
+	u.Status = models.AccountStatusActive
+	r.st.users[userID] = u
+
+	return nil
+}
+
+// revokeCredentials — общая часть удаления аккаунта и сброса пароля:
+// refresh- и reset-токены удаляются, активные magic links гасятся.
+func (s *state) revokeCredentials(userID int64, now time.Time) {
+	for id, rt := range s.refreshTokens {
+		if rt.UserID == userID {
+			delete(s.refreshTokens, id)
+		}
+	}
+	for id, rt := range s.resetTokens {
+		if rt.UserID == userID {
+			delete(s.resetTokens, id)
+		}
+	}
+	s.invalidateMagicLinks(userID, now)
+}
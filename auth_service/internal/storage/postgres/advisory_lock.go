@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"auth_service/internal/storage"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AdvisoryLock — сессионный pg_advisory_lock, удерживаемый на выделенном
+// соединении из пула. Если процесс умирает или соединение рвётся, Postgres
+// снимает блокировку сам — поэтому на нём можно строить выбор лидера.
+type AdvisoryLock struct {
+	conn *pgxpool.Conn
+	key  int64
+}
+
+// TryAdvisoryLock пытается взять блокировку без ожидания. ok == false —
+// блокировку держит другая реплика.
+func (r *PostgresRepo) TryAdvisoryLock(ctx context.Context, key int64) (lock storage.Lock, ok bool, err error) {
+	const op = "storage.postgres.TryAdvisoryLock"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&ok); err != nil {
+		conn.Release()
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !ok {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	return &AdvisoryLock{conn: conn, key: key}, true, nil
+}
+
+// Alive проверяет, что соединение, на котором держится блокировка, живо.
+func (l *AdvisoryLock) Alive(ctx context.Context) error {
+	const op = "storage.postgres.AdvisoryLock.Alive"
+
+	if err := l.conn.Ping(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Release снимает блокировку и возвращает соединение в пул. Если unlock не
+// прошёл, соединение закрывается — иначе блокировка уехала бы в пул вместе
+// с ним и висела до его пересоздания.
+func (l *AdvisoryLock) Release(ctx context.Context) error {
+	const op = "storage.postgres.AdvisoryLock.Release"
+
+	if _, err := l.conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, l.key); err != nil {
+		_ = l.conn.Conn().Close(ctx)
+		l.conn.Release()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	l.conn.Release()
+
+	return nil
+}
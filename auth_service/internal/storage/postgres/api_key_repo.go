@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// * SaveAPIKey сохраняет новый ключ; created_at заполняется из БД.
+func (r *PostgresRepo) SaveAPIKey(ctx context.Context, key *models.APIKey) error {
+	const op = "storage.postgres.SaveAPIKey"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		INSERT INTO api_keys (id, app_id, name, key_hash, scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at
+	`
+
+	scopes := key.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	err := r.db.QueryRow(ctx, query,
+		key.ID, key.AppID, key.Name, key.KeyHash, scopes, key.CreatedBy, key.ExpiresAt,
+	).Scan(&key.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return storage.ErrAppNotFound
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// * APIKeysByAppID — ключи приложения, включая отозванные, новые первыми.
+func (r *PostgresRepo) APIKeysByAppID(ctx context.Context, appID int32) ([]models.APIKey, error) {
+	const op = "storage.postgres.APIKeysByAppID"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT id, app_id, name, key_hash, scopes, created_by, created_at, expires_at, last_used_at, revoked_at
+		FROM api_keys
+		WHERE app_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	keys, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.APIKey])
+	if err != nil {
+		return nil, fmt.Errorf("%s: collect: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// * APIKeyByID ищет ключ по id из его открытой части.
+func (r *PostgresRepo) APIKeyByID(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	const op = "storage.postgres.APIKeyByID"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT id, app_id, name, key_hash, scopes, created_by, created_at, expires_at, last_used_at, revoked_at
+		FROM api_keys
+		WHERE id = $1
+	`
+
+	var key models.APIKey
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&key.ID, &key.AppID, &key.Name, &key.KeyHash, &key.Scopes, &key.CreatedBy,
+		&key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrAPIKeyNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &key, nil
+}
+
+// * RevokeAPIKey отзывает действующий ключ приложения. Уже отозванный или
+// чужой ключ — ErrAPIKeyNotFound.
+func (r *PostgresRepo) RevokeAPIKey(ctx context.Context, appID int32, id uuid.UUID) error {
+	const op = "storage.postgres.RevokeAPIKey"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		UPDATE api_keys
+		SET revoked_at = NOW()
+		WHERE id = $1 AND app_id = $2 AND revoked_at IS NULL
+	`
+
+	tag, err := r.db.Exec(ctx, query, id, appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storage.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// * TouchAPIKey отмечает использование ключа. Обновление не чаще раза в
+// минуту: иначе каждый запрос batch-задачи писал бы в одну строку.
+func (r *PostgresRepo) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	const op = "storage.postgres.TouchAPIKey"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		UPDATE api_keys
+		SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`
+
+	if _, err := r.db.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -9,20 +9,40 @@ import (
 	"auth_service/internal/storage"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 func (r *PostgresRepo) App(ctx context.Context, appID int32) (*models.App, error) {
 	const op = "storage.postgres.App"
 
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
 	query := `
-		SELECT id, name, secret
+		SELECT id, name, secret, access_token_format, signing_alg, redirect_uris, public_client, allowed_scopes, refresh_token_delivery, token_exchange_audiences,
+			COALESCE(access_token_ttl, INTERVAL '0'), COALESCE(refresh_token_ttl, INTERVAL '0')
 		FROM apps
 		WHERE id = $1;
 	`
 
 	var a models.App
 
-	err := r.pool.QueryRow(ctx, query, appID).Scan(&a.ID, &a.Name, &a.Secret)
+	err := r.readReplica(ctx, func(q querier) error {
+		return q.QueryRow(ctx, query, appID).Scan(
+			&a.ID,
+			&a.Name,
+			&a.Secret,
+			&a.AccessTokenFormat,
+			&a.SigningAlg,
+			&a.RedirectURIs,
+			&a.PublicClient,
+			&a.AllowedScopes,
+			&a.RefreshTokenDelivery,
+			&a.TokenExchangeAudiences,
+			&a.AccessTokenTTL,
+			&a.RefreshTokenTTL,
+		)
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, storage.ErrAppNotFound
@@ -34,13 +54,61 @@ func (r *PostgresRepo) App(ctx context.Context, appID int32) (*models.App, error
 	return &a, nil
 }
 
+// CreateApp регистрирует приложение; остальные настройки берутся из
+// значений колонок по умолчанию. publicClient — OAuth-клиент без секрета.
+func (r *PostgresRepo) CreateApp(ctx context.Context, name, secret string, publicClient bool) (int32, error) {
+	const op = "storage.postgres.CreateApp"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `INSERT INTO apps (name, secret, public_client) VALUES ($1, $2, $3) RETURNING id`
+
+	var id int32
+	err := r.db.QueryRow(ctx, query, name, secret, publicClient).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return 0, storage.ErrAppAlreadyExists
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// SetAppSecret заменяет секрет приложения. HS256-токены, подписанные
+// старым секретом, после этого не проходят проверку.
+func (r *PostgresRepo) SetAppSecret(ctx context.Context, appID int32, secret string) error {
+	const op = "storage.postgres.SetAppSecret"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `UPDATE apps SET secret = $2 WHERE id = $1`
+
+	tag, err := r.db.Exec(ctx, query, appID, secret)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrAppNotFound
+	}
+
+	return nil
+}
+
 func (r *PostgresRepo) AppSecret(ctx context.Context, appID int32) (string, error) {
 	const op = "storage.postgres.AppSecret"
 
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
 	query := `SELECT secret FROM apps WHERE id = $1`
 
 	var secret string
-	err := r.pool.QueryRow(ctx, query, appID).Scan(&secret)
+	err := r.db.QueryRow(ctx, query, appID).Scan(&secret)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return "", storage.ErrAppNotFound
@@ -51,3 +119,25 @@ func (r *PostgresRepo) AppSecret(ctx context.Context, appID int32) (string, erro
 
 	return secret, nil
 }
+
+// IsAppMember проверяет, зарегистрирован ли пользователь в приложении.
+func (r *PostgresRepo) IsAppMember(ctx context.Context, userID int64, appID int32) (bool, error) {
+	const op = "storage.postgres.IsAppMember"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM app_members
+			WHERE user_id = $1 AND app_id = $2
+		);
+	`
+
+	var isMember bool
+	if err := r.db.QueryRow(ctx, query, userID, appID).Scan(&isMember); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return isMember, nil
+}
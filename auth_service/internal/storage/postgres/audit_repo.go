@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"auth_service/internal/models"
+)
+
+// SaveAuditEvent пишет событие в audit_events. Пустые IP и User-Agent
+// сохраняются как NULL.
+func (r *PostgresRepo) SaveAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	const op = "storage.postgres.SaveAuditEvent"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	metadata := event.Metadata
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("%s: marshal metadata: %w", op, err)
+	}
+
+	query := `
+		INSERT INTO audit_events (user_id, actor, action, ip, user_agent, metadata)
+		VALUES ($1, $2, $3, NULLIF($4, '')::inet, NULLIF($5, ''), $6)
+		RETURNING id, created_at
+	`
+
+	err = r.db.QueryRow(ctx, query,
+		event.UserID,
+		event.Actor,
+		string(event.Action),
+		event.IP,
+		event.UserAgent,
+		metadataJSON,
+	).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// AuditEventsByUserID возвращает события пользователя с заданными action,
+// от новых к старым. beforeID > 0 — keyset-пагинация: только события с
+// id меньше beforeID.
+func (r *PostgresRepo) AuditEventsByUserID(
+	ctx context.Context,
+	userID int64,
+	actions []models.AuditAction,
+	beforeID int64,
+	limit int,
+) ([]models.AuditEvent, error) {
+	const op = "storage.postgres.AuditEventsByUserID"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	actionNames := make([]string, 0, len(actions))
+	for _, a := range actions {
+		actionNames = append(actionNames, string(a))
+	}
+
+	query := `
+		SELECT id, user_id, actor, action, COALESCE(host(ip), ''), COALESCE(user_agent, ''), metadata, created_at
+		FROM audit_events
+		WHERE user_id = $1
+			AND action = ANY($2)
+			AND ($3 = 0 OR id < $3)
+		ORDER BY id DESC
+		LIMIT $4;
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, actionNames, beforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var events []models.AuditEvent
+	for rows.Next() {
+		var (
+			e            models.AuditEvent
+			action       string
+			metadataJSON []byte
+		)
+
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Actor, &action, &e.IP, &e.UserAgent, &metadataJSON, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+
+		e.Action = models.AuditAction(action)
+
+		if err := json.Unmarshal(metadataJSON, &e.Metadata); err != nil {
+			return nil, fmt.Errorf("%s: unmarshal metadata: %w", op, err)
+		}
+
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows: %w", op, err)
+	}
+
+	return events, nil
+}
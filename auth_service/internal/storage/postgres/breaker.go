@@ -0,0 +1,209 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	sl "auth_service/internal/lib/logger"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrCircuitOpen — primary подряд не отвечал вовремя, запрос отклонён без
+// обращения к пулу.
+var ErrCircuitOpen = errors.New("postgres circuit breaker is open")
+
+// breaker — circuit breaker перед пулом primary. Когда база тормозит,
+// запросы висят до таймаута и держат соединения, а остальные хендлеры
+// выстраиваются в очередь на пул. После threshold подряд отказов breaker
+// на cooldown отклоняет запросы сразу, затем пропускает один пробный:
+// успешный закрывает breaker, неудачный открывает снова.
+type breaker struct {
+	log       *slog.Logger
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time // нулевое — breaker закрыт
+}
+
+func newBreaker(log *slog.Logger, threshold int, cooldown time.Duration) *breaker {
+	return &breaker{
+		log:       log,
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// allow решает, пропустить ли запрос. Пока идёт пробный запрос, остальные
+// отклоняются; если проба не отчиталась за cooldown, пропускается следующая.
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openedAt.IsZero() {
+		return nil
+	}
+
+	if time.Since(b.openedAt) < b.cooldown {
+		return ErrCircuitOpen
+	}
+
+	// пробный запрос сдвигает отсчёт: следующие ждут его исхода
+	b.openedAt = time.Now()
+
+	return nil
+}
+
+// done учитывает исход пропущенного запроса.
+func (b *breaker) done(err error) {
+	failed := isUnavailable(err)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		if !b.openedAt.IsZero() {
+			b.log.Info("postgres circuit breaker closed")
+		}
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return
+	}
+
+	b.failures++
+	if b.failures < b.threshold {
+		return
+	}
+
+	if b.openedAt.IsZero() {
+		b.log.Warn("postgres circuit breaker opened, queries are rejected",
+			slog.Int("failures", b.failures),
+			slog.Duration("cooldown", b.cooldown),
+			sl.Err(err),
+		)
+	}
+	b.openedAt = time.Now()
+}
+
+// isUnavailable — запрос упал потому, что база не справляется или
+// недоступна: истёк таймаут (клиентский или statement_timeout), соединение
+// не установлено или оборвалось, сервер отказал в соединении. Отмена
+// контекста вызывающим и ошибки самого запроса отказом не считаются.
+func isUnavailable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if pgconn.Timeout(err) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// 57014 — query_canceled (в том числе statement_timeout),
+		// 53xxx — нехватка ресурсов сервера, 57P0x — сервер останавливается
+		return pgErr.Code == "57014" ||
+			strings.HasPrefix(pgErr.Code, "53") ||
+			strings.HasPrefix(pgErr.Code, "57P0")
+	}
+
+	return isConnError(err)
+}
+
+// breakerQuerier пропускает запросы к пулу через breaker. Транзакция
+// проходит его один раз, на Begin: запросы внутри неё идут через pgx.Tx
+// напрямую.
+type breakerQuerier struct {
+	q       *pgxpool.Pool
+	breaker *breaker
+}
+
+func (b *breakerQuerier) Begin(ctx context.Context) (pgx.Tx, error) {
+	if err := b.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	tx, err := b.q.Begin(ctx)
+	b.breaker.done(err)
+
+	return tx, err
+}
+
+func (b *breakerQuerier) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	if err := b.breaker.allow(); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+
+	tag, err := b.q.Exec(ctx, sql, arguments...)
+	b.breaker.done(err)
+
+	return tag, err
+}
+
+func (b *breakerQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if err := b.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	rows, err := b.q.Query(ctx, sql, args...)
+	if err != nil {
+		b.breaker.done(err)
+		return nil, err
+	}
+
+	return &breakerRows{Rows: rows, breaker: b.breaker}, nil
+}
+
+func (b *breakerQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if err := b.breaker.allow(); err != nil {
+		return errRow{err: err}
+	}
+
+	return breakerRow{row: b.q.QueryRow(ctx, sql, args...), breaker: b.breaker}
+}
+
+// breakerRow — исход QueryRow известен только после Scan.
+type breakerRow struct {
+	row     pgx.Row
+	breaker *breaker
+}
+
+func (r breakerRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	r.breaker.done(err)
+
+	return err
+}
+
+// breakerRows — таймаут может прийти и во время чтения строк, поэтому
+// исход Query учитывается при первом Close.
+type breakerRows struct {
+	pgx.Rows
+	breaker *breaker
+	closed  bool
+}
+
+func (r *breakerRows) Close() {
+	r.Rows.Close()
+
+	if !r.closed {
+		r.closed = true
+		r.breaker.done(r.Rows.Err())
+	}
+}
+
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(...any) error {
+	return r.err
+}
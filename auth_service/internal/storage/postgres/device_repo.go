@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"auth_service/internal/models"
+)
+
+// * TouchKnownDevice запоминает устройство пользователя или обновляет время
+// последнего входа с него. inserted — устройство встречено впервые,
+// hadDevices — до этого у пользователя были другие известные устройства.
+func (r *PostgresRepo) TouchKnownDevice(
+	ctx context.Context,
+	userID int64,
+	fp models.Fingerprint,
+) (inserted, hadDevices bool, err error) {
+	const op = "storage.postgres.TouchKnownDevice"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	// xmax = 0 только у строки, вставленной этим запросом: при ON CONFLICT
+	// DO UPDATE в xmax попадает id обновившей транзакции
+	query := `
+		WITH prior AS (
+			SELECT COUNT(*) AS n FROM known_devices WHERE user_id = $1
+		), upsert AS (
+			INSERT INTO known_devices (user_id, fingerprint, ip, user_agent)
+			VALUES ($1, $2, NULLIF($3, '')::inet, NULLIF($4, ''))
+			ON CONFLICT (user_id, fingerprint) DO UPDATE
+			SET ip = EXCLUDED.ip,
+				user_agent = EXCLUDED.user_agent,
+				last_seen_at = NOW()
+			RETURNING (xmax = 0) AS inserted
+		)
+		SELECT upsert.inserted, prior.n > 0 FROM upsert, prior
+	`
+
+	err = r.db.QueryRow(ctx, query, userID, fp.Hash, fp.IP, fp.UserAgent).Scan(&inserted, &hadDevices)
+	if err != nil {
+		return false, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return inserted, hadDevices, nil
+}
+
+// * KnownDevice сообщает, входил ли пользователь с устройства раньше.
+// hasDevices == false — известных устройств нет вовсе (первый вход или
+// аккаунт, созданный до учёта устройств).
+func (r *PostgresRepo) KnownDevice(ctx context.Context, userID int64, fingerprint []byte) (known, hasDevices bool, err error) {
+	const op = "storage.postgres.KnownDevice"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT COALESCE(BOOL_OR(fingerprint = $2), FALSE), COUNT(*) > 0
+		FROM known_devices
+		WHERE user_id = $1
+	`
+
+	if err := r.db.QueryRow(ctx, query, userID, fingerprint).Scan(&known, &hasDevices); err != nil {
+		return false, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return known, hasDevices, nil
+}
@@ -0,0 +1,264 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// * SaveIdentity привязывает внешнюю учётку к существующему пользователю.
+func (r *PostgresRepo) SaveIdentity(ctx context.Context, identity *models.Identity) error {
+	const op = "storage.postgres.SaveIdentity"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		INSERT INTO identities (user_id, kind, provider, subject, email)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := r.db.Exec(ctx, query, identity.UserID, identity.Kind, identity.Provider, identity.Subject, identity.Email)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, identityInsertError(err))
+	}
+
+	return nil
+}
+
+// * IdentityBySubject — основной lookup при входе через внешний провайдер.
+func (r *PostgresRepo) IdentityBySubject(
+	ctx context.Context,
+	provider string,
+	subject string,
+) (*models.Identity, error) {
+	const op = "storage.postgres.IdentityBySubject"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, kind, provider, subject, email, created_at
+		FROM identities
+		WHERE provider = $1 AND subject = $2
+	`
+
+	var i models.Identity
+	err := r.db.QueryRow(ctx, query, provider, subject).Scan(
+		&i.ID, &i.UserID, &i.Kind, &i.Provider, &i.Subject, &i.Email, &i.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrIdentityNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &i, nil
+}
+
+// * IdentitiesByUserID — список привязанных учёток, для профиля/настроек.
+func (r *PostgresRepo) IdentitiesByUserID(ctx context.Context, userID int64) ([]*models.Identity, error) {
+	const op = "storage.postgres.IdentitiesByUserID"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, kind, provider, subject, email, created_at
+		FROM identities
+		WHERE user_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	identities, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[models.Identity])
+	if err != nil {
+		return nil, fmt.Errorf("%s: collect: %w", op, err)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return identities, nil
+}
+
+// * HasIdentities проверяет, есть ли у пользователя хотя бы одна привязанная внешняя учётка.
+func (r *PostgresRepo) HasIdentities(ctx context.Context, userID int64) (bool, error) {
+	const op = "storage.postgres.HasIdentities"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	var exists bool
+
+	query := `SELECT EXISTS(SELECT 1 FROM identities WHERE user_id = $1)`
+
+	if err := r.db.QueryRow(ctx, query, userID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return exists, nil
+}
+
+// * UnlinkIdentity отвязывает provider от юзера.
+func (r *PostgresRepo) UnlinkIdentity(ctx context.Context, userID int64, provider string) error {
+	const op = "storage.postgres.UnlinkIdentity"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.log.Error("rollback failed", sl.Err(rbErr))
+		}
+	}()
+
+	// Блокируем строку юзера — вторая параллельная транзакция на unlink
+	// этого же userID будет ждать здесь, пока первая не закоммитится.
+	// Это и закрывает гонку: без FOR UPDATE обе транзакции могли бы
+	// прочитать "ещё есть привязки" параллельно и обе пройти проверку.
+	var hasPassword bool
+	const lockUserQuery = `
+		SELECT password_hash IS NOT NULL
+		FROM users
+		WHERE id = $1
+		FOR UPDATE
+	`
+	if err := tx.QueryRow(ctx, lockUserQuery, userID).Scan(&hasPassword); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.ErrUserNotFound
+		}
+		return fmt.Errorf("%s: lock user: %w", op, err)
+	}
+
+	if !hasPassword {
+		var remaining int
+		const countQuery = `
+			SELECT COUNT(*)
+			FROM identities
+			WHERE user_id = $1 AND provider != $2
+		`
+		if err := tx.QueryRow(ctx, countQuery, userID, provider).Scan(&remaining); err != nil {
+			return fmt.Errorf("%s: count accounts: %w", op, err)
+		}
+		if remaining == 0 {
+			return storage.ErrLastAuthMethod
+		}
+	}
+
+	const deleteQuery = `
+		DELETE FROM identities
+		WHERE user_id = $1 AND provider = $2
+	`
+	res, err := tx.Exec(ctx, deleteQuery, userID, provider)
+	if err != nil {
+		return fmt.Errorf("%s: delete: %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return storage.ErrIdentityNotFound
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return nil
+}
+
+// * SaveUserWithIdentity регистрирует через внешний провайдер юзера, у
+// которого ещё нет аккаунта. Email берётся из identity и считается
+// подтверждённым провайдером.
+func (r *PostgresRepo) SaveUserWithIdentity(
+	ctx context.Context,
+	username string,
+	identity *models.Identity,
+	appID int32,
+) (int64, error) {
+	const op = "storage.postgres.SaveUserWithIdentity"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.log.Error("rollback failed", sl.Err(rbErr))
+		}
+	}()
+
+	insertUser := `
+		INSERT INTO users (email, username, password_hash, is_verified)
+		VALUES ($1, $2, NULL, TRUE)
+		RETURNING id
+	`
+
+	var userID int64
+	if err := tx.QueryRow(ctx, insertUser, identity.Email, username).Scan(&userID); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return 0, storage.ErrUserAlreadyExists
+		}
+
+		return 0, fmt.Errorf("%s: insert user: %w", op, err)
+	}
+
+	insertIdentity := `
+		INSERT INTO identities (user_id, kind, provider, subject, email)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err = tx.Exec(ctx, insertIdentity, userID, identity.Kind, identity.Provider, identity.Subject, identity.Email)
+	if err != nil {
+		return 0, fmt.Errorf("%s: insert identity: %w", op, identityInsertError(err))
+	}
+
+	insertMember := `
+		INSERT INTO app_members (user_id, app_id)
+		VALUES ($1, $2)
+	`
+	if _, err := tx.Exec(ctx, insertMember, userID, appID); err != nil {
+		return 0, fmt.Errorf("%s: insert app member: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+// identityInsertError переводит нарушения уникальности identities в
+// ошибки storage.
+func identityInsertError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		switch pgErr.ConstraintName {
+		case "uq_identities_provider_subject":
+			return storage.ErrIdentityAlreadyLinked
+		case "uq_identities_user_provider":
+			return storage.ErrProviderAlreadyLinked
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// * SaveIPRule сохраняет правило; id и created_at заполняются из БД. На
+// одну сеть — одно правило, повтор — ErrIPRuleExists.
+func (r *PostgresRepo) SaveIPRule(ctx context.Context, rule *models.IPRule) error {
+	const op = "storage.postgres.SaveIPRule"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		INSERT INTO ip_rules (cidr, action, reason, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		rule.Prefix, rule.Action, rule.Reason, rule.CreatedBy, rule.ExpiresAt,
+	).Scan(&rule.ID, &rule.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return storage.ErrIPRuleExists
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// * BlockIPTemporarily ставит временный deny на сеть, если для неё нет
+// правила. Истёкшее правило заменяется; действующее (allow или
+// бессрочный deny) не трогается — тогда false.
+func (r *PostgresRepo) BlockIPTemporarily(
+	ctx context.Context,
+	prefix netip.Prefix,
+	reason, createdBy string,
+	expiresAt time.Time,
+) (bool, error) {
+	const op = "storage.postgres.BlockIPTemporarily"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		INSERT INTO ip_rules (cidr, action, reason, created_by, expires_at)
+		VALUES ($1, 'deny', $2, $3, $4)
+		ON CONFLICT (cidr) DO UPDATE
+		SET action = 'deny', reason = EXCLUDED.reason, created_by = EXCLUDED.created_by,
+			created_at = NOW(), expires_at = EXCLUDED.expires_at
+		WHERE ip_rules.expires_at IS NOT NULL AND ip_rules.expires_at <= NOW()
+	`
+
+	tag, err := r.db.Exec(ctx, query, prefix, reason, createdBy, expiresAt)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// * ActiveIPRules — правила без срока или с ещё не наступившим, самые
+// узкие сети первыми.
+func (r *PostgresRepo) ActiveIPRules(ctx context.Context) ([]models.IPRule, error) {
+	const op = "storage.postgres.ActiveIPRules"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT id, cidr, action, reason, created_by, created_at, expires_at
+		FROM ip_rules
+		WHERE expires_at IS NULL OR expires_at > NOW()
+		ORDER BY masklen(cidr) DESC, id
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rules, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.IPRule, error) {
+		var rule models.IPRule
+		err := row.Scan(
+			&rule.ID, &rule.Prefix, &rule.Action, &rule.Reason,
+			&rule.CreatedBy, &rule.CreatedAt, &rule.ExpiresAt,
+		)
+		return rule, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: collect: %w", op, err)
+	}
+
+	return rules, nil
+}
+
+// * DeleteIPRule удаляет правило по id.
+func (r *PostgresRepo) DeleteIPRule(ctx context.Context, id int64) error {
+	const op = "storage.postgres.DeleteIPRule"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	tag, err := r.db.Exec(ctx, `DELETE FROM ip_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storage.ErrIPRuleNotFound
+	}
+
+	return nil
+}
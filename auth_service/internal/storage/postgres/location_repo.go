@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// * LastLoginLocation возвращает место последнего входа пользователя.
+func (r *PostgresRepo) LastLoginLocation(ctx context.Context, userID int64) (*models.GeoLocation, error) {
+	const op = "storage.postgres.LastLoginLocation"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT host(ip), country, city, latitude, longitude, logged_in_at
+		FROM login_locations
+		WHERE user_id = $1
+	`
+
+	var loc models.GeoLocation
+
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&loc.IP,
+		&loc.Country,
+		&loc.City,
+		&loc.Latitude,
+		&loc.Longitude,
+		&loc.SeenAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrLoginLocationNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &loc, nil
+}
+
+// * SaveLoginLocation запоминает место входа вместо предыдущего.
+func (r *PostgresRepo) SaveLoginLocation(ctx context.Context, userID int64, loc models.GeoLocation) error {
+	const op = "storage.postgres.SaveLoginLocation"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		INSERT INTO login_locations (user_id, ip, country, city, latitude, longitude, logged_in_at)
+		VALUES ($1, $2::inet, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id) DO UPDATE
+		SET ip = EXCLUDED.ip,
+			country = EXCLUDED.country,
+			city = EXCLUDED.city,
+			latitude = EXCLUDED.latitude,
+			longitude = EXCLUDED.longitude,
+			logged_in_at = EXCLUDED.logged_in_at
+	`
+
+	_, err := r.db.Exec(ctx, query, userID, loc.IP, loc.Country, loc.City, loc.Latitude, loc.Longitude, loc.SeenAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
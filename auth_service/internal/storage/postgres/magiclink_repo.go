@@ -15,18 +15,23 @@ import (
 func (r *PostgresRepo) SaveMagicLink(ctx context.Context, link *models.MagicLink) error {
 	const op = "storage.postgres.SaveMagicLink"
 
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
 	query := `
 		INSERT INTO magic_links (
 			user_id, 
 			app_id, 
 			token_hash, 
 			session_id, 
-			expires_at
-		) VALUES ($1, $2, $3, $4, $5)
+			expires_at,
+			ip_address,
+			user_agent
+		) VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::inet, NULLIF($7, ''))
 		RETURNING id, created_at
 	`
 
-	err := r.pool.QueryRow(
+	err := r.db.QueryRow(
 		ctx,
 		query,
 		link.UserID,
@@ -34,6 +39,8 @@ func (r *PostgresRepo) SaveMagicLink(ctx context.Context, link *models.MagicLink
 		link.TokenHash,
 		link.SessionID,
 		link.ExpiresAt,
+		link.IPAddress,
+		link.UserAgent,
 	).Scan(&link.ID, &link.CreatedAt)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
@@ -46,6 +53,9 @@ func (r *PostgresRepo) SaveMagicLink(ctx context.Context, link *models.MagicLink
 func (r *PostgresRepo) ConsumeMagicLink(ctx context.Context, tokenHash []byte) (*models.MagicLink, error) {
 	const op = "storage.postgres.ConsumeMagicLink"
 
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
 	query := `
 		UPDATE magic_links
 		SET used_at = NOW()
@@ -57,7 +67,7 @@ func (r *PostgresRepo) ConsumeMagicLink(ctx context.Context, tokenHash []byte) (
 
 	link := &models.MagicLink{}
 
-	err := r.pool.QueryRow(ctx, query, tokenHash).Scan(
+	err := r.db.QueryRow(ctx, query, tokenHash).Scan(
 		&link.ID, &link.UserID, &link.AppID, &link.TokenHash, &link.SessionID,
 		&link.UsedAt, &link.ExpiresAt, &link.CreatedAt,
 	)
@@ -72,17 +82,59 @@ func (r *PostgresRepo) ConsumeMagicLink(ctx context.Context, tokenHash []byte) (
 	return link, nil
 }
 
+// * ActiveMagicLinksByUserID возвращает неиспользованные и неистёкшие
+// magic links пользователя, новые первыми.
+func (r *PostgresRepo) ActiveMagicLinksByUserID(ctx context.Context, userID int64) ([]models.MagicLink, error) {
+	const op = "storage.postgres.ActiveMagicLinksByUserID"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, app_id, session_id,
+			COALESCE(host(ip_address), ''), COALESCE(user_agent, ''), expires_at, created_at
+		FROM magic_links
+		WHERE user_id = $1 AND used_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	var links []models.MagicLink
+	for rows.Next() {
+		var l models.MagicLink
+		err := rows.Scan(&l.ID, &l.UserID, &l.AppID, &l.SessionID, &l.IPAddress, &l.UserAgent, &l.ExpiresAt, &l.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		links = append(links, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
+
 // * InvalidateMagicLinksByUserID инвалидирует все активные magic links пользователя
 func (r *PostgresRepo) InvalidateMagicLinksByUserID(ctx context.Context, userID int64) (int64, error) {
 	const op = "storage.postgres.InvalidateMagicLinksByUserID"
 
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
 	query := `
 		UPDATE magic_links
 		SET used_at = NOW()
 		WHERE user_id = $1 AND used_at IS NULL AND expires_at > NOW()
 	`
 
-	result, err := r.pool.Exec(ctx, query, userID)
+	result, err := r.db.Exec(ctx, query, userID)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
@@ -94,6 +146,9 @@ func (r *PostgresRepo) InvalidateMagicLinksByUserID(ctx context.Context, userID
 func (r *PostgresRepo) EnableMagicLink2FA(ctx context.Context, userID int64) error {
 	const op = "storage.postgres.EnableMagicLink2FA"
 
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
 	query := `
 		UPDATE users
 		SET is_2fa_enabled = TRUE,
@@ -102,7 +157,7 @@ func (r *PostgresRepo) EnableMagicLink2FA(ctx context.Context, userID int64) err
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	result, err := r.pool.Exec(ctx, query, userID)
+	result, err := r.db.Exec(ctx, query, userID)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -118,6 +173,9 @@ func (r *PostgresRepo) EnableMagicLink2FA(ctx context.Context, userID int64) err
 func (r *PostgresRepo) DisableMagicLink2FA(ctx context.Context, userID int64) error {
 	const op = "storage.postgres.DisableMagicLink2FA"
 
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
 	query := `
 		UPDATE users
 		SET is_2fa_enabled = FALSE,
@@ -126,7 +184,7 @@ func (r *PostgresRepo) DisableMagicLink2FA(ctx context.Context, userID int64) er
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	result, err := r.pool.Exec(ctx, query, userID)
+	result, err := r.db.Exec(ctx, query, userID)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -141,6 +199,9 @@ func (r *PostgresRepo) DisableMagicLink2FA(ctx context.Context, userID int64) er
 func (r *PostgresRepo) TwoFAStatus(ctx context.Context, userID int64) (*models.TwoFAStatus, error) {
 	const op = "storage.postgres.TwoFAStatus"
 
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
 	query := `
 		SELECT is_2fa_enabled, two_fa_method, (password_hash IS NOT NULL) AS has_password
 		FROM users
@@ -149,7 +210,7 @@ func (r *PostgresRepo) TwoFAStatus(ctx context.Context, userID int64) (*models.T
 
 	status := &models.TwoFAStatus{}
 
-	err := r.pool.QueryRow(ctx, query, userID).Scan(&status.IsEnabled, &status.Method, &status.HasPassword)
+	err := r.db.QueryRow(ctx, query, userID).Scan(&status.IsEnabled, &status.Method, &status.HasPassword)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, storage.ErrUserNotFound
@@ -165,10 +226,13 @@ func (r *PostgresRepo) TwoFAStatus(ctx context.Context, userID int64) (*models.T
 func (r *PostgresRepo) CleanupExpiredMagicLinks(ctx context.Context) (int, error) {
 	const op = "storage.postgres.CleanupExpiredMagicLinks"
 
+	ctx, cancel := r.queryCtx(ctx, op, queryCleanup)
+	defer cancel()
+
 	query := `SELECT cleanup_expired_magic_links()`
 
 	var deleted int
-	err := r.pool.QueryRow(ctx, query).Scan(&deleted)
+	err := r.db.QueryRow(ctx, query).Scan(&deleted)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
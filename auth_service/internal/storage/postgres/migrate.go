@@ -0,0 +1,258 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	sl "auth_service/internal/lib/logger"
+	"auth_service/migrations"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrSchemaOutdated — в БД применены не все миграции, встроенные в бинарник.
+var ErrSchemaOutdated = errors.New("database schema is outdated, run with --migrate")
+
+// migrationLockKey — advisory lock на время применения миграций: реплики,
+// запущенные с --migrate одновременно, применяют их по очереди.
+const migrationLockKey int64 = 727100000
+
+// Таблица версий совместима с goose: базы, которые мигрировались goose CLI,
+// подхватываются без ручных шагов, и goose CLI продолжает работать с ними.
+const versionTable = "goose_db_version"
+
+type migration struct {
+	version int64
+	name    string
+	up      string
+}
+
+// Migrate применяет невыполненные миграции по возрастанию версии. Каждая
+// миграция выполняется в своей транзакции вместе с записью версии.
+func (r *PostgresRepo) Migrate(ctx context.Context) error {
+	const op = "storage.postgres.Migrate"
+
+	all, err := loadMigrations()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer conn.Release()
+
+	// ожидание lock'а и тяжёлые миграции (построение индексов) не должны
+	// упираться в statement_timeout пула; RESET возвращает значение из
+	// параметров соединения, прежде чем оно уйдёт обратно в пул
+	if _, err := conn.Exec(ctx, `SET statement_timeout = 0`); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if _, err := conn.Exec(context.Background(), `RESET statement_timeout`); err != nil {
+			r.log.Error("failed to reset statement_timeout", sl.Err(err))
+			conn.Conn().Close(context.Background())
+		}
+	}()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("%s: lock: %w", op, err)
+	}
+	defer func() {
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+			r.log.Error("failed to release migration lock", sl.Err(err))
+		}
+	}()
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, m := range all {
+		if applied[m.version] {
+			continue
+		}
+
+		err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, m.up); err != nil {
+				return err
+			}
+
+			_, err := tx.Exec(ctx,
+				`INSERT INTO `+versionTable+` (version_id, is_applied) VALUES ($1, TRUE)`,
+				m.version,
+			)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("%s: apply %s: %w", op, m.name, err)
+		}
+
+		r.log.Info("migration applied", slog.Int64("version", m.version), slog.String("name", m.name))
+	}
+
+	return nil
+}
+
+// checkSchema сверяет применённые миграции со встроенными. Невыполненная
+// миграция — ErrSchemaOutdated: запросы упали бы на отсутствующих таблицах и
+// колонках. Версии из БД, неизвестные бинарнику, — норма при rolling
+// update, когда новая реплика уже мигрировала схему.
+func (r *PostgresRepo) checkSchema(ctx context.Context) error {
+	const op = "storage.postgres.checkSchema"
+
+	all, err := loadMigrations()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var exists bool
+	if err := r.pool.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, versionTable).Scan(&exists); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	applied := map[int64]bool{}
+	if exists {
+		if applied, err = appliedVersions(ctx, r.pool); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	var pending []string
+	for _, m := range all {
+		if !applied[m.version] {
+			pending = append(pending, m.name)
+		}
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("%s: %w: pending %s", op, ErrSchemaOutdated, strings.Join(pending, ", "))
+	}
+
+	return nil
+}
+
+func ensureVersionTable(ctx context.Context, conn querier) error {
+	var exists bool
+	if err := conn.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, versionTable).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	// нулевая версия — как у goose, иначе goose CLI считает таблицу пустой
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `
+			CREATE TABLE `+versionTable+` (
+				id SERIAL PRIMARY KEY,
+				version_id BIGINT NOT NULL,
+				is_applied BOOLEAN NOT NULL,
+				tstamp TIMESTAMP DEFAULT NOW()
+			)
+		`); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx, `INSERT INTO `+versionTable+` (version_id, is_applied) VALUES (0, TRUE)`)
+		return err
+	})
+}
+
+// appliedVersions — версии, последняя запись которых is_applied: откат
+// через goose CLI дописывает строку с is_applied = FALSE.
+func appliedVersions(ctx context.Context, conn querier) (map[int64]bool, error) {
+	rows, err := conn.Query(ctx, `SELECT version_id, is_applied FROM `+versionTable+` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[int64]bool{}
+
+	var (
+		version   int64
+		isApplied bool
+	)
+	_, err = pgx.ForEachRow(rows, []any{&version, &isApplied}, func() error {
+		applied[version] = isApplied
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// loadMigrations читает встроенные миграции
+// (migrations/<name>/<version>_<name>.sql) и берёт из них секцию Up.
+func loadMigrations() ([]migration, error) {
+	files, err := fs.Glob(migrations.FS, "*/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]migration, 0, len(files))
+
+	for _, file := range files {
+		name := strings.TrimSuffix(path.Base(file), ".sql")
+
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", file, err)
+		}
+
+		content, err := fs.ReadFile(migrations.FS, file)
+		if err != nil {
+			return nil, err
+		}
+
+		up, err := upSection(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", file, err)
+		}
+
+		result = append(result, migration{version: version, name: name, up: up})
+	}
+
+	slices.SortFunc(result, func(a, b migration) int {
+		return cmp.Compare(a.version, b.version)
+	})
+
+	for i := 1; i < len(result); i++ {
+		if result[i].version == result[i-1].version {
+			return nil, fmt.Errorf("duplicate migration version %d", result[i].version)
+		}
+	}
+
+	return result, nil
+}
+
+// upSection — текст между "-- +goose Up" и "-- +goose Down". Маркеры
+// StatementBegin/End — SQL-комментарии: секция уходит в Postgres одним
+// запросом по simple protocol, поэтому функции с ";" внутри не ломаются.
+func upSection(content string) (string, error) {
+	_, rest, ok := strings.Cut(content, "-- +goose Up")
+	if !ok {
+		return "", errors.New("missing -- +goose Up")
+	}
+
+	up, _, _ := strings.Cut(rest, "-- +goose Down")
+
+	return up, nil
+}
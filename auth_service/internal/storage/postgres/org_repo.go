@@ -0,0 +1,497 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// * CreateOrganization создаёт организацию и делает создателя её
+// владельцем в одной транзакции.
+func (r *PostgresRepo) CreateOrganization(ctx context.Context, org *models.Organization, ownerID int64) error {
+	const op = "storage.postgres.CreateOrganization"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.log.Error("rollback failed", sl.Err(rbErr))
+		}
+	}()
+
+	const insertOrgQuery = `
+		INSERT INTO organizations (app_id, name, created_by)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+
+	err = tx.QueryRow(ctx, insertOrgQuery, org.AppID, org.Name, ownerID).Scan(&org.ID, &org.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			if pgErr.ConstraintName == "fk_organizations_app" {
+				return storage.ErrAppNotFound
+			}
+			return storage.ErrUserNotFound
+		}
+
+		return fmt.Errorf("%s: insert organization: %w", op, err)
+	}
+
+	const insertOwnerQuery = `
+		INSERT INTO org_members (org_id, user_id, role)
+		VALUES ($1, $2, $3)
+	`
+
+	if _, err := tx.Exec(ctx, insertOwnerQuery, org.ID, ownerID, models.OrgRoleOwner); err != nil {
+		return fmt.Errorf("%s: insert owner: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return nil
+}
+
+// * OrganizationsByUserID — членства пользователя в организациях приложения.
+func (r *PostgresRepo) OrganizationsByUserID(ctx context.Context, userID int64, appID int32) ([]models.OrgMember, error) {
+	const op = "storage.postgres.OrganizationsByUserID"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT m.org_id, o.name, m.user_id, m.role, m.created_at
+		FROM org_members m
+		JOIN organizations o ON o.id = m.org_id
+		WHERE m.user_id = $1 AND o.app_id = $2
+		ORDER BY o.name, o.id
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var members []models.OrgMember
+	for rows.Next() {
+		var m models.OrgMember
+		if err := rows.Scan(&m.OrgID, &m.OrgName, &m.UserID, &m.Role, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return members, nil
+}
+
+// * OrgMember — членство пользователя в организации. appID != 0 —
+// организация должна принадлежать этому приложению: токены одного
+// приложения не дают доступа к тенантам другого.
+func (r *PostgresRepo) OrgMember(ctx context.Context, orgID, userID int64, appID int32) (*models.OrgMember, error) {
+	const op = "storage.postgres.OrgMember"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT m.org_id, o.name, m.user_id, u.email, m.role, m.created_at
+		FROM org_members m
+		JOIN organizations o ON o.id = m.org_id
+		JOIN users u ON u.id = m.user_id
+		WHERE m.org_id = $1 AND m.user_id = $2 AND ($3::BIGINT = 0 OR o.app_id = $3)
+	`
+
+	var m models.OrgMember
+	err := r.db.QueryRow(ctx, query, orgID, userID, appID).Scan(
+		&m.OrgID, &m.OrgName, &m.UserID, &m.Email, &m.Role, &m.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrOrgMemberNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &m, nil
+}
+
+// * OrgMembers — участники организации, владельцы первыми.
+func (r *PostgresRepo) OrgMembers(ctx context.Context, orgID int64) ([]models.OrgMember, error) {
+	const op = "storage.postgres.OrgMembers"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT m.org_id, o.name, m.user_id, u.email, m.role, m.created_at
+		FROM org_members m
+		JOIN organizations o ON o.id = m.org_id
+		JOIN users u ON u.id = m.user_id
+		WHERE m.org_id = $1
+		ORDER BY CASE m.role WHEN 'owner' THEN 0 WHEN 'admin' THEN 1 ELSE 2 END, m.created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var members []models.OrgMember
+	for rows.Next() {
+		var m models.OrgMember
+		if err := rows.Scan(&m.OrgID, &m.OrgName, &m.UserID, &m.Email, &m.Role, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return members, nil
+}
+
+// * SetOrgMemberRole меняет роль участника. Понизить последнего владельца
+// нельзя — организация осталась бы без управления.
+func (r *PostgresRepo) SetOrgMemberRole(ctx context.Context, orgID, userID int64, role models.OrgRole) error {
+	const op = "storage.postgres.SetOrgMemberRole"
+
+	return r.withOwnerGuard(ctx, op, orgID, userID, func(ctx context.Context, tx pgx.Tx) (int64, error) {
+		tag, err := tx.Exec(ctx, `UPDATE org_members SET role = $3 WHERE org_id = $1 AND user_id = $2`, orgID, userID, role)
+		return tag.RowsAffected(), err
+	}, role != models.OrgRoleOwner)
+}
+
+// * RemoveOrgMember исключает участника из организации. Последнего
+// владельца исключить нельзя.
+func (r *PostgresRepo) RemoveOrgMember(ctx context.Context, orgID, userID int64) error {
+	const op = "storage.postgres.RemoveOrgMember"
+
+	return r.withOwnerGuard(ctx, op, orgID, userID, func(ctx context.Context, tx pgx.Tx) (int64, error) {
+		tag, err := tx.Exec(ctx, `DELETE FROM org_members WHERE org_id = $1 AND user_id = $2`, orgID, userID)
+		return tag.RowsAffected(), err
+	}, true)
+}
+
+// withOwnerGuard выполняет изменение участника под блокировкой владельцев
+// организации. demotesOwner — изменение лишает участника роли owner; если
+// он последний владелец, изменение отклоняется.
+func (r *PostgresRepo) withOwnerGuard(
+	ctx context.Context,
+	op string,
+	orgID, userID int64,
+	change func(ctx context.Context, tx pgx.Tx) (int64, error),
+	demotesOwner bool,
+) error {
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.log.Error("rollback failed", sl.Err(rbErr))
+		}
+	}()
+
+	// Блокируем владельцев — два параллельных понижения разных владельцев
+	// не пройдут проверку "владелец не последний" одновременно.
+	const ownersQuery = `
+		SELECT user_id FROM org_members
+		WHERE org_id = $1 AND role = 'owner'
+		FOR UPDATE
+	`
+
+	rows, err := tx.Query(ctx, ownersQuery, orgID)
+	if err != nil {
+		return fmt.Errorf("%s: lock owners: %w", op, err)
+	}
+	owners, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return fmt.Errorf("%s: lock owners: %w", op, err)
+	}
+
+	if demotesOwner && len(owners) == 1 && owners[0] == userID {
+		return storage.ErrLastOrgOwner
+	}
+
+	affected, err := change(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrOrgMemberNotFound
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return nil
+}
+
+// * SaveOrgInvitation сохраняет приглашение.
+func (r *PostgresRepo) SaveOrgInvitation(ctx context.Context, inv *models.OrgInvitation) error {
+	const op = "storage.postgres.SaveOrgInvitation"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		INSERT INTO org_invitations (org_id, email, role, token_hash, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		inv.OrgID,
+		inv.Email,
+		inv.Role,
+		inv.TokenHash,
+		inv.InvitedBy,
+		inv.ExpiresAt,
+	).Scan(&inv.ID, &inv.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return storage.ErrOrgNotFound
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// * AcceptOrgInvitation гасит приглашение и добавляет пользователя в
+// организацию. Приглашение принимается только аккаунтом с тем email, на
+// который оно отправлено.
+func (r *PostgresRepo) AcceptOrgInvitation(
+	ctx context.Context,
+	tokenHash []byte,
+	userID int64,
+	email string,
+) (*models.OrgInvitation, error) {
+	const op = "storage.postgres.AcceptOrgInvitation"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.log.Error("rollback failed", sl.Err(rbErr))
+		}
+	}()
+
+	// UPDATE ... RETURNING гасит приглашение атомарно: второй запрос с тем
+	// же токеном не найдёт строку с accepted_at IS NULL.
+	const acceptQuery = `
+		UPDATE org_invitations
+		SET accepted_at = NOW()
+		WHERE token_hash = $1
+		  AND accepted_at IS NULL
+		  AND declined_at IS NULL
+		  AND expires_at > $2
+		  AND LOWER(email) = LOWER($3)
+		RETURNING id, org_id, email, role, invited_by, expires_at, accepted_at, created_at
+	`
+
+	var (
+		inv       models.OrgInvitation
+		invitedBy *int64
+	)
+	err = tx.QueryRow(ctx, acceptQuery, tokenHash, time.Now(), email).Scan(
+		&inv.ID, &inv.OrgID, &inv.Email, &inv.Role, &invitedBy, &inv.ExpiresAt, &inv.AcceptedAt, &inv.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrOrgInvitationNotFound
+		}
+
+		return nil, fmt.Errorf("%s: accept: %w", op, err)
+	}
+	if invitedBy != nil {
+		inv.InvitedBy = *invitedBy
+	}
+
+	const insertMemberQuery = `
+		INSERT INTO org_members (org_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (org_id, user_id) DO NOTHING
+	`
+
+	tag, err := tx.Exec(ctx, insertMemberQuery, inv.OrgID, userID, inv.Role)
+	if err != nil {
+		return nil, fmt.Errorf("%s: insert member: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, storage.ErrOrgMemberExists
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return &inv, nil
+}
+
+// * DeclineOrgInvitation отклоняет действующее приглашение. Токен после
+// этого не принимается.
+func (r *PostgresRepo) DeclineOrgInvitation(ctx context.Context, tokenHash []byte) (*models.OrgInvitation, error) {
+	const op = "storage.postgres.DeclineOrgInvitation"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		UPDATE org_invitations
+		SET declined_at = NOW()
+		WHERE token_hash = $1
+		  AND accepted_at IS NULL
+		  AND declined_at IS NULL
+		  AND expires_at > $2
+		RETURNING id, org_id, email, role, invited_by, expires_at, declined_at, created_at
+	`
+
+	var (
+		inv       models.OrgInvitation
+		invitedBy *int64
+	)
+	err := r.db.QueryRow(ctx, query, tokenHash, time.Now()).Scan(
+		&inv.ID, &inv.OrgID, &inv.Email, &inv.Role, &invitedBy, &inv.ExpiresAt, &inv.DeclinedAt, &inv.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrOrgInvitationNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if invitedBy != nil {
+		inv.InvitedBy = *invitedBy
+	}
+
+	return &inv, nil
+}
+
+// * SaveUserFromOrgInvitation создаёт аккаунт приглашённому, у которого его
+// ещё нет, и принимает приглашение в одной транзакции. Email берётся из
+// приглашения и считается подтверждённым: токен пришёл на этот адрес.
+// Пользователь становится участником приложения организации.
+func (r *PostgresRepo) SaveUserFromOrgInvitation(
+	ctx context.Context,
+	tokenHash []byte,
+	username string,
+	passHash []byte,
+) (int64, *models.OrgInvitation, error) {
+	const op = "storage.postgres.SaveUserFromOrgInvitation"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.log.Error("rollback failed", sl.Err(rbErr))
+		}
+	}()
+
+	const acceptQuery = `
+		UPDATE org_invitations i
+		SET accepted_at = NOW()
+		FROM organizations o
+		WHERE o.id = i.org_id
+		  AND i.token_hash = $1
+		  AND i.accepted_at IS NULL
+		  AND i.declined_at IS NULL
+		  AND i.expires_at > $2
+		RETURNING i.id, i.org_id, i.email, i.role, i.invited_by, i.expires_at, i.accepted_at, i.created_at, o.app_id
+	`
+
+	var (
+		inv       models.OrgInvitation
+		invitedBy *int64
+		appID     int32
+	)
+	err = tx.QueryRow(ctx, acceptQuery, tokenHash, time.Now()).Scan(
+		&inv.ID, &inv.OrgID, &inv.Email, &inv.Role, &invitedBy, &inv.ExpiresAt, &inv.AcceptedAt, &inv.CreatedAt, &appID,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil, storage.ErrOrgInvitationNotFound
+		}
+
+		return 0, nil, fmt.Errorf("%s: accept: %w", op, err)
+	}
+	if invitedBy != nil {
+		inv.InvitedBy = *invitedBy
+	}
+
+	const insertUserQuery = `
+		INSERT INTO users (email, username, password_hash, is_verified)
+		VALUES ($1, $2, $3, TRUE)
+		RETURNING id
+	`
+
+	var userID int64
+	if err := tx.QueryRow(ctx, insertUserQuery, inv.Email, username, passHash).Scan(&userID); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return 0, nil, storage.ErrUserAlreadyExists
+		}
+
+		return 0, nil, fmt.Errorf("%s: insert user: %w", op, err)
+	}
+
+	const insertAppMemberQuery = `
+		INSERT INTO app_members (user_id, app_id)
+		VALUES ($1, $2)
+	`
+	if _, err := tx.Exec(ctx, insertAppMemberQuery, userID, appID); err != nil {
+		return 0, nil, fmt.Errorf("%s: insert app member: %w", op, err)
+	}
+
+	const insertMemberQuery = `
+		INSERT INTO org_members (org_id, user_id, role)
+		VALUES ($1, $2, $3)
+	`
+	if _, err := tx.Exec(ctx, insertMemberQuery, inv.OrgID, userID, inv.Role); err != nil {
+		return 0, nil, fmt.Errorf("%s: insert member: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, nil, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return userID, &inv, nil
+}
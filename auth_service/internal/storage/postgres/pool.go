@@ -4,50 +4,228 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"auth_service/internal/config"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/metrics"
+	"auth_service/internal/storage"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// querier — общее подмножество *pgxpool.Pool и pgx.Tx. Методы репозитория
+// работают через него и не знают, выполняются ли они в рамках UoW-транзакции
+// или напрямую на пуле. Begin внутри pgx.Tx создаёт savepoint, поэтому
+// методы с собственной транзакцией (DeleteAccount, ResetPassword, ...)
+// корректно вкладываются во внешнюю.
+type querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+var _ storage.Repository = (*PostgresRepo)(nil)
+
 type PostgresRepo struct {
-	pool *pgxpool.Pool
-	log  *slog.Logger
+	pool     *pgxpool.Pool
+	db       querier
+	replicas *replicaSet
+	log      *slog.Logger
+	timeouts queryTimeouts
+
+	// password — пароль для новых соединений пула, меняется SetPassword
+	password *atomic.Pointer[string]
 }
 
-func New(ctx context.Context, cfg *config.Config, log *slog.Logger) (*PostgresRepo, error) {
+func New(ctx context.Context, cfg *config.Config, log *slog.Logger, m *metrics.Metrics) (*PostgresRepo, error) {
 	const op = "storage.postgres.New"
 
-	dsn := dsn(cfg)
+	password := new(atomic.Pointer[string])
+	password.Store(&cfg.Postgres.Password)
+
+	tracer := newQueryTracer(log, m, cfg.Postgres.SlowQueryThreshold)
+
+	pool, err := newPool(ctx, cfg, cfg.Postgres.Host, cfg.Postgres.Port, password, tracer)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("%s: failed to ping database: %w", op, err)
+	}
+
+	var db querier = pool
+	if cfg.Postgres.CircuitBreaker.Threshold > 0 {
+		db = &breakerQuerier{
+			q:       pool,
+			breaker: newBreaker(log, cfg.Postgres.CircuitBreaker.Threshold, cfg.Postgres.CircuitBreaker.Cooldown),
+		}
+	}
+
+	repo := &PostgresRepo{
+		pool: pool,
+		db:   db,
+		log:  log,
+		timeouts: queryTimeouts{
+			read:    cfg.Postgres.ReadTimeout,
+			write:   cfg.Postgres.WriteTimeout,
+			cleanup: cfg.Postgres.CleanupTimeout,
+		},
+		password: password,
+	}
+
+	if cfg.Postgres.Migrate {
+		if err := repo.Migrate(ctx); err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// схема без нужных таблиц и колонок роняла бы запросы уже под нагрузкой —
+	// лучше не стартовать
+	if err := repo.checkSchema(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m.Registry.MustRegister(newPoolCollector(pool, "primary"))
+
+	if len(cfg.Postgres.Replicas) > 0 {
+		replicas, err := openReplicas(ctx, cfg, log, m, password, tracer)
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		repo.replicas = replicas
+	}
+
+	return repo, nil
+}
+
+// openReplicas создаёт пулы реплик. Недоступная при старте реплика не
+// мешает запуску: она сразу выводится из ротации и возвращается, когда
+// начнёт отвечать.
+func openReplicas(
+	ctx context.Context,
+	cfg *config.Config,
+	log *slog.Logger,
+	m *metrics.Metrics,
+	password *atomic.Pointer[string],
+	tracer pgx.QueryTracer,
+) (*replicaSet, error) {
+	replicas := make([]*replica, 0, len(cfg.Postgres.Replicas))
+	closeAll := func() {
+		for _, rep := range replicas {
+			rep.pool.Close()
+		}
+	}
+
+	for _, addr := range cfg.Postgres.Replicas {
+		host, portStr, err := net.SplitHostPort(addr)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("replica %q: %w", addr, err)
+		}
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("replica %q: invalid port: %w", addr, err)
+		}
+
+		pool, err := newPool(ctx, cfg, host, port, password, tracer)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("replica %q: %w", addr, err)
+		}
+
+		rep := &replica{addr: addr, pool: pool}
+		if err := pool.Ping(ctx); err != nil {
+			log.Warn("postgres replica is unavailable at startup, reads fall back to primary",
+				slog.String("replica", addr),
+				sl.Err(err),
+			)
+			rep.down.Store(true)
+		}
+
+		m.Registry.MustRegister(newPoolCollector(pool, addr))
+		replicas = append(replicas, rep)
+	}
+
+	return newReplicaSet(log, replicas, cfg.Postgres.ReplicaRetryInterval, cfg.Postgres.ReadTimeout), nil
+}
 
-	poolConfig, err := pgxpool.ParseConfig(dsn)
+// queryExecModes — значения postgres.query_exec_mode, проверенные config.Load.
+var queryExecModes = map[string]pgx.QueryExecMode{
+	"cache_statement": pgx.QueryExecModeCacheStatement,
+	"cache_describe":  pgx.QueryExecModeCacheDescribe,
+	"describe_exec":   pgx.QueryExecModeDescribeExec,
+	"exec":            pgx.QueryExecModeExec,
+	"simple_protocol": pgx.QueryExecModeSimpleProtocol,
+}
+
+// newPool — пул к одному серверу. Пароль читается при каждом новом
+// соединении, поэтому SetPassword действует и на primary, и на реплики.
+func newPool(
+	ctx context.Context,
+	cfg *config.Config,
+	host string,
+	port int,
+	password *atomic.Pointer[string],
+	tracer pgx.QueryTracer,
+) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(dsn(cfg, host, port))
 	if err != nil {
-		return nil, fmt.Errorf("%s: failed to parse config: %w", op, err)
+		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
 	poolConfig.MaxConns = 10
 	poolConfig.MinConns = 2
 	poolConfig.MaxConnLifetime = time.Hour
 	poolConfig.MaxConnIdleTime = time.Minute * 30
+	poolConfig.ConnConfig.Tracer = tracer
+	poolConfig.ConnConfig.DefaultQueryExecMode = queryExecModes[cfg.Postgres.QueryExecMode]
+	poolConfig.ConnConfig.StatementCacheCapacity = cfg.Postgres.StatementCacheCapacity
+	poolConfig.ConnConfig.DescriptionCacheCapacity = cfg.Postgres.StatementCacheCapacity
+
+	if cfg.Postgres.StatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.Postgres.StatementTimeout.Milliseconds(), 10)
+	}
+
+	poolConfig.BeforeConnect = func(_ context.Context, cc *pgx.ConnConfig) error {
+		cc.Password = *password.Load()
+		return nil
+	}
 
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
-		return nil, fmt.Errorf("%s: failed to create pool: %w", op, err)
+		return nil, fmt.Errorf("failed to create pool: %w", err)
 	}
 
-	if err := pool.Ping(ctx); err != nil {
-		pool.Close()
-		return nil, fmt.Errorf("%s: failed to ping database: %w", op, err)
-	}
+	return pool, nil
+}
 
-	return &PostgresRepo{pool: pool, log: log}, nil
+// SetPassword применяется к новым соединениям пула: после ротации пароля
+// открытые соединения дорабатывают до MaxConnLifetime, а новые
+// аутентифицируются уже новым паролем, без рестарта сервиса.
+func (r *PostgresRepo) SetPassword(password string) {
+	r.password.Store(&password)
 }
 
 func (r *PostgresRepo) Close(ctx context.Context) error {
 	done := make(chan struct{})
 
 	go func() {
+		if r.replicas != nil {
+			r.replicas.close()
+		}
 		r.pool.Close()
 		close(done)
 	}()
@@ -61,11 +239,11 @@ func (r *PostgresRepo) Close(ctx context.Context) error {
 	}
 }
 
-// * dsn формирует конфигурацию базы данных.
-func dsn(cfg *config.Config) string {
+// * dsn формирует конфигурацию базы данных для сервера host:port.
+func dsn(cfg *config.Config, host string, port int) string {
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s database=%s sslmode=%s",
-		cfg.Postgres.Host,
-		cfg.Postgres.Port,
+		host,
+		port,
 		cfg.Postgres.User,
 		cfg.Postgres.Password,
 		cfg.Postgres.DBName,
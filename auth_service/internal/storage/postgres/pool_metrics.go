@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector отдаёт статистику пула соединений на каждый scrape —
+// pgxpool считает её сам, дублировать счётчики не нужно.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired     *prometheus.Desc
+	idle         *prometheus.Desc
+	total        *prometheus.Desc
+	max          *prometheus.Desc
+	acquireCount *prometheus.Desc
+	acquireWait  *prometheus.Desc
+	emptyAcquire *prometheus.Desc
+}
+
+// name попадает в метку pool: primary или host:port реплики.
+func newPoolCollector(pool *pgxpool.Pool, name string) *poolCollector {
+	labels := prometheus.Labels{"pool": name}
+
+	return &poolCollector{
+		pool: pool,
+
+		acquired: prometheus.NewDesc("db_pool_acquired_connections",
+			"Number of currently acquired Postgres pool connections", nil, labels),
+		idle: prometheus.NewDesc("db_pool_idle_connections",
+			"Number of currently idle Postgres pool connections", nil, labels),
+		total: prometheus.NewDesc("db_pool_total_connections",
+			"Total number of open Postgres pool connections", nil, labels),
+		max: prometheus.NewDesc("db_pool_max_connections",
+			"Maximum size of the Postgres pool", nil, labels),
+		acquireCount: prometheus.NewDesc("db_pool_acquires_total",
+			"Count of successful connection acquires from the Postgres pool", nil, labels),
+		acquireWait: prometheus.NewDesc("db_pool_acquire_wait_seconds_total",
+			"Total time spent waiting for a Postgres pool connection", nil, labels),
+		// растёт — пула не хватает, запросы ждут соединения
+		emptyAcquire: prometheus.NewDesc("db_pool_empty_acquires_total",
+			"Count of acquires that had to wait because the Postgres pool was empty", nil, labels),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquired
+	ch <- c.idle
+	ch <- c.total
+	ch <- c.max
+	ch <- c.acquireCount
+	ch <- c.acquireWait
+	ch <- c.emptyAcquire
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireWait, prometheus.CounterValue, s.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquire, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+}
@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sl "auth_service/internal/lib/logger"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// replica — пул реплики для чтения. Реплика, на которой запрос упал с
+// ошибкой соединения, выводится из ротации до успешного ping.
+type replica struct {
+	addr string
+	pool *pgxpool.Pool
+	down atomic.Bool
+}
+
+// replicaSet раздаёт чтения по живым репликам по кругу и в фоне
+// проверяет выведенные из ротации.
+type replicaSet struct {
+	replicas      []*replica
+	next          atomic.Uint64
+	retryInterval time.Duration
+	pingTimeout   time.Duration
+	log           *slog.Logger
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+func newReplicaSet(log *slog.Logger, replicas []*replica, retryInterval, pingTimeout time.Duration) *replicaSet {
+	s := &replicaSet{
+		replicas:      replicas,
+		retryInterval: retryInterval,
+		pingTimeout:   pingTimeout,
+		log:           log,
+		stop:          make(chan struct{}),
+	}
+
+	s.done.Add(1)
+	go s.watch()
+
+	return s
+}
+
+// pick возвращает следующую живую реплику или nil, если живых нет.
+func (s *replicaSet) pick() *replica {
+	n := uint64(len(s.replicas))
+	start := s.next.Add(1)
+
+	for i := range n {
+		rep := s.replicas[(start+i)%n]
+		if !rep.down.Load() {
+			return rep
+		}
+	}
+
+	return nil
+}
+
+func (s *replicaSet) markDown(rep *replica, err error) {
+	if rep.down.CompareAndSwap(false, true) {
+		s.log.Warn("postgres replica is unavailable, reads fall back to primary",
+			slog.String("replica", rep.addr),
+			sl.Err(err),
+		)
+	}
+}
+
+// watch каждые retryInterval пингует выведенные из ротации реплики и
+// возвращает ответившие.
+func (s *replicaSet) watch() {
+	defer s.done.Done()
+
+	ticker := time.NewTicker(s.retryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+
+		for _, rep := range s.replicas {
+			if !rep.down.Load() {
+				continue
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), s.pingTimeout)
+			err := rep.pool.Ping(ctx)
+			cancel()
+
+			if err == nil {
+				rep.down.Store(false)
+				s.log.Info("postgres replica is back in rotation", slog.String("replica", rep.addr))
+			}
+		}
+	}
+}
+
+func (s *replicaSet) close() {
+	close(s.stop)
+	s.done.Wait()
+
+	for _, rep := range s.replicas {
+		rep.pool.Close()
+	}
+}
+
+// readReplica выполняет чтение на реплике, если они настроены и запрос
+// идёт не в транзакции, иначе — на r.db. Ошибка соединения или отказ
+// реплики выполнить запрос (конфликт с восстановлением, остановка)
+// повторяет его на primary, как и pgx.ErrNoRows. Найденная строка может
+// отставать от primary на время репликации — сюда идут только запросы,
+// которым это не страшно.
+func (r *PostgresRepo) readReplica(ctx context.Context, read func(q querier) error) error {
+	if r.replicas == nil {
+		return read(r.db)
+	}
+
+	rep := r.replicas.pick()
+	if rep == nil {
+		return read(r.db)
+	}
+
+	err := read(rep.pool)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// строка могла ещё не доехать до реплики: только что созданного
+		// пользователя или приложения ищем и на primary
+	case errors.As(err, &pgErr):
+		// 40001 — запрос отменён конфликтом с восстановлением реплики,
+		// 57xxx — реплика останавливается или ещё не принимает соединения
+		if pgErr.Code != "40001" && !strings.HasPrefix(pgErr.Code, "57") {
+			return err
+		}
+	case isConnError(err):
+		r.replicas.markDown(rep, err)
+	default:
+		return err
+	}
+
+	return read(r.db)
+}
+
+// isConnError — реплика недоступна, а не запрос некорректен: ошибка
+// установки соединения, сети или соединение оборвалось до ответа.
+func isConnError(err error) bool {
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+
+	return errors.As(err, &connectErr) ||
+		errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		pgconn.SafeToRetry(err)
+}
@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// PurgeAuditEvents удаляет до limit событий аудита, созданных раньше before.
+func (r *PostgresRepo) PurgeAuditEvents(ctx context.Context, before time.Time, limit int) (int64, error) {
+	const op = "storage.postgres.PurgeAuditEvents"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryCleanup)
+	defer cancel()
+
+	query := `
+		DELETE FROM audit_events
+		WHERE id IN (
+			SELECT id
+			FROM audit_events
+			WHERE created_at < $1
+			LIMIT $2
+		)
+	`
+
+	res, err := r.db.Exec(ctx, query, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// PurgeDeletedAccounts безвозвратно удаляет до limit аккаунтов, soft-deleted
+// раньше before. Связанные строки (refresh-токены, identities, magic links,
+// членство в приложениях) удаляются каскадом; аудит остаётся до своей
+// политики хранения.
+func (r *PostgresRepo) PurgeDeletedAccounts(ctx context.Context, before time.Time, limit int) (int64, error) {
+	const op = "storage.postgres.PurgeDeletedAccounts"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryCleanup)
+	defer cancel()
+
+	// SKIP LOCKED — строки, которые прямо сейчас восстанавливает
+	// RestoreAccount (SELECT ... FOR UPDATE), не трогаем
+	query := `
+		DELETE FROM users
+		WHERE id IN (
+			SELECT id
+			FROM users
+			WHERE deleted_at IS NOT NULL AND deleted_at < $1
+			ORDER BY id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+	`
+
+	res, err := r.db.Exec(ctx, query, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// PurgeUsedMagicLinks удаляет до limit использованных magic-link токенов,
+// погашенных раньше before. Неиспользованные истёкшие чистит
+// cleanup_expired_magic_links().
+func (r *PostgresRepo) PurgeUsedMagicLinks(ctx context.Context, before time.Time, limit int) (int64, error) {
+	const op = "storage.postgres.PurgeUsedMagicLinks"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryCleanup)
+	defer cancel()
+
+	query := `
+		DELETE FROM magic_links
+		WHERE id IN (
+			SELECT id
+			FROM magic_links
+			WHERE used_at IS NOT NULL AND used_at < $1
+			LIMIT $2
+		)
+	`
+
+	res, err := r.db.Exec(ctx, query, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// PurgeExpiredTrustedDevices удаляет до limit доверенных устройств, срок
+// которых истёк раньше before.
+func (r *PostgresRepo) PurgeExpiredTrustedDevices(ctx context.Context, before time.Time, limit int) (int64, error) {
+	const op = "storage.postgres.PurgeExpiredTrustedDevices"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryCleanup)
+	defer cancel()
+
+	query := `
+		DELETE FROM trusted_devices
+		WHERE id IN (
+			SELECT id
+			FROM trusted_devices
+			WHERE expires_at < $1
+			LIMIT $2
+		)
+	`
+
+	res, err := r.db.Exec(ctx, query, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// PurgeExpiredRefreshTokens удаляет до limit refresh-токенов, истёкших
+// раньше before. Такие сессии уже не продлить, в списке сессий их нет.
+func (r *PostgresRepo) PurgeExpiredRefreshTokens(ctx context.Context, before time.Time, limit int) (int64, error) {
+	const op = "storage.postgres.PurgeExpiredRefreshTokens"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryCleanup)
+	defer cancel()
+
+	query := `
+		DELETE FROM refresh_tokens
+		WHERE id IN (
+			SELECT id
+			FROM refresh_tokens
+			WHERE expires_at < $1
+			LIMIT $2
+		)
+	`
+
+	res, err := r.db.Exec(ctx, query, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// PurgeExpiredIPRules удаляет до limit временных правил доступа по IP,
+// истёкших раньше before.
+func (r *PostgresRepo) PurgeExpiredIPRules(ctx context.Context, before time.Time, limit int) (int64, error) {
+	const op = "storage.postgres.PurgeExpiredIPRules"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryCleanup)
+	defer cancel()
+
+	query := `
+		DELETE FROM ip_rules
+		WHERE id IN (
+			SELECT id
+			FROM ip_rules
+			WHERE expires_at < $1
+			LIMIT $2
+		)
+	`
+
+	res, err := r.db.Exec(ctx, query, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.RowsAffected(), nil
+}
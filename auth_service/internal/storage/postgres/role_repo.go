@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// * CreateRole заводит роль приложения. Имя уникально в пределах приложения.
+func (r *PostgresRepo) CreateRole(ctx context.Context, role *models.Role) error {
+	const op = "storage.postgres.CreateRole"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		INSERT INTO roles (app_id, name, description, permissions)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	permissions := role.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	err := r.db.QueryRow(ctx, query, role.AppID, role.Name, role.Description, permissions).
+		Scan(&role.ID, &role.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505":
+				return storage.ErrRoleAlreadyExists
+			case "23503":
+				return storage.ErrAppNotFound
+			}
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// * RolesByAppID — все роли приложения, по имени.
+func (r *PostgresRepo) RolesByAppID(ctx context.Context, appID int32) ([]models.Role, error) {
+	const op = "storage.postgres.RolesByAppID"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT id, app_id, name, description, permissions, created_at
+		FROM roles
+		WHERE app_id = $1
+		ORDER BY name
+	`
+
+	rows, err := r.db.Query(ctx, query, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	roles, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Role])
+	if err != nil {
+		return nil, fmt.Errorf("%s: collect: %w", op, err)
+	}
+
+	return roles, nil
+}
+
+// * RoleByName ищет роль приложения по имени.
+func (r *PostgresRepo) RoleByName(ctx context.Context, appID int32, name string) (*models.Role, error) {
+	const op = "storage.postgres.RoleByName"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT id, app_id, name, description, permissions, created_at
+		FROM roles
+		WHERE app_id = $1 AND name = $2
+	`
+
+	var role models.Role
+	err := r.db.QueryRow(ctx, query, appID, name).Scan(
+		&role.ID, &role.AppID, &role.Name, &role.Description, &role.Permissions, &role.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrRoleNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &role, nil
+}
+
+// * UserRoles — роли пользователя в приложении. Вызывается при каждой
+// выдаче access-токена, поэтому читает только по индексу первичного ключа.
+func (r *PostgresRepo) UserRoles(ctx context.Context, userID int64, appID int32) ([]models.Role, error) {
+	const op = "storage.postgres.UserRoles"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT r.id, r.app_id, r.name, r.description, r.permissions, r.created_at
+		FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id
+		WHERE ur.user_id = $1 AND r.app_id = $2
+		ORDER BY r.name
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	roles, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Role])
+	if err != nil {
+		return nil, fmt.Errorf("%s: collect: %w", op, err)
+	}
+
+	return roles, nil
+}
+
+// * AssignRole назначает роль пользователю. false — роль уже была назначена.
+func (r *PostgresRepo) AssignRole(ctx context.Context, userID, roleID int64, grantedBy string) (bool, error) {
+	const op = "storage.postgres.AssignRole"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		INSERT INTO user_roles (user_id, role_id, granted_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, role_id) DO NOTHING
+	`
+
+	tag, err := r.db.Exec(ctx, query, userID, roleID, grantedBy)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			if pgErr.ConstraintName == "fk_user_roles_user" {
+				return false, storage.ErrUserNotFound
+			}
+			return false, storage.ErrRoleNotFound
+		}
+
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// * RevokeRole снимает роль с пользователя. false — роли у него не было.
+func (r *PostgresRepo) RevokeRole(ctx context.Context, userID, roleID int64) (bool, error) {
+	const op = "storage.postgres.RevokeRole"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	tag, err := r.db.Exec(ctx, `DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2`, userID, roleID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
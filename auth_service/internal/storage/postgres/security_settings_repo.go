@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// * TwoFADelivery возвращает канал доставки magic-link 2FA пользователя.
+// ErrTwoFADeliveryNotFound — канал не выбран, ссылка уходит на email.
+func (r *PostgresRepo) TwoFADelivery(ctx context.Context, userID int64) (*models.TwoFADelivery, error) {
+	const op = "storage.postgres.TwoFADelivery"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT two_fa_channel, two_fa_destination
+		FROM user_security_settings
+		WHERE user_id = $1
+	`
+
+	delivery := &models.TwoFADelivery{}
+
+	err := r.db.QueryRow(ctx, query, userID).Scan(&delivery.Channel, &delivery.Destination)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrTwoFADeliveryNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return delivery, nil
+}
+
+// * SetTwoFADelivery сохраняет канал доставки magic-link 2FA. Email — канал
+// по умолчанию, для него строка удаляется: других настроек в ней пока нет.
+func (r *PostgresRepo) SetTwoFADelivery(ctx context.Context, userID int64, delivery models.TwoFADelivery) error {
+	const op = "storage.postgres.SetTwoFADelivery"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	if delivery.Channel == models.TwoFAChannelEmail {
+		if _, err := r.db.Exec(ctx, `DELETE FROM user_security_settings WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	}
+
+	query := `
+		INSERT INTO user_security_settings (user_id, two_fa_channel, two_fa_destination)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET two_fa_channel = EXCLUDED.two_fa_channel,
+			two_fa_destination = EXCLUDED.two_fa_destination,
+			updated_at = NOW()
+	`
+
+	if _, err := r.db.Exec(ctx, query, userID, delivery.Channel, delivery.Destination); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const signingKeyColumns = `kid, app_id, alg, private_key, public_key, created_at, retired_at, expires_at`
+
+// * ActiveSigningKey возвращает ключ, которым сейчас подписываются токены
+// приложения.
+func (r *PostgresRepo) ActiveSigningKey(ctx context.Context, appID int32) (*models.SigningKey, error) {
+	const op = "storage.postgres.ActiveSigningKey"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT ` + signingKeyColumns + `
+		FROM signing_keys
+		WHERE app_id = $1 AND retired_at IS NULL
+	`
+
+	key, err := scanSigningKey(r.db.QueryRow(ctx, query, appID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrSigningKeyNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// * SigningKeyByKID возвращает ключ по kid из заголовка токена. Ключи с
+// истёкшим grace-периодом не возвращаются.
+func (r *PostgresRepo) SigningKeyByKID(ctx context.Context, kid string) (*models.SigningKey, error) {
+	const op = "storage.postgres.SigningKeyByKID"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT ` + signingKeyColumns + `
+		FROM signing_keys
+		WHERE kid = $1 AND (expires_at IS NULL OR expires_at > NOW())
+	`
+
+	key, err := scanSigningKey(r.db.QueryRow(ctx, query, kid))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrSigningKeyNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// * RotateSigningKey выводит активный ключ приложения (если он есть) и
+// делает активным key. Выведенный ключ принимается до retiredExpiresAt.
+// Если активный ключ сменился параллельно, возвращает ErrSigningKeyConflict.
+func (r *PostgresRepo) RotateSigningKey(ctx context.Context, key *models.SigningKey, retiredExpiresAt time.Time) error {
+	const op = "storage.postgres.RotateSigningKey"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			r.log.Error("rollback failed", sl.Err(err))
+		}
+	}()
+
+	const retireQuery = `
+		UPDATE signing_keys
+		SET retired_at = NOW(),
+			expires_at = $2
+		WHERE app_id = $1 AND retired_at IS NULL
+	`
+
+	if _, err := tx.Exec(ctx, retireQuery, key.AppID, retiredExpiresAt); err != nil {
+		return fmt.Errorf("%s: retire: %w", op, err)
+	}
+
+	const insertQuery = `
+		INSERT INTO signing_keys (kid, app_id, alg, private_key, public_key)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err = tx.Exec(ctx, insertQuery, key.KID, key.AppID, key.Alg, key.PrivateKey, key.PublicKey)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505":
+				// активный ключ вставлен другой репликой после нашего UPDATE
+				return storage.ErrSigningKeyConflict
+			case "23503":
+				return storage.ErrAppNotFound
+			}
+		}
+
+		return fmt.Errorf("%s: insert: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return nil
+}
+
+// * PublicSigningKeys возвращает публичные части асимметричных ключей,
+// которые ещё принимаются при проверке, — для JWKS. Приватные ключи из БД
+// не читаются, HS256-ключи не публикуются.
+func (r *PostgresRepo) PublicSigningKeys(ctx context.Context) ([]models.SigningKey, error) {
+	const op = "storage.postgres.PublicSigningKeys"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT kid, app_id, alg, public_key, created_at, retired_at, expires_at
+		FROM signing_keys
+		WHERE alg <> 'HS256' AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY created_at, kid
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var keys []models.SigningKey
+	for rows.Next() {
+		var k models.SigningKey
+		if err := rows.Scan(&k.KID, &k.AppID, &k.Alg, &k.PublicKey, &k.CreatedAt, &k.RetiredAt, &k.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+
+		keys = append(keys, k)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// * AppsDueForKeyRotation возвращает приложения, чей активный ключ создан
+// раньше createdBefore.
+func (r *PostgresRepo) AppsDueForKeyRotation(ctx context.Context, createdBefore time.Time) ([]int32, error) {
+	const op = "storage.postgres.AppsDueForKeyRotation"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT app_id
+		FROM signing_keys
+		WHERE retired_at IS NULL AND created_at < $1
+		ORDER BY app_id
+	`
+
+	rows, err := r.db.Query(ctx, query, createdBefore)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	appIDs, err := pgx.CollectRows(rows, pgx.RowTo[int32])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return appIDs, nil
+}
+
+// * DeleteExpiredSigningKeys удаляет выведенные ключи, grace-период которых
+// закончился.
+func (r *PostgresRepo) DeleteExpiredSigningKeys(ctx context.Context) (int64, error) {
+	const op = "storage.postgres.DeleteExpiredSigningKeys"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryCleanup)
+	defer cancel()
+
+	query := `
+		DELETE FROM signing_keys
+		WHERE expires_at IS NOT NULL AND expires_at <= NOW()
+	`
+
+	result, err := r.db.Exec(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func scanSigningKey(row pgx.Row) (*models.SigningKey, error) {
+	var k models.SigningKey
+
+	err := row.Scan(
+		&k.KID,
+		&k.AppID,
+		&k.Alg,
+		&k.PrivateKey,
+		&k.PublicKey,
+		&k.CreatedAt,
+		&k.RetiredAt,
+		&k.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &k, nil
+}
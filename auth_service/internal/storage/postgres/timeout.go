@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+	"time"
+)
+
+// queryKind определяет, какой из таймаутов конфига применяется к запросу.
+type queryKind int
+
+const (
+	queryRead queryKind = iota
+	queryWrite
+	queryCleanup
+)
+
+type queryTimeouts struct {
+	read    time.Duration
+	write   time.Duration
+	cleanup time.Duration
+}
+
+// queryCtx — общая точка входа для всех методов репозитория: подписывает
+// контекст именем операции (для queryTracer) и ставит собственный дедлайн
+// по типу запроса. Дедлайн хендлера по-прежнему действует, если он раньше —
+// context.WithTimeout берёт минимальный из двух, так что один медленный
+// запрос не съедает весь бюджет хендлера, но и не переживает его.
+func (r *PostgresRepo) queryCtx(ctx context.Context, op string, kind queryKind) (context.Context, context.CancelFunc) {
+	ctx = withOp(ctx, op)
+
+	var timeout time.Duration
+	switch kind {
+	case queryWrite:
+		timeout = r.timeouts.write
+	case queryCleanup:
+		timeout = r.timeouts.cleanup
+	default:
+		timeout = r.timeouts.read
+	}
+
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
@@ -19,22 +19,46 @@ func (r *PostgresRepo) SaveRefreshToken(
 	id string,
 	userID int64,
 	appID int32,
+	device *models.Device,
+	fp models.Fingerprint,
 	tokenHash []byte,
 	expiresAt time.Time,
+	absoluteExpiresAt time.Time,
+	scopes []string,
 ) error {
 	const op = "storage.postgres.SaveRefreshToken"
 
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
 	query := `
-		INSERT INTO refresh_tokens (id, user_id, app_id, token_hash, expires_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO refresh_tokens (
+			id, user_id, app_id, device_id, device_name, token_hash, expires_at, scopes, ip, user_agent, fingerprint, absolute_expires_at
+		) VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, NULLIF($9, '')::inet, NULLIF($10, ''), $11, $12)
 	`
 
-	_, err := r.pool.Exec(ctx, query,
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	var deviceID, deviceName string
+	if device != nil {
+		deviceID, deviceName = device.ID, device.Name
+	}
+
+	_, err := r.db.Exec(ctx, query,
 		id,
 		userID,
 		appID,
+		deviceID,
+		deviceName,
 		tokenHash,
 		expiresAt,
+		scopes,
+		fp.IP,
+		fp.UserAgent,
+		fp.Hash,
+		absoluteExpiresAt,
 	)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
@@ -49,21 +73,27 @@ func (r *PostgresRepo) UpdateRefreshToken(
 	newTokenHash []byte,
 	oldTokenHash []byte,
 	expiresAt time.Time,
+	orgID int64,
 ) error {
 	const op = "storage.postgres.UpdateRefreshToken"
 
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
 	query := `
 		UPDATE refresh_tokens
 		SET token_hash = $1,
-			expires_at = $2
+			expires_at = $2,
+			org_id = NULLIF($5::BIGINT, 0)
 		WHERE id = $3 AND token_hash = $4
 	`
 
-	res, err := r.pool.Exec(ctx, query,
+	res, err := r.db.Exec(ctx, query,
 		newTokenHash,
 		expiresAt,
 		id,
 		oldTokenHash,
+		orgID,
 	)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
@@ -81,20 +111,26 @@ func (r *PostgresRepo) RefreshTokenByID(
 ) (*models.RefreshToken, error) {
 	const op = "storage.postgres.RefreshTokenByID"
 
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
 	query := `
-		SELECT id, user_id, app_id, token_hash, expires_at
+		SELECT id, user_id, app_id, token_hash, expires_at, absolute_expires_at, scopes, COALESCE(org_id, 0)
 		FROM refresh_tokens
 		WHERE id = $1
 	`
 
 	var rt models.RefreshToken
 
-	err := r.pool.QueryRow(ctx, query, id).Scan(
+	err := r.db.QueryRow(ctx, query, id).Scan(
 		&rt.ID,
 		&rt.UserID,
 		&rt.AppID,
 		&rt.TokenHash,
 		&rt.ExpiresAt,
+		&rt.AbsoluteExpiresAt,
+		&rt.Scopes,
+		&rt.OrgID,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -107,18 +143,94 @@ func (r *PostgresRepo) RefreshTokenByID(
 	return &rt, nil
 }
 
+// SessionsByUserID возвращает неистёкшие refresh-токены пользователя,
+// новые первыми.
+func (r *PostgresRepo) SessionsByUserID(ctx context.Context, userID int64) ([]models.Session, error) {
+	const op = "storage.postgres.SessionsByUserID"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT
+			id, app_id, COALESCE(device_id, ''), COALESCE(device_name, ''),
+			COALESCE(host(ip), ''), COALESCE(user_agent, ''), created_at, expires_at
+		FROM refresh_tokens
+		WHERE user_id = $1 AND expires_at > NOW()
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+	for rows.Next() {
+		var s models.Session
+		err := rows.Scan(&s.ID, &s.AppID, &s.DeviceID, &s.DeviceName, &s.IP, &s.UserAgent, &s.CreatedAt, &s.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sessions, nil
+}
+
+// LockActiveSessions вызывается в транзакции логина: блокировка строки
+// пользователя не даёт параллельным логинам одновременно пройти проверку
+// лимита сессий.
+func (r *PostgresRepo) LockActiveSessions(ctx context.Context, userID int64, appID int32) ([]uuid.UUID, error) {
+	const op = "storage.postgres.LockActiveSessions"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	const lockUserQuery = `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`
+	if _, err := r.db.Exec(ctx, lockUserQuery, userID); err != nil {
+		return nil, fmt.Errorf("%s: lock user: %w", op, err)
+	}
+
+	query := `
+		SELECT id
+		FROM refresh_tokens
+		WHERE user_id = $1 AND app_id = $2 AND expires_at > NOW()
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ids, nil
+}
+
 func (r *PostgresRepo) DeleteRefreshToken(
 	ctx context.Context,
 	id uuid.UUID,
 ) error {
 	const op = "storage.postgres.DeleteRefreshToken"
 
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
 	query := `
 		DELETE FROM refresh_tokens
 		WHERE id = $1
 	`
 
-	_, err := r.pool.Exec(ctx, query, id)
+	_, err := r.db.Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -133,14 +245,17 @@ func (r *PostgresRepo) SaveResetToken(
 	tokenHash []byte,
 	expiresAt time.Time,
 ) error {
-	const op = "storage.postgres.App"
+	const op = "storage.postgres.SaveResetToken"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
 
 	query := `
 		INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at)
 		VALUES ($1, $2, $3, $4)
 	`
 
-	_, err := r.pool.Exec(ctx, query,
+	_, err := r.db.Exec(ctx, query,
 		tokenID,
 		userID,
 		tokenHash,
@@ -154,6 +269,11 @@ func (r *PostgresRepo) SaveResetToken(
 }
 
 func (r *PostgresRepo) ResetTokenByID(ctx context.Context, tokenID uuid.UUID) (*models.ResetToken, error) {
+	const op = "storage.postgres.ResetTokenByID"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
 	query := `
 		SELECT id, user_id, token_hash, expires_at, used_at
 		FROM password_reset_tokens
@@ -161,7 +281,7 @@ func (r *PostgresRepo) ResetTokenByID(ctx context.Context, tokenID uuid.UUID) (*
 	`
 	var rt models.ResetToken
 
-	err := r.pool.QueryRow(ctx, query, tokenID).Scan(
+	err := r.db.QueryRow(ctx, query, tokenID).Scan(
 		&rt.ID,
 		&rt.UserID,
 		&rt.TokenHash,
@@ -173,21 +293,66 @@ func (r *PostgresRepo) ResetTokenByID(ctx context.Context, tokenID uuid.UUID) (*
 			return nil, storage.ErrResetTokenNotFound
 		}
 
-		return nil, fmt.Errorf("scan reset token %s: %w", tokenID, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return &rt, nil
 }
 
+// DeleteAllRefreshTokens удаляет все refresh-токены пользователя во всех
+// приложениях и возвращает их количество.
+func (r *PostgresRepo) DeleteAllRefreshTokens(ctx context.Context, userID int64) (int64, error) {
+	const op = "storage.postgres.DeleteAllRefreshTokens"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		DELETE
+		FROM refresh_tokens
+		WHERE user_id = $1
+	`
+	res, err := r.db.Exec(ctx, query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// DeleteDeviceRefreshTokens удаляет сессию пользователя на устройстве —
+// перед выдачей новой, чтобы на одном device_id была одна сессия.
+func (r *PostgresRepo) DeleteDeviceRefreshTokens(ctx context.Context, userID int64, deviceID string) (int64, error) {
+	const op = "storage.postgres.DeleteDeviceRefreshTokens"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		DELETE
+		FROM refresh_tokens
+		WHERE user_id = $1 AND device_id = $2
+	`
+	res, err := r.db.Exec(ctx, query, userID, deviceID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.RowsAffected(), nil
+}
+
 func (r *PostgresRepo) DeleteAllResetTokens(ctx context.Context, uid int64) error {
-	const op = "postgres.DeleteAllResetTokens"
+	const op = "storage.postgres.DeleteAllResetTokens"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
 
 	query := `
 		DELETE
 		FROM password_reset_tokens
 		WHERE user_id = $1
 	`
-	_, err := r.pool.Exec(ctx, query, uid)
+	_, err := r.db.Exec(ctx, query, uid)
 	if err != nil {
 		return fmt.Errorf("%s: failed to save user: %w", op, err)
 	}
@@ -203,7 +368,10 @@ func (r *PostgresRepo) ResetPassword(
 ) error {
 	const op = "storage.postgres.ResetPassword"
 
-	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("%s: begin tx: %w", op, err)
 	}
@@ -228,7 +396,10 @@ func (r *PostgresRepo) ResetPassword(
 	}
 
 	const updatePasswordQuery = `
-    UPDATE users SET password_hash = $1 WHERE id = $2 AND deleted_at IS NULL
+    UPDATE users
+		SET password_hash = $1,
+			must_reset_password = FALSE
+		WHERE id = $2 AND deleted_at IS NULL
   `
 	res, err = tx.Exec(ctx, updatePasswordQuery, newPasswordHash, userID)
 	if err != nil {
@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// * SaveTOTPSecret сохраняет новый секрет для enroll. Неподтверждённый секрет
+// перезаписывается (повторный enroll), подтверждённый — нет.
+func (r *PostgresRepo) SaveTOTPSecret(ctx context.Context, userID int64, secretEnc []byte) error {
+	const op = "storage.postgres.SaveTOTPSecret"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		INSERT INTO totp_secrets (user_id, secret_enc)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret_enc = EXCLUDED.secret_enc,
+			last_used_step = 0,
+			created_at = NOW()
+		WHERE totp_secrets.confirmed_at IS NULL
+	`
+
+	result, err := r.db.Exec(ctx, query, userID, secretEnc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return storage.ErrTOTPAlreadyConfirmed
+	}
+
+	return nil
+}
+
+// * TOTPSecret возвращает TOTP-секрет пользователя.
+func (r *PostgresRepo) TOTPSecret(ctx context.Context, userID int64) (*models.TOTPSecret, error) {
+	const op = "storage.postgres.TOTPSecret"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT user_id, secret_enc, confirmed_at, last_used_step, created_at
+		FROM totp_secrets
+		WHERE user_id = $1
+	`
+
+	secret := &models.TOTPSecret{}
+
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&secret.UserID,
+		&secret.SecretEnc,
+		&secret.ConfirmedAt,
+		&secret.LastUsedStep,
+		&secret.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrTOTPNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return secret, nil
+}
+
+// * ConfirmTOTP подтверждает секрет и включает TOTP 2FA пользователю одним
+// запросом. step — шаг кода, которым подтверждён enroll: он сразу
+// считается использованным.
+func (r *PostgresRepo) ConfirmTOTP(ctx context.Context, userID int64, step int64) error {
+	const op = "storage.postgres.ConfirmTOTP"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		WITH confirmed AS (
+			UPDATE totp_secrets
+			SET confirmed_at = NOW(),
+				last_used_step = $2
+			WHERE user_id = $1 AND confirmed_at IS NULL
+			RETURNING user_id
+		)
+		UPDATE users
+		SET is_2fa_enabled = TRUE,
+			two_fa_method = 'totp',
+			two_fa_enabled_at = NOW()
+		WHERE id IN (SELECT user_id FROM confirmed) AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(ctx, query, userID, step)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return storage.ErrTOTPNotFound
+	}
+
+	return nil
+}
+
+// * UseTOTPStep помечает шаг кода использованным. Шаг, не превышающий уже
+// принятый, отклоняется — один и тот же код нельзя предъявить дважды.
+func (r *PostgresRepo) UseTOTPStep(ctx context.Context, userID int64, step int64) error {
+	const op = "storage.postgres.UseTOTPStep"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		UPDATE totp_secrets
+		SET last_used_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2
+	`
+
+	result, err := r.db.Exec(ctx, query, userID, step)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return storage.ErrTOTPCodeReused
+	}
+
+	return nil
+}
+
+// * DeleteTOTP удаляет секрет и выключает 2FA, если она была включена
+// через TOTP.
+func (r *PostgresRepo) DeleteTOTP(ctx context.Context, userID int64) error {
+	const op = "storage.postgres.DeleteTOTP"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		WITH deleted AS (
+			DELETE FROM totp_secrets
+			WHERE user_id = $1
+			RETURNING user_id
+		)
+		UPDATE users
+		SET is_2fa_enabled = FALSE,
+			two_fa_method = NULL,
+			two_fa_enabled_at = NULL
+		WHERE id IN (SELECT user_id FROM deleted) AND two_fa_method = 'totp'
+	`
+
+	if _, err := r.db.Exec(ctx, query, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"auth_service/internal/metrics"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type opContextKey struct{}
+
+type queryStartContextKey struct{}
+
+type queryStart struct {
+	op    string
+	start time.Time
+}
+
+// withOp кладёт имя операции репозитория (то же, что const op в методах)
+// в контекст — по нему queryTracer группирует метрики и slow query логи.
+// Все запросы внутри транзакции метода получают одно и то же имя.
+func withOp(ctx context.Context, op string) context.Context {
+	return context.WithValue(ctx, opContextKey{}, op)
+}
+
+func opFromContext(ctx context.Context) string {
+	op, ok := ctx.Value(opContextKey{}).(string)
+	if !ok || op == "" {
+		return "unknown"
+	}
+	return op
+}
+
+// queryTracer — pgx.QueryTracer, навешиваемый на весь пул. Пишет
+// гистограмму длительности по операциям и логирует запросы дольше
+// slowThreshold. Аргументы запроса не логируются — там хеши токенов и
+// password_hash.
+type queryTracer struct {
+	log           *slog.Logger
+	metrics       *metrics.Metrics
+	slowThreshold time.Duration
+}
+
+func newQueryTracer(log *slog.Logger, m *metrics.Metrics, slowThreshold time.Duration) *queryTracer {
+	return &queryTracer{
+		log:           log,
+		metrics:       m,
+		slowThreshold: slowThreshold,
+	}
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartContextKey{}, queryStart{
+		op:    opFromContext(ctx),
+		start: time.Now(),
+	})
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	qs, ok := ctx.Value(queryStartContextKey{}).(queryStart)
+	if !ok {
+		return
+	}
+
+	duration := time.Since(qs.start)
+
+	status := "ok"
+	if data.Err != nil {
+		status = "error"
+	}
+
+	t.metrics.DBQueryDuration.WithLabelValues(qs.op, status).Observe(duration.Seconds())
+
+	if t.slowThreshold > 0 && duration >= t.slowThreshold {
+		t.metrics.DBSlowQueriesTotal.WithLabelValues(qs.op).Inc()
+
+		t.log.Warn("slow query",
+			slog.String("op", qs.op),
+			slog.Duration("duration", duration),
+			slog.Int64("rows", data.CommandTag.RowsAffected()),
+			slog.String("status", status),
+		)
+	}
+}
@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// * SaveTrustedDevice запоминает доверенное устройство пользователя.
+func (r *PostgresRepo) SaveTrustedDevice(ctx context.Context, d *models.TrustedDevice) error {
+	const op = "storage.postgres.SaveTrustedDevice"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		INSERT INTO trusted_devices (
+			user_id,
+			token_hash,
+			device_id,
+			device_name,
+			ip,
+			user_agent,
+			expires_at
+		) VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, '')::inet, NULLIF($6, ''), $7)
+		RETURNING id, created_at, last_used_at
+	`
+
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		d.UserID,
+		d.TokenHash,
+		d.DeviceID,
+		d.DeviceName,
+		d.IP,
+		d.UserAgent,
+		d.ExpiresAt,
+	).Scan(&d.ID, &d.CreatedAt, &d.LastUsedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// * TouchTrustedDevice проверяет, что токен доверенного устройства выдан
+// этому пользователю и не истёк, и обновляет время последнего входа.
+func (r *PostgresRepo) TouchTrustedDevice(ctx context.Context, userID int64, tokenHash []byte) (bool, error) {
+	const op = "storage.postgres.TouchTrustedDevice"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		UPDATE trusted_devices
+		SET last_used_at = NOW()
+		WHERE user_id = $1 AND token_hash = $2 AND expires_at > NOW()
+	`
+
+	result, err := r.db.Exec(ctx, query, userID, tokenHash)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// * TrustedDevices возвращает действующие доверенные устройства
+// пользователя, последние использованные первыми.
+func (r *PostgresRepo) TrustedDevices(ctx context.Context, userID int64) ([]models.TrustedDevice, error) {
+	const op = "storage.postgres.TrustedDevices"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, COALESCE(device_id, ''), COALESCE(device_name, ''),
+			COALESCE(host(ip), ''), COALESCE(user_agent, ''), created_at, last_used_at, expires_at
+		FROM trusted_devices
+		WHERE user_id = $1 AND expires_at > NOW()
+		ORDER BY last_used_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var devices []models.TrustedDevice
+	for rows.Next() {
+		var d models.TrustedDevice
+		err := rows.Scan(&d.ID, &d.UserID, &d.DeviceID, &d.DeviceName, &d.IP, &d.UserAgent, &d.CreatedAt, &d.LastUsedAt, &d.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		devices = append(devices, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return devices, nil
+}
+
+// * DeleteTrustedDevice отзывает доверие к одному устройству пользователя.
+func (r *PostgresRepo) DeleteTrustedDevice(ctx context.Context, userID, id int64) error {
+	const op = "storage.postgres.DeleteTrustedDevice"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	result, err := r.db.Exec(ctx, `DELETE FROM trusted_devices WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return storage.ErrTrustedDeviceNotFound
+	}
+
+	return nil
+}
+
+// * DeleteTrustedDevices отзывает доверие ко всем устройствам пользователя.
+func (r *PostgresRepo) DeleteTrustedDevices(ctx context.Context, userID int64) (int64, error) {
+	const op = "storage.postgres.DeleteTrustedDevices"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	result, err := r.db.Exec(ctx, `DELETE FROM trusted_devices WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/storage"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// * Do реализует storage.UoW: fn получает копию репозитория, привязанную к
+// одной транзакции. Таймауты отдельных запросов внутри fn продолжают
+// действовать, общий дедлайн транзакции — дедлайн ctx вызывающего.
+func (r *PostgresRepo) Do(ctx context.Context, fn func(ctx context.Context, tx storage.Tx) error) (err error) {
+	const op = "storage.postgres.Do"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			_ = tx.Rollback(ctx)
+			panic(rec)
+		}
+
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+				r.log.Error("rollback failed", sl.Err(rbErr))
+			}
+		}
+	}()
+
+	if err := fn(ctx, r.withTx(tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepo) withTx(tx pgx.Tx) *PostgresRepo {
+	return &PostgresRepo{
+		pool:     r.pool,
+		db:       tx,
+		log:      r.log,
+		timeouts: r.timeouts,
+		password: r.password,
+	}
+}
+
+func (r *PostgresRepo) Users() storage.UserRepo { return r }
+
+func (r *PostgresRepo) Tokens() storage.TokenRepo { return r }
+
+func (r *PostgresRepo) MagicLinks() storage.MagicLinkRepo { return r }
+
+func (r *PostgresRepo) Audit() storage.AuditRepo { return r }
+
+func (r *PostgresRepo) Roles() storage.RoleRepo { return r }
+
+func (r *PostgresRepo) Devices() storage.DeviceRepo { return r }
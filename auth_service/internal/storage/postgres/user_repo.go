@@ -14,22 +14,39 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
-func (r *PostgresRepo) SaveUser(ctx context.Context, email, username string, passHash []byte) (int64, error) {
+// SaveUser создаёт пользователя и сразу делает его участником приложения,
+// в котором он регистрируется, — одним запросом, без отдельной транзакции.
+func (r *PostgresRepo) SaveUser(ctx context.Context, email, username string, passHash []byte, appID int32) (int64, error) {
 	const op = "storage.postgres.SaveUser"
 
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
 	query := `
-		INSERT INTO users (email, username, password_hash)
-		VALUES ($1, $2, $3)
-		RETURNING id;
+		WITH new_user AS (
+			INSERT INTO users (email, username, password_hash)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		)
+		INSERT INTO app_members (user_id, app_id)
+		SELECT id, $4 FROM new_user
+		RETURNING user_id;
 	`
 
 	var id int64
 
-	err := r.pool.QueryRow(ctx, query, email, username, passHash).Scan(&id)
+	err := r.db.QueryRow(ctx, query, email, username, passHash, appID).Scan(&id)
 	if err != nil {
 		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-			return 0, storage.ErrUserAlreadyExists
+		if errors.As(err, &pgErr) {
+			switch {
+			case pgErr.Code == "23505" && pgErr.ConstraintName == "uq_users_username":
+				return 0, storage.ErrUsernameTaken
+			case pgErr.Code == "23505":
+				return 0, storage.ErrUserAlreadyExists
+			case pgErr.Code == "23503":
+				return 0, storage.ErrAppNotFound
+			}
 		}
 
 		return 0, fmt.Errorf("%s: failed to save user: %w", op, err)
@@ -39,25 +56,32 @@ func (r *PostgresRepo) SaveUser(ctx context.Context, email, username string, pas
 }
 
 func (r *PostgresRepo) UserByEmail(ctx context.Context, email string) (*models.User, error) {
-	const op = "storage.postgres.User"
+	const op = "storage.postgres.UserByEmail"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
 
 	query := `
-		SELECT id, email, username, password_hash, is_verified, deleted_at
+		SELECT id, email, username, password_hash, is_verified, must_reset_password,
+			status, status_reason, deleted_at
 		FROM users
 		WHERE email = $1;
 	`
 
-	row := r.pool.QueryRow(ctx, query, email)
-
 	var u models.User
-	err := row.Scan(
-		&u.ID,
-		&u.Email,
-		&u.Username,
-		&u.PassHash,
-		&u.IsVerified,
-		&u.DeletedAt,
-	)
+	err := r.readReplica(ctx, func(q querier) error {
+		return q.QueryRow(ctx, query, email).Scan(
+			&u.ID,
+			&u.Email,
+			&u.Username,
+			&u.PassHash,
+			&u.IsVerified,
+			&u.MustResetPassword,
+			&u.Status,
+			&u.StatusReason,
+			&u.DeletedAt,
+		)
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, storage.ErrUserNotFound
@@ -72,23 +96,30 @@ func (r *PostgresRepo) UserByEmail(ctx context.Context, email string) (*models.U
 func (r *PostgresRepo) UserByID(ctx context.Context, id int64) (*models.User, error) {
 	const op = "storage.postgres.UserByID"
 
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
 	query := `
-		SELECT id, email, username, password_hash, is_verified, deleted_at
+		SELECT id, email, username, password_hash, is_verified, must_reset_password,
+			status, status_reason, deleted_at
 		FROM users
 		WHERE id = $1;
 	`
 
-	row := r.pool.QueryRow(ctx, query, id)
-
 	var u models.User
-	err := row.Scan(
-		&u.ID,
-		&u.Email,
-		&u.Username,
-		&u.PassHash,
-		&u.IsVerified,
-		&u.DeletedAt,
-	)
+	err := r.readReplica(ctx, func(q querier) error {
+		return q.QueryRow(ctx, query, id).Scan(
+			&u.ID,
+			&u.Email,
+			&u.Username,
+			&u.PassHash,
+			&u.IsVerified,
+			&u.MustResetPassword,
+			&u.Status,
+			&u.StatusReason,
+			&u.DeletedAt,
+		)
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, storage.ErrUserNotFound
@@ -100,7 +131,10 @@ func (r *PostgresRepo) UserByID(ctx context.Context, id int64) (*models.User, er
 }
 
 func (r *PostgresRepo) UserIDByEmail(ctx context.Context, email string) (int64, error) {
-	const op = "storage.postgres.UserByEmail"
+	const op = "storage.postgres.UserIDByEmail"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
 
 	query := `
 		SELECT id
@@ -110,7 +144,7 @@ func (r *PostgresRepo) UserIDByEmail(ctx context.Context, email string) (int64,
 
 	var id int64
 
-	err := r.pool.QueryRow(ctx, query, email).Scan(&id)
+	err := r.db.QueryRow(ctx, query, email).Scan(&id)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return 0, storage.ErrUserNotFound
@@ -126,12 +160,15 @@ func (r *PostgresRepo) UserIDByEmail(ctx context.Context, email string) (int64,
 func (r *PostgresRepo) CheckIfUserVerified(ctx context.Context, email string) (int64, bool, error) {
 	const op = "storage.postgres.CheckIfUserVerified"
 
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
 	query := `	
 		SELECT id, is_verified
 		FROM users
 		WHERE email = $1 AND deleted_at IS NULL;
 	`
-	row := r.pool.QueryRow(ctx, query, email)
+	row := r.db.QueryRow(ctx, query, email)
 
 	var isVerified bool
 	var id int64
@@ -154,10 +191,91 @@ func (r *PostgresRepo) CheckIfUserVerified(ctx context.Context, email string) (i
 func (r *PostgresRepo) SetEmailVerified(ctx context.Context, userID int64) error {
 	const op = "storage.postgres.SetEmailVerified"
 
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
 	query := `UPDATE users SET is_verified = TRUE WHERE id = $1 AND deleted_at IS NULL;`
 
-	res, err := r.pool.Exec(ctx, query, userID)
+	res, err := r.db.Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return storage.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateUsername меняет username. Занятый username — storage.ErrUsernameTaken.
+func (r *PostgresRepo) UpdateUsername(ctx context.Context, userID int64, username string) error {
+	const op = "storage.postgres.UpdateUsername"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `UPDATE users SET username = $1 WHERE id = $2 AND deleted_at IS NULL;`
+
+	res, err := r.db.Exec(ctx, query, username, userID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return storage.ErrUsernameTaken
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return storage.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// RehashPassword заменяет хеш пароля тем же паролем под новым алгоритмом,
+// только если хеш всё ещё равен oldHash: пароль, сменённый конкурентно,
+// не перезаписывается. В этом случае ошибки нет — перехеширование не нужно.
+func (r *PostgresRepo) RehashPassword(ctx context.Context, userID int64, oldHash, newHash []byte) error {
+	const op = "storage.postgres.RehashPassword"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET password_hash = $3
+		WHERE id = $1 AND password_hash = $2 AND deleted_at IS NULL;
+	`
+
+	if _, err := r.db.Exec(ctx, query, userID, oldHash, newHash); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ChangeEmail меняет email, только если он всё ещё равен oldEmail, и
+// сбрасывает подтверждение. Email сменился раньше или аккаунт удалён —
+// storage.ErrUserNotFound, адрес занят — storage.ErrUserAlreadyExists.
+func (r *PostgresRepo) ChangeEmail(ctx context.Context, userID int64, oldEmail, newEmail string) error {
+	const op = "storage.postgres.ChangeEmail"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET email = $3, is_verified = FALSE
+		WHERE id = $1 AND email = $2 AND deleted_at IS NULL;
+	`
+
+	res, err := r.db.Exec(ctx, query, userID, oldEmail, newEmail)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return storage.ErrUserAlreadyExists
+		}
+
 		return fmt.Errorf("%s: %w", op, err)
 	}
 	if res.RowsAffected() == 0 {
@@ -167,10 +285,67 @@ func (r *PostgresRepo) SetEmailVerified(ctx context.Context, userID int64) error
 	return nil
 }
 
+// SetMustResetPassword выставляет или снимает флаг обязательной смены пароля.
+func (r *PostgresRepo) SetMustResetPassword(ctx context.Context, userID int64, mustReset bool) error {
+	const op = "storage.postgres.SetMustResetPassword"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `UPDATE users SET must_reset_password = $1 WHERE id = $2 AND deleted_at IS NULL;`
+
+	res, err := r.db.Exec(ctx, query, mustReset, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return storage.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// SetUserStatus переводит аккаунт из from в to. Сравнение с from защищает
+// от гонки двух администраторов: если статус уже изменился, вернётся
+// storage.ErrUserStatusConflict.
+func (r *PostgresRepo) SetUserStatus(
+	ctx context.Context,
+	userID int64,
+	from, to models.AccountStatus,
+	reason, actor string,
+) error {
+	const op = "storage.postgres.SetUserStatus"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET status = $1,
+			status_reason = NULLIF($2, ''),
+			status_changed_by = $3,
+			status_changed_at = NOW()
+		WHERE id = $4 AND status = $5
+	`
+
+	res, err := r.db.Exec(ctx, query, string(to), reason, actor, userID, string(from))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return storage.ErrUserStatusConflict
+	}
+
+	return nil
+}
+
 func (r *PostgresRepo) DeleteAccount(ctx context.Context, userID int64) error {
 	const op = "storage.postgres.DeleteAccount"
 
-	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("%s: begin tx: %w", op, err)
 	}
@@ -202,7 +377,11 @@ func (r *PostgresRepo) DeleteAccount(ctx context.Context, userID int64) error {
 
 	const updateQuery = `
 		UPDATE users
-		SET deleted_at = NOW()
+		SET deleted_at = NOW(),
+			status = 'pending_deletion',
+			status_reason = NULL,
+			status_changed_by = 'user',
+			status_changed_at = NOW()
 		WHERE id = $1
 	`
 	res, err := tx.Exec(ctx, updateQuery, userID)
@@ -238,10 +417,13 @@ func (r *PostgresRepo) DeleteAccount(ctx context.Context, userID int64) error {
 }
 
 // * RestoreAccount снимает флаг soft-delete, если grace period ещё не истёк.
-func (r *PostgresRepo) RestoreAccount(ctx context.Context, userID int64) error {
+func (r *PostgresRepo) RestoreAccount(ctx context.Context, userID int64, gracePeriod time.Duration) error {
 	const op = "storage.postgres.RestoreAccount"
 
-	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	ctx, cancel := r.queryCtx(ctx, op, queryWrite)
+	defer cancel()
+
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("%s: begin tx: %w", op, err)
 	}
@@ -270,13 +452,16 @@ func (r *PostgresRepo) RestoreAccount(ctx context.Context, userID int64) error {
 	if deletedAt == nil {
 		return storage.ErrNothingToRestore
 	}
-	if deletedAt.Before(time.Now().Add(-7 * 24 * time.Hour)) {
+	if deletedAt.Before(time.Now().Add(-gracePeriod)) {
 		return storage.ErrRestoreWindowExpired
 	}
 
 	const updateQuery = `
 		UPDATE users
-		SET deleted_at = NULL
+		SET deleted_at = NULL,
+			status = 'active',
+			status_changed_by = 'user',
+			status_changed_at = NOW()
 		WHERE id = $1
 	`
 	res, err := tx.Exec(ctx, updateQuery, userID)
@@ -293,3 +478,50 @@ func (r *PostgresRepo) RestoreAccount(ctx context.Context, userID int64) error {
 
 	return nil
 }
+
+// ListUsers возвращает до limit пользователей с id > afterID по возрастанию
+// id — keyset-пагинация для административных выгрузок.
+func (r *PostgresRepo) ListUsers(ctx context.Context, afterID int64, limit int) ([]models.User, error) {
+	const op = "storage.postgres.ListUsers"
+
+	ctx, cancel := r.queryCtx(ctx, op, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT id, email, username, is_verified, must_reset_password,
+			status, status_reason, deleted_at
+		FROM users
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2;
+	`
+
+	rows, err := r.db.Query(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(
+			&u.ID,
+			&u.Email,
+			&u.Username,
+			&u.IsVerified,
+			&u.MustResetPassword,
+			&u.Status,
+			&u.StatusReason,
+			&u.DeletedAt,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return users, nil
+}
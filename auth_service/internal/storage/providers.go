@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"auth_service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// UserSaver — запись пользователей и их сессий вне UoW-транзакции.
+type UserSaver interface {
+	SaveUser(ctx context.Context, email string, username string, passHash []byte, appID int32) (uid int64, err error)
+	DeleteAccount(ctx context.Context, userID int64) error
+	RestoreAccount(ctx context.Context, userID int64, gracePeriod time.Duration) error
+
+	UpdateRefreshToken(ctx context.Context, id uuid.UUID, newTokenHash []byte, oldTokenHash []byte, expiresAt time.Time, orgID int64) error
+	DeleteRefreshToken(ctx context.Context, id uuid.UUID) error
+
+	UpdateUsername(ctx context.Context, userID int64, username string) error
+	RehashPassword(ctx context.Context, userID int64, oldHash, newHash []byte) error
+	ChangeEmail(ctx context.Context, userID int64, oldEmail, newEmail string) error
+}
+
+// UserProvider — чтение пользователей, их сессий, токенов и настроек 2FA,
+// а также точечные изменения, которые сервис делает без UoW.
+type UserProvider interface {
+	UserByEmail(ctx context.Context, email string) (*models.User, error)
+	UserByID(ctx context.Context, id int64) (*models.User, error)
+	UserIDByEmail(ctx context.Context, email string) (int64, error)
+
+	RefreshTokenByID(ctx context.Context, id uuid.UUID) (*models.RefreshToken, error)
+	SessionsByUserID(ctx context.Context, userID int64) ([]models.Session, error)
+	KnownDevice(ctx context.Context, userID int64, fingerprint []byte) (known, hasDevices bool, err error)
+	AuditEventsByUserID(ctx context.Context, userID int64, actions []models.AuditAction, beforeID int64, limit int) ([]models.AuditEvent, error)
+
+	ResetTokenByID(ctx context.Context, tokenID uuid.UUID) (*models.ResetToken, error)
+	ResetPassword(ctx context.Context, userID int64, tokenID uuid.UUID, newPasswordHash []byte) error
+
+	SetEmailVerified(ctx context.Context, uid int64) error
+	CheckIfUserVerified(ctx context.Context, email string) (int64, bool, error)
+
+	TwoFAStatus(ctx context.Context, userID int64) (*models.TwoFAStatus, error)
+	EnableMagicLink2FA(ctx context.Context, userID int64) error
+	DisableMagicLink2FA(ctx context.Context, userID int64) error
+
+	HasIdentities(ctx context.Context, userID int64) (bool, error)
+
+	UserRoles(ctx context.Context, userID int64, appID int32) ([]models.Role, error)
+	OrgMember(ctx context.Context, orgID, userID int64, appID int32) (*models.OrgMember, error)
+}
+
+// AppProvider — приложения-клиенты сервиса.
+type AppProvider interface {
+	App(ctx context.Context, appID int32) (*models.App, error)
+	IsAppMember(ctx context.Context, userID int64, appID int32) (bool, error)
+}
@@ -0,0 +1,194 @@
+package redis
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"auth_service/internal/lib/jwt"
+	"auth_service/internal/storage"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	accessTokensPrefix = "access_tokens:user:"
+	revokedJTIPrefix   = "access_tokens:revoked:"
+	opaqueTokenPrefix  = "access_tokens:opaque:"
+)
+
+// opaqueAccessToken — claims opaque-токена в том виде, в каком они лежат в Redis.
+type opaqueAccessToken struct {
+	UserID    int64  `json:"uid"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	AppID     int32  `json:"app_id"`
+	JTI       string `json:"jti"`
+	ExpiresAt int64  `json:"exp"`
+
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	Scopes      []string `json:"scopes,omitempty"`
+	OrgID       int64    `json:"org_id,omitempty"`
+	OrgRole     string   `json:"org_role,omitempty"`
+	Actor       int32    `json:"actor,omitempty"`
+}
+
+// revokeScript атомарно переносит все ещё живые jti пользователя в
+// denylist (TTL — до exp токена плюс leeway) и очищает индекс. Атомарность
+// нужна, чтобы jti, выданный параллельным логином, не потерялся между
+// чтением и DEL.
+//
+// KEYS[1] - индекс jti пользователя (ZSET, score = exp unix)
+// ARGV[1] - now unix seconds
+// ARGV[2] - leeway seconds
+// ARGV[3] - префикс ключей denylist
+//
+// Возвращает количество отозванных jti.
+var revokeScript = redis.NewScript(`
+	local now = tonumber(ARGV[1])
+	local leeway = tonumber(ARGV[2])
+
+	local items = redis.call('ZRANGEBYSCORE', KEYS[1], '(' .. (now - leeway), '+inf', 'WITHSCORES')
+
+	local revoked = 0
+	for i = 1, #items, 2 do
+		local ttl = math.floor(tonumber(items[i + 1]) - now + leeway) + 1
+		redis.call('SET', ARGV[3] .. items[i], '1', 'EX', ttl)
+		revoked = revoked + 1
+	end
+
+	redis.call('DEL', KEYS[1])
+
+	return revoked
+`)
+
+// TrackAccessToken добавляет jti в индекс токенов пользователя. Истёкшие
+// jti вычищаются при каждой записи, сам индекс живёт до exp последнего токена.
+func (r *RedisRepo) TrackAccessToken(ctx context.Context, userID int64, jti string, expiresAt time.Time) error {
+	const op = "storage.redis.TrackAccessToken"
+
+	key := accessTokensKey(userID)
+	now := time.Now()
+
+	pipe := r.client.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(expiresAt.Unix()), Member: jti})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", "("+strconv.FormatInt(now.Unix(), 10))
+	pipe.ExpireGT(ctx, key, time.Until(expiresAt))
+	pipe.ExpireNX(ctx, key, time.Until(expiresAt))
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeUserAccessTokens отзывает все ещё действующие access-токены
+// пользователя. leeway — тот же допуск, что и при проверке exp: токен,
+// истёкший меньше leeway назад, ещё принимается и тоже должен быть отозван.
+func (r *RedisRepo) RevokeUserAccessTokens(ctx context.Context, userID int64, leeway time.Duration) (int, error) {
+	const op = "storage.redis.RevokeUserAccessTokens"
+
+	revoked, err := revokeScript.Run(ctx, r.client,
+		[]string{accessTokensKey(userID)},
+		time.Now().Unix(),
+		int64(leeway.Seconds()),
+		revokedJTIPrefix,
+	).Int()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revoked, nil
+}
+
+// IsAccessTokenRevoked проверяет jti по denylist.
+func (r *RedisRepo) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	const op = "storage.redis.IsAccessTokenRevoked"
+
+	n, err := r.client.Exists(ctx, revokedJTIPrefix+jti).Result()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n > 0, nil
+}
+
+// SaveOpaqueAccessToken сохраняет claims opaque-токена под его хешем.
+// Ключ живёт ровно до exp — истёкший токен просто не находится.
+func (r *RedisRepo) SaveOpaqueAccessToken(ctx context.Context, tokenHash []byte, claims jwt.Claims) error {
+	const op = "storage.redis.SaveOpaqueAccessToken"
+
+	data, err := json.Marshal(opaqueAccessToken{
+		UserID:    claims.UserID,
+		Username:  claims.Username,
+		Email:     claims.Email,
+		AppID:     claims.AppID,
+		JTI:       claims.ID,
+		ExpiresAt: claims.ExpiresAt.Unix(),
+
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		Scopes:      claims.Scopes,
+		OrgID:       claims.OrgID,
+		OrgRole:     claims.OrgRole,
+		Actor:       claims.Actor,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: marshal claims: %w", op, err)
+	}
+
+	if err := r.client.Set(ctx, opaqueTokenKey(tokenHash), data, time.Until(claims.ExpiresAt)).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// OpaqueAccessToken возвращает claims opaque-токена по его хешу.
+func (r *RedisRepo) OpaqueAccessToken(ctx context.Context, tokenHash []byte) (*jwt.Claims, error) {
+	const op = "storage.redis.OpaqueAccessToken"
+
+	data, err := r.client.Get(ctx, opaqueTokenKey(tokenHash)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, storage.ErrAccessTokenNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var t opaqueAccessToken
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("%s: unmarshal claims: %w", op, err)
+	}
+
+	return &jwt.Claims{
+		UserID:    t.UserID,
+		Username:  t.Username,
+		Email:     t.Email,
+		AppID:     t.AppID,
+		ID:        t.JTI,
+		ExpiresAt: time.Unix(t.ExpiresAt, 0),
+
+		Roles:       t.Roles,
+		Permissions: t.Permissions,
+		Scopes:      t.Scopes,
+		OrgID:       t.OrgID,
+		OrgRole:     t.OrgRole,
+		Actor:       t.Actor,
+	}, nil
+}
+
+func opaqueTokenKey(tokenHash []byte) string {
+	return opaqueTokenPrefix + hex.EncodeToString(tokenHash)
+}
+
+func accessTokensKey(userID int64) string {
+	return accessTokensPrefix + strconv.FormatInt(userID, 10)
+}
@@ -0,0 +1,97 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"auth_service/internal/storage"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IncrChallengeFailures увеличивает счётчик неудач IP или email. Окно, как
+// и у lockout, отсчитывается от первой неудачи.
+func (r *RedisRepo) IncrChallengeFailures(ctx context.Context, key string, window time.Duration) (int64, error) {
+	const op = "storage.redis.IncrChallengeFailures"
+
+	pipe := r.client.TxPipeline()
+	incr := pipe.Incr(ctx, challengeFailuresKey(key))
+	pipe.ExpireNX(ctx, challengeFailuresKey(key), window)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return incr.Val(), nil
+}
+
+// ChallengeFailures читает счётчики одним MGET; для пустого ключа и
+// отсутствующего счётчика — 0.
+func (r *RedisRepo) ChallengeFailures(ctx context.Context, keys ...string) ([]int64, error) {
+	const op = "storage.redis.ChallengeFailures"
+
+	redisKeys := make([]string, len(keys))
+	for i, key := range keys {
+		if key != "" {
+			redisKeys[i] = challengeFailuresKey(key)
+		}
+	}
+
+	values, err := r.client.MGet(ctx, redisKeys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	failures := make([]int64, len(keys))
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok || keys[i] == "" {
+			continue
+		}
+		if failures[i], err = strconv.ParseInt(s, 10, 64); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return failures, nil
+}
+
+// SaveChallenge сохраняет выданную задачу вместе со сложностью, с которой
+// её решение принимается.
+func (r *RedisRepo) SaveChallenge(ctx context.Context, nonce string, difficulty int, ttl time.Duration) error {
+	const op = "storage.redis.SaveChallenge"
+
+	if err := r.client.Set(ctx, challengeNonceKey(nonce), difficulty, ttl).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ConsumeChallenge атомарно читает и удаляет задачу: одно решение — один
+// запрос.
+func (r *RedisRepo) ConsumeChallenge(ctx context.Context, nonce string) (int, error) {
+	const op = "storage.redis.ConsumeChallenge"
+
+	difficulty, err := r.client.GetDel(ctx, challengeNonceKey(nonce)).Int()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, storage.ErrChallengeNotFound
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return difficulty, nil
+}
+
+func challengeFailuresKey(key string) string {
+	return "challenge:failures:" + key
+}
+
+func challengeNonceKey(nonce string) string {
+	return "challenge:nonce:" + nonce
+}
@@ -0,0 +1,55 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth_service/internal/storage"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SaveCSRFToken сохраняет хеш CSRF-токена сессии. Новый токен вытесняет
+// прежний: после ротации refresh-токена действует только последний.
+func (r *RedisRepo) SaveCSRFToken(ctx context.Context, sessionID string, tokenHash []byte, ttl time.Duration) error {
+	const op = "storage.redis.SaveCSRFToken"
+
+	if err := r.client.Set(ctx, csrfTokenKey(sessionID), tokenHash, ttl).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// CSRFTokenHash возвращает хеш текущего CSRF-токена сессии.
+func (r *RedisRepo) CSRFTokenHash(ctx context.Context, sessionID string) ([]byte, error) {
+	const op = "storage.redis.CSRFTokenHash"
+
+	hash, err := r.client.Get(ctx, csrfTokenKey(sessionID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, storage.ErrCSRFTokenNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return hash, nil
+}
+
+// DeleteCSRFToken удаляет CSRF-токен завершённой сессии.
+func (r *RedisRepo) DeleteCSRFToken(ctx context.Context, sessionID string) error {
+	const op = "storage.redis.DeleteCSRFToken"
+
+	if err := r.client.Del(ctx, csrfTokenKey(sessionID)).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func csrfTokenKey(sessionID string) string {
+	return fmt.Sprintf("csrf:session:%s", sessionID)
+}
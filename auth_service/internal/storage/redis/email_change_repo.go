@@ -0,0 +1,88 @@
+package redis
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SaveEmailChange сохраняет запрос смены email под хешем токена и
+// запоминает его как последний для пользователя: более ранние запросы
+// после этого не подтверждаются.
+func (r *RedisRepo) SaveEmailChange(
+	ctx context.Context,
+	tokenHash []byte,
+	change models.EmailChange,
+	ttl time.Duration,
+) error {
+	const op = "storage.redis.SaveEmailChange"
+
+	data, err := json.Marshal(change)
+	if err != nil {
+		return fmt.Errorf("%s: marshal change: %w", op, err)
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, emailChangeKey(tokenHash), data, ttl)
+	pipe.Set(ctx, emailChangePendingKey(change.UserID), hex.EncodeToString(tokenHash), ttl)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ConsumeEmailChange атомарно читает и удаляет запрос (GETDEL). Запрос,
+// вытесненный более новым, — ErrEmailChangeNotFound.
+func (r *RedisRepo) ConsumeEmailChange(ctx context.Context, tokenHash []byte) (*models.EmailChange, error) {
+	const op = "storage.redis.ConsumeEmailChange"
+
+	data, err := r.client.GetDel(ctx, emailChangeKey(tokenHash)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, storage.ErrEmailChangeNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var change models.EmailChange
+	if err := json.Unmarshal([]byte(data), &change); err != nil {
+		return nil, fmt.Errorf("%s: unmarshal change: %w", op, err)
+	}
+
+	pending, err := r.client.Get(ctx, emailChangePendingKey(change.UserID)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, storage.ErrEmailChangeNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if pending != hex.EncodeToString(tokenHash) {
+		return nil, storage.ErrEmailChangeNotFound
+	}
+
+	if err := r.client.Del(ctx, emailChangePendingKey(change.UserID)).Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &change, nil
+}
+
+func emailChangeKey(tokenHash []byte) string {
+	return "email_change:token:" + hex.EncodeToString(tokenHash)
+}
+
+func emailChangePendingKey(userID int64) string {
+	return fmt.Sprintf("email_change:pending:%d", userID)
+}
@@ -0,0 +1,104 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"auth_service/internal/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const ipRulesKey = "ipfilter:rules"
+
+type ipRule struct {
+	ID        int64               `json:"id"`
+	Prefix    netip.Prefix        `json:"prefix"`
+	Action    models.IPRuleAction `json:"action"`
+	Reason    string              `json:"reason"`
+	CreatedBy string              `json:"created_by"`
+	CreatedAt time.Time           `json:"created_at"`
+	ExpiresAt *time.Time          `json:"expires_at,omitempty"`
+}
+
+// CachedIPRules возвращает правила, закешированные из Postgres; ok == false —
+// кеша нет (истёк или сброшен после изменения).
+func (r *RedisRepo) CachedIPRules(ctx context.Context) ([]models.IPRule, bool, error) {
+	const op = "storage.redis.CachedIPRules"
+
+	data, err := r.client.Get(ctx, ipRulesKey).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var cached []ipRule
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, false, fmt.Errorf("%s: unmarshal rules: %w", op, err)
+	}
+
+	rules := make([]models.IPRule, 0, len(cached))
+	for _, c := range cached {
+		rules = append(rules, models.IPRule(c))
+	}
+
+	return rules, true, nil
+}
+
+// CacheIPRules кладёт правила в кеш на ttl.
+func (r *RedisRepo) CacheIPRules(ctx context.Context, rules []models.IPRule, ttl time.Duration) error {
+	const op = "storage.redis.CacheIPRules"
+
+	cached := make([]ipRule, 0, len(rules))
+	for _, rule := range rules {
+		cached = append(cached, ipRule(rule))
+	}
+
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return fmt.Errorf("%s: marshal rules: %w", op, err)
+	}
+
+	if err := r.client.Set(ctx, ipRulesKey, data, ttl).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// InvalidateIPRules сбрасывает кеш: следующее чтение на любой реплике
+// пойдёт в Postgres.
+func (r *RedisRepo) InvalidateIPRules(ctx context.Context) error {
+	const op = "storage.redis.InvalidateIPRules"
+
+	if err := r.client.Del(ctx, ipRulesKey).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// IncrIPAbuse увеличивает счётчик подозрительных ответов IP. Окно
+// отсчитывается от первого события.
+func (r *RedisRepo) IncrIPAbuse(ctx context.Context, ip string, window time.Duration) (int64, error) {
+	const op = "storage.redis.IncrIPAbuse"
+
+	key := "ipfilter:abuse:" + ip
+
+	pipe := r.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return incr.Val(), nil
+}
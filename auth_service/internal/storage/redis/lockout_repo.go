@@ -0,0 +1,234 @@
+package redis
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"auth_service/internal/storage"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IncrLoginFailures увеличивает счётчик неудачных логинов на email с ip.
+// Окно отсчитывается от первой неудачи: EXPIRE NX не продлевает его
+// последующими попытками.
+func (r *RedisRepo) IncrLoginFailures(ctx context.Context, email, ip string, window time.Duration) (int64, error) {
+	const op = "storage.redis.IncrLoginFailures"
+
+	key := loginFailuresKey(email, ip)
+
+	pipe := r.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return incr.Val(), nil
+}
+
+// LockLoginFromIP блокирует вход на email с ip на d и увеличивает номер
+// блокировки пары в серии — по нему считается экспоненциальный рост
+// длительности следующей; серия забывается через levelTTL без новых
+// блокировок. Возвращает, со скольких IP вход на email блокировался за
+// последние window.
+func (r *RedisRepo) LockLoginFromIP(ctx context.Context, email, ip string, d, levelTTL, window time.Duration) (int64, error) {
+	const op = "storage.redis.LockLoginFromIP"
+
+	now := time.Now()
+	ipsKey := lockedIPsKey(email)
+
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, loginLockKey(email, ip), 1, d)
+	pipe.Del(ctx, loginFailuresKey(email, ip))
+	pipe.Incr(ctx, lockoutLevelKey(email, ip))
+	pipe.Expire(ctx, lockoutLevelKey(email, ip), levelTTL)
+	// в наборе — все IP с блокировками серии: по нему UnlockAccount находит
+	// их ключи; для порога считаются только свежие
+	pipe.ZAdd(ctx, ipsKey, redis.Z{Score: float64(now.UnixMilli()), Member: ip})
+	pipe.Expire(ctx, ipsKey, levelTTL)
+	count := pipe.ZCount(ctx, ipsKey, strconv.FormatInt(now.Add(-window).UnixMilli(), 10), "+inf")
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count.Val(), nil
+}
+
+// LockAccount блокирует вход на email с любого IP на d и увеличивает номер
+// блокировки email в серии.
+func (r *RedisRepo) LockAccount(ctx context.Context, email string, d, levelTTL time.Duration) error {
+	const op = "storage.redis.LockAccount"
+
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, loginLockKey(email, ""), 1, d)
+	pipe.Incr(ctx, lockoutLevelKey(email, ""))
+	pipe.Expire(ctx, lockoutLevelKey(email, ""), levelTTL)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// LockoutLevel — сколько блокировок подряд уже было у email с ip;
+// ip == "" — блокировок email целиком.
+func (r *RedisRepo) LockoutLevel(ctx context.Context, email, ip string) (int64, error) {
+	const op = "storage.redis.LockoutLevel"
+
+	level, err := r.client.Get(ctx, lockoutLevelKey(email, ip)).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return level, nil
+}
+
+// LoginLockTTL возвращает больший из остатков блокировки email целиком и
+// email с ip; 0 — вход не заблокирован.
+func (r *RedisRepo) LoginLockTTL(ctx context.Context, email, ip string) (time.Duration, error) {
+	const op = "storage.redis.LoginLockTTL"
+
+	pipe := r.client.Pipeline()
+	account := pipe.PTTL(ctx, loginLockKey(email, ""))
+	pair := pipe.PTTL(ctx, loginLockKey(email, ip))
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// -2 — ключа нет, -1 — без TTL (не выставляется, но не считаем вечной)
+	return max(account.Val(), pair.Val(), 0), nil
+}
+
+// ResetLoginFailures сбрасывает счётчик, блокировку и серию email с ip —
+// после успешного входа с него.
+func (r *RedisRepo) ResetLoginFailures(ctx context.Context, email, ip string) error {
+	const op = "storage.redis.ResetLoginFailures"
+
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx,
+		loginLockKey(email, ip),
+		loginFailuresKey(email, ip),
+		lockoutLevelKey(email, ip),
+	)
+	pipe.ZRem(ctx, lockedIPsKey(email), ip)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UnlockAccount снимает все блокировки email и сбрасывает счётчики IP, с
+// которых он блокировался, — после сброса пароля или перехода по ссылке из
+// письма.
+func (r *RedisRepo) UnlockAccount(ctx context.Context, email string) error {
+	const op = "storage.redis.UnlockAccount"
+
+	ips, err := r.client.ZRange(ctx, lockedIPsKey(email), 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	keys := []string{
+		loginLockKey(email, ""),
+		lockoutLevelKey(email, ""),
+		lockedIPsKey(email),
+	}
+	for _, ip := range ips {
+		keys = append(keys,
+			loginLockKey(email, ip),
+			loginFailuresKey(email, ip),
+			lockoutLevelKey(email, ip),
+		)
+	}
+
+	if err := r.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// AllowUnlockEmail отмечает отправку письма разблокировки на email; false —
+// письмо уже уходило за последние interval.
+func (r *RedisRepo) AllowUnlockEmail(ctx context.Context, email string, interval time.Duration) (bool, error) {
+	const op = "storage.redis.AllowUnlockEmail"
+
+	if interval <= 0 {
+		return true, nil
+	}
+
+	ok, err := r.client.SetNX(ctx, unlockEmailKey(email), 1, interval).Result()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ok, nil
+}
+
+// SaveUnlockToken сохраняет хеш одноразового токена разблокировки из письма.
+func (r *RedisRepo) SaveUnlockToken(ctx context.Context, tokenHash []byte, email string, ttl time.Duration) error {
+	const op = "storage.redis.SaveUnlockToken"
+
+	if err := r.client.Set(ctx, unlockTokenKey(tokenHash), email, ttl).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ConsumeUnlockToken атомарно читает и удаляет токен разблокировки и
+// возвращает email, вход на который он разблокирует.
+func (r *RedisRepo) ConsumeUnlockToken(ctx context.Context, tokenHash []byte) (string, error) {
+	const op = "storage.redis.ConsumeUnlockToken"
+
+	email, err := r.client.GetDel(ctx, unlockTokenKey(tokenHash)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", storage.ErrUnlockTokenNotFound
+		}
+
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return email, nil
+}
+
+// Ключи пары email + IP; ip == "" — ключи email целиком.
+func loginFailuresKey(email, ip string) string {
+	return "lockout:failures:" + email + "|" + ip
+}
+
+func loginLockKey(email, ip string) string {
+	return "lockout:locked:" + email + "|" + ip
+}
+
+func lockoutLevelKey(email, ip string) string {
+	return "lockout:level:" + email + "|" + ip
+}
+
+func lockedIPsKey(email string) string {
+	return "lockout:ips:" + email
+}
+
+func unlockEmailKey(email string) string {
+	return "lockout:unlock_email:" + email
+}
+
+func unlockTokenKey(tokenHash []byte) string {
+	return "lockout:unlock:" + hex.EncodeToString(tokenHash)
+}
@@ -3,6 +3,7 @@ package redis
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"auth_service/internal/models"
@@ -20,6 +21,7 @@ func (r *RedisRepo) SetPendingSession(ctx context.Context, sessionID string, ses
 		"user_id":    session.UserID,
 		"app_id":     session.AppID,
 		"action":     string(session.Action),
+		"scopes":     strings.Join(session.Scopes, " "),
 		"created_at": time.Now().Unix(),
 	}
 
@@ -62,6 +64,7 @@ func (r *RedisRepo) GetPendingSession(ctx context.Context, sessionID string) (*m
 		return nil, fmt.Errorf("%s: pending session missing action: %w", op, storage.ErrPendingSessionNotFound)
 	}
 	session.Action = models.Action(action)
+	session.Scopes = strings.Fields(res["scopes"])
 
 	return session, nil
 }
@@ -0,0 +1,82 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth_service/internal/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const maintenanceKey = "maintenance:window"
+
+type maintenanceWindow struct {
+	Reason   string     `json:"reason"`
+	StartsAt time.Time  `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+}
+
+// MaintenanceWindow возвращает окно обслуживания или nil, если оно не задано.
+func (r *RedisRepo) MaintenanceWindow(ctx context.Context) (*models.MaintenanceWindow, error) {
+	const op = "storage.redis.MaintenanceWindow"
+
+	data, err := r.client.Get(ctx, maintenanceKey).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var w maintenanceWindow
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, fmt.Errorf("%s: unmarshal window: %w", op, err)
+	}
+
+	return &models.MaintenanceWindow{
+		Reason:   w.Reason,
+		StartsAt: w.StartsAt,
+		EndsAt:   w.EndsAt,
+	}, nil
+}
+
+// SetMaintenanceWindow сохраняет окно. Окно с концом удаляется Redis'ом
+// само, открытое живёт до ClearMaintenanceWindow.
+func (r *RedisRepo) SetMaintenanceWindow(ctx context.Context, w models.MaintenanceWindow) error {
+	const op = "storage.redis.SetMaintenanceWindow"
+
+	data, err := json.Marshal(maintenanceWindow{
+		Reason:   w.Reason,
+		StartsAt: w.StartsAt,
+		EndsAt:   w.EndsAt,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: marshal window: %w", op, err)
+	}
+
+	var ttl time.Duration
+	if w.EndsAt != nil {
+		ttl = time.Until(*w.EndsAt)
+	}
+
+	if err := r.client.Set(ctx, maintenanceKey, data, ttl).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *RedisRepo) ClearMaintenanceWindow(ctx context.Context) error {
+	const op = "storage.redis.ClearMaintenanceWindow"
+
+	if err := r.client.Del(ctx, maintenanceKey).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SaveAuthorizationCode сохраняет код авторизации OIDC под его хешем.
+func (r *RedisRepo) SaveAuthorizationCode(
+	ctx context.Context,
+	codeHash []byte,
+	code models.AuthorizationCode,
+	ttl time.Duration,
+) error {
+	const op = "storage.redis.SaveAuthorizationCode"
+
+	data, err := json.Marshal(code)
+	if err != nil {
+		return fmt.Errorf("%s: marshal code: %w", op, err)
+	}
+
+	if err := r.client.Set(ctx, authorizationCodeKey(codeHash), data, ttl).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ConsumeAuthorizationCode атомарно читает и удаляет код (GETDEL) —
+// повторный обмен того же кода получает ErrAuthorizationCodeNotFound.
+func (r *RedisRepo) ConsumeAuthorizationCode(ctx context.Context, codeHash []byte) (*models.AuthorizationCode, error) {
+	const op = "storage.redis.ConsumeAuthorizationCode"
+
+	data, err := r.client.GetDel(ctx, authorizationCodeKey(codeHash)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, storage.ErrAuthorizationCodeNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var code models.AuthorizationCode
+	if err := json.Unmarshal([]byte(data), &code); err != nil {
+		return nil, fmt.Errorf("%s: unmarshal code: %w", op, err)
+	}
+
+	return &code, nil
+}
+
+func authorizationCodeKey(codeHash []byte) string {
+	return "oidc:code:" + hex.EncodeToString(codeHash)
+}
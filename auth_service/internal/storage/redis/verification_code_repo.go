@@ -0,0 +1,84 @@
+package redis
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"auth_service/internal/storage"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// checkVerificationCodeScript сверяет хеш кода и считает неверные вводы.
+// Верный код удаляется (одноразовый), после maxAttempts неверных удаляется
+// тоже — перебрать миллион кодов за несколько попыток нельзя.
+//
+// KEYS[1] - код пользователя (HASH: h - hex хеша, a - неверных вводов)
+// ARGV[1] - hex хеша введённого кода
+// ARGV[2] - maxAttempts
+//
+// Возвращает 1 - код верный, 0 - неверный или его нет.
+var checkVerificationCodeScript = redis.NewScript(`
+	local stored = redis.call('HGET', KEYS[1], 'h')
+	if not stored then
+		return 0
+	end
+
+	if stored == ARGV[1] then
+		redis.call('DEL', KEYS[1])
+		return 1
+	end
+
+	local attempts = redis.call('HINCRBY', KEYS[1], 'a', 1)
+	if attempts >= tonumber(ARGV[2]) then
+		redis.call('DEL', KEYS[1])
+	end
+
+	return 0
+`)
+
+// SaveVerificationCode сохраняет хеш кода подтверждения email. Новый код
+// заменяет прежний вместе со счётчиком попыток.
+func (r *RedisRepo) SaveVerificationCode(ctx context.Context, userID int64, codeHash []byte, ttl time.Duration) error {
+	const op = "storage.redis.SaveVerificationCode"
+
+	key := verificationCodeKey(userID)
+
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.HSet(ctx, key, "h", hex.EncodeToString(codeHash), "a", 0)
+	pipe.Expire(ctx, key, ttl)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ConsumeVerificationCode гасит код, если он совпал. Неверный, истёкший
+// или сгоревший по попыткам код — ErrVerificationCodeNotFound.
+func (r *RedisRepo) ConsumeVerificationCode(ctx context.Context, userID int64, codeHash []byte, maxAttempts int) error {
+	const op = "storage.redis.ConsumeVerificationCode"
+
+	ok, err := checkVerificationCodeScript.Run(ctx, r.client,
+		[]string{verificationCodeKey(userID)},
+		hex.EncodeToString(codeHash),
+		maxAttempts,
+	).Int()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if ok != 1 {
+		return storage.ErrVerificationCodeNotFound
+	}
+
+	return nil
+}
+
+func verificationCodeKey(userID int64) string {
+	return fmt.Sprintf("verify_code:%d", userID)
+}
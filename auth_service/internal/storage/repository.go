@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"net/netip"
+	"time"
+
+	"auth_service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Repository — основное хранилище целиком. Сервисы зависят от своих узких
+// интерфейсов; этот нужен там, где бэкенд выбирается по storage.driver, —
+// при сборке зависимостей в cmd. Реализации: postgres.PostgresRepo и
+// sqldb.SQLRepo (MySQL и SQLite).
+type Repository interface {
+	UoW
+	Tx
+	UserSaver
+	UserProvider
+	AppProvider
+	UserRepo
+	TokenRepo
+	MagicLinkRepo
+	DeviceRepo
+	AuditRepo
+	RoleRepo
+
+	ListUsers(ctx context.Context, afterID int64, limit int) ([]models.User, error)
+
+	CreateApp(ctx context.Context, name, secret string, publicClient bool) (int32, error)
+	SetAppSecret(ctx context.Context, appID int32, secret string) error
+	AppSecret(ctx context.Context, appID int32) (string, error)
+
+	ActiveMagicLinksByUserID(ctx context.Context, userID int64) ([]models.MagicLink, error)
+	CleanupExpiredMagicLinks(ctx context.Context) (int, error)
+
+	SaveIdentity(ctx context.Context, identity *models.Identity) error
+	IdentityBySubject(ctx context.Context, provider string, subject string) (*models.Identity, error)
+	IdentitiesByUserID(ctx context.Context, userID int64) ([]*models.Identity, error)
+	UnlinkIdentity(ctx context.Context, userID int64, provider string) error
+	SaveUserWithIdentity(ctx context.Context, username string, identity *models.Identity, appID int32) (int64, error)
+
+	CreateRole(ctx context.Context, role *models.Role) error
+	RolesByAppID(ctx context.Context, appID int32) ([]models.Role, error)
+	RoleByName(ctx context.Context, appID int32, name string) (*models.Role, error)
+
+	CreateOrganization(ctx context.Context, org *models.Organization, ownerID int64) error
+	OrganizationsByUserID(ctx context.Context, userID int64, appID int32) ([]models.OrgMember, error)
+	OrgMembers(ctx context.Context, orgID int64) ([]models.OrgMember, error)
+	SetOrgMemberRole(ctx context.Context, orgID, userID int64, role models.OrgRole) error
+	RemoveOrgMember(ctx context.Context, orgID, userID int64) error
+	SaveOrgInvitation(ctx context.Context, inv *models.OrgInvitation) error
+	AcceptOrgInvitation(ctx context.Context, tokenHash []byte, userID int64, email string) (*models.OrgInvitation, error)
+	DeclineOrgInvitation(ctx context.Context, tokenHash []byte) (*models.OrgInvitation, error)
+	SaveUserFromOrgInvitation(ctx context.Context, tokenHash []byte, username string, passHash []byte) (int64, *models.OrgInvitation, error)
+
+	ActiveSigningKey(ctx context.Context, appID int32) (*models.SigningKey, error)
+	SigningKeyByKID(ctx context.Context, kid string) (*models.SigningKey, error)
+	RotateSigningKey(ctx context.Context, key *models.SigningKey, retiredExpiresAt time.Time) error
+	PublicSigningKeys(ctx context.Context) ([]models.SigningKey, error)
+	AppsDueForKeyRotation(ctx context.Context, createdBefore time.Time) ([]int32, error)
+	DeleteExpiredSigningKeys(ctx context.Context) (int64, error)
+
+	SaveTOTPSecret(ctx context.Context, userID int64, secretEnc []byte) error
+	TOTPSecret(ctx context.Context, userID int64) (*models.TOTPSecret, error)
+	ConfirmTOTP(ctx context.Context, userID int64, step int64) error
+	UseTOTPStep(ctx context.Context, userID int64, step int64) error
+	DeleteTOTP(ctx context.Context, userID int64) error
+
+	TwoFADelivery(ctx context.Context, userID int64) (*models.TwoFADelivery, error)
+	SetTwoFADelivery(ctx context.Context, userID int64, delivery models.TwoFADelivery) error
+
+	SaveTrustedDevice(ctx context.Context, d *models.TrustedDevice) error
+	TouchTrustedDevice(ctx context.Context, userID int64, tokenHash []byte) (bool, error)
+	TrustedDevices(ctx context.Context, userID int64) ([]models.TrustedDevice, error)
+	DeleteTrustedDevice(ctx context.Context, userID, id int64) error
+	DeleteTrustedDevices(ctx context.Context, userID int64) (int64, error)
+
+	LastLoginLocation(ctx context.Context, userID int64) (*models.GeoLocation, error)
+	SaveLoginLocation(ctx context.Context, userID int64, loc models.GeoLocation) error
+
+	SaveAPIKey(ctx context.Context, key *models.APIKey) error
+	APIKeysByAppID(ctx context.Context, appID int32) ([]models.APIKey, error)
+	APIKeyByID(ctx context.Context, id uuid.UUID) (*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, appID int32, id uuid.UUID) error
+	TouchAPIKey(ctx context.Context, id uuid.UUID) error
+
+	SaveIPRule(ctx context.Context, rule *models.IPRule) error
+	BlockIPTemporarily(ctx context.Context, prefix netip.Prefix, reason, createdBy string, expiresAt time.Time) (bool, error)
+	ActiveIPRules(ctx context.Context) ([]models.IPRule, error)
+	DeleteIPRule(ctx context.Context, id int64) error
+
+	PurgeAuditEvents(ctx context.Context, before time.Time, limit int) (int64, error)
+	PurgeDeletedAccounts(ctx context.Context, before time.Time, limit int) (int64, error)
+	PurgeUsedMagicLinks(ctx context.Context, before time.Time, limit int) (int64, error)
+	PurgeExpiredTrustedDevices(ctx context.Context, before time.Time, limit int) (int64, error)
+	PurgeExpiredRefreshTokens(ctx context.Context, before time.Time, limit int) (int64, error)
+	PurgeExpiredIPRules(ctx context.Context, before time.Time, limit int) (int64, error)
+
+	// TryAdvisoryLock берёт блокировку key без ожидания. ok == false —
+	// её держит другая реплика.
+	TryAdvisoryLock(ctx context.Context, key int64) (lock Lock, ok bool, err error)
+
+	Migrate(ctx context.Context) error
+	// SetPassword — пароль для новых соединений после ротации секрета.
+	SetPassword(password string)
+	Close(ctx context.Context) error
+}
+
+// Lock — блокировка, взятая TryAdvisoryLock. Держится, пока живо её
+// соединение; на ней строится выбор лидера.
+type Lock interface {
+	Alive(ctx context.Context) error
+	Release(ctx context.Context) error
+}
@@ -0,0 +1,156 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const apiKeyColumns = `id, app_id, name, key_hash, scopes, created_by, created_at, expires_at, last_used_at, revoked_at`
+
+func scanAPIKey(row interface{ Scan(dest ...any) error }, key *models.APIKey) error {
+	var scopes jsonArray[string]
+
+	err := row.Scan(
+		&key.ID, &key.AppID, &key.Name, &key.KeyHash, &scopes, &key.CreatedBy,
+		&key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt,
+	)
+	if err != nil {
+		return err
+	}
+	key.Scopes = scopes
+
+	return nil
+}
+
+// * SaveAPIKey сохраняет новый ключ и заполняет created_at.
+func (r *SQLRepo) SaveAPIKey(ctx context.Context, key *models.APIKey) error {
+	const op = "storage.sqldb.SaveAPIKey"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	createdAt := now()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO api_keys (id, app_id, name, key_hash, scopes, created_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, key.ID, key.AppID, key.Name, key.KeyHash, jsonArray[string](key.Scopes), key.CreatedBy, createdAt, key.ExpiresAt)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return storage.ErrAppNotFound
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	key.CreatedAt = createdAt
+
+	return nil
+}
+
+// * APIKeysByAppID — ключи приложения, включая отозванные, новые первыми.
+func (r *SQLRepo) APIKeysByAppID(ctx context.Context, appID int32) ([]models.APIKey, error) {
+	const op = "storage.sqldb.APIKeysByAppID"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE app_id = ?
+		ORDER BY created_at DESC
+	`, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		var key models.APIKey
+		if err := scanAPIKey(rows, &key); err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// * APIKeyByID ищет ключ по id из его открытой части.
+func (r *SQLRepo) APIKeyByID(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	const op = "storage.sqldb.APIKeyByID"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	var key models.APIKey
+
+	err := scanAPIKey(r.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = ?`, id), &key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrAPIKeyNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &key, nil
+}
+
+// * RevokeAPIKey отзывает действующий ключ приложения. Уже отозванный или
+// чужой ключ — ErrAPIKeyNotFound.
+func (r *SQLRepo) RevokeAPIKey(ctx context.Context, appID int32, id uuid.UUID) error {
+	const op = "storage.sqldb.RevokeAPIKey"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	err := r.execOne(ctx, storage.ErrAPIKeyNotFound, `
+		UPDATE api_keys
+		SET revoked_at = ?
+		WHERE id = ? AND app_id = ? AND revoked_at IS NULL
+	`, now(), id, appID)
+	if err != nil {
+		if errors.Is(err, storage.ErrAPIKeyNotFound) {
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// * TouchAPIKey отмечает использование ключа. Обновление не чаще раза в
+// минуту: иначе каждый запрос batch-задачи писал бы в одну строку.
+func (r *SQLRepo) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	const op = "storage.sqldb.TouchAPIKey"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	t := now()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE api_keys
+		SET last_used_at = ?
+		WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)
+	`, t, id, t.Add(-time.Minute))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
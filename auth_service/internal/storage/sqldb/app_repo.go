@@ -0,0 +1,146 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+func (r *SQLRepo) App(ctx context.Context, appID int32) (*models.App, error) {
+	const op = "storage.sqldb.App"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	// TTL хранятся в наносекундах: INTERVAL Postgres здесь не переносится
+	query := `
+		SELECT id, name, secret, access_token_format, signing_alg, redirect_uris, public_client, allowed_scopes, refresh_token_delivery, token_exchange_audiences,
+			COALESCE(access_token_ttl, 0), COALESCE(refresh_token_ttl, 0)
+		FROM apps
+		WHERE id = ?
+	`
+
+	var (
+		a                     models.App
+		redirectURIs, scopes  jsonArray[string]
+		audiences             jsonArray[int32]
+		accessTTL, refreshTTL int64
+	)
+
+	err := r.db.QueryRowContext(ctx, query, appID).Scan(
+		&a.ID,
+		&a.Name,
+		&a.Secret,
+		&a.AccessTokenFormat,
+		&a.SigningAlg,
+		&redirectURIs,
+		&a.PublicClient,
+		&scopes,
+		&a.RefreshTokenDelivery,
+		&audiences,
+		&accessTTL,
+		&refreshTTL,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrAppNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.RedirectURIs = redirectURIs
+	a.AllowedScopes = scopes
+	a.TokenExchangeAudiences = audiences
+	a.AccessTokenTTL = time.Duration(accessTTL)
+	a.RefreshTokenTTL = time.Duration(refreshTTL)
+
+	return &a, nil
+}
+
+// CreateApp регистрирует приложение; остальные настройки берутся из
+// значений колонок по умолчанию. publicClient — OAuth-клиент без секрета.
+func (r *SQLRepo) CreateApp(ctx context.Context, name, secret string, publicClient bool) (int32, error) {
+	const op = "storage.sqldb.CreateApp"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO apps (name, secret, public_client) VALUES (?, ?, ?)`,
+		name, secret, publicClient,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, storage.ErrAppAlreadyExists
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return int32(id), nil
+}
+
+// SetAppSecret заменяет секрет приложения. HS256-токены, подписанные
+// старым секретом, после этого не проходят проверку.
+func (r *SQLRepo) SetAppSecret(ctx context.Context, appID int32, secret string) error {
+	const op = "storage.sqldb.SetAppSecret"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	err := r.execOne(ctx, storage.ErrAppNotFound, `UPDATE apps SET secret = ? WHERE id = ?`, secret, appID)
+	if err != nil && !errors.Is(err, storage.ErrAppNotFound) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return err
+}
+
+func (r *SQLRepo) AppSecret(ctx context.Context, appID int32) (string, error) {
+	const op = "storage.sqldb.AppSecret"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	var secret string
+	err := r.db.QueryRowContext(ctx, `SELECT secret FROM apps WHERE id = ?`, appID).Scan(&secret)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", storage.ErrAppNotFound
+		}
+
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return secret, nil
+}
+
+// IsAppMember проверяет, зарегистрирован ли пользователь в приложении.
+func (r *SQLRepo) IsAppMember(ctx context.Context, userID int64, appID int32) (bool, error) {
+	const op = "storage.sqldb.IsAppMember"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	var n int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM app_members WHERE user_id = ? AND app_id = ?`,
+		userID, appID,
+	).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n > 0, nil
+}
@@ -0,0 +1,129 @@
+package sqldb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"auth_service/internal/models"
+)
+
+// SaveAuditEvent пишет событие в audit_events. Пустые IP и User-Agent
+// сохраняются как NULL.
+func (r *SQLRepo) SaveAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	const op = "storage.sqldb.SaveAuditEvent"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	metadata := event.Metadata
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("%s: marshal metadata: %w", op, err)
+	}
+
+	createdAt := now()
+
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO audit_events (user_id, actor, action, ip, user_agent, metadata, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`,
+		event.UserID,
+		event.Actor,
+		string(event.Action),
+		nullIfEmpty(event.IP),
+		nullIfEmpty(event.UserAgent),
+		string(metadataJSON),
+		createdAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if event.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	event.CreatedAt = createdAt
+
+	return nil
+}
+
+// placeholders — "?, ?, ?" для IN со списком из n значений.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// AuditEventsByUserID возвращает события пользователя с заданными action,
+// от новых к старым. beforeID > 0 — keyset-пагинация: только события с
+// id меньше beforeID.
+func (r *SQLRepo) AuditEventsByUserID(
+	ctx context.Context,
+	userID int64,
+	actions []models.AuditAction,
+	beforeID int64,
+	limit int,
+) ([]models.AuditEvent, error) {
+	const op = "storage.sqldb.AuditEventsByUserID"
+
+	if len(actions) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	args := make([]any, 0, len(actions)+4)
+	args = append(args, userID)
+	for _, a := range actions {
+		args = append(args, string(a))
+	}
+	args = append(args, beforeID, beforeID, limit)
+
+	query := `
+		SELECT id, user_id, actor, action, COALESCE(ip, ''), COALESCE(user_agent, ''), metadata, created_at
+		FROM audit_events
+		WHERE user_id = ?
+			AND action IN (` + placeholders(len(actions)) + `)
+			AND (? = 0 OR id < ?)
+		ORDER BY id DESC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var events []models.AuditEvent
+	for rows.Next() {
+		var (
+			e            models.AuditEvent
+			action       string
+			metadataJSON []byte
+		)
+
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Actor, &action, &e.IP, &e.UserAgent, &metadataJSON, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+
+		e.Action = models.AuditAction(action)
+
+		if err := json.Unmarshal(metadataJSON, &e.Metadata); err != nil {
+			return nil, fmt.Errorf("%s: unmarshal metadata: %w", op, err)
+		}
+
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows: %w", op, err)
+	}
+
+	return events, nil
+}
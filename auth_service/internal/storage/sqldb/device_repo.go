@@ -0,0 +1,87 @@
+package sqldb
+
+import (
+	"context"
+	"fmt"
+
+	"auth_service/internal/models"
+)
+
+// * TouchKnownDevice запоминает устройство пользователя или обновляет время
+// последнего входа с него. inserted — устройство встречено впервые,
+// hadDevices — до этого у пользователя были другие известные устройства.
+func (r *SQLRepo) TouchKnownDevice(
+	ctx context.Context,
+	userID int64,
+	fp models.Fingerprint,
+) (inserted, hadDevices bool, err error) {
+	const op = "storage.sqldb.TouchKnownDevice"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	// RETURNING (xmax = 0) из Postgres здесь нет: устройства пользователя
+	// считаются и обновляются в одной транзакции под блокировкой строки
+	// пользователя, чтобы два входа с нового устройства не вставили его оба
+	err = r.inTx(ctx, func(q querier) error {
+		if _, err := r.lockUserDeletedAt(ctx, q, userID); err != nil {
+			return err
+		}
+
+		var total, same int
+		if err := q.QueryRowContext(ctx, `
+			SELECT COUNT(*), COALESCE(SUM(CASE WHEN fingerprint = ? THEN 1 ELSE 0 END), 0)
+			FROM known_devices
+			WHERE user_id = ?
+		`, fp.Hash, userID).Scan(&total, &same); err != nil {
+			return err
+		}
+
+		t := now()
+		hadDevices = total > 0
+
+		if same > 0 {
+			_, err := q.ExecContext(ctx, `
+				UPDATE known_devices
+				SET ip = ?, user_agent = ?, last_seen_at = ?
+				WHERE user_id = ? AND fingerprint = ?
+			`, nullIfEmpty(fp.IP), nullIfEmpty(fp.UserAgent), t, userID, fp.Hash)
+			return err
+		}
+
+		inserted = true
+
+		_, err := q.ExecContext(ctx, `
+			INSERT INTO known_devices (user_id, fingerprint, ip, user_agent, first_seen_at, last_seen_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, userID, fp.Hash, nullIfEmpty(fp.IP), nullIfEmpty(fp.UserAgent), t, t)
+		return err
+	})
+	if err != nil {
+		return false, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return inserted, hadDevices, nil
+}
+
+// * KnownDevice сообщает, входил ли пользователь с устройства раньше.
+// hasDevices == false — известных устройств нет вовсе (первый вход или
+// аккаунт, созданный до учёта устройств).
+func (r *SQLRepo) KnownDevice(ctx context.Context, userID int64, fingerprint []byte) (known, hasDevices bool, err error) {
+	const op = "storage.sqldb.KnownDevice"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	var total, same int
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN fingerprint = ? THEN 1 ELSE 0 END), 0)
+		FROM known_devices
+		WHERE user_id = ?
+	`, fingerprint, userID).Scan(&total, &same)
+	if err != nil {
+		return false, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return same > 0, total > 0, nil
+}
@@ -0,0 +1,143 @@
+package sqldb
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"modernc.org/sqlite"
+)
+
+// dialect — различия MySQL и SQLite, которые не укладываются в общий SQL.
+type dialect int
+
+const (
+	dialectMySQL dialect = iota
+	dialectSQLite
+)
+
+const (
+	mysqlErrDupEntry   = 1062
+	mysqlErrNoRefRow   = 1452
+	sqliteErrConstrain = 19
+)
+
+func (d dialect) String() string {
+	if d == dialectSQLite {
+		return "sqlite"
+	}
+
+	return "mysql"
+}
+
+// forUpdate — блокировка читаемых строк до конца транзакции. В SQLite
+// транзакции открываются с _txlock=immediate и уже держат блокировку
+// записи на весь файл, отдельная блокировка строк не нужна.
+func (d dialect) forUpdate() string {
+	if d == dialectSQLite {
+		return ""
+	}
+
+	return " FOR UPDATE"
+}
+
+// upsert — хвост INSERT, обновляющий columns значениями из вставки при
+// конфликте по conflict. MySQL конфликтует по любому уникальному ключу,
+// поэтому conflict ему не нужен.
+func (d dialect) upsert(conflict string, columns ...string) string {
+	sets := make([]string, len(columns))
+
+	if d == dialectSQLite {
+		for i, col := range columns {
+			sets[i] = col + " = excluded." + col
+		}
+		return " ON CONFLICT (" + conflict + ") DO UPDATE SET " + strings.Join(sets, ", ")
+	}
+
+	for i, col := range columns {
+		sets[i] = col + " = VALUES(" + col + ")"
+	}
+	return " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+}
+
+// insertIgnore — INSERT, молча пропускающий строки с конфликтом ключа.
+func (d dialect) insertIgnore() string {
+	if d == dialectSQLite {
+		return "INSERT OR IGNORE"
+	}
+
+	return "INSERT IGNORE"
+}
+
+// uniqueKey — уникальный ключ так, как его называют ошибки СУБД: MySQL
+// пишет имя индекса, SQLite — список колонок "таблица.колонка".
+type uniqueKey struct {
+	name    string
+	columns string
+}
+
+var (
+	keyUsersUsername = uniqueKey{"uq_users_username", "users.username"}
+
+	keyIdentitiesProviderSubject = uniqueKey{"uq_identities_provider_subject", "identities.provider, identities.subject"}
+	keyIdentitiesUserProvider    = uniqueKey{"uq_identities_user_provider", "identities.user_id, identities.provider"}
+)
+
+// isUniqueViolation сообщает, нарушен ли какой-либо уникальный ключ.
+func isUniqueViolation(err error) bool {
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		return myErr.Number == mysqlErrDupEntry
+	}
+
+	var liteErr *sqlite.Error
+	if errors.As(err, &liteErr) {
+		return liteErr.Code()&0xff == sqliteErrConstrain &&
+			(strings.Contains(liteErr.Error(), "UNIQUE constraint failed") ||
+				strings.Contains(liteErr.Error(), "PRIMARY KEY constraint failed"))
+	}
+
+	return false
+}
+
+// violates сообщает, нарушен ли именно ключ key.
+func violates(err error, key uniqueKey) bool {
+	if !isUniqueViolation(err) {
+		return false
+	}
+
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		return strings.Contains(myErr.Message, key.name+"'")
+	}
+
+	return strings.HasSuffix(strings.TrimSpace(stripCode(err.Error())), key.columns)
+}
+
+// isForeignKeyViolation сообщает о ссылке на несуществующую строку. Какой
+// именно внешний ключ нарушен, SQLite не сообщает: там, где это важно,
+// вызывающий уточняет причину отдельным запросом.
+func isForeignKeyViolation(err error) bool {
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		return myErr.Number == mysqlErrNoRefRow
+	}
+
+	var liteErr *sqlite.Error
+	if errors.As(err, &liteErr) {
+		return liteErr.Code()&0xff == sqliteErrConstrain &&
+			strings.Contains(liteErr.Error(), "FOREIGN KEY constraint failed")
+	}
+
+	return false
+}
+
+// stripCode отрезает код ошибки, который modernc.org/sqlite дописывает в
+// конец сообщения: "UNIQUE constraint failed: users.username (2067)".
+func stripCode(msg string) string {
+	if i := strings.LastIndex(msg, " ("); i >= 0 && strings.HasSuffix(msg, ")") {
+		return msg[:i]
+	}
+
+	return msg
+}
@@ -0,0 +1,230 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// * SaveIdentity привязывает внешнюю учётку к существующему пользователю.
+func (r *SQLRepo) SaveIdentity(ctx context.Context, identity *models.Identity) error {
+	const op = "storage.sqldb.SaveIdentity"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	if err := insertIdentity(ctx, r.db, identity.UserID, identity); err != nil {
+		return fmt.Errorf("%s: %w", op, identityInsertError(err))
+	}
+
+	return nil
+}
+
+func insertIdentity(ctx context.Context, q querier, userID int64, identity *models.Identity) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO identities (user_id, kind, provider, subject, email, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, userID, string(identity.Kind), identity.Provider, identity.Subject, identity.Email, now())
+
+	return err
+}
+
+const identityColumns = `id, user_id, kind, provider, subject, email, created_at`
+
+// * IdentityBySubject — основной lookup при входе через внешний провайдер.
+func (r *SQLRepo) IdentityBySubject(
+	ctx context.Context,
+	provider string,
+	subject string,
+) (*models.Identity, error) {
+	const op = "storage.sqldb.IdentityBySubject"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	var i models.Identity
+	err := r.db.QueryRowContext(ctx,
+		`SELECT `+identityColumns+` FROM identities WHERE provider = ? AND subject = ?`,
+		provider, subject,
+	).Scan(&i.ID, &i.UserID, &i.Kind, &i.Provider, &i.Subject, &i.Email, &i.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrIdentityNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &i, nil
+}
+
+// * IdentitiesByUserID — список привязанных учёток, для профиля/настроек.
+func (r *SQLRepo) IdentitiesByUserID(ctx context.Context, userID int64) ([]*models.Identity, error) {
+	const op = "storage.sqldb.IdentitiesByUserID"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+identityColumns+` FROM identities WHERE user_id = ? ORDER BY created_at, id`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var identities []*models.Identity
+	for rows.Next() {
+		var i models.Identity
+		if err := rows.Scan(&i.ID, &i.UserID, &i.Kind, &i.Provider, &i.Subject, &i.Email, &i.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		identities = append(identities, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return identities, nil
+}
+
+// * HasIdentities проверяет, есть ли у пользователя хотя бы одна привязанная внешняя учётка.
+func (r *SQLRepo) HasIdentities(ctx context.Context, userID int64) (bool, error) {
+	const op = "storage.sqldb.HasIdentities"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	var n int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM identities WHERE user_id = ?`, userID).Scan(&n); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n > 0, nil
+}
+
+// * UnlinkIdentity отвязывает provider от юзера.
+func (r *SQLRepo) UnlinkIdentity(ctx context.Context, userID int64, provider string) error {
+	const op = "storage.sqldb.UnlinkIdentity"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	err := r.inTx(ctx, func(q querier) error {
+		// блокировка строки юзера закрывает гонку двух параллельных unlink,
+		// как в postgres.UnlinkIdentity
+		var hasPassword bool
+		err := q.QueryRowContext(ctx,
+			`SELECT password_hash IS NOT NULL FROM users WHERE id = ?`+r.dialect.forUpdate(),
+			userID,
+		).Scan(&hasPassword)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return storage.ErrUserNotFound
+			}
+			return fmt.Errorf("lock user: %w", err)
+		}
+
+		if !hasPassword {
+			var remaining int
+			err := q.QueryRowContext(ctx,
+				`SELECT COUNT(*) FROM identities WHERE user_id = ? AND provider <> ?`,
+				userID, provider,
+			).Scan(&remaining)
+			if err != nil {
+				return fmt.Errorf("count accounts: %w", err)
+			}
+			if remaining == 0 {
+				return storage.ErrLastAuthMethod
+			}
+		}
+
+		res, err := q.ExecContext(ctx, `DELETE FROM identities WHERE user_id = ? AND provider = ?`, userID, provider)
+		if err != nil {
+			return fmt.Errorf("delete: %w", err)
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return storage.ErrIdentityNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUserNotFound),
+			errors.Is(err, storage.ErrLastAuthMethod),
+			errors.Is(err, storage.ErrIdentityNotFound):
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// * SaveUserWithIdentity регистрирует через внешний провайдер юзера, у
+// которого ещё нет аккаунта. Email берётся из identity и считается
+// подтверждённым провайдером.
+func (r *SQLRepo) SaveUserWithIdentity(
+	ctx context.Context,
+	username string,
+	identity *models.Identity,
+	appID int32,
+) (int64, error) {
+	const op = "storage.sqldb.SaveUserWithIdentity"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	var userID int64
+
+	err := r.inTx(ctx, func(q querier) error {
+		var err error
+		if userID, err = insertUser(ctx, q, identity.Email, username, nil, true); err != nil {
+			if isUniqueViolation(err) {
+				return storage.ErrUserAlreadyExists
+			}
+			return fmt.Errorf("insert user: %w", err)
+		}
+
+		if err := insertIdentity(ctx, q, userID, identity); err != nil {
+			return fmt.Errorf("insert identity: %w", identityInsertError(err))
+		}
+
+		if err := addAppMember(ctx, q, userID, appID); err != nil {
+			return fmt.Errorf("insert app member: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrUserAlreadyExists) {
+			return 0, err
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+// identityInsertError переводит нарушения уникальности identities в
+// ошибки storage.
+func identityInsertError(err error) error {
+	switch {
+	case violates(err, keyIdentitiesProviderSubject):
+		return storage.ErrIdentityAlreadyLinked
+	case violates(err, keyIdentitiesUserProvider):
+		return storage.ErrProviderAlreadyLinked
+	}
+
+	return err
+}
@@ -0,0 +1,175 @@
+package sqldb
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// * SaveIPRule сохраняет правило; id и created_at заполняются здесь. На
+// одну сеть — одно правило, повтор — ErrIPRuleExists.
+func (r *SQLRepo) SaveIPRule(ctx context.Context, rule *models.IPRule) error {
+	const op = "storage.sqldb.SaveIPRule"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	createdAt := now()
+
+	// сеть хранится строкой в каноническом виде, как её приводит тип
+	// CIDR Postgres, — иначе 10.0.0.1/8 и 10.0.0.0/8 не совпали бы по
+	// уникальному ключу
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO ip_rules (cidr, action, reason, created_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, rule.Prefix.Masked().String(), string(rule.Action), rule.Reason, rule.CreatedBy, createdAt, rule.ExpiresAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrIPRuleExists
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rule.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	rule.CreatedAt = createdAt
+
+	return nil
+}
+
+// * BlockIPTemporarily ставит временный deny на сеть, если для неё нет
+// правила. Истёкшее правило заменяется; действующее (allow или
+// бессрочный deny) не трогается — тогда false.
+func (r *SQLRepo) BlockIPTemporarily(
+	ctx context.Context,
+	prefix netip.Prefix,
+	reason, createdBy string,
+	expiresAt time.Time,
+) (bool, error) {
+	const op = "storage.sqldb.BlockIPTemporarily"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	cidr := prefix.Masked().String()
+	blocked := false
+
+	err := r.inTx(ctx, func(q querier) error {
+		t := now()
+
+		var current *time.Time
+		err := q.QueryRowContext(ctx,
+			`SELECT expires_at FROM ip_rules WHERE cidr = ?`+r.dialect.forUpdate(),
+			cidr,
+		).Scan(&current)
+
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			_, err := q.ExecContext(ctx, `
+				INSERT INTO ip_rules (cidr, action, reason, created_by, created_at, expires_at)
+				VALUES (?, 'deny', ?, ?, ?, ?)
+			`, cidr, reason, createdBy, t, expiresAt)
+			if isUniqueViolation(err) {
+				// правило вставили параллельно — оно действующее
+				return nil
+			}
+			blocked = err == nil
+			return err
+		case err != nil:
+			return err
+		case current == nil || current.After(t):
+			return nil
+		}
+
+		_, err = q.ExecContext(ctx, `
+			UPDATE ip_rules
+			SET action = 'deny', reason = ?, created_by = ?, created_at = ?, expires_at = ?
+			WHERE cidr = ?
+		`, reason, createdBy, t, expiresAt, cidr)
+		blocked = err == nil
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return blocked, nil
+}
+
+// * ActiveIPRules — правила без срока или с ещё не наступившим, самые
+// узкие сети первыми.
+func (r *SQLRepo) ActiveIPRules(ctx context.Context) ([]models.IPRule, error) {
+	const op = "storage.sqldb.ActiveIPRules"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, cidr, action, reason, created_by, created_at, expires_at
+		FROM ip_rules
+		WHERE expires_at IS NULL OR expires_at > ?
+	`, now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var rules []models.IPRule
+	for rows.Next() {
+		var (
+			rule models.IPRule
+			cidr string
+		)
+		err := rows.Scan(
+			&rule.ID, &cidr, &rule.Action, &rule.Reason,
+			&rule.CreatedBy, &rule.CreatedAt, &rule.ExpiresAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+
+		if rule.Prefix, err = netip.ParsePrefix(cidr); err != nil {
+			return nil, fmt.Errorf("%s: rule %d: %w", op, rule.ID, err)
+		}
+
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// masklen() в SQL нет: сеть — строка
+	slices.SortFunc(rules, func(a, b models.IPRule) int {
+		if c := cmp.Compare(b.Prefix.Bits(), a.Prefix.Bits()); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return rules, nil
+}
+
+// * DeleteIPRule удаляет правило по id.
+func (r *SQLRepo) DeleteIPRule(ctx context.Context, id int64) error {
+	const op = "storage.sqldb.DeleteIPRule"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	err := r.execOne(ctx, storage.ErrIPRuleNotFound, `DELETE FROM ip_rules WHERE id = ?`, id)
+	if err != nil && !errors.Is(err, storage.ErrIPRuleNotFound) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return err
+}
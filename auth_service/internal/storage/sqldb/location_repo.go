@@ -0,0 +1,65 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// * LastLoginLocation возвращает место последнего входа пользователя.
+func (r *SQLRepo) LastLoginLocation(ctx context.Context, userID int64) (*models.GeoLocation, error) {
+	const op = "storage.sqldb.LastLoginLocation"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT ip, country, city, latitude, longitude, logged_in_at
+		FROM login_locations
+		WHERE user_id = ?
+	`
+
+	var loc models.GeoLocation
+
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&loc.IP,
+		&loc.Country,
+		&loc.City,
+		&loc.Latitude,
+		&loc.Longitude,
+		&loc.SeenAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrLoginLocationNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &loc, nil
+}
+
+// * SaveLoginLocation запоминает место входа вместо предыдущего.
+func (r *SQLRepo) SaveLoginLocation(ctx context.Context, userID int64, loc models.GeoLocation) error {
+	const op = "storage.sqldb.SaveLoginLocation"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	query := `
+		INSERT INTO login_locations (user_id, ip, country, city, latitude, longitude, logged_in_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	` + r.dialect.upsert("user_id", "ip", "country", "city", "latitude", "longitude", "logged_in_at")
+
+	_, err := r.db.ExecContext(ctx, query, userID, loc.IP, loc.Country, loc.City, loc.Latitude, loc.Longitude, loc.SeenAt.UTC())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"auth_service/internal/storage"
+)
+
+// mysqlLock — именованная блокировка GET_LOCK, удерживаемая на выделенном
+// соединении. Как и advisory lock в Postgres, MySQL снимает её сам, если
+// соединение рвётся, — на ней строится выбор лидера.
+type mysqlLock struct {
+	conn *sql.Conn
+	name string
+}
+
+// memLock — блокировка SQLite. Базу-файл открывает один процесс, поэтому
+// реплика всегда одна и блокировки в памяти процесса достаточно.
+type memLock struct {
+	locks *memLocks
+	key   int64
+}
+
+type memLocks struct {
+	mu   sync.Mutex
+	held map[int64]bool
+}
+
+func newMemLocks() *memLocks {
+	return &memLocks{held: make(map[int64]bool)}
+}
+
+// lockName — имя блокировки MySQL: они общие на весь сервер, а не на базу.
+func lockName(key int64) string {
+	return "auth_service:" + strconv.FormatInt(key, 10)
+}
+
+// TryAdvisoryLock пытается взять блокировку без ожидания. ok == false —
+// блокировку держит другая реплика.
+func (r *SQLRepo) TryAdvisoryLock(ctx context.Context, key int64) (lock storage.Lock, ok bool, err error) {
+	const op = "storage.sqldb.TryAdvisoryLock"
+
+	if r.dialect == dialectSQLite {
+		r.locks.mu.Lock()
+		defer r.locks.mu.Unlock()
+
+		if r.locks.held[key] {
+			return nil, false, nil
+		}
+		r.locks.held[key] = true
+
+		return &memLock{locks: r.locks, key: key}, true, nil
+	}
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	conn, err := r.pool.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	name := lockName(key)
+
+	var got sql.NullInt64
+	if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, 0)`, name).Scan(&got); err != nil {
+		_ = conn.Close()
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if got.Int64 != 1 {
+		_ = conn.Close()
+		return nil, false, nil
+	}
+
+	return &mysqlLock{conn: conn, name: name}, true, nil
+}
+
+// Alive проверяет, что соединение, на котором держится блокировка, живо.
+func (l *mysqlLock) Alive(ctx context.Context) error {
+	const op = "storage.sqldb.mysqlLock.Alive"
+
+	if err := l.conn.PingContext(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Release снимает блокировку и возвращает соединение в пул. Если
+// RELEASE_LOCK не прошёл, соединение выбрасывается из пула — иначе
+// блокировка уехала бы в пул вместе с ним.
+func (l *mysqlLock) Release(ctx context.Context) error {
+	const op = "storage.sqldb.mysqlLock.Release"
+
+	if _, err := l.conn.ExecContext(ctx, `SELECT RELEASE_LOCK(?)`, l.name); err != nil {
+		_ = l.conn.Raw(func(any) error { return driver.ErrBadConn })
+		_ = l.conn.Close()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return l.conn.Close()
+}
+
+func (l *memLock) Alive(context.Context) error { return nil }
+
+func (l *memLock) Release(context.Context) error {
+	l.locks.mu.Lock()
+	defer l.locks.mu.Unlock()
+
+	delete(l.locks.held, l.key)
+
+	return nil
+}
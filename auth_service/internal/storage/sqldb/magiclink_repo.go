@@ -0,0 +1,248 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// * SaveMagicLink сохраняет magic link
+func (r *SQLRepo) SaveMagicLink(ctx context.Context, link *models.MagicLink) error {
+	const op = "storage.sqldb.SaveMagicLink"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	createdAt := now()
+
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO magic_links (
+			user_id,
+			app_id,
+			token_hash,
+			session_id,
+			expires_at,
+			created_at,
+			ip_address,
+			user_agent
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		link.UserID,
+		link.AppID,
+		link.TokenHash,
+		link.SessionID,
+		link.ExpiresAt,
+		createdAt,
+		nullIfEmpty(link.IPAddress),
+		nullIfEmpty(link.UserAgent),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if link.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	link.CreatedAt = createdAt
+
+	return nil
+}
+
+// * ConsumeMagicLink атомарно проверяет и инвалидирует magic link по хешу токена.
+func (r *SQLRepo) ConsumeMagicLink(ctx context.Context, tokenHash []byte) (*models.MagicLink, error) {
+	const op = "storage.sqldb.ConsumeMagicLink"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	link := &models.MagicLink{}
+
+	// UPDATE ... RETURNING нет в MySQL: ссылка читается под блокировкой и
+	// гасится в той же транзакции, второй параллельный вызов её не найдёт
+	err := r.inTx(ctx, func(q querier) error {
+		t := now()
+
+		err := q.QueryRowContext(ctx, `
+			SELECT id, user_id, app_id, token_hash, session_id, expires_at, created_at
+			FROM magic_links
+			WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?`+r.dialect.forUpdate(),
+			tokenHash, t,
+		).Scan(&link.ID, &link.UserID, &link.AppID, &link.TokenHash, &link.SessionID, &link.ExpiresAt, &link.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.ExecContext(ctx, `UPDATE magic_links SET used_at = ? WHERE id = ?`, t, link.ID); err != nil {
+			return err
+		}
+		link.UsedAt = &t
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrMagicLinkNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return link, nil
+}
+
+// * ActiveMagicLinksByUserID возвращает неиспользованные и неистёкшие
+// magic links пользователя, новые первыми.
+func (r *SQLRepo) ActiveMagicLinksByUserID(ctx context.Context, userID int64) ([]models.MagicLink, error) {
+	const op = "storage.sqldb.ActiveMagicLinksByUserID"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, app_id, session_id,
+			COALESCE(ip_address, ''), COALESCE(user_agent, ''), expires_at, created_at
+		FROM magic_links
+		WHERE user_id = ? AND used_at IS NULL AND expires_at > ?
+		ORDER BY created_at DESC
+	`, userID, now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var links []models.MagicLink
+	for rows.Next() {
+		var l models.MagicLink
+		err := rows.Scan(&l.ID, &l.UserID, &l.AppID, &l.SessionID, &l.IPAddress, &l.UserAgent, &l.ExpiresAt, &l.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		links = append(links, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
+
+// * InvalidateMagicLinksByUserID инвалидирует все активные magic links пользователя
+func (r *SQLRepo) InvalidateMagicLinksByUserID(ctx context.Context, userID int64) (int64, error) {
+	const op = "storage.sqldb.InvalidateMagicLinksByUserID"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	n, err := invalidateMagicLinks(ctx, r.db, userID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+func invalidateMagicLinks(ctx context.Context, q querier, userID int64) (int64, error) {
+	t := now()
+
+	res, err := q.ExecContext(ctx, `
+		UPDATE magic_links
+		SET used_at = ?
+		WHERE user_id = ? AND used_at IS NULL AND expires_at > ?
+	`, t, userID, t)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// * EnableMagicLink2FA включает magic-link 2FA пользователю.
+func (r *SQLRepo) EnableMagicLink2FA(ctx context.Context, userID int64) error {
+	const op = "storage.sqldb.EnableMagicLink2FA"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	t := now()
+
+	err := r.execOne(ctx, storage.ErrUserNotFound, `
+		UPDATE users
+		SET is_2fa_enabled = TRUE,
+			two_fa_method = 'magic_link',
+			two_fa_enabled_at = ?,
+			updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`, t, t, userID)
+	if err != nil && !errors.Is(err, storage.ErrUserNotFound) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return err
+}
+
+// * DisableMagicLink2FA отключает 2FA пользователю.
+func (r *SQLRepo) DisableMagicLink2FA(ctx context.Context, userID int64) error {
+	const op = "storage.sqldb.DisableMagicLink2FA"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	err := r.execOne(ctx, storage.ErrUserNotFound, `
+		UPDATE users
+		SET is_2fa_enabled = FALSE,
+			two_fa_method = NULL,
+			two_fa_enabled_at = NULL,
+			updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`, now(), userID)
+	if err != nil && !errors.Is(err, storage.ErrUserNotFound) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return err
+}
+
+func (r *SQLRepo) TwoFAStatus(ctx context.Context, userID int64) (*models.TwoFAStatus, error) {
+	const op = "storage.sqldb.TwoFAStatus"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	status := &models.TwoFAStatus{}
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT is_2fa_enabled, two_fa_method, password_hash IS NOT NULL
+		FROM users
+		WHERE id = ? AND deleted_at IS NULL
+	`, userID).Scan(&status.IsEnabled, &status.Method, &status.HasPassword)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return status, nil
+}
+
+// * CleanupExpiredMagicLinks удаляет ссылки, истёкшие больше суток назад, —
+// то же, что функция cleanup_expired_magic_links() в Postgres.
+func (r *SQLRepo) CleanupExpiredMagicLinks(ctx context.Context) (int, error) {
+	const op = "storage.sqldb.CleanupExpiredMagicLinks"
+
+	ctx, cancel := r.queryCtx(ctx, queryCleanup)
+	defer cancel()
+
+	n, err := r.execCount(ctx, `DELETE FROM magic_links WHERE expires_at < ?`, now().Add(-24*time.Hour))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return int(n), nil
+}
@@ -0,0 +1,312 @@
+package sqldb
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	sl "auth_service/internal/lib/logger"
+)
+
+// Миграции лежат отдельно от auth_service/migrations: там схема Postgres,
+// здесь — её перевод на каждый диалект, по каталогу на диалект.
+//
+//go:embed migrations/*/*.sql
+var migrationsFS embed.FS
+
+// ErrSchemaOutdated — в БД применены не все миграции, встроенные в бинарник.
+var ErrSchemaOutdated = errors.New("database schema is outdated, run with --migrate")
+
+// migrationLock — блокировка MySQL на время применения миграций: реплики,
+// запущенные с --migrate одновременно, применяют их по очереди.
+const migrationLock = "auth_service:migrate"
+
+// Таблица версий совместима с goose, как и у storage/postgres.
+const versionTable = "goose_db_version"
+
+type migration struct {
+	version int64
+	name    string
+	up      string
+}
+
+// Migrate применяет невыполненные миграции по возрастанию версии. В SQLite
+// миграция выполняется в одной транзакции с записью версии. DDL в MySQL
+// коммитится неявно, поэтому миграции для него пишутся так, чтобы
+// упавшую на середине можно было дочистить руками и повторить.
+func (r *SQLRepo) Migrate(ctx context.Context) error {
+	const op = "storage.sqldb.Migrate"
+
+	all, err := loadMigrations(r.dialect)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	conn, err := r.pool.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer conn.Close()
+
+	if r.dialect == dialectMySQL {
+		var got sql.NullInt64
+		if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, -1)`, migrationLock).Scan(&got); err != nil {
+			return fmt.Errorf("%s: lock: %w", op, err)
+		}
+		if got.Int64 != 1 {
+			return fmt.Errorf("%s: lock: GET_LOCK returned %v", op, got)
+		}
+		defer func() {
+			if _, err := conn.ExecContext(context.Background(), `SELECT RELEASE_LOCK(?)`, migrationLock); err != nil {
+				r.log.Error("failed to release migration lock", sl.Err(err))
+			}
+		}()
+	}
+
+	if err := r.ensureVersionTable(ctx, conn); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, m := range all {
+		if applied[m.version] {
+			continue
+		}
+
+		if err := r.apply(ctx, conn, m); err != nil {
+			return fmt.Errorf("%s: apply %s: %w", op, m.name, err)
+		}
+
+		r.log.Info("migration applied", slog.Int64("version", m.version), slog.String("name", m.name))
+	}
+
+	return nil
+}
+
+func (r *SQLRepo) apply(ctx context.Context, conn *sql.Conn, m migration) error {
+	record := `INSERT INTO ` + versionTable + ` (version_id, is_applied) VALUES (?, TRUE)`
+
+	// драйвер MySQL без multiStatements выполняет по одному запросу
+	if r.dialect == dialectMySQL {
+		for _, stmt := range splitStatements(m.up) {
+			if _, err := conn.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+
+		_, err := conn.ExecContext(ctx, record, m.version)
+		return err
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, m.up); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, m.version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkSchema сверяет применённые миграции со встроенными, как
+// postgres.checkSchema.
+func (r *SQLRepo) checkSchema(ctx context.Context) error {
+	const op = "storage.sqldb.checkSchema"
+
+	all, err := loadMigrations(r.dialect)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	exists, err := r.versionTableExists(ctx, r.pool)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	applied := map[int64]bool{}
+	if exists {
+		if applied, err = appliedVersions(ctx, r.pool); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	var pending []string
+	for _, m := range all {
+		if !applied[m.version] {
+			pending = append(pending, m.name)
+		}
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("%s: %w: pending %s", op, ErrSchemaOutdated, strings.Join(pending, ", "))
+	}
+
+	return nil
+}
+
+func (r *SQLRepo) versionTableExists(ctx context.Context, q querier) (bool, error) {
+	query := `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?`
+	if r.dialect == dialectSQLite {
+		query = `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`
+	}
+
+	var n int
+	if err := q.QueryRowContext(ctx, query, versionTable).Scan(&n); err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (r *SQLRepo) ensureVersionTable(ctx context.Context, conn *sql.Conn) error {
+	exists, err := r.versionTableExists(ctx, conn)
+	if err != nil || exists {
+		return err
+	}
+
+	create := `
+		CREATE TABLE ` + versionTable + ` (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			version_id BIGINT NOT NULL,
+			is_applied BOOLEAN NOT NULL,
+			tstamp TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+	if r.dialect == dialectSQLite {
+		create = `
+			CREATE TABLE ` + versionTable + ` (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				version_id INTEGER NOT NULL,
+				is_applied INTEGER NOT NULL,
+				tstamp TIMESTAMP DEFAULT (datetime('now'))
+			)
+		`
+	}
+
+	if _, err := conn.ExecContext(ctx, create); err != nil {
+		return err
+	}
+
+	// нулевая версия — как у goose, иначе goose CLI считает таблицу пустой
+	_, err = conn.ExecContext(ctx, `INSERT INTO `+versionTable+` (version_id, is_applied) VALUES (0, TRUE)`)
+	return err
+}
+
+// appliedVersions — версии, последняя запись которых is_applied: откат
+// через goose CLI дописывает строку с is_applied = FALSE.
+func appliedVersions(ctx context.Context, q querier) (map[int64]bool, error) {
+	rows, err := q.QueryContext(ctx, `SELECT version_id, is_applied FROM `+versionTable+` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]bool{}
+
+	for rows.Next() {
+		var (
+			version   int64
+			isApplied bool
+		)
+		if err := rows.Scan(&version, &isApplied); err != nil {
+			return nil, err
+		}
+		applied[version] = isApplied
+	}
+
+	return applied, rows.Err()
+}
+
+// loadMigrations читает встроенные миграции диалекта
+// (migrations/<dialect>/<version>_<name>.sql) и берёт из них секцию Up.
+func loadMigrations(d dialect) ([]migration, error) {
+	files, err := fs.Glob(migrationsFS, "migrations/"+d.String()+"/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]migration, 0, len(files))
+
+	for _, file := range files {
+		name := strings.TrimSuffix(path.Base(file), ".sql")
+
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", file, err)
+		}
+
+		content, err := fs.ReadFile(migrationsFS, file)
+		if err != nil {
+			return nil, err
+		}
+
+		_, rest, ok := strings.Cut(string(content), "-- +goose Up")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: missing -- +goose Up", file)
+		}
+		up, _, _ := strings.Cut(rest, "-- +goose Down")
+
+		result = append(result, migration{version: version, name: name, up: up})
+	}
+
+	slices.SortFunc(result, func(a, b migration) int {
+		return cmp.Compare(a.version, b.version)
+	})
+
+	for i := 1; i < len(result); i++ {
+		if result[i].version == result[i-1].version {
+			return nil, fmt.Errorf("duplicate migration version %d", result[i].version)
+		}
+	}
+
+	return result, nil
+}
+
+// splitStatements режет секцию на запросы по ";" в конце строки. В
+// миграциях MySQL нет триггеров и процедур, так что ";" внутри запроса не
+// встречается.
+func splitStatements(section string) []string {
+	var stmts []string
+
+	for stmt := range strings.SplitSeq(section, ";\n") {
+		stmt = strings.TrimSpace(stmt)
+		stmt = strings.TrimSuffix(stmt, ";")
+		if stmt == "" || isComment(stmt) {
+			continue
+		}
+		stmts = append(stmts, stmt)
+	}
+
+	return stmts
+}
+
+// isComment — фрагмент целиком из строк-комментариев.
+func isComment(stmt string) bool {
+	for line := range strings.SplitSeq(stmt, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,12 @@
+-- +goose Up
+-- disabled — обратимое отключение аккаунта, отдельное от модерационного
+-- suspended.
+ALTER TABLE users DROP CHECK chk_users_status;
+ALTER TABLE users ADD CONSTRAINT chk_users_status
+  CHECK (status IN ('active', 'disabled', 'suspended', 'banned', 'pending_deletion'));
+
+-- +goose Down
+UPDATE users SET status = 'suspended' WHERE status = 'disabled';
+ALTER TABLE users DROP CHECK chk_users_status;
+ALTER TABLE users ADD CONSTRAINT chk_users_status
+  CHECK (status IN ('active', 'suspended', 'banned', 'pending_deletion'));
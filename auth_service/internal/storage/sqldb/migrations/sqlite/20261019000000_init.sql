@@ -0,0 +1,329 @@
+-- +goose Up
+-- Схема storage/postgres на момент появления SQLite-бэкенда, одной
+-- миграцией. Отличия от Postgres:
+--   * массивы (TEXT[], INTEGER[]) хранятся как JSON-массивы в TEXT
+--   * INET и CIDR — строки, INTERVAL — INTEGER наносекунд
+--   * CITEXT — TEXT COLLATE NOCASE (без учёта регистра только для ASCII)
+--   * время пишет драйвер строкой в UTC, колонки объявлены DATETIME, чтобы
+--     драйвер читал их как time.Time
+--   * updated_at выставляет приложение, а не триггер
+CREATE TABLE users (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  email TEXT COLLATE NOCASE NOT NULL CONSTRAINT uq_users_email UNIQUE,
+  username TEXT COLLATE NOCASE NOT NULL CONSTRAINT uq_users_username UNIQUE,
+  password_hash BLOB,
+  is_verified BOOLEAN NOT NULL DEFAULT FALSE,
+  is_2fa_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  two_fa_method TEXT CONSTRAINT chk_users_2fa_method CHECK (two_fa_method IN ('magic_link', 'totp')),
+  two_fa_enabled_at DATETIME,
+  must_reset_password BOOLEAN NOT NULL DEFAULT FALSE,
+  status TEXT NOT NULL DEFAULT 'active',
+  status_reason TEXT,
+  status_changed_by TEXT,
+  status_changed_at DATETIME,
+  deleted_at DATETIME,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT chk_users_2fa_method_consistency CHECK (
+    (is_2fa_enabled = FALSE AND two_fa_method IS NULL)
+    OR (is_2fa_enabled = TRUE AND two_fa_method IS NOT NULL)
+  )
+);
+
+CREATE INDEX idx_users_deleted_at ON users (id) WHERE deleted_at IS NULL;
+
+-- Допустимые статусы проверяют триггеры, а не CHECK: CHECK в SQLite не
+-- изменить без пересборки таблицы, а пересобрать users внутри транзакции
+-- миграции нельзя — внешние ключи на неё удалили бы зависимые строки.
+-- +goose StatementBegin
+CREATE TRIGGER trg_users_status_insert BEFORE INSERT ON users
+WHEN NEW.status NOT IN ('active', 'suspended', 'banned', 'pending_deletion')
+BEGIN
+  SELECT RAISE(ABORT, 'CHECK constraint failed: chk_users_status');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER trg_users_status_update BEFORE UPDATE OF status ON users
+WHEN NEW.status NOT IN ('active', 'suspended', 'banned', 'pending_deletion')
+BEGIN
+  SELECT RAISE(ABORT, 'CHECK constraint failed: chk_users_status');
+END;
+-- +goose StatementEnd
+
+CREATE TABLE apps (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL CONSTRAINT uq_apps_name UNIQUE,
+  secret TEXT NOT NULL CONSTRAINT uq_apps_secret UNIQUE,
+  access_token_format TEXT NOT NULL DEFAULT 'jwt' CONSTRAINT chk_apps_access_token_format CHECK (access_token_format IN ('jwt', 'opaque')),
+  signing_alg TEXT NOT NULL DEFAULT 'HS256' CONSTRAINT chk_apps_signing_alg CHECK (signing_alg IN ('HS256', 'RS256', 'ES256')),
+  redirect_uris TEXT NOT NULL DEFAULT '[]',
+  allowed_scopes TEXT NOT NULL DEFAULT '[]',
+  refresh_token_delivery TEXT NOT NULL DEFAULT 'body' CONSTRAINT chk_apps_refresh_token_delivery CHECK (refresh_token_delivery IN ('body', 'cookie')),
+  token_exchange_audiences TEXT NOT NULL DEFAULT '[]',
+  access_token_ttl INTEGER CONSTRAINT chk_apps_access_token_ttl CHECK (access_token_ttl > 0),
+  refresh_token_ttl INTEGER CONSTRAINT chk_apps_refresh_token_ttl CHECK (refresh_token_ttl > 0),
+  public_client BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+INSERT INTO apps (id, name, secret)
+VALUES (1, 'default_app', 'super-secret-key');
+
+CREATE TABLE organizations (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  app_id INTEGER NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_organizations_app_id ON organizations (app_id);
+
+CREATE TABLE refresh_tokens (
+  id TEXT PRIMARY KEY,
+  token_hash BLOB NOT NULL CONSTRAINT uq_refresh_tokens_hash UNIQUE,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  app_id INTEGER NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+  org_id INTEGER REFERENCES organizations(id) ON DELETE SET NULL,
+  device_id TEXT,
+  device_name TEXT,
+  scopes TEXT NOT NULL DEFAULT '[]',
+  ip TEXT,
+  user_agent TEXT,
+  fingerprint BLOB,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  expires_at DATETIME NOT NULL,
+  absolute_expires_at DATETIME NOT NULL,
+  CONSTRAINT chk_refresh_tokens_expiration CHECK (expires_at > created_at),
+  CONSTRAINT chk_refresh_tokens_absolute_expiration CHECK (expires_at <= absolute_expires_at)
+);
+
+CREATE UNIQUE INDEX uq_refresh_tokens_user_device ON refresh_tokens (user_id, device_id) WHERE device_id IS NOT NULL;
+CREATE INDEX idx_refresh_tokens_expires_at ON refresh_tokens (expires_at);
+
+CREATE TABLE password_reset_tokens (
+  id TEXT PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  token_hash BLOB NOT NULL,
+  expires_at DATETIME NOT NULL,
+  used_at DATETIME
+);
+
+CREATE UNIQUE INDEX uq_password_reset_tokens_hash ON password_reset_tokens (token_hash);
+CREATE INDEX idx_password_reset_tokens_active ON password_reset_tokens (user_id) WHERE used_at IS NULL;
+
+CREATE TABLE identities (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL DEFAULT 'oauth' CONSTRAINT chk_identities_kind CHECK (kind IN ('oauth', 'saml')),
+  provider TEXT NOT NULL,
+  subject TEXT NOT NULL,
+  email TEXT COLLATE NOCASE NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT uq_identities_provider_subject UNIQUE (provider, subject),
+  CONSTRAINT uq_identities_user_provider UNIQUE (user_id, provider)
+);
+
+CREATE INDEX idx_identities_user_id ON identities (user_id);
+
+CREATE TABLE magic_links (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  app_id INTEGER NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+  token_hash BLOB NOT NULL,
+  session_id TEXT NOT NULL,
+  ip_address TEXT,
+  user_agent TEXT,
+  used_at DATETIME,
+  expires_at DATETIME NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT chk_magic_links_expiry CHECK (expires_at > created_at)
+);
+
+CREATE UNIQUE INDEX uq_magic_links_token_hash_active ON magic_links (token_hash) WHERE used_at IS NULL;
+CREATE INDEX idx_magic_links_user_active ON magic_links (user_id, expires_at) WHERE used_at IS NULL;
+CREATE INDEX idx_magic_links_session_id ON magic_links (session_id) WHERE used_at IS NULL;
+CREATE INDEX idx_magic_links_used_at ON magic_links (used_at) WHERE used_at IS NOT NULL;
+CREATE INDEX idx_magic_links_expires_at ON magic_links (expires_at);
+
+-- Без FK на users: журнал должен переживать hard delete аккаунта.
+CREATE TABLE audit_events (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL,
+  actor TEXT NOT NULL,
+  action TEXT NOT NULL,
+  ip TEXT,
+  user_agent TEXT,
+  metadata TEXT NOT NULL DEFAULT '{}',
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_events_user_id_id ON audit_events (user_id, id DESC);
+CREATE INDEX idx_audit_events_created_at ON audit_events (created_at);
+
+CREATE TABLE app_members (
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  app_id INTEGER NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, app_id)
+);
+
+CREATE INDEX idx_app_members_app_id ON app_members (app_id);
+
+CREATE TABLE totp_secrets (
+  user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  secret_enc BLOB NOT NULL,
+  confirmed_at DATETIME,
+  last_used_step INTEGER NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE signing_keys (
+  kid TEXT PRIMARY KEY,
+  app_id INTEGER NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+  alg TEXT NOT NULL CONSTRAINT chk_signing_keys_alg CHECK (alg IN ('HS256', 'RS256', 'ES256')),
+  private_key TEXT NOT NULL,
+  public_key TEXT NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  retired_at DATETIME,
+  expires_at DATETIME
+);
+
+CREATE UNIQUE INDEX uq_signing_keys_app_active ON signing_keys (app_id) WHERE retired_at IS NULL;
+CREATE INDEX idx_signing_keys_expires_at ON signing_keys (expires_at) WHERE expires_at IS NOT NULL;
+
+CREATE TABLE roles (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  app_id INTEGER NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  permissions TEXT NOT NULL DEFAULT '[]',
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT uq_roles_app_name UNIQUE (app_id, name)
+);
+
+CREATE TABLE user_roles (
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+  granted_by TEXT NOT NULL,
+  granted_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX idx_user_roles_role_id ON user_roles (role_id);
+
+CREATE TABLE org_members (
+  org_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role TEXT NOT NULL CONSTRAINT chk_org_members_role CHECK (role IN ('owner', 'admin', 'member')),
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (org_id, user_id)
+);
+
+CREATE INDEX idx_org_members_user_id ON org_members (user_id);
+
+CREATE TABLE org_invitations (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  org_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  email TEXT NOT NULL,
+  role TEXT NOT NULL CONSTRAINT chk_org_invitations_role CHECK (role IN ('admin', 'member')),
+  token_hash BLOB NOT NULL CONSTRAINT uq_org_invitations_token_hash UNIQUE,
+  invited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+  expires_at DATETIME NOT NULL,
+  accepted_at DATETIME,
+  declined_at DATETIME,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE known_devices (
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  fingerprint BLOB NOT NULL,
+  ip TEXT,
+  user_agent TEXT,
+  first_seen_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  last_seen_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, fingerprint)
+);
+
+CREATE TABLE login_locations (
+  user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  ip TEXT NOT NULL,
+  country TEXT NOT NULL DEFAULT '',
+  city TEXT NOT NULL DEFAULT '',
+  latitude REAL NOT NULL,
+  longitude REAL NOT NULL,
+  logged_in_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE api_keys (
+  id TEXT PRIMARY KEY,
+  app_id INTEGER NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  key_hash BLOB NOT NULL,
+  scopes TEXT NOT NULL DEFAULT '[]',
+  created_by TEXT NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  expires_at DATETIME,
+  last_used_at DATETIME,
+  revoked_at DATETIME
+);
+
+CREATE INDEX idx_api_keys_app_id ON api_keys (app_id);
+
+CREATE TABLE user_security_settings (
+  user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  two_fa_channel TEXT NOT NULL CHECK (two_fa_channel IN ('sms', 'telegram')),
+  two_fa_destination TEXT NOT NULL,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE trusted_devices (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  token_hash BLOB NOT NULL UNIQUE,
+  device_id TEXT,
+  device_name TEXT,
+  ip TEXT,
+  user_agent TEXT,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  last_used_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  expires_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_trusted_devices_user_id ON trusted_devices (user_id);
+CREATE INDEX idx_trusted_devices_expires_at ON trusted_devices (expires_at);
+
+CREATE TABLE ip_rules (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  cidr TEXT NOT NULL CONSTRAINT uq_ip_rules_cidr UNIQUE,
+  action TEXT NOT NULL CONSTRAINT chk_ip_rules_action CHECK (action IN ('allow', 'deny')),
+  reason TEXT NOT NULL DEFAULT '',
+  created_by TEXT NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  expires_at DATETIME
+);
+
+CREATE INDEX idx_ip_rules_expires_at ON ip_rules (expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS ip_rules;
+DROP TABLE IF EXISTS trusted_devices;
+DROP TABLE IF EXISTS user_security_settings;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS login_locations;
+DROP TABLE IF EXISTS known_devices;
+DROP TABLE IF EXISTS org_invitations;
+DROP TABLE IF EXISTS org_members;
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS roles;
+DROP TABLE IF EXISTS signing_keys;
+DROP TABLE IF EXISTS totp_secrets;
+DROP TABLE IF EXISTS app_members;
+DROP TABLE IF EXISTS audit_events;
+DROP TABLE IF EXISTS magic_links;
+DROP TABLE IF EXISTS identities;
+DROP TABLE IF EXISTS password_reset_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS organizations;
+DROP TABLE IF EXISTS apps;
+DROP TABLE IF EXISTS users;
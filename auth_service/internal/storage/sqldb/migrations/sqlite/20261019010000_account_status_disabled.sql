@@ -0,0 +1,42 @@
+-- +goose Up
+-- disabled — обратимое отключение аккаунта, отдельное от модерационного
+-- suspended. Статус проверяют триггеры, их пересоздаём с новым списком.
+DROP TRIGGER trg_users_status_insert;
+DROP TRIGGER trg_users_status_update;
+
+-- +goose StatementBegin
+CREATE TRIGGER trg_users_status_insert BEFORE INSERT ON users
+WHEN NEW.status NOT IN ('active', 'disabled', 'suspended', 'banned', 'pending_deletion')
+BEGIN
+  SELECT RAISE(ABORT, 'CHECK constraint failed: chk_users_status');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER trg_users_status_update BEFORE UPDATE OF status ON users
+WHEN NEW.status NOT IN ('active', 'disabled', 'suspended', 'banned', 'pending_deletion')
+BEGIN
+  SELECT RAISE(ABORT, 'CHECK constraint failed: chk_users_status');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+UPDATE users SET status = 'suspended' WHERE status = 'disabled';
+DROP TRIGGER trg_users_status_insert;
+DROP TRIGGER trg_users_status_update;
+
+-- +goose StatementBegin
+CREATE TRIGGER trg_users_status_insert BEFORE INSERT ON users
+WHEN NEW.status NOT IN ('active', 'suspended', 'banned', 'pending_deletion')
+BEGIN
+  SELECT RAISE(ABORT, 'CHECK constraint failed: chk_users_status');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER trg_users_status_update BEFORE UPDATE OF status ON users
+WHEN NEW.status NOT IN ('active', 'suspended', 'banned', 'pending_deletion')
+BEGIN
+  SELECT RAISE(ABORT, 'CHECK constraint failed: chk_users_status');
+END;
+-- +goose StatementEnd
@@ -0,0 +1,482 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// * CreateOrganization создаёт организацию и делает создателя её
+// владельцем в одной транзакции.
+func (r *SQLRepo) CreateOrganization(ctx context.Context, org *models.Organization, ownerID int64) error {
+	const op = "storage.sqldb.CreateOrganization"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	createdAt := now()
+
+	err := r.inTx(ctx, func(q querier) error {
+		res, err := q.ExecContext(ctx,
+			`INSERT INTO organizations (app_id, name, created_by, created_at) VALUES (?, ?, ?, ?)`,
+			org.AppID, org.Name, ownerID, createdAt,
+		)
+		if err != nil {
+			if isForeignKeyViolation(err) {
+				// какой именно ключ нарушен, SQLite не сообщает
+				if exists, _ := rowExists(ctx, q, `SELECT COUNT(*) FROM apps WHERE id = ?`, org.AppID); !exists {
+					return storage.ErrAppNotFound
+				}
+				return storage.ErrUserNotFound
+			}
+
+			return fmt.Errorf("insert organization: %w", err)
+		}
+
+		if org.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+
+		if _, err := q.ExecContext(ctx,
+			`INSERT INTO org_members (org_id, user_id, role, created_at) VALUES (?, ?, ?, ?)`,
+			org.ID, ownerID, string(models.OrgRoleOwner), createdAt,
+		); err != nil {
+			return fmt.Errorf("insert owner: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) || errors.Is(err, storage.ErrUserNotFound) {
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	org.CreatedAt = createdAt
+
+	return nil
+}
+
+// rowExists выполняет SELECT COUNT(*) и сообщает, нашлась ли хоть одна строка.
+func rowExists(ctx context.Context, q querier, query string, args ...any) (bool, error) {
+	var n int
+	if err := q.QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// * OrganizationsByUserID — членства пользователя в организациях приложения.
+func (r *SQLRepo) OrganizationsByUserID(ctx context.Context, userID int64, appID int32) ([]models.OrgMember, error) {
+	const op = "storage.sqldb.OrganizationsByUserID"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT m.org_id, o.name, m.user_id, m.role, m.created_at
+		FROM org_members m
+		JOIN organizations o ON o.id = m.org_id
+		WHERE m.user_id = ? AND o.app_id = ?
+		ORDER BY o.name, o.id
+	`, userID, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var members []models.OrgMember
+	for rows.Next() {
+		var m models.OrgMember
+		if err := rows.Scan(&m.OrgID, &m.OrgName, &m.UserID, &m.Role, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return members, nil
+}
+
+// * OrgMember — членство пользователя в организации. appID != 0 —
+// организация должна принадлежать этому приложению: токены одного
+// приложения не дают доступа к тенантам другого.
+func (r *SQLRepo) OrgMember(ctx context.Context, orgID, userID int64, appID int32) (*models.OrgMember, error) {
+	const op = "storage.sqldb.OrgMember"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	var m models.OrgMember
+	err := r.db.QueryRowContext(ctx, `
+		SELECT m.org_id, o.name, m.user_id, u.email, m.role, m.created_at
+		FROM org_members m
+		JOIN organizations o ON o.id = m.org_id
+		JOIN users u ON u.id = m.user_id
+		WHERE m.org_id = ? AND m.user_id = ? AND (? = 0 OR o.app_id = ?)
+	`, orgID, userID, appID, appID).Scan(
+		&m.OrgID, &m.OrgName, &m.UserID, &m.Email, &m.Role, &m.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrOrgMemberNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &m, nil
+}
+
+// * OrgMembers — участники организации, владельцы первыми.
+func (r *SQLRepo) OrgMembers(ctx context.Context, orgID int64) ([]models.OrgMember, error) {
+	const op = "storage.sqldb.OrgMembers"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT m.org_id, o.name, m.user_id, u.email, m.role, m.created_at
+		FROM org_members m
+		JOIN organizations o ON o.id = m.org_id
+		JOIN users u ON u.id = m.user_id
+		WHERE m.org_id = ?
+		ORDER BY CASE m.role WHEN 'owner' THEN 0 WHEN 'admin' THEN 1 ELSE 2 END, m.created_at
+	`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var members []models.OrgMember
+	for rows.Next() {
+		var m models.OrgMember
+		if err := rows.Scan(&m.OrgID, &m.OrgName, &m.UserID, &m.Email, &m.Role, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return members, nil
+}
+
+// * SetOrgMemberRole меняет роль участника. Понизить последнего владельца
+// нельзя — организация осталась бы без управления.
+func (r *SQLRepo) SetOrgMemberRole(ctx context.Context, orgID, userID int64, role models.OrgRole) error {
+	const op = "storage.sqldb.SetOrgMemberRole"
+
+	return r.withOwnerGuard(ctx, op, orgID, userID, func(q querier) (sql.Result, error) {
+		return q.ExecContext(ctx, `UPDATE org_members SET role = ? WHERE org_id = ? AND user_id = ?`, string(role), orgID, userID)
+	}, role != models.OrgRoleOwner)
+}
+
+// * RemoveOrgMember исключает участника из организации. Последнего
+// владельца исключить нельзя.
+func (r *SQLRepo) RemoveOrgMember(ctx context.Context, orgID, userID int64) error {
+	const op = "storage.sqldb.RemoveOrgMember"
+
+	return r.withOwnerGuard(ctx, op, orgID, userID, func(q querier) (sql.Result, error) {
+		return q.ExecContext(ctx, `DELETE FROM org_members WHERE org_id = ? AND user_id = ?`, orgID, userID)
+	}, true)
+}
+
+// withOwnerGuard выполняет изменение участника под блокировкой владельцев
+// организации. demotesOwner — изменение лишает участника роли owner; если
+// он последний владелец, изменение отклоняется.
+func (r *SQLRepo) withOwnerGuard(
+	ctx context.Context,
+	op string,
+	orgID, userID int64,
+	change func(q querier) (sql.Result, error),
+	demotesOwner bool,
+) error {
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	err := r.inTx(ctx, func(q querier) error {
+		// блокируем владельцев — два параллельных понижения разных
+		// владельцев не пройдут проверку "владелец не последний" одновременно
+		rows, err := q.QueryContext(ctx,
+			`SELECT user_id FROM org_members WHERE org_id = ? AND role = 'owner'`+r.dialect.forUpdate(),
+			orgID,
+		)
+		if err != nil {
+			return fmt.Errorf("lock owners: %w", err)
+		}
+
+		var owners []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return fmt.Errorf("lock owners: %w", err)
+			}
+			owners = append(owners, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("lock owners: %w", err)
+		}
+
+		if demotesOwner && len(owners) == 1 && owners[0] == userID {
+			return storage.ErrLastOrgOwner
+		}
+
+		res, err := change(q)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return storage.ErrOrgMemberNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrLastOrgOwner) || errors.Is(err, storage.ErrOrgMemberNotFound) {
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// * SaveOrgInvitation сохраняет приглашение.
+func (r *SQLRepo) SaveOrgInvitation(ctx context.Context, inv *models.OrgInvitation) error {
+	const op = "storage.sqldb.SaveOrgInvitation"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	createdAt := now()
+
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO org_invitations (org_id, email, role, token_hash, invited_by, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`,
+		inv.OrgID,
+		inv.Email,
+		string(inv.Role),
+		inv.TokenHash,
+		inv.InvitedBy,
+		inv.ExpiresAt,
+		createdAt,
+	)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return storage.ErrOrgNotFound
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if inv.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	inv.CreatedAt = createdAt
+
+	return nil
+}
+
+// claimInvitation находит действующее приглашение под блокировкой и
+// гасит его, выставляя column (accepted_at или declined_at). email != "" —
+// приглашение должно быть отправлено на этот адрес. Возвращает приглашение
+// и приложение его организации.
+func (r *SQLRepo) claimInvitation(
+	ctx context.Context,
+	q querier,
+	tokenHash []byte,
+	email string,
+	column string,
+) (*models.OrgInvitation, int32, error) {
+	t := now()
+
+	var (
+		inv       models.OrgInvitation
+		invitedBy *int64
+		appID     int32
+	)
+
+	err := q.QueryRowContext(ctx, `
+		SELECT i.id, i.org_id, i.email, i.role, i.invited_by, i.expires_at, i.created_at, o.app_id
+		FROM org_invitations i
+		JOIN organizations o ON o.id = i.org_id
+		WHERE i.token_hash = ?
+		  AND i.accepted_at IS NULL
+		  AND i.declined_at IS NULL
+		  AND i.expires_at > ?
+		  AND (? = '' OR LOWER(i.email) = LOWER(?))`+r.dialect.forUpdate(),
+		tokenHash, t, email, email,
+	).Scan(&inv.ID, &inv.OrgID, &inv.Email, &inv.Role, &invitedBy, &inv.ExpiresAt, &inv.CreatedAt, &appID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, 0, storage.ErrOrgInvitationNotFound
+		}
+
+		return nil, 0, err
+	}
+	if invitedBy != nil {
+		inv.InvitedBy = *invitedBy
+	}
+
+	if _, err := q.ExecContext(ctx, `UPDATE org_invitations SET `+column+` = ? WHERE id = ?`, t, inv.ID); err != nil {
+		return nil, 0, err
+	}
+
+	if column == "accepted_at" {
+		inv.AcceptedAt = &t
+	} else {
+		inv.DeclinedAt = &t
+	}
+
+	return &inv, appID, nil
+}
+
+// * AcceptOrgInvitation гасит приглашение и добавляет пользователя в
+// организацию. Приглашение принимается только аккаунтом с тем email, на
+// который оно отправлено.
+func (r *SQLRepo) AcceptOrgInvitation(
+	ctx context.Context,
+	tokenHash []byte,
+	userID int64,
+	email string,
+) (*models.OrgInvitation, error) {
+	const op = "storage.sqldb.AcceptOrgInvitation"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	var inv *models.OrgInvitation
+
+	err := r.inTx(ctx, func(q querier) error {
+		var err error
+		if inv, _, err = r.claimInvitation(ctx, q, tokenHash, email, "accepted_at"); err != nil {
+			return err
+		}
+
+		res, err := q.ExecContext(ctx,
+			r.dialect.insertIgnore()+` INTO org_members (org_id, user_id, role, created_at) VALUES (?, ?, ?, ?)`,
+			inv.OrgID, userID, string(inv.Role), now(),
+		)
+		if err != nil {
+			return fmt.Errorf("insert member: %w", err)
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return storage.ErrOrgMemberExists
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrOrgInvitationNotFound) || errors.Is(err, storage.ErrOrgMemberExists) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return inv, nil
+}
+
+// * DeclineOrgInvitation отклоняет действующее приглашение. Токен после
+// этого не принимается.
+func (r *SQLRepo) DeclineOrgInvitation(ctx context.Context, tokenHash []byte) (*models.OrgInvitation, error) {
+	const op = "storage.sqldb.DeclineOrgInvitation"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	var inv *models.OrgInvitation
+
+	err := r.inTx(ctx, func(q querier) error {
+		var err error
+		inv, _, err = r.claimInvitation(ctx, q, tokenHash, "", "declined_at")
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrOrgInvitationNotFound) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return inv, nil
+}
+
+// * SaveUserFromOrgInvitation создаёт аккаунт приглашённому, у которого его
+// ещё нет, и принимает приглашение в одной транзакции. Email берётся из
+// приглашения и считается подтверждённым: токен пришёл на этот адрес.
+// Пользователь становится участником приложения организации.
+func (r *SQLRepo) SaveUserFromOrgInvitation(
+	ctx context.Context,
+	tokenHash []byte,
+	username string,
+	passHash []byte,
+) (int64, *models.OrgInvitation, error) {
+	const op = "storage.sqldb.SaveUserFromOrgInvitation"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	var (
+		inv    *models.OrgInvitation
+		userID int64
+	)
+
+	err := r.inTx(ctx, func(q querier) error {
+		var (
+			appID int32
+			err   error
+		)
+		if inv, appID, err = r.claimInvitation(ctx, q, tokenHash, "", "accepted_at"); err != nil {
+			return err
+		}
+
+		if userID, err = insertUser(ctx, q, inv.Email, username, passHash, true); err != nil {
+			if isUniqueViolation(err) {
+				return storage.ErrUserAlreadyExists
+			}
+			return fmt.Errorf("insert user: %w", err)
+		}
+
+		if err := addAppMember(ctx, q, userID, appID); err != nil {
+			return fmt.Errorf("insert app member: %w", err)
+		}
+
+		if _, err := q.ExecContext(ctx,
+			`INSERT INTO org_members (org_id, user_id, role, created_at) VALUES (?, ?, ?, ?)`,
+			inv.OrgID, userID, string(inv.Role), now(),
+		); err != nil {
+			return fmt.Errorf("insert member: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrOrgInvitationNotFound) || errors.Is(err, storage.ErrUserAlreadyExists) {
+			return 0, nil, err
+		}
+
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, inv, nil
+}
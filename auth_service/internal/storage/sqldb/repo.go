@@ -0,0 +1,235 @@
+// Package sqldb — основное хранилище на MySQL 8.0+ или SQLite через
+// database/sql с той же семантикой, что у storage/postgres. Различия
+// диалектов (upsert, блокировки строк, ошибки уникальности) собраны в
+// dialect, запросы пишутся на общем подмножестве SQL с плейсхолдерами "?".
+//
+// Типы Postgres без аналога хранятся так: массивы — JSON-текстом, INET и
+// CIDR — строкой, INTERVAL — наносекундами в BIGINT, CITEXT — колонкой с
+// регистронезависимой сортировкой. Время пишется из Go в UTC, а не
+// NOW() сервера: так сравнения одинаково работают в обоих диалектах.
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"auth_service/internal/config"
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/storage"
+
+	"github.com/go-sql-driver/mysql"
+	_ "modernc.org/sqlite"
+)
+
+var _ storage.Repository = (*SQLRepo)(nil)
+
+// querier — общее подмножество *sql.DB и *sql.Tx. Методы репозитория
+// работают через него и не знают, выполняются ли они в рамках
+// UoW-транзакции или напрямую на пуле.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type SQLRepo struct {
+	pool     *sql.DB
+	db       querier
+	dialect  dialect
+	log      *slog.Logger
+	timeouts queryTimeouts
+	migrate  bool
+
+	// locks — блокировки лидера SQLite: файл не делится между
+	// процессами-репликами, поэтому блокировка живёт в памяти процесса
+	locks *memLocks
+
+	// password — пароль MySQL для новых соединений, меняется SetPassword
+	password *atomic.Pointer[string]
+}
+
+// New подключается к СУБД из cfg.Storage.Driver — mysql или sqlite.
+func New(ctx context.Context, cfg *config.Config, log *slog.Logger) (*SQLRepo, error) {
+	const op = "storage.sqldb.New"
+
+	repo := &SQLRepo{
+		log:      log,
+		locks:    newMemLocks(),
+		password: new(atomic.Pointer[string]),
+	}
+
+	var err error
+
+	switch cfg.Storage.Driver {
+	case config.StorageDriverMySQL:
+		repo.dialect = dialectMySQL
+		repo.migrate = cfg.MySQL.Migrate
+		repo.timeouts = queryTimeouts{
+			read:    cfg.MySQL.ReadTimeout,
+			write:   cfg.MySQL.WriteTimeout,
+			cleanup: cfg.MySQL.CleanupTimeout,
+		}
+		repo.password.Store(&cfg.MySQL.Password)
+		repo.pool, err = openMySQL(cfg.MySQL, repo.password)
+	case config.StorageDriverSQLite:
+		repo.dialect = dialectSQLite
+		repo.migrate = cfg.SQLite.Migrate
+		repo.timeouts = queryTimeouts{
+			read:    cfg.SQLite.ReadTimeout,
+			write:   cfg.SQLite.WriteTimeout,
+			cleanup: cfg.SQLite.CleanupTimeout,
+		}
+		repo.pool, err = openSQLite(cfg.SQLite)
+	default:
+		return nil, fmt.Errorf("%s: unsupported driver %q", op, cfg.Storage.Driver)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	repo.db = repo.pool
+
+	if err := repo.pool.PingContext(ctx); err != nil {
+		_ = repo.pool.Close()
+		return nil, fmt.Errorf("%s: failed to ping database: %w", op, err)
+	}
+
+	if repo.migrate {
+		if err := repo.Migrate(ctx); err != nil {
+			_ = repo.pool.Close()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := repo.checkSchema(ctx); err != nil {
+		_ = repo.pool.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return repo, nil
+}
+
+// openMySQL — пул к MySQL. Пароль читается при каждом новом соединении,
+// поэтому SetPassword действует без пересоздания пула.
+func openMySQL(cfg config.MySQL, password *atomic.Pointer[string]) (*sql.DB, error) {
+	mysqlCfg := mysql.NewConfig()
+	mysqlCfg.Net = "tcp"
+	mysqlCfg.Addr = cfg.Host + ":" + strconv.Itoa(cfg.Port)
+	mysqlCfg.User = cfg.User
+	mysqlCfg.Passwd = cfg.Password
+	mysqlCfg.DBName = cfg.DBName
+	mysqlCfg.TLSConfig = cfg.TLS
+	// DATETIME читаются и пишутся в UTC
+	mysqlCfg.ParseTime = true
+	mysqlCfg.Loc = time.UTC
+	mysqlCfg.Params = map[string]string{"time_zone": "'+00:00'"}
+	// RowsAffected считает найденные строки, а не изменённые, как в
+	// Postgres: UPDATE тем же значением не должен выглядеть как «не найдено»
+	mysqlCfg.ClientFoundRows = true
+
+	err := mysqlCfg.Apply(mysql.BeforeConnect(func(_ context.Context, c *mysql.Config) error {
+		c.Passwd = *password.Load()
+		return nil
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	connector, err := mysql.NewConnector(mysqlCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connector: %w", err)
+	}
+
+	db := sql.OpenDB(connector)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxOpenConns)
+	db.SetConnMaxLifetime(time.Hour)
+	db.SetConnMaxIdleTime(30 * time.Minute)
+
+	return db, nil
+}
+
+// openSQLite открывает файл базы, создавая каталог. WAL даёт читать, пока
+// идёт запись; _txlock=immediate берёт блокировку записи в начале
+// транзакции, а не при первом UPDATE, — иначе две транзакции,
+// прочитавшие данные, упирались бы друг в друга при записи (SQLITE_BUSY
+// без ожидания busy_timeout). Время пишется текстом в одном формате и в
+// UTC — тогда строки DATETIME сравниваются в SQL так же, как моменты.
+func openSQLite(cfg config.SQLite) (*sql.DB, error) {
+	if dir := filepath.Dir(cfg.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
+	}
+
+	dsn := fmt.Sprintf(
+		"file:%s?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(%d)&_txlock=immediate&_time_format=sqlite&_timezone=UTC",
+		cfg.Path,
+		cfg.BusyTimeout.Milliseconds(),
+	)
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	return db, nil
+}
+
+// SetPassword применяется к новым соединениям пула MySQL: открытые
+// дорабатывают до ConnMaxLifetime. У SQLite пароля нет.
+func (r *SQLRepo) SetPassword(password string) {
+	r.password.Store(&password)
+}
+
+func (r *SQLRepo) Close(ctx context.Context) error {
+	done := make(chan error, 1)
+
+	go func() {
+		done <- r.pool.Close()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		r.log.Error("database pool close timed out, connections may leak")
+		return ctx.Err()
+	}
+}
+
+// inTx выполняет fn в транзакции. Внутри UoW транзакция уже открыта —
+// fn выполняется в ней, а коммит и откат остаются за Do: database/sql не
+// умеет вложенных транзакций, поэтому ошибка fn откатывает всю внешнюю.
+func (r *SQLRepo) inTx(ctx context.Context, fn func(q querier) error) error {
+	if _, ok := r.db.(*sql.Tx); ok {
+		return fn(r.db)
+	}
+
+	tx, err := r.pool.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.log.Error("rollback failed", sl.Err(err))
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	return nil
+}
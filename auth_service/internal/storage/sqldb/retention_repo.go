@@ -0,0 +1,157 @@
+package sqldb
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// purge удаляет до limit строк table, подходящих под cond с параметром
+// before. DELETE ... WHERE id IN (SELECT ... LIMIT) MySQL не поддерживает,
+// поэтому id выбираются отдельно в той же транзакции. skipLocked — строки,
+// заблокированные другой транзакцией, пропускаются (только MySQL: SQLite
+// блокирует файл целиком).
+func (r *SQLRepo) purge(ctx context.Context, table, cond string, before time.Time, limit int, skipLocked bool) (int64, error) {
+	lock := ""
+	if skipLocked && r.dialect == dialectMySQL {
+		lock = " FOR UPDATE SKIP LOCKED"
+	}
+
+	var deleted int64
+
+	err := r.inTx(ctx, func(q querier) error {
+		rows, err := q.QueryContext(ctx,
+			`SELECT id FROM `+table+` WHERE `+cond+` ORDER BY id LIMIT ?`+lock,
+			before, limit,
+		)
+		if err != nil {
+			return err
+		}
+
+		var ids []any
+		for rows.Next() {
+			var id any
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+
+		res, err := q.ExecContext(ctx, `DELETE FROM `+table+` WHERE id IN (`+placeholders(len(ids))+`)`, ids...)
+		if err != nil {
+			return err
+		}
+
+		deleted, err = res.RowsAffected()
+		return err
+	})
+
+	return deleted, err
+}
+
+// PurgeAuditEvents удаляет до limit событий аудита, созданных раньше before.
+func (r *SQLRepo) PurgeAuditEvents(ctx context.Context, before time.Time, limit int) (int64, error) {
+	const op = "storage.sqldb.PurgeAuditEvents"
+
+	ctx, cancel := r.queryCtx(ctx, queryCleanup)
+	defer cancel()
+
+	n, err := r.purge(ctx, "audit_events", "created_at < ?", before, limit, false)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// PurgeDeletedAccounts безвозвратно удаляет до limit аккаунтов, soft-deleted
+// раньше before. Связанные строки удаляются каскадом; аудит остаётся до
+// своей политики хранения. Строки, которые прямо сейчас восстанавливает
+// RestoreAccount, не трогаются.
+func (r *SQLRepo) PurgeDeletedAccounts(ctx context.Context, before time.Time, limit int) (int64, error) {
+	const op = "storage.sqldb.PurgeDeletedAccounts"
+
+	ctx, cancel := r.queryCtx(ctx, queryCleanup)
+	defer cancel()
+
+	n, err := r.purge(ctx, "users", "deleted_at IS NOT NULL AND deleted_at < ?", before, limit, true)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// PurgeUsedMagicLinks удаляет до limit использованных magic-link токенов,
+// погашенных раньше before. Неиспользованные истёкшие чистит
+// CleanupExpiredMagicLinks.
+func (r *SQLRepo) PurgeUsedMagicLinks(ctx context.Context, before time.Time, limit int) (int64, error) {
+	const op = "storage.sqldb.PurgeUsedMagicLinks"
+
+	ctx, cancel := r.queryCtx(ctx, queryCleanup)
+	defer cancel()
+
+	n, err := r.purge(ctx, "magic_links", "used_at IS NOT NULL AND used_at < ?", before, limit, false)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// PurgeExpiredTrustedDevices удаляет до limit доверенных устройств, срок
+// которых истёк раньше before.
+func (r *SQLRepo) PurgeExpiredTrustedDevices(ctx context.Context, before time.Time, limit int) (int64, error) {
+	const op = "storage.sqldb.PurgeExpiredTrustedDevices"
+
+	ctx, cancel := r.queryCtx(ctx, queryCleanup)
+	defer cancel()
+
+	n, err := r.purge(ctx, "trusted_devices", "expires_at < ?", before, limit, false)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// PurgeExpiredRefreshTokens удаляет до limit refresh-токенов, истёкших
+// раньше before. Такие сессии уже не продлить, в списке сессий их нет.
+func (r *SQLRepo) PurgeExpiredRefreshTokens(ctx context.Context, before time.Time, limit int) (int64, error) {
+	const op = "storage.sqldb.PurgeExpiredRefreshTokens"
+
+	ctx, cancel := r.queryCtx(ctx, queryCleanup)
+	defer cancel()
+
+	n, err := r.purge(ctx, "refresh_tokens", "expires_at < ?", before, limit, false)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// PurgeExpiredIPRules удаляет до limit временных правил доступа по IP,
+// истёкших раньше before.
+func (r *SQLRepo) PurgeExpiredIPRules(ctx context.Context, before time.Time, limit int) (int64, error) {
+	const op = "storage.sqldb.PurgeExpiredIPRules"
+
+	ctx, cancel := r.queryCtx(ctx, queryCleanup)
+	defer cancel()
+
+	n, err := r.purge(ctx, "ip_rules", "expires_at < ?", before, limit, false)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,184 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// * CreateRole заводит роль приложения. Имя уникально в пределах приложения.
+func (r *SQLRepo) CreateRole(ctx context.Context, role *models.Role) error {
+	const op = "storage.sqldb.CreateRole"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	createdAt := now()
+
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO roles (app_id, name, description, permissions, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, role.AppID, role.Name, role.Description, jsonArray[string](role.Permissions), createdAt)
+	if err != nil {
+		switch {
+		case isUniqueViolation(err):
+			return storage.ErrRoleAlreadyExists
+		case isForeignKeyViolation(err):
+			return storage.ErrAppNotFound
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if role.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	role.CreatedAt = createdAt
+
+	return nil
+}
+
+// scanRoles читает роли в порядке запроса.
+func scanRoles(rows *sql.Rows) ([]models.Role, error) {
+	defer rows.Close()
+
+	var roles []models.Role
+	for rows.Next() {
+		var (
+			role        models.Role
+			permissions jsonArray[string]
+		)
+		if err := rows.Scan(&role.ID, &role.AppID, &role.Name, &role.Description, &permissions, &role.CreatedAt); err != nil {
+			return nil, err
+		}
+		role.Permissions = permissions
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+// * RolesByAppID — все роли приложения, по имени.
+func (r *SQLRepo) RolesByAppID(ctx context.Context, appID int32) ([]models.Role, error) {
+	const op = "storage.sqldb.RolesByAppID"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, app_id, name, description, permissions, created_at
+		FROM roles
+		WHERE app_id = ?
+		ORDER BY name
+	`, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	roles, err := scanRoles(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: collect: %w", op, err)
+	}
+
+	return roles, nil
+}
+
+// * RoleByName ищет роль приложения по имени.
+func (r *SQLRepo) RoleByName(ctx context.Context, appID int32, name string) (*models.Role, error) {
+	const op = "storage.sqldb.RoleByName"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	var (
+		role        models.Role
+		permissions jsonArray[string]
+	)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, app_id, name, description, permissions, created_at
+		FROM roles
+		WHERE app_id = ? AND name = ?
+	`, appID, name).Scan(&role.ID, &role.AppID, &role.Name, &role.Description, &permissions, &role.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrRoleNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	role.Permissions = permissions
+
+	return &role, nil
+}
+
+// * UserRoles — роли пользователя в приложении. Вызывается при каждой
+// выдаче access-токена, поэтому читает только по индексу первичного ключа.
+func (r *SQLRepo) UserRoles(ctx context.Context, userID int64, appID int32) ([]models.Role, error) {
+	const op = "storage.sqldb.UserRoles"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT r.id, r.app_id, r.name, r.description, r.permissions, r.created_at
+		FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id
+		WHERE ur.user_id = ? AND r.app_id = ?
+		ORDER BY r.name
+	`, userID, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	roles, err := scanRoles(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: collect: %w", op, err)
+	}
+
+	return roles, nil
+}
+
+// * AssignRole назначает роль пользователю. false — роль уже была назначена.
+func (r *SQLRepo) AssignRole(ctx context.Context, userID, roleID int64, grantedBy string) (bool, error) {
+	const op = "storage.sqldb.AssignRole"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	n, err := r.execCount(ctx,
+		r.dialect.insertIgnore()+` INTO user_roles (user_id, role_id, granted_by, granted_at) VALUES (?, ?, ?, ?)`,
+		userID, roleID, grantedBy, now(),
+	)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			// какой именно ключ нарушен, SQLite не сообщает
+			if exists, _ := rowExists(ctx, r.db, `SELECT COUNT(*) FROM users WHERE id = ?`, userID); !exists {
+				return false, storage.ErrUserNotFound
+			}
+			return false, storage.ErrRoleNotFound
+		}
+
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n > 0, nil
+}
+
+// * RevokeRole снимает роль с пользователя. false — роли у него не было.
+func (r *SQLRepo) RevokeRole(ctx context.Context, userID, roleID int64) (bool, error) {
+	const op = "storage.sqldb.RevokeRole"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	n, err := r.execCount(ctx, `DELETE FROM user_roles WHERE user_id = ? AND role_id = ?`, userID, roleID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n > 0, nil
+}
@@ -0,0 +1,64 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// * TwoFADelivery возвращает канал доставки magic-link 2FA пользователя.
+// ErrTwoFADeliveryNotFound — канал не выбран, ссылка уходит на email.
+func (r *SQLRepo) TwoFADelivery(ctx context.Context, userID int64) (*models.TwoFADelivery, error) {
+	const op = "storage.sqldb.TwoFADelivery"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	delivery := &models.TwoFADelivery{}
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT two_fa_channel, two_fa_destination FROM user_security_settings WHERE user_id = ?`,
+		userID,
+	).Scan(&delivery.Channel, &delivery.Destination)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrTwoFADeliveryNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return delivery, nil
+}
+
+// * SetTwoFADelivery сохраняет канал доставки magic-link 2FA. Email — канал
+// по умолчанию, для него строка удаляется: других настроек в ней пока нет.
+func (r *SQLRepo) SetTwoFADelivery(ctx context.Context, userID int64, delivery models.TwoFADelivery) error {
+	const op = "storage.sqldb.SetTwoFADelivery"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	if delivery.Channel == models.TwoFAChannelEmail {
+		if _, err := r.db.ExecContext(ctx, `DELETE FROM user_security_settings WHERE user_id = ?`, userID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	}
+
+	query := `
+		INSERT INTO user_security_settings (user_id, two_fa_channel, two_fa_destination, updated_at)
+		VALUES (?, ?, ?, ?)
+	` + r.dialect.upsert("user_id", "two_fa_channel", "two_fa_destination", "updated_at")
+
+	if _, err := r.db.ExecContext(ctx, query, userID, string(delivery.Channel), delivery.Destination, now()); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
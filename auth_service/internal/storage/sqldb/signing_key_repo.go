@@ -0,0 +1,227 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+const signingKeyColumns = `kid, app_id, alg, private_key, public_key, created_at, retired_at, expires_at`
+
+func scanSigningKey(row interface{ Scan(dest ...any) error }) (*models.SigningKey, error) {
+	var k models.SigningKey
+
+	err := row.Scan(
+		&k.KID,
+		&k.AppID,
+		&k.Alg,
+		&k.PrivateKey,
+		&k.PublicKey,
+		&k.CreatedAt,
+		&k.RetiredAt,
+		&k.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &k, nil
+}
+
+// * ActiveSigningKey возвращает ключ, которым сейчас подписываются токены
+// приложения.
+func (r *SQLRepo) ActiveSigningKey(ctx context.Context, appID int32) (*models.SigningKey, error) {
+	const op = "storage.sqldb.ActiveSigningKey"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT ` + signingKeyColumns + `
+		FROM signing_keys
+		WHERE app_id = ? AND retired_at IS NULL
+	`
+
+	key, err := scanSigningKey(r.db.QueryRowContext(ctx, query, appID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrSigningKeyNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// * SigningKeyByKID возвращает ключ по kid из заголовка токена. Ключи с
+// истёкшим grace-периодом не возвращаются.
+func (r *SQLRepo) SigningKeyByKID(ctx context.Context, kid string) (*models.SigningKey, error) {
+	const op = "storage.sqldb.SigningKeyByKID"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT ` + signingKeyColumns + `
+		FROM signing_keys
+		WHERE kid = ? AND (expires_at IS NULL OR expires_at > ?)
+	`
+
+	key, err := scanSigningKey(r.db.QueryRowContext(ctx, query, kid, now()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrSigningKeyNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// * RotateSigningKey выводит активный ключ приложения (если он есть) и
+// делает активным key. Выведенный ключ принимается до retiredExpiresAt.
+// Если активный ключ сменился параллельно, возвращает ErrSigningKeyConflict.
+func (r *SQLRepo) RotateSigningKey(ctx context.Context, key *models.SigningKey, retiredExpiresAt time.Time) error {
+	const op = "storage.sqldb.RotateSigningKey"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	t := now()
+
+	err := r.inTx(ctx, func(q querier) error {
+		_, err := q.ExecContext(ctx, `
+			UPDATE signing_keys
+			SET retired_at = ?, expires_at = ?
+			WHERE app_id = ? AND retired_at IS NULL
+		`, t, retiredExpiresAt, key.AppID)
+		if err != nil {
+			return fmt.Errorf("retire: %w", err)
+		}
+
+		_, err = q.ExecContext(ctx, `
+			INSERT INTO signing_keys (kid, app_id, alg, private_key, public_key, created_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, key.KID, key.AppID, string(key.Alg), key.PrivateKey, key.PublicKey, t)
+		if err != nil {
+			switch {
+			case isUniqueViolation(err):
+				// активный ключ вставлен другой репликой после нашего UPDATE
+				return storage.ErrSigningKeyConflict
+			case isForeignKeyViolation(err):
+				return storage.ErrAppNotFound
+			}
+
+			return fmt.Errorf("insert: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrSigningKeyConflict) || errors.Is(err, storage.ErrAppNotFound) {
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// * PublicSigningKeys возвращает публичные части асимметричных ключей,
+// которые ещё принимаются при проверке, — для JWKS. Приватные ключи из БД
+// не читаются, HS256-ключи не публикуются.
+func (r *SQLRepo) PublicSigningKeys(ctx context.Context) ([]models.SigningKey, error) {
+	const op = "storage.sqldb.PublicSigningKeys"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT kid, app_id, alg, public_key, created_at, retired_at, expires_at
+		FROM signing_keys
+		WHERE alg <> 'HS256' AND (expires_at IS NULL OR expires_at > ?)
+		ORDER BY created_at, kid
+	`, now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var keys []models.SigningKey
+	for rows.Next() {
+		var k models.SigningKey
+		if err := rows.Scan(&k.KID, &k.AppID, &k.Alg, &k.PublicKey, &k.CreatedAt, &k.RetiredAt, &k.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+
+		keys = append(keys, k)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// * AppsDueForKeyRotation возвращает приложения, чей активный ключ создан
+// раньше createdBefore.
+func (r *SQLRepo) AppsDueForKeyRotation(ctx context.Context, createdBefore time.Time) ([]int32, error) {
+	const op = "storage.sqldb.AppsDueForKeyRotation"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT app_id
+		FROM signing_keys
+		WHERE retired_at IS NULL AND created_at < ?
+		ORDER BY app_id
+	`, createdBefore)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var appIDs []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		appIDs = append(appIDs, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return appIDs, nil
+}
+
+// * DeleteExpiredSigningKeys удаляет выведенные ключи, grace-период которых
+// закончился.
+func (r *SQLRepo) DeleteExpiredSigningKeys(ctx context.Context) (int64, error) {
+	const op = "storage.sqldb.DeleteExpiredSigningKeys"
+
+	ctx, cancel := r.queryCtx(ctx, queryCleanup)
+	defer cancel()
+
+	n, err := r.execCount(ctx,
+		`DELETE FROM signing_keys WHERE expires_at IS NOT NULL AND expires_at <= ?`,
+		now(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,283 @@
+package sqldb_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/netip"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"auth_service/internal/config"
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+	"auth_service/internal/storage/sqldb"
+)
+
+// defaultApp — приложение, которое заводит начальная миграция.
+const defaultApp int32 = 1
+
+func sqliteConfig(path string, migrate bool) *config.Config {
+	return &config.Config{
+		Storage: config.Storage{Driver: config.StorageDriverSQLite},
+		SQLite: config.SQLite{
+			Path:           path,
+			Migrate:        migrate,
+			BusyTimeout:    5 * time.Second,
+			ReadTimeout:    2 * time.Second,
+			WriteTimeout:   5 * time.Second,
+			CleanupTimeout: 5 * time.Second,
+		},
+	}
+}
+
+// newRepo открывает SQLite во временном каталоге с применёнными миграциями.
+func newRepo(t *testing.T) *sqldb.SQLRepo {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	repo, err := sqldb.New(t.Context(), sqliteConfig(filepath.Join(t.TempDir(), "auth.db"), true), log)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close(context.Background()) })
+
+	return repo
+}
+
+func TestOpenRequiresMigrations(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	path := filepath.Join(t.TempDir(), "auth.db")
+
+	if _, err := sqldb.New(t.Context(), sqliteConfig(path, false), log); !errors.Is(err, sqldb.ErrSchemaOutdated) {
+		t.Fatalf("got %v, want %v", err, sqldb.ErrSchemaOutdated)
+	}
+
+	repo, err := sqldb.New(t.Context(), sqliteConfig(path, true), log)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	_ = repo.Close(t.Context())
+
+	// повторный запуск без --migrate видит актуальную схему
+	repo, err = sqldb.New(t.Context(), sqliteConfig(path, false), log)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	_ = repo.Close(t.Context())
+}
+
+func TestSaveUser(t *testing.T) {
+	repo := newRepo(t)
+	ctx := t.Context()
+
+	id, err := repo.SaveUser(ctx, "alice@example.com", "alice", []byte("hash"), defaultApp)
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	// email и username сравниваются без учёта регистра, как CITEXT
+	user, err := repo.UserByEmail(ctx, "ALICE@example.com")
+	if err != nil {
+		t.Fatalf("by email: %v", err)
+	}
+	if user.ID != id || user.Status != models.AccountStatusActive {
+		t.Fatalf("got id %d status %q, want %d %q", user.ID, user.Status, id, models.AccountStatusActive)
+	}
+
+	member, err := repo.IsAppMember(ctx, id, defaultApp)
+	if err != nil || !member {
+		t.Fatalf("app member = %v, %v", member, err)
+	}
+
+	tests := []struct {
+		name     string
+		email    string
+		username string
+		appID    int32
+		want     error
+	}{
+		{"username taken", "bob@example.com", "ALICE", defaultApp, storage.ErrUsernameTaken},
+		{"email taken", "Alice@Example.com", "alice2", defaultApp, storage.ErrUserAlreadyExists},
+		{"unknown app", "carol@example.com", "carol", 42, storage.ErrAppNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := repo.SaveUser(ctx, tt.email, tt.username, []byte("hash"), tt.appID); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// Ошибка внутри UoW откатывает все изменения транзакции.
+func TestDoRollback(t *testing.T) {
+	repo := newRepo(t)
+	ctx := t.Context()
+
+	id, err := repo.SaveUser(ctx, "alice@example.com", "alice", []byte("hash"), defaultApp)
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	errAbort := errors.New("abort")
+
+	err = repo.Do(ctx, func(ctx context.Context, tx storage.Tx) error {
+		if err := tx.Users().SetEmailVerified(ctx, id); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("do: got %v, want %v", err, errAbort)
+	}
+
+	user, err := repo.UserByID(ctx, id)
+	if err != nil {
+		t.Fatalf("by id: %v", err)
+	}
+	if user.IsVerified {
+		t.Fatal("verification was not rolled back")
+	}
+}
+
+func TestSetUserStatus(t *testing.T) {
+	repo := newRepo(t)
+	ctx := t.Context()
+
+	id, err := repo.SaveUser(ctx, "alice@example.com", "alice", []byte("hash"), defaultApp)
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	if err := repo.SetUserStatus(ctx, id, models.AccountStatusActive, models.AccountStatusSuspended, "spam", "admin:root"); err != nil {
+		t.Fatalf("suspend: %v", err)
+	}
+
+	// статус уже сменился — переход из active не применяется
+	err = repo.SetUserStatus(ctx, id, models.AccountStatusActive, models.AccountStatusSuspended, "", "admin:root")
+	if !errors.Is(err, storage.ErrUserStatusConflict) {
+		t.Fatalf("got %v, want %v", err, storage.ErrUserStatusConflict)
+	}
+
+	// disabled добавлен отдельной миграцией поверх исходной схемы
+	if err := repo.SetUserStatus(ctx, id, models.AccountStatusSuspended, models.AccountStatusDisabled, "", "admin:root"); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+
+	// неизвестный статус отклоняет сама схема
+	if err := repo.SetUserStatus(ctx, id, models.AccountStatusDisabled, "unknown", "", "admin:root"); err == nil {
+		t.Fatal("unknown status accepted")
+	}
+}
+
+func TestRotateSigningKey(t *testing.T) {
+	repo := newRepo(t)
+	ctx := t.Context()
+
+	grace := time.Now().Add(time.Hour)
+
+	for _, kid := range []string{"k1", "k2"} {
+		key := &models.SigningKey{KID: kid, AppID: defaultApp, Alg: models.SigningAlgRS256, PrivateKey: "priv", PublicKey: "pub"}
+		if err := repo.RotateSigningKey(ctx, key, grace); err != nil {
+			t.Fatalf("rotate to %s: %v", kid, err)
+		}
+	}
+
+	active, err := repo.ActiveSigningKey(ctx, defaultApp)
+	if err != nil {
+		t.Fatalf("active: %v", err)
+	}
+	if active.KID != "k2" {
+		t.Fatalf("active kid = %s, want k2", active.KID)
+	}
+
+	retired, err := repo.SigningKeyByKID(ctx, "k1")
+	if err != nil {
+		t.Fatalf("retired: %v", err)
+	}
+	if retired.RetiredAt == nil || retired.ExpiresAt == nil {
+		t.Fatal("k1 is not retired")
+	}
+
+	keys, err := repo.PublicSigningKeys(ctx)
+	if err != nil {
+		t.Fatalf("public keys: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("public keys = %d, want 2", len(keys))
+	}
+
+	key := &models.SigningKey{KID: "k3", AppID: 42, Alg: models.SigningAlgRS256, PrivateKey: "priv", PublicKey: "pub"}
+	if err := repo.RotateSigningKey(ctx, key, grace); !errors.Is(err, storage.ErrAppNotFound) {
+		t.Fatalf("unknown app: got %v, want %v", err, storage.ErrAppNotFound)
+	}
+}
+
+// Более узкие сети идут первыми: по ним решает фильтр.
+func TestActiveIPRules(t *testing.T) {
+	repo := newRepo(t)
+	ctx := t.Context()
+
+	expired := time.Now().Add(-time.Minute)
+
+	rules := []*models.IPRule{
+		{Prefix: netip.MustParsePrefix("10.0.0.0/8"), Action: models.IPRuleDeny, CreatedBy: "admin:root"},
+		{Prefix: netip.MustParsePrefix("10.1.2.3/24"), Action: models.IPRuleAllow, CreatedBy: "admin:root"},
+		{Prefix: netip.MustParsePrefix("192.168.0.0/16"), Action: models.IPRuleDeny, CreatedBy: "system:abuse", ExpiresAt: &expired},
+	}
+	for _, rule := range rules {
+		if err := repo.SaveIPRule(ctx, rule); err != nil {
+			t.Fatalf("save %s: %v", rule.Prefix, err)
+		}
+	}
+
+	// та же сеть в другой записи
+	dup := &models.IPRule{Prefix: netip.MustParsePrefix("10.1.2.0/24"), Action: models.IPRuleDeny, CreatedBy: "admin:root"}
+	if err := repo.SaveIPRule(ctx, dup); !errors.Is(err, storage.ErrIPRuleExists) {
+		t.Fatalf("duplicate: got %v, want %v", err, storage.ErrIPRuleExists)
+	}
+
+	active, err := repo.ActiveIPRules(ctx)
+	if err != nil {
+		t.Fatalf("active: %v", err)
+	}
+
+	want := []string{"10.1.2.0/24", "10.0.0.0/8"}
+	if len(active) != len(want) {
+		t.Fatalf("active rules = %d, want %d", len(active), len(want))
+	}
+	for i, rule := range active {
+		if rule.Prefix.String() != want[i] {
+			t.Fatalf("rule %d = %s, want %s", i, rule.Prefix, want[i])
+		}
+	}
+}
+
+func TestPurgeAuditEvents(t *testing.T) {
+	repo := newRepo(t)
+	ctx := t.Context()
+
+	for range 3 {
+		event := &models.AuditEvent{UserID: 1, Actor: "user", Action: "login", Metadata: map[string]any{"app_id": 1}}
+		if err := repo.SaveAuditEvent(ctx, event); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+
+	before := time.Now().Add(time.Second)
+
+	deleted, err := repo.PurgeAuditEvents(ctx, before, 2)
+	if err != nil || deleted != 2 {
+		t.Fatalf("first batch = %d, %v; want 2", deleted, err)
+	}
+
+	deleted, err = repo.PurgeAuditEvents(ctx, before, 2)
+	if err != nil || deleted != 1 {
+		t.Fatalf("second batch = %d, %v; want 1", deleted, err)
+	}
+}
@@ -0,0 +1,41 @@
+package sqldb
+
+import (
+	"context"
+	"time"
+)
+
+// queryKind определяет, какой из таймаутов конфига применяется к запросу.
+type queryKind int
+
+const (
+	queryRead queryKind = iota
+	queryWrite
+	queryCleanup
+)
+
+type queryTimeouts struct {
+	read    time.Duration
+	write   time.Duration
+	cleanup time.Duration
+}
+
+// queryCtx ставит запросу дедлайн по его типу; как и в storage/postgres,
+// дедлайн вызывающего действует, если он раньше.
+func (r *SQLRepo) queryCtx(ctx context.Context, kind queryKind) (context.Context, context.CancelFunc) {
+	var timeout time.Duration
+	switch kind {
+	case queryWrite:
+		timeout = r.timeouts.write
+	case queryCleanup:
+		timeout = r.timeouts.cleanup
+	default:
+		timeout = r.timeouts.read
+	}
+
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
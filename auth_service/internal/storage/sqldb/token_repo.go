@@ -0,0 +1,416 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+func (r *SQLRepo) SaveRefreshToken(
+	ctx context.Context,
+	id string,
+	userID int64,
+	appID int32,
+	device *models.Device,
+	fp models.Fingerprint,
+	tokenHash []byte,
+	expiresAt time.Time,
+	absoluteExpiresAt time.Time,
+	scopes []string,
+) error {
+	const op = "storage.sqldb.SaveRefreshToken"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	query := `
+		INSERT INTO refresh_tokens (
+			id, user_id, app_id, device_id, device_name, token_hash, created_at, expires_at,
+			scopes, ip, user_agent, fingerprint, absolute_expires_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	var deviceID, deviceName string
+	if device != nil {
+		deviceID, deviceName = device.ID, device.Name
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		id,
+		userID,
+		appID,
+		nullIfEmpty(deviceID),
+		nullIfEmpty(deviceName),
+		tokenHash,
+		now(),
+		expiresAt,
+		jsonArray[string](scopes),
+		nullIfEmpty(fp.IP),
+		nullIfEmpty(fp.UserAgent),
+		fp.Hash,
+		absoluteExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SQLRepo) UpdateRefreshToken(
+	ctx context.Context,
+	id uuid.UUID,
+	newTokenHash []byte,
+	oldTokenHash []byte,
+	expiresAt time.Time,
+	orgID int64,
+) error {
+	const op = "storage.sqldb.UpdateRefreshToken"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	var org any
+	if orgID != 0 {
+		org = orgID
+	}
+
+	err := r.execOne(ctx, storage.ErrRefreshTokenConflict, `
+		UPDATE refresh_tokens
+		SET token_hash = ?,
+			expires_at = ?,
+			org_id = ?
+		WHERE id = ? AND token_hash = ?
+	`, newTokenHash, expiresAt, org, id, oldTokenHash)
+	if err != nil && !errors.Is(err, storage.ErrRefreshTokenConflict) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return err
+}
+
+func (r *SQLRepo) RefreshTokenByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (*models.RefreshToken, error) {
+	const op = "storage.sqldb.RefreshTokenByID"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, app_id, token_hash, expires_at, absolute_expires_at, scopes, COALESCE(org_id, 0)
+		FROM refresh_tokens
+		WHERE id = ?
+	`
+
+	var (
+		rt     models.RefreshToken
+		scopes jsonArray[string]
+	)
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&rt.ID,
+		&rt.UserID,
+		&rt.AppID,
+		&rt.TokenHash,
+		&rt.ExpiresAt,
+		&rt.AbsoluteExpiresAt,
+		&scopes,
+		&rt.OrgID,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrRefreshTokenNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	rt.Scopes = scopes
+
+	return &rt, nil
+}
+
+// SessionsByUserID возвращает неистёкшие refresh-токены пользователя,
+// новые первыми.
+func (r *SQLRepo) SessionsByUserID(ctx context.Context, userID int64) ([]models.Session, error) {
+	const op = "storage.sqldb.SessionsByUserID"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT
+			id, app_id, COALESCE(device_id, ''), COALESCE(device_name, ''),
+			COALESCE(ip, ''), COALESCE(user_agent, ''), created_at, expires_at
+		FROM refresh_tokens
+		WHERE user_id = ? AND expires_at > ?
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+	for rows.Next() {
+		var s models.Session
+		err := rows.Scan(&s.ID, &s.AppID, &s.DeviceID, &s.DeviceName, &s.IP, &s.UserAgent, &s.CreatedAt, &s.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sessions, nil
+}
+
+// LockActiveSessions вызывается в транзакции логина: блокировка строки
+// пользователя не даёт параллельным логинам одновременно пройти проверку
+// лимита сессий.
+func (r *SQLRepo) LockActiveSessions(ctx context.Context, userID int64, appID int32) ([]uuid.UUID, error) {
+	const op = "storage.sqldb.LockActiveSessions"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	if _, err := r.lockUserDeletedAt(ctx, r.db, userID); err != nil && !errors.Is(err, storage.ErrUserNotFound) {
+		return nil, fmt.Errorf("%s: lock user: %w", op, err)
+	}
+
+	query := `
+		SELECT id
+		FROM refresh_tokens
+		WHERE user_id = ? AND app_id = ? AND expires_at > ?
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, appID, now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ids, nil
+}
+
+func (r *SQLRepo) DeleteRefreshToken(
+	ctx context.Context,
+	id uuid.UUID,
+) error {
+	const op = "storage.sqldb.DeleteRefreshToken"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SQLRepo) SaveResetToken(
+	ctx context.Context,
+	tokenID uuid.UUID,
+	userID int64,
+	tokenHash []byte,
+	expiresAt time.Time,
+) error {
+	const op = "storage.sqldb.SaveResetToken"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	query := `
+		INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, tokenID, userID, tokenHash, expiresAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SQLRepo) ResetTokenByID(ctx context.Context, tokenID uuid.UUID) (*models.ResetToken, error) {
+	const op = "storage.sqldb.ResetTokenByID"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, token_hash, expires_at, used_at
+		FROM password_reset_tokens
+		WHERE id = ?
+	`
+
+	var rt models.ResetToken
+
+	err := r.db.QueryRowContext(ctx, query, tokenID).Scan(
+		&rt.ID,
+		&rt.UserID,
+		&rt.TokenHash,
+		&rt.ExpiresAt,
+		&rt.UsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrResetTokenNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &rt, nil
+}
+
+// execCount выполняет DELETE или UPDATE и возвращает число затронутых строк.
+func (r *SQLRepo) execCount(ctx context.Context, query string, args ...any) (int64, error) {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// DeleteAllRefreshTokens удаляет все refresh-токены пользователя во всех
+// приложениях и возвращает их количество.
+func (r *SQLRepo) DeleteAllRefreshTokens(ctx context.Context, userID int64) (int64, error) {
+	const op = "storage.sqldb.DeleteAllRefreshTokens"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	n, err := r.execCount(ctx, `DELETE FROM refresh_tokens WHERE user_id = ?`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// DeleteDeviceRefreshTokens удаляет сессию пользователя на устройстве —
+// перед выдачей новой, чтобы на одном device_id была одна сессия.
+func (r *SQLRepo) DeleteDeviceRefreshTokens(ctx context.Context, userID int64, deviceID string) (int64, error) {
+	const op = "storage.sqldb.DeleteDeviceRefreshTokens"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	n, err := r.execCount(ctx, `DELETE FROM refresh_tokens WHERE user_id = ? AND device_id = ?`, userID, deviceID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+func (r *SQLRepo) DeleteAllResetTokens(ctx context.Context, uid int64) error {
+	const op = "storage.sqldb.DeleteAllResetTokens"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM password_reset_tokens WHERE user_id = ?`, uid); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SQLRepo) ResetPassword(
+	ctx context.Context,
+	userID int64,
+	tokenID uuid.UUID,
+	newPasswordHash []byte,
+) error {
+	const op = "storage.sqldb.ResetPassword"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	err := r.inTx(ctx, func(q querier) error {
+		t := now()
+
+		res, err := q.ExecContext(ctx, `
+			UPDATE password_reset_tokens
+			SET used_at = ?
+			WHERE id = ? AND user_id = ? AND used_at IS NULL
+		`, t, tokenID, userID)
+		if err != nil {
+			return fmt.Errorf("invalidate token: %w", err)
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			// Либо уже использован конкурентным запросом, либо не существует/чужой
+			return storage.ErrResetTokenUsed
+		}
+
+		res, err = q.ExecContext(ctx, `
+			UPDATE users
+			SET password_hash = ?,
+				must_reset_password = FALSE,
+				updated_at = ?
+			WHERE id = ? AND deleted_at IS NULL
+		`, newPasswordHash, t, userID)
+		if err != nil {
+			return fmt.Errorf("update password: %w", err)
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return storage.ErrUserNotFound
+		}
+
+		if _, err := q.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE user_id = ?`, userID); err != nil {
+			return fmt.Errorf("delete refresh tokens: %w", err)
+		}
+
+		if _, err := q.ExecContext(ctx, `DELETE FROM password_reset_tokens WHERE user_id = ?`, userID); err != nil {
+			return fmt.Errorf("delete reset tokens: %w", err)
+		}
+
+		if _, err := invalidateMagicLinks(ctx, q, userID); err != nil {
+			return fmt.Errorf("invalidate magic links: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrResetTokenUsed) || errors.Is(err, storage.ErrUserNotFound) {
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// * SaveTOTPSecret сохраняет новый секрет для enroll. Неподтверждённый секрет
+// перезаписывается (повторный enroll), подтверждённый — нет.
+func (r *SQLRepo) SaveTOTPSecret(ctx context.Context, userID int64, secretEnc []byte) error {
+	const op = "storage.sqldb.SaveTOTPSecret"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	// условного ON CONFLICT ... WHERE в MySQL нет, поэтому строка
+	// блокируется и проверяется отдельно
+	err := r.inTx(ctx, func(q querier) error {
+		var confirmedAt *time.Time
+
+		err := q.QueryRowContext(ctx,
+			`SELECT confirmed_at FROM totp_secrets WHERE user_id = ?`+r.dialect.forUpdate(),
+			userID,
+		).Scan(&confirmedAt)
+
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			_, err = q.ExecContext(ctx,
+				`INSERT INTO totp_secrets (user_id, secret_enc, last_used_step, created_at) VALUES (?, ?, 0, ?)`,
+				userID, secretEnc, now(),
+			)
+			return err
+		case err != nil:
+			return err
+		case confirmedAt != nil:
+			return storage.ErrTOTPAlreadyConfirmed
+		}
+
+		_, err = q.ExecContext(ctx,
+			`UPDATE totp_secrets SET secret_enc = ?, last_used_step = 0, created_at = ? WHERE user_id = ?`,
+			secretEnc, now(), userID,
+		)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrTOTPAlreadyConfirmed) {
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// * TOTPSecret возвращает TOTP-секрет пользователя.
+func (r *SQLRepo) TOTPSecret(ctx context.Context, userID int64) (*models.TOTPSecret, error) {
+	const op = "storage.sqldb.TOTPSecret"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	secret := &models.TOTPSecret{}
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT user_id, secret_enc, confirmed_at, last_used_step, created_at
+		FROM totp_secrets
+		WHERE user_id = ?
+	`, userID).Scan(
+		&secret.UserID,
+		&secret.SecretEnc,
+		&secret.ConfirmedAt,
+		&secret.LastUsedStep,
+		&secret.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrTOTPNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return secret, nil
+}
+
+// * ConfirmTOTP подтверждает секрет и включает TOTP 2FA пользователю в одной
+// транзакции. step — шаг кода, которым подтверждён enroll: он сразу
+// считается использованным.
+func (r *SQLRepo) ConfirmTOTP(ctx context.Context, userID int64, step int64) error {
+	const op = "storage.sqldb.ConfirmTOTP"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	t := now()
+
+	err := r.inTx(ctx, func(q querier) error {
+		res, err := q.ExecContext(ctx, `
+			UPDATE totp_secrets
+			SET confirmed_at = ?, last_used_step = ?
+			WHERE user_id = ? AND confirmed_at IS NULL
+		`, t, step, userID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return errOr(err, storage.ErrTOTPNotFound)
+		}
+
+		res, err = q.ExecContext(ctx, `
+			UPDATE users
+			SET is_2fa_enabled = TRUE, two_fa_method = 'totp', two_fa_enabled_at = ?
+			WHERE id = ? AND deleted_at IS NULL
+		`, t, userID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return errOr(err, storage.ErrTOTPNotFound)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrTOTPNotFound) {
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// errOr возвращает err, а если его нет — fallback.
+func errOr(err, fallback error) error {
+	if err != nil {
+		return err
+	}
+
+	return fallback
+}
+
+// * UseTOTPStep помечает шаг кода использованным. Шаг, не превышающий уже
+// принятый, отклоняется — один и тот же код нельзя предъявить дважды.
+func (r *SQLRepo) UseTOTPStep(ctx context.Context, userID int64, step int64) error {
+	const op = "storage.sqldb.UseTOTPStep"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	err := r.execOne(ctx, storage.ErrTOTPCodeReused, `
+		UPDATE totp_secrets
+		SET last_used_step = ?
+		WHERE user_id = ? AND confirmed_at IS NOT NULL AND last_used_step < ?
+	`, step, userID, step)
+	if err != nil {
+		if errors.Is(err, storage.ErrTOTPCodeReused) {
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// * DeleteTOTP удаляет секрет и выключает 2FA, если она была включена
+// через TOTP.
+func (r *SQLRepo) DeleteTOTP(ctx context.Context, userID int64) error {
+	const op = "storage.sqldb.DeleteTOTP"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	err := r.inTx(ctx, func(q querier) error {
+		res, err := q.ExecContext(ctx, `DELETE FROM totp_secrets WHERE user_id = ?`, userID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return err
+		}
+
+		_, err = q.ExecContext(ctx, `
+			UPDATE users
+			SET is_2fa_enabled = FALSE, two_fa_method = NULL, two_fa_enabled_at = NULL
+			WHERE id = ? AND two_fa_method = 'totp'
+		`, userID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package sqldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+// * SaveTrustedDevice запоминает доверенное устройство пользователя.
+func (r *SQLRepo) SaveTrustedDevice(ctx context.Context, d *models.TrustedDevice) error {
+	const op = "storage.sqldb.SaveTrustedDevice"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	t := now()
+
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO trusted_devices (
+			user_id,
+			token_hash,
+			device_id,
+			device_name,
+			ip,
+			user_agent,
+			created_at,
+			last_used_at,
+			expires_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		d.UserID,
+		d.TokenHash,
+		nullIfEmpty(d.DeviceID),
+		nullIfEmpty(d.DeviceName),
+		nullIfEmpty(d.IP),
+		nullIfEmpty(d.UserAgent),
+		t,
+		t,
+		d.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if d.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	d.CreatedAt, d.LastUsedAt = t, t
+
+	return nil
+}
+
+// * TouchTrustedDevice проверяет, что токен доверенного устройства выдан
+// этому пользователю и не истёк, и обновляет время последнего входа.
+func (r *SQLRepo) TouchTrustedDevice(ctx context.Context, userID int64, tokenHash []byte) (bool, error) {
+	const op = "storage.sqldb.TouchTrustedDevice"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	t := now()
+
+	n, err := r.execCount(ctx, `
+		UPDATE trusted_devices
+		SET last_used_at = ?
+		WHERE user_id = ? AND token_hash = ? AND expires_at > ?
+	`, t, userID, tokenHash, t)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n > 0, nil
+}
+
+// * TrustedDevices возвращает действующие доверенные устройства
+// пользователя, последние использованные первыми.
+func (r *SQLRepo) TrustedDevices(ctx context.Context, userID int64) ([]models.TrustedDevice, error) {
+	const op = "storage.sqldb.TrustedDevices"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, COALESCE(device_id, ''), COALESCE(device_name, ''),
+			COALESCE(ip, ''), COALESCE(user_agent, ''), created_at, last_used_at, expires_at
+		FROM trusted_devices
+		WHERE user_id = ? AND expires_at > ?
+		ORDER BY last_used_at DESC
+	`, userID, now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var devices []models.TrustedDevice
+	for rows.Next() {
+		var d models.TrustedDevice
+		err := rows.Scan(&d.ID, &d.UserID, &d.DeviceID, &d.DeviceName, &d.IP, &d.UserAgent, &d.CreatedAt, &d.LastUsedAt, &d.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		devices = append(devices, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return devices, nil
+}
+
+// * DeleteTrustedDevice отзывает доверие к одному устройству пользователя.
+func (r *SQLRepo) DeleteTrustedDevice(ctx context.Context, userID, id int64) error {
+	const op = "storage.sqldb.DeleteTrustedDevice"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	err := r.execOne(ctx, storage.ErrTrustedDeviceNotFound,
+		`DELETE FROM trusted_devices WHERE id = ? AND user_id = ?`, id, userID,
+	)
+	if err != nil {
+		if errors.Is(err, storage.ErrTrustedDeviceNotFound) {
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// * DeleteTrustedDevices отзывает доверие ко всем устройствам пользователя.
+func (r *SQLRepo) DeleteTrustedDevices(ctx context.Context, userID int64) (int64, error) {
+	const op = "storage.sqldb.DeleteTrustedDevices"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	n, err := r.execCount(ctx, `DELETE FROM trusted_devices WHERE user_id = ?`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
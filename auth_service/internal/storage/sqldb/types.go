@@ -0,0 +1,65 @@
+package sqldb
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// jsonArray — массив в JSON-колонке вместо TEXT[] и INTEGER[] Postgres.
+// NULL и пустая строка читаются как пустой массив.
+type jsonArray[T any] []T
+
+func (a jsonArray[T]) Value() (driver.Value, error) {
+	if a == nil {
+		return "[]", nil
+	}
+
+	data, err := json.Marshal([]T(a))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func (a *jsonArray[T]) Scan(src any) error {
+	var data []byte
+
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("jsonArray: unsupported type %T", src)
+	}
+
+	if len(data) == 0 {
+		*a = nil
+		return nil
+	}
+
+	return json.Unmarshal(data, (*[]T)(a))
+}
+
+// nullIfEmpty пишет пустую строку как NULL — аналог NULLIF в запросах
+// storage/postgres.
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
+
+// now — текущее время для запросов. Время задаётся из Go, а не NOW() СУБД:
+// в SQLite нет функции, возвращающей время в формате, в котором драйвер
+// пишет DATETIME, а сравнивать строки разных форматов нельзя.
+func now() time.Time {
+	return time.Now().UTC()
+}
@@ -0,0 +1,70 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	sl "auth_service/internal/lib/logger"
+	"auth_service/internal/storage"
+)
+
+// * Do реализует storage.UoW: fn получает копию репозитория, привязанную к
+// одной транзакции.
+func (r *SQLRepo) Do(ctx context.Context, fn func(ctx context.Context, tx storage.Tx) error) (err error) {
+	const op = "storage.sqldb.Do"
+
+	tx, err := r.pool.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			_ = tx.Rollback()
+			panic(rec)
+		}
+
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				r.log.Error("rollback failed", sl.Err(rbErr))
+			}
+		}
+	}()
+
+	if err := fn(ctx, r.withTx(tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SQLRepo) withTx(tx *sql.Tx) *SQLRepo {
+	return &SQLRepo{
+		pool:     r.pool,
+		db:       tx,
+		dialect:  r.dialect,
+		log:      r.log,
+		timeouts: r.timeouts,
+		migrate:  r.migrate,
+		locks:    r.locks,
+		password: r.password,
+	}
+}
+
+func (r *SQLRepo) Users() storage.UserRepo { return r }
+
+func (r *SQLRepo) Tokens() storage.TokenRepo { return r }
+
+func (r *SQLRepo) MagicLinks() storage.MagicLinkRepo { return r }
+
+func (r *SQLRepo) Audit() storage.AuditRepo { return r }
+
+func (r *SQLRepo) Roles() storage.RoleRepo { return r }
+
+func (r *SQLRepo) Devices() storage.DeviceRepo { return r }
@@ -0,0 +1,481 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth_service/internal/models"
+	"auth_service/internal/storage"
+)
+
+const userColumns = `id, email, username, password_hash, is_verified, must_reset_password,
+	status, status_reason, deleted_at`
+
+func scanUser(row interface{ Scan(dest ...any) error }, u *models.User) error {
+	return row.Scan(
+		&u.ID,
+		&u.Email,
+		&u.Username,
+		&u.PassHash,
+		&u.IsVerified,
+		&u.MustResetPassword,
+		&u.Status,
+		&u.StatusReason,
+		&u.DeletedAt,
+	)
+}
+
+// SaveUser создаёт пользователя и сразу делает его участником приложения,
+// в котором он регистрируется.
+func (r *SQLRepo) SaveUser(ctx context.Context, email, username string, passHash []byte, appID int32) (int64, error) {
+	const op = "storage.sqldb.SaveUser"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	var id int64
+
+	err := r.inTx(ctx, func(q querier) error {
+		var err error
+		id, err = insertUser(ctx, q, email, username, passHash, false)
+		if err != nil {
+			return err
+		}
+
+		return addAppMember(ctx, q, id, appID)
+	})
+	if err != nil {
+		switch {
+		case violates(err, keyUsersUsername):
+			return 0, storage.ErrUsernameTaken
+		case isUniqueViolation(err):
+			return 0, storage.ErrUserAlreadyExists
+		case isForeignKeyViolation(err):
+			return 0, storage.ErrAppNotFound
+		}
+
+		return 0, fmt.Errorf("%s: failed to save user: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func insertUser(ctx context.Context, q querier, email, username string, passHash []byte, verified bool) (int64, error) {
+	t := now()
+
+	res, err := q.ExecContext(ctx, `
+		INSERT INTO users (email, username, password_hash, is_verified, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, email, username, passHash, verified, t, t)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+func addAppMember(ctx context.Context, q querier, userID int64, appID int32) error {
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO app_members (user_id, app_id, created_at) VALUES (?, ?, ?)`,
+		userID, appID, now(),
+	)
+
+	return err
+}
+
+func (r *SQLRepo) UserByEmail(ctx context.Context, email string) (*models.User, error) {
+	const op = "storage.sqldb.UserByEmail"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	var u models.User
+	err := scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE email = ?`, email), &u)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("%s: failed to get user: %w", op, err)
+	}
+
+	return &u, nil
+}
+
+func (r *SQLRepo) UserByID(ctx context.Context, id int64) (*models.User, error) {
+	const op = "storage.sqldb.UserByID"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	var u models.User
+	err := scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, id), &u)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("%s: failed to get user by id: %w", op, err)
+	}
+
+	return &u, nil
+}
+
+func (r *SQLRepo) UserIDByEmail(ctx context.Context, email string) (int64, error) {
+	const op = "storage.sqldb.UserIDByEmail"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	var id int64
+
+	err := r.db.QueryRowContext(ctx, `SELECT id FROM users WHERE email = ? AND deleted_at IS NULL`, email).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, storage.ErrUserNotFound
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// * CheckIfUserVerified проверяет, подтвердил ли пользователь свой email
+func (r *SQLRepo) CheckIfUserVerified(ctx context.Context, email string) (int64, bool, error) {
+	const op = "storage.sqldb.CheckIfUserVerified"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	var (
+		id         int64
+		isVerified bool
+	)
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, is_verified FROM users WHERE email = ? AND deleted_at IS NULL`,
+		email,
+	).Scan(&id, &isVerified)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, storage.ErrUserNotFound
+		}
+
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, isVerified, nil
+}
+
+// execOne выполняет UPDATE или DELETE и возвращает notFound, если запрос не
+// затронул ни одной строки.
+func (r *SQLRepo) execOne(ctx context.Context, notFound error, query string, args ...any) error {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+func (r *SQLRepo) SetEmailVerified(ctx context.Context, userID int64) error {
+	const op = "storage.sqldb.SetEmailVerified"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	err := r.execOne(ctx, storage.ErrUserNotFound,
+		`UPDATE users SET is_verified = TRUE, updated_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		now(), userID,
+	)
+	if err != nil && !errors.Is(err, storage.ErrUserNotFound) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return err
+}
+
+// UpdateUsername меняет username. Занятый username — storage.ErrUsernameTaken.
+func (r *SQLRepo) UpdateUsername(ctx context.Context, userID int64, username string) error {
+	const op = "storage.sqldb.UpdateUsername"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	err := r.execOne(ctx, storage.ErrUserNotFound,
+		`UPDATE users SET username = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		username, now(), userID,
+	)
+	switch {
+	case err == nil, errors.Is(err, storage.ErrUserNotFound):
+		return err
+	case isUniqueViolation(err):
+		return storage.ErrUsernameTaken
+	}
+
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+// RehashPassword заменяет хеш пароля, только если он всё ещё равен
+// oldHash; см. postgres.RehashPassword.
+func (r *SQLRepo) RehashPassword(ctx context.Context, userID int64, oldHash, newHash []byte) error {
+	const op = "storage.sqldb.RehashPassword"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE users
+		SET password_hash = ?, updated_at = ?
+		WHERE id = ? AND password_hash = ? AND deleted_at IS NULL
+	`, newHash, now(), userID, oldHash)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ChangeEmail меняет email, только если он всё ещё равен oldEmail, и
+// сбрасывает подтверждение. Email сменился раньше или аккаунт удалён —
+// storage.ErrUserNotFound, адрес занят — storage.ErrUserAlreadyExists.
+func (r *SQLRepo) ChangeEmail(ctx context.Context, userID int64, oldEmail, newEmail string) error {
+	const op = "storage.sqldb.ChangeEmail"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	err := r.execOne(ctx, storage.ErrUserNotFound, `
+		UPDATE users
+		SET email = ?, is_verified = FALSE, updated_at = ?
+		WHERE id = ? AND email = ? AND deleted_at IS NULL
+	`, newEmail, now(), userID, oldEmail)
+	switch {
+	case err == nil, errors.Is(err, storage.ErrUserNotFound):
+		return err
+	case isUniqueViolation(err):
+		return storage.ErrUserAlreadyExists
+	}
+
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+// SetMustResetPassword выставляет или снимает флаг обязательной смены пароля.
+func (r *SQLRepo) SetMustResetPassword(ctx context.Context, userID int64, mustReset bool) error {
+	const op = "storage.sqldb.SetMustResetPassword"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	err := r.execOne(ctx, storage.ErrUserNotFound,
+		`UPDATE users SET must_reset_password = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		mustReset, now(), userID,
+	)
+	if err != nil && !errors.Is(err, storage.ErrUserNotFound) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return err
+}
+
+// SetUserStatus переводит аккаунт из from в to. Если статус уже изменился,
+// вернётся storage.ErrUserStatusConflict.
+func (r *SQLRepo) SetUserStatus(
+	ctx context.Context,
+	userID int64,
+	from, to models.AccountStatus,
+	reason, actor string,
+) error {
+	const op = "storage.sqldb.SetUserStatus"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	t := now()
+
+	err := r.execOne(ctx, storage.ErrUserStatusConflict, `
+		UPDATE users
+		SET status = ?,
+			status_reason = ?,
+			status_changed_by = ?,
+			status_changed_at = ?,
+			updated_at = ?
+		WHERE id = ? AND status = ?
+	`, string(to), nullIfEmpty(reason), actor, t, t, userID, string(from))
+	if err != nil && !errors.Is(err, storage.ErrUserStatusConflict) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return err
+}
+
+// lockUserDeletedAt блокирует пользователя до конца транзакции и
+// возвращает его deleted_at.
+func (r *SQLRepo) lockUserDeletedAt(ctx context.Context, q querier, userID int64) (*time.Time, error) {
+	var deletedAt *time.Time
+
+	err := q.QueryRowContext(ctx, `SELECT deleted_at FROM users WHERE id = ?`+r.dialect.forUpdate(), userID).Scan(&deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrUserNotFound
+	}
+
+	return deletedAt, err
+}
+
+func (r *SQLRepo) DeleteAccount(ctx context.Context, userID int64) error {
+	const op = "storage.sqldb.DeleteAccount"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	err := r.inTx(ctx, func(q querier) error {
+		deletedAt, err := r.lockUserDeletedAt(ctx, q, userID)
+		if err != nil {
+			return err
+		}
+		if deletedAt != nil {
+			return storage.ErrUserAlreadyDeleted
+		}
+
+		t := now()
+
+		if _, err := q.ExecContext(ctx, `
+			UPDATE users
+			SET deleted_at = ?,
+				status = 'pending_deletion',
+				status_reason = NULL,
+				status_changed_by = 'user',
+				status_changed_at = ?,
+				updated_at = ?
+			WHERE id = ?
+		`, t, t, t, userID); err != nil {
+			return fmt.Errorf("mark deleted: %w", err)
+		}
+
+		if _, err := q.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE user_id = ?`, userID); err != nil {
+			return fmt.Errorf("delete refresh tokens: %w", err)
+		}
+
+		if _, err := q.ExecContext(ctx, `DELETE FROM password_reset_tokens WHERE user_id = ?`, userID); err != nil {
+			return fmt.Errorf("delete reset tokens: %w", err)
+		}
+
+		if _, err := invalidateMagicLinks(ctx, q, userID); err != nil {
+			return fmt.Errorf("invalidate magic links: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) || errors.Is(err, storage.ErrUserAlreadyDeleted) {
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// * RestoreAccount снимает флаг soft-delete, если grace period ещё не истёк.
+func (r *SQLRepo) RestoreAccount(ctx context.Context, userID int64, gracePeriod time.Duration) error {
+	const op = "storage.sqldb.RestoreAccount"
+
+	ctx, cancel := r.queryCtx(ctx, queryWrite)
+	defer cancel()
+
+	err := r.inTx(ctx, func(q querier) error {
+		deletedAt, err := r.lockUserDeletedAt(ctx, q, userID)
+		if err != nil {
+			return err
+		}
+		if deletedAt == nil {
+			return storage.ErrNothingToRestore
+		}
+		if deletedAt.Before(time.Now().Add(-gracePeriod)) {
+			return storage.ErrRestoreWindowExpired
+		}
+
+		t := now()
+
+		_, err = q.ExecContext(ctx, `
+			UPDATE users
+			SET deleted_at = NULL,
+				status = 'active',
+				status_changed_by = 'user',
+				status_changed_at = ?,
+				updated_at = ?
+			WHERE id = ?
+		`, t, t, userID)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUserNotFound),
+			errors.Is(err, storage.ErrNothingToRestore),
+			errors.Is(err, storage.ErrRestoreWindowExpired):
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ListUsers возвращает до limit пользователей с id > afterID по возрастанию
+// id — keyset-пагинация для административных выгрузок.
+func (r *SQLRepo) ListUsers(ctx context.Context, afterID int64, limit int) ([]models.User, error) {
+	const op = "storage.sqldb.ListUsers"
+
+	ctx, cancel := r.queryCtx(ctx, queryRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, email, username, is_verified, must_reset_password,
+			status, status_reason, deleted_at
+		FROM users
+		WHERE id > ?
+		ORDER BY id
+		LIMIT ?
+	`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(
+			&u.ID,
+			&u.Email,
+			&u.Username,
+			&u.IsVerified,
+			&u.MustResetPassword,
+			&u.Status,
+			&u.StatusReason,
+			&u.DeletedAt,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return users, nil
+}
@@ -5,8 +5,23 @@ import "errors"
 var (
 	ErrUserAlreadyExists = errors.New("user already exists")
 	ErrUserNotFound      = errors.New("user not found")
+	ErrUsernameTaken     = errors.New("username already taken")
 
-	ErrAppNotFound = errors.New("app not found")
+	ErrAppNotFound       = errors.New("app not found")
+	ErrAppAlreadyExists  = errors.New("app already exists")
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleAlreadyExists = errors.New("role already exists")
+
+	ErrSigningKeyNotFound = errors.New("signing key not found")
+	ErrSigningKeyConflict = errors.New("signing key has been rotated concurrently")
+
+	ErrOrgNotFound           = errors.New("organization not found")
+	ErrOrgMemberNotFound     = errors.New("organization member not found")
+	ErrOrgMemberExists       = errors.New("user is already a member of the organization")
+	ErrOrgInvitationNotFound = errors.New("organization invitation not found or expired")
+	ErrLastOrgOwner          = errors.New("cannot remove the last owner of the organization")
+
+	ErrAccessTokenNotFound = errors.New("access token not found")
 
 	ErrRefreshTokenNotFound = errors.New("refresh token not found")
 	ErrRefreshTokenConflict = errors.New("refresh token has already been rotated")
@@ -14,30 +29,59 @@ var (
 	ErrResetTokenNotFound = errors.New("reset token not found")
 	ErrResetTokenUsed     = errors.New("reset token already used")
 
-	ErrOAuthAccountNotFound       = errors.New("oauth account not found")
-	ErrOAuthAccountAlreadyLinked  = errors.New("oauth account already linked to another user")
-	ErrOAuthProviderAlreadyLinked = errors.New("user already has this provider linked")
-	ErrOAuthStateNotFound         = errors.New("oauth state not found or expired")
-	ErrOAuthLastAuthMethod        = errors.New("cannot unlink last authentication method")
+	ErrIdentityNotFound      = errors.New("identity not found")
+	ErrIdentityAlreadyLinked = errors.New("identity already linked to another user")
+	ErrProviderAlreadyLinked = errors.New("user already has this provider linked")
+	ErrLastAuthMethod        = errors.New("cannot unlink last authentication method")
+	ErrOAuthStateNotFound    = errors.New("oauth state not found or expired")
 
 	ErrMagicLinkNotFound      = errors.New("magic link not found")
 	ErrPendingSessionNotFound = errors.New("pending session not found or expired")
 
+	ErrUnlockTokenNotFound = errors.New("unlock token not found or expired")
+
+	ErrChallengeNotFound = errors.New("challenge not found or expired")
+
+	ErrEmailChangeNotFound = errors.New("email change request not found or expired")
+
+	ErrLoginLocationNotFound = errors.New("login location not found")
+
+	ErrAuthorizationCodeNotFound = errors.New("authorization code not found or expired")
+
+	ErrCSRFTokenNotFound = errors.New("csrf token not found or expired")
+
+	ErrAPIKeyNotFound = errors.New("api key not found")
+
+	ErrIPRuleNotFound = errors.New("ip rule not found")
+	ErrIPRuleExists   = errors.New("ip rule for this network already exists")
+
+	ErrVerificationCodeNotFound = errors.New("verification code not found or expired")
+
 	ErrUserAlreadyDeleted = errors.New("user already deleted")
+	ErrUserStatusConflict = errors.New("user status has been changed concurrently")
 
 	ErrNothingToRestore     = errors.New("account is not deleted")
 	ErrRestoreWindowExpired = errors.New("restore window has expired")
+
+	ErrTOTPNotFound         = errors.New("totp secret not found")
+	ErrTOTPAlreadyConfirmed = errors.New("totp already confirmed")
+	ErrTOTPCodeReused       = errors.New("totp code already used")
+
+	ErrTwoFADeliveryNotFound = errors.New("2fa delivery channel not set")
+
+	ErrTrustedDeviceNotFound = errors.New("trusted device not found")
 )
 
 // gcraScript реализует GCRA (Generic Cell Rate Algorithm) одним атомарным
 // вызовом EVALSHA — исключает race между read-modify-write, которая была бы
-// при отдельных INCR+EXPIRE командах.
+// при отдельных INCR+EXPIRE командах. Время берётся из Redis (TIME), а не
+// у вызывающей реплики: иначе расхождение часов между репликами сдвигало бы
+// TAT общего ключа и пропускало лишние запросы или блокировало лишние.
 //
 // KEYS[1] - ключ лимита
 // ARGV[1] - burst (максимум токенов в бакете)
 // ARGV[2] - rate (токенов в секунду, как float string "0.0833" для 5/min)
 // ARGV[3] - cost (сколько токенов стоит текущий запрос, обычно 1)
-// ARGV[4] - now unix milliseconds
 //
 // Возвращает: {allowed (0/1), retry_after_ms, remaining}
 const (
@@ -46,7 +90,9 @@ const (
 		local burst = tonumber(ARGV[1])
 		local rate = tonumber(ARGV[2])
 		local cost = tonumber(ARGV[3])
-		local now = tonumber(ARGV[4])
+
+		local time = redis.call('TIME')
+		local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
 
 		local emission_interval = 1000 / rate
 		local increment = emission_interval * cost
@@ -68,7 +114,6 @@ const (
 			return {0, math.floor(retry_after), 0}
 		end
 
-		local ttl = math.ceil((new_tat - now) / 1000) + 1
 		redis.call('SET', key, new_tat, 'PX', math.floor(burst_offset + increment) + 1000)
 
 		local remaining = math.floor((burst_offset - (new_tat - now)) / emission_interval)
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"auth_service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// UserRepo — операции над users, доступные внутри UoW-транзакции.
+type UserRepo interface {
+	SaveUser(ctx context.Context, email string, username string, passHash []byte, appID int32) (int64, error)
+	UserByID(ctx context.Context, id int64) (*models.User, error)
+	UserByEmail(ctx context.Context, email string) (*models.User, error)
+	SetEmailVerified(ctx context.Context, userID int64) error
+	SetMustResetPassword(ctx context.Context, userID int64, mustReset bool) error
+	SetUserStatus(ctx context.Context, userID int64, from, to models.AccountStatus, reason, actor string) error
+}
+
+// TokenRepo — refresh- и reset-токены.
+type TokenRepo interface {
+	SaveRefreshToken(
+		ctx context.Context,
+		id string,
+		userID int64,
+		appID int32,
+		device *models.Device,
+		fp models.Fingerprint,
+		tokenHash []byte,
+		expiresAt time.Time,
+		absoluteExpiresAt time.Time,
+		scopes []string,
+	) error
+	DeleteRefreshToken(ctx context.Context, id uuid.UUID) error
+	// LockActiveSessions блокирует пользователя до конца транзакции и
+	// возвращает его неистёкшие refresh-токены в приложении, старые первыми.
+	LockActiveSessions(ctx context.Context, userID int64, appID int32) ([]uuid.UUID, error)
+	DeleteAllRefreshTokens(ctx context.Context, userID int64) (int64, error)
+	DeleteDeviceRefreshTokens(ctx context.Context, userID int64, deviceID string) (int64, error)
+
+	SaveResetToken(ctx context.Context, tokenID uuid.UUID, userID int64, tokenHash []byte, expiresAt time.Time) error
+	DeleteAllResetTokens(ctx context.Context, uid int64) error
+}
+
+// MagicLinkRepo — magic-link токены 2FA.
+type MagicLinkRepo interface {
+	SaveMagicLink(ctx context.Context, link *models.MagicLink) error
+	ConsumeMagicLink(ctx context.Context, tokenHash []byte) (*models.MagicLink, error)
+	InvalidateMagicLinksByUserID(ctx context.Context, userID int64) (int64, error)
+}
+
+// DeviceRepo — устройства, с которых пользователь уже входил.
+type DeviceRepo interface {
+	TouchKnownDevice(ctx context.Context, userID int64, fp models.Fingerprint) (inserted, hadDevices bool, err error)
+}
+
+// AuditRepo — журнал действий над аккаунтами.
+type AuditRepo interface {
+	SaveAuditEvent(ctx context.Context, event *models.AuditEvent) error
+}
+
+// RoleRepo — назначение ролей пользователям.
+type RoleRepo interface {
+	AssignRole(ctx context.Context, userID, roleID int64, grantedBy string) (bool, error)
+	RevokeRole(ctx context.Context, userID, roleID int64) (bool, error)
+}
+
+// Tx — транзакционные варианты репозиториев. Всё, что сделано через
+// репозитории одного Tx, коммитится или откатывается целиком.
+type Tx interface {
+	Users() UserRepo
+	Tokens() TokenRepo
+	MagicLinks() MagicLinkRepo
+	Audit() AuditRepo
+	Roles() RoleRepo
+	Devices() DeviceRepo
+}
+
+// UoW (unit of work) открывает транзакцию, отдаёт её в fn и коммитит, если
+// fn вернула nil; любая ошибка (или паника) — откат. Ошибка fn возвращается
+// как есть, без обёртки, чтобы вызывающий мог сравнивать её через errors.Is.
+type UoW interface {
+	Do(ctx context.Context, fn func(ctx context.Context, tx Tx) error) error
+}
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Формат access-токенов приложения: jwt — самодостаточный подписанный токен,
+-- opaque — случайная строка, claims которой хранятся в Redis.
+ALTER TABLE apps
+ADD COLUMN IF NOT EXISTS access_token_format TEXT NOT NULL DEFAULT 'jwt' CONSTRAINT chk_apps_access_token_format CHECK (access_token_format IN ('jwt', 'opaque'));
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE apps DROP COLUMN IF EXISTS access_token_format;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Безвозвратное удаление аккаунтов переехало в задачу retention сервиса:
+-- grace period задаётся retention.deleted_accounts, а не зашит в функцию.
+SELECT cron.unschedule('hard_delete_expired_accounts');
+DROP FUNCTION IF EXISTS hard_delete_expired_accounts();
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION hard_delete_expired_accounts() RETURNS INTEGER LANGUAGE plpgsql AS $$
+DECLARE total_deleted INTEGER := 0;
+batch_deleted INTEGER;
+BEGIN LOOP
+DELETE FROM users
+WHERE id IN (
+		SELECT id
+		FROM users
+		WHERE deleted_at IS NOT NULL
+			AND deleted_at < NOW() - INTERVAL '7 days'
+		ORDER BY id
+		LIMIT 100 FOR
+		UPDATE SKIP LOCKED
+	);
+GET DIAGNOSTICS batch_deleted = ROW_COUNT;
+total_deleted := total_deleted + batch_deleted;
+EXIT
+WHEN batch_deleted < 100;
+END LOOP;
+RETURN total_deleted;
+END;
+$$;
+SELECT cron.schedule(
+		'hard_delete_expired_accounts',
+		'0 * * * *',
+		$$SELECT hard_delete_expired_accounts() $$
+	);
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Состояние аккаунта для модерации. pending_deletion дублирует deleted_at
+-- и выставляется/снимается вместе с ним (DeleteAccount/RestoreAccount).
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active' CONSTRAINT chk_users_status CHECK (
+    status IN ('active', 'suspended', 'banned', 'pending_deletion')
+  ),
+  ADD COLUMN IF NOT EXISTS status_reason TEXT,
+  ADD COLUMN IF NOT EXISTS status_changed_by TEXT,
+  ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMPTZ;
+UPDATE users
+SET status = 'pending_deletion',
+  status_changed_by = 'user',
+  status_changed_at = deleted_at
+WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS status_changed_at,
+  DROP COLUMN IF EXISTS status_changed_by,
+  DROP COLUMN IF EXISTS status_reason,
+  DROP COLUMN IF EXISTS status;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- disabled — обратимое отключение аккаунта, отдельное от модерационного
+-- suspended.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_status,
+  ADD CONSTRAINT chk_users_status CHECK (
+    status IN (
+      'active',
+      'disabled',
+      'suspended',
+      'banned',
+      'pending_deletion'
+    )
+  );
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+UPDATE users
+SET status = 'suspended'
+WHERE status = 'disabled';
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_status,
+  ADD CONSTRAINT chk_users_status CHECK (
+    status IN ('active', 'suspended', 'banned', 'pending_deletion')
+  );
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Долгоживущие ключи машинных клиентов (batch-задачи), привязанные к
+-- приложению и набору scope'ов. Хранится только хеш секретной части ключа.
+CREATE TABLE IF NOT EXISTS api_keys (
+  id UUID CONSTRAINT pk_api_keys PRIMARY KEY,
+  app_id BIGINT NOT NULL,
+  name TEXT NOT NULL,
+  key_hash BYTEA NOT NULL,
+  scopes TEXT [] NOT NULL DEFAULT '{}',
+  -- кто выпустил: admin:<login>
+  created_by TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  expires_at TIMESTAMPTZ,
+  last_used_at TIMESTAMPTZ,
+  revoked_at TIMESTAMPTZ,
+  CONSTRAINT fk_api_keys_app FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_app_id ON api_keys (app_id);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS api_keys;
+-- +goose StatementEnd
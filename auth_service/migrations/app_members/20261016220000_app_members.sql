@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Принадлежность пользователя приложению. Запись создаётся при регистрации
+-- (в том числе через OAuth) в приложении, от имени которого она пришла.
+CREATE TABLE IF NOT EXISTS app_members (
+  user_id BIGINT NOT NULL,
+  app_id BIGINT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  CONSTRAINT pk_app_members PRIMARY KEY (user_id, app_id),
+  CONSTRAINT fk_app_members_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_app_members_app FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_app_members_app_id ON app_members (app_id);
+-- До появления таблицы пул пользователей был общим: существующие
+-- пользователи становятся участниками всех существующих приложений,
+-- чтобы включение проверки не заблокировало им вход.
+INSERT INTO app_members (user_id, app_id)
+SELECT u.id,
+  a.id
+FROM users u
+  CROSS JOIN apps a ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS app_members;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Сроки жизни токенов приложения. NULL — глобальные tokens.access_token_ttl
+-- и tokens.refresh_token_ttl из конфига: мобильному приложению можно дать
+-- refresh на 30 дней, админке — на 8 часов.
+ALTER TABLE apps
+ADD COLUMN IF NOT EXISTS access_token_ttl INTERVAL CONSTRAINT chk_apps_access_token_ttl CHECK (access_token_ttl > INTERVAL '0'),
+ADD COLUMN IF NOT EXISTS refresh_token_ttl INTERVAL CONSTRAINT chk_apps_refresh_token_ttl CHECK (refresh_token_ttl > INTERVAL '0');
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE apps
+DROP COLUMN IF EXISTS access_token_ttl,
+DROP COLUMN IF EXISTS refresh_token_ttl;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- ==========================================================
+-- Audit events
+-- ==========================================================
+-- Без FK на users: журнал должен переживать hard delete аккаунта.
+CREATE TABLE IF NOT EXISTS audit_events (
+  id BIGSERIAL CONSTRAINT pk_audit_events PRIMARY KEY,
+  user_id BIGINT NOT NULL,
+  actor TEXT NOT NULL,
+  action TEXT NOT NULL,
+  ip INET,
+  user_agent TEXT,
+  metadata JSONB NOT NULL DEFAULT '{}'::jsonb,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_audit_events_user_created ON audit_events (user_id, created_at DESC);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS audit_events;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Лента активности листается по id (keyset), а не по created_at.
+CREATE INDEX IF NOT EXISTS idx_audit_events_user_id_id ON audit_events (user_id, id DESC);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_audit_events_user_id_id;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Отпечаток устройства, с которого выдан refresh-токен: IP и User-Agent
+-- логина и хеш, по которому устройство узнаётся при следующих входах.
+ALTER TABLE refresh_tokens
+ADD COLUMN IF NOT EXISTS ip INET,
+  ADD COLUMN IF NOT EXISTS user_agent TEXT,
+  ADD COLUMN IF NOT EXISTS fingerprint BYTEA;
+-- Откуда запрошен magic link — колонки были заготовлены ещё в init_table.
+ALTER TABLE magic_links
+ADD COLUMN IF NOT EXISTS ip_address INET,
+  ADD COLUMN IF NOT EXISTS user_agent TEXT;
+-- Устройства, с которых пользователь уже входил. Переживают сессии:
+-- logout не делает устройство снова «новым».
+CREATE TABLE IF NOT EXISTS known_devices (
+  user_id BIGINT NOT NULL,
+  fingerprint BYTEA NOT NULL,
+  ip INET,
+  user_agent TEXT,
+  first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  CONSTRAINT pk_known_devices PRIMARY KEY (user_id, fingerprint),
+  CONSTRAINT fk_known_devices_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS known_devices;
+ALTER TABLE magic_links DROP COLUMN IF EXISTS user_agent,
+  DROP COLUMN IF EXISTS ip_address;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS fingerprint,
+  DROP COLUMN IF EXISTS user_agent,
+  DROP COLUMN IF EXISTS ip;
+-- +goose StatementEnd
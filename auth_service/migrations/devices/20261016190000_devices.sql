@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Устройство, с которого выдан refresh-токен. device_id присылает клиент
+-- (стабильный идентификатор установки), device_name — для списка сессий.
+ALTER TABLE refresh_tokens
+ADD COLUMN IF NOT EXISTS device_id TEXT,
+  ADD COLUMN IF NOT EXISTS device_name TEXT;
+-- Одна сессия на устройство: новый логин с того же device_id заменяет старую.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_refresh_tokens_user_device ON refresh_tokens (user_id, device_id)
+WHERE device_id IS NOT NULL;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS uq_refresh_tokens_user_device;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS device_name,
+  DROP COLUMN IF EXISTS device_id;
+-- +goose StatementEnd
@@ -0,0 +1,50 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Внешние учётки пользователя — не только OAuth: kind различает тип
+-- провайдера (oauth, saml), subject — ID пользователя у провайдера (sub,
+-- NameID). Список провайдеров больше не фиксирован в CHECK: он задаётся
+-- конфигом сервиса.
+ALTER TABLE oauth_accounts
+  RENAME TO identities;
+ALTER TABLE identities
+  RENAME COLUMN provider_user_id TO subject;
+ALTER TABLE identities
+ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'oauth' CONSTRAINT chk_identities_kind CHECK (kind IN ('oauth', 'saml')),
+  DROP CONSTRAINT IF EXISTS chk_oauth_provider;
+ALTER TABLE identities
+  RENAME CONSTRAINT pk_oauth_accounts TO pk_identities;
+ALTER TABLE identities
+  RENAME CONSTRAINT uq_oauth_provider_user TO uq_identities_provider_subject;
+ALTER TABLE identities
+  RENAME CONSTRAINT uq_oauth_user_provider TO uq_identities_user_provider;
+ALTER TABLE identities
+  RENAME CONSTRAINT fk_oauth_accounts_user TO fk_identities_user;
+ALTER INDEX IF EXISTS idx_oauth_accounts_user_id
+RENAME TO idx_identities_user_id;
+ALTER SEQUENCE IF EXISTS oauth_accounts_id_seq
+RENAME TO identities_id_seq;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM identities
+WHERE kind <> 'oauth'
+  OR provider NOT IN ('google', 'github');
+ALTER SEQUENCE IF EXISTS identities_id_seq
+RENAME TO oauth_accounts_id_seq;
+ALTER INDEX IF EXISTS idx_identities_user_id
+RENAME TO idx_oauth_accounts_user_id;
+ALTER TABLE identities
+  RENAME CONSTRAINT fk_identities_user TO fk_oauth_accounts_user;
+ALTER TABLE identities
+  RENAME CONSTRAINT uq_identities_user_provider TO uq_oauth_user_provider;
+ALTER TABLE identities
+  RENAME CONSTRAINT uq_identities_provider_subject TO uq_oauth_provider_user;
+ALTER TABLE identities
+  RENAME CONSTRAINT pk_identities TO pk_oauth_accounts;
+ALTER TABLE identities DROP COLUMN IF EXISTS kind,
+  ADD CONSTRAINT chk_oauth_provider CHECK (provider IN ('google', 'github'));
+ALTER TABLE identities
+  RENAME COLUMN subject TO provider_user_id;
+ALTER TABLE identities
+  RENAME TO oauth_accounts;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Правила доступа по IP: deny блокирует сеть, allow освобождает её от
+-- блокировок (в том числе автоматических). Временные правила с expires_at
+-- ставит и детектор злоупотреблений.
+CREATE TABLE IF NOT EXISTS ip_rules (
+  id BIGSERIAL CONSTRAINT pk_ip_rules PRIMARY KEY,
+  cidr CIDR NOT NULL,
+  action TEXT NOT NULL,
+  reason TEXT NOT NULL DEFAULT '',
+  -- кто создал: admin:<login> или system:abuse
+  created_by TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  expires_at TIMESTAMPTZ,
+  CONSTRAINT uq_ip_rules_cidr UNIQUE (cidr),
+  CONSTRAINT chk_ip_rules_action CHECK (action IN ('allow', 'deny'))
+);
+CREATE INDEX IF NOT EXISTS idx_ip_rules_expires_at ON ip_rules (expires_at);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ip_rules;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Место последнего входа пользователя по базе GeoIP — от него считается
+-- скорость перемещения при следующем входе. Хранится одна строка на
+-- пользователя: история входов остаётся в audit_events.
+CREATE TABLE IF NOT EXISTS login_locations (
+  user_id BIGINT PRIMARY KEY,
+  ip INET NOT NULL,
+  country TEXT NOT NULL DEFAULT '',
+  city TEXT NOT NULL DEFAULT '',
+  latitude DOUBLE PRECISION NOT NULL,
+  longitude DOUBLE PRECISION NOT NULL,
+  logged_in_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_login_locations_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS login_locations;
+-- +goose StatementEnd
@@ -0,0 +1,8 @@
+// Package migrations встраивает SQL-миграции в бинарник. Файлы остаются в
+// формате goose: их по-прежнему можно применять и откатывать goose CLI.
+package migrations
+
+import "embed"
+
+//go:embed */*.sql
+var FS embed.FS
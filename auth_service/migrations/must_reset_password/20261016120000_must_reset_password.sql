@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Флаг выставляет администратор: вход по паролю блокируется, пока
+-- пользователь не сменит пароль через /auth/password/reset.
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS must_reset_password BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS must_reset_password;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Приложения — OAuth-клиенты OIDC-провайдера: client_id = apps.id,
+-- client_secret = apps.secret. Код авторизации выдаётся только на
+-- redirect_uri из этого списка, сравнение точное.
+ALTER TABLE apps
+ADD COLUMN IF NOT EXISTS redirect_uris TEXT [] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE apps DROP COLUMN IF EXISTS redirect_uris;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Отклонённое приглашение больше нельзя принять.
+ALTER TABLE org_invitations
+ADD COLUMN IF NOT EXISTS declined_at TIMESTAMPTZ;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE org_invitations DROP COLUMN IF EXISTS declined_at;
+-- +goose StatementEnd
@@ -0,0 +1,58 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Организации — тенанты внутри приложения. Пользователь может состоять в
+-- нескольких организациях; активная организация сессии попадает в claim
+-- org_id access-токена.
+CREATE TABLE IF NOT EXISTS organizations (
+  id BIGSERIAL CONSTRAINT pk_organizations PRIMARY KEY,
+  app_id BIGINT NOT NULL,
+  name TEXT NOT NULL,
+  created_by BIGINT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_organizations_app FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE,
+  CONSTRAINT fk_organizations_created_by FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_organizations_app_id ON organizations (app_id);
+-- Роль участника в организации: owner управляет участниками и ролями,
+-- admin приглашает и исключает member'ов.
+CREATE TABLE IF NOT EXISTS org_members (
+  org_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  role TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  CONSTRAINT pk_org_members PRIMARY KEY (org_id, user_id),
+  CONSTRAINT fk_org_members_org FOREIGN KEY (org_id) REFERENCES organizations(id) ON DELETE CASCADE,
+  CONSTRAINT fk_org_members_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT chk_org_members_role CHECK (role IN ('owner', 'admin', 'member'))
+);
+CREATE INDEX IF NOT EXISTS idx_org_members_user_id ON org_members (user_id);
+-- Приглашения по email. Хранится только хеш токена из письма.
+CREATE TABLE IF NOT EXISTS org_invitations (
+  id BIGSERIAL CONSTRAINT pk_org_invitations PRIMARY KEY,
+  org_id BIGINT NOT NULL,
+  email TEXT NOT NULL,
+  role TEXT NOT NULL,
+  token_hash BYTEA NOT NULL,
+  invited_by BIGINT,
+  expires_at TIMESTAMPTZ NOT NULL,
+  accepted_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  CONSTRAINT uq_org_invitations_token_hash UNIQUE (token_hash),
+  CONSTRAINT fk_org_invitations_org FOREIGN KEY (org_id) REFERENCES organizations(id) ON DELETE CASCADE,
+  CONSTRAINT fk_org_invitations_invited_by FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL,
+  CONSTRAINT chk_org_invitations_role CHECK (role IN ('admin', 'member'))
+);
+-- Организация, выбранная в сессии; NULL — сессия без тенанта.
+ALTER TABLE refresh_tokens
+ADD COLUMN IF NOT EXISTS org_id BIGINT;
+ALTER TABLE refresh_tokens
+ADD CONSTRAINT fk_refresh_tokens_org FOREIGN KEY (org_id) REFERENCES organizations(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens DROP CONSTRAINT IF EXISTS fk_refresh_tokens_org;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS org_id;
+DROP TABLE IF EXISTS org_invitations;
+DROP TABLE IF EXISTS org_members;
+DROP TABLE IF EXISTS organizations;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Тип OAuth-клиента (RFC 6749 §2.1). Конфиденциальный клиент обязан
+-- предъявлять client_secret на /oauth2/token; публичный (SPA, мобильное
+-- приложение) секрета хранить не может и защищён только PKCE. По умолчанию
+-- клиент конфиденциальный: публичным его делают явно.
+ALTER TABLE apps
+ADD COLUMN IF NOT EXISTS public_client BOOLEAN NOT NULL DEFAULT false;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE apps DROP COLUMN IF EXISTS public_client;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Роли приложения и их права. Роли и права попадают в claims access-токена
+-- этого приложения, чтобы resource server'ам не ходить за ними в сервис.
+CREATE TABLE IF NOT EXISTS roles (
+  id BIGSERIAL CONSTRAINT pk_roles PRIMARY KEY,
+  app_id BIGINT NOT NULL,
+  name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  permissions TEXT [] NOT NULL DEFAULT '{}',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  CONSTRAINT uq_roles_app_name UNIQUE (app_id, name),
+  CONSTRAINT fk_roles_app FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS user_roles (
+  user_id BIGINT NOT NULL,
+  role_id BIGINT NOT NULL,
+  -- кто назначил: admin:<login>
+  granted_by TEXT NOT NULL,
+  granted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  CONSTRAINT pk_user_roles PRIMARY KEY (user_id, role_id),
+  CONSTRAINT fk_user_roles_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_user_roles_role FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_user_roles_role_id ON user_roles (role_id);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS roles;
+-- +goose StatementEnd
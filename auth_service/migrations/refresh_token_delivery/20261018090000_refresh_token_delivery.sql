@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Как приложение получает refresh-токен: body — в JSON ответа, cookie —
+-- HttpOnly cookie, недоступной JavaScript (браузерные SPA).
+ALTER TABLE apps
+ADD COLUMN IF NOT EXISTS refresh_token_delivery TEXT NOT NULL DEFAULT 'body' CONSTRAINT chk_apps_refresh_token_delivery CHECK (refresh_token_delivery IN ('body', 'cookie'));
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE apps DROP COLUMN IF EXISTS refresh_token_delivery;
+-- +goose StatementEnd
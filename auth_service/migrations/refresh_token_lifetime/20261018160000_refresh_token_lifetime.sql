@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Абсолютный предел сессии: ротация продлевает expires_at, но не дальше
+-- absolute_expires_at. Уже выданным токенам — 90 дней от выдачи, как
+-- tokens.refresh_max_lifetime по умолчанию.
+ALTER TABLE refresh_tokens
+ADD COLUMN IF NOT EXISTS absolute_expires_at TIMESTAMPTZ;
+
+UPDATE refresh_tokens
+SET absolute_expires_at = GREATEST(expires_at, created_at + INTERVAL '90 days')
+WHERE absolute_expires_at IS NULL;
+
+ALTER TABLE refresh_tokens
+ALTER COLUMN absolute_expires_at SET NOT NULL,
+ADD CONSTRAINT chk_refresh_tokens_absolute_expiration CHECK (expires_at <= absolute_expires_at);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS absolute_expires_at;
+-- +goose StatementEnd
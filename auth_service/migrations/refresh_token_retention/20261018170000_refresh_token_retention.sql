@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Индекс под пакетный DELETE истёкших refresh-токенов (internal/retention).
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at ON refresh_tokens (expires_at);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_refresh_tokens_expires_at;
+-- +goose StatementEnd
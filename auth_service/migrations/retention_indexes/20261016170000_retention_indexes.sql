@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Индексы под пакетные DELETE политик хранения (internal/retention).
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at);
+CREATE INDEX IF NOT EXISTS idx_magic_links_used_at ON magic_links (used_at)
+WHERE used_at IS NOT NULL;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_magic_links_used_at;
+DROP INDEX IF EXISTS idx_audit_events_created_at;
+-- +goose StatementEnd
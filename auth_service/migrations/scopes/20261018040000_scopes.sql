@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Scope'ы, которые приложение разрешает запрашивать при логине. Пустой
+-- список — приложение scope'ы не использует, запрос с ними отклоняется.
+ALTER TABLE apps
+ADD COLUMN IF NOT EXISTS allowed_scopes TEXT [] NOT NULL DEFAULT '{}';
+-- Scope'ы, выданные сессии при логине. Refresh может только сузить их.
+ALTER TABLE refresh_tokens
+ADD COLUMN IF NOT EXISTS scopes TEXT [] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS scopes;
+ALTER TABLE apps DROP COLUMN IF EXISTS allowed_scopes;
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Ротация ключей подписи. У приложения один активный ключ (retired_at IS
+-- NULL) — им подписываются новые токены. Выведенный ключ до expires_at
+-- продолжает приниматься при проверке и публикуется в JWKS, чтобы уже
+-- выданные токены доживали свой TTL. HS256-ключи (секрет в private_key,
+-- public_key пустой) позволяют ротировать подпись HMAC-приложений, не
+-- трогая apps.secret; токены без kid по-прежнему проверяются apps.secret.
+ALTER TABLE signing_keys
+ADD COLUMN IF NOT EXISTS retired_at TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ,
+  DROP CONSTRAINT IF EXISTS chk_signing_keys_alg,
+  ADD CONSTRAINT chk_signing_keys_alg CHECK (alg IN ('HS256', 'RS256', 'ES256'));
+DROP INDEX IF EXISTS uq_signing_keys_app_alg;
+-- раньше у приложения мог остаться ключ каждого алгоритма: активным
+-- оставляем самый новый
+UPDATE signing_keys k
+SET retired_at = NOW(),
+  expires_at = NOW() + INTERVAL '1 day'
+WHERE EXISTS (
+    SELECT 1
+    FROM signing_keys n
+    WHERE n.app_id = k.app_id
+      AND (n.created_at, n.kid) > (k.created_at, k.kid)
+  );
+CREATE UNIQUE INDEX IF NOT EXISTS uq_signing_keys_app_active ON signing_keys (app_id)
+WHERE retired_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_signing_keys_expires_at ON signing_keys (expires_at)
+WHERE expires_at IS NOT NULL;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM signing_keys
+WHERE retired_at IS NOT NULL
+  OR alg = 'HS256';
+DROP INDEX IF EXISTS idx_signing_keys_expires_at;
+DROP INDEX IF EXISTS uq_signing_keys_app_active;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_signing_keys_app_alg ON signing_keys (app_id, alg);
+ALTER TABLE signing_keys DROP CONSTRAINT IF EXISTS chk_signing_keys_alg,
+  ADD CONSTRAINT chk_signing_keys_alg CHECK (alg IN ('RS256', 'ES256')),
+  DROP COLUMN IF EXISTS expires_at,
+  DROP COLUMN IF EXISTS retired_at;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Алгоритм подписи access-токенов приложения. HS256 — общий секрет
+-- apps.secret (как раньше); RS256/ES256 — ключевая пара из signing_keys,
+-- публичная часть отдаётся в /.well-known/jwks.json, и resource server'ам
+-- не нужен секрет приложения. Ключ создаётся при первой выдаче токена.
+ALTER TABLE apps
+ADD COLUMN IF NOT EXISTS signing_alg TEXT NOT NULL DEFAULT 'HS256' CONSTRAINT chk_apps_signing_alg CHECK (signing_alg IN ('HS256', 'RS256', 'ES256'));
+CREATE TABLE IF NOT EXISTS signing_keys (
+  kid TEXT CONSTRAINT pk_signing_keys PRIMARY KEY,
+  app_id BIGINT NOT NULL,
+  alg TEXT NOT NULL CONSTRAINT chk_signing_keys_alg CHECK (alg IN ('RS256', 'ES256')),
+  -- PKCS#8 и PKIX в PEM
+  private_key TEXT NOT NULL,
+  public_key TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_signing_keys_app FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE
+);
+-- Один ключ на приложение и алгоритм: реплики, одновременно создающие
+-- ключ, сходятся на первом записанном.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_signing_keys_app_alg ON signing_keys (app_id, alg);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS signing_keys;
+ALTER TABLE apps DROP COLUMN IF EXISTS signing_alg;
+-- +goose StatementEnd
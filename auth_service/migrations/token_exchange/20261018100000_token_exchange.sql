@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Приложения, на токены которых это приложение может обменивать токены
+-- своих пользователей (/token/exchange, RFC 8693). Пустой список — обмен
+-- запрещён.
+ALTER TABLE apps
+ADD COLUMN IF NOT EXISTS token_exchange_audiences INTEGER [] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE apps DROP COLUMN IF EXISTS token_exchange_audiences;
+-- +goose StatementEnd
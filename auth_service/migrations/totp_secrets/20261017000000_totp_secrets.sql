@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Секрет TOTP хранится зашифрованным (AES-GCM, ключ из конфига) — дамп БД
+-- без ключа не позволяет генерировать коды. confirmed_at выставляется после
+-- первого верного кода; до этого 2FA не включена и enroll можно повторить.
+-- last_used_step — номер последнего принятого 30-секундного шага, защищает
+-- от повторного использования одного и того же кода.
+CREATE TABLE IF NOT EXISTS totp_secrets (
+  user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  secret_enc BYTEA NOT NULL,
+  confirmed_at TIMESTAMPTZ,
+  last_used_step BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS totp_secrets;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Доверенные устройства: после 2FA пользователь может попросить не
+-- спрашивать второй фактор на этом устройстве до expires_at. Токен хранит
+-- клиент, здесь — только его SHA-256.
+CREATE TABLE IF NOT EXISTS trusted_devices (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  token_hash BYTEA NOT NULL UNIQUE,
+  device_id TEXT,
+  device_name TEXT,
+  ip INET,
+  user_agent TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  last_used_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_trusted_devices_user_id ON trusted_devices (user_id);
+CREATE INDEX IF NOT EXISTS idx_trusted_devices_expires_at ON trusted_devices (expires_at);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS trusted_devices;
+-- +goose StatementEnd
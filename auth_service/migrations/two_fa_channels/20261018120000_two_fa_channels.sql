@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Канал доставки magic-link 2FA. Нет строки — ссылка уходит на email
+-- пользователя. destination — номер в E.164 для sms, chat_id для telegram.
+CREATE TABLE IF NOT EXISTS two_fa_channels (
+  user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  channel TEXT NOT NULL CHECK (channel IN ('sms', 'telegram')),
+  destination TEXT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS two_fa_channels;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Настройки безопасности пользователя собираются в одной таблице, пока в
+-- ней только канал доставки magic-link 2FA. Сам флаг 2FA и её способ
+-- остаются в users: их читает каждый логин.
+ALTER TABLE two_fa_channels RENAME TO user_security_settings;
+ALTER TABLE user_security_settings RENAME COLUMN channel TO two_fa_channel;
+ALTER TABLE user_security_settings RENAME COLUMN destination TO two_fa_destination;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_security_settings RENAME COLUMN two_fa_destination TO destination;
+ALTER TABLE user_security_settings RENAME COLUMN two_fa_channel TO channel;
+ALTER TABLE user_security_settings RENAME TO two_fa_channels;
+-- +goose StatementEnd
@@ -0,0 +1,252 @@
+// Package authmw — проверка access-токенов auth_service для сервисов,
+// которые его используют. Токен проверяется через интроспекцию (RFC 7662):
+// так одинаково работают JWT и opaque-токены, и отозванный токен
+// отклоняется сразу, а не после истечения exp.
+//
+//	client := authmw.New(authmw.Config{
+//		IntrospectURL: "https://auth.example.com/token/introspect",
+//		AppID:         1,
+//		AppSecret:     os.Getenv("AUTH_APP_SECRET"),
+//		CacheTTL:      30 * time.Second,
+//	})
+//
+//	r.With(authmw.RequireAuth(client, authmw.Scopes("orders:read"))).Get("/orders", list)
+package authmw
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid or expired access token")
+	ErrUnavailable  = errors.New("auth service unavailable")
+)
+
+const defaultTimeout = 5 * time.Second
+
+// Config — подключение к auth_service. AppID и AppSecret — приложение,
+// которому выданы проверяемые токены: токены других приложений
+// отклоняются.
+type Config struct {
+	IntrospectURL string
+	AppID         int32
+	AppSecret     string
+	// HTTPClient — nil: клиент с таймаутом 5 секунд
+	HTTPClient *http.Client
+	// CacheTTL — сколько помнить результат проверки токена. 0 — без кеша:
+	// отзыв токена виден сразу, но каждый запрос ходит в auth_service.
+	CacheTTL time.Duration
+}
+
+// Claims — данные access-токена.
+type Claims struct {
+	UserID      int64
+	Username    string
+	AppID       int32
+	JTI         string
+	ExpiresAt   time.Time
+	Roles       []string
+	Permissions []string
+	Scopes      []string
+	// OrgID == 0 — токен без организации
+	OrgID   int64
+	OrgRole string
+	// ActorAppID — сервис, получивший токен обменом (claim act); 0 — токен
+	// выдан пользователю напрямую
+	ActorAppID int32
+}
+
+func (c *Claims) HasScope(scope string) bool { return contains(c.Scopes, scope) }
+
+func (c *Claims) HasRole(role string) bool { return contains(c.Roles, role) }
+
+func (c *Claims) HasPermission(permission string) bool { return contains(c.Permissions, permission) }
+
+// Client проверяет токены через /token/introspect.
+type Client struct {
+	cfg  Config
+	http *http.Client
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]cacheEntry
+}
+
+type cacheEntry struct {
+	claims    *Claims
+	expiresAt time.Time
+}
+
+func New(cfg Config) *Client {
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+
+	return &Client{
+		cfg:   cfg,
+		http:  client,
+		cache: make(map[[sha256.Size]byte]cacheEntry),
+	}
+}
+
+// Validate проверяет токен. Ошибка — ErrInvalidToken или ErrUnavailable
+// (auth_service не ответил — считать токен действительным нельзя).
+func (c *Client) Validate(ctx context.Context, token string) (*Claims, error) {
+	const op = "authmw.Validate"
+
+	key := sha256.Sum256([]byte(token))
+
+	if claims, ok := c.cached(key); ok {
+		return claims, nil
+	}
+
+	claims, err := c.introspect(ctx, token)
+	if err != nil {
+		if errors.Is(err, ErrInvalidToken) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrUnavailable, err)
+	}
+
+	c.remember(key, claims)
+
+	return claims, nil
+}
+
+type introspection struct {
+	Active      bool     `json:"active"`
+	Subject     string   `json:"sub"`
+	AppID       int32    `json:"app_id"`
+	Username    string   `json:"username"`
+	Exp         int64    `json:"exp"`
+	JTI         string   `json:"jti"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+	Scope       string   `json:"scope"`
+	OrgID       int64    `json:"org_id"`
+	OrgRole     string   `json:"org_role"`
+	Act         *struct {
+		ClientID string `json:"client_id"`
+	} `json:"act"`
+}
+
+func (c *Client) introspect(ctx context.Context, token string) (*Claims, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.IntrospectURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(strconv.FormatInt(int64(c.cfg.AppID), 10), c.cfg.AppSecret)
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("introspection returned %d", res.StatusCode)
+	}
+
+	var body introspection
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode introspection: %w", err)
+	}
+
+	if !body.Active {
+		return nil, ErrInvalidToken
+	}
+
+	userID, err := strconv.ParseInt(body.Subject, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse sub: %w", err)
+	}
+
+	var actor int64
+	if body.Act != nil {
+		actor, _ = strconv.ParseInt(body.Act.ClientID, 10, 32)
+	}
+
+	return &Claims{
+		UserID:      userID,
+		Username:    body.Username,
+		AppID:       body.AppID,
+		JTI:         body.JTI,
+		ExpiresAt:   time.Unix(body.Exp, 0),
+		Roles:       body.Roles,
+		Permissions: body.Permissions,
+		Scopes:      strings.Fields(body.Scope),
+		OrgID:       body.OrgID,
+		OrgRole:     body.OrgRole,
+		ActorAppID:  int32(actor),
+	}, nil
+}
+
+func (c *Client) cached(key [sha256.Size]byte) (*Claims, bool) {
+	if c.cfg.CacheTTL <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.cache[key]
+	if !ok {
+		return nil, false
+	}
+
+	if time.Now().After(entry.expiresAt) {
+		delete(c.cache, key)
+		return nil, false
+	}
+
+	return entry.claims, true
+}
+
+// remember кладёт результат в кеш не дольше, чем живёт сам токен.
+// Неактивные токены не кешируются: их проверка дешёвая и редкая.
+func (c *Client) remember(key [sha256.Size]byte, claims *Claims) {
+	if c.cfg.CacheTTL <= 0 {
+		return
+	}
+
+	expiresAt := time.Now().Add(c.cfg.CacheTTL)
+	if claims.ExpiresAt.Before(expiresAt) {
+		expiresAt = claims.ExpiresAt
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// истёкшие записи вычищаются при записи, чтобы кеш не рос бесконечно
+	now := time.Now()
+	for k, entry := range c.cache {
+		if now.After(entry.expiresAt) {
+			delete(c.cache, k)
+		}
+	}
+
+	c.cache[key] = cacheEntry{claims: claims, expiresAt: expiresAt}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package authmw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+var claimsContextKey = contextKey{}
+
+// Validator проверяет access-токен. Реализован Client; в тестах сервиса
+// его можно подменить.
+type Validator interface {
+	Validate(ctx context.Context, token string) (*Claims, error)
+}
+
+// Requirement — условие доступа поверх валидного токена.
+type Requirement func(claims *Claims) bool
+
+// Scopes требует все перечисленные scope'ы.
+func Scopes(scopes ...string) Requirement {
+	return func(claims *Claims) bool {
+		for _, s := range scopes {
+			if !claims.HasScope(s) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Roles требует хотя бы одну из перечисленных ролей.
+func Roles(roles ...string) Requirement {
+	return func(claims *Claims) bool {
+		for _, role := range roles {
+			if claims.HasRole(role) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Permissions требует все перечисленные права.
+func Permissions(permissions ...string) Requirement {
+	return func(claims *Claims) bool {
+		for _, p := range permissions {
+			if !claims.HasPermission(p) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// RequireAuth — middleware для chi (и любого net/http роутера): проверяет
+// Bearer-токен, кладёт claims в контекст и проверяет requirements. Нет или
+// невалиден токен — 401, не выполнено требование — 403, auth_service
+// недоступен — 503.
+func RequireAuth(v Validator, requirements ...Requirement) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const prefix = "Bearer "
+
+			header := r.Header.Get("Authorization")
+			if !strings.HasPrefix(header, prefix) {
+				unauthorized(w)
+				return
+			}
+
+			claims, err := v.Validate(r.Context(), strings.TrimPrefix(header, prefix))
+			if err != nil {
+				if errors.Is(err, ErrInvalidToken) {
+					unauthorized(w)
+					return
+				}
+
+				writeError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "service temporarily unavailable")
+				return
+			}
+
+			for _, ok := range requirements {
+				if !ok(claims) {
+					writeError(w, http.StatusForbidden, "AUTH_INSUFFICIENT_PERMISSIONS", "insufficient permissions")
+					return
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), claimsContextKey, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ClaimsFromContext возвращает claims, положенные RequireAuth.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey).(*Claims)
+	return claims, ok
+}
+
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer`)
+	writeError(w, http.StatusUnauthorized, "AUTH_INVALID_ACCESS_TOKEN", "invalid or expired access token")
+}
+
+// writeError отвечает в формате ошибок auth_service.
+func writeError(w http.ResponseWriter, status int, code, msg string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"status": "Error",
+		"code":   code,
+		"error":  msg,
+	})
+}
@@ -0,0 +1,70 @@
+// Package contract — формат сообщений, которыми обмениваются auth_service
+// (publisher) и email_sender (consumer) через RabbitMQ. Обе стороны
+// подключают этот модуль, поэтому поля не расходятся между сервисами.
+//
+// Совместимость: новые поля добавляются только опциональными, тогда
+// SchemaVersion не меняется и старый consumer их просто не видит.
+// Несовместимое изменение поднимает SchemaVersion; consumer отклоняет
+// сообщения новее, чем умеет читать, а не отправляет письмо наугад.
+package contract
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SchemaVersion — версия формата EmailMessage, которую пишет Encode.
+// Сообщения без поля v опубликованы до появления контракта (версия 0) и
+// читаются так же, как версия 1.
+const SchemaVersion = 1
+
+var ErrUnsupportedVersion = errors.New("contract: unsupported message schema version")
+
+type EmailMessage struct {
+	// Version — версия схемы; проставляет Encode.
+	Version int `json:"v,omitempty"`
+	// ID — идентификатор письма: по нему consumer отбрасывает повторные
+	// доставки одного сообщения. Пусто — проверки нет.
+	ID string `json:"id,omitempty"`
+	// Email — адрес получателя.
+	Email string `json:"to"`
+	// Link — путь ссылки в письме (токен подтверждения, сброса и т.п.).
+	Link    string `json:"link"`
+	Purpose string `json:"purpose"`
+	// Data — параметры для шаблона письма (устройство, IP и т.п.);
+	// шаблон обращается к ним как {{.Data.<ключ>}}.
+	Data map[string]string `json:"data,omitempty"`
+	// Locale — язык письма ("en", "pt-BR"); пусто — язык по умолчанию
+	// email_sender.
+	Locale string `json:"locale,omitempty"`
+}
+
+// Encode сериализует письмо с текущей SchemaVersion.
+func Encode(msg EmailMessage) ([]byte, error) {
+	msg.Version = SchemaVersion
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("contract: encode: %w", err)
+	}
+
+	return body, nil
+}
+
+// DecodeEmail читает письмо любой поддерживаемой версии. Неизвестные поля
+// игнорируются — их мог добавить более новый publisher той же версии.
+func DecodeEmail(body []byte) (EmailMessage, error) {
+	var msg EmailMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return EmailMessage{}, fmt.Errorf("contract: decode: %w", err)
+	}
+
+	if msg.Version < 0 || msg.Version > SchemaVersion {
+		return EmailMessage{}, fmt.Errorf("%w: %d", ErrUnsupportedVersion, msg.Version)
+	}
+
+	msg.Version = SchemaVersion
+
+	return msg, nil
+}
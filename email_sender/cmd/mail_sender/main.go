@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -12,16 +12,27 @@ import (
 	"time"
 
 	"email_sender/internal/config"
+	dlqList "email_sender/internal/http_server/handlers/admin/dlq/list"
+	dlqRedrive "email_sender/internal/http_server/handlers/admin/dlq/redrive"
 	"email_sender/internal/http_server/handlers/infrastructure/health"
+	"email_sender/internal/http_server/handlers/infrastructure/healthz"
 	metricsHandler "email_sender/internal/http_server/handlers/infrastructure/metrics"
+	adminAuth "email_sender/internal/http_server/handlers/middleware/admin_auth"
+	metricsCollector "email_sender/internal/http_server/handlers/middleware/metrics_collector"
 	sl "email_sender/internal/lib/logger"
 	mailer "email_sender/internal/mail-sender"
 	"email_sender/internal/metrics"
-	"email_sender/internal/models"
 	"email_sender/internal/rabbitmq"
+	"email_sender/internal/sendlimit"
+	"email_sender/internal/throttle"
 
+	"github.com/XdMishaXd/auth_service/contract"
+	"github.com/XdMishaXd/auth_service/secrets"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -37,9 +48,15 @@ func main() {
 
 	log.Info("starting email_sender", slog.String("env", cfg.Env))
 
+	// * W3C trace context: traceparent приходит в заголовках AMQP от auth_service
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
 	m := metrics.New()
 
-	rabbitMQClient, err := rabbitmq.New(cfg.RabbitMQ.URL, m)
+	rabbitMQClient, err := rabbitmq.New(cfg.RabbitMQ, m)
 	if err != nil {
 		log.Error("failed to connect rabbitmq", slog.String("err", err.Error()))
 		os.Exit(1)
@@ -47,14 +64,47 @@ func main() {
 
 	log.Info("rabbitmq connected successfully")
 
-	mailSender := &mailer.Mailer{
-		Host:     cfg.Email.Host,
-		Port:     cfg.Email.Port,
-		Username: cfg.Email.Username,
-		Password: cfg.Email.Password,
+	mailSender, err := mailer.New(cfg.Email, log, m)
+	if err != nil {
+		log.Error("failed to configure mailer", slog.String("err", err.Error()))
+		os.Exit(1)
+	}
+
+	throttler, err := throttle.New(
+		throttlePolicy(cfg.Throttle.Default),
+		throttleDomains(cfg.Throttle.Domains),
+		cfg.Throttle.MaxWait,
+	)
+	if err != nil {
+		log.Error("failed to configure throttle", slog.String("err", err.Error()))
+		os.Exit(1)
+	}
+
+	// * лимиты на получателя, дедупликация и учёт отправленных ID — только
+	// при настроенном Redis
+	var limiter *sendlimit.Limiter
+	if cfg.Redis.Addr != "" {
+		limiter, err = sendlimit.New(
+			context.Background(),
+			cfg.Redis.Addr,
+			cfg.Redis.Password,
+			cfg.Redis.Db,
+			recipientLimits(cfg.RecipientLimits.Purposes),
+			cfg.RecipientLimits.DedupWindow,
+			cfg.RecipientLimits.ProcessedTTL,
+		)
+		if err != nil {
+			log.Error("failed to configure recipient limits", slog.String("err", err.Error()))
+			os.Exit(1)
+		}
+
+		log.Info("redis connected successfully")
 	}
 
-	router := setupRouter(m)
+	router := setupRouter(log, cfg, m, rabbitMQClient, map[string]healthz.Checker{
+		"amqp": rabbitMQClient,
+		"smtp": mailSender,
+	})
 
 	srv := &http.Server{
 		Addr:         cfg.HTTPServer.Address,
@@ -75,12 +125,49 @@ func main() {
 		serverErrors <- srv.ListenAndServe()
 	}()
 
+	go func() {
+		if err := mailSender.WatchTemplates(consumerCtx); err != nil {
+			log.Error("templates hot reload stopped", sl.Err(err))
+		}
+	}()
+
+	// * ротация секретов во внешнем хранилище: SMTP-логин и пароль
+	// применяются на лету, остальное — рестартом
+	secretsProvider, err := secrets.New(cfg.Secrets)
+	if err != nil {
+		log.Error("failed to init secrets provider", slog.String("err", err.Error()))
+		os.Exit(1)
+	}
+	if secretsProvider != nil {
+		go secrets.Watch(
+			consumerCtx,
+			log,
+			secretsProvider,
+			cfg.Secrets.RefreshInterval,
+			cfg.Secrets.Timeout,
+			func(changed map[string]string) { applyRotatedSecrets(log, mailSender, changed) },
+		)
+	}
+
+	consumers, err := setupConsumers(cfg.RabbitMQ, map[string]rabbitmq.Handler{
+		"email": func(ctx context.Context, msg []byte) error {
+			return handleMessage(ctx, log, m, mailSender, throttler, limiter, msg)
+		},
+	})
+	if err != nil {
+		log.Error("failed to configure consumers", slog.String("err", err.Error()))
+		os.Exit(1)
+	}
+
 	consumerErrors := make(chan error, 1)
 	go func() {
-		log.Info("starting rabbitmq consumer", slog.String("queue", cfg.RabbitMQ.QueueName))
-		consumerErrors <- rabbitMQClient.StartReading(consumerCtx, cfg.RabbitMQ.QueueName, func(msg []byte) error {
-			return handleMessage(log, mailSender, cfg, msg)
-		})
+		for _, c := range consumers {
+			log.Info("starting rabbitmq consumer",
+				slog.String("queue", c.Queue),
+				slog.Int("concurrency", c.Concurrency),
+			)
+		}
+		consumerErrors <- rabbitMQClient.StartReading(consumerCtx, consumers)
 	}()
 
 	// * graceful shutdown
@@ -109,8 +196,24 @@ func main() {
 	case sig := <-shutdown:
 		log.Info("shutdown signal received", slog.String("signal", sig.String()))
 
+		// * сначала перестаём брать новые сообщения и ждём, пока consumer
+		// допишет текущее письмо и ack/nack'нет его — только потом можно
+		// закрывать канал, иначе ack-статус сообщения не определён
 		consumerCancel()
 
+		select {
+		case err := <-consumerErrors:
+			if err != nil {
+				log.Error("consumer stopped with error", slog.String("error", err.Error()))
+			} else {
+				log.Info("consumer drained")
+			}
+		case <-time.After(cfg.RabbitMQ.DrainTimeout):
+			log.Warn("consumer drain timed out, in-flight message will be redelivered",
+				slog.Duration("timeout", cfg.RabbitMQ.DrainTimeout),
+			)
+		}
+
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer shutdownCancel()
 
@@ -136,6 +239,22 @@ func main() {
 			return nil
 		})
 
+		eg.Go(func() error {
+			if err := mailSender.Close(closeCtx); err != nil {
+				return fmt.Errorf("smtp close: %w", err)
+			}
+			return nil
+		})
+
+		if limiter != nil {
+			eg.Go(func() error {
+				if err := limiter.Close(); err != nil {
+					return fmt.Errorf("redis close: %w", err)
+				}
+				return nil
+			})
+		}
+
 		if err := eg.Wait(); err != nil {
 			log.Error("failed to close resources gracefully", slog.String("err", err.Error()))
 		}
@@ -144,37 +263,165 @@ func main() {
 	}
 }
 
-func setupRouter(m *metrics.Metrics) *chi.Mux {
+func setupRouter(
+	log *slog.Logger,
+	cfg *config.Config,
+	m *metrics.Metrics,
+	rabbitMQClient *rabbitmq.RabbitMQClient,
+	checks map[string]healthz.Checker,
+) *chi.Mux {
 	r := chi.NewRouter()
+	r.Use(metricsCollector.New(m))
 	r.Use(middleware.Recoverer)
 
 	r.Get("/health", health.New())
+	r.Get("/healthz", healthz.New(log, checks, cfg.HTTPServer.HealthCheckTimeout))
 	r.Get("/metrics", metricsHandler.New(m))
 
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(adminAuth.New(cfg.Admin.Username, cfg.Admin.Password))
+
+		r.Get("/dlq/{queue}", dlqList.New(log, rabbitMQClient))
+		r.Post("/dlq/{queue}/redrive", dlqRedrive.New(log, rabbitMQClient, cfg.Admin.RedriveTimeout))
+	})
+
 	return r
 }
 
-func handleMessage(log *slog.Logger, mailSender *mailer.Mailer, cfg *config.Config, msg []byte) error {
-	var emailMsg models.EmailMessage
-	if err := json.Unmarshal(msg, &emailMsg); err != nil {
-		log.Error("failed to unmarshal message", sl.Err(err))
-		return fmt.Errorf("unmarshal: %w", err)
+func handleMessage(
+	ctx context.Context,
+	log *slog.Logger,
+	m *metrics.Metrics,
+	mailSender *mailer.Mailer,
+	throttler *throttle.Throttler,
+	limiter *sendlimit.Limiter,
+	msg []byte,
+) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		log = log.With(slog.String("trace_id", sc.TraceID().String()))
+	}
+
+	// сообщение новее, чем умеет читать этот email_sender, тоже уходит в
+	// parking: после обновления его можно вернуть через /admin/dlq
+	emailMsg, err := contract.DecodeEmail(msg)
+	if err != nil {
+		log.Error("failed to decode message", sl.Err(err))
+		return fmt.Errorf("decode: %w: %w", err, rabbitmq.ErrPermanent)
+	}
+
+	if err := throttler.Wait(ctx, emailMsg.Email); err != nil {
+		log.Warn("recipient domain throttled, requeueing", sl.Err(err))
+		return fmt.Errorf("throttle: %w: %w", err, rabbitmq.ErrRequeue)
+	}
+
+	// * превышение лимита и дубликат — не ошибка обработки: сообщение
+	// ack'ается без отправки, повтор дал бы тот же результат
+	acquired := false
+	if limiter != nil {
+		err := limiter.Acquire(ctx, emailMsg)
+		switch {
+		case err == nil:
+			acquired = true
+		case errors.Is(err, sendlimit.ErrAlreadyProcessed):
+			m.EmailsSuppressedTotal.WithLabelValues(emailMsg.Purpose, "already_processed").Inc()
+			log.Info("message already processed, skipping", slog.String("id", emailMsg.ID))
+			return nil
+		case errors.Is(err, sendlimit.ErrDuplicate):
+			m.EmailsSuppressedTotal.WithLabelValues(emailMsg.Purpose, "duplicate").Inc()
+			log.Info("duplicate message dropped", slog.String("purpose", emailMsg.Purpose))
+			return nil
+		case errors.Is(err, sendlimit.ErrLimitExceeded):
+			m.EmailsSuppressedTotal.WithLabelValues(emailMsg.Purpose, "recipient_limit").Inc()
+			log.Warn("recipient limit exceeded, message dropped", slog.String("purpose", emailMsg.Purpose), sl.Err(err))
+			return nil
+		default:
+			// Redis недоступен — письмо с кодом важнее лимита, отправляем без проверки
+			log.Error("failed to check recipient limits, sending anyway", sl.Err(err))
+		}
 	}
 
 	if err := mailSender.Send(
 		emailMsg.Email,
-		cfg.Email.Username,
-		"http://localhost"+emailMsg.MessageText,
+		mailSender.From,
+		emailMsg.Link,
 		emailMsg.Purpose,
+		emailMsg.Locale,
+		emailMsg.Data,
 	); err != nil {
 		log.Error("failed to send message", sl.Err(err))
+
+		if acquired {
+			if relErr := limiter.Release(context.WithoutCancel(ctx), emailMsg); relErr != nil {
+				log.Error("failed to release recipient limit", sl.Err(relErr))
+			}
+		}
+
+		if errors.Is(err, mailer.ErrUnknownPurpose) {
+			return fmt.Errorf("send: %w: %w", err, rabbitmq.ErrPermanent)
+		}
 		return fmt.Errorf("send: %w", err)
 	}
 
+	if acquired {
+		// письмо ушло — ошибка отметки грозит лишь повтором при redelivery
+		if err := limiter.Done(context.WithoutCancel(ctx), emailMsg); err != nil {
+			log.Error("failed to mark message processed", sl.Err(err))
+		}
+	}
+
 	log.Info("message sent successfully")
 	return nil
 }
 
+// setupConsumers сопоставляет очереди из конфига с handler'ами по имени.
+func setupConsumers(cfg config.RabbitMQ, handlers map[string]rabbitmq.Handler) ([]rabbitmq.Consumer, error) {
+	specs := cfg.Consumers
+	if len(specs) == 0 {
+		specs = []config.RabbitMQConsumer{{Queue: cfg.QueueName, Handler: "email"}}
+	}
+
+	consumers := make([]rabbitmq.Consumer, 0, len(specs))
+	for _, spec := range specs {
+		if spec.Queue == "" {
+			return nil, errors.New("consumer queue is required")
+		}
+
+		h, ok := handlers[spec.Handler]
+		if !ok {
+			return nil, fmt.Errorf("unknown handler %q for queue %q", spec.Handler, spec.Queue)
+		}
+
+		consumers = append(consumers, rabbitmq.Consumer{
+			Queue:       spec.Queue,
+			Concurrency: max(spec.Concurrency, 1),
+			Prefetch:    spec.Prefetch,
+			Handler:     h,
+		})
+	}
+
+	return consumers, nil
+}
+
+func throttlePolicy(p config.ThrottlePolicy) throttle.Policy {
+	return throttle.Policy{Rate: p.Rate, Burst: p.Burst, Period: p.Period}
+}
+
+func throttleDomains(domains map[string]config.ThrottlePolicy) map[string]throttle.Policy {
+	res := make(map[string]throttle.Policy, len(domains))
+	for domain, p := range domains {
+		res[domain] = throttlePolicy(p)
+	}
+	return res
+}
+
+func recipientLimits(purposes map[string]config.RecipientLimit) map[string]sendlimit.Limit {
+	res := make(map[string]sendlimit.Limit, len(purposes))
+	for purpose, l := range purposes {
+		res[purpose] = sendlimit.Limit{Max: l.Limit, Period: l.Period}
+	}
+	return res
+}
+
 func setupLogger(env string) *slog.Logger {
 	var log *slog.Logger
 
@@ -195,3 +442,32 @@ func setupLogger(env string) *slog.Logger {
 
 	return log
 }
+
+// applyRotatedSecrets пересобирает SMTP-транспорт при смене логина или
+// пароля; остальные секреты (RabbitMQ, Redis, admin) применяются рестартом.
+func applyRotatedSecrets(log *slog.Logger, mailSender *mailer.Mailer, changed map[string]string) {
+	username, password := mailSender.Credentials()
+	smtpChanged := false
+
+	for name, value := range changed {
+		switch name {
+		case "EMAIL_USERNAME":
+			username, smtpChanged = value, true
+		case "EMAIL_PASSWORD":
+			password, smtpChanged = value, true
+		default:
+			log.Warn("secret changed, restart required to apply", slog.String("name", name))
+		}
+	}
+
+	if !smtpChanged {
+		return
+	}
+
+	if err := mailSender.SetCredentials(username, password); err != nil {
+		log.Error("failed to apply rotated smtp credentials", sl.Err(err))
+		return
+	}
+
+	log.Info("smtp credentials rotated")
+}
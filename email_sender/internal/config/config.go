@@ -5,6 +5,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/XdMishaXd/auth_service/secrets"
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
@@ -13,24 +14,222 @@ type Config struct {
 	RabbitMQ   `yaml:"rabbitmq"`
 	Email      `yaml:"email"`
 	HTTPServer `yaml:"http_server"`
+	Throttle   `yaml:"throttle"`
+	Admin      `yaml:"admin"`
+
+	Redis           `yaml:"redis"`
+	RecipientLimits `yaml:"recipient_limits"`
+	// Secrets — внешнее хранилище секретов. Секрет — набор пар «имя
+	// env-переменной конфига → значение» (EMAIL_PASSWORD,
+	// RABBITMQ_PASSWORD, ...): при старте они подставляются вместо незаданных
+	// переменных окружения, затем каждые RefreshInterval перечитываются, и
+	// ротация применяется без рестарта там, где это возможно (логин и пароль
+	// SMTP).
+	Secrets secrets.Config `yaml:"secrets"`
+}
+
+// Redis — хранилище счётчиков RecipientLimits. Addr пуст — лимиты на
+// получателя и дедупликация выключены.
+type Redis struct {
+	Addr     string `yaml:"addr" env:"REDIS_ADDR"`
+	Password string `yaml:"-" env:"REDIS_PASSWORD"`
+	Db       int    `yaml:"db" env:"REDIS_DB" env-default:"2"`
+}
+
+// RecipientLimits — защита репутации отправителя от абьюза форм: не больше
+// Limit писем одного purpose на адрес за Period и не больше одного
+// одинакового письма за DedupWindow. Общие для всех реплик (Redis).
+type RecipientLimits struct {
+	DedupWindow time.Duration             `yaml:"dedup_window" env:"RECIPIENT_LIMITS_DEDUP_WINDOW" env-default:"10m"`
+	Purposes    map[string]RecipientLimit `yaml:"purposes"`
+
+	// ProcessedTTL — сколько помним ID отправленных писем, чтобы повторная
+	// доставка того же сообщения брокером не дала второе письмо.
+	ProcessedTTL time.Duration `yaml:"processed_ttl" env:"RECIPIENT_LIMITS_PROCESSED_TTL" env-default:"24h"`
+}
+
+type RecipientLimit struct {
+	Limit  int           `yaml:"limit"`
+	Period time.Duration `yaml:"period"`
+}
+
+// Admin — basic auth для /admin/* (просмотр и re-drive DLQ). Без
+// credentials эндпоинты отвечают 404.
+type Admin struct {
+	Username       string        `yaml:"-" env:"ADMIN_USERNAME"`
+	Password       string        `yaml:"-" env:"ADMIN_PASSWORD"`
+	RedriveTimeout time.Duration `yaml:"redrive_timeout" env:"ADMIN_REDRIVE_TIMEOUT" env-default:"30s"`
+}
+
+// Throttle — лимиты отправки по домену получателя, чтобы не упираться в
+// throttling gmail.com/mail.ru и т.п. во время всплесков регистраций.
+type Throttle struct {
+	MaxWait time.Duration             `yaml:"max_wait" env:"THROTTLE_MAX_WAIT" env-default:"10s"`
+	Default ThrottlePolicy            `yaml:"default"`
+	Domains map[string]ThrottlePolicy `yaml:"domains"`
+}
+
+type ThrottlePolicy struct {
+	Rate   int           `yaml:"rate" env:"THROTTLE_DEFAULT_RATE"`
+	Burst  int           `yaml:"burst" env:"THROTTLE_DEFAULT_BURST"`
+	Period time.Duration `yaml:"period" env:"THROTTLE_DEFAULT_PERIOD"`
 }
 
 type RabbitMQ struct {
 	URL       string `yaml:"-" env:"RABBITMQ_URL" env-required:"true"`
-	QueueName string `yaml:"queue_name" env-default:"notificationsQueue"`
+	QueueName string `yaml:"queue_name" env:"RABBITMQ_QUEUE_NAME" env-default:"notificationsQueue"`
+
+	Username       string           `yaml:"-" env:"RABBITMQ_USERNAME"`
+	Password       string           `yaml:"-" env:"RABBITMQ_PASSWORD"`
+	ConnectionName string           `yaml:"connection_name" env:"RABBITMQ_CONNECTION_NAME" env-default:"email_sender"`
+	Heartbeat      time.Duration    `yaml:"heartbeat" env:"RABBITMQ_HEARTBEAT" env-default:"10s"`
+	TLS            RabbitMQTLS      `yaml:"tls"`
+	Queue          RabbitMQQueue    `yaml:"queue"`
+	Topology       RabbitMQTopology `yaml:"topology"`
+
+	// DrainTimeout — сколько при остановке ждём письма, которые уже
+	// отправляются, прежде чем закрыть канал.
+	DrainTimeout time.Duration `yaml:"drain_timeout" env:"RABBITMQ_DRAIN_TIMEOUT" env-default:"20s"`
+
+	// Prefetch ограничивает число неподтверждённых сообщений у consumer'а —
+	// пока он ждёт throttle, брокер не досылает новые.
+	Prefetch int `yaml:"prefetch" env:"RABBITMQ_PREFETCH" env-default:"10"`
+
+	// Consumers — очереди, которые читает сервис. Пусто — одна очередь
+	// QueueName с handler'ом email.
+	Consumers []RabbitMQConsumer `yaml:"consumers"`
+
+	// MaxAttempts — сколько раз пробуем обработать сообщение, прежде чем
+	// отправить его в ParkingQueue с причиной в заголовке x-error.
+	MaxAttempts  int    `yaml:"max_attempts" env:"RABBITMQ_MAX_ATTEMPTS" env-default:"5"`
+	ParkingQueue string `yaml:"parking_queue" env:"RABBITMQ_PARKING_QUEUE" env-default:"email.parking"`
+
+	// RetryDelays — задержка перед повтором: попытка N ждёт RetryDelays[N-1],
+	// дальше — последнюю задержку. Пусто — повтор сразу, в хвост очереди.
+	RetryDelays []time.Duration `yaml:"retry_delays" env:"RABBITMQ_RETRY_DELAYS" env-default:"10s,1m,5m"`
+}
+
+type RabbitMQConsumer struct {
+	Queue       string `yaml:"queue"`
+	Handler     string `yaml:"handler"`     // имя handler'а из cmd/mail_sender
+	Concurrency int    `yaml:"concurrency"` // 0 — 1 воркер
+	Prefetch    int    `yaml:"prefetch"`    // 0 — rabbitmq.prefetch
+}
+
+// RabbitMQTopology — дополнительные exchange'и, очереди и биндинги, которые
+// сервис идемпотентно объявляет при старте. Секция одинакова в auth_service
+// и email_sender: кто бы ни стартовал первым, топология будет на месте.
+type RabbitMQTopology struct {
+	Exchanges []RabbitMQExchange      `yaml:"exchanges"`
+	Queues    []RabbitMQTopologyQueue `yaml:"queues"`
+	Bindings  []RabbitMQBinding       `yaml:"bindings"`
+}
+
+type RabbitMQExchange struct {
+	Name       string         `yaml:"name"`
+	Kind       string         `yaml:"kind"` // direct | fanout | topic | headers
+	Durable    bool           `yaml:"durable"`
+	AutoDelete bool           `yaml:"auto_delete"`
+	Arguments  map[string]any `yaml:"arguments"`
+}
+
+type RabbitMQTopologyQueue struct {
+	Name       string         `yaml:"name"`
+	Durable    bool           `yaml:"durable"`
+	AutoDelete bool           `yaml:"auto_delete"`
+	Arguments  map[string]any `yaml:"arguments"`
+}
+
+type RabbitMQBinding struct {
+	Queue      string         `yaml:"queue"`
+	Exchange   string         `yaml:"exchange"`
+	RoutingKey string         `yaml:"routing_key"`
+	Arguments  map[string]any `yaml:"arguments"`
+}
+
+// RabbitMQQueue — аргументы объявления основной очереди и её DLQ.
+type RabbitMQQueue struct {
+	Type                 string        `yaml:"type" env:"RABBITMQ_QUEUE_TYPE" env-default:"classic"` // classic | quorum | lazy
+	MessageTTL           time.Duration `yaml:"message_ttl" env:"RABBITMQ_QUEUE_MESSAGE_TTL"`
+	MaxLength            int           `yaml:"max_length" env:"RABBITMQ_QUEUE_MAX_LENGTH"`
+	Overflow             string        `yaml:"overflow" env:"RABBITMQ_QUEUE_OVERFLOW"` // drop-head | reject-publish | reject-publish-dlx
+	DeadLetterExchange   string        `yaml:"dead_letter_exchange" env:"RABBITMQ_QUEUE_DEAD_LETTER_EXCHANGE" env-default:"email.dlx"`
+	DeadLetterRoutingKey string        `yaml:"dead_letter_routing_key" env:"RABBITMQ_QUEUE_DEAD_LETTER_ROUTING_KEY"`
+	DeadLetterQueue      string        `yaml:"dead_letter_queue" env:"RABBITMQ_QUEUE_DEAD_LETTER_QUEUE" env-default:"email.verification.dlq"`
+}
+
+// RabbitMQTLS применяется только для amqps:// URL.
+type RabbitMQTLS struct {
+	CAFile     string `yaml:"ca_file" env:"RABBITMQ_TLS_CA_FILE"`
+	CertFile   string `yaml:"cert_file" env:"RABBITMQ_TLS_CERT_FILE"`
+	KeyFile    string `yaml:"key_file" env:"RABBITMQ_TLS_KEY_FILE"`
+	ServerName string `yaml:"server_name" env:"RABBITMQ_TLS_SERVER_NAME"`
 }
 
 type HTTPServer struct {
-	Address     string        `yaml:"address" env-default:"localhost:8080"`
-	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
-	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	Address     string        `yaml:"address" env:"HTTP_SERVER_ADDRESS" env-default:"localhost:8080"`
+	Timeout     time.Duration `yaml:"timeout" env:"HTTP_SERVER_TIMEOUT" env-default:"4s"`
+	IdleTimeout time.Duration `yaml:"idle_timeout" env:"HTTP_SERVER_IDLE_TIMEOUT" env-default:"60s"`
+
+	HealthCheckTimeout time.Duration `yaml:"health_check_timeout" env:"HTTP_SERVER_HEALTH_CHECK_TIMEOUT" env-default:"3s"`
 }
 
 type Email struct {
-	Host     string `yaml:"host" env-default:"smtp.gmail.com"`
-	Port     int    `yaml:"port" env-default:"587"`
-	Username string `yaml:"-" env:"EMAIL_USERNAME" env-required:"true"`
-	Password string `yaml:"-" env:"EMAIL_PASSWORD" env-required:"true"`
+	Host     string `yaml:"host" env:"EMAIL_HOST" env-default:"smtp.gmail.com"`
+	Port     int    `yaml:"port" env:"EMAIL_PORT" env-default:"587"`
+	Username string `yaml:"-" env:"EMAIL_USERNAME"` // обязателен для delivery: smtp
+	Password string `yaml:"-" env:"EMAIL_PASSWORD"`
+	From     string `yaml:"from" env:"EMAIL_FROM"` // по умолчанию Username
+
+	// Delivery: smtp — реальный SMTP; log — письмо в лог; file — .eml в
+	// SandboxDir; mailhog — SMTP без auth/TLS на MailHogAddr. Всё, кроме
+	// smtp, — sandbox для локального стенда, в prod запрещено.
+	Delivery    string `yaml:"delivery" env:"EMAIL_DELIVERY" env-default:"smtp"`
+	SandboxDir  string `yaml:"sandbox_dir" env:"EMAIL_SANDBOX_DIR" env-default:"./tmp/emails"`
+	MailHogAddr string `yaml:"mailhog_addr" env:"EMAIL_MAILHOG_ADDR" env-default:"localhost:1025"`
+
+	// TemplatesDir — каталог с purposes.yaml и *.tmpl (та же структура,
+	// что у вшитых шаблонов). Изменения подхватываются без рестарта.
+	// Пусто — используются шаблоны из бинарника.
+	TemplatesDir string `yaml:"templates_dir" env:"EMAIL_TEMPLATES_DIR"`
+
+	// DefaultLocale — язык шаблонов в корне каталога; переводы лежат в
+	// подкаталогах <locale>/. Письмо без locale или на языке без перевода
+	// уходит на нём.
+	DefaultLocale string `yaml:"default_locale" env:"EMAIL_DEFAULT_LOCALE" env-default:"ru"`
+
+	// AuthMechanism: auto | plain | login | cram-md5 | none. auto — выбор
+	// gomail по EHLO; LOGIN нужен для Office 365 и части корпоративных
+	// релеев, которые не принимают PLAIN.
+	AuthMechanism string    `yaml:"auth_mechanism" env:"EMAIL_AUTH_MECHANISM" env-default:"auto"`
+	TLS           EmailTLS  `yaml:"tls"`
+	Pool          EmailPool `yaml:"pool"`
+}
+
+// EmailPool — SMTP-сессии, которые воркеры consumer'ов переиспользуют между
+// письмами (smtp и mailhog).
+type EmailPool struct {
+	// Size — сколько простаивающих сессий держим открытыми; разумно — по
+	// одной на воркер, т.е. суммарная concurrency consumer'ов. 0 — новое
+	// соединение на каждое письмо.
+	Size int `yaml:"size" env:"EMAIL_POOL_SIZE" env-default:"0"`
+	// IdleTimeout — сессию, простоявшую дольше, не переиспользуем: серверы
+	// сами закрывают неактивные сессии, обычно через 1–5 минут.
+	IdleTimeout time.Duration `yaml:"idle_timeout" env:"EMAIL_POOL_IDLE_TIMEOUT" env-default:"30s"`
+	// MaxMessages — писем на сессию, после чего она закрывается (лимиты
+	// провайдеров на письма за соединение). 0 — без лимита.
+	MaxMessages int `yaml:"max_messages" env:"EMAIL_POOL_MAX_MESSAGES" env-default:"100"`
+}
+
+type EmailTLS struct {
+	// Mode: auto — implicit TLS на 465, иначе STARTTLS, если сервер его
+	// объявляет; implicit — TLS сразу после connect; starttls — STARTTLS
+	// обязателен; none — без шифрования (только локальный relay/mailpit).
+	Mode       string `yaml:"mode" env:"EMAIL_TLS_MODE" env-default:"auto"`
+	CAFile     string `yaml:"ca_file" env:"EMAIL_TLS_CA_FILE"`
+	ServerName string `yaml:"server_name" env:"EMAIL_TLS_SERVER_NAME"`
+	// InsecureSkipVerify — только для dev, в prod конфиг не загрузится.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify" env:"EMAIL_TLS_INSECURE_SKIP_VERIFY"`
 }
 
 func MustLoad() *Config {
@@ -40,11 +239,46 @@ func MustLoad() *Config {
 		panic(fmt.Sprintf("config file does not exist: %s", configPath))
 	}
 
+	if err := loadSecretFiles(); err != nil {
+		panic(fmt.Sprintf("failed to read secret file: %s", err))
+	}
+
+	if err := loadProviderSecrets(configPath); err != nil {
+		panic(fmt.Sprintf("failed to load secrets: %s", err))
+	}
+
 	var cfg Config
 
+	// значения из YAML — умолчания: заданная env-переменная их перекрывает
 	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
 		panic(fmt.Sprintf("failed to read config: %s", err))
 	}
 
+	if cfg.Email.Delivery == "smtp" && (cfg.Email.Username == "" || cfg.Email.Password == "") {
+		panic("EMAIL_USERNAME and EMAIL_PASSWORD are required for smtp delivery")
+	}
+
+	if cfg.Env == "prod" && cfg.Email.Delivery != "smtp" {
+		panic("email.delivery must be smtp in prod")
+	}
+
+	if cfg.Email.Pool.Size < 0 || cfg.Email.Pool.MaxMessages < 0 {
+		panic("email.pool.size and email.pool.max_messages must be >= 0")
+	}
+
+	if cfg.Email.Pool.Size > 0 && cfg.Email.Pool.IdleTimeout <= 0 {
+		panic("email.pool.idle_timeout must be positive")
+	}
+
+	for _, d := range cfg.RabbitMQ.RetryDelays {
+		if d <= 0 {
+			panic("rabbitmq.retry_delays must be positive")
+		}
+	}
+
+	if cfg.Env == "prod" && cfg.Email.TLS.InsecureSkipVerify {
+		panic("email.tls.insecure_skip_verify is not allowed in prod")
+	}
+
 	return &cfg
 }
@@ -0,0 +1,90 @@
+package list
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"email_sender/internal/lib/redact"
+	"email_sender/internal/rabbitmq"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+const defaultLimit = 20
+
+type MessageLister interface {
+	ListMessages(queue string, limit int) ([]rabbitmq.ParkedMessage, error)
+}
+
+type Message struct {
+	ID            string         `json:"id"`
+	Attempts      int64          `json:"attempts"`
+	Error         string         `json:"error,omitempty"`
+	OriginalQueue string         `json:"original_queue"`
+	Timestamp     time.Time      `json:"timestamp"`
+	Payload       map[string]any `json:"payload"`
+}
+
+type Response struct {
+	Queue    string    `json:"queue"`
+	Messages []Message `json:"messages"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// New — GET /admin/dlq/{queue}?limit=N: сообщения parking-очереди или DLQ
+// с замаскированными адресом и токеном.
+func New(log *slog.Logger, lister MessageLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.dlq.list.New"
+
+		log := log.With(slog.String("op", op))
+
+		queue := chi.URLParam(r, "queue")
+
+		limit := defaultLimit
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n <= 0 {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, ErrorResponse{Error: "invalid limit"})
+				return
+			}
+			limit = n
+		}
+
+		parked, err := lister.ListMessages(queue, limit)
+		if err != nil {
+			if errors.Is(err, rabbitmq.ErrQueueNotInspectable) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, ErrorResponse{Error: "queue not found"})
+				return
+			}
+
+			log.Error("failed to list messages", slog.String("queue", queue), slog.String("error", err.Error()))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, ErrorResponse{Error: "internal error"})
+			return
+		}
+
+		res := Response{Queue: queue, Messages: make([]Message, 0, len(parked))}
+		for _, p := range parked {
+			res.Messages = append(res.Messages, Message{
+				ID:            p.ID,
+				Attempts:      p.Attempts,
+				Error:         p.Error,
+				OriginalQueue: p.OriginalQueue,
+				Timestamp:     p.Timestamp,
+				Payload:       redact.Payload(p.Body),
+			})
+		}
+
+		render.JSON(w, r, res)
+	}
+}
@@ -0,0 +1,84 @@
+package redrive
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"email_sender/internal/rabbitmq"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type Redriver interface {
+	Redrive(ctx context.Context, queue string, ids []string) (int, error)
+}
+
+type Request struct {
+	// IDs из списка /admin/dlq/{queue}; пустой список + All — вся очередь
+	IDs []string `json:"ids"`
+	All bool     `json:"all"`
+}
+
+type Response struct {
+	Queue    string `json:"queue"`
+	Redriven int    `json:"redriven"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// New — POST /admin/dlq/{queue}/redrive: возвращает выбранные сообщения
+// в исходную очередь со сброшенным счётчиком попыток.
+func New(log *slog.Logger, redriver Redriver, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.dlq.redrive.New"
+
+		log := log.With(slog.String("op", op))
+
+		queue := chi.URLParam(r, "queue")
+
+		var req Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, ErrorResponse{Error: "failed to decode request"})
+			return
+		}
+
+		// пустой ids без явного all — почти наверняка ошибка оператора
+		if len(req.IDs) == 0 && !req.All {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, ErrorResponse{Error: "ids or all is required"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		n, err := redriver.Redrive(ctx, queue, req.IDs)
+		if err != nil {
+			if errors.Is(err, rabbitmq.ErrQueueNotInspectable) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, ErrorResponse{Error: "queue not found"})
+				return
+			}
+
+			log.Error("failed to redrive messages",
+				slog.String("queue", queue),
+				slog.Int("redriven", n),
+				slog.String("error", err.Error()),
+			)
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, ErrorResponse{Error: "internal error"})
+			return
+		}
+
+		log.Info("messages redriven", slog.String("queue", queue), slog.Int("count", n))
+
+		render.JSON(w, r, Response{Queue: queue, Redriven: n})
+	}
+}
@@ -0,0 +1,75 @@
+package healthz
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	statusOK   = "ok"
+	statusFail = "fail"
+)
+
+// Checker — зависимость, без которой воркер не может доставлять письма.
+type Checker interface {
+	Ping(ctx context.Context) error
+}
+
+type Response struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// New в отличие от /health (жив ли процесс) проверяет зависимости:
+// соединение с RabbitMQ и SMTP-сервер. Проверки идут параллельно под общим
+// таймаутом; любая неудачная — 503.
+func New(log *slog.Logger, checks map[string]Checker, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.healthz.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		var (
+			mu  sync.Mutex
+			wg  sync.WaitGroup
+			res = Response{Status: statusOK, Checks: make(map[string]string, len(checks))}
+		)
+
+		for name, c := range checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				err := c.Ping(ctx)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				if err != nil {
+					log.Warn("health check failed",
+						slog.String("op", op),
+						slog.String("check", name),
+						slog.String("error", err.Error()),
+					)
+					res.Status = statusFail
+					res.Checks[name] = err.Error()
+					return
+				}
+				res.Checks[name] = statusOK
+			}()
+		}
+
+		wg.Wait()
+
+		if res.Status != statusOK {
+			render.Status(r, http.StatusServiceUnavailable)
+		}
+		render.JSON(w, r, res)
+	}
+}
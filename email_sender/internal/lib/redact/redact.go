@@ -0,0 +1,49 @@
+package redact
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Payload маскирует в JSON-сообщении адрес получателя и секреты в ссылке
+// (токен в query/fragment), оставляя остальное как есть. Невалидный JSON
+// целиком заменяется заглушкой — его содержимое неизвестно.
+func Payload(body []byte) map[string]any {
+	var m map[string]any
+	if err := json.Unmarshal(body, &m); err != nil {
+		return map[string]any{"_raw": "<non-json payload redacted>", "_size": len(body)}
+	}
+
+	for k, v := range m {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		switch k {
+		case "to", "email":
+			m[k] = Email(s)
+		case "link", "url":
+			m[k] = Link(s)
+		}
+	}
+
+	return m
+}
+
+// Email: john.doe@gmail.com → j***@gmail.com.
+func Email(s string) string {
+	at := strings.LastIndexByte(s, '@')
+	if at <= 0 {
+		return "***"
+	}
+	return s[:1] + "***" + s[at:]
+}
+
+// Link отрезает query и fragment — в них живут одноразовые токены.
+func Link(s string) string {
+	if i := strings.IndexAny(s, "?#"); i >= 0 {
+		return s[:i] + string(s[i]) + "***"
+	}
+	return s
+}
@@ -0,0 +1,162 @@
+package mailSender
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"os"
+
+	"email_sender/internal/config"
+
+	"gopkg.in/gomail.v2"
+)
+
+const (
+	tlsModeAuto     = "auto"
+	tlsModeImplicit = "implicit"
+	tlsModeSTARTTLS = "starttls"
+	tlsModeNone     = "none"
+
+	authAuto    = "auto"
+	authPlain   = "plain"
+	authLogin   = "login"
+	authCRAMMD5 = "cram-md5"
+	authNone    = "none"
+)
+
+var errSTARTTLSRequired = errors.New("smtp server does not support STARTTLS")
+
+// newDialer собирает gomail.Dialer по конфигу. Сам gomail умеет только
+// implicit TLS и «STARTTLS, если объявлен»; обязательный STARTTLS и
+// механизмы кроме PLAIN/CRAM-MD5 добираем через собственный smtp.Auth.
+func newDialer(cfg config.Email) (*gomail.Dialer, error) {
+	d := gomail.NewDialer(cfg.Host, cfg.Port, cfg.Username, cfg.Password)
+
+	tlsCfg, err := smtpTLSConfig(cfg.TLS, cfg.Host)
+	if err != nil {
+		return nil, err
+	}
+	d.TLSConfig = tlsCfg
+
+	mode := cfg.TLS.Mode
+	switch mode {
+	case "", tlsModeAuto:
+		// дефолт gomail: SSL = port == 465
+	case tlsModeImplicit:
+		d.SSL = true
+	case tlsModeSTARTTLS, tlsModeNone:
+		d.SSL = false
+	default:
+		return nil, fmt.Errorf("unknown smtp tls mode %q", mode)
+	}
+
+	var auth smtp.Auth
+	switch cfg.AuthMechanism {
+	case "", authAuto:
+		// gomail сам выберет CRAM-MD5/LOGIN/PLAIN по EHLO; для обязательного
+		// STARTTLS нужна явная Auth-обёртка, поэтому берём PLAIN
+		if mode == tlsModeSTARTTLS {
+			auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+		}
+	case authPlain:
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	case authLogin:
+		auth = &loginAuth{username: cfg.Username, password: cfg.Password, host: cfg.Host}
+	case authCRAMMD5:
+		auth = smtp.CRAMMD5Auth(cfg.Username, cfg.Password)
+	case authNone:
+		if mode == tlsModeSTARTTLS {
+			return nil, errors.New("smtp tls mode starttls requires authentication")
+		}
+		// gomail пропускает AUTH, если Username пустой
+		d.Username, d.Password = "", ""
+	default:
+		return nil, fmt.Errorf("unknown smtp auth mechanism %q", cfg.AuthMechanism)
+	}
+
+	if auth != nil && mode == tlsModeSTARTTLS {
+		auth = &requireTLSAuth{next: auth}
+	}
+	d.Auth = auth
+
+	return d, nil
+}
+
+func smtpTLSConfig(cfg config.EmailTLS, host string) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         host,
+		InsecureSkipVerify: cfg.InsecureSkipVerify, // dev only, в prod запрещено при загрузке конфига
+	}
+
+	if cfg.ServerName != "" {
+		tlsCfg.ServerName = cfg.ServerName
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read smtp ca file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	return tlsCfg, nil
+}
+
+// requireTLSAuth обрывает сессию до отправки письма, если соединение так и
+// не стало шифрованным: gomail делает STARTTLS только когда сервер его
+// объявляет, а gomail.Dialer вызывает Auth сразу после этого шага.
+type requireTLSAuth struct {
+	next smtp.Auth
+}
+
+func (a *requireTLSAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS {
+		return "", nil, errSTARTTLSRequired
+	}
+	return a.next.Start(server)
+}
+
+func (a *requireTLSAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	return a.next.Next(fromServer, more)
+}
+
+// loginAuth — механизм AUTH LOGIN, которого нет в net/smtp.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && server.Name != "localhost" && server.Name != "127.0.0.1" {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+
+	switch string(fromServer) {
+	case "Username:", "User Name\x00":
+		return []byte(a.username), nil
+	case "Password:", "Password\x00":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge: %q", fromServer)
+	}
+}
@@ -1,30 +1,223 @@
 package mailSender
 
-import "gopkg.in/gomail.v2"
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"email_sender/internal/config"
+	"email_sender/internal/metrics"
+
+	"gopkg.in/gomail.v2"
+)
 
 type Mailer struct {
-	Host     string
-	Port     int
-	Username string
-	Password string
+	From string
+
+	// transport подменяется целиком при ротации SMTP-пароля (SetCredentials)
+	transport atomic.Pointer[transport]
+	cfg       config.Email
+	metrics   *metrics.Metrics
+	log       *slog.Logger
+
+	// templates подменяется целиком при hot reload из templatesDir
+	templates     atomic.Pointer[templates]
+	templatesDir  string
+	defaultLocale string
 }
 
-func (m *Mailer) Send(to, from, body, purpose string) error {
+type transport struct {
+	send sendFunc
+	ping pingFunc
+	pool *connPool // nil — доставка без SMTP-сессий или без пула
+}
+
+func New(cfg config.Email, log *slog.Logger, m *metrics.Metrics) (*Mailer, error) {
+	const op = "mailSender.New"
+
+	tr, err := newTransport(cfg, log)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	templatesFS, err := embeddedTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if cfg.TemplatesDir != "" {
+		templatesFS = os.DirFS(cfg.TemplatesDir)
+	}
+
+	tmpl, err := parseTemplates(templatesFS, cfg.DefaultLocale)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	from := cfg.From
+	if from == "" {
+		from = cfg.Username
+	}
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+
+	mailer := &Mailer{
+		From:          from,
+		cfg:           cfg,
+		metrics:       m,
+		log:           log,
+		templatesDir:  cfg.TemplatesDir,
+		defaultLocale: cfg.DefaultLocale,
+	}
+	mailer.templates.Store(tmpl)
+	mailer.transport.Store(tr)
+
+	return mailer, nil
+}
+
+func newTransport(cfg config.Email, log *slog.Logger) (*transport, error) {
+	switch cfg.Delivery {
+	case "", deliverySMTP:
+		dialer, err := newDialer(cfg)
+		if err != nil {
+			return nil, err
+		}
+		send, pool := smtpSender(dialer, cfg.Pool)
+		return &transport{send: send, ping: smtpPing(dialer), pool: pool}, nil
+	case deliveryLog:
+		return &transport{send: logSender(log), ping: noopPing}, nil
+	case deliveryFile:
+		send, err := fileSender(cfg.SandboxDir)
+		if err != nil {
+			return nil, err
+		}
+		return &transport{send: send, ping: noopPing}, nil
+	case deliveryMailHog:
+		dialer, err := mailHogDialer(cfg.MailHogAddr)
+		if err != nil {
+			return nil, err
+		}
+		send, pool := smtpSender(dialer, cfg.Pool)
+		return &transport{send: send, ping: smtpPing(dialer), pool: pool}, nil
+	default:
+		return nil, fmt.Errorf("unknown email delivery %q", cfg.Delivery)
+	}
+}
+
+// Credentials — текущие SMTP-логин и пароль.
+func (m *Mailer) Credentials() (username, password string) {
+	return m.cfg.Username, m.cfg.Password
+}
+
+// SetCredentials пересобирает SMTP-транспорт с новыми логином и паролем
+// после ротации секрета. Письма в работе дописываются через прежние сессии,
+// их пул закрывается по мере возврата сессий.
+func (m *Mailer) SetCredentials(username, password string) error {
+	const op = "mailSender.SetCredentials"
+
+	cfg := m.cfg
+	cfg.Username, cfg.Password = username, password
+
+	tr, err := newTransport(cfg, m.log)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	m.cfg = cfg
+	old := m.transport.Swap(tr)
+	if old.pool != nil {
+		old.pool.close()
+	}
+
+	return nil
+}
+
+// smtpSender — отправка через пул сессий, а при pool.size: 0 — по
+// соединению на письмо.
+func smtpSender(dialer *gomail.Dialer, cfg config.EmailPool) (sendFunc, *connPool) {
+	if cfg.Size <= 0 {
+		return func(msg *gomail.Message, _ renderedEmail) error {
+			return dialAndSend(dialer, msg)
+		}, nil
+	}
+
+	pool := newConnPool(dialer, cfg.Size, cfg.IdleTimeout, cfg.MaxMessages)
+
+	return func(msg *gomail.Message, _ renderedEmail) error {
+		return pool.send(msg)
+	}, pool
+}
+
+// dialAndSend работает с копией Dialer: при пустом Auth gomail.Dial
+// записывает выбранный механизм прямо в Dialer, а consumer'ы с
+// concurrency > 1 шлют письма параллельно.
+func dialAndSend(d *gomail.Dialer, msg *gomail.Message) error {
+	dialer := *d
+	return dialer.DialAndSend(msg)
+}
+
+// Send отправляет письмо multipart/alternative: text/plain как fallback и
+// HTML-версию последней — клиенты показывают последнюю понятную им часть.
+// locale выбирает перевод шаблона; пустой или неизвестный — язык по
+// умолчанию.
+func (m *Mailer) Send(to, from, link, purpose, locale string, params map[string]string) error {
+	const op = "mailSender.Send"
+
+	email, err := m.templates.Load().render(purpose, locale, link, params)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	msg := gomail.NewMessage()
 	msg.SetHeader("To", to)
-	msg.SetHeader("From", m.Username)
+	msg.SetHeader("From", m.From)
+	msg.SetHeader("Subject", email.subject)
+
+	msg.SetBody("text/plain", email.text)
+	msg.AddAlternative("text/html", email.html)
+
+	start := time.Now()
+	err = m.transport.Load().send(msg, email)
+	m.metrics.EmailSendDuration.WithLabelValues(purpose).Observe(time.Since(start).Seconds())
 
-	switch {
-	case purpose == "reset_password":
-		msg.SetHeader("Subject", "Сброс пароля")
-	case purpose == "email_verification":
-		msg.SetHeader("Subject", "Подтверждение почты")
-	case purpose == "2fa":
-		msg.SetHeader("Subject", "Подтверждение действия")
+	if err != nil {
+		m.metrics.EmailSendFailuresTotal.WithLabelValues(purpose).Inc()
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	msg.SetBody("text/plain", body)
+	m.metrics.EmailsSentTotal.WithLabelValues(purpose).Inc()
 
-	dialer := gomail.NewDialer(m.Host, m.Port, m.Username, m.Password)
-	return dialer.DialAndSend(msg)
+	return nil
+}
+
+// Close закрывает простаивающие SMTP-сессии. Вызывается после того, как
+// consumer'ы дописали текущие письма.
+func (m *Mailer) Close(ctx context.Context) error {
+	const op = "mailSender.Close"
+
+	pool := m.transport.Load().pool
+	if pool == nil {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		pool.close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	}
+}
+
+// Ping проверяет доступность транспорта: для SMTP — connect, EHLO и NOOP.
+func (m *Mailer) Ping(ctx context.Context) error {
+	return m.transport.Load().ping(ctx)
 }
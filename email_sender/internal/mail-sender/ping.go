@@ -0,0 +1,73 @@
+package mailSender
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+
+	"gopkg.in/gomail.v2"
+)
+
+type pingFunc func(ctx context.Context) error
+
+func noopPing(context.Context) error { return nil }
+
+// smtpPing повторяет начало сессии gomail (connect, EHLO, STARTTLS) и
+// шлёт NOOP — без AUTH, чтобы health-check не жёг попытки логина.
+func smtpPing(d *gomail.Dialer) pingFunc {
+	return func(ctx context.Context) error {
+		addr := net.JoinHostPort(d.Host, strconv.Itoa(d.Port))
+
+		tlsCfg := d.TLSConfig
+		if tlsCfg == nil {
+			tlsCfg = &tls.Config{ServerName: d.Host}
+		}
+
+		var (
+			conn net.Conn
+			err  error
+		)
+		if d.SSL {
+			conn, err = (&tls.Dialer{Config: tlsCfg}).DialContext(ctx, "tcp", addr)
+		} else {
+			conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+		}
+		if err != nil {
+			return fmt.Errorf("smtp dial: %w", err)
+		}
+		defer conn.Close()
+
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+
+		c, err := smtp.NewClient(conn, d.Host)
+		if err != nil {
+			return fmt.Errorf("smtp greeting: %w", err)
+		}
+		defer c.Close()
+
+		if d.LocalName != "" {
+			if err := c.Hello(d.LocalName); err != nil {
+				return fmt.Errorf("smtp hello: %w", err)
+			}
+		}
+
+		if !d.SSL {
+			if ok, _ := c.Extension("STARTTLS"); ok {
+				if err := c.StartTLS(tlsCfg); err != nil {
+					return fmt.Errorf("smtp starttls: %w", err)
+				}
+			}
+		}
+
+		if err := c.Noop(); err != nil {
+			return fmt.Errorf("smtp noop: %w", err)
+		}
+
+		return c.Quit()
+	}
+}
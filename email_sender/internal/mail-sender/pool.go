@@ -0,0 +1,115 @@
+package mailSender
+
+import (
+	"sync"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+// connPool держит открытые SMTP-сессии между письмами: без него каждое
+// письмо — это TCP, TLS-рукопожатие и AUTH заново, и при concurrency > 1
+// соединения становятся узким местом раньше, чем сам SMTP-сервер. Воркер
+// берёт сессию на время письма и возвращает её, так что открытых сессий не
+// больше числа воркеров, а простаивающих — не больше size.
+type connPool struct {
+	dialer      *gomail.Dialer
+	size        int
+	idleTimeout time.Duration
+	maxMessages int
+
+	mu     sync.Mutex
+	idle   []*pooledConn
+	closed bool
+}
+
+type pooledConn struct {
+	sc       gomail.SendCloser
+	sent     int
+	lastUsed time.Time
+}
+
+func newConnPool(d *gomail.Dialer, size int, idleTimeout time.Duration, maxMessages int) *connPool {
+	return &connPool{
+		dialer:      d,
+		size:        size,
+		idleTimeout: idleTimeout,
+		maxMessages: maxMessages,
+	}
+}
+
+// send отправляет письмо через свободную сессию или новую. После ошибки
+// сессия закрывается: в каком состоянии осталась SMTP-транзакция,
+// неизвестно. Сессию, закрытую сервером по таймауту, gomail переоткрывает
+// сам, если обрыв обнаружился на MAIL FROM.
+func (p *connPool) send(msg *gomail.Message) error {
+	conn, err := p.get()
+	if err != nil {
+		return err
+	}
+
+	if err := gomail.Send(conn.sc, msg); err != nil {
+		_ = conn.sc.Close()
+		return err
+	}
+
+	conn.sent++
+	conn.lastUsed = time.Now()
+	p.put(conn)
+
+	return nil
+}
+
+func (p *connPool) get() (*pooledConn, error) {
+	p.mu.Lock()
+	for len(p.idle) > 0 {
+		conn := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+
+		if time.Since(conn.lastUsed) < p.idleTimeout {
+			p.mu.Unlock()
+			return conn, nil
+		}
+
+		// сервер, скорее всего, уже закрыл сессию — QUIT вежливый, но не обязательный
+		go conn.sc.Close()
+	}
+	p.mu.Unlock()
+
+	// копия Dialer: при пустом Auth gomail.Dial записывает выбранный
+	// механизм прямо в Dialer, а сессии открываются параллельно
+	dialer := *p.dialer
+
+	sc, err := dialer.Dial()
+	if err != nil {
+		return nil, err
+	}
+
+	return &pooledConn{sc: sc}, nil
+}
+
+func (p *connPool) put(conn *pooledConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed || len(p.idle) >= p.size || (p.maxMessages > 0 && conn.sent >= p.maxMessages) {
+		go conn.sc.Close()
+		return
+	}
+
+	p.idle = append(p.idle, conn)
+}
+
+// close завершает простаивающие сессии; сессии, занятые письмами, закроются
+// при возврате в пул.
+func (p *connPool) close() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.closed = true
+	p.mu.Unlock()
+
+	for _, conn := range idle {
+		_ = conn.sc.Close()
+	}
+}
@@ -0,0 +1,103 @@
+package mailSender
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	sl "email_sender/internal/lib/logger"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce склеивает пачку событий от одного сохранения/деплоя
+// (редактор пишет через temp-файл, ConfigMap меняет симлинк ..data).
+const reloadDebounce = 500 * time.Millisecond
+
+// WatchTemplates перечитывает шаблоны из email.templates_dir при любом
+// изменении каталога или подкаталогов переводов, пока не отменён ctx. Сломанный шаблон логируется и
+// не подменяет рабочий набор. Без templates_dir сразу возвращает nil.
+func (m *Mailer) WatchTemplates(ctx context.Context) error {
+	const op = "mailSender.WatchTemplates"
+
+	if m.templatesDir == "" {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(m.templatesDir); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log := m.log.With(slog.String("op", op), slog.String("dir", m.templatesDir))
+
+	watchLocaleDirs(watcher, m.templatesDir, log)
+
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return fmt.Errorf("%s: watcher closed", op)
+			}
+			if ev.Has(fsnotify.Chmod) {
+				continue
+			}
+			debounce.Reset(reloadDebounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return fmt.Errorf("%s: watcher closed", op)
+			}
+			log.Error("templates watcher error", sl.Err(err))
+
+		case <-debounce.C:
+			// новый перевод появляется событием в корне — подписываемся и на него
+			watchLocaleDirs(watcher, m.templatesDir, log)
+
+			tmpl, err := parseTemplates(os.DirFS(m.templatesDir), m.defaultLocale)
+			if err != nil {
+				log.Error("failed to reload templates, keeping previous", sl.Err(err))
+				continue
+			}
+
+			m.templates.Store(tmpl)
+			log.Info("templates reloaded", slog.Int("locales", len(tmpl.locales)))
+		}
+	}
+}
+
+// watchLocaleDirs добавляет в watcher подкаталоги переводов: fsnotify не
+// следит за вложенными каталогами сам. Повторный Add того же пути — no-op.
+func watchLocaleDirs(watcher *fsnotify.Watcher, dir string, log *slog.Logger) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Error("failed to list templates dir", sl.Err(err))
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		if err := watcher.Add(filepath.Join(dir, entry.Name())); err != nil {
+			log.Error("failed to watch locale dir", slog.String("locale", entry.Name()), sl.Err(err))
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package mailSender
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+const (
+	deliverySMTP    = "smtp"
+	deliveryLog     = "log"
+	deliveryFile    = "file"
+	deliveryMailHog = "mailhog"
+)
+
+// sendFunc — способ доставки готового письма. Рендер и заголовки одинаковы
+// для всех режимов, отличается только транспорт.
+type sendFunc func(msg *gomail.Message, e renderedEmail) error
+
+func logSender(log *slog.Logger) sendFunc {
+	return func(msg *gomail.Message, e renderedEmail) error {
+		log.Info("sandbox email",
+			slog.String("to", strings.Join(msg.GetHeader("To"), ", ")),
+			slog.String("subject", e.subject),
+			slog.String("text", e.text),
+		)
+		return nil
+	}
+}
+
+// fileSender пишет письмо целиком (MIME, как ушло бы по SMTP) в .eml —
+// такие файлы открываются любым почтовым клиентом.
+func fileSender(dir string) (sendFunc, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create sandbox dir: %w", err)
+	}
+
+	return func(msg *gomail.Message, _ renderedEmail) error {
+		to := strings.Join(msg.GetHeader("To"), "_")
+		name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), sanitizeFileName(to))
+
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o640)
+		if err != nil {
+			return err
+		}
+
+		if _, err := msg.WriteTo(f); err != nil {
+			f.Close()
+			return err
+		}
+
+		return f.Close()
+	}, nil
+}
+
+func mailHogDialer(addr string) (*gomail.Dialer, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("parse mailhog address: %w", err)
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("parse mailhog port: %w", err)
+	}
+
+	// MailHog/Mailpit не требуют ни auth, ни TLS
+	return &gomail.Dialer{Host: host, Port: port}, nil
+}
+
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '@', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
@@ -0,0 +1,223 @@
+package mailSender
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmlTemplate "html/template"
+	"io/fs"
+	"strings"
+	textTemplate "text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed templates/*.tmpl templates/purposes.yaml templates/*/*.tmpl templates/*/purposes.yaml
+var embeddedFS embed.FS
+
+const (
+	purposesFile = "purposes.yaml"
+	layoutFile   = "layout.html.tmpl"
+)
+
+var ErrUnknownPurpose = errors.New("unknown email purpose")
+
+// * purpose приходит из auth_service в поле Purpose сообщения
+type purposeInfo struct {
+	Subject    string `yaml:"subject"`
+	ButtonText string `yaml:"button_text"`
+}
+
+type templateData struct {
+	Subject    string
+	ButtonText string
+	Link       string
+	Data       map[string]string
+}
+
+type renderedEmail struct {
+	subject string
+	text    string
+	html    string
+}
+
+type purposeTemplates struct {
+	info purposeInfo
+	text *textTemplate.Template
+	html *htmlTemplate.Template
+}
+
+// purposeSet — шаблоны одного языка по purpose. HTML-версия каждого purpose
+// собирается из layout.html.tmpl и своего блока "content".
+type purposeSet map[string]purposeTemplates
+
+// templates — распарсенные шаблоны по языку. Корень каталога — язык по
+// умолчанию (email.default_locale), подкаталог <locale>/ — перевод со своим
+// purposes.yaml. Перевод может быть неполным: purpose без перевода и
+// неизвестный язык уходят на языке по умолчанию.
+type templates struct {
+	defaultLocale string
+	locales       map[string]purposeSet
+}
+
+// embeddedTemplates — шаблоны, вшитые в бинарник; используются, если
+// email.templates_dir не задан.
+func embeddedTemplates() (fs.FS, error) {
+	return fs.Sub(embeddedFS, "templates")
+}
+
+// parseTemplates читает шаблоны языка по умолчанию и всех переводов из
+// fsys. Ошибка в любом purpose — ошибка целиком: полупарсенный набор не
+// подменяет рабочий.
+func parseTemplates(fsys fs.FS, defaultLocale string) (*templates, error) {
+	const op = "mailSender.parseTemplates"
+
+	base, err := htmlTemplate.ParseFS(fsys, layoutFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defaults, err := parsePurposeSet(fsys, base)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	t := &templates{
+		defaultLocale: normalizeLocale(defaultLocale),
+		locales:       map[string]purposeSet{normalizeLocale(defaultLocale): defaults},
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, entry := range entries {
+		// скрытые каталоги — служебные (..data у ConfigMap)
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		locale := normalizeLocale(entry.Name())
+
+		sub, err := fs.Sub(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", op, locale, err)
+		}
+
+		// свой layout у перевода необязателен, но без него подвал письма
+		// останется на языке по умолчанию
+		localeBase := base
+		if _, err := fs.Stat(sub, layoutFile); err == nil {
+			if localeBase, err = htmlTemplate.ParseFS(sub, layoutFile); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", op, locale, err)
+			}
+		}
+
+		set, err := parsePurposeSet(sub, localeBase)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", op, locale, err)
+		}
+
+		for purpose := range set {
+			if _, ok := defaults[purpose]; !ok {
+				return nil, fmt.Errorf("%s: %s: purpose %q has no default template", op, locale, purpose)
+			}
+		}
+
+		t.locales[locale] = set
+	}
+
+	return t, nil
+}
+
+// parsePurposeSet читает purposes.yaml и шаблоны одного языка.
+func parsePurposeSet(fsys fs.FS, base *htmlTemplate.Template) (purposeSet, error) {
+	raw, err := fs.ReadFile(fsys, purposesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var purposes map[string]purposeInfo
+	if err := yaml.Unmarshal(raw, &purposes); err != nil {
+		return nil, fmt.Errorf("%s: %w", purposesFile, err)
+	}
+
+	set := make(purposeSet, len(purposes))
+
+	for purpose, info := range purposes {
+		if info.Subject == "" {
+			return nil, fmt.Errorf("%s: empty subject", purpose)
+		}
+
+		text, err := textTemplate.ParseFS(fsys, purpose+".txt.tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("%s text: %w", purpose, err)
+		}
+
+		html, err := base.Clone()
+		if err != nil {
+			return nil, fmt.Errorf("%s html: %w", purpose, err)
+		}
+		if html, err = html.ParseFS(fsys, purpose+".html.tmpl"); err != nil {
+			return nil, fmt.Errorf("%s html: %w", purpose, err)
+		}
+
+		set[purpose] = purposeTemplates{info: info, text: text, html: html}
+	}
+
+	return set, nil
+}
+
+// lookup выбирает перевод: точный язык ("pt-br"), затем основной ("pt"),
+// затем язык по умолчанию.
+func (t *templates) lookup(locale, purpose string) (purposeTemplates, bool) {
+	locale = normalizeLocale(locale)
+	lang, _, _ := strings.Cut(locale, "-")
+
+	for _, l := range []string{locale, lang, t.defaultLocale} {
+		if pt, ok := t.locales[l][purpose]; ok {
+			return pt, true
+		}
+	}
+
+	return purposeTemplates{}, false
+}
+
+// normalizeLocale приводит "en_US" и "en-US" к "en-us".
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+func (t *templates) render(purpose, locale, link string, params map[string]string) (renderedEmail, error) {
+	const op = "mailSender.render"
+
+	pt, ok := t.lookup(locale, purpose)
+	if !ok {
+		return renderedEmail{}, fmt.Errorf("%s: %q: %w", op, purpose, ErrUnknownPurpose)
+	}
+
+	data := templateData{
+		Subject:    pt.info.Subject,
+		ButtonText: pt.info.ButtonText,
+		Link:       link,
+		Data:       params,
+	}
+
+	var text bytes.Buffer
+	if err := pt.text.Execute(&text, data); err != nil {
+		return renderedEmail{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var html bytes.Buffer
+	if err := pt.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return renderedEmail{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return renderedEmail{
+		subject: pt.info.Subject,
+		text:    text.String(),
+		html:    html.String(),
+	}, nil
+}
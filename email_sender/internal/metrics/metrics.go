@@ -16,6 +16,15 @@ type Metrics struct {
 	MessagesConsumedTotal     prometheus.Counter
 	MessagesFailedTotal       *prometheus.CounterVec
 	MessageProcessingDuration prometheus.Histogram
+	MessagesRedeliveredTotal  prometheus.Counter
+	MessagesRetriedTotal      prometheus.Counter
+	MessagesParkedTotal       *prometheus.CounterVec
+
+	// SMTP-метрики (по purpose письма)
+	EmailsSentTotal        *prometheus.CounterVec
+	EmailSendFailuresTotal *prometheus.CounterVec
+	EmailSendDuration      *prometheus.HistogramVec
+	EmailsSuppressedTotal  *prometheus.CounterVec
 }
 
 func New() *Metrics {
@@ -53,6 +62,36 @@ func New() *Metrics {
 			Help:    "Duration of message handler execution",
 			Buckets: prometheus.DefBuckets,
 		}),
+		MessagesRedeliveredTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "messages_redelivered_total",
+			Help: "Total deliveries received with the redelivered flag set by the broker",
+		}),
+		MessagesRetriedTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "messages_retried_total",
+			Help: "Total failed messages republished for another attempt",
+		}),
+		MessagesParkedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "messages_parked_total",
+			Help: "Total messages moved to the parking queue, labeled by reason",
+		}, []string{"reason"}),
+
+		EmailsSentTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "emails_sent_total",
+			Help: "Total emails handed over to the delivery transport, labeled by purpose",
+		}, []string{"purpose"}),
+		EmailSendFailuresTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "email_send_failures_total",
+			Help: "Total emails the delivery transport rejected, labeled by purpose",
+		}, []string{"purpose"}),
+		EmailSendDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "email_send_duration_seconds",
+			Help:    "Duration of a single email send (dial + SMTP transaction)",
+			Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"purpose"}),
+		EmailsSuppressedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "emails_suppressed_total",
+			Help: "Total emails dropped without sending, labeled by purpose and reason (already_processed, duplicate, recipient_limit)",
+		}, []string{"purpose", "reason"}),
 	}
 
 	reg.MustRegister(
@@ -61,6 +100,13 @@ func New() *Metrics {
 		m.MessagesConsumedTotal,
 		m.MessagesFailedTotal,
 		m.MessageProcessingDuration,
+		m.MessagesRedeliveredTotal,
+		m.MessagesRetriedTotal,
+		m.MessagesParkedTotal,
+		m.EmailsSentTotal,
+		m.EmailSendFailuresTotal,
+		m.EmailSendDuration,
+		m.EmailsSuppressedTotal,
 		collectors.NewGoCollector(),
 		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
 	)
@@ -1,7 +1,7 @@
 package models
 
-type EmailMessage struct {
-	Email       string `json:"to"`
-	MessageText string `json:"link"`
-	Purpose     string `json:"purpose"`
-}
+import "github.com/XdMishaXd/auth_service/contract"
+
+// EmailMessage — письмо от auth_service; формат общий с ним и живёт в
+// модуле contract.
+type EmailMessage = contract.EmailMessage
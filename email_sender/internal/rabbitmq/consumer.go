@@ -0,0 +1,138 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"golang.org/x/sync/errgroup"
+)
+
+// Handler возвращает error — это единственный способ узнать, удалось ли
+// обработать сообщение, и соответственно ack или nack его, плюс записать
+// это в metrics. ctx несёт trace context из заголовков сообщения.
+type Handler func(ctx context.Context, body []byte) error
+
+// Consumer — подписка на одну очередь. Concurrency воркеров читают из
+// одного канала, Prefetch ограничивает число неподтверждённых сообщений
+// на этом канале (0 — rabbitmq.prefetch).
+type Consumer struct {
+	Queue       string
+	Concurrency int
+	Prefetch    int
+	Handler     Handler
+}
+
+// StartReading запускает все consumer'ы и блокируется, пока не отменён ctx
+// (штатный shutdown, возвращает nil) или пока не упал канал одного из
+// них — тогда останавливаются и остальные, а наружу уходит ошибка.
+func (r *RabbitMQClient) StartReading(ctx context.Context, consumers []Consumer) error {
+	const op = "rabbitmq.StartReading"
+
+	if len(consumers) == 0 {
+		return fmt.Errorf("%s: no consumers configured", op)
+	}
+
+	eg, egCtx := errgroup.WithContext(ctx)
+
+	for _, c := range consumers {
+		eg.Go(func() error {
+			if err := r.consume(egCtx, c); err != nil {
+				return fmt.Errorf("%s: %s: %w", op, c.Queue, err)
+			}
+			return nil
+		})
+	}
+
+	return eg.Wait()
+}
+
+func (r *RabbitMQClient) consume(ctx context.Context, c Consumer) error {
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	prefetch := c.Prefetch
+	if prefetch <= 0 {
+		prefetch = r.prefetch
+	}
+	concurrency := max(c.Concurrency, 1)
+
+	if err := ch.Qos(max(prefetch, concurrency), 0, false); err != nil {
+		return err
+	}
+
+	if err := declareRetryQueues(ch, c.Queue, r.retryDelays); err != nil {
+		return err
+	}
+
+	tag := "email_sender." + c.Queue
+
+	msgs, err := ch.Consume(c.Queue, tag, false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg      sync.WaitGroup
+		errOnce sync.Once
+		workErr error
+	)
+
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+
+				case msg, ok := <-msgs:
+					if !ok {
+						// канал закрылся НЕ из-за ctx.Done() — это авария
+						// (разрыв соединения/канала с RabbitMQ), а не штатный shutdown
+						errOnce.Do(func() { workErr = errors.New("channel closed unexpectedly") })
+						return
+					}
+
+					// * обработка не должна обрываться отменой consumer ctx:
+					// начатое письмо дописываем и честно ack/nack'аем, а ctx
+					// отменяет только приём новых сообщений
+					r.processMessage(context.WithoutCancel(ctx), c.Queue, msg, c.Handler)
+				}
+			}
+		}()
+	}
+
+	// воркеры выходят либо по ctx (после текущего письма), либо по закрытию
+	// msgs; в обоих случаях подписку снимаем уже после того, как in-flight
+	// сообщения подтверждены
+	wg.Wait()
+
+	if workErr != nil {
+		return workErr
+	}
+
+	stopConsuming(ch, tag, msgs)
+	return nil
+}
+
+// stopConsuming отменяет подписку, чтобы брокер перестал слать новые
+// сообщения, и возвращает в очередь те, что уже успели прилететь в буфер
+// клиента, но не начали обрабатываться. После Cancel брокер закрывает msgs.
+func stopConsuming(ch *amqp.Channel, tag string, msgs <-chan amqp.Delivery) {
+	if err := ch.Cancel(tag, false); err != nil {
+		// канал уже мёртв — неподтверждённые сообщения брокер вернёт сам
+		return
+	}
+
+	for msg := range msgs {
+		_ = msg.Nack(false, true)
+	}
+}
@@ -0,0 +1,86 @@
+package rabbitmq
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"email_sender/internal/config"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// dial открывает соединение с брокером с учётом TLS, явных credentials,
+// heartbeat и connection_name (видно в management UI — без него все
+// соединения сервиса выглядят как безымянные "amqp091-go").
+func dial(cfg config.RabbitMQ) (*amqp.Connection, error) {
+	amqpCfg := amqp.Config{
+		Heartbeat:  cfg.Heartbeat,
+		Locale:     "en_US",
+		Properties: amqp.NewConnectionProperties(),
+	}
+
+	if cfg.ConnectionName != "" {
+		amqpCfg.Properties.SetClientConnectionName(cfg.ConnectionName)
+	}
+
+	// Credentials из env имеют приоритет над user:pass в URL — так пароль
+	// брокера не обязательно держать в одной строке с адресом.
+	if cfg.Username != "" {
+		amqpCfg.SASL = []amqp.Authentication{
+			&amqp.PlainAuth{Username: cfg.Username, Password: cfg.Password},
+		}
+	}
+
+	uri, err := amqp.ParseURI(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+
+	if uri.Scheme == "amqps" {
+		tlsCfg, err := tlsConfig(cfg.TLS, uri.Host)
+		if err != nil {
+			return nil, fmt.Errorf("tls config: %w", err)
+		}
+		amqpCfg.TLSClientConfig = tlsCfg
+	}
+
+	return amqp.DialConfig(cfg.URL, amqpCfg)
+}
+
+// tlsConfig собирает tls.Config из файлов CA и клиентского сертификата.
+// Пустой CAFile — системный пул; пара cert/key нужна только для mTLS.
+func tlsConfig(cfg config.RabbitMQTLS, host string) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: host,
+	}
+
+	if cfg.ServerName != "" {
+		tlsCfg.ServerName = cfg.ServerName
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsCfg, nil
+}
@@ -0,0 +1,203 @@
+package rabbitmq
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// maxInspect — верхняя граница сообщений, которые вытаскиваем из очереди
+// за один запрос списка/re-drive.
+const maxInspect = 1000
+
+var ErrQueueNotInspectable = errors.New("queue is not inspectable")
+
+// ParkedMessage — сообщение из parking-очереди или DLQ в том виде, в
+// котором его можно показать оператору.
+type ParkedMessage struct {
+	ID            string
+	Body          []byte
+	Attempts      int64
+	Error         string
+	OriginalQueue string
+	Timestamp     time.Time
+}
+
+// InspectableQueues — очереди, которые можно просматривать и re-drive'ить.
+func (r *RabbitMQClient) InspectableQueues() []string {
+	return []string{r.parkingQueue, r.deadLetterQueue}
+}
+
+// ListMessages возвращает до limit сообщений из queue, не удаляя их: всё
+// забирается basic.get без ack на отдельном канале, а закрытие канала
+// возвращает сообщения в очередь. Порядок при этом может поменяться.
+func (r *RabbitMQClient) ListMessages(queue string, limit int) ([]ParkedMessage, error) {
+	const op = "rabbitmq.ListMessages"
+
+	if !slices.Contains(r.InspectableQueues(), queue) {
+		return nil, fmt.Errorf("%s: %s: %w", op, queue, ErrQueueNotInspectable)
+	}
+
+	r.inspectMu.Lock()
+	defer r.inspectMu.Unlock()
+
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer ch.Close()
+
+	limit = min(max(limit, 1), maxInspect)
+
+	res := make([]ParkedMessage, 0, limit)
+	for len(res) < limit {
+		msg, ok, err := ch.Get(queue, false)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if !ok {
+			break
+		}
+		res = append(res, r.toParked(msg))
+	}
+
+	return res, nil
+}
+
+// Redrive возвращает сообщения с указанными ID (или все, если ids пуст) из
+// queue в исходную очередь со сброшенным счётчиком попыток. Возвращает
+// число переотправленных сообщений.
+func (r *RabbitMQClient) Redrive(ctx context.Context, queue string, ids []string) (int, error) {
+	const op = "rabbitmq.Redrive"
+
+	if !slices.Contains(r.InspectableQueues(), queue) {
+		return 0, fmt.Errorf("%s: %s: %w", op, queue, ErrQueueNotInspectable)
+	}
+
+	r.inspectMu.Lock()
+	defer r.inspectMu.Unlock()
+
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	// неподходящие сообщения остаются без ack и вернутся в очередь при Close
+	defer ch.Close()
+
+	if err := ch.Confirm(false); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	redriven := 0
+	for range maxInspect {
+		msg, ok, err := ch.Get(queue, false)
+		if err != nil {
+			return redriven, fmt.Errorf("%s: %w", op, err)
+		}
+		if !ok {
+			break
+		}
+
+		parked := r.toParked(msg)
+		if len(ids) > 0 && !slices.Contains(ids, parked.ID) {
+			continue
+		}
+
+		confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, "", parked.OriginalQueue, false, false, amqp.Publishing{
+			Headers:       redriveHeaders(msg.Headers),
+			ContentType:   msg.ContentType,
+			DeliveryMode:  amqp.Persistent,
+			CorrelationId: msg.CorrelationId,
+			MessageId:     msg.MessageId,
+			Timestamp:     msg.Timestamp,
+			Body:          msg.Body,
+		})
+		if err != nil {
+			return redriven, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := waitConfirm(ctx, parked.OriginalQueue, confirm); err != nil {
+			return redriven, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := msg.Ack(false); err != nil {
+			return redriven, fmt.Errorf("%s: %w", op, err)
+		}
+		redriven++
+
+		if len(ids) > 0 && redriven == len(ids) {
+			break
+		}
+	}
+
+	return redriven, nil
+}
+
+func (r *RabbitMQClient) toParked(msg amqp.Delivery) ParkedMessage {
+	p := ParkedMessage{
+		ID:        messageID(msg),
+		Body:      msg.Body,
+		Attempts:  attempts(msg),
+		Timestamp: msg.Timestamp,
+	}
+
+	if e, ok := msg.Headers[headerError].(string); ok {
+		p.Error = e
+	}
+
+	p.OriginalQueue = originalQueue(msg)
+	if p.OriginalQueue == "" {
+		p.OriginalQueue = r.mainQueue
+	}
+
+	return p
+}
+
+// messageID — MessageId публикатора, а если его нет — хеш тела: стабильный
+// между запросами список → re-drive.
+func messageID(msg amqp.Delivery) string {
+	if msg.MessageId != "" {
+		return msg.MessageId
+	}
+	sum := sha256.Sum256(msg.Body)
+	return hex.EncodeToString(sum[:8])
+}
+
+// originalQueue — откуда сообщение пришло: x-original-queue ставим мы при
+// парковке, x-death — брокер при dead-lettering.
+func originalQueue(msg amqp.Delivery) string {
+	if q, ok := msg.Headers[headerOriginalQueue].(string); ok && q != "" {
+		return q
+	}
+
+	if deaths, ok := msg.Headers["x-death"].([]any); ok && len(deaths) > 0 {
+		if t, ok := deaths[0].(amqp.Table); ok {
+			if q, ok := t["queue"].(string); ok {
+				return q
+			}
+		}
+	}
+
+	return ""
+}
+
+func redriveHeaders(src amqp.Table) amqp.Table {
+	headers := make(amqp.Table, len(src)+1)
+	for k, v := range src {
+		switch k {
+		case headerAttempts, headerError, headerOriginalQueue, headerParkedAt,
+			"x-death", "x-delivery-count", "x-first-death-exchange",
+			"x-first-death-queue", "x-first-death-reason",
+			"x-last-death-exchange", "x-last-death-queue", "x-last-death-reason":
+			continue
+		}
+		headers[k] = v
+	}
+	headers["x-redriven-at"] = time.Now().UTC().Format(time.RFC3339)
+	return headers
+}
@@ -0,0 +1,172 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ErrPermanent — ошибка, которую повтор не исправит (битый JSON, неизвестный
+// purpose): сообщение сразу уезжает в parking-очередь.
+var ErrPermanent = errors.New("permanent failure")
+
+var errNotConfirmed = errors.New("broker did not confirm the publish")
+
+const (
+	headerAttempts      = "x-attempts"
+	headerError         = "x-error"
+	headerOriginalQueue = "x-original-queue"
+	headerParkedAt      = "x-parked-at"
+)
+
+// attempts — сколько раз сообщение уже обрабатывалось до текущей доставки.
+// Собственный счётчик x-attempts ставим при republish; x-death (циклы через
+// DLX) и x-delivery-count (quorum-очереди) учитываем, если сообщение ходило
+// по кругу мимо нас.
+func attempts(msg amqp.Delivery) int64 {
+	n := headerInt(msg.Headers[headerAttempts])
+
+	if deaths, ok := msg.Headers["x-death"].([]any); ok {
+		var total int64
+		for _, d := range deaths {
+			if t, ok := d.(amqp.Table); ok {
+				total += headerInt(t["count"])
+			}
+		}
+		n = max(n, total)
+	}
+
+	n = max(n, headerInt(msg.Headers["x-delivery-count"]))
+
+	if msg.Redelivered && n == 0 {
+		// classic-очередь без счётчика: хотя бы одна попытка точно была
+		n = 1
+	}
+
+	return n
+}
+
+func headerInt(v any) int64 {
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int16:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case int64:
+		return n
+	default:
+		return 0
+	}
+}
+
+// handleFailure решает судьбу сообщения после ошибки handler'а:
+// ErrRequeue — назад в очередь без счёта попыток; ErrPermanent или
+// исчерпанные попытки — в parking-очередь с причиной; иначе — republish с
+// x-attempts+1 в очередь задержки, откуда сообщение по TTL вернётся в свою
+// очередь (без retry_delays — сразу в хвост своей). Оригинал ack'ается
+// только после того, как брокер подтвердил копию; без подтверждения — nack:
+// из parking-ветки в DLQ, из retry-ветки обратно в очередь.
+func (r *RabbitMQClient) handleFailure(ctx context.Context, queue string, msg amqp.Delivery, procErr error) {
+	if errors.Is(procErr, ErrRequeue) {
+		_ = msg.Nack(false, true)
+		return
+	}
+
+	attempt := attempts(msg) + 1
+
+	if errors.Is(procErr, ErrPermanent) || attempt >= int64(r.maxAttempts) {
+		reason := "max_attempts"
+		if errors.Is(procErr, ErrPermanent) {
+			reason = "permanent"
+		}
+
+		if err := r.republish(ctx, r.parkingQueue, msg, amqp.Table{
+			headerAttempts:      attempt,
+			headerError:         procErr.Error(),
+			headerOriginalQueue: queue,
+			headerParkedAt:      time.Now().UTC().Format(time.RFC3339),
+		}); err != nil {
+			_ = msg.Nack(false, false)
+			return
+		}
+
+		r.metrics.MessagesParkedTotal.WithLabelValues(reason).Inc()
+		_ = msg.Ack(false)
+		return
+	}
+
+	if err := r.republish(ctx, r.retryTarget(queue, attempt), msg, amqp.Table{
+		headerAttempts: attempt,
+		headerError:    procErr.Error(),
+	}); err != nil {
+		_ = msg.Nack(false, true)
+		return
+	}
+
+	r.metrics.MessagesRetriedTotal.Inc()
+	_ = msg.Ack(false)
+}
+
+// retryTarget — очередь, куда уходит сообщение перед попыткой attempt+1.
+func (r *RabbitMQClient) retryTarget(queue string, attempt int64) string {
+	if len(r.retryDelays) == 0 {
+		return queue
+	}
+
+	i := min(int(attempt), len(r.retryDelays)) - 1
+
+	return retryQueueName(queue, r.retryDelays[i])
+}
+
+// republish кладёт копию сообщения в queue через default exchange,
+// сохраняя пользовательские заголовки (в т.ч. traceparent) и дописывая extra.
+func (r *RabbitMQClient) republish(ctx context.Context, queue string, msg amqp.Delivery, extra amqp.Table) error {
+	headers := make(amqp.Table, len(msg.Headers)+len(extra))
+	for k, v := range msg.Headers {
+		// служебные заголовки брокера: их значение уже учтено в x-attempts
+		if k == "x-death" || k == "x-delivery-count" {
+			continue
+		}
+		headers[k] = v
+	}
+	for k, v := range extra {
+		headers[k] = v
+	}
+
+	r.publishMu.Lock()
+	confirm, err := r.channel.PublishWithDeferredConfirmWithContext(ctx, "", queue, false, false, amqp.Publishing{
+		Headers:       headers,
+		ContentType:   msg.ContentType,
+		DeliveryMode:  amqp.Persistent,
+		CorrelationId: msg.CorrelationId,
+		MessageId:     msg.MessageId,
+		Timestamp:     msg.Timestamp,
+		Body:          msg.Body,
+	})
+	r.publishMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("republish to %s: %w", queue, err)
+	}
+
+	return waitConfirm(ctx, queue, confirm)
+}
+
+// waitConfirm ждёт подтверждения публикации от брокера. Без него копия
+// могла не дойти до очереди, и ack оригинала потерял бы сообщение.
+// Закрытие канала тоже даёт отказ: неподтверждённые публикации отклоняются.
+func waitConfirm(ctx context.Context, queue string, confirm *amqp.DeferredConfirmation) error {
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("republish to %s: wait confirm: %w", queue, err)
+	}
+	if !acked {
+		return fmt.Errorf("republish to %s: %w", queue, errNotConfirmed)
+	}
+
+	return nil
+}
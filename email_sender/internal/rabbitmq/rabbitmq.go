@@ -4,23 +4,49 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	"email_sender/internal/config"
 	"email_sender/internal/metrics"
 
 	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrRequeue — handler оборачивает им временные ошибки (например, лимит
+// домена получателя), после которых сообщение надо вернуть в очередь, а не
+// отправлять в DLQ.
+var ErrRequeue = errors.New("requeue")
+
 type RabbitMQClient struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
-	metrics *metrics.Metrics
+	conn *amqp.Connection
+	// channel — служебный, в режиме publisher confirms: объявление
+	// топологии, republish и health-check. У каждого consumer'а свой канал,
+	// чтобы prefetch считался по очереди.
+	channel  *amqp.Channel
+	metrics  *metrics.Metrics
+	prefetch int
+
+	// publishMu сериализует republish в служебный канал из воркеров
+	publishMu    sync.Mutex
+	parkingQueue string
+	maxAttempts  int
+	retryDelays  []time.Duration
+
+	// inspectMu — просмотр и re-drive DLQ по одному за раз, иначе два
+	// параллельных запроса видят каждый только свою часть очереди
+	inspectMu       sync.Mutex
+	mainQueue       string
+	deadLetterQueue string
 }
 
-func New(url string, m *metrics.Metrics) (*RabbitMQClient, error) {
+func New(cfg config.RabbitMQ, m *metrics.Metrics) (*RabbitMQClient, error) {
 	const op = "rabbitmq.New"
 
-	conn, err := amqp.Dial(url)
+	conn, err := dial(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -31,46 +57,58 @@ func New(url string, m *metrics.Metrics) (*RabbitMQClient, error) {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &RabbitMQClient{
-		conn:    conn,
-		channel: ch,
-		metrics: m,
-	}, nil
-}
+	if _, err := declareQueue(ch, cfg.QueueName, cfg.Queue); err != nil {
+		ch.Close()
+		conn.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-// handler теперь возвращает error — это единственный способ узнать,
-// удалось ли обработать сообщение, и соответственно ack или nack его,
-// плюс записать это в metrics.
-func (r *RabbitMQClient) StartReading(ctx context.Context, queueName string, handler func([]byte) error) error {
-	const op = "rabbitmq.StartReading"
+	// parking-очередь без DLX и TTL: сюда попадают сообщения, которые не
+	// удалось обработать за MaxAttempts попыток, для ручного разбора
+	if _, err := ch.QueueDeclare(cfg.ParkingQueue, true, false, false, false, nil); err != nil {
+		ch.Close()
+		conn.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-	msgs, err := r.channel.Consume(
-		queueName, "", false, false, false, false, nil,
-	)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	if err := declareTopology(ch, cfg.Topology); err != nil {
+		ch.Close()
+		conn.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return nil
+	// republish ack'ает оригинал только после подтверждения брокером копии
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		conn.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-		case msg, ok := <-msgs:
-			if !ok {
-				// канал закрылся НЕ из-за ctx.Done() — это авария
-				// (разрыв соединения/канала с RabbitMQ), а не штатный shutdown
-				return fmt.Errorf("%s: channel closed unexpectedly", op)
-			}
+	return &RabbitMQClient{
+		conn:     conn,
+		channel:  ch,
+		metrics:  m,
+		prefetch: cfg.Prefetch,
 
-			r.processMessage(msg, handler)
-		}
-	}
+		parkingQueue: cfg.ParkingQueue,
+		maxAttempts:  max(cfg.MaxAttempts, 1),
+		retryDelays:  cfg.RetryDelays,
+
+		mainQueue:       cfg.QueueName,
+		deadLetterQueue: cfg.Queue.DeadLetterQueue,
+	}, nil
 }
 
-func (r *RabbitMQClient) processMessage(msg amqp.Delivery, handler func([]byte) error) {
+func (r *RabbitMQClient) processMessage(ctx context.Context, queue string, msg amqp.Delivery, handler Handler) {
 	start := time.Now()
 
+	ctx, span := otel.Tracer(tracerName).Start(
+		contextFromHeaders(ctx, msg.Headers),
+		msg.RoutingKey+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+	)
+	defer span.End()
+
 	var procErr error
 
 	func() {
@@ -79,18 +117,22 @@ func (r *RabbitMQClient) processMessage(msg amqp.Delivery, handler func([]byte)
 				procErr = fmt.Errorf("handler panicked: %v", rec)
 			}
 		}()
-		procErr = handler(msg.Body)
+		procErr = handler(ctx, msg.Body)
 	}()
 
 	duration := time.Since(start).Seconds()
 	r.metrics.MessageProcessingDuration.Observe(duration)
 
+	if msg.Redelivered {
+		r.metrics.MessagesRedeliveredTotal.Inc()
+	}
+
 	if procErr != nil {
-		r.metrics.MessagesFailedTotal.WithLabelValues(reasonLabel()).Inc()
-		// requeue=false: не гоняем письмо по кругу бесконечно при постоянной
-		// ошибке (невалидный email и т.п.) — это отдельный разговор про DLQ,
-		// пока хотя бы не теряем сообщение молча и не крутим retry storm
-		_ = msg.Nack(false, false)
+		span.RecordError(procErr)
+		span.SetStatus(codes.Error, procErr.Error())
+		r.metrics.MessagesFailedTotal.WithLabelValues(reasonLabel(procErr)).Inc()
+
+		r.handleFailure(ctx, queue, msg, procErr)
 		return
 	}
 
@@ -98,10 +140,25 @@ func (r *RabbitMQClient) processMessage(msg amqp.Delivery, handler func([]byte)
 	_ = msg.Ack(false)
 }
 
-func reasonLabel() string {
-	// пока просто "processing_error" — если появятся различимые типы ошибок
-	// (SMTP timeout vs невалидный адрес vs шаблон) — разнесём на конкретные reason
-	return "processing_error"
+func reasonLabel(err error) string {
+	switch {
+	case errors.Is(err, ErrRequeue):
+		return "requeued"
+	case errors.Is(err, ErrPermanent):
+		return "permanent"
+	default:
+		return "processing_error"
+	}
+}
+
+func (r *RabbitMQClient) Ping(context.Context) error {
+	if r.conn.IsClosed() {
+		return errors.New("amqp connection closed")
+	}
+	if r.channel.IsClosed() {
+		return errors.New("amqp channel closed")
+	}
+	return nil
 }
 
 func (r *RabbitMQClient) Close(ctx context.Context) error {
@@ -0,0 +1,46 @@
+package rabbitmq
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const tracerName = "email_sender/rabbitmq"
+
+// headerCarrier адаптирует amqp.Table к propagation.TextMapCarrier, чтобы
+// traceparent/tracestate ехали в заголовках сообщения.
+type headerCarrier amqp.Table
+
+var _ propagation.TextMapCarrier = headerCarrier(nil)
+
+func (c headerCarrier) Get(key string) string {
+	v, ok := c[key].(string)
+	if !ok {
+		return ""
+	}
+	return v
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// contextFromHeaders восстанавливает trace context, который auth_service
+// положил в заголовки при публикации.
+func contextFromHeaders(ctx context.Context, headers amqp.Table) context.Context {
+	if headers == nil {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, headerCarrier(headers))
+}
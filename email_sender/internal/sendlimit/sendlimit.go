@@ -0,0 +1,275 @@
+package sendlimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"email_sender/internal/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrDuplicate        = errors.New("sendlimit: duplicate message")
+	ErrLimitExceeded    = errors.New("sendlimit: recipient limit exceeded")
+	ErrAlreadyProcessed = errors.New("sendlimit: message already processed")
+)
+
+const (
+	dedupPrefix     = "email:dedup:"
+	limitPrefix     = "email:limit:"
+	processedPrefix = "email:processed:"
+)
+
+// Limit — не больше Max писем одного purpose на адрес за Period
+// (фиксированное окно от первого письма). Max == 0 — без лимита.
+type Limit struct {
+	Max    int
+	Period time.Duration
+}
+
+func (l Limit) Validate() error {
+	if l.Max < 0 {
+		return fmt.Errorf("sendlimit: max must be >= 0, got %d", l.Max)
+	}
+	if l.Max > 0 && l.Period <= 0 {
+		return fmt.Errorf("sendlimit: period must be > 0, got %s", l.Period)
+	}
+	return nil
+}
+
+// acquireScript атомарно проверяет повторную доставку, дубликат и счётчик
+// получателя. Ключ дедупликации ставится только для пропущенного письма,
+// поэтому письмо, отклонённое лимитом, не считается отправленным.
+//
+// KEYS[1] - ключ дедупликации
+// KEYS[2] - счётчик получателя по purpose
+// KEYS[3] - отметка об отправке письма с этим ID
+// ARGV[1] - окно дедупликации, мс (0 - выключена)
+// ARGV[2] - лимит писем (0 - без лимита)
+// ARGV[3] - окно лимита, мс
+// ARGV[4] - "1", если у письма есть ID
+//
+// Возвращает 0 - можно отправлять, 1 - дубликат, 2 - лимит исчерпан,
+// 3 - письмо с этим ID уже отправлено.
+var acquireScript = redis.NewScript(`
+	local dedup = tonumber(ARGV[1])
+	local limit = tonumber(ARGV[2])
+
+	if ARGV[4] == '1' and redis.call('EXISTS', KEYS[3]) == 1 then
+		return 3
+	end
+
+	if dedup > 0 and redis.call('EXISTS', KEYS[1]) == 1 then
+		return 1
+	end
+
+	if limit > 0 then
+		local n = redis.call('INCR', KEYS[2])
+		if n == 1 then
+			redis.call('PEXPIRE', KEYS[2], ARGV[3])
+		end
+		if n > limit then
+			return 2
+		end
+	end
+
+	if dedup > 0 then
+		redis.call('SET', KEYS[1], 1, 'PX', dedup)
+	end
+
+	return 0
+`)
+
+// releaseScript откатывает acquireScript для письма, которое не ушло:
+// повтор из retry-очереди не должен попасть под дедупликацию и второй раз
+// списать лимит. Счётчик с истёкшим окном не трогаем, иначе DECR создаст
+// ключ без TTL.
+//
+// KEYS[1] - ключ дедупликации
+// KEYS[2] - счётчик получателя по purpose
+var releaseScript = redis.NewScript(`
+	redis.call('DEL', KEYS[1])
+
+	if redis.call('EXISTS', KEYS[2]) == 1 then
+		redis.call('DECR', KEYS[2])
+	end
+
+	return 0
+`)
+
+// Limiter — лимиты писем на получателя, дедупликация одинаковых писем и
+// учёт отправленных ID.
+// Состояние в Redis, поэтому лимит общий для всех реплик email_sender —
+// в отличие от throttle, который защищает SMTP-провайдера, Limiter
+// защищает получателя от спама через формы регистрации и сброса пароля.
+type Limiter struct {
+	client       *redis.Client
+	limits       map[string]Limit // по purpose
+	dedupWindow  time.Duration
+	processedTTL time.Duration
+}
+
+// New: processedTTL — сколько помним ID отправленных писем; должен
+// перекрывать время, за которое сообщение может вернуться из retry- и
+// parking-очередей.
+func New(
+	ctx context.Context,
+	addr, pass string,
+	db int,
+	limits map[string]Limit,
+	dedupWindow, processedTTL time.Duration,
+) (*Limiter, error) {
+	const op = "sendlimit.New"
+
+	for purpose, l := range limits {
+		if err := l.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", op, purpose, err)
+		}
+	}
+
+	if dedupWindow < 0 {
+		return nil, fmt.Errorf("%s: dedup window must be >= 0, got %s", op, dedupWindow)
+	}
+
+	if processedTTL <= 0 {
+		return nil, fmt.Errorf("%s: processed ttl must be > 0, got %s", op, processedTTL)
+	}
+
+	client := redis.NewClient(
+		&redis.Options{
+			Addr:         addr,
+			Password:     pass,
+			DB:           db,
+			MaxRetries:   3,
+			DialTimeout:  5 * time.Second,
+			ReadTimeout:  3 * time.Second,
+			WriteTimeout: 3 * time.Second,
+			PoolSize:     10,
+			MinIdleConns: 2,
+		})
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Limiter{
+		client:       client,
+		limits:       limits,
+		dedupWindow:  dedupWindow,
+		processedTTL: processedTTL,
+	}, nil
+}
+
+// Acquire резервирует отправку письма: ErrAlreadyProcessed — письмо с этим
+// ID уже отправлено (брокер доставил его повторно), ErrDuplicate — такое же
+// письмо уже уходило в пределах окна дедупликации, ErrLimitExceeded —
+// получатель исчерпал лимит писем этого purpose. Если письмо так и не
+// ушло, слот нужно вернуть через Release, если ушло — отметить через Done.
+func (l *Limiter) Acquire(ctx context.Context, msg models.EmailMessage) error {
+	const op = "sendlimit.Acquire"
+
+	limit := l.limits[msg.Purpose]
+
+	res, err := acquireScript.Run(ctx, l.client,
+		l.keys(msg),
+		l.dedupWindow.Milliseconds(),
+		limit.Max,
+		limit.Period.Milliseconds(),
+		hasID(msg),
+	).Int()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch res {
+	case 1:
+		return ErrDuplicate
+	case 2:
+		return fmt.Errorf("%w: %d per %s", ErrLimitExceeded, limit.Max, limit.Period)
+	case 3:
+		return fmt.Errorf("%w: %s", ErrAlreadyProcessed, msg.ID)
+	}
+
+	return nil
+}
+
+// Done отмечает письмо с ID отправленным. Отметка ставится после отправки,
+// а не в Acquire: если процесс упадёт между ними, письмо при повторной
+// доставке уйдёт, а не потеряется.
+func (l *Limiter) Done(ctx context.Context, msg models.EmailMessage) error {
+	const op = "sendlimit.Done"
+
+	if msg.ID == "" {
+		return nil
+	}
+
+	if err := l.client.Set(ctx, processedPrefix+msg.ID, 1, l.processedTTL).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Release возвращает слот, зарезервированный Acquire.
+func (l *Limiter) Release(ctx context.Context, msg models.EmailMessage) error {
+	const op = "sendlimit.Release"
+
+	if err := releaseScript.Run(ctx, l.client, l.keys(msg)).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (l *Limiter) Close() error {
+	return l.client.Close()
+}
+
+// keys — ключ дедупликации, счётчик получателя и отметка об отправке. Адрес в ключах только в
+// виде хэша, чтобы в Redis не копились персональные данные.
+func (l *Limiter) keys(msg models.EmailMessage) []string {
+	to := strings.ToLower(strings.TrimSpace(msg.Email))
+
+	return []string{
+		dedupPrefix + digest(to, msg.Purpose, msg.Link, msg.Locale, canonicalData(msg.Data)),
+		limitPrefix + msg.Purpose + ":" + digest(to),
+		processedPrefix + msg.ID,
+	}
+}
+
+func hasID(msg models.EmailMessage) string {
+	if msg.ID == "" {
+		return "0"
+	}
+	return "1"
+}
+
+// canonicalData — Data с ключами по порядку: одинаковые письма должны
+// давать одинаковый хэш независимо от порядка полей в JSON.
+func canonicalData(data map[string]string) string {
+	var b strings.Builder
+	for _, k := range slices.Sorted(maps.Keys(data)) {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(data[k])
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func digest(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
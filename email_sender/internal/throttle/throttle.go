@@ -0,0 +1,135 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrThrottled = errors.New("throttle: recipient domain limit exceeded")
+
+// Policy — лимит на домен получателя, та же семантика, что у rate limiter
+// в auth_service: Rate писем за Period в устойчивом режиме плюс Burst
+// писем одномоментно сверх этого. Rate == 0 — без лимита.
+type Policy struct {
+	Rate   int
+	Burst  int
+	Period time.Duration
+}
+
+func (p Policy) Validate() error {
+	if p.Rate < 0 || p.Burst < 0 {
+		return fmt.Errorf("throttle: rate and burst must be >= 0, got %d/%d", p.Rate, p.Burst)
+	}
+	if p.Rate > 0 && p.Period <= 0 {
+		return fmt.Errorf("throttle: period must be > 0, got %s", p.Period)
+	}
+	return nil
+}
+
+// Throttler — in-memory GCRA по домену получателя. Состояние локально для
+// процесса: при нескольких репликах email_sender лимит домена делится на
+// число реплик в конфиге.
+type Throttler struct {
+	defaultPolicy Policy
+	domains       map[string]Policy
+	maxWait       time.Duration
+
+	mu  sync.Mutex
+	tat map[string]time.Time // theoretical arrival time по домену
+}
+
+func New(defaultPolicy Policy, domains map[string]Policy, maxWait time.Duration) (*Throttler, error) {
+	const op = "throttle.New"
+
+	if err := defaultPolicy.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: default: %w", op, err)
+	}
+
+	normalized := make(map[string]Policy, len(domains))
+	for domain, p := range domains {
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", op, domain, err)
+		}
+		normalized[strings.ToLower(domain)] = p
+	}
+
+	return &Throttler{
+		defaultPolicy: defaultPolicy,
+		domains:       normalized,
+		maxWait:       maxWait,
+		tat:           make(map[string]time.Time),
+	}, nil
+}
+
+// Wait блокирует до момента, когда письмо на домен адреса to можно
+// отправить. Пока consumer ждёт, он не берёт новые сообщения — это и есть
+// backpressure на очередь. Если ждать пришлось бы дольше maxWait, Wait
+// выжидает maxWait (чтобы requeue не превратился в busy loop) и
+// возвращает ErrThrottled без резервирования слота.
+func (t *Throttler) Wait(ctx context.Context, to string) error {
+	domain := domainOf(to)
+
+	p, ok := t.domains[domain]
+	if !ok {
+		p = t.defaultPolicy
+	}
+	if p.Rate == 0 {
+		return nil
+	}
+
+	delay, reserved := t.reserve(domain, p, time.Now())
+	if !reserved {
+		delay = t.maxWait
+	}
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if !reserved {
+		return fmt.Errorf("%w: %s", ErrThrottled, domain)
+	}
+	return nil
+}
+
+func (t *Throttler) reserve(domain string, p Policy, now time.Time) (time.Duration, bool) {
+	interval := p.Period / time.Duration(p.Rate)
+	tolerance := interval * time.Duration(p.Burst)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tat := t.tat[domain]
+	if tat.Before(now) {
+		tat = now
+	}
+
+	allowAt := tat.Add(-tolerance)
+	delay := max(allowAt.Sub(now), 0)
+
+	if delay > t.maxWait {
+		return delay, false
+	}
+
+	t.tat[domain] = tat.Add(interval)
+	return delay, true
+}
+
+func domainOf(email string) string {
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
@@ -0,0 +1,172 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	awsSecretsService = "secretsmanager"
+	awsSigningAlgo    = "AWS4-HMAC-SHA256"
+)
+
+// awsProvider вызывает GetSecretValue AWS Secrets Manager, подписывая
+// запрос SigV4 статическими ключами (или временными с session token).
+// Роли инстанса через IMDS не поддерживаются: ключи передаются env.
+type awsProvider struct {
+	endpoint string
+	host     string
+	region   string
+	secretID string
+
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+
+	client *http.Client
+}
+
+func newAWSProvider(cfg AWS, timeout time.Duration) *awsProvider {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.%s.amazonaws.com", awsSecretsService, cfg.Region)
+	}
+	endpoint = strings.TrimRight(endpoint, "/")
+
+	host := endpoint
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	return &awsProvider{
+		endpoint:        endpoint,
+		host:            host,
+		region:          cfg.Region,
+		secretID:        cfg.SecretID,
+		accessKeyID:     cfg.AccessKeyID,
+		secretAccessKey: cfg.SecretAccessKey,
+		sessionToken:    cfg.SessionToken,
+		client:          &http.Client{Timeout: timeout},
+	}
+}
+
+func (a *awsProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	const op = "secrets.awsProvider.Fetch"
+
+	payload, err := json.Marshal(map[string]string{"SecretId": a.secretID})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint+"/", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	a.sign(req, payload, time.Now().UTC())
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, res.Body)
+		return nil, fmt.Errorf("%s: unexpected status %d", op, res.StatusCode)
+	}
+
+	var body struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var data map[string]any
+	if err := json.Unmarshal([]byte(body.SecretString), &data); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, errSecretNotObject)
+	}
+
+	return stringValues(data)
+}
+
+// sign добавляет заголовки SigV4: подписываются метод, путь, content-type,
+// host, дата, target и тело запроса.
+func (a *awsProvider) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if a.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.sessionToken)
+	}
+
+	// заголовки в каноническом запросе — в нижнем регистре и по алфавиту
+	headers := [][2]string{
+		{"content-type", req.Header.Get("Content-Type")},
+		{"host", a.host},
+		{"x-amz-date", amzDate},
+	}
+	if a.sessionToken != "" {
+		headers = append(headers, [2]string{"x-amz-security-token", a.sessionToken})
+	}
+	headers = append(headers, [2]string{"x-amz-target", req.Header.Get("X-Amz-Target")})
+
+	var canonicalHeaders strings.Builder
+	names := make([]string, 0, len(headers))
+	for _, h := range headers {
+		canonicalHeaders.WriteString(h[0] + ":" + h[1] + "\n")
+		names = append(names, h[0])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(payload),
+	}, "\n")
+
+	scope := strings.Join([]string{date, a.region, awsSecretsService, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		awsSigningAlgo,
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+a.secretAccessKey), date)
+	key = hmacSHA256(key, a.region)
+	key = hmacSHA256(key, awsSecretsService)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsSigningAlgo, a.accessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
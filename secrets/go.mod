@@ -0,0 +1,3 @@
+module github.com/XdMishaXd/auth_service/secrets
+
+go 1.25.3
@@ -0,0 +1,116 @@
+// Package secrets — чтение секретов конфига из внешнего хранилища (KV v2
+// HashiCorp Vault или AWS Secrets Manager) и отслеживание их ротации.
+// Общий для auth_service и email_sender: секрет — набор пар «имя
+// env-переменной конфига → значение», какие имена известны, решает сервис.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+const (
+	ProviderVault = "vault"
+	ProviderAWS   = "aws"
+)
+
+var errSecretNotObject = errors.New("secret value must be a JSON object of strings")
+
+// Config — секция secrets конфига сервиса.
+type Config struct {
+	// Provider — vault | aws; пусто — секреты только из env и файлов.
+	Provider        string        `yaml:"provider" env:"SECRETS_PROVIDER"`
+	Timeout         time.Duration `yaml:"timeout" env:"SECRETS_TIMEOUT" env-default:"5s"`
+	RefreshInterval time.Duration `yaml:"refresh_interval" env:"SECRETS_REFRESH_INTERVAL" env-default:"5m"`
+
+	Vault Vault `yaml:"vault"`
+	AWS   AWS   `yaml:"aws"`
+}
+
+// Vault — секрет в KV v2 HashiCorp Vault: <mount>/data/<path>. Path у
+// каждого сервиса свой и задаётся в его config.yaml.
+type Vault struct {
+	Addr      string `yaml:"addr" env:"VAULT_ADDR"`
+	Token     string `yaml:"-" env:"VAULT_TOKEN"`
+	Namespace string `yaml:"namespace" env:"VAULT_NAMESPACE"`
+	Mount     string `yaml:"mount" env:"VAULT_MOUNT" env-default:"secret"`
+	Path      string `yaml:"path" env:"VAULT_SECRET_PATH"`
+}
+
+// AWS — секрет AWS Secrets Manager, SecretString которого — JSON-объект
+// со строковыми значениями. Ключи доступа берутся из стандартных
+// переменных AWS SDK.
+type AWS struct {
+	Region   string `yaml:"region" env:"AWS_REGION"`
+	SecretID string `yaml:"secret_id" env:"AWS_SECRET_ID"`
+	// Endpoint — свой адрес API (VPC endpoint, LocalStack); пусто —
+	// https://secretsmanager.<region>.amazonaws.com.
+	Endpoint string `yaml:"endpoint" env:"AWS_SECRETS_MANAGER_ENDPOINT"`
+
+	AccessKeyID     string `yaml:"-" env:"AWS_ACCESS_KEY_ID"`
+	SecretAccessKey string `yaml:"-" env:"AWS_SECRET_ACCESS_KEY"`
+	SessionToken    string `yaml:"-" env:"AWS_SESSION_TOKEN"`
+}
+
+// Provider читает секреты конфига из внешнего хранилища. Ключи — имена
+// env-переменных конфига, по ним значения и подставляются.
+type Provider interface {
+	Fetch(ctx context.Context) (map[string]string, error)
+}
+
+// New возвращает nil без ошибки, если provider не задан.
+func New(cfg Config) (Provider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case ProviderVault:
+		if cfg.Vault.Addr == "" || cfg.Vault.Token == "" || cfg.Vault.Path == "" {
+			return nil, errors.New("secrets.vault: VAULT_ADDR, VAULT_TOKEN and path are required")
+		}
+		return newVaultProvider(cfg.Vault, cfg.Timeout), nil
+	case ProviderAWS:
+		if cfg.AWS.Region == "" || cfg.AWS.SecretID == "" ||
+			cfg.AWS.AccessKeyID == "" || cfg.AWS.SecretAccessKey == "" {
+			return nil, errors.New("secrets.aws: region, secret_id, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required")
+		}
+		return newAWSProvider(cfg.AWS, cfg.Timeout), nil
+	default:
+		return nil, fmt.Errorf("secrets.provider must be one of vault, aws, got %q", cfg.Provider)
+	}
+}
+
+// Load подставляет секреты хранилища в окружение до чтения конфига.
+// Явно заданная env-переменная важнее хранилища; ключи, которых нет в
+// known, пропускаются.
+func Load(cfg Config, known []string) error {
+	provider, err := New(cfg)
+	if err != nil || provider == nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	secrets, err := provider.Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch secrets from %s: %w", cfg.Provider, err)
+	}
+
+	for name, value := range secrets {
+		if !slices.Contains(known, name) {
+			continue
+		}
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+		if err := os.Setenv(name, value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
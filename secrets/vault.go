@@ -0,0 +1,84 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// vaultProvider читает секрет из KV v2 по HTTP API Vault с токеном.
+type vaultProvider struct {
+	url       string
+	token     string
+	namespace string
+	client    *http.Client
+}
+
+func newVaultProvider(cfg Vault, timeout time.Duration) *vaultProvider {
+	return &vaultProvider{
+		url: fmt.Sprintf("%s/v1/%s/data/%s",
+			strings.TrimRight(cfg.Addr, "/"),
+			url.PathEscape(strings.Trim(cfg.Mount, "/")),
+			strings.Trim(cfg.Path, "/"),
+		),
+		token:     cfg.Token,
+		namespace: cfg.Namespace,
+		client:    &http.Client{Timeout: timeout},
+	}
+}
+
+func (v *vaultProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	const op = "secrets.vaultProvider.Fetch"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+
+	res, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		// тело ответа Vault может содержать путь и политики — не логируем
+		_, _ = io.Copy(io.Discard, res.Body)
+		return nil, fmt.Errorf("%s: unexpected status %d", op, res.StatusCode)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return stringValues(body.Data.Data)
+}
+
+// stringValues требует строковые значения: числа и вложенные объекты в
+// секрете — почти всегда ошибка заполнения, а не то, что ждёт конфиг.
+func stringValues(data map[string]any) (map[string]string, error) {
+	secrets := make(map[string]string, len(data))
+	for name, value := range data {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", errSecretNotObject, name)
+		}
+		secrets[name] = s
+	}
+
+	return secrets, nil
+}
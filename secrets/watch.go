@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Watch перечитывает секреты каждые interval, пока не отменён ctx, и
+// передаёт в onChange только изменившиеся значения. Сравнивать есть с чем
+// только после удачного чтения: первое из них запоминается как исходное —
+// то, что было при старте, уже применено при загрузке конфига. Пока
+// исходного чтения нет, ротация не сообщается: иначе каждый ключ выглядел
+// бы изменённым. Ошибка чтения логируется, прежние значения остаются в силе.
+func Watch(
+	ctx context.Context,
+	log *slog.Logger,
+	provider Provider,
+	interval time.Duration,
+	timeout time.Duration,
+	onChange func(changed map[string]string),
+) {
+	const op = "secrets.Watch"
+
+	log = log.With(slog.String("op", op))
+
+	fetch := func() (map[string]string, error) {
+		fetchCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		return provider.Fetch(fetchCtx)
+	}
+
+	current, err := fetch()
+	if err != nil {
+		log.Error("failed to fetch initial secrets, rotation is not tracked until a successful fetch",
+			slog.String("err", err.Error()),
+		)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		secrets, err := fetch()
+		if err != nil {
+			log.Error("failed to refresh secrets", slog.String("err", err.Error()))
+			continue
+		}
+
+		if current == nil {
+			current = secrets
+			continue
+		}
+
+		changed := make(map[string]string)
+		for name, value := range secrets {
+			if old, ok := current[name]; !ok || old != value {
+				changed[name] = value
+			}
+		}
+
+		current = secrets
+		if len(changed) == 0 {
+			continue
+		}
+
+		log.Info("secrets rotated", slog.Any("names", slices.Sorted(maps.Keys(changed))))
+		onChange(changed)
+	}
+}
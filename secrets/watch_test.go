@@ -0,0 +1,93 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedProvider отдаёт ответы по очереди, последний — повторяет.
+type scriptedProvider struct {
+	mu      sync.Mutex
+	results []fetchResult
+}
+
+type fetchResult struct {
+	secrets map[string]string
+	err     error
+}
+
+func (p *scriptedProvider) Fetch(context.Context) (map[string]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	res := p.results[0]
+	if len(p.results) > 1 {
+		p.results = p.results[1:]
+	}
+
+	return res.secrets, res.err
+}
+
+func watch(t *testing.T, provider Provider) <-chan map[string]string {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	changes := make(chan map[string]string, 10)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		Watch(ctx, slog.New(slog.NewTextHandler(io.Discard, nil)), provider, time.Millisecond, time.Second,
+			func(changed map[string]string) { changes <- changed })
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	return changes
+}
+
+func expectChange(t *testing.T, changes <-chan map[string]string, want map[string]string) {
+	t.Helper()
+
+	select {
+	case got := <-changes:
+		if !maps.Equal(got, want) {
+			t.Fatalf("changed = %v, want %v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no change reported, want %v", want)
+	}
+}
+
+func TestWatchReportsOnlyChangedValues(t *testing.T) {
+	changes := watch(t, &scriptedProvider{results: []fetchResult{
+		{secrets: map[string]string{"A": "1", "B": "1"}},
+		{secrets: map[string]string{"A": "1", "B": "1"}},
+		{err: errors.New("vault is down")},
+		{secrets: map[string]string{"A": "1", "B": "2"}},
+	}})
+
+	expectChange(t, changes, map[string]string{"B": "2"})
+}
+
+// Неудачное первое чтение не делает все ключи «изменёнными»: исходными
+// становятся значения первого удачного чтения.
+func TestWatchWaitsForBaselineAfterFailedFirstFetch(t *testing.T) {
+	changes := watch(t, &scriptedProvider{results: []fetchResult{
+		{err: errors.New("vault is down")},
+		{err: errors.New("vault is down")},
+		{secrets: map[string]string{"A": "1", "B": "1"}},
+		{secrets: map[string]string{"A": "2", "B": "1"}},
+	}})
+
+	expectChange(t, changes, map[string]string{"A": "2"})
+}